@@ -32,10 +32,17 @@ import (
 	"github.com/eclipse-che/che-operator/pkg/apis"
 	"github.com/eclipse-che/che-operator/pkg/controller"
 	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/metrics"
+	"github.com/eclipse-che/che-operator/pkg/webhook"
 	"github.com/operator-framework/operator-sdk/pkg/leader"
 	"github.com/operator-framework/operator-sdk/pkg/ready"
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -89,6 +96,11 @@ func printVersion() {
 }
 
 func main() {
+	// `che-operator render` prints the objects of Che installation without contacting a cluster
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		os.Exit(render(os.Args[2:]))
+	}
+
 	flag.Parse()
 	deploy.InitDefaults(defaultsPath)
 	printVersion()
@@ -120,6 +132,9 @@ func main() {
 	options := manager.Options{
 		Namespace:              namespace,
 		HealthProbeBindAddress: ":6789",
+		MetricsBindAddress:     fmt.Sprintf(":%d", metrics.Port),
+		Port:                   webhook.Port,
+		CertDir:                webhook.CertDir,
 	}
 
 	mgr, err := manager.New(cfg, options)
@@ -156,18 +171,41 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := apiextensionsv1beta1.AddToScheme(mgr.GetScheme()); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
 	// Setup all Controllers
 	if err := controller.AddToManager(mgr); err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
 
+	// Setup all Webhooks
+	if err := webhook.AddToManager(mgr); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	// Point the conversion webhook of CheCluster to the Operator
+	if webhook.IsCertificateProvided() {
+		if err := configureConversionWebhook(cfg, mgr.GetScheme()); err != nil {
+			logrus.Errorf("Failed to configure the conversion webhook of CheCluster: %v", err)
+		}
+	}
+
 	// Setup health checks
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		log.Error(err, "Unable to set up health check")
 		os.Exit(1)
 	}
 
+	// Expose the metrics of the Operator
+	if err := syncMetricsService(cfg, mgr.GetScheme()); err != nil {
+		logrus.Errorf("Failed to expose the metrics of the Operator: %v", err)
+	}
+
 	logrus.Info("Starting the Cmd")
 
 	// Start the Cmd
@@ -178,3 +216,49 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// configureConversionWebhook points the conversion webhook of the CheCluster CRD to the namespace of the Operator.
+// The cache of the manager isn't started yet, so the API server is queried directly.
+func configureConversionWebhook(cfg *rest.Config, scheme *apiruntime.Scheme) error {
+	namespace, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		if err == k8sutil.ErrNoNamespace || err == k8sutil.ErrRunLocal {
+			logrus.Info("Operator is not running in a cluster, skipping the conversion webhook configuration")
+			return nil
+		}
+		return err
+	}
+
+	cl, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	return webhook.ConfigureConversion(cl, namespace)
+}
+
+// syncMetricsService creates the service and the ServiceMonitor of the metrics of the Operator.
+// The cache of the manager isn't started yet, so the API server is queried directly.
+func syncMetricsService(cfg *rest.Config, scheme *apiruntime.Scheme) error {
+	namespace, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		if err == k8sutil.ErrNoNamespace || err == k8sutil.ErrRunLocal {
+			logrus.Info("Operator is not running in a cluster, skipping the metrics service")
+			return nil
+		}
+		return err
+	}
+	operatorName, err := k8sutil.GetOperatorName()
+	if err != nil {
+		return err
+	}
+
+	cl, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return metrics.SyncMetricsService(cl, discoveryClient, namespace, operatorName)
+}
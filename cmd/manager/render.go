@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/controller/che"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const renderUsage = `Usage: che-operator render --cr <CheCluster YAML> --defaults-path <Operator deployment YAML> [--platform kubernetes|openshift3|openshift4]
+
+Prints the objects the Operator would create for the CheCluster, without contacting a cluster.
+The values of the secrets, and the generated passwords and certificates, are redacted.
+`
+
+// render runs the `render` subcommand with the given arguments and returns the exit code.
+func render(args []string) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, renderUsage)
+		flags.PrintDefaults()
+	}
+	crPath := flags.String("cr", "", "Path to the CheCluster custom resource YAML.")
+	renderDefaultsPath := flags.String("defaults-path", "", "Path to the Operator deployment YAML with the defaults, for instance deploy/operator.yaml.")
+	platform := flags.String("platform", "kubernetes", "Platform to render the objects for: kubernetes, openshift3 or openshift4.")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *crPath == "" || *renderDefaultsPath == "" {
+		flags.Usage()
+		return 2
+	}
+
+	isOpenShift, isOpenShift4 := false, false
+	switch *platform {
+	case "kubernetes":
+	case "openshift3":
+		isOpenShift = true
+	case "openshift4":
+		isOpenShift, isOpenShift4 = true, true
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown platform '%s', expected kubernetes, openshift3 or openshift4\n", *platform)
+		return 2
+	}
+
+	// Only the problems are logged, the objects are printed to the standard output
+	if _, isFound := os.LookupEnv("LOG_LEVEL"); isFound {
+		setLogLevel()
+	} else {
+		logrus.SetLevel(logrus.WarnLevel)
+	}
+
+	data, err := ioutil.ReadFile(*crPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read the CheCluster: %v\n", err)
+		return 1
+	}
+	cheCluster := &orgv1.CheCluster{}
+	if err := yaml.Unmarshal(data, cheCluster); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse the CheCluster: %v\n", err)
+		return 1
+	}
+	if cheCluster.APIVersion != orgv1.SchemeGroupVersion.String() || cheCluster.Kind != "CheCluster" {
+		fmt.Fprintf(os.Stderr, "Expected a CheCluster of '%s' API version, but got '%s' of '%s' API version\n", orgv1.SchemeGroupVersion.String(), cheCluster.Kind, cheCluster.APIVersion)
+		return 1
+	}
+
+	if err := setEnvFromDefaults(*renderDefaultsPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read the defaults: %v\n", err)
+		return 1
+	}
+	deploy.InitDefaults(*renderDefaultsPath)
+	objects, renderErrors, err := che.Render(cheCluster, isOpenShift, isOpenShift4)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to render the objects: %v\n", err)
+		return 1
+	}
+	for _, renderError := range renderErrors {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", renderError)
+	}
+
+	manifests, err := che.MarshalRenderedObjects(objects)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to serialize the objects: %v\n", err)
+		return 1
+	}
+	os.Stdout.Write(manifests)
+	return 0
+}
+
+// setEnvFromDefaults sets the environment variables of the Operator deployment, as some of the defaults,
+// like the Che flavor, are read from the environment of the Operator. The variables which are set already are kept.
+func setEnvFromDefaults(defaultsPath string) error {
+	data, err := ioutil.ReadFile(defaultsPath)
+	if err != nil {
+		return err
+	}
+	operator := &appsv1.Deployment{}
+	if err := yaml.Unmarshal(data, operator); err != nil {
+		return err
+	}
+	if len(operator.Spec.Template.Spec.Containers) == 0 {
+		return fmt.Errorf("no container found in the Operator deployment '%s'", defaultsPath)
+	}
+
+	for _, env := range operator.Spec.Template.Spec.Containers[0].Env {
+		if _, isFound := os.LookupEnv(env.Name); !isFound && env.ValueFrom == nil {
+			os.Setenv(env.Name, env.Value)
+		}
+	}
+	return nil
+}
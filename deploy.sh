@@ -22,7 +22,11 @@ oc apply -f ${BASE_DIR}/deploy/role.yaml -n $NAMESPACE
 oc apply -f ${BASE_DIR}/deploy/role_binding.yaml -n $NAMESPACE
 oc apply -f ${BASE_DIR}/deploy/namespaces_cluster_role.yaml -n $NAMESPACE
 oc apply -f ${BASE_DIR}/deploy/namespaces_cluster_role_binding.yaml -n $NAMESPACE
-oc apply -f ${BASE_DIR}/deploy/crds/org_v1_che_crd.yaml -n $NAMESPACE
+# the conversion webhook of CheCluster is served by the operator in the namespace
+sed -e "s/namespace: che$/namespace: ${NAMESPACE}/" ${BASE_DIR}/deploy/crds/org_v1_che_crd.yaml | oc apply -f -
+oc apply -f ${BASE_DIR}/deploy/crds/org_v1_chebackup_crd.yaml -n $NAMESPACE
+oc apply -f ${BASE_DIR}/deploy/crds/org_v1_cherestore_crd.yaml -n $NAMESPACE
+oc apply -f ${BASE_DIR}/deploy/crds/org_v1_chebackupschedule_crd.yaml -n $NAMESPACE
 # sometimes the operator cannot get CRD right away
 sleep 2
 
@@ -32,4 +36,5 @@ sleep 2
 #oc apply -f ${BASE_DIR}/deploy/cluster_role_binding.yaml -n $NAMESPACE
 
 oc apply -f ${BASE_DIR}/deploy/operator.yaml -n $NAMESPACE
+oc apply -f ${BASE_DIR}/deploy/service.yaml -n $NAMESPACE
 oc apply -f ${BASE_DIR}/deploy/crds/org_v1_che_cr.yaml -n $NAMESPACE
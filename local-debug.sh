@@ -47,11 +47,6 @@ while [[ "$#" -gt 0 ]]; do
 done
 
 prepareTemplates() {
-  cp templates/keycloak-provision.sh /tmp/keycloak-provision.sh
-  cp templates/delete-identity-provider.sh /tmp/delete-identity-provider.sh
-  cp templates/create-github-identity-provider.sh /tmp/create-github-identity-provider.sh
-  cp templates/oauth-provision.sh /tmp/oauth-provision.sh
-
   # Download Dev Workspace operator templates
   echo "[INFO] Downloading Dev Workspace operator templates ..."
   rm -f /tmp/devworkspace-operator.zip
@@ -87,6 +82,9 @@ createNamespace() {
 
 applyCRandCRD() {
   kubectl apply -f ${ECLIPSE_CHE_CRD}
+  kubectl apply -f ./deploy/crds/org_v1_chebackup_crd.yaml
+  kubectl apply -f ./deploy/crds/org_v1_cherestore_crd.yaml
+  kubectl apply -f ./deploy/crds/org_v1_chebackupschedule_crd.yaml
   kubectl apply -f ${ECLIPSE_CHE_CR} -n $ECLIPSE_CHE_NAMESPACE
 }
 
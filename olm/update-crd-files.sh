@@ -90,4 +90,8 @@ updateFiles
 rm "$BASE_DIR/../deploy/crds/org_v1_che_crd.yaml"
 removeRequired "$BASE_DIR/../deploy/crds/org.eclipse.che_checlusters_crd.yaml" "$BASE_DIR/../deploy/crds/org_v1_che_crd.yaml"
 rm "$BASE_DIR/../deploy/crds/org.eclipse.che_checlusters_crd.yaml"
+# CheCluster versions are converted by the operator, the namespace of the webhook service is set by the operator on start,
+# and the CA is injected by the OpenShift service CA operator, or by the operator when OLM or cert-manager issue the certificate
+yq -riSY '.metadata.annotations["service.beta.openshift.io/inject-cabundle"] = "true"' "$BASE_DIR/../deploy/crds/org_v1_che_crd.yaml"
+yq -riSY '.spec.conversion = {"conversionReviewVersions": ["v1beta1"], "strategy": "Webhook", "webhookClientConfig": {"service": {"name": "che-operator-service", "namespace": "che", "path": "/convert"}}}' "$BASE_DIR/../deploy/crds/org_v1_che_crd.yaml"
 addLicenseHeader "$BASE_DIR/../deploy/crds/org_v1_che_crd.yaml" "#"
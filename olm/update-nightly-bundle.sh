@@ -103,6 +103,13 @@ do
   --deploy-dir "${generateFolder}" \
   --output-dir "${NIGHTLY_BUNDLE_PATH}" 2>&1 | sed -e 's/^/      /'
 
+  # operator-sdk doesn't generate the admission webhook definitions
+  yq -riSY --argjson webhookDefinitions "$(yq '.' "${BASE_DIR}/webhookdefinitions.yaml")" '.spec.webhookdefinitions = $webhookDefinitions' "${NEW_CSV}"
+
+  # OLM mounts the webhook server certificate it issues
+  yq -riSY  'del(.spec.install.spec.deployments[].spec.template.spec.volumes[] | select(.name == "webhook-server-cert"))' "${NEW_CSV}"
+  yq -riSY  'del(.spec.install.spec.deployments[].spec.template.spec.containers[].volumeMounts[] | select(.name == "webhook-server-cert"))' "${NEW_CSV}"
+
   containerImage=$(sed -n 's|^ *image: *\([^ ]*/che-operator:[^ ]*\) *|\1|p' ${NEW_CSV})
   echo "[INFO] Updating new package version fields:"
   echo "[INFO]        - containerImage => ${containerImage}"
@@ -124,11 +131,19 @@ do
   platformCRD="${NIGHTLY_BUNDLE_PATH}/manifests/org_v1_che_crd.yaml"
 
   cp -rf $templateCRD $platformCRD
+  # OLM issues the webhook server certificate and the operator sets its CA in the conversion webhook of the CRD on start,
+  # OLM conversion webhook definitions would require the operator to be installed in all namespaces.
+  # The only annotation of the CRD asks the OpenShift service CA operator to inject its CA, which would overwrite the OLM one
+  yq -riSY  'del(.metadata.annotations)' $platformCRD
   if [[ $platform == "openshift" ]]; then
     yq -riSY  '.spec.preserveUnknownFields = false' $platformCRD
-    yq -riSY  '.spec.validation.openAPIV3Schema.type = "object"' $platformCRD
-    eval head -10 $templateCRD | cat - ${platformCRD} > tmp.crd && mv tmp.crd ${platformCRD}
+    yq -riSY  '.spec.versions[].schema.openAPIV3Schema.type = "object"' $platformCRD
   fi
+  eval head -10 $templateCRD | cat - ${platformCRD} > tmp.crd && mv tmp.crd ${platformCRD}
+
+  for crd in chebackup cherestore chebackupschedule; do
+    cp -rf "${ROOT_PROJECT_DIR}/deploy/crds/org_v1_${crd}_crd.yaml" "${NIGHTLY_BUNDLE_PATH}/manifests/"
+  done
 
   echo "Done for ${platform}"
 
@@ -137,19 +152,6 @@ do
     sed -ri "s/(.*:\s?)${RELEASE}([^-])?$/\1${TAG}\2/" "${NEW_CSV}"
   fi
 
-  if [[ $platform == "openshift" ]]; then
-    # Removes che-tls-secret-creator
-    index=0
-    while [[ $index -le 30 ]]
-    do
-      if [[ $(cat ${NEW_CSV} | yq -r '.spec.install.spec.deployments[0].spec.template.spec.containers[0].env['$index'].name') == "RELATED_IMAGE_che_tls_secrets_creation_job" ]]; then
-        yq -rYSi 'del(.spec.install.spec.deployments[0].spec.template.spec.containers[0].env['$index'])' ${NEW_CSV}
-        break
-      fi
-      index=$((index+1))
-    done
-  fi
-
   # Fix sample
   if [ "${platform}" == "openshift" ]; then
     echo "[INFO] Fix openshift sample"
@@ -97,6 +97,12 @@ type CheClusterSpecServer struct {
 	// See the `cheHost` field.
 	// +optional
 	CheHostTLSSecret string `json:"cheHostTLSSecret,omitempty"`
+	// Reference to a cert-manager `Issuer` or `ClusterIssuer` which issues the certificates of the Che endpoints:
+	// the Che server, the Identity Provider, the registries and the gateway.
+	// When set, the Operator creates cert-manager `Certificate` objects and uses the resulting secrets to secure the ingresses or routes.
+	// It is ignored when cert-manager is not installed in the cluster.
+	// +optional
+	CertManagerIssuer *CertManagerIssuer `json:"certManagerIssuer,omitempty"`
 	// Log level for the Che server: `INFO` or `DEBUG`. Defaults to `INFO`.
 	// +optional
 	CheLogLevel string `json:"cheLogLevel,omitempty"`
@@ -243,6 +249,7 @@ type CheClusterSpecServer struct {
 	ProxyUser string `json:"proxyUser,omitempty"`
 	// Password of the proxy server.
 	// Only use when proxy configuration is required. See the `proxyURL`, `proxyUser` and `proxySecret` fields.
+	// Deprecated in favor of `proxySecret`, the Operator moves the value into a secret and empties the field.
 	// +optional
 	ProxyPassword string `json:"proxyPassword,omitempty"`
 	// The secret that contains `user` and `password` for a proxy server. When the secret is defined, the `proxyUser` and `proxyPassword` are ignored.
@@ -279,6 +286,11 @@ type CheClusterSpecServer struct {
 	// The labels that need to be present in the ConfigMaps representing the gateway configuration.
 	// +optional
 	SingleHostGatewayConfigMapLabels labels.Set `json:"singleHostGatewayConfigMapLabels,omitempty"`
+	// Authentication of the requests at the edge of the gateway in the single host mode, by an OAuth2 proxy
+	// deployed along with the gateway. The dashboard, the registries and the other components exposed by the gateway
+	// are only reachable by authenticated users. Che server and the Identity Provider authenticate requests themselves.
+	// +optional
+	SingleHostGatewayAuthentication SingleHostGatewayAuthentication `json:"singleHostGatewayAuthentication,omitempty"`
 	// The Che server ingress custom settings.
 	// +optional
 	CheServerIngress IngressCustomSettings `json:"cheServerIngress,omitempty"`
@@ -308,6 +320,7 @@ type CheClusterSpecDB struct {
 	// +optional
 	ChePostgresUser string `json:"chePostgresUser,omitempty"`
 	// PostgreSQL password that the Che server uses to connect to the DB. When omitted or left blank, it will be set to an automatically generated value.
+	// Deprecated in favor of `chePostgresSecret`, the Operator moves the value into a secret and empties the field.
 	// +optional
 	ChePostgresPassword string `json:"chePostgresPassword,omitempty"`
 	// PostgreSQL database name that the Che server uses to connect to the DB. Defaults to `dbche`.
@@ -360,6 +373,7 @@ type CheClusterSpecAuth struct {
 	// Overrides the password of Keycloak administrator user.
 	// Override this when an external Identity Provider is in use. See the `externalIdentityProvider` field.
 	// When omitted or left blank, it is set to an auto-generated password.
+	// Deprecated in favor of `identityProviderSecret`, the Operator moves the value into a secret and empties the field.
 	// +optional
 	IdentityProviderPassword string `json:"identityProviderPassword,omitempty"`
 	// The secret that contains `user` and `password` for Identity Provider.
@@ -380,9 +394,24 @@ type CheClusterSpecAuth struct {
 	// When omitted or left blank, it is set to the value of the `flavour` field suffixed with `-public`.
 	// +optional
 	IdentityProviderClientId string `json:"identityProviderClientId,omitempty"`
+	// Declarative configuration of the Identity Provider, Keycloak or RH-SSO, realm and the Che client.
+	// The Operator continuously reconciles the configuration into the realm, reverting manual changes of the managed attributes,
+	// and reports the drift in the `identityProviderRealm` status field. Ignored when an external Identity Provider is in use.
+	// +optional
+	IdentityProviderRealmConfig *IdentityProviderRealmConfig `json:"identityProviderRealmConfig,omitempty"`
+	// LDAP, or Active Directory, user federation providers of the Identity Provider, Keycloak or RH-SSO, realm.
+	// The Operator provisions the providers and keeps them in sync with the configuration. Ignored when an external Identity Provider is in use.
+	// +optional
+	LdapUserFederation []LdapUserFederation `json:"ldapUserFederation,omitempty"`
+	// Generic OpenID Connect provider, such as Dex, Okta or Azure AD, which authenticates Che users instead of Keycloak.
+	// When set, the Operator deploys neither Keycloak nor its database, validates the discovery document of the issuer,
+	// and configures Che server to use the provider. Can't be combined with `externalIdentityProvider`.
+	// +optional
+	OpenIdConnect *OpenIdConnectProvider `json:"openIdConnect,omitempty"`
 	// Password for a Identity Provider, Keycloak or RH-SSO, to connect to the database.
 	// Override this when an external Identity Provider is in use. See the `externalIdentityProvider` field.
 	// When omitted or left blank, it is set to an auto-generated password.
+	// Deprecated in favor of `identityProviderPostgresSecret`, the Operator moves the value into a secret and empties the field.
 	// +optional
 	IdentityProviderPostgresPassword string `json:"identityProviderPostgresPassword,omitempty"`
 	// The secret that contains `password` for the Identity Provider, Keycloak or RH-SSO, to connect to the database.
@@ -425,6 +454,134 @@ type CheClusterSpecAuth struct {
 	IdentityProviderContainerResources ResourcesCustomSettings `json:"identityProviderContainerResources,omitempty"`
 }
 
+// IdentityProviderRealmConfig is the declarative configuration of the Identity Provider realm and the Che client.
+type IdentityProviderRealmConfig struct {
+	// Maximum time, in seconds, before an access token expires.
+	// +optional
+	AccessTokenLifespan *int32 `json:"accessTokenLifespan,omitempty"`
+	// Time, in seconds, a login session is allowed to be idle before it expires.
+	// +optional
+	SsoSessionIdleTimeout *int32 `json:"ssoSessionIdleTimeout,omitempty"`
+	// Maximum time, in seconds, before a login session expires.
+	// +optional
+	SsoSessionMaxLifespan *int32 `json:"ssoSessionMaxLifespan,omitempty"`
+	// Password policy of the realm in the Keycloak format, for example `length(8) and digits(1)`.
+	// +optional
+	PasswordPolicy string `json:"passwordPolicy,omitempty"`
+	// Name of a ConfigMap, in the namespace of the `CheCluster`, with a partial realm representation in JSON format
+	// under the `realm.json` key, and a partial Che client representation under the `client.json` key.
+	// The attributes set by the other fields take precedence over the ones from the ConfigMap.
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+// LdapUserFederation is an LDAP user federation provider of the Identity Provider realm.
+type LdapUserFederation struct {
+	// Name of the provider in the realm.
+	Name string `json:"name"`
+	// Vendor of the LDAP server: `ad` for Active Directory, `rhds` for Red Hat Directory Server, or `other`. Defaults to `other`.
+	// +optional
+	Vendor string `json:"vendor,omitempty"`
+	// URL of the LDAP server, for example `ldaps://ad.example.com:636`.
+	ConnectionURL string `json:"connectionURL"`
+	// The secret that contains `user`, the DN of the LDAP user used by the Identity Provider to bind, and its `password`.
+	// When omitted, the Identity Provider connects to the LDAP server anonymously.
+	// +optional
+	BindCredentialsSecret string `json:"bindCredentialsSecret,omitempty"`
+	// Full DN of the LDAP tree where the users are, for example `ou=users,dc=example,dc=com`.
+	UsersDN string `json:"usersDN"`
+	// LDAP attribute mapped to the username. Defaults to `sAMAccountName` for Active Directory, and to `uid` otherwise.
+	// +optional
+	UsernameLDAPAttribute string `json:"usernameLDAPAttribute,omitempty"`
+	// Comma separated list of the object classes of the LDAP users.
+	// Defaults to `person, organizationalPerson, user` for Active Directory, and to `inetOrgPerson, organizationalPerson` otherwise.
+	// +optional
+	UserObjectClasses string `json:"userObjectClasses,omitempty"`
+	// Additional LDAP filter of the users, for example `(memberOf=cn=che-users,ou=groups,dc=example,dc=com)`.
+	// +optional
+	UserSearchFilter string `json:"userSearchFilter,omitempty"`
+	// Period, in seconds, of the synchronization of all the LDAP users. Disabled when omitted.
+	// +optional
+	FullSyncPeriod *int32 `json:"fullSyncPeriod,omitempty"`
+	// Period, in seconds, of the synchronization of the LDAP users changed since the last synchronization. Disabled when omitted.
+	// +optional
+	ChangedSyncPeriod *int32 `json:"changedSyncPeriod,omitempty"`
+	// Name of a ConfigMap with the CA certificates of the LDAP server. The certificates are merged into the bundle
+	// of additional CA certificates trusted by Che components, the same way as the `serverTrustStoreConfigMapName` ones.
+	// +optional
+	CACertsConfigMap string `json:"caCertsConfigMap,omitempty"`
+	// Mappers of LDAP groups to the groups of the realm.
+	// +optional
+	GroupMappers []LdapGroupMapper `json:"groupMappers,omitempty"`
+}
+
+// LdapGroupMapper maps LDAP groups to the groups of the Identity Provider realm.
+type LdapGroupMapper struct {
+	// Name of the mapper.
+	Name string `json:"name"`
+	// Full DN of the LDAP tree where the groups are, for example `ou=groups,dc=example,dc=com`.
+	GroupsDN string `json:"groupsDN"`
+	// LDAP attribute mapped to the group name. Defaults to `cn`.
+	// +optional
+	GroupNameLDAPAttribute string `json:"groupNameLDAPAttribute,omitempty"`
+	// Comma separated list of the object classes of the LDAP groups.
+	// Defaults to `group` for Active Directory, and to `groupOfNames` otherwise.
+	// +optional
+	GroupObjectClasses string `json:"groupObjectClasses,omitempty"`
+	// LDAP attribute of the group with its members. Defaults to `member`.
+	// +optional
+	MembershipLDAPAttribute string `json:"membershipLDAPAttribute,omitempty"`
+	// Additional LDAP filter of the groups.
+	// +optional
+	GroupsFilter string `json:"groupsFilter,omitempty"`
+}
+
+// OpenIdConnectProvider is a generic OpenID Connect provider which authenticates Che users.
+type OpenIdConnectProvider struct {
+	// URL of the issuer, for example `https://dex.example.com`.
+	// The discovery document of the provider must be served at `<issuerURL>/.well-known/openid-configuration`.
+	IssuerURL string `json:"issuerURL"`
+	// Id of the public client registered in the provider for Che.
+	// The redirect URIs of the client must include `https://<cheHost>/*`.
+	ClientId string `json:"clientId"`
+	// Claim of the ID token which holds the name of the Che user. Defaults to `preferred_username`.
+	// +optional
+	UsernameClaim string `json:"usernameClaim,omitempty"`
+}
+
+// CertManagerIssuer references the cert-manager issuer of the Che endpoints certificates.
+type CertManagerIssuer struct {
+	// Name of the issuer.
+	Name string `json:"name"`
+	// Kind of the issuer: `Issuer` or `ClusterIssuer`. Defaults to `Issuer`, which must be in the namespace of the Che installation.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// API group of the issuer. Defaults to `cert-manager.io`.
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// SingleHostGatewayAuthentication configures the OAuth2 proxy which authenticates the requests routed by the gateway.
+type SingleHostGatewayAuthentication struct {
+	// Enables the OAuth2 proxy in the gateway.
+	// +optional
+	Enable bool `json:"enable"`
+	// The image of the OAuth2 proxy. Omit it or leave it empty to use the default container image provided by the Operator.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// URL of the OpenID Connect issuer which authenticates users. Defaults to the issuer of the `auth.openIdConnect` provider,
+	// or to the Che realm of the Identity Provider.
+	// +optional
+	IssuerURL string `json:"issuerURL,omitempty"`
+	// Name of the secret with the `client-id` and `client-secret` of the confidential client registered for the proxy in the issuer.
+	// The redirect URI of the client is `https://<cheHost>/oauth2/callback`.
+	// +optional
+	ClientSecretName string `json:"clientSecretName,omitempty"`
+	// Lifetime of the session cookie, for example `12h`. Defaults to `168h`.
+	// +optional
+	CookieExpire string `json:"cookieExpire,omitempty"`
+}
+
 // Ingress custom settings, can be extended in the future
 type IngressCustomSettings struct {
 	// Comma separated list of labels that can be used to organize and categorize objects by scoping and selecting.
@@ -502,7 +659,8 @@ type CheClusterSpecK8SOnly struct {
 	// +optional
 	IngressStrategy string `json:"ingressStrategy,omitempty"`
 	// Ingress class that will define the which controller will manage ingresses. Defaults to `nginx`.
-	// NB: This drives the `kubernetes.io/ingress.class` annotation on Che-related ingresses.
+	// NB: This drives the `kubernetes.io/ingress.class` annotation on Che-related ingresses,
+	// or their `ingressClassName` when the cluster serves the `networking.k8s.io/v1` Ingress API.
 	// +optional
 	IngressClass string `json:"ingressClass,omitempty"`
 	// Name of a secret that will be used to setup ingress TLS termination when TLS is enabled.
@@ -521,6 +679,24 @@ type CheClusterSpecK8SOnly struct {
 	// All the endpoints whether backed by the ingress or gateway `route` always point to the subpaths on the same domain. Defaults to `native`.
 	// +optional
 	SingleHostExposureType string `json:"singleHostExposureType,omitempty"`
+	// Gateway API `Gateway` the Che endpoints are attached to. When set, the Operator exposes the endpoints
+	// with `HTTPRoute` objects instead of ingresses, and TLS is terminated by the listeners of the Gateway.
+	// It is ignored when the Gateway API is not installed in the cluster.
+	// +optional
+	GatewayAPIParentRef *GatewayAPIParentRef `json:"gatewayAPIParentRef,omitempty"`
+}
+
+// GatewayAPIParentRef references the Gateway API `Gateway` which routes the traffic to the Che endpoints.
+type GatewayAPIParentRef struct {
+	// Name of the Gateway.
+	Name string `json:"name"`
+	// Namespace of the Gateway. Defaults to the namespace of the Che installation.
+	// The Gateway must allow the routes from the namespace of the Che installation to be attached to it.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the listener of the Gateway the routes are attached to. Defaults to all the listeners.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
 }
 
 type CheClusterSpecMetrics struct {
@@ -576,6 +752,17 @@ type CheClusterStatus struct {
 	// Indicates whether an Identity Provider instance, Keycloak or RH-SSO, has been configured to integrate with the OpenShift OAuth.
 	// +optional
 	OpenShiftoAuthProvisioned bool `json:"openShiftoAuthProvisioned"`
+	// Name of the OpenShift `OAuthClient` generated by the Operator when the `oAuthClientName` field is not set.
+	// +optional
+	OpenShiftOAuthClientName string `json:"openShiftOAuthClientName,omitempty"`
+	// Indicates whether the OpenShift OAuth has been enabled by the Operator when the `openShiftoAuth` field is not set.
+	// The value is detected once, depending on the identity providers configured in the cluster.
+	// +optional
+	OpenShiftoAuthAutoDetected *bool `json:"openShiftoAuthAutoDetected,omitempty"`
+	// Indicates whether the values written to the spec by former versions of the Operator, which are now kept
+	// in memory or in the status, have been removed from the spec. They are removed only once.
+	// +optional
+	LegacySpecValuesRemoved bool `json:"legacySpecValuesRemoved,omitempty"`
 	// Indicates whether an Identity Provider instance, Keycloak or RH-SSO, has been configured to integrate with the GitHub OAuth.
 	// +optional
 	GitHubOAuthProvisioned bool `json:"gitHubOAuthProvisioned"`
@@ -633,6 +820,193 @@ type CheClusterStatus struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Help link"
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:org.w3:link"
 	HelpLink string `json:"helpLink,omitempty"`
+	// The latest available observations of the Che installation state: `Ready`, `Progressing`, `Degraded`
+	// and the readiness of every Che component, such as `PostgresReady` or `CheServerReady`.
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Conditions"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []CheClusterCondition `json:"conditions,omitempty"`
+	// The generation of the `CheCluster` custom resource that has been successfully reconciled by the Operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The last time the Operator rotated the credentials of PostgreSQL and Identity Provider, Keycloak or RH-SSO, accounts.
+	// +optional
+	LastCredentialsRotationTime *metav1.Time `json:"lastCredentialsRotationTime,omitempty"`
+	// Major version of the PostgreSQL data stored in the persistent volume, for example `9.6` or `13`.
+	// +optional
+	PostgresVersion string `json:"postgresVersion,omitempty"`
+	// Progress of the latest PostgreSQL major version upgrade. The Operator upgrades the data
+	// when the PostgreSQL image is replaced with an image of a newer major version.
+	// +optional
+	PostgresUpgrade *PostgresUpgradeStatus `json:"postgresUpgrade,omitempty"`
+	// State of the declarative configuration of the Identity Provider, Keycloak or RH-SSO, realm.
+	// +optional
+	IdentityProviderRealm *IdentityProviderRealmStatus `json:"identityProviderRealm,omitempty"`
+	// LDAP user federation providers provisioned by the Operator in the Identity Provider, Keycloak or RH-SSO, realm.
+	// +optional
+	LdapUserFederation []LdapUserFederationStatus `json:"ldapUserFederation,omitempty"`
+	// OIDC and SAML identity brokers provisioned by the Operator in the Identity Provider, Keycloak or RH-SSO, realm,
+	// from the secrets labeled with `app.kubernetes.io/component=identity-broker-configuration`.
+	// +optional
+	IdentityBrokers []IdentityBrokerStatus `json:"identityBrokers,omitempty"`
+	// Endpoints of the generic OpenID Connect provider, read from its discovery document.
+	// +optional
+	OpenIdConnectProvider *OpenIdConnectProviderStatus `json:"openIdConnectProvider,omitempty"`
+	// TLS certificates generated or used by the Operator, along with their expiry.
+	// +optional
+	TLSCertificates []TLSCertificateStatus `json:"tlsCertificates,omitempty"`
+}
+
+// TLSCertificateStatus describes a TLS certificate generated or used by the Operator.
+type TLSCertificateStatus struct {
+	// Name of the secret which holds the certificate.
+	SecretName string `json:"secretName"`
+	// Subject of the certificate.
+	// +optional
+	Subject string `json:"subject,omitempty"`
+	// Time after which the certificate is no longer valid.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+	// Whether the certificate is generated by the Operator, which renews it before it expires.
+	// +optional
+	Generated bool `json:"generated,omitempty"`
+	// A human readable message indicating that the certificate expires soon, or can't be read.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// OpenIdConnectProviderStatus describes the generic OpenID Connect provider validated by the Operator.
+type OpenIdConnectProviderStatus struct {
+	// Issuer of the provider.
+	Issuer string `json:"issuer"`
+	// URL of the authorization endpoint.
+	// +optional
+	AuthorizationEndpoint string `json:"authorizationEndpoint,omitempty"`
+	// URL of the token endpoint.
+	// +optional
+	TokenEndpoint string `json:"tokenEndpoint,omitempty"`
+	// URL of the JSON Web Key Set of the provider.
+	// +optional
+	JwksURI string `json:"jwksURI,omitempty"`
+	// URL of the logout endpoint, if the provider supports RP-initiated logout.
+	// +optional
+	EndSessionEndpoint string `json:"endSessionEndpoint,omitempty"`
+}
+
+// IdentityBrokerStatus describes an identity broker provisioned by the Operator.
+type IdentityBrokerStatus struct {
+	// Alias of the identity broker in the realm.
+	Alias string `json:"alias"`
+	// Type of the identity broker, `oidc` or `saml`.
+	// +optional
+	Type string `json:"type,omitempty"`
+	// Name of the secret the identity broker is configured from.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// Resource version of the secret the identity broker is configured from.
+	// +optional
+	SecretVersion string `json:"secretVersion,omitempty"`
+	// A human readable message indicating why the identity broker could not be provisioned.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// LdapUserFederationStatus describes an LDAP user federation provider provisioned by the Operator.
+type LdapUserFederationStatus struct {
+	// Name of the provider.
+	Name string `json:"name"`
+	// Id of the provider in the realm.
+	// +optional
+	ComponentId string `json:"componentId,omitempty"`
+	// Resource version of the bind credentials secret the provider is configured with.
+	// +optional
+	BindCredentialsSecretVersion string `json:"bindCredentialsSecretVersion,omitempty"`
+	// A human readable message indicating why the provider could not be provisioned.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// IdentityProviderRealmStatus describes the reconciliation of the declarative realm configuration.
+type IdentityProviderRealmStatus struct {
+	// Hash of the realm configuration that has been applied to the realm.
+	// +optional
+	ConfigHash string `json:"configHash,omitempty"`
+	// Attributes, such as `realm.accessTokenLifespan` or `client.redirectUris`, that have been found modified
+	// outside of the Operator at the last drift, and reverted.
+	// +optional
+	DriftedAttributes []string `json:"driftedAttributes,omitempty"`
+	// The last time the Operator found the realm drifted from the configuration.
+	// +optional
+	LastDriftTime *metav1.Time `json:"lastDriftTime,omitempty"`
+	// A human readable message indicating why the configuration could not be applied.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Phases of a PostgreSQL major version upgrade
+const (
+	PostgresUpgradePhaseInProgress = "InProgress"
+	PostgresUpgradePhaseSucceeded  = "Succeeded"
+	// The upgrade failed, and the previous PostgreSQL image and data have been restored
+	PostgresUpgradePhaseRolledBack = "RolledBack"
+	// The upgrade failed, and so did the rollback. Manual intervention is required.
+	PostgresUpgradePhaseFailed = "Failed"
+)
+
+// PostgresUpgradeStatus describes a PostgreSQL major version upgrade.
+type PostgresUpgradeStatus struct {
+	// Phase of the upgrade: `InProgress`, `Succeeded`, `RolledBack` or `Failed`.
+	Phase string `json:"phase"`
+	// Current stage of an upgrade in progress: `StoppingClients`, `DumpingDatabases`, `ArchivingData`,
+	// `RestoringDatabases` or `RollingBack`.
+	// +optional
+	Stage string `json:"stage,omitempty"`
+	// A human readable message indicating details about the upgrade.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Major version of PostgreSQL before the upgrade.
+	FromVersion string `json:"fromVersion"`
+	// Major version of PostgreSQL after the upgrade.
+	ToVersion string `json:"toVersion"`
+	// PostgreSQL image before the upgrade.
+	FromImage string `json:"fromImage"`
+	// PostgreSQL image after the upgrade.
+	ToImage string `json:"toImage"`
+	// Number of replicas of the Che server and Identity Provider deployments before they were stopped for the upgrade.
+	// They are restored once the upgrade completes.
+	// +optional
+	ClientReplicas map[string]int32 `json:"clientReplicas,omitempty"`
+	// Time the upgrade started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Time the current stage started.
+	// +optional
+	StageStartTime *metav1.Time `json:"stageStartTime,omitempty"`
+	// Time the upgrade completed, successfully or not.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// CheClusterCondition contains details for one aspect of the current state of the Che installation.
+// It has the same layout as the upstream `metav1.Condition`.
+type CheClusterCondition struct {
+	// Type of the condition, for example `Ready` or `PostgresReady`.
+	Type string `json:"type"`
+	// Status of the condition, one of `True`, `False`, `Unknown`.
+	Status metav1.ConditionStatus `json:"status"`
+	// The `metadata.generation` of the `CheCluster` custom resource that the condition was set based upon.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// A brief CamelCase reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// A human readable message indicating details about the transition.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -0,0 +1,219 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Phases of backup and restore
+const (
+	BackupPhaseInProgress = "InProgress"
+	BackupPhaseSucceeded  = "Succeeded"
+	BackupPhaseFailed     = "Failed"
+)
+
+// BackupTarget defines where backups are stored. Exactly one of the targets must be set.
+type BackupTarget struct {
+	// Stores backups in a persistent volume claim in the namespace of the Che installation.
+	// +optional
+	Pvc *BackupTargetPvc `json:"pvc,omitempty"`
+	// Stores backups in a bucket of an S3-compatible object storage, such as AWS S3 or MinIO.
+	// +optional
+	S3 *BackupTargetS3 `json:"s3,omitempty"`
+}
+
+// BackupTargetPvc defines the persistent volume claim to store backups in.
+type BackupTargetPvc struct {
+	// Name of the persistent volume claim. The claim must exist in the namespace of the Che installation.
+	ClaimName string `json:"claimName"`
+	// Directory in the volume to store backups in. Defaults to the root of the volume.
+	// It is a relative path, without `..`, made of the `A-Za-z0-9._/-` characters.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// BackupTargetS3 defines the bucket of an S3-compatible object storage to store backups in.
+type BackupTargetS3 struct {
+	// URL of the S3-compatible endpoint, for example `https://s3.amazonaws.com` or `http://minio.minio:9000`.
+	Endpoint string `json:"endpoint"`
+	// Region of the bucket. Defaults to `us-east-1`.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Name of the bucket. The bucket must exist.
+	Bucket string `json:"bucket"`
+	// Prefix of the backup object keys.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Name of the secret with the `awsAccessKeyId` and `awsSecretAccessKey` keys
+	// used to access the bucket.
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// CheBackupSpec defines the desired backup
+type CheBackupSpec struct {
+	// Name of the `CheCluster` to back up. Defaults to the only `CheCluster` in the namespace.
+	// +optional
+	CheClusterName string `json:"cheClusterName,omitempty"`
+	// Where to store the backup.
+	Target BackupTarget `json:"target"`
+}
+
+// CheBackupStatus defines the observed state of the backup
+type CheBackupStatus struct {
+	// Phase of the backup: `InProgress`, `Succeeded` or `Failed`.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// A human readable message indicating details about the backup.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Name of the snapshot stored in the backup target. It is used to restore the backup.
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+	// Time the backup completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// The `CheBackup` custom resource requests a backup of the Che installation:
+// the Che and Keycloak databases, the `CheCluster` custom resource and the secrets it references.
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="Eclipse Che Backup"
+type CheBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CheBackupSpec   `json:"spec,omitempty"`
+	Status CheBackupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CheBackupList contains a list of CheBackup
+type CheBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CheBackup `json:"items"`
+}
+
+// CheRestoreSpec defines the desired restore
+type CheRestoreSpec struct {
+	// Where the backup is stored.
+	Target BackupTarget `json:"target"`
+	// Name of the snapshot to restore, as reported in the status of the `CheBackup`.
+	SnapshotName string `json:"snapshotName"`
+}
+
+// CheRestoreStatus defines the observed state of the restore
+type CheRestoreStatus struct {
+	// Phase of the restore: `InProgress`, `Succeeded` or `Failed`.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Current stage of the restore in progress.
+	// +optional
+	Stage string `json:"stage,omitempty"`
+	// A human readable message indicating details about the restore.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Name of the restored `CheCluster`.
+	// +optional
+	CheClusterName string `json:"cheClusterName,omitempty"`
+	// Time the restore completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// The `CheRestore` custom resource restores a backup of the Che installation into its namespace.
+// The `CheCluster` and the secrets are recreated, the databases are restored once the Operator deploys PostgreSQL,
+// everything else is provisioned by the Operator as usual.
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="Eclipse Che Restore"
+type CheRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CheRestoreSpec   `json:"spec,omitempty"`
+	Status CheRestoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CheRestoreList contains a list of CheRestore
+type CheRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CheRestore `json:"items"`
+}
+
+// CheBackupScheduleSpec defines the desired backup schedule
+type CheBackupScheduleSpec struct {
+	// Name of the `CheCluster` to back up. Defaults to the only `CheCluster` in the namespace.
+	// +optional
+	CheClusterName string `json:"cheClusterName,omitempty"`
+	// Where to store the backups.
+	Target BackupTarget `json:"target"`
+	// Interval between backups, for example `24h`.
+	Interval string `json:"interval"`
+	// Number of the latest backups to keep. Older backups are deleted along with their snapshots.
+	// All backups are kept when omitted or set to 0.
+	// +optional
+	KeepLast int `json:"keepLast,omitempty"`
+}
+
+// CheBackupScheduleStatus defines the observed state of the backup schedule
+type CheBackupScheduleStatus struct {
+	// Name of the last `CheBackup` created by the schedule.
+	// +optional
+	LastBackupName string `json:"lastBackupName,omitempty"`
+	// Time the last `CheBackup` was created.
+	// +optional
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+	// A human readable message indicating details about the schedule.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// The `CheBackupSchedule` custom resource creates `CheBackup` custom resources periodically.
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="Eclipse Che Backup Schedule"
+type CheBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CheBackupScheduleSpec   `json:"spec,omitempty"`
+	Status CheBackupScheduleStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CheBackupScheduleList contains a list of CheBackupSchedule
+type CheBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CheBackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&CheBackup{}, &CheBackupList{},
+		&CheRestore{}, &CheRestoreList{},
+		&CheBackupSchedule{}, &CheBackupScheduleList{})
+}
@@ -0,0 +1,17 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v1
+
+// Hub marks the v1 CheCluster as the conversion hub, i.e. the storage version
+// which all the other versions are converted to and from.
+// Implements the `conversion.Hub` interface.
+func (*CheCluster) Hub() {}
@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// PlaintextCredentials describes the credentials which can be set in plain text in the CheCluster spec,
+// and the field referencing the Secret which should be used instead.
+type PlaintextCredentials struct {
+	// Component the credentials belong to
+	Component string
+	// Path of the field with the password set in plain text
+	PasswordPath *field.Path
+	// Path of the field with the name of the Secret containing the credentials
+	SecretPath *field.Path
+	// Fields returns the pointers to the Secret name, user name and password fields of the given CheCluster.
+	// The user name is nil when the credentials consist of the password only.
+	Fields func(c *CheCluster) (secret *string, user *string, password *string)
+}
+
+// AllPlaintextCredentials lists all the credentials which can be set in plain text in the CheCluster spec.
+var AllPlaintextCredentials = []PlaintextCredentials{
+	{
+		Component:    "postgres",
+		PasswordPath: field.NewPath("spec", "database", "chePostgresPassword"),
+		SecretPath:   field.NewPath("spec", "database", "chePostgresSecret"),
+		Fields: func(c *CheCluster) (*string, *string, *string) {
+			return &c.Spec.Database.ChePostgresSecret, &c.Spec.Database.ChePostgresUser, &c.Spec.Database.ChePostgresPassword
+		},
+	},
+	{
+		Component:    "identity",
+		PasswordPath: field.NewPath("spec", "auth", "identityProviderPassword"),
+		SecretPath:   field.NewPath("spec", "auth", "identityProviderSecret"),
+		Fields: func(c *CheCluster) (*string, *string, *string) {
+			return &c.Spec.Auth.IdentityProviderSecret, &c.Spec.Auth.IdentityProviderAdminUserName, &c.Spec.Auth.IdentityProviderPassword
+		},
+	},
+	{
+		Component:    "identity-postgres",
+		PasswordPath: field.NewPath("spec", "auth", "identityProviderPostgresPassword"),
+		SecretPath:   field.NewPath("spec", "auth", "identityProviderPostgresSecret"),
+		Fields: func(c *CheCluster) (*string, *string, *string) {
+			return &c.Spec.Auth.IdentityProviderPostgresSecret, nil, &c.Spec.Auth.IdentityProviderPostgresPassword
+		},
+	},
+	{
+		Component:    "proxy",
+		PasswordPath: field.NewPath("spec", "server", "proxyPassword"),
+		SecretPath:   field.NewPath("spec", "server", "proxySecret"),
+		Fields: func(c *CheCluster) (*string, *string, *string) {
+			return &c.Spec.Server.ProxySecret, &c.Spec.Server.ProxyUser, &c.Spec.Server.ProxyPassword
+		},
+	},
+}
+
+// ValidateNoPlaintextCredentials refuses the passwords set in plain text in the CheCluster spec.
+// Passwords which are not changed comparing to the old CheCluster are tolerated,
+// so existing CheClusters can be updated until the Operator moves them into Secrets.
+// The old CheCluster is nil on creation.
+func ValidateNoPlaintextCredentials(c *CheCluster, old *CheCluster) field.ErrorList {
+	errs := field.ErrorList{}
+	for _, credentials := range AllPlaintextCredentials {
+		_, _, password := credentials.Fields(c)
+		if *password == "" {
+			continue
+		}
+		if old != nil {
+			if _, _, oldPassword := credentials.Fields(old); *password == *oldPassword {
+				continue
+			}
+		}
+		errs = append(errs, field.Forbidden(credentials.PasswordPath, "passwords in plain text are not allowed, use '"+credentials.SecretPath.String()+"' instead"))
+	}
+	return errs
+}
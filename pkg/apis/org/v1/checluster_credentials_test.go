@@ -0,0 +1,93 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v1
+
+import (
+	"testing"
+)
+
+func TestValidateNoPlaintextCredentials(t *testing.T) {
+	type testCase struct {
+		name           string
+		spec           CheClusterSpec
+		oldSpec        *CheClusterSpec
+		expectedFields []string
+	}
+
+	testCases := []testCase{
+		{
+			name: "Credentials in secrets",
+			spec: CheClusterSpec{
+				Database: CheClusterSpecDB{ChePostgresSecret: "postgres-credentials"},
+				Server:   CheClusterSpecServer{ProxySecret: "proxy-credentials"},
+			},
+		},
+		{
+			name: "Plaintext credentials on creation",
+			spec: CheClusterSpec{
+				Database: CheClusterSpecDB{ChePostgresPassword: "password"},
+				Auth: CheClusterSpecAuth{
+					IdentityProviderPassword:         "password",
+					IdentityProviderPostgresPassword: "password",
+				},
+				Server: CheClusterSpecServer{ProxyPassword: "password"},
+			},
+			expectedFields: []string{
+				"spec.database.chePostgresPassword",
+				"spec.auth.identityProviderPassword",
+				"spec.auth.identityProviderPostgresPassword",
+				"spec.server.proxyPassword",
+			},
+		},
+		{
+			name: "Unchanged plaintext credentials on update",
+			spec: CheClusterSpec{
+				Database: CheClusterSpecDB{ChePostgresPassword: "password"},
+				Server:   CheClusterSpecServer{ProxyPassword: "new-password"},
+			},
+			oldSpec: &CheClusterSpec{
+				Database: CheClusterSpecDB{ChePostgresPassword: "password"},
+				Server:   CheClusterSpecServer{ProxyPassword: "password"},
+			},
+			expectedFields: []string{
+				"spec.server.proxyPassword",
+			},
+		},
+		{
+			name: "Removed plaintext credentials on update",
+			spec: CheClusterSpec{},
+			oldSpec: &CheClusterSpec{
+				Database: CheClusterSpecDB{ChePostgresPassword: "password"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cheCluster := &CheCluster{Spec: testCase.spec}
+			var oldCheCluster *CheCluster
+			if testCase.oldSpec != nil {
+				oldCheCluster = &CheCluster{Spec: *testCase.oldSpec}
+			}
+
+			errs := ValidateNoPlaintextCredentials(cheCluster, oldCheCluster)
+			if len(errs) != len(testCase.expectedFields) {
+				t.Fatalf("Expected %d errors, got: %v", len(testCase.expectedFields), errs)
+			}
+			for i, expectedField := range testCase.expectedFields {
+				if errs[i].Field != expectedField {
+					t.Errorf("Expected error for field '%s', got: '%s'", expectedField, errs[i].Field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v1
+
+import (
+	"net/url"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var (
+	supportedServerExposureStrategies = []string{"multi-host", "single-host", "default-host"}
+	supportedSingleHostExposureTypes  = []string{"native", "gateway"}
+	supportedPvcStrategies            = []string{"common", "per-workspace", "unique"}
+	supportedProxySchemes             = []string{"http", "https"}
+	supportedCertManagerIssuerKinds   = []string{"Issuer", "ClusterIssuer"}
+)
+
+// ValidateCreate validates the CheCluster on creation.
+// Implements the `admission.Validator` interface.
+func (c *CheCluster) ValidateCreate() error {
+	return toInvalidError(c, ValidateCheCluster(c))
+}
+
+// ValidateUpdate validates the CheCluster on update.
+// Implements the `admission.Validator` interface.
+func (c *CheCluster) ValidateUpdate(old runtime.Object) error {
+	errs := ValidateCheCluster(c)
+	if oldCheCluster, ok := old.(*CheCluster); ok {
+		errs = append(errs, ValidateCheClusterUpdate(c, oldCheCluster)...)
+	}
+	return toInvalidError(c, errs)
+}
+
+// ValidateDelete validates the CheCluster on deletion.
+// Implements the `admission.Validator` interface.
+func (c *CheCluster) ValidateDelete() error {
+	return nil
+}
+
+// ValidateCheCluster detects the configurations with which it is impossible to deploy Che:
+// - malformed resource quantities
+// - unknown values of the enumerated fields
+// - configurations which miss required field(s)
+func ValidateCheCluster(c *CheCluster) field.ErrorList {
+	errs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	serverPath := specPath.Child("server")
+	server := &c.Spec.Server
+	errs = append(errs, validateQuantity(serverPath.Child("serverMemoryRequest"), server.ServerMemoryRequest)...)
+	errs = append(errs, validateQuantity(serverPath.Child("serverMemoryLimit"), server.ServerMemoryLimit)...)
+	errs = append(errs, validateQuantity(serverPath.Child("serverCpuRequest"), server.ServerCpuRequest)...)
+	errs = append(errs, validateQuantity(serverPath.Child("serverCpuLimit"), server.ServerCpuLimit)...)
+	errs = append(errs, validateQuantity(serverPath.Child("devfileRegistryMemoryRequest"), server.DevfileRegistryMemoryRequest)...)
+	errs = append(errs, validateQuantity(serverPath.Child("devfileRegistryMemoryLimit"), server.DevfileRegistryMemoryLimit)...)
+	errs = append(errs, validateQuantity(serverPath.Child("devfileRegistryCpuRequest"), server.DevfileRegistryCpuRequest)...)
+	errs = append(errs, validateQuantity(serverPath.Child("devfileRegistryCpuLimit"), server.DevfileRegistryCpuLimit)...)
+	errs = append(errs, validateQuantity(serverPath.Child("pluginRegistryMemoryRequest"), server.PluginRegistryMemoryRequest)...)
+	errs = append(errs, validateQuantity(serverPath.Child("pluginRegistryMemoryLimit"), server.PluginRegistryMemoryLimit)...)
+	errs = append(errs, validateQuantity(serverPath.Child("pluginRegistryCpuRequest"), server.PluginRegistryCpuRequest)...)
+	errs = append(errs, validateQuantity(serverPath.Child("pluginRegistryCpuLimit"), server.PluginRegistryCpuLimit)...)
+	errs = append(errs, validateEnum(serverPath.Child("serverExposureStrategy"), server.ServerExposureStrategy, supportedServerExposureStrategies)...)
+	if server.ProxyURL != "" {
+		proxyURL, err := url.Parse(server.ProxyURL)
+		if err != nil {
+			errs = append(errs, field.Invalid(serverPath.Child("proxyURL"), server.ProxyURL, err.Error()))
+		} else if proxyURL.Scheme == "" || proxyURL.Host == "" {
+			errs = append(errs, field.Invalid(serverPath.Child("proxyURL"), server.ProxyURL, "must be an absolute URL with a protocol, for example: 'http://proxy.example.com'"))
+		} else {
+			errs = append(errs, validateEnum(serverPath.Child("proxyURL"), proxyURL.Scheme, supportedProxySchemes)...)
+		}
+	}
+
+	if server.SingleHostGatewayAuthentication.Enable && server.SingleHostGatewayAuthentication.ClientSecretName == "" {
+		errs = append(errs, field.Required(serverPath.Child("singleHostGatewayAuthentication", "clientSecretName"), "must be set when 'enable' is true"))
+	}
+
+	if server.CertManagerIssuer != nil {
+		issuerPath := serverPath.Child("certManagerIssuer")
+		if server.CertManagerIssuer.Name == "" {
+			errs = append(errs, field.Required(issuerPath.Child("name"), "must be set when 'certManagerIssuer' is set"))
+		}
+		errs = append(errs, validateEnum(issuerPath.Child("kind"), server.CertManagerIssuer.Kind, supportedCertManagerIssuerKinds)...)
+	}
+
+	databasePath := specPath.Child("database")
+	database := &c.Spec.Database
+	if database.ExternalDb && database.ChePostgresHostName == "" {
+		errs = append(errs, field.Required(databasePath.Child("chePostgresHostName"), "must be set when 'externalDb' is true"))
+	}
+	errs = append(errs, validateResources(databasePath.Child("chePostgresContainerResources"), database.ChePostgresContainerResources)...)
+
+	authPath := specPath.Child("auth")
+	auth := &c.Spec.Auth
+	if auth.ExternalIdentityProvider && auth.IdentityProviderURL == "" {
+		errs = append(errs, field.Required(authPath.Child("identityProviderURL"), "must be set when 'externalIdentityProvider' is true"))
+	}
+	if auth.OpenIdConnect != nil {
+		errs = append(errs, validateOpenIdConnect(authPath.Child("openIdConnect"), auth)...)
+	}
+	errs = append(errs, validateResources(authPath.Child("identityProviderContainerResources"), auth.IdentityProviderContainerResources)...)
+
+	storagePath := specPath.Child("storage")
+	errs = append(errs, validateEnum(storagePath.Child("pvcStrategy"), c.Spec.Storage.PvcStrategy, supportedPvcStrategies)...)
+	errs = append(errs, validateQuantity(storagePath.Child("pvcClaimSize"), c.Spec.Storage.PvcClaimSize)...)
+
+	k8sPath := specPath.Child("k8s")
+	errs = append(errs, validateEnum(k8sPath.Child("ingressStrategy"), c.Spec.K8s.IngressStrategy, supportedServerExposureStrategies)...)
+	errs = append(errs, validateEnum(k8sPath.Child("singleHostExposureType"), c.Spec.K8s.SingleHostExposureType, supportedSingleHostExposureTypes)...)
+	if c.Spec.K8s.GatewayAPIParentRef != nil && c.Spec.K8s.GatewayAPIParentRef.Name == "" {
+		errs = append(errs, field.Required(k8sPath.Child("gatewayAPIParentRef", "name"), "must be set when 'gatewayAPIParentRef' is set"))
+	}
+
+	return errs
+}
+
+// ValidateCheClusterUpdate detects the changes of the fields which can't be modified
+// once Che is installed.
+func ValidateCheClusterUpdate(c *CheCluster, old *CheCluster) field.ErrorList {
+	errs := field.ErrorList{}
+
+	// empty value means the default flavor which is set by the Operator
+	if old.Spec.Server.CheFlavor != "" && c.Spec.Server.CheFlavor != "" && c.Spec.Server.CheFlavor != old.Spec.Server.CheFlavor {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "server", "cheFlavor"), "field is immutable"))
+	}
+
+	return errs
+}
+
+func validateOpenIdConnect(fldPath *field.Path, auth *CheClusterSpecAuth) field.ErrorList {
+	errs := field.ErrorList{}
+	openIdConnect := auth.OpenIdConnect
+	if auth.ExternalIdentityProvider {
+		errs = append(errs, field.Forbidden(fldPath, "can't be set when 'externalIdentityProvider' is true"))
+	}
+	if openIdConnect.IssuerURL == "" {
+		errs = append(errs, field.Required(fldPath.Child("issuerURL"), ""))
+	} else if issuerURL, err := url.Parse(openIdConnect.IssuerURL); err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("issuerURL"), openIdConnect.IssuerURL, err.Error()))
+	} else if issuerURL.Scheme != "https" && issuerURL.Scheme != "http" || issuerURL.Host == "" {
+		errs = append(errs, field.Invalid(fldPath.Child("issuerURL"), openIdConnect.IssuerURL, "must be an absolute URL with a protocol, for example: 'https://dex.example.com'"))
+	}
+	if openIdConnect.ClientId == "" {
+		errs = append(errs, field.Required(fldPath.Child("clientId"), ""))
+	}
+	return errs
+}
+
+func validateQuantity(fldPath *field.Path, value string) field.ErrorList {
+	if value == "" {
+		return nil
+	}
+	if _, err := resource.ParseQuantity(value); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, value, err.Error())}
+	}
+	return nil
+}
+
+func validateResources(fldPath *field.Path, resources ResourcesCustomSettings) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateQuantity(fldPath.Child("request", "memory"), resources.Requests.Memory)...)
+	errs = append(errs, validateQuantity(fldPath.Child("request", "cpu"), resources.Requests.Cpu)...)
+	errs = append(errs, validateQuantity(fldPath.Child("limits", "memory"), resources.Limits.Memory)...)
+	errs = append(errs, validateQuantity(fldPath.Child("limits", "cpu"), resources.Limits.Cpu)...)
+	return errs
+}
+
+func validateEnum(fldPath *field.Path, value string, supportedValues []string) field.ErrorList {
+	if value == "" {
+		return nil
+	}
+	for _, supportedValue := range supportedValues {
+		if value == supportedValue {
+			return nil
+		}
+	}
+	return field.ErrorList{field.NotSupported(fldPath, value, supportedValues)}
+}
+
+func toInvalidError(c *CheCluster, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(schema.GroupKind{Group: SchemeGroupVersion.Group, Kind: "CheCluster"}, c.Name, errs)
+}
@@ -0,0 +1,243 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v1
+
+import (
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateCheCluster(t *testing.T) {
+	type testCase struct {
+		name           string
+		spec           CheClusterSpec
+		expectedFields []string
+	}
+
+	testCases := []testCase{
+		{
+			name: "Valid configuration",
+			spec: CheClusterSpec{
+				Server: CheClusterSpecServer{
+					ServerMemoryLimit:      "1Gi",
+					ServerCpuRequest:       "100m",
+					ServerExposureStrategy: "single-host",
+					ProxyURL:               "http://proxy.example.com",
+				},
+				Database: CheClusterSpecDB{
+					ExternalDb:          true,
+					ChePostgresHostName: "postgres.example.com",
+				},
+				Auth: CheClusterSpecAuth{
+					ExternalIdentityProvider: true,
+					IdentityProviderURL:      "https://keycloak.example.com",
+				},
+				Storage: CheClusterSpecStorage{
+					PvcStrategy: "per-workspace",
+				},
+				K8s: CheClusterSpecK8SOnly{
+					SingleHostExposureType: "gateway",
+				},
+			},
+		},
+		{
+			name: "Invalid resource quantities",
+			spec: CheClusterSpec{
+				Server: CheClusterSpecServer{
+					ServerMemoryLimit:          "1Gb",
+					PluginRegistryCpuRequest:   "one",
+					DevfileRegistryMemoryLimit: "256Mi",
+				},
+				Database: CheClusterSpecDB{
+					ChePostgresContainerResources: ResourcesCustomSettings{
+						Limits: Resources{Memory: "lots"},
+					},
+				},
+			},
+			expectedFields: []string{
+				"spec.server.serverMemoryLimit",
+				"spec.server.pluginRegistryCpuRequest",
+				"spec.database.chePostgresContainerResources.limits.memory",
+			},
+		},
+		{
+			name: "Unknown enum values",
+			spec: CheClusterSpec{
+				Server: CheClusterSpecServer{
+					ServerExposureStrategy: "multihost",
+					CertManagerIssuer: &CertManagerIssuer{
+						Name: "letsencrypt",
+						Kind: "Certificate",
+					},
+				},
+				Storage: CheClusterSpecStorage{
+					PvcStrategy: "shared",
+				},
+				K8s: CheClusterSpecK8SOnly{
+					SingleHostExposureType: "traefik",
+				},
+			},
+			expectedFields: []string{
+				"spec.server.serverExposureStrategy",
+				"spec.server.certManagerIssuer.kind",
+				"spec.storage.pvcStrategy",
+				"spec.k8s.singleHostExposureType",
+			},
+		},
+		{
+			name: "Missing required fields",
+			spec: CheClusterSpec{
+				Server: CheClusterSpecServer{
+					SingleHostGatewayAuthentication: SingleHostGatewayAuthentication{
+						Enable: true,
+					},
+					CertManagerIssuer: &CertManagerIssuer{},
+				},
+				Database: CheClusterSpecDB{
+					ExternalDb: true,
+				},
+				Auth: CheClusterSpecAuth{
+					ExternalIdentityProvider: true,
+				},
+				K8s: CheClusterSpecK8SOnly{
+					GatewayAPIParentRef: &GatewayAPIParentRef{Namespace: "gateways"},
+				},
+			},
+			expectedFields: []string{
+				"spec.server.singleHostGatewayAuthentication.clientSecretName",
+				"spec.server.certManagerIssuer.name",
+				"spec.database.chePostgresHostName",
+				"spec.auth.identityProviderURL",
+				"spec.k8s.gatewayAPIParentRef.name",
+			},
+		},
+		{
+			name: "Valid OpenID Connect provider",
+			spec: CheClusterSpec{
+				Auth: CheClusterSpecAuth{
+					OpenIdConnect: &OpenIdConnectProvider{
+						IssuerURL: "https://dex.example.com",
+						ClientId:  "che",
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid OpenID Connect provider",
+			spec: CheClusterSpec{
+				Auth: CheClusterSpecAuth{
+					ExternalIdentityProvider: true,
+					IdentityProviderURL:      "https://keycloak.example.com",
+					OpenIdConnect: &OpenIdConnectProvider{
+						IssuerURL: "dex.example.com",
+					},
+				},
+			},
+			expectedFields: []string{
+				"spec.auth.openIdConnect",
+				"spec.auth.openIdConnect.issuerURL",
+				"spec.auth.openIdConnect.clientId",
+			},
+		},
+		{
+			name: "Proxy URL without scheme",
+			spec: CheClusterSpec{
+				Server: CheClusterSpecServer{
+					ProxyURL: "proxy.example.com",
+				},
+			},
+			expectedFields: []string{
+				"spec.server.proxyURL",
+			},
+		},
+		{
+			name: "Proxy URL with unsupported scheme",
+			spec: CheClusterSpec{
+				Server: CheClusterSpecServer{
+					ProxyURL: "ftp://proxy.example.com",
+				},
+			},
+			expectedFields: []string{
+				"spec.server.proxyURL",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cheCluster := &CheCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "eclipse-che"},
+				Spec:       testCase.spec,
+			}
+
+			errs := ValidateCheCluster(cheCluster)
+			if len(errs) != len(testCase.expectedFields) {
+				t.Fatalf("Expected %d errors, got: %v", len(testCase.expectedFields), errs)
+			}
+			for i, expectedField := range testCase.expectedFields {
+				if errs[i].Field != expectedField {
+					t.Errorf("Expected error for field '%s', got: %v", expectedField, errs[i])
+				}
+			}
+
+			err := cheCluster.ValidateCreate()
+			if len(testCase.expectedFields) == 0 && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(testCase.expectedFields) != 0 && !apierrors.IsInvalid(err) {
+				t.Fatalf("Expected invalid error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateCheClusterUpdate(t *testing.T) {
+	type testCase struct {
+		name       string
+		oldFlavor  string
+		newFlavor  string
+		expectFail bool
+	}
+
+	testCases := []testCase{
+		{name: "Flavor is not changed", oldFlavor: "che", newFlavor: "che"},
+		{name: "Flavor is set by the Operator", oldFlavor: "", newFlavor: "che"},
+		{name: "Flavor is reset to default", oldFlavor: "che", newFlavor: ""},
+		{name: "Flavor is changed", oldFlavor: "che", newFlavor: "codeready", expectFail: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			oldCheCluster := &CheCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "eclipse-che"},
+				Spec:       CheClusterSpec{Server: CheClusterSpecServer{CheFlavor: testCase.oldFlavor}},
+			}
+			newCheCluster := oldCheCluster.DeepCopy()
+			newCheCluster.Spec.Server.CheFlavor = testCase.newFlavor
+
+			err := newCheCluster.ValidateUpdate(oldCheCluster)
+			if testCase.expectFail {
+				if !apierrors.IsInvalid(err) {
+					t.Fatalf("Expected invalid error, got: %v", err)
+				}
+				errs := ValidateCheClusterUpdate(newCheCluster, oldCheCluster)
+				if len(errs) != 1 || errs[0].Field != "spec.server.cheFlavor" {
+					t.Fatalf("Expected error for field 'spec.server.cheFlavor', got: %v", errs)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
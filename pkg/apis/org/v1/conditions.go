@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheCluster condition types
+const (
+	// Ready indicates that all Che components are deployed and available.
+	ConditionReady = "Ready"
+	// Progressing indicates that the Operator is rolling out the desired state.
+	ConditionProgressing = "Progressing"
+	// Degraded indicates that the Operator failed to reconcile the desired state.
+	ConditionDegraded = "Degraded"
+	// CredentialsMigrated indicates that the passwords set in plain text in the spec were moved into Secrets.
+	ConditionCredentialsMigrated = "CredentialsMigrated"
+	// Paused indicates that the Operator doesn't change anything in the cluster, as requested with the `che.eclipse.org/reconcile: paused` annotation.
+	ConditionPaused = "Paused"
+	// MaintenanceMode indicates that Che server is stopped and a maintenance page is shown instead,
+	// as requested with the `che.eclipse.org/maintenance-mode: "true"` annotation.
+	ConditionMaintenanceMode = "MaintenanceMode"
+	// CertManagerNotFound indicates that the cert-manager issuer set in `server.certManagerIssuer` is ignored,
+	// since cert-manager is not installed.
+	ConditionCertManagerNotFound = "CertManagerNotFound"
+	// GatewayAPINotFound indicates that the Gateway set in `k8s.gatewayAPIParentRef` is ignored,
+	// since the Gateway API is not installed.
+	ConditionGatewayAPINotFound = "GatewayAPINotFound"
+	// IngressClassNotFound indicates that the IngressClass set in `k8s.ingressClass` doesn't exist,
+	// so it is set in the annotation of the ingresses.
+	ConditionIngressClassNotFound = "IngressClassNotFound"
+
+	ConditionPostgresReady              = "PostgresReady"
+	ConditionKeycloakReady              = "KeycloakReady"
+	ConditionOpenIdConnectProviderReady = "OpenIdConnectProviderReady"
+	ConditionDevfileRegistryReady       = "DevfileRegistryReady"
+	ConditionPluginRegistryReady        = "PluginRegistryReady"
+	ConditionGatewayReady               = "GatewayReady"
+	ConditionCheServerReady             = "CheServerReady"
+	ConditionDevWorkspaceReady          = "DevWorkspaceReady"
+	ConditionImagePullerReady           = "ImagePullerReady"
+)
+
+// SetCondition sets the corresponding condition in conditions to newCondition.
+// LastTransitionTime is updated only if the status of the condition changes.
+// Returns true if conditions were modified.
+func SetCondition(conditions *[]CheClusterCondition, newCondition CheClusterCondition) bool {
+	if conditions == nil {
+		return false
+	}
+
+	existingCondition := FindCondition(*conditions, newCondition.Type)
+	if existingCondition == nil {
+		if newCondition.LastTransitionTime.IsZero() {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		*conditions = append(*conditions, newCondition)
+		return true
+	}
+
+	if existingCondition.Status == newCondition.Status &&
+		existingCondition.Reason == newCondition.Reason &&
+		existingCondition.Message == newCondition.Message &&
+		existingCondition.ObservedGeneration == newCondition.ObservedGeneration {
+		return false
+	}
+
+	if existingCondition.Status != newCondition.Status {
+		existingCondition.Status = newCondition.Status
+		if !newCondition.LastTransitionTime.IsZero() {
+			existingCondition.LastTransitionTime = newCondition.LastTransitionTime
+		} else {
+			existingCondition.LastTransitionTime = metav1.Now()
+		}
+	}
+	existingCondition.Reason = newCondition.Reason
+	existingCondition.Message = newCondition.Message
+	existingCondition.ObservedGeneration = newCondition.ObservedGeneration
+	return true
+}
+
+// RemoveCondition removes the corresponding conditionType from conditions.
+// Returns true if conditions were modified.
+func RemoveCondition(conditions *[]CheClusterCondition, conditionType string) bool {
+	if conditions == nil || len(*conditions) == 0 {
+		return false
+	}
+
+	newConditions := make([]CheClusterCondition, 0, len(*conditions)-1)
+	for _, condition := range *conditions {
+		if condition.Type != conditionType {
+			newConditions = append(newConditions, condition)
+		}
+	}
+
+	if len(*conditions) == len(newConditions) {
+		return false
+	}
+	*conditions = newConditions
+	return true
+}
+
+// FindCondition finds the conditionType in conditions.
+func FindCondition(conditions []CheClusterCondition, conditionType string) *CheClusterCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true when the conditionType is present and set to `True`.
+func IsConditionTrue(conditions []CheClusterCondition, conditionType string) bool {
+	condition := FindCondition(conditions, conditionType)
+	return condition != nil && condition.Status == metav1.ConditionTrue
+}
@@ -9,13 +9,283 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTarget) DeepCopyInto(out *BackupTarget) {
+	*out = *in
+	if in.Pvc != nil {
+		in, out := &in.Pvc, &out.Pvc
+		*out = new(BackupTargetPvc)
+		**out = **in
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(BackupTargetS3)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTarget.
+func (in *BackupTarget) DeepCopy() *BackupTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTargetPvc) DeepCopyInto(out *BackupTargetPvc) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTargetPvc.
+func (in *BackupTargetPvc) DeepCopy() *BackupTargetPvc {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTargetPvc)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTargetS3) DeepCopyInto(out *BackupTargetS3) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTargetS3.
+func (in *BackupTargetS3) DeepCopy() *BackupTargetS3 {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTargetS3)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuer) DeepCopyInto(out *CertManagerIssuer) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuer.
+func (in *CertManagerIssuer) DeepCopy() *CertManagerIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheBackup) DeepCopyInto(out *CheBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheBackup.
+func (in *CheBackup) DeepCopy() *CheBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(CheBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CheBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheBackupList) DeepCopyInto(out *CheBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CheBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheBackupList.
+func (in *CheBackupList) DeepCopy() *CheBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(CheBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CheBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheBackupSchedule) DeepCopyInto(out *CheBackupSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheBackupSchedule.
+func (in *CheBackupSchedule) DeepCopy() *CheBackupSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(CheBackupSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CheBackupSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheBackupScheduleList) DeepCopyInto(out *CheBackupScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CheBackupSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheBackupScheduleList.
+func (in *CheBackupScheduleList) DeepCopy() *CheBackupScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(CheBackupScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CheBackupScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheBackupScheduleSpec) DeepCopyInto(out *CheBackupScheduleSpec) {
+	*out = *in
+	in.Target.DeepCopyInto(&out.Target)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheBackupScheduleSpec.
+func (in *CheBackupScheduleSpec) DeepCopy() *CheBackupScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CheBackupScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheBackupScheduleStatus) DeepCopyInto(out *CheBackupScheduleStatus) {
+	*out = *in
+	if in.LastBackupTime != nil {
+		in, out := &in.LastBackupTime, &out.LastBackupTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheBackupScheduleStatus.
+func (in *CheBackupScheduleStatus) DeepCopy() *CheBackupScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CheBackupScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheBackupSpec) DeepCopyInto(out *CheBackupSpec) {
+	*out = *in
+	in.Target.DeepCopyInto(&out.Target)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheBackupSpec.
+func (in *CheBackupSpec) DeepCopy() *CheBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CheBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheBackupStatus) DeepCopyInto(out *CheBackupStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheBackupStatus.
+func (in *CheBackupStatus) DeepCopy() *CheBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CheBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CheCluster) DeepCopyInto(out *CheCluster) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -37,6 +307,23 @@ func (in *CheCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheClusterCondition) DeepCopyInto(out *CheClusterCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheClusterCondition.
+func (in *CheClusterCondition) DeepCopy() *CheClusterCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(CheClusterCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CheClusterList) DeepCopyInto(out *CheClusterList) {
 	*out = *in
@@ -78,7 +365,7 @@ func (in *CheClusterSpec) DeepCopyInto(out *CheClusterSpec) {
 	in.Auth.DeepCopyInto(&out.Auth)
 	out.Storage = in.Storage
 	out.Metrics = in.Metrics
-	out.K8s = in.K8s
+	in.K8s.DeepCopyInto(&out.K8s)
 	out.ImagePuller = in.ImagePuller
 	out.DevWorkspace = in.DevWorkspace
 	return
@@ -102,6 +389,23 @@ func (in *CheClusterSpecAuth) DeepCopyInto(out *CheClusterSpecAuth) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.IdentityProviderRealmConfig != nil {
+		in, out := &in.IdentityProviderRealmConfig, &out.IdentityProviderRealmConfig
+		*out = new(IdentityProviderRealmConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LdapUserFederation != nil {
+		in, out := &in.LdapUserFederation, &out.LdapUserFederation
+		*out = make([]LdapUserFederation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OpenIdConnect != nil {
+		in, out := &in.OpenIdConnect, &out.OpenIdConnect
+		*out = new(OpenIdConnectProvider)
+		**out = **in
+	}
 	if in.OpenShiftoAuth != nil {
 		in, out := &in.OpenShiftoAuth, &out.OpenShiftoAuth
 		*out = new(bool)
@@ -176,6 +480,11 @@ func (in *CheClusterSpecImagePuller) DeepCopy() *CheClusterSpecImagePuller {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CheClusterSpecK8SOnly) DeepCopyInto(out *CheClusterSpecK8SOnly) {
 	*out = *in
+	if in.GatewayAPIParentRef != nil {
+		in, out := &in.GatewayAPIParentRef, &out.GatewayAPIParentRef
+		*out = new(GatewayAPIParentRef)
+		**out = **in
+	}
 	return
 }
 
@@ -208,6 +517,11 @@ func (in *CheClusterSpecMetrics) DeepCopy() *CheClusterSpecMetrics {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CheClusterSpecServer) DeepCopyInto(out *CheClusterSpecServer) {
 	*out = *in
+	if in.CertManagerIssuer != nil {
+		in, out := &in.CertManagerIssuer, &out.CertManagerIssuer
+		*out = new(CertManagerIssuer)
+		**out = **in
+	}
 	out.DevfileRegistryIngress = in.DevfileRegistryIngress
 	out.DevfileRegistryRoute = in.DevfileRegistryRoute
 	out.PluginRegistryIngress = in.PluginRegistryIngress
@@ -226,6 +540,7 @@ func (in *CheClusterSpecServer) DeepCopyInto(out *CheClusterSpecServer) {
 			(*out)[key] = val
 		}
 	}
+	out.SingleHostGatewayAuthentication = in.SingleHostGatewayAuthentication
 	out.CheServerIngress = in.CheServerIngress
 	out.CheServerRoute = in.CheServerRoute
 	return
@@ -260,6 +575,54 @@ func (in *CheClusterSpecStorage) DeepCopy() *CheClusterSpecStorage {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CheClusterStatus) DeepCopyInto(out *CheClusterStatus) {
 	*out = *in
+	if in.OpenShiftoAuthAutoDetected != nil {
+		in, out := &in.OpenShiftoAuthAutoDetected, &out.OpenShiftoAuthAutoDetected
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]CheClusterCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastCredentialsRotationTime != nil {
+		in, out := &in.LastCredentialsRotationTime, &out.LastCredentialsRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PostgresUpgrade != nil {
+		in, out := &in.PostgresUpgrade, &out.PostgresUpgrade
+		*out = new(PostgresUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IdentityProviderRealm != nil {
+		in, out := &in.IdentityProviderRealm, &out.IdentityProviderRealm
+		*out = new(IdentityProviderRealmStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LdapUserFederation != nil {
+		in, out := &in.LdapUserFederation, &out.LdapUserFederation
+		*out = make([]LdapUserFederationStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.IdentityBrokers != nil {
+		in, out := &in.IdentityBrokers, &out.IdentityBrokers
+		*out = make([]IdentityBrokerStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.OpenIdConnectProvider != nil {
+		in, out := &in.OpenIdConnectProvider, &out.OpenIdConnectProvider
+		*out = new(OpenIdConnectProviderStatus)
+		**out = **in
+	}
+	if in.TLSCertificates != nil {
+		in, out := &in.TLSCertificates, &out.TLSCertificates
+		*out = make([]TLSCertificateStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -273,6 +636,192 @@ func (in *CheClusterStatus) DeepCopy() *CheClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheRestore) DeepCopyInto(out *CheRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheRestore.
+func (in *CheRestore) DeepCopy() *CheRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(CheRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CheRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheRestoreList) DeepCopyInto(out *CheRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CheRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheRestoreList.
+func (in *CheRestoreList) DeepCopy() *CheRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(CheRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CheRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheRestoreSpec) DeepCopyInto(out *CheRestoreSpec) {
+	*out = *in
+	in.Target.DeepCopyInto(&out.Target)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheRestoreSpec.
+func (in *CheRestoreSpec) DeepCopy() *CheRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CheRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheRestoreStatus) DeepCopyInto(out *CheRestoreStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheRestoreStatus.
+func (in *CheRestoreStatus) DeepCopy() *CheRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CheRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayAPIParentRef) DeepCopyInto(out *GatewayAPIParentRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayAPIParentRef.
+func (in *GatewayAPIParentRef) DeepCopy() *GatewayAPIParentRef {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayAPIParentRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityBrokerStatus) DeepCopyInto(out *IdentityBrokerStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityBrokerStatus.
+func (in *IdentityBrokerStatus) DeepCopy() *IdentityBrokerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityBrokerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProviderRealmConfig) DeepCopyInto(out *IdentityProviderRealmConfig) {
+	*out = *in
+	if in.AccessTokenLifespan != nil {
+		in, out := &in.AccessTokenLifespan, &out.AccessTokenLifespan
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SsoSessionIdleTimeout != nil {
+		in, out := &in.SsoSessionIdleTimeout, &out.SsoSessionIdleTimeout
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SsoSessionMaxLifespan != nil {
+		in, out := &in.SsoSessionMaxLifespan, &out.SsoSessionMaxLifespan
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderRealmConfig.
+func (in *IdentityProviderRealmConfig) DeepCopy() *IdentityProviderRealmConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityProviderRealmConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProviderRealmStatus) DeepCopyInto(out *IdentityProviderRealmStatus) {
+	*out = *in
+	if in.DriftedAttributes != nil {
+		in, out := &in.DriftedAttributes, &out.DriftedAttributes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastDriftTime != nil {
+		in, out := &in.LastDriftTime, &out.LastDriftTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderRealmStatus.
+func (in *IdentityProviderRealmStatus) DeepCopy() *IdentityProviderRealmStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityProviderRealmStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressCustomSettings) DeepCopyInto(out *IngressCustomSettings) {
 	*out = *in
@@ -289,6 +838,136 @@ func (in *IngressCustomSettings) DeepCopy() *IngressCustomSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LdapGroupMapper) DeepCopyInto(out *LdapGroupMapper) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LdapGroupMapper.
+func (in *LdapGroupMapper) DeepCopy() *LdapGroupMapper {
+	if in == nil {
+		return nil
+	}
+	out := new(LdapGroupMapper)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LdapUserFederation) DeepCopyInto(out *LdapUserFederation) {
+	*out = *in
+	if in.FullSyncPeriod != nil {
+		in, out := &in.FullSyncPeriod, &out.FullSyncPeriod
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ChangedSyncPeriod != nil {
+		in, out := &in.ChangedSyncPeriod, &out.ChangedSyncPeriod
+		*out = new(int32)
+		**out = **in
+	}
+	if in.GroupMappers != nil {
+		in, out := &in.GroupMappers, &out.GroupMappers
+		*out = make([]LdapGroupMapper, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LdapUserFederation.
+func (in *LdapUserFederation) DeepCopy() *LdapUserFederation {
+	if in == nil {
+		return nil
+	}
+	out := new(LdapUserFederation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LdapUserFederationStatus) DeepCopyInto(out *LdapUserFederationStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LdapUserFederationStatus.
+func (in *LdapUserFederationStatus) DeepCopy() *LdapUserFederationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LdapUserFederationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenIdConnectProvider) DeepCopyInto(out *OpenIdConnectProvider) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenIdConnectProvider.
+func (in *OpenIdConnectProvider) DeepCopy() *OpenIdConnectProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenIdConnectProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenIdConnectProviderStatus) DeepCopyInto(out *OpenIdConnectProviderStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenIdConnectProviderStatus.
+func (in *OpenIdConnectProviderStatus) DeepCopy() *OpenIdConnectProviderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenIdConnectProviderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresUpgradeStatus) DeepCopyInto(out *PostgresUpgradeStatus) {
+	*out = *in
+	if in.ClientReplicas != nil {
+		in, out := &in.ClientReplicas, &out.ClientReplicas
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.StageStartTime != nil {
+		in, out := &in.StageStartTime, &out.StageStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresUpgradeStatus.
+func (in *PostgresUpgradeStatus) DeepCopy() *PostgresUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Resources) DeepCopyInto(out *Resources) {
 	*out = *in
@@ -338,3 +1017,39 @@ func (in *RouteCustomSettings) DeepCopy() *RouteCustomSettings {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SingleHostGatewayAuthentication) DeepCopyInto(out *SingleHostGatewayAuthentication) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SingleHostGatewayAuthentication.
+func (in *SingleHostGatewayAuthentication) DeepCopy() *SingleHostGatewayAuthentication {
+	if in == nil {
+		return nil
+	}
+	out := new(SingleHostGatewayAuthentication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSCertificateStatus) DeepCopyInto(out *TLSCertificateStatus) {
+	*out = *in
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSCertificateStatus.
+func (in *TLSCertificateStatus) DeepCopy() *TLSCertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSCertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,540 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+const (
+	// V1FieldsAnnotation keeps the v1 fields which don't have a v2 counterpart,
+	// so they are not lost when a v1 object is read and written back in v2.
+	// Credentials set in plain text in v1 are not kept, annotations are readable by anyone who can read the object.
+	// The Operator moves them into secrets, which the v2 spec references.
+	V1FieldsAnnotation = "che.eclipse.org/v1-fields"
+	// V2FieldsAnnotation keeps the v2 fields which don't have a v1 counterpart,
+	// so they are not lost when the object is stored in v1.
+	V2FieldsAnnotation = "che.eclipse.org/v2-fields"
+
+	// Components whose deployment settings are kept in the V2FieldsAnnotation
+	cheServerComponent        = "cheServer"
+	pluginRegistryComponent   = "pluginRegistry"
+	devfileRegistryComponent  = "devfileRegistry"
+	databaseComponent         = "database"
+	identityProviderComponent = "identityProvider"
+	gatewayComponent          = "gateway"
+
+	clusterRolesSeparator  = ","
+	nonProxyHostsSeparator = "|"
+)
+
+// v1OnlyFields are the v1 fields which can't be represented in v2.
+type v1OnlyFields struct {
+	// security context values which are not valid integers
+	SecurityContextFsGroup   string `json:"securityContextFsGroup,omitempty"`
+	SecurityContextRunAsUser string `json:"securityContextRunAsUser,omitempty"`
+}
+
+// v2OnlyFields are the v2 fields which can't be represented in v1.
+type v2OnlyFields struct {
+	Deployments map[string]deploymentFields `json:"deployments,omitempty"`
+}
+
+type deploymentFields struct {
+	Env             []corev1.EnvVar     `json:"env,omitempty"`
+	SecurityContext *PodSecurityContext `json:"securityContext,omitempty"`
+}
+
+// ConvertTo converts this CheCluster to the Hub version (v1).
+// Implements the `conversion.Convertible` interface.
+func (src *CheCluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*orgv1.CheCluster)
+	if !ok {
+		return fmt.Errorf("unsupported conversion destination type %T", dstRaw)
+	}
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	src.Status.DeepCopyInto(&dst.Status)
+
+	v1Fields := &v1OnlyFields{}
+	if err := popAnnotation(&dst.ObjectMeta.Annotations, V1FieldsAnnotation, v1Fields); err != nil {
+		return err
+	}
+
+	v2Fields := &v2OnlyFields{}
+	convertSpecTo(&src.Spec, &dst.Spec, v1Fields, v2Fields)
+
+	if len(v2Fields.Deployments) > 0 {
+		return pushAnnotation(&dst.ObjectMeta.Annotations, V2FieldsAnnotation, v2Fields)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1) to this version.
+// Implements the `conversion.Convertible` interface.
+func (dst *CheCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*orgv1.CheCluster)
+	if !ok {
+		return fmt.Errorf("unsupported conversion source type %T", srcRaw)
+	}
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	src.Status.DeepCopyInto(&dst.Status)
+
+	v2Fields := &v2OnlyFields{}
+	if err := popAnnotation(&dst.ObjectMeta.Annotations, V2FieldsAnnotation, v2Fields); err != nil {
+		return err
+	}
+
+	v1Fields := &v1OnlyFields{}
+	convertSpecFrom(&src.Spec, &dst.Spec, v1Fields, v2Fields)
+
+	if *v1Fields != (v1OnlyFields{}) {
+		return pushAnnotation(&dst.ObjectMeta.Annotations, V1FieldsAnnotation, v1Fields)
+	}
+	return nil
+}
+
+func convertSpecTo(src *CheClusterSpec, dst *orgv1.CheClusterSpec, v1Fields *v1OnlyFields, v2Fields *v2OnlyFields) {
+	server := &src.Components.CheServer
+	dst.Server = orgv1.CheClusterSpecServer{
+		AirGapContainerRegistryHostname:     src.ContainerRegistry.Hostname,
+		AirGapContainerRegistryOrganization: src.ContainerRegistry.Organization,
+		CheImage:                            server.Deployment.Image,
+		CheImageTag:                         server.ImageTag,
+		CheImagePullPolicy:                  server.Deployment.ImagePullPolicy,
+		CheFlavor:                           server.Flavor,
+		CheHost:                             src.Networking.Hostname,
+		CheHostTLSSecret:                    src.Networking.HostnameTlsSecretName,
+		CertManagerIssuer:                   src.Networking.CertManagerIssuer.DeepCopy(),
+		CheLogLevel:                         server.LogLevel,
+		CheDebug:                            server.Debug,
+		CheClusterRoles:                     strings.Join(server.ClusterRoles, clusterRolesSeparator),
+		CheWorkspaceClusterRole:             src.DevEnvironments.ClusterRole,
+		WorkspaceNamespaceDefault:           src.DevEnvironments.DefaultNamespace,
+		AllowUserDefinedWorkspaceNamespaces: src.DevEnvironments.AllowUserDefinedNamespaces,
+		SelfSignedCert:                      server.SelfSignedCert,
+		ServerTrustStoreConfigMapName:       server.TrustStoreConfigMapName,
+		GitSelfSignedCert:                   server.GitSelfSignedCert,
+		TlsSupport:                          src.Networking.TlsSupport,
+		UseInternalClusterSVCNames:          server.UseInternalClusterSVCNames,
+		CustomCheProperties:                 copyMap(server.ExtraProperties),
+		ProxyURL:                            server.Proxy.Url,
+		ProxyPort:                           server.Proxy.Port,
+		NonProxyHosts:                       strings.Join(server.Proxy.NonProxyHosts, nonProxyHostsSeparator),
+		ProxySecret:                         server.Proxy.CredentialsSecretName,
+		ServerMemoryRequest:                 server.Deployment.Resources.Requests.Memory,
+		ServerMemoryLimit:                   server.Deployment.Resources.Limits.Memory,
+		ServerCpuRequest:                    server.Deployment.Resources.Requests.Cpu,
+		ServerCpuLimit:                      server.Deployment.Resources.Limits.Cpu,
+		ServerExposureStrategy:              src.Networking.ExposureStrategy,
+		CheServerIngress:                    orgv1.IngressCustomSettings(server.Exposure.Ingress),
+		CheServerRoute:                      orgv1.RouteCustomSettings(server.Exposure.Route),
+
+		DevfileRegistryUrl:           src.Components.DevfileRegistry.ExternalUrl,
+		DevfileRegistryImage:         src.Components.DevfileRegistry.Deployment.Image,
+		DevfileRegistryPullPolicy:    src.Components.DevfileRegistry.Deployment.ImagePullPolicy,
+		DevfileRegistryMemoryRequest: src.Components.DevfileRegistry.Deployment.Resources.Requests.Memory,
+		DevfileRegistryMemoryLimit:   src.Components.DevfileRegistry.Deployment.Resources.Limits.Memory,
+		DevfileRegistryCpuRequest:    src.Components.DevfileRegistry.Deployment.Resources.Requests.Cpu,
+		DevfileRegistryCpuLimit:      src.Components.DevfileRegistry.Deployment.Resources.Limits.Cpu,
+		DevfileRegistryIngress:       orgv1.IngressCustomSettings(src.Components.DevfileRegistry.Exposure.Ingress),
+		DevfileRegistryRoute:         orgv1.RouteCustomSettings(src.Components.DevfileRegistry.Exposure.Route),
+		ExternalDevfileRegistry:      src.Components.DevfileRegistry.DisableInternalRegistry,
+
+		PluginRegistryUrl:           src.Components.PluginRegistry.ExternalUrl,
+		PluginRegistryImage:         src.Components.PluginRegistry.Deployment.Image,
+		PluginRegistryPullPolicy:    src.Components.PluginRegistry.Deployment.ImagePullPolicy,
+		PluginRegistryMemoryRequest: src.Components.PluginRegistry.Deployment.Resources.Requests.Memory,
+		PluginRegistryMemoryLimit:   src.Components.PluginRegistry.Deployment.Resources.Limits.Memory,
+		PluginRegistryCpuRequest:    src.Components.PluginRegistry.Deployment.Resources.Requests.Cpu,
+		PluginRegistryCpuLimit:      src.Components.PluginRegistry.Deployment.Resources.Limits.Cpu,
+		PluginRegistryIngress:       orgv1.IngressCustomSettings(src.Components.PluginRegistry.Exposure.Ingress),
+		PluginRegistryRoute:         orgv1.RouteCustomSettings(src.Components.PluginRegistry.Exposure.Route),
+		ExternalPluginRegistry:      src.Components.PluginRegistry.DisableInternalRegistry,
+
+		SingleHostGatewayImage:              src.Components.Gateway.Deployment.Image,
+		SingleHostGatewayConfigSidecarImage: src.Components.Gateway.ConfigSidecarImage,
+		SingleHostGatewayConfigMapLabels:    labels.Set(copyMap(src.Components.Gateway.ConfigLabels)),
+		SingleHostGatewayAuthentication:     src.Components.Gateway.Authentication,
+	}
+
+	database := &src.Components.Database
+	dst.Database = orgv1.CheClusterSpecDB{
+		ExternalDb:                    database.ExternalDb,
+		ChePostgresHostName:           database.PostgresHostName,
+		ChePostgresPort:               database.PostgresPort,
+		ChePostgresDb:                 database.PostgresDb,
+		ChePostgresSecret:             database.CredentialsSecretName,
+		PostgresImage:                 database.Deployment.Image,
+		PostgresImagePullPolicy:       database.Deployment.ImagePullPolicy,
+		ChePostgresContainerResources: convertResourcesTo(database.Deployment.Resources),
+	}
+
+	identityProvider := &src.Components.IdentityProvider
+	dst.Auth = orgv1.CheClusterSpecAuth{
+		InitialOpenShiftOAuthUser:          copyBool(src.Networking.Auth.InitialOpenShiftOAuthUser),
+		ExternalIdentityProvider:           identityProvider.External,
+		IdentityProviderURL:                identityProvider.Url,
+		IdentityProviderSecret:             identityProvider.AdminCredentialsSecretName,
+		IdentityProviderRealm:              identityProvider.Realm,
+		IdentityProviderClientId:           identityProvider.ClientId,
+		IdentityProviderRealmConfig:        (*orgv1.IdentityProviderRealmConfig)(identityProvider.RealmConfig.DeepCopy()),
+		LdapUserFederation:                 copyLdapUserFederation(identityProvider.LdapUserFederation),
+		OpenIdConnect:                      identityProvider.OpenIdConnect.DeepCopy(),
+		IdentityProviderPostgresSecret:     identityProvider.PostgresCredentialsSecretName,
+		UpdateAdminPassword:                identityProvider.UpdateAdminPassword,
+		OpenShiftoAuth:                     copyBool(src.Networking.Auth.OpenShiftOAuth),
+		OAuthClientName:                    src.Networking.Auth.OAuthClientName,
+		IdentityProviderImage:              identityProvider.Deployment.Image,
+		IdentityProviderImagePullPolicy:    identityProvider.Deployment.ImagePullPolicy,
+		IdentityProviderIngress:            orgv1.IngressCustomSettings(identityProvider.Exposure.Ingress),
+		IdentityProviderRoute:              orgv1.RouteCustomSettings(identityProvider.Exposure.Route),
+		IdentityProviderContainerResources: convertResourcesTo(identityProvider.Deployment.Resources),
+	}
+
+	storage := &src.DevEnvironments.Storage
+	dst.Storage = orgv1.CheClusterSpecStorage{
+		PvcStrategy:                  storage.PvcStrategy,
+		PvcClaimSize:                 storage.ClaimSize,
+		PreCreateSubPaths:            storage.PreCreateSubPaths,
+		PvcJobsImage:                 storage.PvcJobsImage,
+		PostgresPVCStorageClassName:  database.Pvc.StorageClassName,
+		WorkspacePVCStorageClassName: storage.StorageClassName,
+	}
+
+	// the security context of the Che server is the only one which exists in v1
+	securityContext := server.Deployment.SecurityContext
+	if securityContext == nil {
+		securityContext = &PodSecurityContext{}
+	}
+	dst.K8s = orgv1.CheClusterSpecK8SOnly{
+		IngressDomain:            src.Networking.Domain,
+		IngressStrategy:          src.Networking.IngressStrategy,
+		IngressClass:             src.Networking.IngressClass,
+		TlsSecretName:            src.Networking.TlsSecretName,
+		GatewayAPIParentRef:      src.Networking.GatewayAPIParentRef.DeepCopy(),
+		SecurityContextFsGroup:   formatInt(securityContext.FSGroup, v1Fields.SecurityContextFsGroup),
+		SecurityContextRunAsUser: formatInt(securityContext.RunAsUser, v1Fields.SecurityContextRunAsUser),
+		SingleHostExposureType:   src.Networking.SingleHostExposureType,
+	}
+
+	dst.Metrics = orgv1.CheClusterSpecMetrics{Enable: src.Components.Metrics.Enable}
+	dst.ImagePuller = orgv1.CheClusterSpecImagePuller{Enable: src.Components.ImagePuller.Enable, Spec: src.Components.ImagePuller.Spec}
+	dst.DevWorkspace = orgv1.CheClusterSpecDevWorkspace{Enable: src.Components.DevWorkspace.Enable}
+
+	v2Fields.Deployments = map[string]deploymentFields{}
+	for component, deployment := range map[string]*Deployment{
+		cheServerComponent:        &server.Deployment,
+		pluginRegistryComponent:   &src.Components.PluginRegistry.Deployment,
+		devfileRegistryComponent:  &src.Components.DevfileRegistry.Deployment,
+		databaseComponent:         &database.Deployment,
+		identityProviderComponent: &identityProvider.Deployment,
+		gatewayComponent:          &src.Components.Gateway.Deployment,
+	} {
+		fields := deploymentFields{Env: deployment.Env}
+		if component != cheServerComponent {
+			fields.SecurityContext = deployment.SecurityContext
+		}
+		if fields.Env != nil || fields.SecurityContext != nil {
+			v2Fields.Deployments[component] = fields
+		}
+	}
+}
+
+func convertSpecFrom(src *orgv1.CheClusterSpec, dst *CheClusterSpec, v1Fields *v1OnlyFields, v2Fields *v2OnlyFields) {
+	server := &src.Server
+	dst.ContainerRegistry = CheClusterContainerRegistry{
+		Hostname:     server.AirGapContainerRegistryHostname,
+		Organization: server.AirGapContainerRegistryOrganization,
+	}
+
+	dst.Networking = CheClusterSpecNetworking{
+		Hostname:               server.CheHost,
+		HostnameTlsSecretName:  server.CheHostTLSSecret,
+		CertManagerIssuer:      server.CertManagerIssuer.DeepCopy(),
+		TlsSupport:             server.TlsSupport,
+		ExposureStrategy:       server.ServerExposureStrategy,
+		SingleHostExposureType: src.K8s.SingleHostExposureType,
+		Domain:                 src.K8s.IngressDomain,
+		IngressStrategy:        src.K8s.IngressStrategy,
+		IngressClass:           src.K8s.IngressClass,
+		TlsSecretName:          src.K8s.TlsSecretName,
+		GatewayAPIParentRef:    src.K8s.GatewayAPIParentRef.DeepCopy(),
+		Auth: Auth{
+			OpenShiftOAuth:            copyBool(src.Auth.OpenShiftoAuth),
+			InitialOpenShiftOAuthUser: copyBool(src.Auth.InitialOpenShiftOAuthUser),
+			OAuthClientName:           src.Auth.OAuthClientName,
+		},
+	}
+
+	dst.DevEnvironments = CheClusterDevEnvironments{
+		DefaultNamespace:           server.WorkspaceNamespaceDefault,
+		AllowUserDefinedNamespaces: server.AllowUserDefinedWorkspaceNamespaces,
+		ClusterRole:                server.CheWorkspaceClusterRole,
+		Storage: WorkspaceStorage{
+			PvcStrategy:       src.Storage.PvcStrategy,
+			ClaimSize:         src.Storage.PvcClaimSize,
+			StorageClassName:  src.Storage.WorkspacePVCStorageClassName,
+			PreCreateSubPaths: src.Storage.PreCreateSubPaths,
+			PvcJobsImage:      src.Storage.PvcJobsImage,
+		},
+	}
+
+	securityContext := &PodSecurityContext{
+		FSGroup:   parseInt(src.K8s.SecurityContextFsGroup, &v1Fields.SecurityContextFsGroup),
+		RunAsUser: parseInt(src.K8s.SecurityContextRunAsUser, &v1Fields.SecurityContextRunAsUser),
+	}
+	if *securityContext == (PodSecurityContext{}) {
+		securityContext = nil
+	}
+
+	dst.Components = CheClusterComponents{
+		CheServer: CheServer{
+			Deployment: Deployment{
+				Image:           server.CheImage,
+				ImagePullPolicy: server.CheImagePullPolicy,
+				Resources: ResourceRequirements{
+					Requests: ResourceList{Memory: server.ServerMemoryRequest, Cpu: server.ServerCpuRequest},
+					Limits:   ResourceList{Memory: server.ServerMemoryLimit, Cpu: server.ServerCpuLimit},
+				},
+				SecurityContext: securityContext,
+			},
+			ImageTag:                   server.CheImageTag,
+			Flavor:                     server.CheFlavor,
+			LogLevel:                   server.CheLogLevel,
+			Debug:                      server.CheDebug,
+			ClusterRoles:               splitList(server.CheClusterRoles, clusterRolesSeparator),
+			SelfSignedCert:             server.SelfSignedCert,
+			GitSelfSignedCert:          server.GitSelfSignedCert,
+			TrustStoreConfigMapName:    server.ServerTrustStoreConfigMapName,
+			UseInternalClusterSVCNames: server.UseInternalClusterSVCNames,
+			ExtraProperties:            copyMap(server.CustomCheProperties),
+			Proxy: Proxy{
+				Url:                   server.ProxyURL,
+				Port:                  server.ProxyPort,
+				NonProxyHosts:         splitList(server.NonProxyHosts, nonProxyHostsSeparator),
+				CredentialsSecretName: server.ProxySecret,
+			},
+			Exposure: Exposure{
+				Ingress: IngressCustomSettings(server.CheServerIngress),
+				Route:   RouteCustomSettings(server.CheServerRoute),
+			},
+		},
+		PluginRegistry: PluginRegistry{
+			Deployment: Deployment{
+				Image:           server.PluginRegistryImage,
+				ImagePullPolicy: server.PluginRegistryPullPolicy,
+				Resources: ResourceRequirements{
+					Requests: ResourceList{Memory: server.PluginRegistryMemoryRequest, Cpu: server.PluginRegistryCpuRequest},
+					Limits:   ResourceList{Memory: server.PluginRegistryMemoryLimit, Cpu: server.PluginRegistryCpuLimit},
+				},
+			},
+			DisableInternalRegistry: server.ExternalPluginRegistry,
+			ExternalUrl:             server.PluginRegistryUrl,
+			Exposure: Exposure{
+				Ingress: IngressCustomSettings(server.PluginRegistryIngress),
+				Route:   RouteCustomSettings(server.PluginRegistryRoute),
+			},
+		},
+		DevfileRegistry: DevfileRegistry{
+			Deployment: Deployment{
+				Image:           server.DevfileRegistryImage,
+				ImagePullPolicy: server.DevfileRegistryPullPolicy,
+				Resources: ResourceRequirements{
+					Requests: ResourceList{Memory: server.DevfileRegistryMemoryRequest, Cpu: server.DevfileRegistryCpuRequest},
+					Limits:   ResourceList{Memory: server.DevfileRegistryMemoryLimit, Cpu: server.DevfileRegistryCpuLimit},
+				},
+			},
+			DisableInternalRegistry: server.ExternalDevfileRegistry,
+			ExternalUrl:             server.DevfileRegistryUrl,
+			Exposure: Exposure{
+				Ingress: IngressCustomSettings(server.DevfileRegistryIngress),
+				Route:   RouteCustomSettings(server.DevfileRegistryRoute),
+			},
+		},
+		Database: Database{
+			ExternalDb:            src.Database.ExternalDb,
+			PostgresHostName:      src.Database.ChePostgresHostName,
+			PostgresPort:          src.Database.ChePostgresPort,
+			PostgresDb:            src.Database.ChePostgresDb,
+			CredentialsSecretName: src.Database.ChePostgresSecret,
+			Deployment: Deployment{
+				Image:           src.Database.PostgresImage,
+				ImagePullPolicy: src.Database.PostgresImagePullPolicy,
+				Resources:       convertResourcesFrom(src.Database.ChePostgresContainerResources),
+			},
+			Pvc: PVC{StorageClassName: src.Storage.PostgresPVCStorageClassName},
+		},
+		IdentityProvider: IdentityProvider{
+			External:                      src.Auth.ExternalIdentityProvider,
+			Url:                           src.Auth.IdentityProviderURL,
+			Realm:                         src.Auth.IdentityProviderRealm,
+			ClientId:                      src.Auth.IdentityProviderClientId,
+			AdminCredentialsSecretName:    src.Auth.IdentityProviderSecret,
+			PostgresCredentialsSecretName: src.Auth.IdentityProviderPostgresSecret,
+			UpdateAdminPassword:           src.Auth.UpdateAdminPassword,
+			RealmConfig:                   (*RealmConfig)(src.Auth.IdentityProviderRealmConfig.DeepCopy()),
+			LdapUserFederation:            copyLdapUserFederation(src.Auth.LdapUserFederation),
+			OpenIdConnect:                 src.Auth.OpenIdConnect.DeepCopy(),
+			Deployment: Deployment{
+				Image:           src.Auth.IdentityProviderImage,
+				ImagePullPolicy: src.Auth.IdentityProviderImagePullPolicy,
+				Resources:       convertResourcesFrom(src.Auth.IdentityProviderContainerResources),
+			},
+			Exposure: Exposure{
+				Ingress: IngressCustomSettings(src.Auth.IdentityProviderIngress),
+				Route:   RouteCustomSettings(src.Auth.IdentityProviderRoute),
+			},
+		},
+		Gateway: Gateway{
+			Deployment:         Deployment{Image: server.SingleHostGatewayImage},
+			ConfigSidecarImage: server.SingleHostGatewayConfigSidecarImage,
+			ConfigLabels:       copyMap(server.SingleHostGatewayConfigMapLabels),
+			Authentication:     server.SingleHostGatewayAuthentication,
+		},
+		Metrics:      Metrics{Enable: src.Metrics.Enable},
+		ImagePuller:  ImagePuller{Enable: src.ImagePuller.Enable, Spec: src.ImagePuller.Spec},
+		DevWorkspace: DevWorkspace{Enable: src.DevWorkspace.Enable},
+	}
+
+	for component, deployment := range map[string]*Deployment{
+		cheServerComponent:        &dst.Components.CheServer.Deployment,
+		pluginRegistryComponent:   &dst.Components.PluginRegistry.Deployment,
+		devfileRegistryComponent:  &dst.Components.DevfileRegistry.Deployment,
+		databaseComponent:         &dst.Components.Database.Deployment,
+		identityProviderComponent: &dst.Components.IdentityProvider.Deployment,
+		gatewayComponent:          &dst.Components.Gateway.Deployment,
+	} {
+		if fields, ok := v2Fields.Deployments[component]; ok {
+			deployment.Env = fields.Env
+			if component != cheServerComponent {
+				deployment.SecurityContext = fields.SecurityContext
+			}
+		}
+	}
+}
+
+func convertResourcesTo(resources ResourceRequirements) orgv1.ResourcesCustomSettings {
+	return orgv1.ResourcesCustomSettings{
+		Requests: orgv1.Resources{Memory: resources.Requests.Memory, Cpu: resources.Requests.Cpu},
+		Limits:   orgv1.Resources{Memory: resources.Limits.Memory, Cpu: resources.Limits.Cpu},
+	}
+}
+
+func convertResourcesFrom(resources orgv1.ResourcesCustomSettings) ResourceRequirements {
+	return ResourceRequirements{
+		Requests: ResourceList{Memory: resources.Requests.Memory, Cpu: resources.Requests.Cpu},
+		Limits:   ResourceList{Memory: resources.Limits.Memory, Cpu: resources.Limits.Cpu},
+	}
+}
+
+// popAnnotation reads the json value of the annotation into the given object and removes the annotation.
+func popAnnotation(annotations *map[string]string, key string, value interface{}) error {
+	data, ok := (*annotations)[key]
+	if !ok {
+		return nil
+	}
+
+	delete(*annotations, key)
+	if len(*annotations) == 0 {
+		*annotations = nil
+	}
+
+	if err := json.Unmarshal([]byte(data), value); err != nil {
+		return fmt.Errorf("failed to read the '%s' annotation: %v", key, err)
+	}
+	return nil
+}
+
+// pushAnnotation stores the given object as the json value of the annotation.
+func pushAnnotation(annotations *map[string]string, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if *annotations == nil {
+		*annotations = map[string]string{}
+	}
+	(*annotations)[key] = string(data)
+	return nil
+}
+
+// formatInt returns the string representation of the value,
+// or the original v1 value if it wasn't a valid integer.
+func formatInt(value *int64, original string) string {
+	if value == nil {
+		return original
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+// parseInt parses the v1 value. If the value can't be restored from the parsed integer,
+// the original value is kept in the unparsed.
+func parseInt(value string, unparsed *string) *int64 {
+	if value == "" {
+		return nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || strconv.FormatInt(parsed, 10) != value {
+		*unparsed = value
+		return nil
+	}
+	return &parsed
+}
+
+func splitList(value string, separator string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, separator)
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+func copyBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	value := *b
+	return &value
+}
+
+func copyLdapUserFederation(providers []orgv1.LdapUserFederation) []orgv1.LdapUserFederation {
+	if providers == nil {
+		return nil
+	}
+	result := make([]orgv1.LdapUserFederation, len(providers))
+	for i := range providers {
+		providers[i].DeepCopyInto(&result[i])
+	}
+	return result
+}
@@ -0,0 +1,550 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v2
+
+import (
+	"strings"
+	"testing"
+
+	chev1alpha1 "github.com/che-incubator/kubernetes-image-puller-operator/pkg/apis/che/v1alpha1"
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRoundTripFromV1(t *testing.T) {
+	type testCase struct {
+		name       string
+		cheCluster *orgv1.CheCluster
+		expected   *orgv1.CheCluster
+	}
+
+	testCases := []testCase{
+		{
+			name: "empty",
+			cheCluster: &orgv1.CheCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "eclipse-che", Namespace: "eclipse-che"},
+			},
+		},
+		{
+			name:       "all fields set",
+			cheCluster: newV1CheCluster(),
+			// plain text credentials are moved into secrets by the Operator, v2 only references the secrets
+			expected: withoutPlaintextCredentials(newV1CheCluster()),
+		},
+		{
+			name: "non integer security context",
+			cheCluster: &orgv1.CheCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "eclipse-che", Namespace: "eclipse-che"},
+				Spec: orgv1.CheClusterSpec{
+					K8s: orgv1.CheClusterSpecK8SOnly{
+						SecurityContextFsGroup:   "01724",
+						SecurityContextRunAsUser: "user",
+					},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			v2CheCluster := &CheCluster{}
+			if err := v2CheCluster.ConvertFrom(testCase.cheCluster.DeepCopy()); err != nil {
+				t.Fatalf("Failed to convert from v1: %v", err)
+			}
+
+			v1CheCluster := &orgv1.CheCluster{}
+			if err := v2CheCluster.ConvertTo(v1CheCluster); err != nil {
+				t.Fatalf("Failed to convert to v1: %v", err)
+			}
+
+			expected := testCase.expected
+			if expected == nil {
+				expected = testCase.cheCluster
+			}
+			if diff := cmp.Diff(expected, v1CheCluster); diff != "" {
+				t.Errorf("Unexpected CheCluster after round trip (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRoundTripFromV2(t *testing.T) {
+	type testCase struct {
+		name       string
+		cheCluster *CheCluster
+	}
+
+	testCases := []testCase{
+		{
+			name: "empty",
+			cheCluster: &CheCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "eclipse-che", Namespace: "eclipse-che"},
+			},
+		},
+		{
+			name:       "all fields set",
+			cheCluster: newV2CheCluster(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			v1CheCluster := &orgv1.CheCluster{}
+			if err := testCase.cheCluster.DeepCopy().ConvertTo(v1CheCluster); err != nil {
+				t.Fatalf("Failed to convert to v1: %v", err)
+			}
+
+			v2CheCluster := &CheCluster{}
+			if err := v2CheCluster.ConvertFrom(v1CheCluster); err != nil {
+				t.Fatalf("Failed to convert from v1: %v", err)
+			}
+
+			if diff := cmp.Diff(testCase.cheCluster, v2CheCluster); diff != "" {
+				t.Errorf("Unexpected CheCluster after round trip (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertFromV1(t *testing.T) {
+	v2CheCluster := &CheCluster{}
+	if err := v2CheCluster.ConvertFrom(newV1CheCluster()); err != nil {
+		t.Fatalf("Failed to convert from v1: %v", err)
+	}
+
+	cheServer := v2CheCluster.Spec.Components.CheServer
+	if cheServer.Deployment.Image != "quay.io/eclipse/che-server" || cheServer.ImageTag != "next" {
+		t.Errorf("Unexpected Che server image: %s, tag: %s", cheServer.Deployment.Image, cheServer.ImageTag)
+	}
+	if cheServer.Deployment.Resources.Limits.Memory != "1Gi" {
+		t.Errorf("Unexpected Che server memory limit: %s", cheServer.Deployment.Resources.Limits.Memory)
+	}
+	if diff := cmp.Diff([]string{"role-1", "role-2"}, cheServer.ClusterRoles); diff != "" {
+		t.Errorf("Unexpected Che server cluster roles (-want, +got):\n%s", diff)
+	}
+	if cheServer.Deployment.SecurityContext == nil || *cheServer.Deployment.SecurityContext.FSGroup != 1724 {
+		t.Errorf("Unexpected Che server security context: %v", cheServer.Deployment.SecurityContext)
+	}
+	if v2CheCluster.Spec.Components.Database.CredentialsSecretName != "postgres-credentials" {
+		t.Errorf("Unexpected database credentials secret: %s", v2CheCluster.Spec.Components.Database.CredentialsSecretName)
+	}
+
+	// plain text credentials are not part of the v2 spec, and must not leak into annotations
+	for _, value := range v2CheCluster.Annotations {
+		for _, password := range []string{"pgche-password", "admin-password", "keycloak-postgres-password", "oauth-secret", "proxy-password"} {
+			if strings.Contains(value, password) {
+				t.Errorf("Annotations are not expected to contain plain text credentials: %v", v2CheCluster.Annotations)
+			}
+		}
+	}
+	if v2CheCluster.Annotations["custom"] != "value" {
+		t.Errorf("Custom annotation is expected to be kept")
+	}
+}
+
+func TestConvertToV1(t *testing.T) {
+	v1CheCluster := &orgv1.CheCluster{}
+	if err := newV2CheCluster().ConvertTo(v1CheCluster); err != nil {
+		t.Fatalf("Failed to convert to v1: %v", err)
+	}
+
+	server := v1CheCluster.Spec.Server
+	if server.PluginRegistryImage != "quay.io/eclipse/che-plugin-registry:next" || server.PluginRegistryCpuLimit != "500m" {
+		t.Errorf("Unexpected plugin registry image: %s, cpu limit: %s", server.PluginRegistryImage, server.PluginRegistryCpuLimit)
+	}
+	if server.NonProxyHosts != "localhost|.example.com" {
+		t.Errorf("Unexpected non proxy hosts: %s", server.NonProxyHosts)
+	}
+	if v1CheCluster.Spec.K8s.SecurityContextRunAsUser != "1724" {
+		t.Errorf("Unexpected security context run as user: %s", v1CheCluster.Spec.K8s.SecurityContextRunAsUser)
+	}
+	if v1CheCluster.Spec.Storage.PostgresPVCStorageClassName != "postgres-storage" {
+		t.Errorf("Unexpected postgres storage class: %s", v1CheCluster.Spec.Storage.PostgresPVCStorageClassName)
+	}
+
+	// environment variables have no v1 counterpart
+	if v1CheCluster.Annotations[V2FieldsAnnotation] == "" {
+		t.Errorf("Annotation %s is expected to keep environment variables", V2FieldsAnnotation)
+	}
+}
+
+// withoutPlaintextCredentials empties the credentials set in plain text in the v1 spec.
+func withoutPlaintextCredentials(cheCluster *orgv1.CheCluster) *orgv1.CheCluster {
+	cheCluster.Spec.Database.ChePostgresUser = ""
+	cheCluster.Spec.Database.ChePostgresPassword = ""
+	cheCluster.Spec.Auth.IdentityProviderAdminUserName = ""
+	cheCluster.Spec.Auth.IdentityProviderPassword = ""
+	cheCluster.Spec.Auth.IdentityProviderPostgresPassword = ""
+	cheCluster.Spec.Auth.OAuthSecret = ""
+	cheCluster.Spec.Server.ProxyUser = ""
+	cheCluster.Spec.Server.ProxyPassword = ""
+	return cheCluster
+}
+
+func newV1CheCluster() *orgv1.CheCluster {
+	trueValue := true
+	falseValue := false
+	accessTokenLifespan := int32(600)
+	fullSyncPeriod := int32(86400)
+	return &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "eclipse-che",
+			Namespace:   "eclipse-che",
+			Annotations: map[string]string{"custom": "value"},
+		},
+		Spec: orgv1.CheClusterSpec{
+			Server: orgv1.CheClusterSpecServer{
+				AirGapContainerRegistryHostname:     "registry.example.com",
+				AirGapContainerRegistryOrganization: "che",
+				CheImage:                            "quay.io/eclipse/che-server",
+				CheImageTag:                         "next",
+				CheImagePullPolicy:                  corev1.PullAlways,
+				CheFlavor:                           "che",
+				CheHost:                             "che.example.com",
+				CheHostTLSSecret:                    "che-tls",
+				CertManagerIssuer:                   &orgv1.CertManagerIssuer{Name: "letsencrypt", Kind: "ClusterIssuer"},
+				CheLogLevel:                         "DEBUG",
+				CheDebug:                            "true",
+				CheClusterRoles:                     "role-1,role-2",
+				CheWorkspaceClusterRole:             "workspace-role",
+				WorkspaceNamespaceDefault:           "<username>-che",
+				AllowUserDefinedWorkspaceNamespaces: true,
+				SelfSignedCert:                      true,
+				ServerTrustStoreConfigMapName:       "truststore",
+				GitSelfSignedCert:                   true,
+				TlsSupport:                          true,
+				UseInternalClusterSVCNames:          true,
+				DevfileRegistryUrl:                  "https://devfile-registry.example.com",
+				DevfileRegistryImage:                "quay.io/eclipse/che-devfile-registry:next",
+				DevfileRegistryPullPolicy:           corev1.PullIfNotPresent,
+				DevfileRegistryMemoryLimit:          "256Mi",
+				DevfileRegistryMemoryRequest:        "16Mi",
+				DevfileRegistryCpuLimit:             "500m",
+				DevfileRegistryCpuRequest:           "100m",
+				DevfileRegistryIngress:              orgv1.IngressCustomSettings{Labels: "a=b"},
+				DevfileRegistryRoute:                orgv1.RouteCustomSettings{Labels: "c=d", Domain: "devfile.example.com"},
+				ExternalDevfileRegistry:             true,
+				PluginRegistryUrl:                   "https://plugin-registry.example.com",
+				PluginRegistryImage:                 "quay.io/eclipse/che-plugin-registry:next",
+				PluginRegistryPullPolicy:            corev1.PullNever,
+				PluginRegistryMemoryLimit:           "256Mi",
+				PluginRegistryMemoryRequest:         "16Mi",
+				PluginRegistryCpuLimit:              "500m",
+				PluginRegistryCpuRequest:            "100m",
+				PluginRegistryIngress:               orgv1.IngressCustomSettings{Labels: "e=f"},
+				PluginRegistryRoute:                 orgv1.RouteCustomSettings{Labels: "g=h", Domain: "plugin.example.com"},
+				ExternalPluginRegistry:              true,
+				CustomCheProperties:                 map[string]string{"CHE_LIMITS_USER_WORKSPACES_COUNT": "1"},
+				ProxyURL:                            "http://proxy.example.com",
+				ProxyPort:                           "3128",
+				NonProxyHosts:                       "localhost|.example.com",
+				ProxyUser:                           "proxy-user",
+				ProxyPassword:                       "proxy-password",
+				ProxySecret:                         "proxy-credentials",
+				ServerMemoryRequest:                 "512Mi",
+				ServerMemoryLimit:                   "1Gi",
+				ServerCpuLimit:                      "1",
+				ServerCpuRequest:                    "100m",
+				ServerExposureStrategy:              "single-host",
+				SingleHostGatewayImage:              "quay.io/eclipse/che--traefik:next",
+				SingleHostGatewayConfigSidecarImage: "quay.io/che-incubator/configbump:next",
+				SingleHostGatewayConfigMapLabels:    map[string]string{"app": "che"},
+				SingleHostGatewayAuthentication: orgv1.SingleHostGatewayAuthentication{
+					Enable:           true,
+					ClientSecretName: "gateway-client",
+					CookieExpire:     "12h",
+				},
+				CheServerIngress:                    orgv1.IngressCustomSettings{Labels: "i=j"},
+				CheServerRoute:                      orgv1.RouteCustomSettings{Labels: "k=l", Domain: "che.example.com"},
+			},
+			Database: orgv1.CheClusterSpecDB{
+				ExternalDb:              true,
+				ChePostgresHostName:     "postgres.example.com",
+				ChePostgresPort:         "5432",
+				ChePostgresUser:         "pgche",
+				ChePostgresPassword:     "pgche-password",
+				ChePostgresDb:           "dbche",
+				ChePostgresSecret:       "postgres-credentials",
+				PostgresImage:           "centos/postgresql-96-centos7:9.6",
+				PostgresImagePullPolicy: corev1.PullAlways,
+				ChePostgresContainerResources: orgv1.ResourcesCustomSettings{
+					Requests: orgv1.Resources{Memory: "256Mi", Cpu: "100m"},
+					Limits:   orgv1.Resources{Memory: "1Gi", Cpu: "500m"},
+				},
+			},
+			Auth: orgv1.CheClusterSpecAuth{
+				InitialOpenShiftOAuthUser:        &trueValue,
+				ExternalIdentityProvider:         true,
+				IdentityProviderURL:              "https://keycloak.example.com",
+				IdentityProviderAdminUserName:    "admin",
+				IdentityProviderPassword:         "admin-password",
+				IdentityProviderSecret:           "keycloak-credentials",
+				IdentityProviderRealm:            "che",
+				IdentityProviderClientId:         "che-public",
+				IdentityProviderRealmConfig: &orgv1.IdentityProviderRealmConfig{
+					AccessTokenLifespan: &accessTokenLifespan,
+					PasswordPolicy:      "length(8)",
+					ConfigMap:           "realm-config",
+				},
+				LdapUserFederation: []orgv1.LdapUserFederation{{
+					Name:                  "ad",
+					Vendor:                "ad",
+					ConnectionURL:         "ldaps://ad.example.com:636",
+					BindCredentialsSecret: "ad-credentials",
+					UsersDN:               "ou=users,dc=example,dc=com",
+					FullSyncPeriod:        &fullSyncPeriod,
+					GroupMappers:          []orgv1.LdapGroupMapper{{Name: "groups", GroupsDN: "ou=groups,dc=example,dc=com"}},
+				}},
+				OpenIdConnect: &orgv1.OpenIdConnectProvider{
+					IssuerURL:     "https://sso.example.com/realms/che",
+					ClientId:      "che",
+					UsernameClaim: "preferred_username",
+				},
+				IdentityProviderPostgresPassword: "keycloak-postgres-password",
+				IdentityProviderPostgresSecret:   "keycloak-postgres-credentials",
+				UpdateAdminPassword:              true,
+				OpenShiftoAuth:                   &falseValue,
+				OAuthClientName:                  "che-oauth",
+				OAuthSecret:                      "oauth-secret",
+				IdentityProviderImage:            "quay.io/eclipse/che-keycloak:next",
+				IdentityProviderImagePullPolicy:  corev1.PullIfNotPresent,
+				IdentityProviderIngress:          orgv1.IngressCustomSettings{Labels: "m=n"},
+				IdentityProviderRoute:            orgv1.RouteCustomSettings{Labels: "o=p", Domain: "keycloak.example.com"},
+				IdentityProviderContainerResources: orgv1.ResourcesCustomSettings{
+					Requests: orgv1.Resources{Memory: "512Mi", Cpu: "100m"},
+					Limits:   orgv1.Resources{Memory: "2Gi", Cpu: "2"},
+				},
+			},
+			Storage: orgv1.CheClusterSpecStorage{
+				PvcStrategy:                  "per-workspace",
+				PvcClaimSize:                 "2Gi",
+				PreCreateSubPaths:            true,
+				PvcJobsImage:                 "registry.access.redhat.com/ubi8-minimal:8.3",
+				PostgresPVCStorageClassName:  "postgres-storage",
+				WorkspacePVCStorageClassName: "workspace-storage",
+			},
+			Metrics: orgv1.CheClusterSpecMetrics{Enable: true},
+			K8s: orgv1.CheClusterSpecK8SOnly{
+				IngressDomain:            "example.com",
+				IngressStrategy:          "multi-host",
+				IngressClass:             "nginx",
+				TlsSecretName:            "che-tls",
+				GatewayAPIParentRef:      &orgv1.GatewayAPIParentRef{Name: "che-gateway", Namespace: "gateways"},
+				SecurityContextFsGroup:   "1724",
+				SecurityContextRunAsUser: "1724",
+				SingleHostExposureType:   "gateway",
+			},
+			ImagePuller: orgv1.CheClusterSpecImagePuller{
+				Enable: true,
+				Spec:   chev1alpha1.KubernetesImagePullerSpec{DeploymentName: "kubernetes-image-puller"},
+			},
+			DevWorkspace: orgv1.CheClusterSpecDevWorkspace{Enable: true},
+		},
+		Status: orgv1.CheClusterStatus{
+			CheClusterRunning: "Available",
+			CheURL:            "https://che.example.com",
+			Conditions: []orgv1.CheClusterCondition{
+				{Type: orgv1.ConditionReady, Status: metav1.ConditionTrue, Reason: "Reconciled"},
+			},
+		},
+	}
+}
+
+func newV2CheCluster() *CheCluster {
+	trueValue := true
+	user := int64(1724)
+	group := int64(1725)
+	accessTokenLifespan := int32(600)
+	fullSyncPeriod := int32(86400)
+	return &CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "eclipse-che",
+			Namespace:   "eclipse-che",
+			Annotations: map[string]string{"custom": "value"},
+		},
+		Spec: CheClusterSpec{
+			Components: CheClusterComponents{
+				CheServer: CheServer{
+					Deployment: Deployment{
+						Image:           "quay.io/eclipse/che-server",
+						ImagePullPolicy: corev1.PullAlways,
+						Resources: ResourceRequirements{
+							Requests: ResourceList{Memory: "512Mi", Cpu: "100m"},
+							Limits:   ResourceList{Memory: "1Gi", Cpu: "1"},
+						},
+						Env:             []corev1.EnvVar{{Name: "JAVA_OPTS", Value: "-Xmx1g"}},
+						SecurityContext: &PodSecurityContext{RunAsUser: &user, FSGroup: &group},
+					},
+					ImageTag:                   "next",
+					Flavor:                     "che",
+					LogLevel:                   "DEBUG",
+					Debug:                      "true",
+					ClusterRoles:               []string{"role-1", "role-2"},
+					SelfSignedCert:             true,
+					GitSelfSignedCert:          true,
+					TrustStoreConfigMapName:    "truststore",
+					UseInternalClusterSVCNames: true,
+					ExtraProperties:            map[string]string{"CHE_LIMITS_USER_WORKSPACES_COUNT": "1"},
+					Proxy: Proxy{
+						Url:                   "http://proxy.example.com",
+						Port:                  "3128",
+						NonProxyHosts:         []string{"localhost", ".example.com"},
+						CredentialsSecretName: "proxy-credentials",
+					},
+					Exposure: Exposure{
+						Ingress: IngressCustomSettings{Labels: "a=b"},
+						Route:   RouteCustomSettings{Labels: "c=d", Domain: "che.example.com"},
+					},
+				},
+				PluginRegistry: PluginRegistry{
+					Deployment: Deployment{
+						Image:           "quay.io/eclipse/che-plugin-registry:next",
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Resources: ResourceRequirements{
+							Requests: ResourceList{Memory: "16Mi", Cpu: "100m"},
+							Limits:   ResourceList{Memory: "256Mi", Cpu: "500m"},
+						},
+						SecurityContext: &PodSecurityContext{RunAsUser: &user},
+					},
+					DisableInternalRegistry: true,
+					ExternalUrl:             "https://plugin-registry.example.com",
+					Exposure: Exposure{
+						Ingress: IngressCustomSettings{Labels: "e=f"},
+						Route:   RouteCustomSettings{Labels: "g=h", Domain: "plugin.example.com"},
+					},
+				},
+				DevfileRegistry: DevfileRegistry{
+					Deployment: Deployment{
+						Image: "quay.io/eclipse/che-devfile-registry:next",
+						Env:   []corev1.EnvVar{{Name: "CHE_DEVFILE_REGISTRY_URL", Value: "https://devfile-registry.example.com"}},
+					},
+					DisableInternalRegistry: true,
+					ExternalUrl:             "https://devfile-registry.example.com",
+				},
+				Database: Database{
+					ExternalDb:            true,
+					PostgresHostName:      "postgres.example.com",
+					PostgresPort:          "5432",
+					PostgresDb:            "dbche",
+					CredentialsSecretName: "postgres-credentials",
+					Deployment: Deployment{
+						Image: "centos/postgresql-96-centos7:9.6",
+						Resources: ResourceRequirements{
+							Limits: ResourceList{Memory: "1Gi"},
+						},
+						Env: []corev1.EnvVar{{
+							Name: "POSTGRESQL_ADMIN_PASSWORD",
+							ValueFrom: &corev1.EnvVarSource{
+								SecretKeyRef: &corev1.SecretKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "postgres-admin"},
+									Key:                  "password",
+								},
+							},
+						}},
+					},
+					Pvc: PVC{StorageClassName: "postgres-storage"},
+				},
+				IdentityProvider: IdentityProvider{
+					External:                      true,
+					Url:                           "https://keycloak.example.com",
+					Realm:                         "che",
+					ClientId:                      "che-public",
+					AdminCredentialsSecretName:    "keycloak-credentials",
+					PostgresCredentialsSecretName: "keycloak-postgres-credentials",
+					UpdateAdminPassword:           true,
+					RealmConfig: &RealmConfig{
+						AccessTokenLifespan: &accessTokenLifespan,
+						PasswordPolicy:      "length(8)",
+						ConfigMap:           "realm-config",
+					},
+					LdapUserFederation: []orgv1.LdapUserFederation{{
+						Name:                  "ad",
+						Vendor:                "ad",
+						ConnectionURL:         "ldaps://ad.example.com:636",
+						BindCredentialsSecret: "ad-credentials",
+						UsersDN:               "ou=users,dc=example,dc=com",
+						FullSyncPeriod:        &fullSyncPeriod,
+						GroupMappers:          []orgv1.LdapGroupMapper{{Name: "groups", GroupsDN: "ou=groups,dc=example,dc=com"}},
+					}},
+					OpenIdConnect: &orgv1.OpenIdConnectProvider{
+						IssuerURL:     "https://sso.example.com/realms/che",
+						ClientId:      "che",
+						UsernameClaim: "preferred_username",
+					},
+					Deployment: Deployment{
+						Image:           "quay.io/eclipse/che-keycloak:next",
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Resources: ResourceRequirements{
+							Requests: ResourceList{Memory: "512Mi", Cpu: "100m"},
+							Limits:   ResourceList{Memory: "2Gi", Cpu: "2"},
+						},
+					},
+					Exposure: Exposure{
+						Route: RouteCustomSettings{Domain: "keycloak.example.com"},
+					},
+				},
+				Gateway: Gateway{
+					Deployment:         Deployment{Image: "quay.io/eclipse/che--traefik:next"},
+					ConfigSidecarImage: "quay.io/che-incubator/configbump:next",
+					ConfigLabels:       map[string]string{"app": "che"},
+					Authentication: orgv1.SingleHostGatewayAuthentication{
+						Enable:           true,
+						ClientSecretName: "gateway-client",
+						CookieExpire:     "12h",
+					},
+				},
+				Metrics:      Metrics{Enable: true},
+				ImagePuller:  ImagePuller{Enable: true, Spec: chev1alpha1.KubernetesImagePullerSpec{DeploymentName: "kubernetes-image-puller"}},
+				DevWorkspace: DevWorkspace{Enable: true},
+			},
+			Networking: CheClusterSpecNetworking{
+				Hostname:               "che.example.com",
+				HostnameTlsSecretName:  "che-tls",
+				CertManagerIssuer:      &orgv1.CertManagerIssuer{Name: "letsencrypt", Kind: "ClusterIssuer"},
+				TlsSupport:             true,
+				ExposureStrategy:       "single-host",
+				SingleHostExposureType: "gateway",
+				Domain:                 "example.com",
+				IngressClass:           "nginx",
+				TlsSecretName:          "che-tls",
+				GatewayAPIParentRef:    &orgv1.GatewayAPIParentRef{Name: "che-gateway", Namespace: "gateways"},
+				Auth: Auth{
+					OpenShiftOAuth:            &trueValue,
+					InitialOpenShiftOAuthUser: &trueValue,
+					OAuthClientName:           "che-oauth",
+				},
+			},
+			DevEnvironments: CheClusterDevEnvironments{
+				DefaultNamespace:           "<username>-che",
+				AllowUserDefinedNamespaces: true,
+				ClusterRole:                "workspace-role",
+				Storage: WorkspaceStorage{
+					PvcStrategy:       "per-workspace",
+					ClaimSize:         "2Gi",
+					StorageClassName:  "workspace-storage",
+					PreCreateSubPaths: true,
+					PvcJobsImage:      "registry.access.redhat.com/ubi8-minimal:8.3",
+				},
+			},
+			ContainerRegistry: CheClusterContainerRegistry{
+				Hostname:     "registry.example.com",
+				Organization: "che",
+			},
+		},
+		Status: orgv1.CheClusterStatus{
+			CheClusterRunning: "Available",
+			CheURL:            "https://che.example.com",
+		},
+	}
+}
@@ -0,0 +1,531 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package v2
+
+// Important: the `v1` API version remains the storage version, see `checluster_conversion.go`.
+// The CRD schema of this version is maintained manually in `deploy/crds/org_v1_che_crd.yaml`.
+
+import (
+	chev1alpha1 "github.com/che-incubator/kubernetes-image-puller-operator/pkg/apis/che/v1alpha1"
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:openapi-gen=true
+// Desired configuration of the Che installation.
+// Settings are grouped per Che component.
+// Credentials are never set in plain text, but are referenced by the names of Secrets.
+type CheClusterSpec struct {
+	// Configuration of the Che components.
+	// +optional
+	Components CheClusterComponents `json:"components"`
+	// Networking, exposure and authentication configuration.
+	// +optional
+	Networking CheClusterSpecNetworking `json:"networking"`
+	// Development environments (workspaces) configuration.
+	// +optional
+	DevEnvironments CheClusterDevEnvironments `json:"devEnvironments"`
+	// Configuration of an alternative registry that stores Che images, used in restricted environments.
+	// +optional
+	ContainerRegistry CheClusterContainerRegistry `json:"containerRegistry"`
+}
+
+// +k8s:openapi-gen=true
+// Configuration of the Che components.
+type CheClusterComponents struct {
+	// Che server configuration.
+	// +optional
+	CheServer CheServer `json:"cheServer"`
+	// Plugin registry configuration.
+	// +optional
+	PluginRegistry PluginRegistry `json:"pluginRegistry"`
+	// Devfile registry configuration.
+	// +optional
+	DevfileRegistry DevfileRegistry `json:"devfileRegistry"`
+	// PostgreSQL database configuration.
+	// +optional
+	Database Database `json:"database"`
+	// Identity provider (Keycloak or RH-SSO) configuration.
+	// +optional
+	IdentityProvider IdentityProvider `json:"identityProvider"`
+	// Single-host gateway configuration.
+	// +optional
+	Gateway Gateway `json:"gateway"`
+	// Metrics collection configuration.
+	// +optional
+	Metrics Metrics `json:"metrics"`
+	// Kubernetes Image Puller configuration.
+	// +optional
+	ImagePuller ImagePuller `json:"imagePuller"`
+	// Dev Workspace operator configuration.
+	// +optional
+	DevWorkspace DevWorkspace `json:"devWorkspace"`
+}
+
+// +k8s:openapi-gen=true
+// Deployment settings which are common to all the Che components.
+type Deployment struct {
+	// Container image. Omit it or leave it empty to use the default container image provided by the Operator.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Image pull policy. Default value is `Always` for `nightly` or `latest` images, and `IfNotPresent` in other cases.
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// Compute resources required by the container.
+	// +optional
+	Resources ResourceRequirements `json:"resources,omitempty"`
+	// List of environment variables to set in the container.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Security options the pod should run with.
+	// +optional
+	SecurityContext *PodSecurityContext `json:"securityContext,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Compute resources required by the container.
+type ResourceRequirements struct {
+	// Describes the minimum amount of compute resources required.
+	// +optional
+	Requests ResourceList `json:"requests,omitempty"`
+	// Describes the maximum amount of compute resources allowed.
+	// +optional
+	Limits ResourceList `json:"limits,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// List of resources.
+type ResourceList struct {
+	// Memory, in bytes. (500Gi = 500GiB = 500 * 1024 * 1024 * 1024)
+	// +optional
+	Memory string `json:"memory,omitempty"`
+	// CPU, in cores. (500m = .5 cores)
+	// +optional
+	Cpu string `json:"cpu,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Security options the pod should run with.
+type PodSecurityContext struct {
+	// The UID to run the entrypoint of the container process.
+	// +optional
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// A special supplemental group that applies to all containers in a pod.
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Customization of the Ingress or Route the component is exposed with.
+type Exposure struct {
+	// Ingress custom settings.
+	// +optional
+	Ingress IngressCustomSettings `json:"ingress,omitempty"`
+	// Route custom settings.
+	// +optional
+	Route RouteCustomSettings `json:"route,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Ingress custom settings.
+type IngressCustomSettings struct {
+	// Comma separated list of labels that can be used to organize and categorize objects by scoping and selecting.
+	// +optional
+	Labels string `json:"labels,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Route custom settings.
+type RouteCustomSettings struct {
+	// Comma separated list of labels that can be used to organize and categorize objects by scoping and selecting.
+	// +optional
+	Labels string `json:"labels,omitempty"`
+	// OpenShift Route domain. Ignored on Kubernetes.
+	// +optional
+	Domain string `json:"domain,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Che server configuration.
+type CheServer struct {
+	// Deployment override options.
+	// +optional
+	Deployment Deployment `json:"deployment,omitempty"`
+	// Overrides the tag of the container image. Omit it or leave it empty to use the default tag.
+	// +optional
+	ImageTag string `json:"imageTag,omitempty"`
+	// Specifies a variation of the installation. The options are `che` for upstream Che installations,
+	// or `codeready` for CodeReady Workspaces installation. The field can't be changed once set.
+	// +optional
+	Flavor string `json:"flavor,omitempty"`
+	// The log level for the Che server: `INFO` or `DEBUG`. Defaults to `INFO`.
+	// +optional
+	LogLevel string `json:"logLevel,omitempty"`
+	// Enables the debug mode for Che server. Defaults to `false`.
+	// +optional
+	Debug string `json:"debug,omitempty"`
+	// ClusterRoles assigned to the Che ServiceAccount.
+	// +optional
+	ClusterRoles []string `json:"clusterRoles,omitempty"`
+	// Enables the support of OpenShift clusters whose router uses self-signed certificates.
+	// +optional
+	SelfSignedCert bool `json:"selfSignedCert"`
+	// When enabled, the certificate from `che-git-self-signed-cert` ConfigMap will be propagated
+	// to the Che components and provide particular configuration for Git.
+	// +optional
+	GitSelfSignedCert bool `json:"gitSelfSignedCert"`
+	// Name of the ConfigMap with public certificates to add to Java trust store of the Che server.
+	// +optional
+	TrustStoreConfigMapName string `json:"trustStoreConfigMapName,omitempty"`
+	// Instructs the Operator to deploy Che with internal cluster SVC names rather than public URLs
+	// to communicate between components.
+	// +optional
+	UseInternalClusterSVCNames bool `json:"useInternalClusterSVCNames"`
+	// Map of additional environment variables that will be applied in the generated `che` ConfigMap
+	// to be used by the Che server, in addition to the values already generated from other fields.
+	// +optional
+	ExtraProperties map[string]string `json:"extraProperties,omitempty"`
+	// Proxy server settings.
+	// +optional
+	Proxy Proxy `json:"proxy,omitempty"`
+	// Customization of the Che server Ingress or Route.
+	// +optional
+	Exposure Exposure `json:"exposure,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Proxy server configuration.
+type Proxy struct {
+	// URL (protocol+host name) of the proxy server. Only use when configuring a proxy is required.
+	// +optional
+	Url string `json:"url,omitempty"`
+	// Port of the proxy server.
+	// +optional
+	Port string `json:"port,omitempty"`
+	// List of hosts that will be reached directly, bypassing the proxy.
+	// +optional
+	NonProxyHosts []string `json:"nonProxyHosts,omitempty"`
+	// The Secret that contains `user` and `password` for the proxy server.
+	// +optional
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Plugin registry configuration.
+type PluginRegistry struct {
+	// Deployment override options.
+	// +optional
+	Deployment Deployment `json:"deployment,omitempty"`
+	// Disables internal plugin registry.
+	// +optional
+	DisableInternalRegistry bool `json:"disableInternalRegistry"`
+	// Public URL of the external plugin registry. Only used when the internal registry is disabled.
+	// +optional
+	ExternalUrl string `json:"externalUrl,omitempty"`
+	// Customization of the plugin registry Ingress or Route.
+	// +optional
+	Exposure Exposure `json:"exposure,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Devfile registry configuration.
+type DevfileRegistry struct {
+	// Deployment override options.
+	// +optional
+	Deployment Deployment `json:"deployment,omitempty"`
+	// Disables internal devfile registry.
+	// +optional
+	DisableInternalRegistry bool `json:"disableInternalRegistry"`
+	// Public URL of the external devfile registry. Only used when the internal registry is disabled.
+	// +optional
+	ExternalUrl string `json:"externalUrl,omitempty"`
+	// Customization of the devfile registry Ingress or Route.
+	// +optional
+	Exposure Exposure `json:"exposure,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// PostgreSQL database configuration.
+type Database struct {
+	// Instructs the Operator to connect to an external database instead of deploying a dedicated one.
+	// +optional
+	ExternalDb bool `json:"externalDb"`
+	// PostgreSQL Database host name that the Che server uses to connect to.
+	// +optional
+	PostgresHostName string `json:"postgresHostName,omitempty"`
+	// PostgreSQL Database port that the Che server uses to connect to.
+	// +optional
+	PostgresPort string `json:"postgresPort,omitempty"`
+	// PostgreSQL database name that the Che server uses to connect to the DB.
+	// +optional
+	PostgresDb string `json:"postgresDb,omitempty"`
+	// The Secret that contains PostgreSQL `user` and `password` that the Che server uses to connect to the DB.
+	// +optional
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+	// Deployment override options.
+	// +optional
+	Deployment Deployment `json:"deployment,omitempty"`
+	// PostgreSQL persistent volume claim settings.
+	// +optional
+	Pvc PVC `json:"pvc,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Persistent volume claim settings.
+type PVC struct {
+	// Storage class for the persistent volume claim.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Identity provider (Keycloak or RH-SSO) configuration.
+type IdentityProvider struct {
+	// Instructs the Operator to use an external identity provider instead of deploying a dedicated one.
+	// +optional
+	External bool `json:"external"`
+	// Public URL of the identity provider server.
+	// +optional
+	Url string `json:"url,omitempty"`
+	// Name of a identity provider realm that is used for Che.
+	// +optional
+	Realm string `json:"realm,omitempty"`
+	// Name of a identity provider client-id that is used for Che.
+	// +optional
+	ClientId string `json:"clientId,omitempty"`
+	// The Secret that contains `user` and `password` for the identity provider admin.
+	// +optional
+	AdminCredentialsSecretName string `json:"adminCredentialsSecretName,omitempty"`
+	// The Secret that contains `password` for the identity provider database.
+	// +optional
+	PostgresCredentialsSecretName string `json:"postgresCredentialsSecretName,omitempty"`
+	// Forces the default `admin` Che user to update password on first login.
+	// +optional
+	UpdateAdminPassword bool `json:"updateAdminPassword"`
+	// Declarative configuration of the realm and the Che client, continuously reconciled by the Operator.
+	// +optional
+	RealmConfig *RealmConfig `json:"realmConfig,omitempty"`
+	// LDAP, or Active Directory, user federation providers of the realm.
+	// +optional
+	LdapUserFederation []orgv1.LdapUserFederation `json:"ldapUserFederation,omitempty"`
+	// Generic OpenID Connect provider used instead of the identity provider.
+	// When set, neither the identity provider nor its database is deployed.
+	// +optional
+	OpenIdConnect *orgv1.OpenIdConnectProvider `json:"openIdConnect,omitempty"`
+	// Deployment override options.
+	// +optional
+	Deployment Deployment `json:"deployment,omitempty"`
+	// Customization of the identity provider Ingress or Route.
+	// +optional
+	Exposure Exposure `json:"exposure,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Declarative configuration of the identity provider realm and the Che client.
+type RealmConfig struct {
+	// Maximum time, in seconds, before an access token expires.
+	// +optional
+	AccessTokenLifespan *int32 `json:"accessTokenLifespan,omitempty"`
+	// Time, in seconds, a login session is allowed to be idle before it expires.
+	// +optional
+	SsoSessionIdleTimeout *int32 `json:"ssoSessionIdleTimeout,omitempty"`
+	// Maximum time, in seconds, before a login session expires.
+	// +optional
+	SsoSessionMaxLifespan *int32 `json:"ssoSessionMaxLifespan,omitempty"`
+	// Password policy of the realm in the Keycloak format.
+	// +optional
+	PasswordPolicy string `json:"passwordPolicy,omitempty"`
+	// The ConfigMap with a partial realm representation under the `realm.json` key,
+	// and a partial Che client representation under the `client.json` key.
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Single-host gateway configuration.
+type Gateway struct {
+	// Deployment override options.
+	// +optional
+	Deployment Deployment `json:"deployment,omitempty"`
+	// The image used for the gateway sidecar that provides configuration.
+	// +optional
+	ConfigSidecarImage string `json:"configSidecarImage,omitempty"`
+	// The labels that need to be present in the ConfigMaps representing the gateway configuration.
+	// +optional
+	ConfigLabels map[string]string `json:"configLabels,omitempty"`
+	// Authentication of the requests by an OAuth2 proxy deployed along with the gateway.
+	// +optional
+	Authentication orgv1.SingleHostGatewayAuthentication `json:"authentication,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Metrics collection configuration.
+type Metrics struct {
+	// Enables `metrics` the Che server endpoint.
+	// +optional
+	Enable bool `json:"enable"`
+}
+
+// +k8s:openapi-gen=true
+// Kubernetes Image Puller configuration.
+type ImagePuller struct {
+	// Install and configure the Community Supported Kubernetes Image Puller Operator.
+	// +optional
+	Enable bool `json:"enable"`
+	// A KubernetesImagePullerSpec to configure the image puller in the CheCluster.
+	// +optional
+	Spec chev1alpha1.KubernetesImagePullerSpec `json:"spec"`
+}
+
+// +k8s:openapi-gen=true
+// Dev Workspace operator configuration.
+type DevWorkspace struct {
+	// Deploys the DevWorkspace Operator in the cluster.
+	// +optional
+	Enable bool `json:"enable"`
+}
+
+// +k8s:openapi-gen=true
+// Networking, exposure and authentication configuration.
+type CheClusterSpecNetworking struct {
+	// Public host name of the installed Che server.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+	// Name of a Secret containing certificates to secure the custom host name of the Che server.
+	// +optional
+	HostnameTlsSecretName string `json:"hostnameTlsSecretName,omitempty"`
+	// Reference to a cert-manager `Issuer` or `ClusterIssuer` which issues the certificates of the Che endpoints.
+	// +optional
+	CertManagerIssuer *orgv1.CertManagerIssuer `json:"certManagerIssuer,omitempty"`
+	// Deprecated. Instructs the Operator to deploy Che in TLS mode.
+	// +optional
+	TlsSupport bool `json:"tlsSupport"`
+	// Strategy for ingress creation: `multi-host`, `single-host` or `default-host`.
+	// +optional
+	ExposureStrategy string `json:"exposureStrategy,omitempty"`
+	// When the exposure strategy is `single-host`, the way the server, registries and workspaces
+	// are exposed can be configured: `native` or `gateway`.
+	// +optional
+	SingleHostExposureType string `json:"singleHostExposureType,omitempty"`
+	// Global ingress domain for a Kubernetes cluster.
+	// +optional
+	Domain string `json:"domain,omitempty"`
+	// Deprecated, use `exposureStrategy` instead.
+	// +optional
+	IngressStrategy string `json:"ingressStrategy,omitempty"`
+	// Ingress class that will define the which controller will manage ingresses. Defaults to `nginx`.
+	// +optional
+	IngressClass string `json:"ingressClass,omitempty"`
+	// Name of a Secret that will be used to setup ingress TLS termination on Kubernetes.
+	// +optional
+	TlsSecretName string `json:"tlsSecretName,omitempty"`
+	// Gateway API `Gateway` the Che endpoints are exposed through with `HTTPRoute` objects, instead of ingresses.
+	// +optional
+	GatewayAPIParentRef *orgv1.GatewayAPIParentRef `json:"gatewayAPIParentRef,omitempty"`
+	// Authentication configuration.
+	// +optional
+	Auth Auth `json:"auth"`
+}
+
+// +k8s:openapi-gen=true
+// Authentication configuration.
+type Auth struct {
+	// Enables the integration of the identity provider with OpenShift OAuth.
+	// +optional
+	OpenShiftOAuth *bool `json:"openShiftOAuth,omitempty"`
+	// For operating with the OpenShift OAuth authentication, create a new user account
+	// since the kubeadmin can not be used.
+	// +optional
+	InitialOpenShiftOAuthUser *bool `json:"initialOpenShiftOAuthUser,omitempty"`
+	// Name of the OpenShift `OAuthClient` resource used to setup identity federation on the OpenShift side.
+	// +optional
+	OAuthClientName string `json:"oAuthClientName,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Development environments (workspaces) configuration.
+type CheClusterDevEnvironments struct {
+	// Defines Kubernetes default namespace in which user's workspaces are created.
+	// +optional
+	DefaultNamespace string `json:"defaultNamespace,omitempty"`
+	// Defines that a user is allowed to specify a Kubernetes namespace different from the default.
+	// +optional
+	AllowUserDefinedNamespaces bool `json:"allowUserDefinedNamespaces"`
+	// Custom cluster role bound to the user for the Che workspaces.
+	// +optional
+	ClusterRole string `json:"clusterRole,omitempty"`
+	// Workspaces persistent storage.
+	// +optional
+	Storage WorkspaceStorage `json:"storage,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Workspaces persistent storage.
+type WorkspaceStorage struct {
+	// Persistent volume claim strategy for the Che server: `common`, `unique` or `per-workspace`.
+	// +optional
+	PvcStrategy string `json:"pvcStrategy,omitempty"`
+	// Size of the persistent volume claim for workspaces. Defaults to `1Gi`.
+	// +optional
+	ClaimSize string `json:"claimSize,omitempty"`
+	// Storage class for the persistent volume claims of workspaces.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// Instructs the Che server to start a special Pod to pre-create a sub-path in the Persistent Volumes.
+	// +optional
+	PreCreateSubPaths bool `json:"preCreateSubPaths"`
+	// Overrides the container image used to create sub-paths in the Persistent Volumes.
+	// +optional
+	PvcJobsImage string `json:"pvcJobsImage,omitempty"`
+}
+
+// +k8s:openapi-gen=true
+// Alternative registry that stores Che images.
+type CheClusterContainerRegistry struct {
+	// Optional host name, or URL, to an alternate container registry to pull images from.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+	// Optional repository name of an alternate container registry to pull images from.
+	// +optional
+	Organization string `json:"organization,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// The `CheCluster` custom resource allows defining and managing a Che server installation
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+type CheCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Desired configuration of the Che installation.
+	Spec CheClusterSpec `json:"spec,omitempty"`
+
+	// CheClusterStatus defines the observed state of Che installation.
+	// It is the same in all the API versions.
+	Status orgv1.CheClusterStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CheClusterList contains a list of CheCluster
+type CheClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CheCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CheCluster{}, &CheClusterList{})
+}
@@ -0,0 +1,15 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+// Package v2 contains API Schema definitions for the org v2 API group
+// +k8s:deepcopy-gen=package,register
+// +groupName=org.eclipse.che
+package v2
@@ -13,21 +13,15 @@ package che
 
 import (
 	"context"
-	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 
 	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"github.com/eclipse-che/che-operator/pkg/deploy"
-	devworkspace "github.com/eclipse-che/che-operator/pkg/deploy/dev-workspace"
-	devfile_registry "github.com/eclipse-che/che-operator/pkg/deploy/devfile-registry"
 	"github.com/eclipse-che/che-operator/pkg/deploy/gateway"
 	identity_provider "github.com/eclipse-che/che-operator/pkg/deploy/identity-provider"
-	plugin_registry "github.com/eclipse-che/che-operator/pkg/deploy/plugin-registry"
-	"github.com/eclipse-che/che-operator/pkg/deploy/postgres"
-	"github.com/eclipse-che/che-operator/pkg/deploy/server"
+	"github.com/eclipse-che/che-operator/pkg/metrics"
 	"github.com/eclipse-che/che-operator/pkg/util"
 	configv1 "github.com/openshift/api/config/v1"
 	oauthv1 "github.com/openshift/api/config/v1"
@@ -43,9 +37,11 @@ import (
 	rbac "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -80,12 +76,14 @@ func newReconciler(mgr manager.Manager) (reconcile.Reconciler, error) {
 		return nil, err
 	}
 	return &ReconcileChe{
-		client:            mgr.GetClient(),
-		nonCachedClient:   noncachedClient,
-		scheme:            mgr.GetScheme(),
-		discoveryClient:   discoveryClient,
-		userHandler:       NewOpenShiftOAuthUserHandler(noncachedClient),
-		permissionChecker: &K8sApiPermissionChecker{},
+		client:             mgr.GetClient(),
+		nonCachedClient:    noncachedClient,
+		scheme:             mgr.GetScheme(),
+		discoveryClient:    discoveryClient,
+		userHandler:        NewOpenShiftOAuthUserHandler(noncachedClient),
+		permissionChecker:  &K8sApiPermissionChecker{},
+		credentialsRotator: &ClientCredentialsRotator{},
+		recorder:           mgr.GetEventRecorderFor("che-operator"),
 	}, nil
 }
 
@@ -201,6 +199,19 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	var toRealmConfigMapRequestMapper handler.ToRequestsFunc = func(obj handler.MapObject) []reconcile.Request {
+		isRealmConfigMap, reconcileRequest := isRealmConfigMap(mgr, obj)
+		if isRealmConfigMap {
+			return []reconcile.Request{reconcileRequest}
+		}
+		return []reconcile.Request{}
+	}
+	if err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: toRealmConfigMapRequestMapper,
+	}, onAllExceptGenericEventsPredicate); err != nil {
+		return err
+	}
+
 	var toEclipseCheSecretRequestMapper handler.ToRequestsFunc = func(obj handler.MapObject) []reconcile.Request {
 		isEclipseCheSecret, reconcileRequest := isEclipseCheSecret(mgr, obj)
 		if isEclipseCheSecret {
@@ -247,7 +258,22 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 			return err
 		}
 	} else {
-		err = c.Watch(&source.Kind{Type: &v1beta1.Ingress{}}, &handler.EnqueueRequestForOwner{
+		// extensions/v1beta1 ingresses are not served anymore since Kubernetes 1.22
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+		if err != nil {
+			return err
+		}
+		isNetworkingV1Ingress, err := deploy.IsNetworkingV1IngressServed(discoveryClient)
+		if err != nil {
+			return err
+		}
+		var ingress runtime.Object = &v1beta1.Ingress{}
+		if isNetworkingV1Ingress {
+			networkingV1Ingress := &unstructured.Unstructured{}
+			networkingV1Ingress.SetGroupVersionKind(deploy.NetworkingV1IngressGVK)
+			ingress = networkingV1Ingress
+		}
+		err = c.Watch(&source.Kind{Type: ingress}, &handler.EnqueueRequestForOwner{
 			IsController: true,
 			OwnerType:    &orgv1.CheCluster{},
 		})
@@ -300,6 +326,10 @@ type ReconcileChe struct {
 	tests             bool
 	userHandler       OpenShiftOAuthUserHandler
 	permissionChecker PermissionChecker
+	// Applies the new credentials when they are rotated
+	credentialsRotator CredentialsRotator
+	// Records events regarding CheCluster custom resource
+	recorder record.EventRecorder
 }
 
 const (
@@ -320,7 +350,7 @@ const (
 // Reconcile reads that state of the cluster for a CheCluster object and makes changes based on the state read
 // and what is in the CheCluster.Spec. The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
-func (r *ReconcileChe) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileChe) Reconcile(request reconcile.Request) (result reconcile.Result, err error) {
 	clusterAPI := deploy.ClusterAPI{
 		Client:          r.client,
 		NonCachedClient: r.nonCachedClient,
@@ -328,7 +358,6 @@ func (r *ReconcileChe) Reconcile(request reconcile.Request) (reconcile.Result, e
 		Scheme:          r.scheme,
 	}
 	// Fetch the CheCluster instance
-	tests := r.tests
 	instance, err := r.GetCR(request)
 
 	if err != nil {
@@ -336,9 +365,32 @@ func (r *ReconcileChe) Reconcile(request reconcile.Request) (reconcile.Result, e
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			metrics.DeleteCheCluster(request.Namespace, request.Name)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
+		metrics.RecordRequeue(metrics.RequeueError)
+		return reconcile.Result{}, err
+	}
+
+	// Record the state of Che installation, as reported by the status once reconciled, and the requeues due to errors
+	defer func() {
+		metrics.SetCheClusterRunning(instance.Namespace, instance.Name, instance.Status.CheClusterRunning)
+		if err != nil {
+			metrics.RecordRequeue(metrics.RequeueError)
+		}
+	}()
+
+	// Nothing is changed in the cluster while the reconciliation is paused, so that the objects patched by hand,
+	// e.g. during an incident, are not reverted. The CR is finalized nevertheless once it is deleted,
+	// otherwise the deletion would hang on the finalizers until the reconciliation is resumed.
+	if deploy.IsReconcilePaused(instance) {
+		if instance.ObjectMeta.DeletionTimestamp.IsZero() {
+			logrus.Infof("Reconciliation of %s CR is paused", instance.Name)
+			return reconcile.Result{}, r.SetPausedCondition(instance, true)
+		}
+		logrus.Infof("Reconciliation of %s CR is paused, but the CR is deleted and is finalized", instance.Name)
+	} else if err := r.SetPausedCondition(instance, false); err != nil {
 		return reconcile.Result{}, err
 	}
 
@@ -346,705 +398,150 @@ func (r *ReconcileChe) Reconcile(request reconcile.Request) (reconcile.Result, e
 		ClusterAPI:      clusterAPI,
 		CheCluster:      instance,
 		InternalService: deploy.InternalService{},
+		EventRecorder:   r.recorder,
 	}
 
-	// Reconcile finalizers before CR is deleted
-	r.reconcileFinalizers(deployContext)
-
-	// Reconcile the imagePuller section of the CheCluster
-	imagePullerResult, err := deploy.ReconcileImagePuller(deployContext)
-	if err != nil {
-		return imagePullerResult, err
-	}
-	if imagePullerResult.Requeue || imagePullerResult.RequeueAfter > 0 {
-		return imagePullerResult, err
+	// The Operator never writes defaults to the spec, they are applied in memory only.
+	// The values written by former versions of the Operator are removed first.
+	if err := deploy.RemoveLegacySpecValues(deployContext); err != nil {
+		return reconcile.Result{}, err
 	}
+	deploy.SetCheClusterDefaults(instance)
+	deploy.SetCheClusterStatusDefaults(instance)
 
 	isOpenShift, isOpenShift4, err := util.DetectOpenShift()
 	if err != nil {
 		logrus.Errorf("An error occurred when detecting current infra: %s", err)
 	}
 
-	// Check Che CR correctness
-	if err := ValidateCheCR(instance, isOpenShift); err != nil {
-		// Che cannot be deployed with current configuration.
-		// Print error message in logs and wait until the configuration is changed.
-		logrus.Error(err)
-		if err := r.SetStatusDetails(instance, request, failedValidationReason, err.Error(), ""); err != nil {
-			return reconcile.Result{}, err
-		}
-		return reconcile.Result{}, nil
-	}
+	reconcileManager := r.getReconcileManager(request, isOpenShift, isOpenShift4)
 
-	if !util.IsTestMode() {
-		if isOpenShift && deployContext.DefaultCheHost == "" {
-			host, err := getDefaultCheHost(deployContext)
-			if host == "" {
-				return reconcile.Result{RequeueAfter: 1 * time.Second}, err
-			}
-			deployContext.DefaultCheHost = host
+	// Clean up the objects which are not garbage collected before the CR is deleted
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !reconcileManager.FinalizeAll(deployContext) {
+			return requeueShortly(metrics.RequeueFinalization, nil)
 		}
-	}
-
-	if isOpenShift4 && util.IsDeleteOAuthInitialUser(instance) {
-		if err := r.userHandler.DeleteOAuthInitialUser(deployContext); err != nil {
-			logrus.Errorf("Unable to delete initial OpenShift OAuth user from a cluster. Cause: %s", err.Error())
-			instance.Spec.Auth.InitialOpenShiftOAuthUser = nil
-			err := r.UpdateCheCRSpec(instance, "initialOpenShiftOAuthUser", "nil")
-			return reconcile.Result{}, err
-		}
-
-		instance.Spec.Auth.OpenShiftoAuth = nil
-		instance.Spec.Auth.InitialOpenShiftOAuthUser = nil
-		updateFields := map[string]string{
-			"openShiftoAuth":            "nil",
-			"initialOpenShiftOAuthUser": "nil",
-		}
-
-		if err := r.UpdateCheCRSpecByFields(instance, updateFields); err != nil {
-			return reconcile.Result{}, err
-		}
-
 		return reconcile.Result{}, nil
 	}
 
-	if instance.Spec.Auth.InitialOpenShiftOAuthUser == nil && instance.Status.OpenShiftOAuthUserCredentialsSecret != "" {
-		secret, err := deploy.GetSecret(deployContext, openShiftOAuthUserCredentialsSecret, instance.Namespace)
-		if secret == nil {
-			if err == nil {
-				instance.Status.OpenShiftOAuthUserCredentialsSecret = ""
-				if err := r.UpdateCheCRStatus(instance, "openShiftOAuthUserCredentialsSecret", ""); err != nil {
-					return reconcile.Result{}, err
-				}
-			} else {
-				return reconcile.Result{}, err
-			}
-		}
-	}
-
-	if isOpenShift && instance.Spec.Auth.OpenShiftoAuth == nil {
-		if reconcileResult, err := r.autoEnableOAuth(deployContext, request, isOpenShift4); err != nil {
-			return reconcileResult, err
-		}
-	}
-
-	// Reconcile Dev Workspace Operator
-	done, err := devworkspace.ReconcileDevWorkspace(deployContext)
-	if !done {
-		if err != nil {
-			logrus.Error(err)
-		}
-		// We should `Requeue` since we don't watch Dev Workspace controller objects
-		return reconcile.Result{RequeueAfter: time.Second}, err
-	}
-
-	// Read proxy configuration
-	proxy, err := r.getProxyConfiguration(instance)
-	if err != nil {
-		logrus.Errorf("Error on reading proxy configuration: %v", err)
-		return reconcile.Result{}, err
-	}
-	// Assign Proxy to the deploy context
-	deployContext.Proxy = proxy
-
-	if proxy.TrustedCAMapName != "" {
-		provisioned, err := r.putOpenShiftCertsIntoConfigMap(deployContext)
-		if !provisioned {
-			configMapName := instance.Spec.Server.ServerTrustStoreConfigMapName
-			if err != nil {
-				logrus.Errorf("Error on provisioning config map '%s': %v", configMapName, err)
-			} else {
-				logrus.Infof("Waiting on provisioning config map '%s'", configMapName)
-			}
-			return reconcile.Result{}, err
-		}
-	}
-
-	cheFlavor := deploy.DefaultCheFlavor(instance)
-	cheDeploymentName := cheFlavor
-
-	// Detect whether self-signed certificate is used
-	selfSignedCertUsed, err := deploy.IsSelfSignedCertificateUsed(deployContext)
-	if err != nil {
-		logrus.Errorf("Failed to detect if self-signed certificate used. Cause: %v", err)
-		return reconcile.Result{}, err
-	}
-
-	if isOpenShift {
-		// create a secret with router tls cert when on OpenShift infra and router is configured with a self signed certificate
-		if selfSignedCertUsed ||
-			// To use Openshift v4 OAuth, the OAuth endpoints are served from a namespace
-			// and NOT from the Openshift API Master URL (as in v3)
-			// So we also need the self-signed certificate to access them (same as the Che server)
-			(isOpenShift4 && util.IsOAuthEnabled(instance) && !instance.Spec.Server.TlsSupport) {
-			if err := deploy.CreateTLSSecretFromEndpoint(deployContext, "", deploy.CheTLSSelfSignedCertificateSecretName); err != nil {
-				return reconcile.Result{}, err
-			}
-		}
-
-		if !tests {
-			deployment := &appsv1.Deployment{}
-			err = r.client.Get(context.TODO(), types.NamespacedName{Name: cheDeploymentName, Namespace: instance.Namespace}, deployment)
-			if err != nil && instance.Status.CheClusterRunning != UnavailableStatus {
-				if err := r.SetCheUnavailableStatus(instance, request); err != nil {
-					return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 1}, err
-				}
-			}
-		}
-
-		if util.IsOAuthEnabled(instance) {
-			// create a secret with OpenShift API crt to be added to keystore that RH SSO will consume
-			baseURL, err := util.GetClusterPublicHostname(isOpenShift4)
-			if err != nil {
-				logrus.Errorf("Failed to get OpenShift cluster public hostname. A secret with API crt will not be created and consumed by RH-SSO/Keycloak")
-			} else {
-				if err := deploy.CreateTLSSecretFromEndpoint(deployContext, baseURL, "openshift-api-crt"); err != nil {
-					return reconcile.Result{}, err
-				}
-			}
-		}
-	} else {
-		// Handle Che TLS certificates on Kubernetes infrastructure
-		if instance.Spec.Server.TlsSupport {
-			if instance.Spec.K8s.TlsSecretName != "" {
-				// Self-signed certificate should be created to secure Che ingresses
-				result, err := deploy.K8sHandleCheTLSSecrets(deployContext)
-				if result.Requeue || result.RequeueAfter > 0 {
-					if err != nil {
-						logrus.Error(err)
-					}
-					if !tests {
-						return result, err
-					}
-				}
-			} else if selfSignedCertUsed {
-				// Use default self-signed ingress certificate
-				if err := deploy.CreateTLSSecretFromEndpoint(deployContext, "", deploy.CheTLSSelfSignedCertificateSecretName); err != nil {
-					return reconcile.Result{}, err
-				}
-			}
-		}
-	}
-
-	// Make sure that CA certificates from all marked config maps are merged into single config map to be propageted to Che components
-	done, err = deploy.SyncAdditionalCACertsConfigMapToCluster(deployContext)
-	if err != nil {
-		logrus.Errorf("Error updating additional CA config map: %v", err)
-		return reconcile.Result{}, err
+	// Only plan the changes when they are to be previewed
+	if deploy.IsDryRun(instance) {
+		return r.planChanges(deployContext, request, isOpenShift, isOpenShift4)
 	}
-	if !done && !tests {
-		// Config map update is in progress
-		// Return and do not force reconcile. When update finishes it will trigger reconcile loop.
+	// The plan of a previous dry run is outdated once the changes are applied
+	if _, err := deploy.DeleteNamespacedObject(deployContext, deploy.DryRunPlanConfigMapName, &corev1.ConfigMap{}); err != nil {
 		return reconcile.Result{}, err
 	}
 
-	// Get custom ConfigMap
-	// if it exists, add the data into CustomCheProperties
-	customConfigMap := &corev1.ConfigMap{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: "custom"}, customConfigMap)
-	if err != nil && !errors.IsNotFound(err) {
-		logrus.Errorf("Error getting custom configMap: %v", err)
-		return reconcile.Result{}, err
-	}
-	if err == nil {
-		logrus.Infof("Found legacy custom ConfigMap.  Adding those values to CheCluster.Spec.Server.CustomCheProperties")
-		if instance.Spec.Server.CustomCheProperties == nil {
-			instance.Spec.Server.CustomCheProperties = make(map[string]string)
-		}
-		for k, v := range customConfigMap.Data {
-			instance.Spec.Server.CustomCheProperties[k] = v
-		}
-		if err := r.client.Update(context.TODO(), instance); err != nil {
-			logrus.Errorf("Error updating CheCluster: %v", err)
-			return reconcile.Result{}, err
-		}
-		if err = r.client.Delete(context.TODO(), customConfigMap); err != nil {
-			logrus.Errorf("Error deleting legacy custom ConfigMap: %v", err)
-			return reconcile.Result{}, err
-		}
-		return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
-	}
-
-	// If the devfile-registry ConfigMap exists, and we are not in airgapped mode, delete the ConfigMap
-	devfileRegistryConfigMap := &corev1.ConfigMap{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: deploy.DevfileRegistryName}, devfileRegistryConfigMap)
-	if err != nil && !errors.IsNotFound(err) {
-		logrus.Errorf("Error getting devfile-registry ConfigMap: %v", err)
-		return reconcile.Result{}, err
-	}
-	if err == nil && instance.Spec.Server.ExternalDevfileRegistry {
-		logrus.Info("Found devfile-registry ConfigMap and while using an external devfile registry.  Deleting.")
-		if err = r.client.Delete(context.TODO(), devfileRegistryConfigMap); err != nil {
-			logrus.Errorf("Error deleting devfile-registry ConfigMap: %v", err)
+	// Check Che CR correctness
+	if err := ValidateCheCR(instance, isOpenShift); err != nil {
+		// Che cannot be deployed with current configuration.
+		// Print error message in logs and wait until the configuration is changed.
+		logrus.Error(err)
+		r.recordEvent(instance, corev1.EventTypeWarning, InvalidConfigurationEventReason, err.Error())
+		if err := r.SetStatusDetails(instance, request, failedValidationReason, err.Error(), ""); err != nil {
 			return reconcile.Result{}, err
 		}
-		return reconcile.Result{Requeue: true}, nil
-	}
-
-	// If the plugin-registry ConfigMap exists, and we are not in airgapped mode, delete the ConfigMap
-	pluginRegistryConfigMap := &corev1.ConfigMap{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: deploy.PluginRegistryName}, pluginRegistryConfigMap)
-	if err != nil && !errors.IsNotFound(err) {
-		logrus.Errorf("Error getting plugin-registry ConfigMap: %v", err)
-		return reconcile.Result{}, err
-	}
-	if err == nil && !instance.IsAirGapMode() {
-		logrus.Info("Found plugin-registry ConfigMap and not in airgap mode.  Deleting.")
-		if err = r.client.Delete(context.TODO(), pluginRegistryConfigMap); err != nil {
-			logrus.Errorf("Error deleting plugin-registry ConfigMap: %v", err)
+		if err := r.SetDegradedCondition(instance, failedValidationReason, err.Error()); err != nil {
 			return reconcile.Result{}, err
 		}
-		return reconcile.Result{Requeue: true}, nil
+		return reconcile.Result{}, nil
 	}
-
 	if err := r.SetStatusDetails(instance, request, "", "", ""); err != nil {
 		return reconcile.Result{}, err
 	}
 
-	// Create service account "che" for che-server component.
-	// "che" is the one which token is used to create workspace objects.
-	// Notice: Also we have on more "che-workspace" SA used by plugins like exec, terminal, metrics with limited privileges.
-	cheSA, err := deploy.SyncServiceAccountToCluster(deployContext, CheServiceAccountName)
-	if cheSA == nil {
-		logrus.Info("Waiting on service account 'che' to be created")
-		if err != nil {
-			logrus.Error(err)
-		}
-		if !tests {
-			return reconcile.Result{RequeueAfter: time.Second}, err
-		}
-	}
-
-	if !util.IsOAuthEnabled(instance) && !util.IsWorkspaceInSameNamespaceWithChe(instance) {
-		сheWorkspacesClusterRoleName := fmt.Sprintf(CheWorkspacesClusterRoleNameTemplate, instance.Namespace)
-		exists, err := deploy.Get(deployContext, types.NamespacedName{Name: сheWorkspacesClusterRoleName}, &rbac.ClusterRole{})
-		if err != nil {
-			logrus.Error(err)
-			return reconcile.Result{RequeueAfter: time.Second}, err
-		}
-		if !exists {
-			policies := append(getCheWorkspacesNamespacePolicy(), getCheWorkspacesPolicy()...)
-			deniedRules, err := r.permissionChecker.GetNotPermittedPolicyRules(policies, "")
-			if err != nil {
-				logrus.Error(err)
-				return reconcile.Result{RequeueAfter: time.Second}, err
-			}
-			// fall back to the "narrower" workspace namespace strategy
-			if len(deniedRules) > 0 {
-				logrus.Warnf("Not enough permissions to start a workspace in dedicated namespace. Denied policies: %v", deniedRules)
-				logrus.Warnf("Fall back to '%s' namespace for workspaces.", instance.Namespace)
-				delete(instance.Spec.Server.CustomCheProperties, "CHE_INFRA_KUBERNETES_NAMESPACE_DEFAULT")
-				instance.Spec.Server.WorkspaceNamespaceDefault = instance.Namespace
-				err := r.UpdateCheCRSpec(instance, "Default namespace for workspaces", instance.Namespace)
-				if err != nil {
-					logrus.Error(err)
-					return reconcile.Result{RequeueAfter: time.Second * 1}, err
-				}
-			} else {
-				reconcileResult, err := r.delegateWorkspacePermissionsInTheDifferNamespaceThanChe(deployContext)
-				if err != nil {
-					logrus.Error(err)
-					return reconcile.Result{RequeueAfter: time.Second}, err
-				}
-				if reconcileResult.Requeue {
-					return reconcileResult, err
-				}
-			}
-		}
-	}
-
-	if util.IsOAuthEnabled(instance) || util.IsWorkspaceInSameNamespaceWithChe(instance) {
-		reconcile, err := r.delegateWorkspacePermissionsInTheSameNamespaceWithChe(deployContext)
-		if err != nil {
-			logrus.Error(err)
-		}
-		if reconcile.Requeue && !tests {
-			return reconcile, err
-		}
+	if done, err := r.initDeployContext(deployContext, isOpenShift); !done {
+		return requeueShortly(metrics.RequeueInitialization, err)
 	}
 
-	if len(instance.Spec.Server.CheClusterRoles) > 0 {
-		cheClusterRoles := strings.Split(instance.Spec.Server.CheClusterRoles, ",")
-		for _, cheClusterRole := range cheClusterRoles {
-			cheClusterRole := strings.TrimSpace(cheClusterRole)
-			cheClusterRoleBindingName := deploy.GetUniqueClusterRoleBindingName(deployContext, CheServiceAccountName, cheClusterRole)
-			done, err := deploy.SyncClusterRoleBindingAndAddFinalizerToCluster(deployContext, cheClusterRoleBindingName, CheServiceAccountName, cheClusterRole)
-			if !tests {
-				if !done {
-					logrus.Infof("Waiting on cluster role binding '%s' to be created", cheClusterRoleBindingName)
-					if err != nil {
-						logrus.Error(err)
-					}
-					return reconcile.Result{RequeueAfter: time.Second}, err
-				}
-			}
-		}
+	// Reconcile the components of Che installation one after the other
+	done, err := reconcileManager.ReconcileAll(deployContext)
+	if !done {
+		return requeueShortly(metrics.RequeueComponentNotReady, err)
 	}
 
-	// If the user specified an additional cluster role to use for the Che workspace, create a role binding for it
-	// Use a role binding instead of a cluster role binding to keep the additional access scoped to the workspace's namespace
-	workspaceClusterRole := instance.Spec.Server.CheWorkspaceClusterRole
-	if workspaceClusterRole != "" {
-		cheWSCustomRoleBinding, err := deploy.SyncRoleBindingToCluster(deployContext, "che-workspace-custom", "view", workspaceClusterRole, "ClusterRole")
-		if cheWSCustomRoleBinding == nil {
-			logrus.Info("Waiting on role binding 'che-workspace-custom' to be created")
-			if err != nil {
-				logrus.Error(err)
-			}
-			if !tests {
-				return reconcile.Result{RequeueAfter: time.Second}, err
-			}
-		}
+	if err := r.SetReadyCondition(instance); err != nil {
+		return reconcile.Result{}, err
 	}
+	metrics.SetLastSuccessfulReconcile(instance.Namespace, instance.Name, time.Now())
 
-	if err := r.GenerateAndSaveFields(deployContext, request); err != nil {
-		instance, _ = r.GetCR(request)
-		return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 1}, err
+	// reconcile again when the credentials are due to be rotated, to detect drift of the realm configuration,
+	// and when the TLS certificates are due to be renewed
+	requeueAfter := getShortestRequeueDelay(
+		GetCredentialsRotationRequeueDelay(instance),
+		identity_provider.GetRealmSyncRequeueDelay(instance),
+		deploy.GetTLSCertificatesRequeueDelay(instance))
+	if requeueAfter > 0 {
+		metrics.RecordRequeue(metrics.RequeueScheduled)
 	}
-	cheMultiUser := deploy.GetCheMultiUser(instance)
-
-	if cheMultiUser == "false" {
-		done, err := deploy.SyncPVCToCluster(deployContext, deploy.DefaultCheVolumeClaimName, "1Gi", cheFlavor)
-		if !tests {
-			if !done {
-				logrus.Infof("Waiting on pvc '%s' to be bound. Sometimes PVC can be bound only when the first consumer is created.", deploy.DefaultCheVolumeClaimName)
-				if err != nil {
-					logrus.Error(err)
-				}
-				return reconcile.Result{}, err
-			}
-		}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
 
-		done, err = deploy.DeleteNamespacedObject(deployContext, deploy.DefaultPostgresVolumeClaimName, &corev1.PersistentVolumeClaim{})
-		if !tests {
-			if !done {
-				if err != nil {
-					logrus.Error(err)
-				}
-				return reconcile.Result{}, err
-			}
-		}
-	} else {
-		done, err := deploy.DeleteNamespacedObject(deployContext, deploy.DefaultCheVolumeClaimName, &corev1.PersistentVolumeClaim{})
-		if !tests {
-			if !done {
-				if err != nil {
-					logrus.Error(err)
-				}
-				return reconcile.Result{}, err
-			}
-		}
+// requeueShortly requeues the reconcile to wait on the reason. The requeues due to an error are recorded as such.
+func requeueShortly(reason string, err error) (reconcile.Result, error) {
+	if err == nil {
+		metrics.RecordRequeue(reason)
 	}
+	return reconcile.Result{RequeueAfter: time.Second}, err
+}
 
-	// Create Postgres resources and provisioning unless an external DB is used
-	externalDB := instance.Spec.Database.ExternalDb
-	if !externalDB {
-		if cheMultiUser == "false" {
-			done, err := deploy.Delete(deployContext, types.NamespacedName{Name: deploy.PostgresName, Namespace: instance.Namespace}, &appsv1.Deployment{})
-			if !tests {
-				if !done {
-					if err != nil {
-						logrus.Error(err)
-					}
-					return reconcile.Result{}, err
-				}
-			}
-		} else {
-			// Create a new postgres service
-			serviceStatus := deploy.SyncServiceToCluster(deployContext, deploy.PostgresName, []string{deploy.PostgresName}, []int32{5432}, deploy.PostgresName)
-			if !tests {
-				if !serviceStatus.Continue {
-					logrus.Info("Waiting on service 'postgres' to be ready")
-					if serviceStatus.Err != nil {
-						logrus.Error(serviceStatus.Err)
-					}
-
-					return reconcile.Result{Requeue: serviceStatus.Requeue}, serviceStatus.Err
-				}
-			}
-
-			// Create a new Postgres PVC object
-			done, err := deploy.SyncPVCToCluster(deployContext, deploy.DefaultPostgresVolumeClaimName, "1Gi", deploy.PostgresName)
-			if !tests {
-				if !done {
-					logrus.Infof("Waiting on pvc '%s' to be bound. Sometimes PVC can be bound only when the first consumer is created.", deploy.DefaultPostgresVolumeClaimName)
-					if err != nil {
-						logrus.Error(err)
-					}
-
-					return reconcile.Result{}, err
-				}
-			}
-
-			// Create a new Postgres deployment
-			provisioned, err := postgres.SyncPostgresDeploymentToCluster(deployContext)
-			if !tests {
-				if !provisioned {
-					logrus.Infof("Waiting on deployment '%s' to be ready", deploy.PostgresName)
-					if err != nil {
-						logrus.Error(err)
-					}
-
-					return reconcile.Result{}, err
-				}
-			}
+// initDeployContext detects the capabilities of the cluster and prepares the CheCluster to be deployed.
+// Returns false if the reconcile has to be requeued.
+func (r *ReconcileChe) initDeployContext(deployContext *deploy.DeployContext, isOpenShift bool) (bool, error) {
+	instance := deployContext.CheCluster
 
-			if !tests {
-				identityProviderPostgresPassword := instance.Spec.Auth.IdentityProviderPostgresPassword
-				identityProviderPostgresSecret := instance.Spec.Auth.IdentityProviderPostgresSecret
-				if len(identityProviderPostgresSecret) > 0 {
-					_, password, err := util.K8sclient.ReadSecret(identityProviderPostgresSecret, instance.Namespace)
-					if err != nil {
-						logrus.Errorf("Failed to read '%s' secret: %s", identityProviderPostgresSecret, err)
-						return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 5}, err
-					}
-					identityProviderPostgresPassword = password
-				}
-				dbStatus := instance.Status.DbProvisoned
-				// provision Db and users for Che and Keycloak servers
-				if !dbStatus {
-					_, err := util.K8sclient.ExecIntoPod(
-						instance,
-						deploy.PostgresName,
-						func(cr *orgv1.CheCluster) (string, error) {
-							return identity_provider.GetPostgresProvisionCommand(identityProviderPostgresPassword), nil
-						},
-						"create Keycloak DB, user, privileges")
-					if err == nil {
-						for {
-							instance.Status.DbProvisoned = true
-							if err := r.UpdateCheCRStatus(instance, "status: provisioned with DB and user", "true"); err != nil &&
-								errors.IsConflict(err) {
-								instance, _ = r.GetCR(request)
-								continue
-							}
-							break
-						}
-					} else {
-						return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 5}, err
-					}
-				}
-			}
-		}
+	// Detect whether the endpoints certificates can be issued by cert-manager
+	if err := deploy.DetectCertManager(deployContext); err != nil {
+		logrus.Errorf("Error detecting cert-manager: %v", err)
+		return false, err
 	}
 
-	tlsSupport := instance.Spec.Server.TlsSupport
-	protocol := "http"
-	if tlsSupport {
-		protocol = "https"
+	// Detect the API version of the ingresses
+	if err := deploy.DetectNetworkingV1Ingress(deployContext); err != nil {
+		logrus.Errorf("Error detecting the Ingress API: %v", err)
+		return false, err
 	}
 
-	// create Che service and route
-	serviceStatus := server.SyncCheServiceToCluster(deployContext)
-	if !tests {
-		if !serviceStatus.Continue {
-			logrus.Infof("Waiting on service '%s' to be ready", deploy.CheServiceName)
-			if serviceStatus.Err != nil {
-				logrus.Error(serviceStatus.Err)
-			}
-
-			return reconcile.Result{Requeue: serviceStatus.Requeue}, serviceStatus.Err
-		}
+	// Detect whether the endpoints can be exposed with the Gateway API
+	if err := deploy.DetectGatewayAPI(deployContext); err != nil {
+		logrus.Errorf("Error detecting the Gateway API: %v", err)
+		return false, err
 	}
 
-	deployContext.InternalService.CheHost = fmt.Sprintf("http://%s.%s.svc:8080", deploy.CheServiceName, deployContext.CheCluster.Namespace)
-
-	exposedServiceName := getServerExposingServiceName(instance)
-	cheHost := ""
-	if !isOpenShift {
-		ingress, err := deploy.SyncIngressToCluster(
-			deployContext,
-			cheFlavor,
-			instance.Spec.Server.CheHost,
-			exposedServiceName,
-			8080,
-			deployContext.CheCluster.Spec.Server.CheServerIngress,
-			cheFlavor)
-		if !tests {
-			if ingress == nil {
-				logrus.Infof("Waiting on ingress '%s' to be ready", cheFlavor)
-				if err != nil {
-					logrus.Error(err)
-				}
-
-				return reconcile.Result{RequeueAfter: time.Second * 1}, err
-			}
-			cheHost = ingress.Spec.Rules[0].Host
-		}
-	} else {
-		customHost := instance.Spec.Server.CheHost
-		if deployContext.DefaultCheHost == customHost {
-			// let OpenShift set a hostname by itself since it requires a routes/custom-host permissions
-			customHost = ""
-		}
-
-		route, err := deploy.SyncRouteToCluster(
-			deployContext,
-			cheFlavor,
-			customHost,
-			exposedServiceName,
-			8080,
-			deployContext.CheCluster.Spec.Server.CheServerRoute,
-			cheFlavor)
-		if route == nil {
-			logrus.Infof("Waiting on route '%s' to be ready", cheFlavor)
-			if err != nil {
-				logrus.Error(err)
-			}
-
-			return reconcile.Result{RequeueAfter: time.Second * 1}, err
-		}
-		cheHost = route.Spec.Host
-		if customHost == "" {
-			deployContext.DefaultCheHost = cheHost
-		}
-	}
-	if instance.Spec.Server.CheHost != cheHost {
-		instance.Spec.Server.CheHost = cheHost
-		if err := r.UpdateCheCRSpec(instance, "CheHost URL", cheHost); err != nil {
-			instance, _ = r.GetCR(request)
-			return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 1}, err
+	// Move the passwords set in plain text in the spec into secrets
+	migrated, err := deploy.MigratePlaintextCredentials(deployContext)
+	if len(migrated) > 0 {
+		if err := r.SetCredentialsMigratedCondition(instance, migrated); err != nil {
+			return false, err
 		}
 	}
-
-	// create and provision Keycloak related objects
-	provisioned, err := identity_provider.SyncIdentityProviderToCluster(deployContext)
-	if !tests {
-		if !provisioned {
-			if err != nil {
-				logrus.Errorf("Error provisioning the identity provider to cluster: %v", err)
-			}
-			return reconcile.Result{}, err
-		}
-	}
-
-	provisioned, err = devfile_registry.SyncDevfileRegistryToCluster(deployContext, cheHost)
-	if !tests {
-		if !provisioned {
-			if err != nil {
-				logrus.Errorf("Error provisioning '%s' to cluster: %v", deploy.DevfileRegistryName, err)
-			}
-			return reconcile.Result{RequeueAfter: time.Second * 1}, err
-		}
-	}
-
-	provisioned, err = plugin_registry.SyncPluginRegistryToCluster(deployContext, cheHost)
-	if !tests {
-		if !provisioned {
-			if err != nil {
-				logrus.Errorf("Error provisioning '%s' to cluster: %v", deploy.PluginRegistryName, err)
-			}
-			return reconcile.Result{RequeueAfter: time.Second * 1}, err
-		}
-	}
-
-	// create Che ConfigMap which is synced with CR and is not supposed to be manually edited
-	// controller will reconcile this CM with CR spec
-	done, err = server.SyncCheConfigMapToCluster(deployContext)
-	if !tests {
-		if !done {
-			logrus.Infof("Waiting on config map '%s' to be created", server.CheConfigMapName)
-			if err != nil {
-				logrus.Error(err)
-			}
-			return reconcile.Result{}, err
-		}
-	}
-
-	err = gateway.SyncGatewayToCluster(deployContext)
 	if err != nil {
-		logrus.Errorf("Failed to create the Server Gateway: %s", err)
-		return reconcile.Result{}, err
+		logrus.Errorf("Failed to move credentials into secrets: %v", err)
+		return false, err
 	}
 
-	// Create a new che deployment
-	provisioned, err = server.SyncCheDeploymentToCluster(deployContext)
-	if !tests {
-		if !provisioned {
-			logrus.Infof("Waiting on deployment '%s' to be ready", cheFlavor)
-			if err != nil {
-				logrus.Error(err)
-			}
-
-			cheDeployment := &appsv1.Deployment{}
-			exists, err := deploy.GetNamespacedObject(deployContext, cheFlavor, cheDeployment)
-			if exists {
-				if cheDeployment.Status.AvailableReplicas < 1 {
-					if instance.Status.CheClusterRunning != UnavailableStatus {
-						if err := r.SetCheUnavailableStatus(instance, request); err != nil {
-							instance, _ = r.GetCR(request)
-							return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 1}, err
-						}
-					}
-				} else if cheDeployment.Status.Replicas != 1 {
-					if instance.Status.CheClusterRunning != RollingUpdateInProgressStatus {
-						if err := r.SetCheRollingUpdateStatus(instance, request); err != nil {
-							instance, _ = r.GetCR(request)
-							return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 1}, err
-						}
-					}
-				}
+	if !util.IsTestMode() {
+		if isOpenShift && deployContext.DefaultCheHost == "" {
+			host, err := getDefaultCheHost(deployContext)
+			if host == "" {
+				return false, err
 			}
-			return reconcile.Result{}, err
-		}
-	}
-	// Update available status
-	if instance.Status.CheClusterRunning != AvailableStatus {
-		cheHost := instance.Spec.Server.CheHost
-		if err := r.SetCheAvailableStatus(instance, request, protocol, cheHost); err != nil {
-			instance, _ = r.GetCR(request)
-			return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 1}, err
-		}
-	}
-
-	// Update Che version status
-	cheVersion := EvaluateCheServerVersion(instance)
-	if instance.Status.CheVersion != cheVersion {
-		instance.Status.CheVersion = cheVersion
-		if err := r.UpdateCheCRStatus(instance, "version", cheVersion); err != nil {
-			instance, _ = r.GetCR(request)
-			return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 1}, err
+			deployContext.DefaultCheHost = host
 		}
 	}
+	return true, nil
+}
 
-	// we can now try to create consolelink, after che instance is available
-	done, err = deploy.ReconcileConsoleLink(deployContext)
-	if !done {
-		if err != nil {
-			logrus.Error(err)
-		}
-		// We should `Requeue` since we created cluster object
-		return reconcile.Result{RequeueAfter: time.Second}, err
-	}
-
-	// Delete OpenShift identity provider if OpenShift oAuth is false in spec
-	// but OpenShiftoAuthProvisioned is true in CR status, e.g. when oAuth has been turned on and then turned off
-	deleted, err := r.ReconcileIdentityProvider(instance, isOpenShift4)
-	if deleted {
-		// ignore error
-		deploy.DeleteFinalizer(deployContext, deploy.OAuthFinalizerName)
-		for {
-			instance.Status.OpenShiftoAuthProvisioned = false
-			if err := r.UpdateCheCRStatus(instance, "status: provisioned with OpenShift identity provider", "false"); err != nil &&
-				errors.IsConflict(err) {
-				instance, _ = r.GetCR(request)
-				continue
-			}
-			break
-		}
-		for {
-			instance.Spec.Auth.OAuthSecret = ""
-			instance.Spec.Auth.OAuthClientName = ""
-			if err := r.UpdateCheCRSpec(instance, "clean oAuth secret name and client name", ""); err != nil &&
-				errors.IsConflict(err) {
-				instance, _ = r.GetCR(request)
-				continue
-			}
-			break
+// getShortestRequeueDelay returns the shortest of the delays, ignoring zero ones, which mean no requeue is needed.
+func getShortestRequeueDelay(delays ...time.Duration) time.Duration {
+	var result time.Duration
+	for _, delay := range delays {
+		if delay > 0 && (result == 0 || delay < result) {
+			result = delay
 		}
 	}
-
-	return reconcile.Result{}, nil
+	return result
 }
 
 // EvaluateCheServerVersion evaluate che version
@@ -1077,6 +574,10 @@ func getServerExposingServiceName(cr *orgv1.CheCluster) string {
 	if cr.Spec.Server.ServerExposureStrategy == "single-host" && deploy.GetSingleHostExposureType(cr) == "gateway" {
 		return gateway.GatewayServiceName
 	}
+	// the maintenance page is shown instead of Che, the gateway routes to it by itself
+	if deploy.IsMaintenanceMode(cr) {
+		return deploy.MaintenancePageName
+	}
 	return deploy.CheServiceName
 }
 
@@ -1092,7 +593,8 @@ func isTrustedBundleConfigMap(mgr manager.Manager, obj handler.MapObject) (bool,
 	}
 
 	// Check if config map is the config map from CR
-	if checlusters.Items[0].Spec.Server.ServerTrustStoreConfigMapName != obj.Meta.GetName() {
+	crConfigMapNames := append(deploy.GetLdapCACertsConfigMapNames(&checlusters.Items[0]), checlusters.Items[0].Spec.Server.ServerTrustStoreConfigMapName)
+	if !util.ContainsString(crConfigMapNames, obj.Meta.GetName()) {
 		// No, it is not form CR
 		// Check for labels
 
@@ -1130,6 +632,9 @@ func (r *ReconcileChe) autoEnableOAuth(deployContext *deploy.DeployContext, requ
 		} else {
 			if len(openshitOAuth.Spec.IdentityProviders) > 0 {
 				oauth = true
+			} else if util.IsInitialOpenShiftOAuthUserEnabled(cr) && deployContext.DryRun {
+				// a dry run doesn't create the initial user, assume it would be
+				oauth = true
 			} else if util.IsInitialOpenShiftOAuthUserEnabled(cr) {
 				provisioned, err := r.userHandler.SyncOAuthInitialUser(openshitOAuth, deployContext)
 				if err != nil {
@@ -1174,12 +679,19 @@ func (r *ReconcileChe) autoEnableOAuth(deployContext *deploy.DeployContext, requ
 		}
 	}
 
+	// the detected value is kept in the status, the spec is set in memory
 	newOAuthValue := util.NewBoolPointer(oauth)
-	if !reflect.DeepEqual(newOAuthValue, cr.Spec.Auth.OpenShiftoAuth) {
-		cr.Spec.Auth.OpenShiftoAuth = newOAuthValue
-		if err := r.UpdateCheCRSpec(cr, "openShiftoAuth", strconv.FormatBool(oauth)); err != nil {
+	cr.Spec.Auth.OpenShiftoAuth = newOAuthValue
+	if !reflect.DeepEqual(newOAuthValue, cr.Status.OpenShiftoAuthAutoDetected) {
+		cr.Status.OpenShiftoAuthAutoDetected = newOAuthValue
+		if err := r.UpdateCheCRStatus(cr, "openShiftoAuthAutoDetected", strconv.FormatBool(oauth)); err != nil {
 			return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 1}, err
 		}
+		if oauth {
+			r.recordEvent(cr, corev1.EventTypeNormal, OpenShiftOAuthEnabledEventReason, "OpenShift OAuth has been enabled automatically")
+		} else {
+			r.recordEventf(cr, corev1.EventTypeWarning, OpenShiftOAuthDisabledEventReason, "OpenShift OAuth has been disabled automatically. %s", message)
+		}
 	}
 
 	if message != "" && reason != "" {
@@ -1191,9 +703,8 @@ func (r *ReconcileChe) autoEnableOAuth(deployContext *deploy.DeployContext, requ
 	return reconcile.Result{}, nil
 }
 
-// isEclipseCheSecret indicates if there is a secret with
-// the label 'app.kubernetes.io/part-of=che.eclipse.org' in a che namespace
-func isEclipseCheSecret(mgr manager.Manager, obj handler.MapObject) (bool, reconcile.Request) {
+// isRealmConfigMap detects whether given config map holds the declarative configuration of the Identity Provider realm
+func isRealmConfigMap(mgr manager.Manager, obj handler.MapObject) (bool, reconcile.Request) {
 	checlusters := &orgv1.CheClusterList{}
 	if err := mgr.GetClient().List(context.TODO(), checlusters, &client.ListOptions{}); err != nil {
 		return false, reconcile.Request{}
@@ -1203,43 +714,45 @@ func isEclipseCheSecret(mgr manager.Manager, obj handler.MapObject) (bool, recon
 		return false, reconcile.Request{}
 	}
 
-	if value, exists := obj.Meta.GetLabels()[deploy.KubernetesPartOfLabelKey]; !exists || value != deploy.CheEclipseOrg {
-		// Labels do not match
+	cheCluster := &checlusters.Items[0]
+	if cheCluster.Namespace != obj.Meta.GetNamespace() || !identity_provider.IsRealmConfigMap(cheCluster, obj.Meta.GetName()) {
 		return false, reconcile.Request{}
 	}
 
 	return true, reconcile.Request{
 		NamespacedName: types.NamespacedName{
-			Namespace: checlusters.Items[0].Namespace,
-			Name:      checlusters.Items[0].Name,
+			Namespace: cheCluster.Namespace,
+			Name:      cheCluster.Name,
 		},
 	}
 }
 
-func (r *ReconcileChe) reconcileFinalizers(deployContext *deploy.DeployContext) {
-	if util.IsOpenShift && util.IsOAuthEnabled(deployContext.CheCluster) {
-		if err := deploy.ReconcileOAuthClientFinalizer(deployContext); err != nil {
-			logrus.Error(err)
-		}
+// isEclipseCheSecret indicates if there is a secret with
+// the label 'app.kubernetes.io/part-of=che.eclipse.org' in a che namespace,
+// or a secret with the bind credentials of an LDAP server declared in CR
+func isEclipseCheSecret(mgr manager.Manager, obj handler.MapObject) (bool, reconcile.Request) {
+	checlusters := &orgv1.CheClusterList{}
+	if err := mgr.GetClient().List(context.TODO(), checlusters, &client.ListOptions{}); err != nil {
+		return false, reconcile.Request{}
 	}
 
-	if err := r.reconcileWorkspacePermissionsFinalizer(deployContext); err != nil {
-		logrus.Error(err)
+	if len(checlusters.Items) != 1 {
+		return false, reconcile.Request{}
 	}
 
-	if err := deploy.ReconcileConsoleLinkFinalizer(deployContext); err != nil {
-		logrus.Error(err)
+	if value, exists := obj.Meta.GetLabels()[deploy.KubernetesPartOfLabelKey]; !exists || value != deploy.CheEclipseOrg {
+		// Secrets with the bind credentials of LDAP servers are referenced from CR, and not necessarily labeled
+		if checlusters.Items[0].Namespace != obj.Meta.GetNamespace() || !identity_provider.IsLdapBindCredentialsSecret(&checlusters.Items[0], obj.Meta.GetName()) {
+			// Labels do not match
+			return false, reconcile.Request{}
+		}
 	}
 
-	if len(deployContext.CheCluster.Spec.Server.CheClusterRoles) > 0 {
-		cheClusterRoles := strings.Split(deployContext.CheCluster.Spec.Server.CheClusterRoles, ",")
-		for _, cheClusterRole := range cheClusterRoles {
-			cheClusterRole := strings.TrimSpace(cheClusterRole)
-			cheClusterRoleBindingName := deploy.GetUniqueClusterRoleBindingName(deployContext, CheServiceAccountName, cheClusterRole)
-			if err := deploy.ReconcileClusterRoleBindingFinalizer(deployContext, cheClusterRoleBindingName); err != nil {
-				logrus.Error(err)
-			}
-		}
+	return true, reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: checlusters.Items[0].Namespace,
+			Name:      checlusters.Items[0].Name,
+		},
 	}
 }
 
@@ -475,36 +475,14 @@ func TestImagePullerConfiguration(t *testing.T) {
 			},
 		},
 		{
-			name:   "image puller enabled with finalizer but default values are empty, subscription exists, should update the CR",
+			name:   "image puller enabled with finalizer but default values are empty, subscription exists, should create a KubernetesImagePuller with the default values",
 			initCR: InitCheCRWithImagePullerFinalizer(),
-			expectedCR: &orgv1.CheCluster{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "CheCluster",
-					APIVersion: "org.eclipse.che/v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Name:            name,
-					Namespace:       namespace,
-					ResourceVersion: "1",
-					Finalizers: []string{
-						"kubernetesimagepullers.finalizers.che.eclipse.org",
-					},
-				},
-				Spec: orgv1.CheClusterSpec{
-					ImagePuller: orgv1.CheClusterSpecImagePuller{
-						Enable: true,
-						Spec: chev1alpha1.KubernetesImagePullerSpec{
-							DeploymentName: "kubernetes-image-puller",
-							ConfigMapName:  "k8s-image-puller",
-						},
-					},
-				},
-			},
 			initObjects: []runtime.Object{
 				packageManifest,
 				operatorGroup,
 				subscription,
 			},
+			expectedImagePuller: defaultImagePuller,
 		},
 		{
 			name:   "image puller enabled default values already set, subscription exists, should create a KubernetesImagePuller",
@@ -715,6 +693,20 @@ func TestCheController(t *testing.T) {
 		t.Errorf("CR not found")
 	}
 
+	// legacy custom ConfigMap of the former versions of the Operator
+	customCm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "custom",
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"CHE_WORKSPACE_LEGACY__PROPERTY": "legacy-value",
+		},
+	}
+	if err := cl.Create(context.TODO(), customCm); err != nil {
+		t.Fatalf("Failed to create custom ConfigMap: %s", err)
+	}
+
 	// Mock request to simulate Reconcile() being called on an event for a
 	// watched resource .
 	req := reconcile.Request{
@@ -773,12 +765,14 @@ func TestCheController(t *testing.T) {
 		t.Errorf("ConfigMap %s not found: %s", cm.Name, err)
 	}
 
-	customCm := &corev1.ConfigMap{}
-
-	// Custom ConfigMap should be gone
-	err = cl.Get(context.TODO(), types.NamespacedName{Name: "custom", Namespace: cheCR.Namespace}, customCm)
-	if !errors.IsNotFound(err) {
-		t.Errorf("Custom config map should be deleted and merged with Che ConfigMap")
+	// Custom ConfigMap should be merged with Che ConfigMap and kept
+	for key, value := range customCm.Data {
+		if cm.Data[key] != value {
+			t.Errorf("Key %s of the custom ConfigMap is expected in Che ConfigMap with the value %s, got %s", key, value, cm.Data[key])
+		}
+	}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "custom", Namespace: cheCR.Namespace}, customCm); err != nil {
+		t.Errorf("Custom ConfigMap should be kept: %s", err)
 	}
 
 	// Get the custom role binding that should have been created for the role we passed in
@@ -1236,6 +1230,43 @@ func TestShouldFallBackWorspaceNamespaceDefaultBecauseNotEnoughtPermissions(t *t
 	}
 }
 
+func TestStrictCredentialsModeRefusesPlaintextCredentials(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+	os.Setenv(deploy.StrictCredentialsModeEnv, "true")
+	defer os.Unsetenv(deploy.StrictCredentialsModeEnv)
+
+	cl, dc, scheme := Init()
+	r := &ReconcileChe{client: cl, nonCachedClient: cl, scheme: &scheme, discoveryClient: dc, tests: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	cheCR := &orgv1.CheCluster{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	cheCR.Spec.Database.ChePostgresPassword = "db-password"
+	if err := cl.Update(context.TODO(), cheCR); err != nil {
+		t.Fatalf("Failed to update CheCluster: %v", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("Failed to reconcile: %v", err)
+	}
+
+	cheCR = &orgv1.CheCluster{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if !orgv1.IsConditionTrue(cheCR.Status.Conditions, orgv1.ConditionDegraded) {
+		t.Fatalf("Condition %s is expected to be true", orgv1.ConditionDegraded)
+	}
+	if cheCR.Spec.Database.ChePostgresPassword != "db-password" {
+		t.Fatalf("Password in plain text is not expected to be migrated in the strict credentials mode")
+	}
+	cheDeploymentName := types.NamespacedName{Name: deploy.DefaultCheFlavor(cheCR), Namespace: namespace}
+	if err := cl.Get(context.TODO(), cheDeploymentName, &appsv1.Deployment{}); err == nil {
+		t.Fatalf("Che deployment is not expected to be created with passwords in plain text in the strict credentials mode")
+	}
+}
+
 func Init() (client.Client, discovery.DiscoveryInterface, runtime.Scheme) {
 	objs, ds, scheme := createAPIObjects()
 
@@ -1370,13 +1401,16 @@ func ExpectedCheCRWithImagePullerFinalizer() *orgv1.CheCluster {
 			Finalizers: []string{
 				"kubernetesimagepullers.finalizers.che.eclipse.org",
 			},
-			ResourceVersion: "1",
+			ResourceVersion: "2",
 		},
 		Spec: orgv1.CheClusterSpec{
 			ImagePuller: orgv1.CheClusterSpecImagePuller{
 				Enable: true,
 			},
 		},
+		Status: orgv1.CheClusterStatus{
+			LegacySpecValuesRemoved: true,
+		},
 	}
 }
 
@@ -15,6 +15,7 @@ import (
 	"fmt"
 
 	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
 )
 
 // ValidateCheCR checks Che CR configuration.
@@ -29,5 +30,13 @@ func ValidateCheCR(checluster *orgv1.CheCluster, isOpenshift bool) error {
 		}
 	}
 
+	// the validating webhook refuses new passwords in plain text only,
+	// existing ones are not migrated into secrets in the strict credentials mode
+	if deploy.IsStrictCredentialsMode() {
+		if errs := orgv1.ValidateNoPlaintextCredentials(checluster, nil); len(errs) > 0 {
+			return fmt.Errorf("Strict credentials mode is enabled: %v", errs.ToAggregate())
+		}
+	}
+
 	return nil
 }
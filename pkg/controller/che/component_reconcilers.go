@@ -0,0 +1,569 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"fmt"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	devfile_registry "github.com/eclipse-che/che-operator/pkg/deploy/devfile-registry"
+	"github.com/eclipse-che/che-operator/pkg/deploy/gateway"
+	identity_provider "github.com/eclipse-che/che-operator/pkg/deploy/identity-provider"
+	"github.com/eclipse-che/che-operator/pkg/deploy/maintenance"
+	plugin_registry "github.com/eclipse-che/che-operator/pkg/deploy/plugin-registry"
+	"github.com/eclipse-che/che-operator/pkg/deploy/postgres"
+	"github.com/eclipse-che/che-operator/pkg/deploy/server"
+	"github.com/eclipse-che/che-operator/pkg/metrics"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// cheVolumeReconciler provisions the volume keeping the data of Che server in single user mode.
+type cheVolumeReconciler struct {
+	r *ReconcileChe
+}
+
+func (v *cheVolumeReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	instance := deployContext.CheCluster
+	if deploy.GetCheMultiUser(instance) == "true" {
+		done, err := deploy.DeleteNamespacedObject(deployContext, deploy.DefaultCheVolumeClaimName, &corev1.PersistentVolumeClaim{})
+		return done || v.r.tests, err
+	}
+
+	done, err := deploy.SyncPVCToCluster(deployContext, deploy.DefaultCheVolumeClaimName, "1Gi", deploy.DefaultCheFlavor(instance))
+	if !done && !v.r.tests {
+		logrus.Infof("Waiting on pvc '%s' to be bound. Sometimes PVC can be bound only when the first consumer is created.", deploy.DefaultCheVolumeClaimName)
+		v.r.recordEventf(instance, corev1.EventTypeNormal, WaitingEventReason, "Waiting on pvc '%s' to be bound", deploy.DefaultCheVolumeClaimName)
+		return false, err
+	}
+
+	// the database isn't used in single user mode
+	for _, name := range []string{deploy.DefaultPostgresVolumeClaimName, postgres.PostgresUpgradeBackupVolumeClaimName} {
+		done, err := deploy.DeleteNamespacedObject(deployContext, name, &corev1.PersistentVolumeClaim{})
+		if !done && !v.r.tests {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (v *cheVolumeReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+// postgresReconciler deploys PostgreSQL and provisions the databases of Che server and Keycloak,
+// unless an external database is used.
+type postgresReconciler struct {
+	r *ReconcileChe
+}
+
+func (p *postgresReconciler) IsEnabled(deployContext *deploy.DeployContext) bool {
+	return !deployContext.CheCluster.Spec.Database.ExternalDb
+}
+
+func (p *postgresReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	instance := deployContext.CheCluster
+	if deploy.GetCheMultiUser(instance) == "false" {
+		done, err := deploy.Delete(deployContext, types.NamespacedName{Name: deploy.PostgresName, Namespace: instance.Namespace}, &appsv1.Deployment{})
+		return done || p.r.tests, err
+	}
+
+	// Create a new postgres service
+	serviceStatus := deploy.SyncServiceToCluster(deployContext, deploy.PostgresName, []string{deploy.PostgresName}, []int32{5432}, deploy.PostgresName)
+	if !serviceStatus.Continue && !p.r.tests {
+		logrus.Info("Waiting on service 'postgres' to be ready")
+		return false, serviceStatus.Err
+	}
+
+	// Create a new Postgres PVC object
+	done, err := deploy.SyncPVCToCluster(deployContext, deploy.DefaultPostgresVolumeClaimName, deploy.DefaultPostgresVolumeClaimSize, deploy.PostgresName)
+	if !done && !p.r.tests {
+		logrus.Infof("Waiting on pvc '%s' to be bound. Sometimes PVC can be bound only when the first consumer is created.", deploy.DefaultPostgresVolumeClaimName)
+		return false, err
+	}
+
+	// Upgrade the Postgres data if the image moves to a newer major version
+	done, err = postgres.SyncPostgresUpgrade(deployContext)
+	if !done && !p.r.tests {
+		logrus.Info("Waiting on PostgreSQL upgrade to complete")
+		return false, err
+	}
+
+	// Create a new Postgres deployment
+	provisioned, err := postgres.SyncPostgresDeploymentToCluster(deployContext)
+	if p.r.tests {
+		// the deployment is never rolled out in the fake cluster, so the databases can't be provisioned
+		return true, nil
+	}
+	if !provisioned {
+		return false, err
+	}
+
+	// provision Db and users for Che and Keycloak servers
+	if !instance.Status.DbProvisoned && !deployContext.DryRun {
+		return p.provisionDatabases(deployContext)
+	}
+	return true, nil
+}
+
+func (p *postgresReconciler) provisionDatabases(deployContext *deploy.DeployContext) (bool, error) {
+	instance := deployContext.CheCluster
+	identityProviderPostgresPassword := instance.Spec.Auth.IdentityProviderPostgresPassword
+	identityProviderPostgresSecret := instance.Spec.Auth.IdentityProviderPostgresSecret
+	if deploy.IsOpenIdConnectProviderEnabled(instance) {
+		identityProviderPostgresPassword = ""
+	} else if len(identityProviderPostgresSecret) > 0 {
+		_, password, err := util.K8sclient.ReadSecret(identityProviderPostgresSecret, instance.Namespace)
+		if err != nil {
+			return false, fmt.Errorf("failed to read '%s' secret: %v", identityProviderPostgresSecret, err)
+		}
+		identityProviderPostgresPassword = password
+	}
+	chePostgresUser := instance.Spec.Database.ChePostgresUser
+	if len(instance.Spec.Database.ChePostgresSecret) > 0 {
+		user, _, err := util.K8sclient.ReadSecret(instance.Spec.Database.ChePostgresSecret, instance.Namespace)
+		if err != nil {
+			return false, fmt.Errorf("failed to read '%s' secret: %v", instance.Spec.Database.ChePostgresSecret, err)
+		}
+		chePostgresUser = user
+	}
+
+	if err := postgres.ProvisionDatabases(deployContext, chePostgresUser, identityProviderPostgresPassword); err != nil {
+		metrics.RecordProvisioningFailure(metrics.ProvisioningDatabase)
+		return false, err
+	}
+	for {
+		instance.Status.DbProvisoned = true
+		if err := p.r.UpdateCheCRStatus(instance, "status: provisioned with DB and user", "true"); err != nil &&
+			errors.IsConflict(err) {
+			deploy.ReloadCheCluster(deployContext)
+			continue
+		}
+		break
+	}
+	return true, nil
+}
+
+func (p *postgresReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+func (p *postgresReconciler) Condition(deployContext *deploy.DeployContext) (string, bool) {
+	instance := deployContext.CheCluster
+	return orgv1.ConditionPostgresReady, deploy.GetCheMultiUser(instance) == "true"
+}
+
+// maintenancePageReconciler deploys the page which is shown instead of Che while it is in maintenance mode,
+// before the Che endpoint is routed to it, and removes the page once the maintenance mode is disabled.
+type maintenancePageReconciler struct {
+	r *ReconcileChe
+}
+
+func (m *maintenancePageReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	instance := deployContext.CheCluster
+	if !deploy.IsMaintenanceMode(instance) {
+		done, err := maintenance.DeleteMaintenancePage(deployContext)
+		if !done {
+			return false, err
+		}
+		return true, m.r.SetMaintenanceModeCondition(instance, false)
+	}
+
+	done, err := maintenance.SyncMaintenancePageToCluster(deployContext)
+	if !done && !m.r.tests {
+		logrus.Infof("Waiting on deployment '%s' to be ready", deploy.MaintenancePageName)
+		return false, err
+	}
+	return true, m.r.SetMaintenanceModeCondition(instance, true)
+}
+
+func (m *maintenancePageReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+// cheEndpointReconciler exposes Che server and sets the host it is available at.
+// The readiness of Che server is reported once its deployment is rolled out, so only the failures are reported here.
+type cheEndpointReconciler struct {
+	r           *ReconcileChe
+	isOpenShift bool
+}
+
+func (e *cheEndpointReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	done, err := e.syncEndpoint(deployContext)
+	if !done {
+		e.r.SetComponentCondition(deployContext.CheCluster, orgv1.ConditionCheServerReady, false, err)
+	}
+	return done, err
+}
+
+func (e *cheEndpointReconciler) syncEndpoint(deployContext *deploy.DeployContext) (bool, error) {
+	instance := deployContext.CheCluster
+	cheFlavor := deploy.DefaultCheFlavor(instance)
+
+	serviceStatus := server.SyncCheServiceToCluster(deployContext)
+	if !serviceStatus.Continue && !e.r.tests {
+		logrus.Infof("Waiting on service '%s' to be ready", deploy.CheServiceName)
+		return false, serviceStatus.Err
+	}
+	deployContext.InternalService.CheHost = fmt.Sprintf("http://%s.%s.svc:8080", deploy.CheServiceName, instance.Namespace)
+
+	exposedServiceName := getServerExposingServiceName(instance)
+	cheHost := ""
+	if !e.isOpenShift && deploy.IsGatewayAPIUsed(deployContext) {
+		route, err := deploy.SyncHTTPRouteToCluster(deployContext, cheFlavor, instance.Spec.Server.CheHost, exposedServiceName, 8080, cheFlavor)
+		if !e.r.tests {
+			if route == nil {
+				logrus.Infof("Waiting on HTTP route '%s' to be ready", cheFlavor)
+				return false, err
+			}
+			cheHost = deploy.GetHTTPRouteHost(route)
+		}
+		if err := deploy.DeleteIngressIfExists(cheFlavor, deployContext); err != nil {
+			logrus.Error(err)
+		}
+	} else if !e.isOpenShift {
+		ingress, err := deploy.SyncIngressToCluster(
+			deployContext,
+			cheFlavor,
+			instance.Spec.Server.CheHost,
+			exposedServiceName,
+			8080,
+			instance.Spec.Server.CheServerIngress,
+			cheFlavor)
+		if !e.r.tests {
+			if ingress == nil {
+				logrus.Infof("Waiting on ingress '%s' to be ready", cheFlavor)
+				return false, err
+			}
+			cheHost = ingress.Spec.Rules[0].Host
+		}
+		if err := deploy.DeleteHTTPRouteIfExists(cheFlavor, deployContext); err != nil {
+			logrus.Error(err)
+		}
+	} else {
+		customHost := instance.Spec.Server.CheHost
+		if deployContext.DefaultCheHost == customHost {
+			// let OpenShift set a hostname by itself since it requires a routes/custom-host permissions
+			customHost = ""
+		}
+
+		route, err := deploy.SyncRouteToCluster(
+			deployContext,
+			cheFlavor,
+			customHost,
+			exposedServiceName,
+			8080,
+			instance.Spec.Server.CheServerRoute,
+			cheFlavor)
+		if route == nil {
+			logrus.Infof("Waiting on route '%s' to be ready", cheFlavor)
+			return false, err
+		}
+		cheHost = route.Spec.Host
+		if customHost == "" {
+			deployContext.DefaultCheHost = cheHost
+		}
+	}
+	// the host is reported in the status, the spec is set in memory
+	instance.Spec.Server.CheHost = cheHost
+
+	done, err := deploy.SyncCertManagerCertificate(deployContext, cheFlavor, cheHost)
+	return done || e.r.tests, err
+}
+
+func (e *cheEndpointReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+// keycloakReconciler deploys and configures Keycloak, unless a generic OpenID Connect provider is used.
+// Its condition is reported only when Keycloak is deployed by the Operator.
+type keycloakReconciler struct {
+	r *ReconcileChe
+}
+
+func (k *keycloakReconciler) IsEnabled(deployContext *deploy.DeployContext) bool {
+	return !deploy.IsOpenIdConnectProviderEnabled(deployContext.CheCluster)
+}
+
+func (k *keycloakReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	provisioned, err := identity_provider.SyncIdentityProviderToCluster(deployContext)
+	return provisioned || k.r.tests, err
+}
+
+func (k *keycloakReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+func (k *keycloakReconciler) Condition(deployContext *deploy.DeployContext) (string, bool) {
+	return orgv1.ConditionKeycloakReady, deploy.IsKeycloakDeployed(deployContext.CheCluster)
+}
+
+// openIdConnectProviderReconciler validates the generic OpenID Connect provider replacing Keycloak.
+type openIdConnectProviderReconciler struct {
+	r *ReconcileChe
+}
+
+func (o *openIdConnectProviderReconciler) IsEnabled(deployContext *deploy.DeployContext) bool {
+	return deploy.IsOpenIdConnectProviderEnabled(deployContext.CheCluster)
+}
+
+func (o *openIdConnectProviderReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	provisioned, err := identity_provider.SyncOpenIdConnectProviderToCluster(deployContext)
+	return provisioned || o.r.tests, err
+}
+
+func (o *openIdConnectProviderReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+func (o *openIdConnectProviderReconciler) Condition(deployContext *deploy.DeployContext) (string, bool) {
+	return orgv1.ConditionOpenIdConnectProviderReady, true
+}
+
+// devfileRegistryReconciler deploys the devfile registry, unless an external one is used.
+type devfileRegistryReconciler struct {
+	r *ReconcileChe
+}
+
+func (d *devfileRegistryReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	provisioned, err := devfile_registry.SyncDevfileRegistryToCluster(deployContext, deployContext.CheCluster.Spec.Server.CheHost)
+	return provisioned || d.r.tests, err
+}
+
+func (d *devfileRegistryReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+func (d *devfileRegistryReconciler) Condition(deployContext *deploy.DeployContext) (string, bool) {
+	return orgv1.ConditionDevfileRegistryReady, !deployContext.CheCluster.Spec.Server.ExternalDevfileRegistry
+}
+
+// pluginRegistryReconciler deploys the plugin registry, unless an external one is used.
+type pluginRegistryReconciler struct {
+	r *ReconcileChe
+}
+
+func (p *pluginRegistryReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	provisioned, err := plugin_registry.SyncPluginRegistryToCluster(deployContext, deployContext.CheCluster.Spec.Server.CheHost)
+	return provisioned || p.r.tests, err
+}
+
+func (p *pluginRegistryReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+func (p *pluginRegistryReconciler) Condition(deployContext *deploy.DeployContext) (string, bool) {
+	return orgv1.ConditionPluginRegistryReady, !deployContext.CheCluster.Spec.Server.ExternalPluginRegistry
+}
+
+// cheConfigMapReconciler syncs the configuration of Che server with the CheCluster.
+// The config map is not supposed to be manually edited.
+type cheConfigMapReconciler struct {
+	r *ReconcileChe
+}
+
+func (c *cheConfigMapReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	done, err := server.SyncCheConfigMapToCluster(deployContext)
+	if !done && !c.r.tests {
+		c.r.recordEventf(deployContext.CheCluster, corev1.EventTypeNormal, WaitingEventReason, "Waiting on config map '%s' to be created", server.CheConfigMapName)
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *cheConfigMapReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+// gatewayReconciler deploys the gateway routing the traffic to Che components in single-host mode.
+type gatewayReconciler struct{}
+
+func (g *gatewayReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	if err := gateway.SyncGatewayToCluster(deployContext); err != nil {
+		return false, fmt.Errorf("failed to create the Server Gateway: %v", err)
+	}
+	return true, nil
+}
+
+func (g *gatewayReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+func (g *gatewayReconciler) Condition(deployContext *deploy.DeployContext) (string, bool) {
+	return orgv1.ConditionGatewayReady, gateway.IsGatewayEnabled(deployContext.CheCluster)
+}
+
+// cheServerReconciler deploys Che server and reports where it is available once it is rolled out.
+type cheServerReconciler struct {
+	r       *ReconcileChe
+	request reconcile.Request
+}
+
+func (s *cheServerReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	r := s.r
+	instance := deployContext.CheCluster
+	cheFlavor := deploy.DefaultCheFlavor(instance)
+
+	if deploy.IsMaintenanceMode(instance) {
+		return s.scaleDown(deployContext)
+	}
+
+	provisioned, err := server.SyncCheDeploymentToCluster(deployContext)
+	if !provisioned && !r.tests {
+		logrus.Infof("Waiting on deployment '%s' to be ready", cheFlavor)
+		cheDeployment := &appsv1.Deployment{}
+		exists, getErr := deploy.GetNamespacedObject(deployContext, cheFlavor, cheDeployment)
+		if exists {
+			if cheDeployment.Status.AvailableReplicas < 1 {
+				if instance.Status.CheClusterRunning != UnavailableStatus {
+					if err := r.SetCheUnavailableStatus(instance, s.request); err != nil {
+						return false, err
+					}
+				}
+			} else if cheDeployment.Status.Replicas != 1 {
+				if instance.Status.CheClusterRunning != RollingUpdateInProgressStatus {
+					if err := r.SetCheRollingUpdateStatus(instance, s.request); err != nil {
+						return false, err
+					}
+				}
+			}
+		} else if getErr != nil {
+			logrus.Error(getErr)
+		}
+		return false, err
+	}
+
+	// Update available status
+	if instance.Status.CheClusterRunning != AvailableStatus {
+		protocol := "http"
+		if instance.Spec.Server.TlsSupport {
+			protocol = "https"
+		}
+		if err := r.SetCheAvailableStatus(instance, s.request, protocol, instance.Spec.Server.CheHost); err != nil {
+			return false, err
+		}
+	}
+
+	// Update Che version status
+	cheVersion := EvaluateCheServerVersion(instance)
+	if instance.Status.CheVersion != cheVersion {
+		instance.Status.CheVersion = cheVersion
+		if err := r.UpdateCheCRStatus(instance, "version", cheVersion); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// scaleDown stops Che server while the maintenance page is shown instead.
+// It is started again, with the same configuration, once the maintenance mode is disabled.
+func (s *cheServerReconciler) scaleDown(deployContext *deploy.DeployContext) (bool, error) {
+	instance := deployContext.CheCluster
+	cheFlavor := deploy.DefaultCheFlavor(instance)
+
+	if _, err := server.SyncCheDeploymentToCluster(deployContext); err != nil {
+		return false, err
+	}
+	cheDeployment := &appsv1.Deployment{}
+	exists, err := deploy.GetNamespacedObject(deployContext, cheFlavor, cheDeployment)
+	if err != nil {
+		return false, err
+	}
+	if exists && cheDeployment.Status.Replicas > 0 && !s.r.tests {
+		logrus.Infof("Waiting on deployment '%s' to be scaled down", cheFlavor)
+		return false, nil
+	}
+
+	if instance.Status.CheClusterRunning != MaintenanceModeStatus {
+		if err := s.r.SetCheMaintenanceModeStatus(instance, s.request); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (s *cheServerReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+func (s *cheServerReconciler) Condition(deployContext *deploy.DeployContext) (string, bool) {
+	return orgv1.ConditionCheServerReady, true
+}
+
+// consoleLinkReconciler adds a link to Che to the OpenShift console, once Che is available.
+type consoleLinkReconciler struct{}
+
+func (c *consoleLinkReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	return deploy.ReconcileConsoleLink(deployContext)
+}
+
+func (c *consoleLinkReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return deploy.ReconcileConsoleLinkFinalizer(deployContext)
+}
+
+// openShiftIdentityProviderReconciler removes the OpenShift identity provider from Keycloak
+// when OpenShift OAuth has been turned off after it was provisioned.
+type openShiftIdentityProviderReconciler struct {
+	r            *ReconcileChe
+	isOpenShift4 bool
+}
+
+// IsEnabled skips the dry runs, as the identity provider is removed from Keycloak through its REST API.
+func (o *openShiftIdentityProviderReconciler) IsEnabled(deployContext *deploy.DeployContext) bool {
+	return !deployContext.DryRun
+}
+
+func (o *openShiftIdentityProviderReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	r := o.r
+	instance := deployContext.CheCluster
+	deleted, err := r.ReconcileIdentityProvider(deployContext, o.isOpenShift4)
+	if err != nil {
+		// don't hang on the removal, it is retried on the next reconcile
+		logrus.Errorf("Failed to remove the OpenShift identity provider: %v", err)
+	}
+	if !deleted {
+		return true, nil
+	}
+
+	r.recordEvent(instance, corev1.EventTypeNormal, OpenShiftOAuthRemovedEventReason, "OpenShift identity provider has been removed from Keycloak")
+	// ignore error
+	deploy.DeleteFinalizer(deployContext, deploy.OAuthFinalizerName)
+	for {
+		instance.Status.OpenShiftoAuthProvisioned = false
+		if err := r.UpdateCheCRStatus(instance, "status: provisioned with OpenShift identity provider", "false"); err != nil &&
+			errors.IsConflict(err) {
+			deploy.ReloadCheCluster(deployContext)
+			continue
+		}
+		break
+	}
+	for {
+		instance.Status.OpenShiftOAuthClientName = ""
+		if err := r.UpdateCheCRStatus(instance, "clean oAuth client name", ""); err != nil &&
+			errors.IsConflict(err) {
+			deploy.ReloadCheCluster(deployContext)
+			continue
+		}
+		break
+	}
+	return true, nil
+}
+
+func (o *openShiftIdentityProviderReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
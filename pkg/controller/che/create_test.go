@@ -0,0 +1,177 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// specGuardClient fails the test if the spec of the CheCluster is modified.
+// Unlike the fake client, the status update doesn't store the spec.
+type specGuardClient struct {
+	client.Client
+	t *testing.T
+}
+
+type specGuardStatusWriter struct {
+	client.Client
+}
+
+func (c *specGuardClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if cheCluster, ok := obj.(*orgv1.CheCluster); ok {
+		actual := &orgv1.CheCluster{}
+		if err := c.Client.Get(ctx, types.NamespacedName{Name: cheCluster.Name, Namespace: cheCluster.Namespace}, actual); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(actual.Spec, cheCluster.Spec) {
+			c.t.Errorf("CheCluster spec is not supposed to be updated: %s", cmp.Diff(actual.Spec, cheCluster.Spec))
+		}
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *specGuardClient) Status() client.StatusWriter {
+	return &specGuardStatusWriter{c.Client}
+}
+
+func (w *specGuardStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	cheCluster, ok := obj.(*orgv1.CheCluster)
+	if !ok {
+		return w.Client.Status().Update(ctx, obj, opts...)
+	}
+
+	actual := &orgv1.CheCluster{}
+	if err := w.Client.Get(ctx, types.NamespacedName{Name: cheCluster.Name, Namespace: cheCluster.Namespace}, actual); err != nil {
+		return err
+	}
+	cheCluster.Status.DeepCopyInto(&actual.Status)
+	if err := w.Client.Update(ctx, actual, opts...); err != nil {
+		return err
+	}
+	cheCluster.ResourceVersion = actual.ResourceVersion
+	return nil
+}
+
+func (w *specGuardStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return w.Client.Status().Patch(ctx, obj, patch, opts...)
+}
+
+func TestReconcileShouldNotUpdateCheClusterSpec(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+
+	cl, dc, scheme := Init()
+	cli := &specGuardClient{Client: cl, t: t}
+	r := &ReconcileChe{client: cli, nonCachedClient: cli, scheme: &scheme, discoveryClient: dc, tests: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+
+	cheCluster := &orgv1.CheCluster{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCluster); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if cheCluster.Spec.Server.CheFlavor != "" || cheCluster.Spec.Database.ChePostgresSecret != "" || cheCluster.Spec.Auth.IdentityProviderSecret != "" {
+		t.Errorf("Defaults are not supposed to be written to the spec: %v", cheCluster.Spec)
+	}
+	if !orgv1.IsConditionTrue(cheCluster.Status.Conditions, orgv1.ConditionReady) {
+		t.Errorf("Che is expected to be installed, conditions: %v", cheCluster.Status.Conditions)
+	}
+
+	// generated secrets are used even though they are not referenced in the spec
+	for _, secretName := range []string{deploy.DefaultChePostgresSecret(), deploy.DefaultCheIdentitySecret(), deploy.DefaultCheIdentityPostgresSecret()} {
+		secret := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: namespace}, secret); err != nil {
+			t.Errorf("Secret %s is expected to be generated, error: %v", secretName, err)
+		}
+	}
+}
+
+func TestReconcileShouldApplyLegacyCustomConfigMapInMemory(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+
+	cl, dc, scheme := Init()
+	customConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: namespace},
+		Data:       map[string]string{"CHE_LEGACY_PROPERTY": "legacy-value"},
+	}
+	if err := cl.Create(context.TODO(), customConfigMap); err != nil {
+		t.Fatalf("Failed to create config map: %v", err)
+	}
+	cli := &specGuardClient{Client: cl, t: t}
+	r := &ReconcileChe{client: cli, nonCachedClient: cli, scheme: &scheme, discoveryClient: dc, tests: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+
+	cheConfigMap := &corev1.ConfigMap{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "che", Namespace: namespace}, cheConfigMap); err != nil {
+		t.Fatalf("Failed to get Che config map: %v", err)
+	}
+	if cheConfigMap.Data["CHE_LEGACY_PROPERTY"] != "legacy-value" {
+		t.Errorf("Values of the legacy config map are expected in the Che config map: %v", cheConfigMap.Data)
+	}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "custom", Namespace: namespace}, customConfigMap); err != nil {
+		t.Errorf("Legacy config map is expected to be kept: %v", err)
+	}
+}
+
+func TestSetCheClusterStatusDefaults(t *testing.T) {
+	oAuthEnabled := true
+	cheCluster := &orgv1.CheCluster{
+		Status: orgv1.CheClusterStatus{
+			OpenShiftoAuthAutoDetected: &oAuthEnabled,
+			OpenShiftOAuthClientName:   "eclipse-che-openshift-identity-provider-abcdef",
+			KeycloakURL:                "https://keycloak",
+		},
+	}
+
+	deploy.SetCheClusterStatusDefaults(cheCluster)
+
+	if cheCluster.Spec.Auth.OpenShiftoAuth == nil || !*cheCluster.Spec.Auth.OpenShiftoAuth {
+		t.Errorf("OpenShift OAuth is expected to be enabled")
+	}
+	if cheCluster.Spec.Auth.OAuthClientName != "eclipse-che-openshift-identity-provider-abcdef" {
+		t.Errorf("Unexpected OAuth client name: %s", cheCluster.Spec.Auth.OAuthClientName)
+	}
+	if cheCluster.Spec.Auth.IdentityProviderURL != "https://keycloak" {
+		t.Errorf("Unexpected identity provider URL: %s", cheCluster.Spec.Auth.IdentityProviderURL)
+	}
+
+	// user defined values take precedence
+	cheCluster.Spec.Auth.OpenShiftoAuth = new(bool)
+	cheCluster.Spec.Auth.OAuthClientName = "custom"
+	deploy.SetCheClusterStatusDefaults(cheCluster)
+	if *cheCluster.Spec.Auth.OpenShiftoAuth || cheCluster.Spec.Auth.OAuthClientName != "custom" {
+		t.Errorf("User defined values are not supposed to be overridden")
+	}
+}
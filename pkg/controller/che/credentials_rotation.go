@@ -0,0 +1,349 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"context"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	identity_provider "github.com/eclipse-che/che-operator/pkg/deploy/identity-provider"
+	"github.com/eclipse-che/che-operator/pkg/deploy/postgres"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// the new password is kept in the secret until it is applied,
+	// so the rotation can be resumed if the Operator fails in the middle
+	pendingPasswordSecretKey = "pending-password"
+	// the user whose password is revoked once the components use the new credentials
+	revokedUserSecretKey = "revoked-user"
+)
+
+// CredentialsRotator applies the new credentials to the components deployed by the Operator.
+type CredentialsRotator interface {
+	EnablePostgresUser(deployContext *deploy.DeployContext, user string, password string) error
+	RevokePostgresUserPassword(deployContext *deploy.DeployContext, user string) error
+	UpdateKeycloakAdminPassword(deployContext *deploy.DeployContext, user string, password string, newPassword string) error
+}
+
+// ClientCredentialsRotator - CredentialsRotator implementation which connects to PostgreSQL and Keycloak directly.
+type ClientCredentialsRotator struct {
+}
+
+func (rotator *ClientCredentialsRotator) EnablePostgresUser(deployContext *deploy.DeployContext, user string, password string) error {
+	return postgres.EnableUser(deployContext, user, password)
+}
+
+func (rotator *ClientCredentialsRotator) RevokePostgresUserPassword(deployContext *deploy.DeployContext, user string) error {
+	return postgres.RevokeUserPassword(deployContext, user)
+}
+
+func (rotator *ClientCredentialsRotator) UpdateKeycloakAdminPassword(deployContext *deploy.DeployContext, user string, password string, newPassword string) error {
+	return identity_provider.UpdateAdminPassword(deployContext, user, password, newPassword)
+}
+
+// rotatedCredentials are the credentials stored in a secret and the way to apply new password
+type rotatedCredentials struct {
+	secretName string
+	// defaultUser is the user when the secret doesn't hold one
+	defaultUser string
+	// getNewUser returns the user which gets the new password while the components still use the current one.
+	// If it isn't set, the password of the current user is changed right away.
+	getNewUser func(user string) string
+	apply      func(user string, password string, newUser string, newPassword string) error
+	// revoke revokes the password of the former user once the clients use the new credentials
+	revoke func(user string) error
+	// clients are the deployments which use the credentials
+	clients []string
+}
+
+// ReconcileCredentialsRotation rotates the credentials of PostgreSQL and Keycloak accounts managed by the Operator
+// when it is requested by the `che.eclipse.org/rotate-credentials` annotation or the rotation interval,
+// set in the `che.eclipse.org/credentials-rotation-interval` annotation, has elapsed.
+// PostgreSQL users are rotated in stages, so the components keep working during the rotation:
+// the new password is set to a standby user first and the secrets are updated with the standby user,
+// then Keycloak and Che server deployments are rolled out, and the password of the former user is revoked
+// once the rollout is complete. The password of Keycloak administrator is changed right away,
+// since it's used by the Operator only.
+// Returns true if the credentials have been rotated.
+func (r *ReconcileChe) ReconcileCredentialsRotation(deployContext *deploy.DeployContext) (bool, error) {
+	cheCluster := deployContext.CheCluster
+	credentials := r.getRotatedCredentials(deployContext)
+
+	// the previous rotation must be complete before the credentials are rotated again
+	revoked, err := revokeFormerCredentials(deployContext, credentials)
+	if err != nil {
+		r.recordEventf(cheCluster, corev1.EventTypeWarning, CredentialsRotationFailedEventReason, "Failed to revoke former credentials: %s", err.Error())
+		return false, err
+	}
+	if !revoked {
+		logrus.Info("Waiting for the components to use the rotated credentials to revoke the former ones")
+		return false, nil
+	}
+
+	if !isCredentialsRotationDue(cheCluster) {
+		return false, nil
+	}
+
+	if len(credentials) == 0 {
+		logrus.Info("No credentials managed by the Operator to rotate")
+		return false, removeRotateCredentialsAnnotation(deployContext)
+	}
+
+	// credentials can be applied only when components are running
+	if !cheCluster.Spec.Database.ExternalDb && !orgv1.IsConditionTrue(cheCluster.Status.Conditions, orgv1.ConditionPostgresReady) ||
+		deploy.IsKeycloakDeployed(cheCluster) && !orgv1.IsConditionTrue(cheCluster.Status.Conditions, orgv1.ConditionKeycloakReady) {
+		logrus.Info("Waiting for PostgreSQL and Keycloak to be ready to rotate credentials")
+		return false, nil
+	}
+
+	for _, c := range credentials {
+		if err := rotateSecretCredentials(deployContext, c); err != nil {
+			r.recordEventf(cheCluster, corev1.EventTypeWarning, CredentialsRotationFailedEventReason, "Failed to rotate credentials stored in the secret '%s': %s", c.secretName, err.Error())
+			return false, err
+		}
+	}
+
+	now := metav1.Now()
+	cheCluster.Status.LastCredentialsRotationTime = &now
+	if err := r.UpdateCheCRStatus(cheCluster, "status: last credentials rotation time", now.String()); err != nil {
+		return false, err
+	}
+	if err := removeRotateCredentialsAnnotation(deployContext); err != nil {
+		return false, err
+	}
+
+	r.recordEventf(cheCluster, corev1.EventTypeNormal, CredentialsRotatedEventReason, "Credentials stored in %d secret(s) have been rotated", len(credentials))
+	return true, nil
+}
+
+// removeRotateCredentialsAnnotation removes the annotation requesting credentials rotation once it is handled.
+func removeRotateCredentialsAnnotation(deployContext *deploy.DeployContext) error {
+	if _, ok := deployContext.CheCluster.Annotations[deploy.CheEclipseOrgRotateCredentials]; !ok {
+		return nil
+	}
+	return deploy.UpdateCheCluster(deployContext, func(cheCluster *orgv1.CheCluster) {
+		delete(cheCluster.Annotations, deploy.CheEclipseOrgRotateCredentials)
+	})
+}
+
+// getRotatedCredentials returns the credentials of the accounts created by the Operator.
+// Accounts of external PostgreSQL or Keycloak are not rotated.
+func (r *ReconcileChe) getRotatedCredentials(deployContext *deploy.DeployContext) []rotatedCredentials {
+	cheCluster := deployContext.CheCluster
+	credentials := []rotatedCredentials{}
+	if deploy.GetCheMultiUser(cheCluster) != "true" {
+		return credentials
+	}
+
+	enablePostgresUser := func(user string, password string, newUser string, newPassword string) error {
+		return r.credentialsRotator.EnablePostgresUser(deployContext, newUser, newPassword)
+	}
+	revokePostgresUserPassword := func(user string) error {
+		return r.credentialsRotator.RevokePostgresUserPassword(deployContext, user)
+	}
+
+	if !cheCluster.Spec.Database.ExternalDb {
+		if cheCluster.Spec.Database.ChePostgresSecret != "" {
+			credentials = append(credentials, rotatedCredentials{
+				secretName: cheCluster.Spec.Database.ChePostgresSecret,
+				getNewUser: postgres.GetStandbyUser,
+				apply:      enablePostgresUser,
+				revoke:     revokePostgresUserPassword,
+				clients:    []string{deploy.DefaultCheFlavor(cheCluster)},
+			})
+		}
+		if deploy.IsKeycloakDeployed(cheCluster) && cheCluster.Spec.Auth.IdentityProviderPostgresSecret != "" {
+			credentials = append(credentials, rotatedCredentials{
+				secretName:  cheCluster.Spec.Auth.IdentityProviderPostgresSecret,
+				defaultUser: postgres.KeycloakPostgresUser,
+				getNewUser:  postgres.GetStandbyUser,
+				apply:       enablePostgresUser,
+				revoke:      revokePostgresUserPassword,
+				clients:     []string{deploy.IdentityProviderName},
+			})
+		}
+	}
+
+	if deploy.IsKeycloakDeployed(cheCluster) && cheCluster.Spec.Auth.IdentityProviderSecret != "" {
+		credentials = append(credentials, rotatedCredentials{
+			secretName: cheCluster.Spec.Auth.IdentityProviderSecret,
+			apply: func(user string, password string, newUser string, newPassword string) error {
+				return r.credentialsRotator.UpdateKeycloakAdminPassword(deployContext, user, password, newPassword)
+			},
+		})
+	}
+
+	return credentials
+}
+
+// rotateSecretCredentials generates a new password, applies it and stores it in the secret.
+func rotateSecretCredentials(deployContext *deploy.DeployContext, credentials rotatedCredentials) error {
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: credentials.secretName, Namespace: deployContext.CheCluster.Namespace}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), key, secret); err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	newPassword := string(secret.Data[pendingPasswordSecretKey])
+	if newPassword == "" {
+		newPassword = util.GeneratePasswd(12)
+		secret.Data[pendingPasswordSecretKey] = []byte(newPassword)
+		if err := deployContext.ClusterAPI.Client.Update(context.TODO(), secret); err != nil {
+			return err
+		}
+	}
+
+	user := util.GetValue(string(secret.Data["user"]), credentials.defaultUser)
+	newUser := user
+	if credentials.getNewUser != nil {
+		newUser = credentials.getNewUser(user)
+	}
+
+	if err := credentials.apply(user, string(secret.Data["password"]), newUser, newPassword); err != nil {
+		return err
+	}
+
+	if newUser != user {
+		secret.Data["user"] = []byte(newUser)
+		secret.Data[revokedUserSecretKey] = []byte(user)
+	}
+	secret.Data["password"] = []byte(newPassword)
+	delete(secret.Data, pendingPasswordSecretKey)
+	if err := deployContext.ClusterAPI.Client.Update(context.TODO(), secret); err != nil {
+		return err
+	}
+
+	logrus.Infof("Credentials stored in the secret '%s' have been rotated", credentials.secretName)
+	return nil
+}
+
+// revokeFormerCredentials revokes the passwords of the users which have been replaced by the standby ones
+// once all the clients have been rolled out with the new credentials.
+// Returns true if there are no former credentials left to revoke.
+func revokeFormerCredentials(deployContext *deploy.DeployContext, credentials []rotatedCredentials) (bool, error) {
+	done := true
+	for _, c := range credentials {
+		if c.revoke == nil {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		key := types.NamespacedName{Name: c.secretName, Namespace: deployContext.CheCluster.Namespace}
+		if err := deployContext.ClusterAPI.Client.Get(context.TODO(), key, secret); err != nil {
+			return false, err
+		}
+		formerUser := string(secret.Data[revokedUserSecretKey])
+		if formerUser == "" {
+			continue
+		}
+
+		rolledOut, err := areClientsRolledOut(deployContext, c.clients)
+		if err != nil {
+			return false, err
+		}
+		if !rolledOut {
+			done = false
+			continue
+		}
+
+		if err := c.revoke(formerUser); err != nil {
+			return false, err
+		}
+		delete(secret.Data, revokedUserSecretKey)
+		if err := deployContext.ClusterAPI.Client.Update(context.TODO(), secret); err != nil {
+			return false, err
+		}
+		logrus.Infof("Former credentials stored in the secret '%s' have been revoked", c.secretName)
+	}
+	return done, nil
+}
+
+// areClientsRolledOut checks if all the pods of the given deployments have been recreated
+// after the last credentials rotation.
+func areClientsRolledOut(deployContext *deploy.DeployContext, clients []string) (bool, error) {
+	rotatedAt := deploy.GetCredentialsRotationAnnotations(deployContext.CheCluster)[deploy.CheEclipseOrgCredentialsRotatedAt]
+	for _, name := range clients {
+		deployment, err := deploy.GetClusterDeployment(name, deployContext.CheCluster.Namespace, deployContext.ClusterAPI.Client)
+		if err != nil {
+			return false, err
+		}
+		if deployment == nil {
+			continue
+		}
+
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		status := deployment.Status
+		if deployment.Spec.Template.Annotations[deploy.CheEclipseOrgCredentialsRotatedAt] != rotatedAt ||
+			status.ObservedGeneration < deployment.Generation ||
+			status.UpdatedReplicas != replicas || status.AvailableReplicas != replicas || status.Replicas != replicas {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isCredentialsRotationDue checks if credentials rotation is requested or the rotation interval has elapsed.
+func isCredentialsRotationDue(cheCluster *orgv1.CheCluster) bool {
+	if cheCluster.Annotations[deploy.CheEclipseOrgRotateCredentials] == "true" {
+		return true
+	}
+
+	delay, ok := getCredentialsRotationDelay(cheCluster)
+	return ok && delay <= 0
+}
+
+// getCredentialsRotationDelay returns the time left until the next scheduled credentials rotation.
+// Returns false if the rotation interval isn't set.
+func getCredentialsRotationDelay(cheCluster *orgv1.CheCluster) (time.Duration, bool) {
+	value := cheCluster.Annotations[deploy.CheEclipseOrgCredentialsRotationInterval]
+	if value == "" {
+		return 0, false
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		logrus.Errorf("Invalid credentials rotation interval '%s': %v", value, err)
+		return 0, false
+	}
+
+	lastRotationTime := cheCluster.ObjectMeta.CreationTimestamp
+	if cheCluster.Status.LastCredentialsRotationTime != nil {
+		lastRotationTime = *cheCluster.Status.LastCredentialsRotationTime
+	}
+	return time.Until(lastRotationTime.Add(interval)), true
+}
+
+// GetCredentialsRotationRequeueDelay returns the delay after which the CheCluster should be reconciled
+// to rotate credentials on time, or zero if the rotation interval isn't set.
+func GetCredentialsRotationRequeueDelay(cheCluster *orgv1.CheCluster) time.Duration {
+	delay, ok := getCredentialsRotationDelay(cheCluster)
+	if !ok {
+		return 0
+	}
+	if delay < time.Second {
+		return time.Second
+	}
+	return delay
+}
@@ -0,0 +1,305 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	che_mocks "github.com/eclipse-che/che-operator/mocks/pkg/controller/che"
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/golang/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func getCredentialsRotationTestObjects() (*orgv1.CheCluster, []runtime.Object) {
+	cheCluster := &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "eclipse-che",
+			Namespace: "eclipse-che",
+			Annotations: map[string]string{
+				deploy.CheEclipseOrgRotateCredentials: "true",
+			},
+		},
+		Spec: orgv1.CheClusterSpec{
+			Database: orgv1.CheClusterSpecDB{
+				ChePostgresSecret: "che-postgres-secret",
+			},
+			Auth: orgv1.CheClusterSpecAuth{
+				IdentityProviderSecret:         "che-identity-secret",
+				IdentityProviderPostgresSecret: "che-identity-postgres-secret",
+			},
+		},
+		Status: orgv1.CheClusterStatus{
+			Conditions: []orgv1.CheClusterCondition{
+				{Type: orgv1.ConditionPostgresReady, Status: metav1.ConditionTrue},
+				{Type: orgv1.ConditionKeycloakReady, Status: metav1.ConditionTrue},
+			},
+		},
+	}
+
+	newSecret := func(name string, data map[string]string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "eclipse-che"},
+			Data:       map[string][]byte{},
+		}
+		for key, value := range data {
+			secret.Data[key] = []byte(value)
+		}
+		return secret
+	}
+
+	return cheCluster, []runtime.Object{
+		cheCluster.DeepCopy(),
+		newSecret("che-postgres-secret", map[string]string{"user": "pgche", "password": "pgche-password"}),
+		newSecret("che-identity-postgres-secret", map[string]string{"password": "keycloak-password"}),
+		newSecret("che-identity-secret", map[string]string{"user": "admin", "password": "admin-password"}),
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: deploy.DefaultCheFlavor(cheCluster), Namespace: "eclipse-che"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: deploy.IdentityProviderName, Namespace: "eclipse-che"}},
+	}
+}
+
+func getSecretData(t *testing.T, cli client.Client, name string) map[string][]byte {
+	secret := &corev1.Secret{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "eclipse-che"}, secret); err != nil {
+		t.Fatalf("Failed to get secret '%s': %v", name, err)
+	}
+	return secret.Data
+}
+
+func TestReconcileCredentialsRotation(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cheCluster, objects := getCredentialsRotationTestObjects()
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, objects...)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	rotator := che_mocks.NewMockCredentialsRotator(ctrl)
+
+	r := &ReconcileChe{client: cli, nonCachedClient: cli, scheme: scheme.Scheme, credentialsRotator: rotator}
+	deployContext := &deploy.DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: deploy.ClusterAPI{Client: cli, NonCachedClient: cli, Scheme: scheme.Scheme},
+	}
+
+	newPasswords := map[string]string{}
+	rotator.EXPECT().EnablePostgresUser(deployContext, "pgche_standby", gomock.Any()).DoAndReturn(
+		func(deployContext *deploy.DeployContext, user string, password string) error {
+			newPasswords["che-postgres-secret"] = password
+			return nil
+		})
+	rotator.EXPECT().EnablePostgresUser(deployContext, "keycloak_standby", gomock.Any()).DoAndReturn(
+		func(deployContext *deploy.DeployContext, user string, password string) error {
+			newPasswords["che-identity-postgres-secret"] = password
+			return nil
+		})
+	rotator.EXPECT().UpdateKeycloakAdminPassword(deployContext, "admin", "admin-password", gomock.Any()).DoAndReturn(
+		func(deployContext *deploy.DeployContext, user string, password string, newPassword string) error {
+			newPasswords["che-identity-secret"] = newPassword
+			return nil
+		})
+
+	rotated, err := r.ReconcileCredentialsRotation(deployContext)
+	if err != nil || !rotated {
+		t.Fatalf("Credentials are expected to be rotated: %v", err)
+	}
+
+	for name, oldPassword := range map[string]string{
+		"che-postgres-secret":          "pgche-password",
+		"che-identity-postgres-secret": "keycloak-password",
+		"che-identity-secret":          "admin-password",
+	} {
+		data := getSecretData(t, cli, name)
+		if string(data["password"]) == oldPassword || string(data["password"]) != newPasswords[name] {
+			t.Errorf("Secret '%s' is expected to contain the applied password '%s', but got '%s'", name, newPasswords[name], string(data["password"]))
+		}
+		if _, ok := data[pendingPasswordSecretKey]; ok {
+			t.Errorf("Secret '%s' is not expected to contain the pending password", name)
+		}
+	}
+	for name, users := range map[string][2]string{
+		"che-postgres-secret":          {"pgche_standby", "pgche"},
+		"che-identity-postgres-secret": {"keycloak_standby", "keycloak"},
+		"che-identity-secret":          {"admin", ""},
+	} {
+		data := getSecretData(t, cli, name)
+		if string(data["user"]) != users[0] || string(data[revokedUserSecretKey]) != users[1] {
+			t.Errorf("Secret '%s' is expected to contain the user '%s' and the revoked user '%s', but got: %v", name, users[0], users[1], data)
+		}
+	}
+
+	actual := &orgv1.CheCluster{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "eclipse-che", Namespace: "eclipse-che"}, actual); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if actual.Status.LastCredentialsRotationTime == nil {
+		t.Error("Last credentials rotation time is expected to be set")
+	}
+	if _, ok := actual.Annotations[deploy.CheEclipseOrgRotateCredentials]; ok {
+		t.Error("Credentials rotation annotation is expected to be removed")
+	}
+	if deploy.GetCredentialsRotationAnnotations(cheCluster) == nil {
+		t.Error("Deployments are expected to be rolled out")
+	}
+
+	// the former passwords are kept until the deployments are rolled out
+	rotated, err = r.ReconcileCredentialsRotation(deployContext)
+	if err != nil || rotated {
+		t.Fatalf("Credentials are not expected to be rotated again: %v", err)
+	}
+
+	for _, name := range []string{deploy.DefaultCheFlavor(cheCluster), deploy.IdentityProviderName} {
+		deployment := &appsv1.Deployment{}
+		if err := cli.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "eclipse-che"}, deployment); err != nil {
+			t.Fatalf("Failed to get deployment '%s': %v", name, err)
+		}
+		deployment.Spec.Template.Annotations = deploy.GetCredentialsRotationAnnotations(cheCluster)
+		deployment.Status = appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+		if err := cli.Update(context.TODO(), deployment); err != nil {
+			t.Fatalf("Failed to update deployment '%s': %v", name, err)
+		}
+	}
+
+	rotator.EXPECT().RevokePostgresUserPassword(deployContext, "pgche").Return(nil)
+	rotator.EXPECT().RevokePostgresUserPassword(deployContext, "keycloak").Return(nil)
+	rotated, err = r.ReconcileCredentialsRotation(deployContext)
+	if err != nil || rotated {
+		t.Fatalf("Credentials are not expected to be rotated again: %v", err)
+	}
+	for _, name := range []string{"che-postgres-secret", "che-identity-postgres-secret"} {
+		if _, ok := getSecretData(t, cli, name)[revokedUserSecretKey]; ok {
+			t.Errorf("Secret '%s' is not expected to contain the revoked user", name)
+		}
+	}
+
+	// rotation is done, nothing to do anymore
+	rotated, err = r.ReconcileCredentialsRotation(deployContext)
+	if err != nil || rotated {
+		t.Fatalf("Credentials are not expected to be rotated again: %v", err)
+	}
+}
+
+func TestReconcileCredentialsRotationResumesAfterFailure(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cheCluster, objects := getCredentialsRotationTestObjects()
+	cheCluster.Spec.Auth.ExternalIdentityProvider = true
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, objects...)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	rotator := che_mocks.NewMockCredentialsRotator(ctrl)
+
+	r := &ReconcileChe{client: cli, nonCachedClient: cli, scheme: scheme.Scheme, credentialsRotator: rotator}
+	deployContext := &deploy.DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: deploy.ClusterAPI{Client: cli, NonCachedClient: cli, Scheme: scheme.Scheme},
+	}
+
+	var pendingPassword string
+	rotator.EXPECT().EnablePostgresUser(deployContext, "pgche_standby", gomock.Any()).DoAndReturn(
+		func(deployContext *deploy.DeployContext, user string, password string) error {
+			pendingPassword = password
+			return fmt.Errorf("postgres is not available")
+		})
+
+	if _, err := r.ReconcileCredentialsRotation(deployContext); err == nil {
+		t.Fatal("Credentials rotation is expected to fail")
+	}
+
+	data := getSecretData(t, cli, "che-postgres-secret")
+	if string(data["password"]) != "pgche-password" || string(data[pendingPasswordSecretKey]) != pendingPassword {
+		t.Fatalf("Secret is expected to keep the old password and the pending one, but got: %v", data)
+	}
+
+	// the same password is applied on the next attempt,
+	// credentials of external Keycloak are not rotated
+	rotator.EXPECT().EnablePostgresUser(deployContext, "pgche_standby", pendingPassword).Return(nil)
+	rotated, err := r.ReconcileCredentialsRotation(deployContext)
+	if err != nil || !rotated {
+		t.Fatalf("Credentials are expected to be rotated: %v", err)
+	}
+
+	data = getSecretData(t, cli, "che-postgres-secret")
+	if string(data["user"]) != "pgche_standby" || string(data["password"]) != pendingPassword {
+		t.Errorf("Secret is expected to contain the user 'pgche_standby' and the password '%s', but got: %v", pendingPassword, data)
+	}
+	if string(getSecretData(t, cli, "che-identity-secret")["password"]) != "admin-password" {
+		t.Error("Credentials of external Identity Provider are not expected to be rotated")
+	}
+}
+
+func TestGetCredentialsRotationRequeueDelay(t *testing.T) {
+	type testCase struct {
+		name             string
+		interval         string
+		lastRotationTime time.Time
+		minDelay         time.Duration
+		maxDelay         time.Duration
+	}
+
+	testCases := []testCase{
+		{
+			name:     "Rotation interval is not set",
+			minDelay: 0,
+			maxDelay: 0,
+		},
+		{
+			name:     "Invalid rotation interval",
+			interval: "month",
+			minDelay: 0,
+			maxDelay: 0,
+		},
+		{
+			name:             "Rotation is scheduled",
+			interval:         "24h",
+			lastRotationTime: time.Now().Add(-time.Hour),
+			minDelay:         22 * time.Hour,
+			maxDelay:         23 * time.Hour,
+		},
+		{
+			name:             "Rotation is overdue",
+			interval:         "1h",
+			lastRotationTime: time.Now().Add(-2 * time.Hour),
+			minDelay:         time.Second,
+			maxDelay:         time.Second,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cheCluster := &orgv1.CheCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations:       map[string]string{deploy.CheEclipseOrgCredentialsRotationInterval: testCase.interval},
+					CreationTimestamp: metav1.NewTime(testCase.lastRotationTime),
+				},
+			}
+
+			delay := GetCredentialsRotationRequeueDelay(cheCluster)
+			if delay < testCase.minDelay || delay > testCase.maxDelay {
+				t.Errorf("Expected delay between %v and %v, but got %v", testCase.minDelay, testCase.maxDelay, delay)
+			}
+			if due := isCredentialsRotationDue(cheCluster); due != (testCase.name == "Rotation is overdue") {
+				t.Errorf("Unexpected rotation due: %t", due)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"reflect"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// planChanges computes the changes the reconcile would make to the cluster, without applying them,
+// and writes their summary into the plan config map. It is requested with the `che.eclipse.org/dry-run` annotation.
+func (r *ReconcileChe) planChanges(deployContext *deploy.DeployContext, request reconcile.Request, isOpenShift bool, isOpenShift4 bool) (reconcile.Result, error) {
+	instance := deployContext.CheCluster
+	plan := deploy.NewDryRunPlan(r.scheme)
+	dryRun, dryRunContext := r.newDryRun(plan, instance)
+	dryRun.planComponents(dryRunContext, plan, request, isOpenShift, isOpenShift4)
+
+	data := plan.ConfigMapData()
+	existing := &corev1.ConfigMap{}
+	exists, err := deploy.GetNamespacedObject(deployContext, deploy.DryRunPlanConfigMapName, existing)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if exists && reflect.DeepEqual(existing.Data, data) {
+		return reconcile.Result{}, nil
+	}
+
+	configMap := deploy.GetConfigMapSpec(deployContext, deploy.DryRunPlanConfigMapName, data, deploy.DefaultCheFlavor(instance))
+	if _, err := deploy.SyncConfigMapSpecToCluster(deployContext, configMap); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logrus.Infof("Dry run: %s", plan.Summary())
+	r.recordEventf(instance, corev1.EventTypeNormal, DryRunPlannedEventReason, "Dry run: %s, see the config map '%s'", plan.Summary(), deploy.DryRunPlanConfigMapName)
+	return reconcile.Result{}, nil
+}
+
+// newDryRun returns a copy of the reconciler which writes through the clients of the plan,
+// and the context to reconcile the CheCluster with it.
+func (r *ReconcileChe) newDryRun(plan *deploy.DryRunPlan, instance *orgv1.CheCluster) (*ReconcileChe, *deploy.DeployContext) {
+	dryRun := *r
+	dryRun.client = plan.WrapClient(r.client)
+	dryRun.nonCachedClient = plan.WrapClient(r.nonCachedClient)
+	dryRun.recorder = nil
+	dryRunContext := &deploy.DeployContext{
+		ClusterAPI: deploy.ClusterAPI{
+			Client:          dryRun.client,
+			NonCachedClient: dryRun.nonCachedClient,
+			DiscoveryClient: r.discoveryClient,
+			Scheme:          r.scheme,
+		},
+		// the components update the CheCluster in memory
+		CheCluster:      instance.DeepCopy(),
+		InternalService: deploy.InternalService{},
+		// the plan goes on when the proxy configuration fails to be read
+		Proxy:  &deploy.Proxy{},
+		DryRun: true,
+	}
+	return &dryRun, dryRunContext
+}
+
+// planComponents plans the changes to all the components, it is called on a dry run copy of the reconciler.
+func (r *ReconcileChe) planComponents(deployContext *deploy.DeployContext, plan *deploy.DryRunPlan, request reconcile.Request, isOpenShift bool, isOpenShift4 bool) {
+	if err := ValidateCheCR(deployContext.CheCluster, isOpenShift); err != nil {
+		plan.AddError("validation", err)
+		return
+	}
+
+	// the default Che host may not be known yet, the components are planned anyway
+	if _, err := r.initDeployContext(deployContext, isOpenShift); err != nil {
+		plan.AddError("initialization", err)
+	}
+	r.getReconcileManager(request, isOpenShift, isOpenShift4).PlanAll(deployContext, plan)
+}
@@ -0,0 +1,77 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestDryRun(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+
+	cl, dc, scheme := Init()
+	r := &ReconcileChe{client: cl, nonCachedClient: cl, scheme: &scheme, discoveryClient: dc, tests: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	cheCR := &orgv1.CheCluster{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	cheCR.ObjectMeta.Annotations = map[string]string{deploy.CheEclipseOrgDryRun: "true"}
+	if err := cl.Update(context.TODO(), cheCR); err != nil {
+		t.Fatalf("Failed to update CheCluster: %v", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("Failed to reconcile: %v", err)
+	}
+
+	// the changes are planned, not applied
+	plan := &corev1.ConfigMap{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: deploy.DryRunPlanConfigMapName, Namespace: namespace}, plan); err != nil {
+		t.Fatalf("Plan config map not found: %v", err)
+	}
+	cheDeployment := "Deployment " + namespace + "/" + deploy.DefaultCheFlavor(cheCR)
+	if !strings.Contains(plan.Data["create"], cheDeployment) {
+		t.Fatalf("Creation of '%s' is expected to be planned, but got: %s", cheDeployment, plan.Data["create"])
+	}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: deploy.DefaultCheFlavor(cheCR), Namespace: namespace}, &appsv1.Deployment{}); err == nil {
+		t.Fatalf("Che deployment is not expected to be created by a dry run")
+	}
+
+	// the plan is removed once the changes are applied
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	cheCR.ObjectMeta.Annotations = map[string]string{}
+	if err := cl.Update(context.TODO(), cheCR); err != nil {
+		t.Fatalf("Failed to update CheCluster: %v", err)
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("Failed to reconcile: %v", err)
+	}
+
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: deploy.DryRunPlanConfigMapName, Namespace: namespace}, &corev1.ConfigMap{}); err == nil {
+		t.Fatalf("Plan config map is expected to be removed")
+	}
+}
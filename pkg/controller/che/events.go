@@ -0,0 +1,54 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"fmt"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+)
+
+// Reasons of the events emitted by the controller.
+// They are part of the Operator API, so don't change them.
+const (
+	InvalidConfigurationEventReason       = "InvalidConfiguration"
+	WaitingEventReason                    = "Waiting"
+	ReconcileFailedEventReason            = "ReconcileFailed"
+	ComponentReadyEventReason             = "ComponentReady"
+	ReadyEventReason                      = "Ready"
+	WorkspaceNamespaceFallbackEventReason = "WorkspaceNamespaceFallback"
+	LegacyConfigMapDeletedEventReason     = "LegacyConfigMapDeleted"
+	OpenShiftOAuthEnabledEventReason      = "OpenShiftOAuthEnabled"
+	OpenShiftOAuthDisabledEventReason     = "OpenShiftOAuthDisabled"
+	OpenShiftOAuthRemovedEventReason      = "OpenShiftOAuthRemoved"
+	InitialUserDeletedEventReason         = "InitialOpenShiftOAuthUserDeleted"
+	CredentialsRotatedEventReason         = "CredentialsRotated"
+	CredentialsRotationFailedEventReason  = "CredentialsRotationFailed"
+	DryRunPlannedEventReason              = "DryRunPlanned"
+	ReconcilePausedEventReason            = "ReconcilePaused"
+	ReconcileResumedEventReason           = "ReconcileResumed"
+	MaintenanceModeEnabledEventReason     = "MaintenanceModeEnabled"
+	MaintenanceModeDisabledEventReason    = "MaintenanceModeDisabled"
+)
+
+// recordEvent emits an event regarding the CheCluster custom resource.
+// Does nothing if the event recorder is not configured, e.g. in tests.
+func (r *ReconcileChe) recordEvent(instance *orgv1.CheCluster, eventType string, reason string, message string) {
+	if r.recorder == nil {
+		return
+	}
+	r.recorder.Event(instance, eventType, reason, message)
+}
+
+func (r *ReconcileChe) recordEventf(instance *orgv1.CheCluster, eventType string, reason string, messageFmt string, args ...interface{}) {
+	r.recordEvent(instance, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
@@ -0,0 +1,56 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileShouldRecordEvents(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+
+	cl, dc, scheme := Init()
+	recorder := record.NewFakeRecorder(1000)
+	r := &ReconcileChe{client: cl, nonCachedClient: cl, scheme: &scheme, discoveryClient: dc, tests: true, recorder: recorder}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+
+	for _, reason := range []string{deploy.ObjectCreatedEventReason, ComponentReadyEventReason, ReadyEventReason} {
+		found := false
+		for _, event := range events {
+			if strings.HasPrefix(event, "Normal "+reason+" ") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Event with reason '%s' not found, recorded events: %v", reason, events)
+		}
+	}
+}
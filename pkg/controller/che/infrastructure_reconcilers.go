@@ -0,0 +1,508 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	devworkspace "github.com/eclipse-che/che-operator/pkg/deploy/dev-workspace"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// imagePullerReconciler configures the Kubernetes Image Puller, which pre-pulls the workspace images on the nodes.
+// Its readiness is reported only once it is configured or when it fails, not while its operator is being installed.
+type imagePullerReconciler struct {
+	r *ReconcileChe
+}
+
+func (p *imagePullerReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	instance := deployContext.CheCluster
+	result, err := deploy.ReconcileImagePuller(deployContext)
+	if err != nil {
+		p.r.SetComponentCondition(instance, orgv1.ConditionImagePullerReady, false, err)
+		return false, err
+	}
+	if result.Requeue || result.RequeueAfter > 0 {
+		return false, nil
+	}
+
+	if instance.Spec.ImagePuller.Enable {
+		err = p.r.SetComponentCondition(instance, orgv1.ConditionImagePullerReady, true, nil)
+	} else {
+		err = p.r.RemoveComponentCondition(instance, orgv1.ConditionImagePullerReady)
+	}
+	return err == nil, err
+}
+
+func (p *imagePullerReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	if deploy.HasImagePullerFinalizer(deployContext.CheCluster) {
+		return deploy.ReconcileImagePullerFinalizer(deployContext)
+	}
+	return nil
+}
+
+// openShiftOAuthReconciler detects whether OpenShift OAuth can be used and manages the initial OpenShift OAuth user.
+type openShiftOAuthReconciler struct {
+	r            *ReconcileChe
+	request      reconcile.Request
+	isOpenShift  bool
+	isOpenShift4 bool
+}
+
+func (o *openShiftOAuthReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	r := o.r
+	instance := deployContext.CheCluster
+	if o.isOpenShift4 && util.IsDeleteOAuthInitialUser(instance) && !deployContext.DryRun {
+		if err := r.userHandler.DeleteOAuthInitialUser(deployContext); err != nil {
+			logrus.Errorf("Unable to delete initial OpenShift OAuth user from a cluster. Cause: %s", err.Error())
+			// Don't try to delete initial user any more, che-operator shouldn't hang on this step.
+			instance.Status.OpenShiftOAuthUserCredentialsSecret = ""
+			err := r.UpdateCheCRStatus(instance, "openShiftOAuthUserCredentialsSecret", "")
+			return false, err
+		}
+
+		r.recordEvent(instance, corev1.EventTypeNormal, InitialUserDeletedEventReason, "Initial OpenShift OAuth user has been deleted")
+
+		// OpenShift OAuth will be detected again
+		instance.Status.OpenShiftoAuthAutoDetected = nil
+		err := r.UpdateCheCRStatus(instance, "openShiftoAuthAutoDetected", "nil")
+		return false, err
+	}
+
+	if instance.Spec.Auth.InitialOpenShiftOAuthUser == nil && instance.Status.OpenShiftOAuthUserCredentialsSecret != "" {
+		secret, err := deploy.GetSecret(deployContext, openShiftOAuthUserCredentialsSecret, instance.Namespace)
+		if err != nil {
+			return false, err
+		}
+		if secret == nil {
+			instance.Status.OpenShiftOAuthUserCredentialsSecret = ""
+			if err := r.UpdateCheCRStatus(instance, "openShiftOAuthUserCredentialsSecret", ""); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if o.isOpenShift && instance.Spec.Auth.OpenShiftoAuth == nil {
+		if _, err := r.autoEnableOAuth(deployContext, o.request, o.isOpenShift4); err != nil {
+			return false, err
+		}
+	}
+
+	if util.IsOpenShift && util.IsOAuthEnabled(instance) {
+		if err := deploy.ReconcileOAuthClientFinalizer(deployContext); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (o *openShiftOAuthReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	if util.IsOpenShift && util.IsOAuthEnabled(deployContext.CheCluster) {
+		return deploy.ReconcileOAuthClientFinalizer(deployContext)
+	}
+	return nil
+}
+
+// devWorkspaceReconciler deploys the Dev Workspace operator.
+type devWorkspaceReconciler struct {
+	isOpenShift4 bool
+}
+
+func (d *devWorkspaceReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	return devworkspace.ReconcileDevWorkspace(deployContext)
+}
+
+func (d *devWorkspaceReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+func (d *devWorkspaceReconciler) Condition(deployContext *deploy.DeployContext) (string, bool) {
+	instance := deployContext.CheCluster
+	return orgv1.ConditionDevWorkspaceReady, d.isOpenShift4 && util.IsOAuthEnabled(instance) && instance.Spec.DevWorkspace.Enable
+}
+
+// proxyReconciler reads the proxy configuration and provisions the CA certificates trusted by the cluster proxy.
+type proxyReconciler struct {
+	r *ReconcileChe
+}
+
+func (p *proxyReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	r := p.r
+	instance := deployContext.CheCluster
+	proxy, err := r.getProxyConfiguration(instance)
+	if err != nil {
+		return false, err
+	}
+	deployContext.Proxy = proxy
+
+	if proxy.TrustedCAMapName != "" {
+		provisioned, err := r.putOpenShiftCertsIntoConfigMap(deployContext)
+		if !provisioned {
+			configMapName := instance.Spec.Server.ServerTrustStoreConfigMapName
+			if err != nil {
+				r.recordEventf(instance, corev1.EventTypeWarning, ReconcileFailedEventReason, "Failed to provision config map '%s': %v", configMapName, err)
+			} else {
+				r.recordEventf(instance, corev1.EventTypeNormal, WaitingEventReason, "Waiting on provisioning config map '%s'", configMapName)
+			}
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (p *proxyReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+// cheAvailabilityReconciler reports Che as unavailable as long as its deployment doesn't exist.
+type cheAvailabilityReconciler struct {
+	r           *ReconcileChe
+	request     reconcile.Request
+	isOpenShift bool
+}
+
+func (a *cheAvailabilityReconciler) IsEnabled(deployContext *deploy.DeployContext) bool {
+	return a.isOpenShift && !a.r.tests
+}
+
+func (a *cheAvailabilityReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	instance := deployContext.CheCluster
+	if instance.Status.CheClusterRunning == UnavailableStatus {
+		return true, nil
+	}
+
+	exists, err := deploy.GetNamespacedObject(deployContext, deploy.DefaultCheFlavor(instance), &appsv1.Deployment{})
+	if !exists {
+		if err := a.r.SetCheUnavailableStatus(instance, a.request); err != nil {
+			return false, err
+		}
+	}
+	return true, err
+}
+
+func (a *cheAvailabilityReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+// tlsReconciler provisions the TLS certificates of Che endpoints and of the cluster endpoints Che relies on.
+type tlsReconciler struct {
+	r            *ReconcileChe
+	isOpenShift  bool
+	isOpenShift4 bool
+}
+
+func (t *tlsReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	instance := deployContext.CheCluster
+
+	// Detect whether self-signed certificate is used
+	selfSignedCertUsed, err := deploy.IsSelfSignedCertificateUsed(deployContext)
+	if err != nil {
+		return false, fmt.Errorf("failed to detect if self-signed certificate used, cause: %v", err)
+	}
+
+	if t.isOpenShift {
+		// create a secret with router tls cert when on OpenShift infra and router is configured with a self signed certificate
+		if selfSignedCertUsed ||
+			// To use Openshift v4 OAuth, the OAuth endpoints are served from a namespace
+			// and NOT from the Openshift API Master URL (as in v3)
+			// So we also need the self-signed certificate to access them (same as the Che server)
+			(t.isOpenShift4 && util.IsOAuthEnabled(instance) && !instance.Spec.Server.TlsSupport) {
+			if err := deploy.CreateTLSSecretFromEndpoint(deployContext, "", deploy.CheTLSSelfSignedCertificateSecretName); err != nil {
+				return false, err
+			}
+		}
+
+		// the OpenShift API certificate isn't rendered offline
+		if util.IsOAuthEnabled(instance) && !deployContext.Offline {
+			// create a secret with OpenShift API crt to be added to keystore that RH SSO will consume
+			baseURL, err := util.GetClusterPublicHostname(t.isOpenShift4)
+			if err != nil {
+				logrus.Errorf("Failed to get OpenShift cluster public hostname. A secret with API crt will not be created and consumed by RH-SSO/Keycloak")
+			} else {
+				if err := deploy.CreateTLSSecretFromEndpoint(deployContext, baseURL, "openshift-api-crt"); err != nil {
+					return false, err
+				}
+			}
+		}
+	} else {
+		// Handle Che TLS certificates on Kubernetes infrastructure,
+		// unless they are issued by cert-manager once the endpoints are exposed
+		if instance.Spec.Server.TlsSupport && !deploy.UseCertManagerCertificate(deployContext, deploy.DefaultCheFlavor(instance)) {
+			if instance.Spec.K8s.TlsSecretName != "" {
+				// Self-signed certificate should be created to secure Che ingresses
+				result, err := deploy.K8sHandleCheTLSSecrets(deployContext)
+				if result.Requeue || result.RequeueAfter > 0 {
+					if err != nil {
+						logrus.Error(err)
+					}
+					if !t.r.tests {
+						return false, err
+					}
+				}
+			} else if selfSignedCertUsed {
+				// Use default self-signed ingress certificate
+				if err := deploy.CreateTLSSecretFromEndpoint(deployContext, "", deploy.CheTLSSelfSignedCertificateSecretName); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+
+	// Record the expiry of the TLS certificates and warn about the ones which expire soon
+	if err := deploy.SyncTLSCertificatesStatus(deployContext); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (t *tlsReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+// caBundleReconciler merges the CA certificates from all marked config maps into a single config map
+// to be propagated to Che components.
+type caBundleReconciler struct {
+	r *ReconcileChe
+}
+
+func (c *caBundleReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	done, err := deploy.SyncAdditionalCACertsConfigMapToCluster(deployContext)
+	if err != nil {
+		return false, err
+	}
+	// When the config map update is in progress, its update will trigger the next reconcile
+	return done || c.r.tests, nil
+}
+
+func (c *caBundleReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+// legacyConfigMapsReconciler applies or removes the config maps used by former versions of the Operator.
+type legacyConfigMapsReconciler struct {
+	r *ReconcileChe
+}
+
+func (l *legacyConfigMapsReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	r := l.r
+	instance := deployContext.CheCluster
+
+	// Get custom ConfigMap
+	// if it exists, add the data into CustomCheProperties in memory, the values set in the spec take precedence
+	customConfigMap := &corev1.ConfigMap{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: "custom"}, customConfigMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil {
+		logrus.Infof("Found legacy custom ConfigMap.  Adding those values to CheCluster.Spec.Server.CustomCheProperties")
+		if instance.Spec.Server.CustomCheProperties == nil {
+			instance.Spec.Server.CustomCheProperties = make(map[string]string)
+		}
+		for k, v := range customConfigMap.Data {
+			if _, ok := instance.Spec.Server.CustomCheProperties[k]; !ok {
+				instance.Spec.Server.CustomCheProperties[k] = v
+			}
+		}
+	}
+
+	// If the devfile-registry ConfigMap exists, and we are not in airgapped mode, delete the ConfigMap
+	devfileRegistryConfigMap := &corev1.ConfigMap{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: deploy.DevfileRegistryName}, devfileRegistryConfigMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil && instance.Spec.Server.ExternalDevfileRegistry {
+		logrus.Info("Found devfile-registry ConfigMap and while using an external devfile registry.  Deleting.")
+		if err = r.client.Delete(context.TODO(), devfileRegistryConfigMap); err != nil {
+			return false, err
+		}
+		r.recordEventf(instance, corev1.EventTypeNormal, LegacyConfigMapDeletedEventReason, "Config map '%s' has been deleted since an external devfile registry is used", deploy.DevfileRegistryName)
+		return false, nil
+	}
+
+	// If the plugin-registry ConfigMap exists, and we are not in airgapped mode, delete the ConfigMap
+	pluginRegistryConfigMap := &corev1.ConfigMap{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: deploy.PluginRegistryName}, pluginRegistryConfigMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil && !instance.IsAirGapMode() {
+		logrus.Info("Found plugin-registry ConfigMap and not in airgap mode.  Deleting.")
+		if err = r.client.Delete(context.TODO(), pluginRegistryConfigMap); err != nil {
+			return false, err
+		}
+		r.recordEventf(instance, corev1.EventTypeNormal, LegacyConfigMapDeletedEventReason, "Config map '%s' has been deleted since Che is not in the air gap mode", deploy.PluginRegistryName)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (l *legacyConfigMapsReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
+
+// rbacReconciler grants the permissions needed by Che server and the workspaces.
+type rbacReconciler struct {
+	r *ReconcileChe
+}
+
+func (b *rbacReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	r := b.r
+	instance := deployContext.CheCluster
+
+	if err := r.reconcileWorkspacePermissionsFinalizer(deployContext); err != nil {
+		return false, err
+	}
+
+	// Create service account "che" for che-server component.
+	// "che" is the one which token is used to create workspace objects.
+	// Notice: Also we have on more "che-workspace" SA used by plugins like exec, terminal, metrics with limited privileges.
+	cheSA, err := deploy.SyncServiceAccountToCluster(deployContext, CheServiceAccountName)
+	if cheSA == nil {
+		r.recordEvent(instance, corev1.EventTypeNormal, WaitingEventReason, "Waiting on service account 'che' to be created")
+		if err != nil {
+			logrus.Error(err)
+		}
+		if !r.tests {
+			return false, err
+		}
+	}
+
+	if !util.IsOAuthEnabled(instance) && !util.IsWorkspaceInSameNamespaceWithChe(instance) {
+		сheWorkspacesClusterRoleName := fmt.Sprintf(CheWorkspacesClusterRoleNameTemplate, instance.Namespace)
+		exists, err := deploy.Get(deployContext, types.NamespacedName{Name: сheWorkspacesClusterRoleName}, &rbac.ClusterRole{})
+		if err != nil {
+			return false, err
+		}
+		if !exists {
+			policies := append(getCheWorkspacesNamespacePolicy(), getCheWorkspacesPolicy()...)
+			deniedRules, err := r.permissionChecker.GetNotPermittedPolicyRules(policies, "")
+			if err != nil {
+				return false, err
+			}
+			// fall back to the "narrower" workspace namespace strategy
+			if len(deniedRules) > 0 {
+				logrus.Warnf("Not enough permissions to start a workspace in dedicated namespace. Denied policies: %v", deniedRules)
+				logrus.Warnf("Fall back to '%s' namespace for workspaces.", instance.Namespace)
+				r.recordEventf(instance, corev1.EventTypeWarning, WorkspaceNamespaceFallbackEventReason,
+					"Not enough permissions to start a workspace in dedicated namespace. Fall back to '%s' namespace for workspaces.", instance.Namespace)
+				delete(instance.Spec.Server.CustomCheProperties, "CHE_INFRA_KUBERNETES_NAMESPACE_DEFAULT")
+				instance.Spec.Server.WorkspaceNamespaceDefault = instance.Namespace
+			} else {
+				reconcileResult, err := r.delegateWorkspacePermissionsInTheDifferNamespaceThanChe(deployContext)
+				if err != nil || reconcileResult.Requeue {
+					return false, err
+				}
+			}
+		}
+	}
+
+	if util.IsOAuthEnabled(instance) || util.IsWorkspaceInSameNamespaceWithChe(instance) {
+		reconcileResult, err := r.delegateWorkspacePermissionsInTheSameNamespaceWithChe(deployContext)
+		if err != nil {
+			logrus.Error(err)
+		}
+		if reconcileResult.Requeue && !r.tests {
+			return false, err
+		}
+	}
+
+	for _, cheClusterRole := range getCheClusterRoles(instance) {
+		cheClusterRoleBindingName := deploy.GetUniqueClusterRoleBindingName(deployContext, CheServiceAccountName, cheClusterRole)
+		done, err := deploy.SyncClusterRoleBindingAndAddFinalizerToCluster(deployContext, cheClusterRoleBindingName, CheServiceAccountName, cheClusterRole)
+		if !done && !r.tests {
+			r.recordEventf(instance, corev1.EventTypeNormal, WaitingEventReason, "Waiting on cluster role binding '%s' to be created", cheClusterRoleBindingName)
+			return false, err
+		}
+	}
+
+	// If the user specified an additional cluster role to use for the Che workspace, create a role binding for it
+	// Use a role binding instead of a cluster role binding to keep the additional access scoped to the workspace's namespace
+	workspaceClusterRole := instance.Spec.Server.CheWorkspaceClusterRole
+	if workspaceClusterRole != "" {
+		cheWSCustomRoleBinding, err := deploy.SyncRoleBindingToCluster(deployContext, "che-workspace-custom", "view", workspaceClusterRole, "ClusterRole")
+		if cheWSCustomRoleBinding == nil {
+			r.recordEvent(instance, corev1.EventTypeNormal, WaitingEventReason, "Waiting on role binding 'che-workspace-custom' to be created")
+			if err != nil {
+				logrus.Error(err)
+			}
+			if !r.tests {
+				return false, err
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func (b *rbacReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	if err := b.r.reconcileWorkspacePermissionsFinalizer(deployContext); err != nil {
+		return err
+	}
+	for _, cheClusterRole := range getCheClusterRoles(deployContext.CheCluster) {
+		cheClusterRoleBindingName := deploy.GetUniqueClusterRoleBindingName(deployContext, CheServiceAccountName, cheClusterRole)
+		if err := deploy.ReconcileClusterRoleBindingFinalizer(deployContext, cheClusterRoleBindingName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getCheClusterRoles returns the additional cluster roles granted to Che server.
+func getCheClusterRoles(instance *orgv1.CheCluster) []string {
+	cheClusterRoles := []string{}
+	if len(instance.Spec.Server.CheClusterRoles) > 0 {
+		for _, cheClusterRole := range strings.Split(instance.Spec.Server.CheClusterRoles, ",") {
+			cheClusterRoles = append(cheClusterRoles, strings.TrimSpace(cheClusterRole))
+		}
+	}
+	return cheClusterRoles
+}
+
+// credentialsReconciler generates the credentials which are not provided by the user and rotates them.
+type credentialsReconciler struct {
+	r *ReconcileChe
+}
+
+func (c *credentialsReconciler) Reconcile(deployContext *deploy.DeployContext) (bool, error) {
+	if err := c.r.GenerateFields(deployContext); err != nil {
+		return false, err
+	}
+
+	// The credentials are applied to the running components, so their rotation can't be planned
+	if deployContext.DryRun {
+		return true, nil
+	}
+
+	// Rotate credentials before the deployments using them are synced, so they are rolled out right away
+	if _, err := c.r.ReconcileCredentialsRotation(deployContext); err != nil {
+		return false, fmt.Errorf("failed to rotate credentials: %v", err)
+	}
+	return true, nil
+}
+
+func (c *credentialsReconciler) Finalize(deployContext *deploy.DeployContext) error {
+	return nil
+}
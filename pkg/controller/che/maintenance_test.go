@@ -0,0 +1,176 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	routev1 "github.com/openshift/api/route/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestPausedReconcile(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+
+	cl, dc, scheme := Init()
+	r := &ReconcileChe{client: cl, nonCachedClient: cl, scheme: &scheme, discoveryClient: dc, tests: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	cheCR := setCheClusterAnnotations(t, cl, req, map[string]string{deploy.CheEclipseOrgReconcile: "paused"})
+	reconcileTimes(t, r, req, 4)
+
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if !orgv1.IsConditionTrue(cheCR.Status.Conditions, orgv1.ConditionPaused) {
+		t.Fatalf("Condition %s is expected to be true", orgv1.ConditionPaused)
+	}
+	cheDeploymentName := types.NamespacedName{Name: deploy.DefaultCheFlavor(cheCR), Namespace: namespace}
+	if err := cl.Get(context.TODO(), cheDeploymentName, &appsv1.Deployment{}); err == nil {
+		t.Fatalf("Che deployment is not expected to be created while the reconciliation is paused")
+	}
+
+	// the reconciliation is resumed once the annotation is removed
+	setCheClusterAnnotations(t, cl, req, map[string]string{})
+	reconcileTimes(t, r, req, 4)
+
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if orgv1.FindCondition(cheCR.Status.Conditions, orgv1.ConditionPaused) != nil {
+		t.Fatalf("Condition %s is expected to be removed", orgv1.ConditionPaused)
+	}
+	if err := cl.Get(context.TODO(), cheDeploymentName, &appsv1.Deployment{}); err != nil {
+		t.Fatalf("Che deployment is expected to be created: %v", err)
+	}
+}
+
+func TestPausedReconcileFinalizesDeletedCheCluster(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+
+	cl, dc, scheme := Init()
+	r := &ReconcileChe{client: cl, nonCachedClient: cl, scheme: &scheme, discoveryClient: dc, tests: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	cheCR := setCheClusterAnnotations(t, cl, req, map[string]string{deploy.CheEclipseOrgReconcile: "paused"})
+	now := metav1.Now()
+	cheCR.ObjectMeta.DeletionTimestamp = &now
+	cheCR.ObjectMeta.Finalizers = []string{cheWorkspacesClusterPermissionsFinalizerName}
+	if err := cl.Update(context.TODO(), cheCR); err != nil {
+		t.Fatalf("Failed to update CheCluster: %v", err)
+	}
+	reconcileTimes(t, r, req, 1)
+
+	cheCR = &orgv1.CheCluster{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if len(cheCR.ObjectMeta.Finalizers) != 0 {
+		t.Fatalf("CheCluster is expected to be finalized while the reconciliation is paused, but got finalizers: %v", cheCR.ObjectMeta.Finalizers)
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+
+	cl, dc, scheme := Init()
+	r := &ReconcileChe{client: cl, nonCachedClient: cl, scheme: &scheme, discoveryClient: dc, tests: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	cheCR := setCheClusterAnnotations(t, cl, req, map[string]string{deploy.CheEclipseOrgMaintenanceMode: "true"})
+	reconcileTimes(t, r, req, 4)
+
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if !orgv1.IsConditionTrue(cheCR.Status.Conditions, orgv1.ConditionMaintenanceMode) {
+		t.Fatalf("Condition %s is expected to be true", orgv1.ConditionMaintenanceMode)
+	}
+	if cheCR.Status.CheClusterRunning != MaintenanceModeStatus {
+		t.Fatalf("Status '%s' is expected, but got '%s'", MaintenanceModeStatus, cheCR.Status.CheClusterRunning)
+	}
+
+	cheDeployment := &appsv1.Deployment{}
+	cheDeploymentName := types.NamespacedName{Name: deploy.DefaultCheFlavor(cheCR), Namespace: namespace}
+	if err := cl.Get(context.TODO(), cheDeploymentName, cheDeployment); err != nil {
+		t.Fatalf("Failed to get Che deployment: %v", err)
+	}
+	if *cheDeployment.Spec.Replicas != 0 {
+		t.Fatalf("Che deployment is expected to be scaled down, but got %d replicas", *cheDeployment.Spec.Replicas)
+	}
+	maintenancePageName := types.NamespacedName{Name: deploy.MaintenancePageName, Namespace: namespace}
+	if err := cl.Get(context.TODO(), maintenancePageName, &appsv1.Deployment{}); err != nil {
+		t.Fatalf("Maintenance page deployment is expected to be created: %v", err)
+	}
+	route := &routev1.Route{}
+	if err := cl.Get(context.TODO(), cheDeploymentName, route); err != nil {
+		t.Fatalf("Failed to get Che route: %v", err)
+	}
+	if route.Spec.To.Name != deploy.MaintenancePageName {
+		t.Fatalf("Che route is expected to be routed to '%s', but got '%s'", deploy.MaintenancePageName, route.Spec.To.Name)
+	}
+
+	// everything is restored once the maintenance mode is disabled
+	setCheClusterAnnotations(t, cl, req, map[string]string{})
+	reconcileTimes(t, r, req, 4)
+
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if orgv1.FindCondition(cheCR.Status.Conditions, orgv1.ConditionMaintenanceMode) != nil {
+		t.Fatalf("Condition %s is expected to be removed", orgv1.ConditionMaintenanceMode)
+	}
+	if err := cl.Get(context.TODO(), cheDeploymentName, cheDeployment); err != nil {
+		t.Fatalf("Failed to get Che deployment: %v", err)
+	}
+	if *cheDeployment.Spec.Replicas != 1 {
+		t.Fatalf("Che deployment is expected to be scaled up, but got %d replicas", *cheDeployment.Spec.Replicas)
+	}
+	if err := cl.Get(context.TODO(), maintenancePageName, &appsv1.Deployment{}); err == nil {
+		t.Fatalf("Maintenance page deployment is expected to be removed")
+	}
+	if err := cl.Get(context.TODO(), cheDeploymentName, route); err != nil {
+		t.Fatalf("Failed to get Che route: %v", err)
+	}
+	if route.Spec.To.Name != deploy.CheServiceName {
+		t.Fatalf("Che route is expected to be routed to '%s', but got '%s'", deploy.CheServiceName, route.Spec.To.Name)
+	}
+}
+
+func setCheClusterAnnotations(t *testing.T, cl client.Client, req reconcile.Request, annotations map[string]string) *orgv1.CheCluster {
+	cheCR := &orgv1.CheCluster{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCR); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	cheCR.ObjectMeta.Annotations = annotations
+	if err := cl.Update(context.TODO(), cheCR); err != nil {
+		t.Fatalf("Failed to update CheCluster: %v", err)
+	}
+	return cheCR
+}
+
+// reconcileTimes reconciles the CheCluster as many times as the components need to be deployed one after the other.
+func reconcileTimes(t *testing.T, r *ReconcileChe, req reconcile.Request, times int) {
+	for i := 0; i < times; i++ {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("Failed to reconcile: %v", err)
+		}
+	}
+}
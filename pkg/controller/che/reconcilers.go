@@ -0,0 +1,53 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// getReconcileManager registers the components of Che installation in the order they are reconciled.
+// A component is reconciled only once the previous ones are up to date.
+// The components which depend on the configuration of the CheCluster tell themselves whether they are enabled.
+func (r *ReconcileChe) getReconcileManager(request reconcile.Request, isOpenShift bool, isOpenShift4 bool) *deploy.ReconcileManager {
+	manager := deploy.NewReconcileManager(r)
+
+	// infrastructure which Che components rely on
+	manager.RegisterReconciler("image puller", &imagePullerReconciler{r: r})
+	manager.RegisterReconciler("OpenShift OAuth", &openShiftOAuthReconciler{r: r, request: request, isOpenShift: isOpenShift, isOpenShift4: isOpenShift4})
+	manager.RegisterReconciler("Dev Workspace", &devWorkspaceReconciler{isOpenShift4: isOpenShift4})
+	manager.RegisterReconciler("proxy", &proxyReconciler{r: r})
+	manager.RegisterReconciler("Che availability", &cheAvailabilityReconciler{r: r, request: request, isOpenShift: isOpenShift})
+	manager.RegisterReconciler("TLS certificates", &tlsReconciler{r: r, isOpenShift: isOpenShift, isOpenShift4: isOpenShift4})
+	manager.RegisterReconciler("CA certificates bundle", &caBundleReconciler{r: r})
+	manager.RegisterReconciler("legacy config maps", &legacyConfigMapsReconciler{r: r})
+	manager.RegisterReconciler("RBAC", &rbacReconciler{r: r})
+	manager.RegisterReconciler("credentials", &credentialsReconciler{r: r})
+
+	// Che components
+	manager.RegisterReconciler("Che volume", &cheVolumeReconciler{r: r})
+	manager.RegisterReconciler("PostgreSQL", &postgresReconciler{r: r})
+	manager.RegisterReconciler("maintenance page", &maintenancePageReconciler{r: r})
+	manager.RegisterReconciler("Che endpoint", &cheEndpointReconciler{r: r, isOpenShift: isOpenShift})
+	manager.RegisterReconciler("Keycloak", &keycloakReconciler{r: r})
+	manager.RegisterReconciler("OpenID Connect provider", &openIdConnectProviderReconciler{r: r})
+	manager.RegisterReconciler("devfile registry", &devfileRegistryReconciler{r: r})
+	manager.RegisterReconciler("plugin registry", &pluginRegistryReconciler{r: r})
+	manager.RegisterReconciler("Che config map", &cheConfigMapReconciler{r: r})
+	manager.RegisterReconciler("gateway", &gatewayReconciler{})
+	manager.RegisterReconciler("Che server", &cheServerReconciler{r: r, request: request})
+	manager.RegisterReconciler("console link", &consoleLinkReconciler{})
+	manager.RegisterReconciler("OpenShift identity provider", &openShiftIdentityProviderReconciler{r: r, isOpenShift4: isOpenShift4})
+
+	return manager
+}
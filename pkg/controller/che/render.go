@@ -0,0 +1,277 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"bytes"
+	"strings"
+
+	image_puller_api "github.com/che-incubator/kubernetes-image-puller-operator/pkg/apis"
+	"github.com/eclipse-che/che-operator/pkg/apis"
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	configv1 "github.com/openshift/api/config/v1"
+	consolev1 "github.com/openshift/api/console/v1"
+	oauth "github.com/openshift/api/oauth/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	userv1 "github.com/openshift/api/user/v1"
+	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	packagesv1 "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// the components wait on the objects created by the previous ones,
+	// so they are reconciled again until no more objects are rendered
+	maxRenderPasses = 5
+
+	redactedSecretValue = "<redacted>"
+)
+
+// Render returns the objects the Operator would create for the CheCluster on the given platform, without contacting a cluster,
+// along with the errors of the components which failed to be rendered. It backs the `render` subcommand of the Operator.
+// The objects have no owner references, since the CheCluster has no UID yet. The values of the secrets and the generated
+// certificates are redacted, the generated passwords are rendered as references to the rendered secrets holding them,
+// or as placeholders.
+func Render(cheCluster *orgv1.CheCluster, isOpenShift bool, isOpenShift4 bool) ([]runtime.Object, []string, error) {
+	util.IsOpenShift, util.IsOpenShift4 = isOpenShift, isOpenShift4
+
+	scheme, err := newRenderScheme()
+	if err != nil {
+		return nil, nil, err
+	}
+	if cheCluster.Namespace == "" {
+		cheCluster.Namespace = "eclipse-che"
+	}
+
+	// The objects are rendered into an empty in-memory cluster,
+	// with the cluster configuration which always exists on OpenShift 4, without a cluster-wide proxy
+	objects := []runtime.Object{cheCluster.DeepCopy()}
+	if isOpenShift4 {
+		objects = append(objects,
+			&configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}},
+			&configv1.OAuth{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}})
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, objects...)
+	r := &ReconcileChe{
+		client:             cl,
+		nonCachedClient:    cl,
+		scheme:             scheme,
+		discoveryClient:    &fakeDiscovery.FakeDiscovery{Fake: &clienttesting.Fake{}},
+		userHandler:        NewOpenShiftOAuthUserHandler(cl),
+		permissionChecker:  &offlinePermissionChecker{},
+		credentialsRotator: &ClientCredentialsRotator{},
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: cheCluster.Namespace, Name: cheCluster.Name}}
+
+	instance := cheCluster.DeepCopy()
+	deploy.SetCheClusterDefaults(instance)
+	deploy.SetCheClusterStatusDefaults(instance)
+	if err := ValidateCheCR(instance, isOpenShift); err != nil {
+		return nil, nil, err
+	}
+
+	plan := deploy.NewDryRunPlan(scheme)
+	dryRun, deployContext := r.newDryRun(plan, instance)
+	deployContext.Offline = true
+	for i := 0; i < maxRenderPasses; i++ {
+		rendered := len(plan.Changes())
+		plan.ResetErrors()
+		dryRun.planComponents(deployContext, plan, request, isOpenShift, isOpenShift4)
+		if len(plan.Changes()) == rendered {
+			break
+		}
+	}
+
+	rendered := []runtime.Object{}
+	for _, object := range plan.Objects() {
+		gvk, err := apiutil.GVKForObject(object, scheme)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the CheCluster is the input of the rendering
+		if gvk.Kind == "CheCluster" {
+			continue
+		}
+		object.GetObjectKind().SetGroupVersionKind(gvk)
+		// the CheCluster doesn't exist yet, so the objects can't refer to its UID
+		objectMeta, err := meta.Accessor(object)
+		if err != nil {
+			return nil, nil, err
+		}
+		objectMeta.SetOwnerReferences(nil)
+		rendered = append(rendered, object)
+	}
+
+	redactGeneratedPasswords(rendered)
+	for _, object := range rendered {
+		switch object := object.(type) {
+		case *corev1.Secret:
+			redactSecret(object)
+		case *corev1.ConfigMap:
+			redactCertificates(object)
+		}
+	}
+	return rendered, plan.Errors(), nil
+}
+
+// MarshalRenderedObjects serializes the rendered objects into a multi-document YAML.
+func MarshalRenderedObjects(objects []runtime.Object) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, object := range objects {
+		data, err := yaml.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		buffer.WriteString("---\n")
+		buffer.Write(data)
+	}
+	return buffer.Bytes(), nil
+}
+
+// redactGeneratedPasswords replaces the generated passwords, which differ on each installation, in the rendered deployments.
+// The environment variables set to a password stored in a rendered secret reference the secret key instead,
+// the other passwords, including the ones embedded in the commands, are replaced with a placeholder.
+func redactGeneratedPasswords(objects []runtime.Object) {
+	secretKeys := map[string]corev1.SecretKeySelector{}
+	for _, object := range objects {
+		if secret, ok := object.(*corev1.Secret); ok {
+			for key, value := range secret.Data {
+				if isPasswordKey(key) && len(value) > 0 {
+					secretKeys[string(value)] = corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name}, Key: key}
+				}
+			}
+			for key, value := range secret.StringData {
+				if isPasswordKey(key) && value != "" {
+					secretKeys[value] = corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name}, Key: key}
+				}
+			}
+		}
+	}
+
+	passwords := []string{}
+	for password := range secretKeys {
+		passwords = append(passwords, password)
+	}
+	containers := []*corev1.Container{}
+	for _, object := range objects {
+		if deployment, ok := object.(*appsv1.Deployment); ok {
+			podSpec := &deployment.Spec.Template.Spec
+			for i := range podSpec.InitContainers {
+				containers = append(containers, &podSpec.InitContainers[i])
+			}
+			for i := range podSpec.Containers {
+				containers = append(containers, &podSpec.Containers[i])
+			}
+		}
+	}
+	for _, container := range containers {
+		for i := range container.Env {
+			env := &container.Env[i]
+			if env.Value == "" {
+				continue
+			}
+			if selector, ok := secretKeys[env.Value]; ok {
+				env.Value = ""
+				env.ValueFrom = &corev1.EnvVarSource{SecretKeyRef: &selector}
+			} else if strings.Contains(strings.ToUpper(env.Name), "PASSWORD") {
+				passwords = append(passwords, env.Value)
+				env.Value = redactedSecretValue
+			}
+		}
+	}
+	for _, container := range containers {
+		for _, args := range [][]string{container.Command, container.Args} {
+			for i := range args {
+				for _, password := range passwords {
+					args[i] = strings.ReplaceAll(args[i], password, redactedSecretValue)
+				}
+			}
+		}
+	}
+}
+
+func isPasswordKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "secret")
+}
+
+func redactSecret(secret *corev1.Secret) {
+	if len(secret.Data) == 0 && len(secret.StringData) == 0 {
+		return
+	}
+
+	stringData := map[string]string{}
+	for key := range secret.Data {
+		stringData[key] = redactedSecretValue
+	}
+	for key := range secret.StringData {
+		stringData[key] = redactedSecretValue
+	}
+	secret.Data = nil
+	secret.StringData = stringData
+}
+
+// redactCertificates redacts the certificates and the keys of the config map, which are generated along with the secrets.
+func redactCertificates(configMap *corev1.ConfigMap) {
+	for key, value := range configMap.Data {
+		if strings.Contains(value, "-----BEGIN CERTIFICATE-----") || strings.Contains(value, "PRIVATE KEY-----") {
+			configMap.Data[key] = redactedSecretValue
+		}
+	}
+}
+
+func newRenderScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		apis.AddToScheme,
+		apiextensionsv1.AddToScheme,
+		routev1.AddToScheme,
+		oauth.AddToScheme,
+		userv1.AddToScheme,
+		configv1.AddToScheme,
+		consolev1.AddToScheme,
+		image_puller_api.AddToScheme,
+		packagesv1.AddToScheme,
+		operatorsv1.AddToScheme,
+		operatorsv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			return nil, err
+		}
+	}
+	return scheme, nil
+}
+
+// offlinePermissionChecker assumes every permission is granted to the Operator, as they can't be checked offline.
+type offlinePermissionChecker struct{}
+
+func (pc *offlinePermissionChecker) GetNotPermittedPolicyRules(policies []rbac.PolicyRule, namespace string) ([]rbac.PolicyRule, error) {
+	return []rbac.PolicyRule{}, nil
+}
@@ -0,0 +1,182 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the rendered objects")
+
+func TestRender(t *testing.T) {
+	isOpenShift, isOpenShift4 := util.IsOpenShift, util.IsOpenShift4
+	defer func() {
+		util.IsOpenShift, util.IsOpenShift4 = isOpenShift, isOpenShift4
+	}()
+
+	type testCase struct {
+		name         string
+		isOpenShift  bool
+		isOpenShift4 bool
+	}
+
+	testCases := []testCase{
+		{name: "kubernetes", isOpenShift: false, isOpenShift4: false},
+		{name: "openshift3", isOpenShift: true, isOpenShift4: false},
+		{name: "openshift4", isOpenShift: true, isOpenShift4: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cheCluster := &orgv1.CheCluster{}
+			data, err := ioutil.ReadFile("../../../deploy/crds/org_v1_che_cr.yaml")
+			if err != nil {
+				t.Fatalf("Failed to read CheCluster: %v", err)
+			}
+			if err := yaml.Unmarshal(data, cheCluster); err != nil {
+				t.Fatalf("Failed to parse CheCluster: %v", err)
+			}
+
+			objects, errors, err := Render(cheCluster, testCase.isOpenShift, testCase.isOpenShift4)
+			if err != nil {
+				t.Fatalf("Failed to render: %v", err)
+			}
+			if len(errors) != 0 {
+				t.Fatalf("Failed to render the components: %v", errors)
+			}
+
+			// the objects of the Che server are compared, the other components embed generated passwords
+			cheObjects := []runtime.Object{}
+			for _, object := range objects {
+				objectMeta, err := meta.Accessor(object)
+				if err != nil {
+					t.Fatalf("Failed to get the metadata of the rendered object: %v", err)
+				}
+				for _, ownerReference := range objectMeta.GetOwnerReferences() {
+					if ownerReference.UID == "" {
+						t.Errorf("Owner reference of '%s' is expected to have a UID: %v", objectMeta.GetName(), ownerReference)
+					}
+				}
+				switch object := object.(type) {
+				case *appsv1.Deployment:
+					if object.Name == "che" {
+						cheObjects = append(cheObjects, object)
+					}
+				case *corev1.ConfigMap:
+					if object.Name == "che" {
+						cheObjects = append(cheObjects, object)
+					}
+				case *corev1.Secret:
+					for key, value := range object.StringData {
+						if value != redactedSecretValue {
+							t.Errorf("Value of the key '%s' of the secret '%s' is expected to be redacted", key, object.Name)
+						}
+					}
+				}
+			}
+			if len(cheObjects) != 2 {
+				t.Fatalf("Che deployment and config map are expected to be rendered, but got %d objects", len(cheObjects))
+			}
+
+			actual, err := MarshalRenderedObjects(cheObjects)
+			if err != nil {
+				t.Fatalf("Failed to marshal the rendered objects: %v", err)
+			}
+			golden := filepath.Join("testdata", "render", testCase.name+".yaml")
+			if *updateGolden {
+				if err := ioutil.WriteFile(golden, actual, 0644); err != nil {
+					t.Fatalf("Failed to update the golden file: %v", err)
+				}
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Failed to read the golden file: %v", err)
+			}
+			if !bytes.Equal(expected, actual) {
+				t.Errorf("Rendered objects differ from '%s', run the test with -update to update it:\n%s", golden, diffLines(string(expected), string(actual)))
+			}
+		})
+	}
+}
+
+// diffLines returns the first line which differs.
+func diffLines(expected string, actual string) string {
+	expectedLines, actualLines := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	for i := 0; i < len(expectedLines) && i < len(actualLines); i++ {
+		if expectedLines[i] != actualLines[i] {
+			return "- " + expectedLines[i] + "\n+ " + actualLines[i]
+		}
+	}
+	return "line count differs"
+}
+
+func TestRedactGeneratedPasswords(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "che-postgres-secret"},
+		Data:       map[string][]byte{"user": []byte("pgche"), "password": []byte("generatedDbPassword")},
+	}
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Command: []string{"/bin/sh", "-c", "keytool -storepass generatedTrustPassword -noprompt"},
+							Env: []corev1.EnvVar{
+								{Name: "POSTGRESQL_USER", Value: "pgche"},
+								{Name: "POSTGRESQL_PASSWORD", Value: "generatedDbPassword"},
+								{Name: "TRUSTSTORE_PASSWORD", Value: "generatedTrustPassword"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	redactGeneratedPasswords([]runtime.Object{secret, deployment})
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	expectedEnv := []corev1.EnvVar{
+		{Name: "POSTGRESQL_USER", Value: "pgche"},
+		{
+			Name: "POSTGRESQL_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "che-postgres-secret"},
+					Key:                  "password",
+				},
+			},
+		},
+		{Name: "TRUSTSTORE_PASSWORD", Value: redactedSecretValue},
+	}
+	if !reflect.DeepEqual(container.Env, expectedEnv) {
+		t.Errorf("Unexpected env, expected: %v, got: %v", expectedEnv, container.Env)
+	}
+	if expectedCommand := "keytool -storepass " + redactedSecretValue + " -noprompt"; container.Command[2] != expectedCommand {
+		t.Errorf("Unexpected command, expected: %s, got: %s", expectedCommand, container.Command[2])
+	}
+}
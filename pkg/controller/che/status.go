@@ -12,9 +12,14 @@
 package che
 
 import (
+	"fmt"
+	"strings"
+
 	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"github.com/eclipse-che/che-operator/pkg/deploy"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -22,6 +27,18 @@ const (
 	AvailableStatus               = "Available"
 	UnavailableStatus             = "Unavailable"
 	RollingUpdateInProgressStatus = "Available: Rolling update in progress"
+	MaintenanceModeStatus         = "Unavailable: Maintenance mode"
+)
+
+// Reasons of the CheCluster status conditions
+const (
+	ReconciledReason      = "Reconciled"
+	InProgressReason      = "InProgress"
+	ReconcileFailedReason = "ReconcileFailed"
+
+	PlaintextCredentialsMigratedReason = "PlaintextCredentialsMigrated"
+	ReconcilePausedReason              = "ReconcilePaused"
+	MaintenanceModeReason              = "MaintenanceMode"
 )
 
 func (r *ReconcileChe) SetCheAvailableStatus(instance *orgv1.CheCluster, request reconcile.Request, protocol string, cheHost string) (err error) {
@@ -60,6 +77,15 @@ func (r *ReconcileChe) SetCheUnavailableStatus(instance *orgv1.CheCluster, reque
 	return nil
 }
 
+func (r *ReconcileChe) SetCheMaintenanceModeStatus(instance *orgv1.CheCluster, request reconcile.Request) (err error) {
+	instance.Status.CheClusterRunning = MaintenanceModeStatus
+	if err := r.UpdateCheCRStatus(instance, "status: Che API", MaintenanceModeStatus); err != nil {
+		instance, _ = r.GetCR(request)
+		return err
+	}
+	return nil
+}
+
 func (r *ReconcileChe) SetStatusDetails(instance *orgv1.CheCluster, request reconcile.Request, reason string, message string, helpLink string) (err error) {
 	if reason != instance.Status.Reason {
 		instance.Status.Reason = reason
@@ -94,3 +120,130 @@ func (r *ReconcileChe) SetCheRollingUpdateStatus(instance *orgv1.CheCluster, req
 	}
 	return nil
 }
+
+// SetComponentCondition updates the readiness condition of a Che component according to the result of its sync.
+// Not ready component makes the whole installation `Progressing`, a failed one makes it `Degraded` as well.
+// The CR status is updated only if conditions have been changed.
+func (r *ReconcileChe) SetComponentCondition(instance *orgv1.CheCluster, conditionType string, done bool, err error) error {
+	changed := false
+	switch {
+	case done:
+		if !orgv1.IsConditionTrue(instance.Status.Conditions, conditionType) {
+			r.recordEvent(instance, corev1.EventTypeNormal, ComponentReadyEventReason, fmt.Sprintf("Condition %s is set to True", conditionType))
+		}
+		changed = setCondition(instance, conditionType, metav1.ConditionTrue, ReconciledReason, "")
+	case err != nil:
+		r.recordEvent(instance, corev1.EventTypeWarning, ReconcileFailedEventReason, fmt.Sprintf("%s: %s", conditionType, err.Error()))
+		changed = setCondition(instance, conditionType, metav1.ConditionFalse, ReconcileFailedReason, err.Error())
+		changed = setCondition(instance, orgv1.ConditionProgressing, metav1.ConditionTrue, InProgressReason, "") || changed
+		changed = setCondition(instance, orgv1.ConditionDegraded, metav1.ConditionTrue, ReconcileFailedReason, fmt.Sprintf("%s: %s", conditionType, err.Error())) || changed
+		changed = setCondition(instance, orgv1.ConditionReady, metav1.ConditionFalse, ReconcileFailedReason, "") || changed
+	default:
+		message := fmt.Sprintf("Waiting for %s", conditionType)
+		r.recordEvent(instance, corev1.EventTypeNormal, WaitingEventReason, message)
+		changed = setCondition(instance, conditionType, metav1.ConditionFalse, InProgressReason, message)
+		changed = setCondition(instance, orgv1.ConditionProgressing, metav1.ConditionTrue, InProgressReason, message) || changed
+		changed = setCondition(instance, orgv1.ConditionDegraded, metav1.ConditionFalse, InProgressReason, "") || changed
+		changed = setCondition(instance, orgv1.ConditionReady, metav1.ConditionFalse, InProgressReason, "") || changed
+	}
+
+	if !changed {
+		return nil
+	}
+	return r.UpdateCheCRStatus(instance, "condition: "+conditionType, fmt.Sprintf("%t", done))
+}
+
+// RemoveComponentCondition removes the readiness condition of a component that is not deployed by the Operator.
+func (r *ReconcileChe) RemoveComponentCondition(instance *orgv1.CheCluster, conditionType string) error {
+	if !orgv1.RemoveCondition(&instance.Status.Conditions, conditionType) {
+		return nil
+	}
+	return r.UpdateCheCRStatus(instance, "condition: "+conditionType, "removed")
+}
+
+// SetDegradedCondition marks Che installation as `Degraded` when the desired state can't be reconciled.
+func (r *ReconcileChe) SetDegradedCondition(instance *orgv1.CheCluster, reason string, message string) error {
+	changed := setCondition(instance, orgv1.ConditionDegraded, metav1.ConditionTrue, reason, message)
+	changed = setCondition(instance, orgv1.ConditionProgressing, metav1.ConditionFalse, reason, "") || changed
+	changed = setCondition(instance, orgv1.ConditionReady, metav1.ConditionFalse, reason, "") || changed
+	if !changed {
+		return nil
+	}
+	return r.UpdateCheCRStatus(instance, "condition: "+orgv1.ConditionDegraded, message)
+}
+
+// SetCredentialsMigratedCondition records the passwords which were moved from the CheCluster spec into Secrets.
+func (r *ReconcileChe) SetCredentialsMigratedCondition(instance *orgv1.CheCluster, migrated []string) error {
+	message := "Credentials moved into secrets: " + strings.Join(migrated, ", ")
+	if !setCondition(instance, orgv1.ConditionCredentialsMigrated, metav1.ConditionTrue, PlaintextCredentialsMigratedReason, message) {
+		return nil
+	}
+	return r.UpdateCheCRStatus(instance, "condition: "+orgv1.ConditionCredentialsMigrated, message)
+}
+
+// SetPausedCondition reports whether the reconciliation is paused.
+// The condition is removed once the reconciliation is resumed.
+func (r *ReconcileChe) SetPausedCondition(instance *orgv1.CheCluster, paused bool) error {
+	if !paused {
+		if !orgv1.RemoveCondition(&instance.Status.Conditions, orgv1.ConditionPaused) {
+			return nil
+		}
+		r.recordEvent(instance, corev1.EventTypeNormal, ReconcileResumedEventReason, "Reconciliation is resumed")
+		return r.UpdateCheCRStatus(instance, "condition: "+orgv1.ConditionPaused, "removed")
+	}
+
+	message := fmt.Sprintf("Reconciliation is paused with the '%s' annotation", deploy.CheEclipseOrgReconcile)
+	if !setCondition(instance, orgv1.ConditionPaused, metav1.ConditionTrue, ReconcilePausedReason, message) {
+		return nil
+	}
+	r.recordEvent(instance, corev1.EventTypeNormal, ReconcilePausedEventReason, message)
+	return r.UpdateCheCRStatus(instance, "condition: "+orgv1.ConditionPaused, "true")
+}
+
+// SetMaintenanceModeCondition reports whether Che is in maintenance mode.
+// The condition is removed once the maintenance mode is disabled.
+func (r *ReconcileChe) SetMaintenanceModeCondition(instance *orgv1.CheCluster, maintenance bool) error {
+	if !maintenance {
+		if !orgv1.RemoveCondition(&instance.Status.Conditions, orgv1.ConditionMaintenanceMode) {
+			return nil
+		}
+		r.recordEvent(instance, corev1.EventTypeNormal, MaintenanceModeDisabledEventReason, "Che server is restarted, the maintenance page is removed")
+		return r.UpdateCheCRStatus(instance, "condition: "+orgv1.ConditionMaintenanceMode, "removed")
+	}
+
+	message := "Che server is stopped, the maintenance page is shown instead"
+	if !setCondition(instance, orgv1.ConditionMaintenanceMode, metav1.ConditionTrue, MaintenanceModeReason, message) {
+		return nil
+	}
+	r.recordEvent(instance, corev1.EventTypeNormal, MaintenanceModeEnabledEventReason, message)
+	return r.UpdateCheCRStatus(instance, "condition: "+orgv1.ConditionMaintenanceMode, "true")
+}
+
+// SetReadyCondition marks Che installation as `Ready` once every component has been reconciled
+// and records the generation of the CR that has been reconciled.
+func (r *ReconcileChe) SetReadyCondition(instance *orgv1.CheCluster) error {
+	if !orgv1.IsConditionTrue(instance.Status.Conditions, orgv1.ConditionReady) {
+		r.recordEvent(instance, corev1.EventTypeNormal, ReadyEventReason, "Che installation is ready")
+	}
+	changed := setCondition(instance, orgv1.ConditionReady, metav1.ConditionTrue, ReconciledReason, "")
+	changed = setCondition(instance, orgv1.ConditionProgressing, metav1.ConditionFalse, ReconciledReason, "") || changed
+	changed = setCondition(instance, orgv1.ConditionDegraded, metav1.ConditionFalse, ReconciledReason, "") || changed
+	if instance.Status.ObservedGeneration != instance.Generation {
+		instance.Status.ObservedGeneration = instance.Generation
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return r.UpdateCheCRStatus(instance, "condition: "+orgv1.ConditionReady, "true")
+}
+
+func setCondition(instance *orgv1.CheCluster, conditionType string, status metav1.ConditionStatus, reason string, message string) bool {
+	return orgv1.SetCondition(&instance.Status.Conditions, orgv1.CheClusterCondition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: instance.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
@@ -0,0 +1,190 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package che
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSetComponentCondition(t *testing.T) {
+	cheCluster := &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Generation: 2,
+		},
+	}
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, cheCluster)
+	r := &ReconcileChe{client: cli, nonCachedClient: cli, scheme: scheme.Scheme, tests: true}
+
+	// component is being deployed
+	if err := r.SetComponentCondition(cheCluster, orgv1.ConditionPostgresReady, false, nil); err != nil {
+		t.Fatalf("Failed to set condition: %v", err)
+	}
+	assertCondition(t, cli, orgv1.ConditionPostgresReady, metav1.ConditionFalse, InProgressReason)
+	assertCondition(t, cli, orgv1.ConditionProgressing, metav1.ConditionTrue, InProgressReason)
+	assertCondition(t, cli, orgv1.ConditionReady, metav1.ConditionFalse, InProgressReason)
+
+	// component failed
+	if err := r.SetComponentCondition(cheCluster, orgv1.ConditionPostgresReady, false, fmt.Errorf("boom")); err != nil {
+		t.Fatalf("Failed to set condition: %v", err)
+	}
+	assertCondition(t, cli, orgv1.ConditionPostgresReady, metav1.ConditionFalse, ReconcileFailedReason)
+	assertCondition(t, cli, orgv1.ConditionDegraded, metav1.ConditionTrue, ReconcileFailedReason)
+
+	// component is ready
+	if err := r.SetComponentCondition(cheCluster, orgv1.ConditionPostgresReady, true, nil); err != nil {
+		t.Fatalf("Failed to set condition: %v", err)
+	}
+	assertCondition(t, cli, orgv1.ConditionPostgresReady, metav1.ConditionTrue, ReconciledReason)
+
+	// whole installation is ready
+	if err := r.SetReadyCondition(cheCluster); err != nil {
+		t.Fatalf("Failed to set condition: %v", err)
+	}
+	assertCondition(t, cli, orgv1.ConditionReady, metav1.ConditionTrue, ReconciledReason)
+	assertCondition(t, cli, orgv1.ConditionProgressing, metav1.ConditionFalse, ReconciledReason)
+	assertCondition(t, cli, orgv1.ConditionDegraded, metav1.ConditionFalse, ReconciledReason)
+
+	actual := &orgv1.CheCluster{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, actual); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if actual.Status.ObservedGeneration != 2 {
+		t.Fatalf("Expected observed generation 2, got %d", actual.Status.ObservedGeneration)
+	}
+
+	// nothing changed, so CR must not be updated
+	resourceVersion := actual.ResourceVersion
+	if err := r.SetReadyCondition(actual); err != nil {
+		t.Fatalf("Failed to set condition: %v", err)
+	}
+	if actual.ResourceVersion != resourceVersion {
+		t.Fatalf("CheCluster is not supposed to be updated")
+	}
+
+	// component is removed
+	if err := r.RemoveComponentCondition(actual, orgv1.ConditionPostgresReady); err != nil {
+		t.Fatalf("Failed to remove condition: %v", err)
+	}
+	if orgv1.FindCondition(actual.Status.Conditions, orgv1.ConditionPostgresReady) != nil {
+		t.Fatalf("Condition %s is supposed to be removed", orgv1.ConditionPostgresReady)
+	}
+}
+
+func TestReconcileSetsReadyCondition(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+
+	cl, dc, scheme := Init()
+	r := &ReconcileChe{client: cl, nonCachedClient: cl, scheme: &scheme, discoveryClient: dc, tests: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+
+	cheCluster := &orgv1.CheCluster{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCluster); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	for _, conditionType := range []string{
+		orgv1.ConditionReady,
+		orgv1.ConditionPostgresReady,
+		orgv1.ConditionKeycloakReady,
+		orgv1.ConditionDevfileRegistryReady,
+		orgv1.ConditionPluginRegistryReady,
+		orgv1.ConditionCheServerReady,
+	} {
+		if !orgv1.IsConditionTrue(cheCluster.Status.Conditions, conditionType) {
+			t.Errorf("Condition %s is expected to be true, conditions: %v", conditionType, cheCluster.Status.Conditions)
+		}
+	}
+	if orgv1.FindCondition(cheCluster.Status.Conditions, orgv1.ConditionGatewayReady) != nil {
+		t.Errorf("Condition %s is not expected when gateway is disabled", orgv1.ConditionGatewayReady)
+	}
+	if cheCluster.Status.ObservedGeneration != cheCluster.Generation {
+		t.Errorf("Expected observed generation %d, got %d", cheCluster.Generation, cheCluster.Status.ObservedGeneration)
+	}
+}
+
+func TestReconcileRemovesKeycloakConditionWithExternalIdentityProvider(t *testing.T) {
+	os.Setenv("OPENSHIFT_VERSION", "3")
+
+	cl, dc, scheme := Init()
+	r := &ReconcileChe{client: cl, nonCachedClient: cl, scheme: &scheme, discoveryClient: dc, tests: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+	assertCondition(t, cl, orgv1.ConditionKeycloakReady, metav1.ConditionTrue, ReconciledReason)
+
+	cheCluster := &orgv1.CheCluster{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCluster); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	cheCluster.Spec.Auth.ExternalIdentityProvider = true
+	cheCluster.Spec.Auth.IdentityProviderURL = "https://sso.example.com"
+	if err := cl.Update(context.TODO(), cheCluster); err != nil {
+		t.Fatalf("Failed to update CheCluster: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+
+	cheCluster = &orgv1.CheCluster{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, cheCluster); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	if orgv1.FindCondition(cheCluster.Status.Conditions, orgv1.ConditionKeycloakReady) != nil {
+		t.Errorf("Condition %s is not expected when Keycloak is not deployed, conditions: %v", orgv1.ConditionKeycloakReady, cheCluster.Status.Conditions)
+	}
+}
+
+func assertCondition(t *testing.T, cli client.Client, conditionType string, status metav1.ConditionStatus, reason string) {
+	cheCluster := &orgv1.CheCluster{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, cheCluster); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+
+	condition := orgv1.FindCondition(cheCluster.Status.Conditions, conditionType)
+	if condition == nil {
+		t.Fatalf("Condition %s not found", conditionType)
+	}
+	if condition.Status != status || condition.Reason != reason {
+		t.Fatalf("Expected condition %s to be %s (%s), got %s (%s)", conditionType, status, reason, condition.Status, condition.Reason)
+	}
+	if condition.ObservedGeneration != cheCluster.Generation {
+		t.Fatalf("Expected condition %s observed generation %d, got %d", conditionType, cheCluster.Generation, condition.ObservedGeneration)
+	}
+	if condition.LastTransitionTime.IsZero() {
+		t.Fatalf("Condition %s has no last transition time", conditionType)
+	}
+}
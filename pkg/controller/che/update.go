@@ -13,8 +13,6 @@ package che
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
 	"github.com/eclipse-che/che-operator/pkg/deploy"
 
@@ -23,13 +21,16 @@ import (
 	"github.com/eclipse-che/che-operator/pkg/util"
 	oauth "github.com/openshift/api/oauth/v1"
 	"github.com/sirupsen/logrus"
-	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// UpdateCheCRStatus updates the status of the CheCluster.
+// The spec isn't reloaded from the response, since the Operator keeps the defaults applied in memory.
 func (r *ReconcileChe) UpdateCheCRStatus(instance *orgv1.CheCluster, updatedField string, value string) (err error) {
 	logrus.Infof("Updating %s CR with %s: %s", instance.Name, updatedField, value)
+	spec := instance.Spec.DeepCopy()
 	err = r.client.Status().Update(context.TODO(), instance)
+	instance.Spec = *spec
 	if err != nil {
 		logrus.Errorf("Failed to update %s CR. Fetching the latest CR version: %s", instance.Name, err.Error())
 		return err
@@ -38,54 +39,14 @@ func (r *ReconcileChe) UpdateCheCRStatus(instance *orgv1.CheCluster, updatedFiel
 	return nil
 }
 
-// UpdateCheCRSpecByFields - updates Che CR "spec" fields by field map
-func (r *ReconcileChe) UpdateCheCRSpecByFields(instance *orgv1.CheCluster, fields map[string]string) (err error) {
-	updateInfo := []string{}
-	for updatedField, value := range fields {
-		updateInfo = append(updateInfo, fmt.Sprintf("%s: %s", updatedField, value))
-	}
-	logrus.Infof(fmt.Sprintf("Updating multiple CR %s fields: ", instance.Name) + strings.Join(updateInfo, ", "))
-
-	err = r.client.Update(context.TODO(), instance)
-	if err != nil {
-		logrus.Errorf("Failed to update %s CR: %s", instance.Name, err.Error())
-		return err
-	}
-	logrus.Infof("Custom resource %s updated", instance.Name)
-
-	return nil
-}
-
-// UpdateCheCRSpec - updates Che CR "spec" by field
-func (r *ReconcileChe) UpdateCheCRSpec(instance *orgv1.CheCluster, updatedField string, value string) (err error) {
-	logrus.Infof("Updating %s CR with %s: %s", instance.Name, updatedField, value)
-	err = r.client.Update(context.TODO(), instance)
-	if err != nil {
-		logrus.Errorf("Failed to update %s CR: %s", instance.Name, err.Error())
-		return err
-	}
-	logrus.Infof("Custom resource %s updated", instance.Name)
-	return nil
-}
-
-func (r *ReconcileChe) ReconcileIdentityProvider(instance *orgv1.CheCluster, isOpenShift4 bool) (deleted bool, err error) {
+func (r *ReconcileChe) ReconcileIdentityProvider(deployContext *deploy.DeployContext, isOpenShift4 bool) (deleted bool, err error) {
+	instance := deployContext.CheCluster
 	if !util.IsOAuthEnabled(instance) && instance.Status.OpenShiftoAuthProvisioned == true {
-		keycloakDeployment := &appsv1.Deployment{}
-		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: deploy.IdentityProviderName, Namespace: instance.Namespace}, keycloakDeployment); err != nil {
-			logrus.Errorf("Deployment %s not found: %s", keycloakDeployment.Name, err.Error())
-		}
-
 		providerName := "openshift-v3"
 		if isOpenShift4 {
 			providerName = "openshift-v4"
 		}
-		_, err := util.K8sclient.ExecIntoPod(
-			instance,
-			keycloakDeployment.Name,
-			func(cr *orgv1.CheCluster) (string, error) {
-				return identity_provider.GetIdentityProviderDeleteCommand(instance, providerName)
-			},
-			"delete OpenShift identity provider")
+		err := identity_provider.DeleteIdentityProvider(deployContext, providerName)
 		if err == nil {
 			oAuthClient := &oauth.OAuthClient{}
 			oAuthClientName := instance.Spec.Auth.OAuthClientName
@@ -0,0 +1,234 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// delay before the next attempt when the backup target or Che components are not ready yet
+	waitingRequeueDelay = 10 * time.Second
+)
+
+// Add creates the CheBackup, CheRestore and CheBackupSchedule controllers and adds them to the Manager.
+func Add(mgr manager.Manager) error {
+	if err := addBackupController(mgr); err != nil {
+		return err
+	}
+	if err := addRestoreController(mgr); err != nil {
+		return err
+	}
+	return addScheduleController(mgr)
+}
+
+func addBackupController(mgr manager.Manager) error {
+	r := &ReconcileCheBackup{
+		client:     mgr.GetClient(),
+		scheme:     mgr.GetScheme(),
+		newStorage: NewBackupStorage,
+		dumper:     &ExecDatabaseDumper{},
+		recorder:   mgr.GetEventRecorderFor("che-operator"),
+	}
+
+	c, err := controller.New("chebackup-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	// the updates of the status don't trigger a reconcile, which would bypass the backoff of the retries
+	return c.Watch(&source.Kind{Type: &orgv1.CheBackup{}}, &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{})
+}
+
+var _ reconcile.Reconciler = &ReconcileCheBackup{}
+
+// ReconcileCheBackup reconciles a CheBackup object
+type ReconcileCheBackup struct {
+	client client.Client
+	scheme *runtime.Scheme
+	// Creates the storage of the backup target
+	newStorage BackupStorageFactory
+	// Dumps the databases
+	dumper DatabaseDumper
+	// Records events regarding the backup custom resources
+	recorder record.EventRecorder
+}
+
+// Reconcile takes a snapshot of the CheCluster and stores it in the backup target.
+// A backup is done once: it either succeeds or fails, and is never retaken once completed.
+// It fails on the validation errors only, the other errors are retried.
+func (r *ReconcileCheBackup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	backup := &orgv1.CheBackup{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, backup); err != nil {
+		if errors.IsNotFound(err) {
+			// the backup may have been deleted while in progress
+			return reconcile.Result{}, revokeBackupExec(r.client, request.Namespace)
+		}
+		return reconcile.Result{}, err
+	}
+
+	if isCompleted(backup.Status.Phase) {
+		return reconcile.Result{}, revokeBackupExec(r.client, backup.Namespace)
+	}
+
+	if err := validateBackupTarget(backup.Spec.Target); err != nil {
+		return reconcile.Result{}, r.fail(backup, err)
+	}
+
+	cheCluster, err := findCheCluster(r.client, backup.Namespace, backup.Spec.CheClusterName)
+	if err != nil {
+		return r.retryOrFail(backup, err)
+	}
+
+	if len(getBackupDatabases(cheCluster)) != 0 && !orgv1.IsConditionTrue(cheCluster.Status.Conditions, orgv1.ConditionPostgresReady) {
+		return reconcile.Result{RequeueAfter: waitingRequeueDelay}, r.updateStatus(backup, orgv1.BackupPhaseInProgress, "Waiting for PostgreSQL to be ready")
+	}
+
+	if err := grantBackupExec(r.client, backup.Namespace); err != nil {
+		return r.retryOrFail(backup, err)
+	}
+
+	storage, err := r.newStorage(r.client, backup.Namespace, backup.Spec.Target)
+	if err == errStorageNotReady {
+		return reconcile.Result{RequeueAfter: waitingRequeueDelay}, r.updateStatus(backup, orgv1.BackupPhaseInProgress, "Waiting for the backup target to be ready")
+	} else if err != nil {
+		return r.retryOrFail(backup, err)
+	}
+	defer func() {
+		if err := storage.Close(); err != nil {
+			logrus.Errorf("Failed to release backup storage: %v", err)
+		}
+	}()
+
+	snapshot, err := TakeSnapshot(r.client, cheCluster)
+	if err != nil {
+		return r.retryOrFail(backup, err)
+	}
+
+	timestamp := backup.CreationTimestamp.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	snapshotName := GetSnapshotName(cheCluster, timestamp)
+	if err := storeSnapshot(storage, r.dumper, cheCluster, snapshot, snapshotName); err != nil {
+		return r.retryOrFail(backup, err)
+	}
+
+	now := metav1.Now()
+	backup.Status.SnapshotName = snapshotName
+	backup.Status.CompletionTime = &now
+	if err := r.updateStatus(backup, orgv1.BackupPhaseSucceeded, fmt.Sprintf("Snapshot '%s' has been stored", snapshotName)); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logrus.Infof("Backup '%s' of the CheCluster '%s' is stored as '%s'", backup.Name, cheCluster.Name, snapshotName)
+	recordEventf(r.recorder, backup, corev1.EventTypeNormal, BackupSucceededEventReason, "Snapshot '%s' has been stored", snapshotName)
+	return reconcile.Result{}, nil
+}
+
+// retryOrFail fails the backup if the error is a validation error, which retrying doesn't fix.
+// Otherwise the error is reported in the status and the backup is retried with a backoff.
+func (r *ReconcileCheBackup) retryOrFail(backup *orgv1.CheBackup, cause error) (reconcile.Result, error) {
+	if isValidationError(cause) {
+		return reconcile.Result{}, r.fail(backup, cause)
+	}
+
+	logrus.Errorf("Backup '%s' will be retried: %v", backup.Name, cause)
+	if err := r.updateStatus(backup, orgv1.BackupPhaseInProgress, fmt.Sprintf("Retrying: %v", cause)); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, cause
+}
+
+func (r *ReconcileCheBackup) fail(backup *orgv1.CheBackup, cause error) error {
+	logrus.Errorf("Backup '%s' failed: %v", backup.Name, cause)
+	recordEventf(r.recorder, backup, corev1.EventTypeWarning, BackupFailedEventReason, cause.Error())
+
+	now := metav1.Now()
+	backup.Status.CompletionTime = &now
+	return r.updateStatus(backup, orgv1.BackupPhaseFailed, cause.Error())
+}
+
+func (r *ReconcileCheBackup) updateStatus(backup *orgv1.CheBackup, phase string, message string) error {
+	if backup.Status.Phase == phase && backup.Status.Message == message && phase == orgv1.BackupPhaseInProgress {
+		return nil
+	}
+	backup.Status.Phase = phase
+	backup.Status.Message = message
+	if err := r.client.Status().Update(context.TODO(), backup); err != nil {
+		return err
+	}
+
+	if isCompleted(phase) {
+		return revokeBackupExec(r.client, backup.Namespace)
+	}
+	return nil
+}
+
+// findCheCluster returns the CheCluster with the given name,
+// or the only CheCluster in the namespace if the name is empty.
+func findCheCluster(cli client.Client, namespace string, name string) (*orgv1.CheCluster, error) {
+	if name != "" {
+		cheCluster := &orgv1.CheCluster{}
+		if err := cli.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, cheCluster); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, newValidationError("CheCluster '%s' not found in the namespace '%s'", name, namespace)
+			}
+			return nil, err
+		}
+		return cheCluster, nil
+	}
+
+	cheClusters := &orgv1.CheClusterList{}
+	if err := cli.List(context.TODO(), cheClusters, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	if len(cheClusters.Items) != 1 {
+		return nil, newValidationError("expected exactly one CheCluster in the namespace '%s', but found %d, set 'cheClusterName'", namespace, len(cheClusters.Items))
+	}
+	return &cheClusters.Items[0], nil
+}
+
+// validationError is an error of the configuration, retrying doesn't fix it
+type validationError struct {
+	message string
+}
+
+func (e *validationError) Error() string {
+	return e.message
+}
+
+func newValidationError(format string, args ...interface{}) error {
+	return &validationError{message: fmt.Sprintf(format, args...)}
+}
+
+func isValidationError(err error) bool {
+	_, ok := err.(*validationError)
+	return ok
+}
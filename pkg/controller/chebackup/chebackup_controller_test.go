@@ -0,0 +1,193 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeStorage keeps the snapshots in memory
+type fakeStorage struct {
+	snapshots map[string][]byte
+	ready     bool
+	// Error returned by the factory, when the storage is ready
+	err error
+}
+
+func (s *fakeStorage) factory(cli client.Client, namespace string, target orgv1.BackupTarget) (BackupStorage, error) {
+	if !s.ready {
+		return nil, errStorageNotReady
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s, nil
+}
+
+func (s *fakeStorage) Put(name string, content io.Reader) error {
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	s.snapshots[name] = data
+	return nil
+}
+
+func (s *fakeStorage) Get(name string) (io.ReadCloser, error) {
+	data, ok := s.snapshots[name]
+	if !ok {
+		return nil, &objectNotFoundError{name: name}
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *fakeStorage) Delete(name string) error {
+	delete(s.snapshots, name)
+	return nil
+}
+
+func (s *fakeStorage) Close() error {
+	return nil
+}
+
+var testBackupTarget = orgv1.BackupTarget{Pvc: &orgv1.BackupTargetPvc{ClaimName: "backups"}}
+
+func TestReconcileCheBackup(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	backup := &orgv1.CheBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "eclipse-che"},
+		Spec:       orgv1.CheBackupSpec{Target: testBackupTarget},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, getTestCheCluster(), getTestSecret("che-postgres-secret", "password"), backup)
+	storage := &fakeStorage{snapshots: map[string][]byte{}}
+	r := &ReconcileCheBackup{
+		client:     cli,
+		scheme:     scheme.Scheme,
+		newStorage: storage.factory,
+		dumper:     &fakeDumper{databases: map[string][]byte{"dbche": []byte("che dump")}},
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "backup", Namespace: "eclipse-che"}}
+
+	// backup target is not ready
+	result, err := r.Reconcile(request)
+	if err != nil || result.RequeueAfter == 0 {
+		t.Fatalf("Backup is expected to wait for the backup target: %v", err)
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, backup); err != nil {
+		t.Fatal(err)
+	}
+	if backup.Status.Phase != orgv1.BackupPhaseInProgress {
+		t.Fatalf("Backup is expected to be in progress, but got: %v", backup.Status)
+	}
+	if !isBackupExecGranted(t, cli, "eclipse-che") {
+		t.Fatal("Exec is expected to be granted while the backup is in progress")
+	}
+
+	storage.ready = true
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile backup: %v", err)
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, backup); err != nil {
+		t.Fatal(err)
+	}
+	if backup.Status.Phase != orgv1.BackupPhaseSucceeded || backup.Status.CompletionTime == nil {
+		t.Fatalf("Backup is expected to succeed, but got: %v", backup.Status)
+	}
+	if isBackupExecGranted(t, cli, "eclipse-che") {
+		t.Fatal("Exec is expected to be revoked once the backup is completed")
+	}
+
+	snapshot, err := readSnapshot(storage, backup.Status.SnapshotName)
+	if err != nil {
+		t.Fatalf("Snapshot is expected to be stored: %v", err)
+	}
+	if snapshot.CheCluster.Name != "eclipse-che" || len(snapshot.Secrets) != 1 || !reflect.DeepEqual(snapshot.Databases, []string{"dbche", "keycloak"}) {
+		t.Errorf("Unexpected snapshot: %v", snapshot)
+	}
+
+	dumper := &fakeDumper{databases: map[string][]byte{}}
+	if err := restoreDatabaseDump(storage, dumper, getTestCheCluster(), backup.Status.SnapshotName, "dbche"); err != nil {
+		t.Fatalf("Database dump is expected to be stored next to the snapshot: %v", err)
+	}
+	if string(dumper.databases["dbche"]) != "che dump" {
+		t.Errorf("Unexpected database dump: %s", dumper.databases["dbche"])
+	}
+}
+
+func TestReconcileCheBackupRetriesOnStorageError(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	backup := &orgv1.CheBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "eclipse-che"},
+		Spec:       orgv1.CheBackupSpec{Target: testBackupTarget},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, getTestCheCluster(), backup)
+	storage := &fakeStorage{snapshots: map[string][]byte{}, ready: true, err: fmt.Errorf("connection refused")}
+	r := &ReconcileCheBackup{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: &fakeDumper{databases: map[string][]byte{}}}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "backup", Namespace: "eclipse-che"}}
+
+	if _, err := r.Reconcile(request); err == nil {
+		t.Fatal("Backup is expected to be retried")
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, backup); err != nil {
+		t.Fatal(err)
+	}
+	if backup.Status.Phase != orgv1.BackupPhaseInProgress {
+		t.Fatalf("Backup is expected to be in progress, but got: %v", backup.Status)
+	}
+
+	storage.err = nil
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile backup: %v", err)
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, backup); err != nil {
+		t.Fatal(err)
+	}
+	if backup.Status.Phase != orgv1.BackupPhaseSucceeded {
+		t.Fatalf("Backup is expected to succeed, but got: %v", backup.Status)
+	}
+}
+
+func TestReconcileCheBackupFailsWithoutCheCluster(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	backup := &orgv1.CheBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "eclipse-che"},
+		Spec:       orgv1.CheBackupSpec{Target: testBackupTarget, CheClusterName: "che"},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, getTestCheCluster(), backup)
+	storage := &fakeStorage{snapshots: map[string][]byte{}, ready: true}
+	r := &ReconcileCheBackup{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: &fakeDumper{}}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "backup", Namespace: "eclipse-che"}}
+
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile backup: %v", err)
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, backup); err != nil {
+		t.Fatal(err)
+	}
+	if backup.Status.Phase != orgv1.BackupPhaseFailed || len(storage.snapshots) != 0 {
+		t.Fatalf("Backup is expected to fail, but got: %v", backup.Status)
+	}
+}
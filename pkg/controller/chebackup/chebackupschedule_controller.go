@@ -0,0 +1,214 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// BackupScheduleLabelKey is the label of the CheBackup set to the name of the schedule which created it
+	BackupScheduleLabelKey = "che.eclipse.org/backup-schedule"
+)
+
+func addScheduleController(mgr manager.Manager) error {
+	r := &ReconcileCheBackupSchedule{
+		client:     mgr.GetClient(),
+		scheme:     mgr.GetScheme(),
+		newStorage: NewBackupStorage,
+		recorder:   mgr.GetEventRecorderFor("che-operator"),
+	}
+
+	c, err := controller.New("chebackupschedule-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Kind{Type: &orgv1.CheBackupSchedule{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	// completed backups are pruned
+	return c.Watch(&source.Kind{Type: &orgv1.CheBackup{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &orgv1.CheBackupSchedule{},
+	})
+}
+
+var _ reconcile.Reconciler = &ReconcileCheBackupSchedule{}
+
+// ReconcileCheBackupSchedule reconciles a CheBackupSchedule object
+type ReconcileCheBackupSchedule struct {
+	client client.Client
+	scheme *runtime.Scheme
+	// Creates the storage of the backup target, to delete the pruned snapshots
+	newStorage BackupStorageFactory
+	// Records events regarding the backup custom resources
+	recorder record.EventRecorder
+	// Returns the current time, can be replaced in tests
+	now func() time.Time
+}
+
+// Reconcile creates a CheBackup once the interval since the last one has elapsed,
+// and deletes the completed backups exceeding the number of backups to keep.
+func (r *ReconcileCheBackupSchedule) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	schedule := &orgv1.CheBackupSchedule{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, schedule); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	interval, err := time.ParseDuration(schedule.Spec.Interval)
+	if err == nil && interval <= 0 {
+		err = fmt.Errorf("interval must be positive")
+	}
+	if err != nil {
+		return reconcile.Result{}, r.updateStatusMessage(schedule, fmt.Sprintf("Invalid interval '%s': %v", schedule.Spec.Interval, err))
+	}
+	if err := validateBackupTarget(schedule.Spec.Target); err != nil {
+		return reconcile.Result{}, r.updateStatusMessage(schedule, err.Error())
+	}
+
+	if err := r.pruneBackups(schedule); err == errStorageNotReady {
+		return reconcile.Result{RequeueAfter: waitingRequeueDelay}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	now := r.getNow()
+	if schedule.Status.LastBackupTime != nil {
+		if delay := schedule.Status.LastBackupTime.Add(interval).Sub(now); delay > 0 {
+			return reconcile.Result{RequeueAfter: delay}, nil
+		}
+	}
+
+	backup := &orgv1.CheBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", schedule.Name, now.UTC().Format("20060102150405")),
+			Namespace: schedule.Namespace,
+			Labels:    map[string]string{BackupScheduleLabelKey: schedule.Name},
+		},
+		Spec: orgv1.CheBackupSpec{
+			CheClusterName: schedule.Spec.CheClusterName,
+			Target:         schedule.Spec.Target,
+		},
+	}
+	if err := controllerutil.SetControllerReference(schedule, backup, r.scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.client.Create(context.TODO(), backup); err != nil && !errors.IsAlreadyExists(err) {
+		return reconcile.Result{}, err
+	}
+
+	lastBackupTime := metav1.NewTime(now)
+	schedule.Status.LastBackupName = backup.Name
+	schedule.Status.LastBackupTime = &lastBackupTime
+	schedule.Status.Message = ""
+	if err := r.client.Status().Update(context.TODO(), schedule); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logrus.Infof("Backup '%s' has been created by the schedule '%s'", backup.Name, schedule.Name)
+	recordEventf(r.recorder, schedule, corev1.EventTypeNormal, BackupScheduledEventReason, "Backup '%s' has been created", backup.Name)
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// pruneBackups deletes the oldest completed backups of the schedule, along with their snapshots,
+// so only the latest `keepLast` backups are kept.
+func (r *ReconcileCheBackupSchedule) pruneBackups(schedule *orgv1.CheBackupSchedule) error {
+	if schedule.Spec.KeepLast <= 0 {
+		return nil
+	}
+
+	backups := &orgv1.CheBackupList{}
+	if err := r.client.List(context.TODO(), backups, client.InNamespace(schedule.Namespace), client.MatchingLabels{BackupScheduleLabelKey: schedule.Name}); err != nil {
+		return err
+	}
+	if len(backups.Items) <= schedule.Spec.KeepLast {
+		return nil
+	}
+
+	// newest first
+	sort.Slice(backups.Items, func(i, j int) bool {
+		return backups.Items[j].CreationTimestamp.Before(&backups.Items[i].CreationTimestamp) ||
+			backups.Items[i].CreationTimestamp.Equal(&backups.Items[j].CreationTimestamp) && backups.Items[i].Name > backups.Items[j].Name
+	})
+
+	var storage BackupStorage
+	defer func() {
+		if storage != nil {
+			if err := storage.Close(); err != nil {
+				logrus.Errorf("Failed to release backup storage: %v", err)
+			}
+		}
+	}()
+
+	for _, backup := range backups.Items[schedule.Spec.KeepLast:] {
+		backup := backup
+		if backup.Status.Phase != orgv1.BackupPhaseSucceeded && backup.Status.Phase != orgv1.BackupPhaseFailed {
+			continue
+		}
+
+		if backup.Status.SnapshotName != "" {
+			if storage == nil {
+				var err error
+				if storage, err = r.newStorage(r.client, backup.Namespace, backup.Spec.Target); err != nil {
+					return err
+				}
+			}
+			if err := deleteSnapshot(storage, backup.Status.SnapshotName); err != nil {
+				return fmt.Errorf("failed to delete snapshot '%s': %v", backup.Status.SnapshotName, err)
+			}
+		}
+
+		if err := r.client.Delete(context.TODO(), &backup); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+
+		logrus.Infof("Backup '%s' has been pruned by the schedule '%s'", backup.Name, schedule.Name)
+		recordEventf(r.recorder, schedule, corev1.EventTypeNormal, BackupPrunedEventReason, "Backup '%s' has been pruned", backup.Name)
+	}
+	return nil
+}
+
+func (r *ReconcileCheBackupSchedule) updateStatusMessage(schedule *orgv1.CheBackupSchedule, message string) error {
+	if schedule.Status.Message == message {
+		return nil
+	}
+	schedule.Status.Message = message
+	return r.client.Status().Update(context.TODO(), schedule)
+}
+
+func (r *ReconcileCheBackupSchedule) getNow() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,126 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileCheBackupSchedule(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	schedule := &orgv1.CheBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily", Namespace: "eclipse-che", UID: "uid"},
+		Spec:       orgv1.CheBackupScheduleSpec{Target: testBackupTarget, Interval: "24h", KeepLast: 1},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, schedule)
+	storage := &fakeStorage{snapshots: map[string][]byte{}, ready: true}
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := &ReconcileCheBackupSchedule{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, now: func() time.Time { return now }}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "daily", Namespace: "eclipse-che"}}
+
+	listBackups := func() []orgv1.CheBackup {
+		backups := &orgv1.CheBackupList{}
+		if err := cli.List(context.TODO(), backups, client.InNamespace("eclipse-che")); err != nil {
+			t.Fatal(err)
+		}
+		return backups.Items
+	}
+
+	// the first backup is created right away
+	result, err := r.Reconcile(request)
+	if err != nil || result.RequeueAfter != 24*time.Hour {
+		t.Fatalf("Unexpected reconcile result: %v, %v", result, err)
+	}
+	backups := listBackups()
+	if len(backups) != 1 || backups[0].Name != "daily-20210102030405" || backups[0].Labels[BackupScheduleLabelKey] != "daily" {
+		t.Fatalf("Backup is expected to be created, but got: %v", backups)
+	}
+
+	// the next backup isn't due yet
+	now = now.Add(time.Hour)
+	result, err = r.Reconcile(request)
+	if err != nil || result.RequeueAfter != 23*time.Hour || len(listBackups()) != 1 {
+		t.Fatalf("Backup is not expected to be created: %v, %v", result, err)
+	}
+
+	// complete the first backup
+	backups[0].Status = orgv1.CheBackupStatus{Phase: orgv1.BackupPhaseSucceeded, SnapshotName: "snapshot-1.tar.gz"}
+	if err := cli.Status().Update(context.TODO(), &backups[0]); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := &Snapshot{CheCluster: getTestCheCluster(), Databases: []string{"dbche"}}
+	dumper := &fakeDumper{databases: map[string][]byte{"dbche": []byte("che dump")}}
+	if err := storeSnapshot(storage, dumper, getTestCheCluster(), snapshot, "snapshot-1.tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second backup is created, the first one is pruned once the second one completes
+	now = now.Add(24 * time.Hour)
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile schedule: %v", err)
+	}
+	backups = listBackups()
+	if len(backups) != 2 {
+		t.Fatalf("Backups are expected to be kept until the latest one completes, but got: %v", backups)
+	}
+	for i := range backups {
+		if backups[i].Name == "daily-20210103040405" {
+			backups[i].CreationTimestamp = metav1.NewTime(now)
+			backups[i].Status.Phase = orgv1.BackupPhaseSucceeded
+			if err := cli.Update(context.TODO(), &backups[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile schedule: %v", err)
+	}
+	backups = listBackups()
+	if len(backups) != 1 || backups[0].Name != "daily-20210103040405" {
+		t.Fatalf("The oldest backup is expected to be pruned, but got: %v", backups)
+	}
+	if len(storage.snapshots) != 0 {
+		t.Errorf("Snapshot of the pruned backup is expected to be deleted with its dumps, but got: %v", storage.snapshots)
+	}
+}
+
+func TestReconcileCheBackupScheduleWithInvalidInterval(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	schedule := &orgv1.CheBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily", Namespace: "eclipse-che"},
+		Spec:       orgv1.CheBackupScheduleSpec{Target: testBackupTarget, Interval: "daily"},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, schedule)
+	r := &ReconcileCheBackupSchedule{client: cli, scheme: scheme.Scheme}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "daily", Namespace: "eclipse-che"}}
+
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile schedule: %v", err)
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, schedule); err != nil {
+		t.Fatal(err)
+	}
+	if schedule.Status.Message == "" || schedule.Status.LastBackupTime != nil {
+		t.Errorf("Schedule is expected to report the invalid interval, but got: %v", schedule.Status)
+	}
+}
@@ -0,0 +1,351 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"context"
+	"fmt"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Stages of the restore
+const (
+	RestoreStageRestoringCheCluster = "RestoringCheCluster"
+	RestoreStageWaitingForDatabase  = "WaitingForDatabase"
+	RestoreStageRestoringDatabases  = "RestoringDatabases"
+)
+
+const (
+	// CheEclipseOrgRestoredFrom is the annotation of the CheCluster set to the name of the snapshot it is restored from
+	CheEclipseOrgRestoredFrom = "che.eclipse.org/restored-from"
+)
+
+func addRestoreController(mgr manager.Manager) error {
+	r := &ReconcileCheRestore{
+		client:     mgr.GetClient(),
+		scheme:     mgr.GetScheme(),
+		newStorage: NewBackupStorage,
+		dumper:     &ExecDatabaseDumper{},
+		recorder:   mgr.GetEventRecorderFor("che-operator"),
+	}
+
+	c, err := controller.New("cherestore-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	// the updates of the status don't trigger a reconcile, which would bypass the backoff of the retries
+	return c.Watch(&source.Kind{Type: &orgv1.CheRestore{}}, &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{})
+}
+
+var _ reconcile.Reconciler = &ReconcileCheRestore{}
+
+// ReconcileCheRestore reconciles a CheRestore object
+type ReconcileCheRestore struct {
+	client client.Client
+	scheme *runtime.Scheme
+	// Creates the storage of the backup target
+	newStorage BackupStorageFactory
+	// Restores the databases
+	dumper DatabaseDumper
+	// Records events regarding the restore custom resources
+	recorder record.EventRecorder
+}
+
+// Reconcile restores the snapshot in stages:
+// the secrets and the CheCluster are created first, so the Operator deploys PostgreSQL with the restored credentials,
+// then the databases are restored, and finally Keycloak and Che server are restarted to pick up the restored data.
+func (r *ReconcileCheRestore) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	restore := &orgv1.CheRestore{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+		if errors.IsNotFound(err) {
+			// the restore may have been deleted while in progress
+			return reconcile.Result{}, revokeBackupExec(r.client, request.Namespace)
+		}
+		return reconcile.Result{}, err
+	}
+
+	if isCompleted(restore.Status.Phase) {
+		return reconcile.Result{}, revokeBackupExec(r.client, restore.Namespace)
+	}
+
+	if err := validateBackupTarget(restore.Spec.Target); err != nil {
+		return reconcile.Result{}, r.fail(restore, err)
+	}
+	if restore.Spec.SnapshotName == "" {
+		return reconcile.Result{}, r.fail(restore, newValidationError("'snapshotName' must be set"))
+	}
+	if err := validateObjectPath("snapshotName", restore.Spec.SnapshotName); err != nil {
+		return reconcile.Result{}, r.fail(restore, err)
+	}
+
+	if err := grantBackupExec(r.client, restore.Namespace); err != nil {
+		return r.retryOrFail(restore, err)
+	}
+
+	switch restore.Status.Stage {
+	case "", RestoreStageRestoringCheCluster:
+		return r.restoreCheCluster(restore)
+	case RestoreStageWaitingForDatabase:
+		return r.waitForDatabase(restore)
+	case RestoreStageRestoringDatabases:
+		return r.restoreDatabases(restore)
+	}
+	return reconcile.Result{}, r.fail(restore, newValidationError("unknown restore stage '%s'", restore.Status.Stage))
+}
+
+// getSnapshot reads the snapshot from the backup target.
+// Returns nil if the backup target is not ready yet.
+func (r *ReconcileCheRestore) getSnapshot(restore *orgv1.CheRestore) (*Snapshot, error) {
+	storage, err := r.newStorage(r.client, restore.Namespace, restore.Spec.Target)
+	if err == errStorageNotReady {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := storage.Close(); err != nil {
+			logrus.Errorf("Failed to release backup storage: %v", err)
+		}
+	}()
+
+	snapshot, err := readSnapshot(storage, restore.Spec.SnapshotName)
+	if isObjectNotFound(err) {
+		return nil, newValidationError("snapshot '%s' not found in the backup target", restore.Spec.SnapshotName)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot '%s': %v", restore.Spec.SnapshotName, err)
+	}
+	return snapshot, nil
+}
+
+func (r *ReconcileCheRestore) restoreCheCluster(restore *orgv1.CheRestore) (reconcile.Result, error) {
+	snapshot, err := r.getSnapshot(restore)
+	if err != nil {
+		return r.retryOrFail(restore, err)
+	} else if snapshot == nil {
+		return reconcile.Result{RequeueAfter: waitingRequeueDelay}, r.updateStatus(restore, orgv1.BackupPhaseInProgress, RestoreStageRestoringCheCluster, "Waiting for the backup target to be ready")
+	}
+
+	// CheCluster can be restored into a fresh namespace only,
+	// the one left by a previous attempt of this restore is reused
+	cheClusters := &orgv1.CheClusterList{}
+	if err := r.client.List(context.TODO(), cheClusters, client.InNamespace(restore.Namespace)); err != nil {
+		return r.retryOrFail(restore, err)
+	}
+	for _, cheCluster := range cheClusters.Items {
+		if cheCluster.Name != snapshot.CheCluster.Name || cheCluster.Annotations[CheEclipseOrgRestoredFrom] != restore.Spec.SnapshotName {
+			return reconcile.Result{}, r.fail(restore, newValidationError("namespace '%s' already contains the CheCluster '%s'", restore.Namespace, cheCluster.Name))
+		}
+	}
+
+	for _, secret := range snapshot.Secrets {
+		secret := secret
+		secret.Namespace = restore.Namespace
+		if err := createOrUpdate(r.client, &secret, &corev1.Secret{}); err != nil {
+			return r.retryOrFail(restore, err)
+		}
+		logrus.Infof("Secret '%s' has been restored", secret.Name)
+	}
+
+	if len(cheClusters.Items) == 0 {
+		cheCluster := snapshot.CheCluster
+		cheCluster.Namespace = restore.Namespace
+		if cheCluster.Annotations == nil {
+			cheCluster.Annotations = map[string]string{}
+		}
+		cheCluster.Annotations[CheEclipseOrgRestoredFrom] = restore.Spec.SnapshotName
+		if err := r.client.Create(context.TODO(), cheCluster); err != nil {
+			return r.retryOrFail(restore, err)
+		}
+		logrus.Infof("CheCluster '%s' has been restored", cheCluster.Name)
+	}
+
+	restore.Status.CheClusterName = snapshot.CheCluster.Name
+	if len(snapshot.Databases) == 0 {
+		return reconcile.Result{}, r.succeed(restore)
+	}
+	return reconcile.Result{RequeueAfter: waitingRequeueDelay}, r.updateStatus(restore, orgv1.BackupPhaseInProgress, RestoreStageWaitingForDatabase, "Waiting for PostgreSQL to be ready")
+}
+
+func (r *ReconcileCheRestore) waitForDatabase(restore *orgv1.CheRestore) (reconcile.Result, error) {
+	cheCluster, err := r.getRestoredCheCluster(restore)
+	if err != nil {
+		return r.retryOrFail(restore, err)
+	}
+
+	if !orgv1.IsConditionTrue(cheCluster.Status.Conditions, orgv1.ConditionPostgresReady) || !cheCluster.Status.DbProvisoned {
+		return reconcile.Result{RequeueAfter: waitingRequeueDelay}, nil
+	}
+	return reconcile.Result{Requeue: true}, r.updateStatus(restore, orgv1.BackupPhaseInProgress, RestoreStageRestoringDatabases, "Restoring databases")
+}
+
+func (r *ReconcileCheRestore) restoreDatabases(restore *orgv1.CheRestore) (reconcile.Result, error) {
+	cheCluster, err := r.getRestoredCheCluster(restore)
+	if err != nil {
+		return r.retryOrFail(restore, err)
+	}
+
+	snapshot, err := r.getSnapshot(restore)
+	if err != nil {
+		return r.retryOrFail(restore, err)
+	} else if snapshot == nil {
+		return reconcile.Result{RequeueAfter: waitingRequeueDelay}, nil
+	}
+
+	storage, err := r.newStorage(r.client, restore.Namespace, restore.Spec.Target)
+	if err == errStorageNotReady {
+		return reconcile.Result{RequeueAfter: waitingRequeueDelay}, nil
+	} else if err != nil {
+		return r.retryOrFail(restore, err)
+	}
+	defer func() {
+		if err := storage.Close(); err != nil {
+			logrus.Errorf("Failed to release backup storage: %v", err)
+		}
+	}()
+
+	// the names are read from the backup target, only the databases deployed by the Operator are restored
+	backupDatabases := getBackupDatabases(cheCluster)
+	for _, database := range snapshot.Databases {
+		if !util.ContainsString(backupDatabases, database) {
+			return reconcile.Result{}, r.fail(restore, newValidationError("snapshot contains the dump of the unknown database '%s'", database))
+		}
+	}
+
+	for _, database := range snapshot.Databases {
+		err := restoreDatabaseDump(storage, r.dumper, cheCluster, restore.Spec.SnapshotName, database)
+		if isObjectNotFound(err) {
+			return r.retryOrFail(restore, newValidationError("dump of the database '%s' not found in the backup target", database))
+		} else if err != nil {
+			return r.retryOrFail(restore, fmt.Errorf("failed to restore database '%s': %v", database, err))
+		}
+		logrus.Infof("Database '%s' has been restored", database)
+	}
+
+	// components have to be restarted, since they may have cached the data
+	for _, component := range []string{deploy.IdentityProviderName, deploy.DefaultCheFlavor(cheCluster)} {
+		if err := deletePods(r.client, restore.Namespace, component); err != nil {
+			return r.retryOrFail(restore, err)
+		}
+	}
+
+	return reconcile.Result{}, r.succeed(restore)
+}
+
+func (r *ReconcileCheRestore) succeed(restore *orgv1.CheRestore) error {
+	now := metav1.Now()
+	restore.Status.CompletionTime = &now
+	if err := r.updateStatus(restore, orgv1.BackupPhaseSucceeded, "", fmt.Sprintf("Snapshot '%s' has been restored", restore.Spec.SnapshotName)); err != nil {
+		return err
+	}
+
+	logrus.Infof("Snapshot '%s' has been restored", restore.Spec.SnapshotName)
+	recordEventf(r.recorder, restore, corev1.EventTypeNormal, RestoreSucceededEventReason, "Snapshot '%s' has been restored", restore.Spec.SnapshotName)
+	return nil
+}
+
+// getRestoredCheCluster returns the CheCluster created by the restore.
+func (r *ReconcileCheRestore) getRestoredCheCluster(restore *orgv1.CheRestore) (*orgv1.CheCluster, error) {
+	cheCluster := &orgv1.CheCluster{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: restore.Status.CheClusterName, Namespace: restore.Namespace}, cheCluster); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, newValidationError("restored CheCluster '%s' not found", restore.Status.CheClusterName)
+		}
+		return nil, err
+	}
+	return cheCluster, nil
+}
+
+// retryOrFail fails the restore if the error is a validation error, which retrying doesn't fix.
+// Otherwise the error is reported in the status and the current stage is retried with a backoff.
+func (r *ReconcileCheRestore) retryOrFail(restore *orgv1.CheRestore, cause error) (reconcile.Result, error) {
+	if isValidationError(cause) {
+		return reconcile.Result{}, r.fail(restore, cause)
+	}
+
+	logrus.Errorf("Restore '%s' will be retried: %v", restore.Name, cause)
+	if err := r.updateStatus(restore, orgv1.BackupPhaseInProgress, restore.Status.Stage, fmt.Sprintf("Retrying: %v", cause)); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, cause
+}
+
+func (r *ReconcileCheRestore) fail(restore *orgv1.CheRestore, cause error) error {
+	logrus.Errorf("Restore '%s' failed: %v", restore.Name, cause)
+	recordEventf(r.recorder, restore, corev1.EventTypeWarning, RestoreFailedEventReason, cause.Error())
+
+	now := metav1.Now()
+	restore.Status.CompletionTime = &now
+	return r.updateStatus(restore, orgv1.BackupPhaseFailed, restore.Status.Stage, cause.Error())
+}
+
+func (r *ReconcileCheRestore) updateStatus(restore *orgv1.CheRestore, phase string, stage string, message string) error {
+	restore.Status.Phase = phase
+	restore.Status.Stage = stage
+	restore.Status.Message = message
+	if err := r.client.Status().Update(context.TODO(), restore); err != nil {
+		return err
+	}
+
+	if isCompleted(phase) {
+		return revokeBackupExec(r.client, restore.Namespace)
+	}
+	return nil
+}
+
+// createOrUpdate creates the object or overwrites the existing one.
+func createOrUpdate(cli client.Client, object runtime.Object, actual runtime.Object) error {
+	key, err := client.ObjectKeyFromObject(object)
+	if err != nil {
+		return err
+	}
+
+	err = cli.Get(context.TODO(), key, actual)
+	if errors.IsNotFound(err) {
+		return cli.Create(context.TODO(), object)
+	} else if err != nil {
+		return err
+	}
+
+	object.(metav1.Object).SetResourceVersion(actual.(metav1.Object).GetResourceVersion())
+	return cli.Update(context.TODO(), object)
+}
+
+// deletePods deletes the pods of the component, so they are recreated by the deployment.
+func deletePods(cli client.Client, namespace string, component string) error {
+	pods := &corev1.PodList{}
+	if err := cli.List(context.TODO(), pods, client.InNamespace(namespace), client.MatchingLabels{"component": component}); err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		if err := cli.Delete(context.TODO(), &pods.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileCheRestore(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+
+	snapshot := &Snapshot{
+		CheCluster: &orgv1.CheCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "eclipse-che"},
+			Spec:       getTestCheCluster().Spec,
+		},
+		Secrets: []corev1.Secret{
+			{ObjectMeta: metav1.ObjectMeta{Name: "che-postgres-secret"}, Data: map[string][]byte{"password": []byte("password")}},
+		},
+		Databases: []string{"dbche", "keycloak"},
+	}
+	storage := &fakeStorage{snapshots: map[string][]byte{}, ready: true}
+	backupDumper := &fakeDumper{databases: map[string][]byte{"dbche": []byte("che dump"), "keycloak": []byte("keycloak dump")}}
+	if err := storeSnapshot(storage, backupDumper, getTestCheCluster(), snapshot, "snapshot.tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	restore := &orgv1.CheRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "che-restored"},
+		Spec:       orgv1.CheRestoreSpec{Target: testBackupTarget, SnapshotName: "snapshot.tar.gz"},
+	}
+	cheServerPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "che-1", Namespace: "che-restored", Labels: map[string]string{"component": "che"}}}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, restore, cheServerPod)
+	dumper := &fakeDumper{databases: map[string][]byte{}}
+	r := &ReconcileCheRestore{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: dumper}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "restore", Namespace: "che-restored"}}
+
+	// secrets and CheCluster are restored
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile restore: %v", err)
+	}
+	secret := &corev1.Secret{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "che-postgres-secret", Namespace: "che-restored"}, secret); err != nil {
+		t.Fatalf("Secret is expected to be restored: %v", err)
+	}
+	cheCluster := &orgv1.CheCluster{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "eclipse-che", Namespace: "che-restored"}, cheCluster); err != nil {
+		t.Fatalf("CheCluster is expected to be restored: %v", err)
+	}
+	if cheCluster.Spec.Database.ChePostgresSecret != "che-postgres-secret" || cheCluster.Annotations[CheEclipseOrgRestoredFrom] != "snapshot.tar.gz" {
+		t.Fatalf("Unexpected restored CheCluster: %v", cheCluster)
+	}
+
+	// the same stage is done again, if the status wasn't updated
+	if _, err := r.restoreCheCluster(restore); err != nil {
+		t.Fatalf("Restoring CheCluster is expected to be idempotent: %v", err)
+	}
+
+	// databases are restored once PostgreSQL is provisioned
+	result, err := r.Reconcile(request)
+	if err != nil || result.RequeueAfter == 0 || len(dumper.databases) != 0 {
+		t.Fatalf("Restore is expected to wait for PostgreSQL: %v", err)
+	}
+	cheCluster.Status = getTestCheCluster().Status
+	if err := cli.Status().Update(context.TODO(), cheCluster); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(request); err != nil {
+			t.Fatalf("Failed to reconcile restore: %v", err)
+		}
+	}
+
+	if string(dumper.databases["dbche"]) != "che dump" || string(dumper.databases["keycloak"]) != "keycloak dump" {
+		t.Errorf("Databases are expected to be restored, but got: %v", dumper.databases)
+	}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "che-1", Namespace: "che-restored"}, &corev1.Pod{}); err == nil {
+		t.Error("Che server pod is expected to be restarted")
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+		t.Fatal(err)
+	}
+	if restore.Status.Phase != orgv1.BackupPhaseSucceeded || restore.Status.CheClusterName != "eclipse-che" {
+		t.Errorf("Restore is expected to succeed, but got: %v", restore.Status)
+	}
+	if isBackupExecGranted(t, cli, "che-restored") {
+		t.Error("Exec is expected to be revoked once the restore is completed")
+	}
+}
+
+func TestReconcileCheRestoreFailsIfCheClusterExists(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+
+	snapshot := &Snapshot{CheCluster: &orgv1.CheCluster{ObjectMeta: metav1.ObjectMeta{Name: "eclipse-che"}}}
+	data, err := snapshot.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := &fakeStorage{snapshots: map[string][]byte{"snapshot.tar.gz": data}, ready: true}
+
+	restore := &orgv1.CheRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "eclipse-che"},
+		Spec:       orgv1.CheRestoreSpec{Target: testBackupTarget, SnapshotName: "snapshot.tar.gz"},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, restore, getTestCheCluster())
+	r := &ReconcileCheRestore{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: &fakeDumper{}}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "restore", Namespace: "eclipse-che"}}
+
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile restore: %v", err)
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+		t.Fatal(err)
+	}
+	if restore.Status.Phase != orgv1.BackupPhaseFailed {
+		t.Errorf("Restore is expected to fail, but got: %v", restore.Status)
+	}
+}
+
+func TestReconcileCheRestoreFailsIfSnapshotNotFound(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+
+	storage := &fakeStorage{snapshots: map[string][]byte{}, ready: true}
+	restore := &orgv1.CheRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "che-restored"},
+		Spec:       orgv1.CheRestoreSpec{Target: testBackupTarget, SnapshotName: "snapshot.tar.gz"},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, restore)
+	r := &ReconcileCheRestore{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: &fakeDumper{}}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "restore", Namespace: "che-restored"}}
+
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile restore: %v", err)
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+		t.Fatal(err)
+	}
+	if restore.Status.Phase != orgv1.BackupPhaseFailed {
+		t.Errorf("Restore is expected to fail, but got: %v", restore.Status)
+	}
+}
+
+func TestReconcileCheRestoreFailsWithInvalidSnapshotName(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+
+	for _, snapshotName := range []string{"../snapshot.tar.gz", "/backups/snapshot.tar.gz", "snapshot'; rm -rf /backups; '.tar.gz"} {
+		t.Run(snapshotName, func(t *testing.T) {
+			storage := &fakeStorage{snapshots: map[string][]byte{}, ready: true}
+			restore := &orgv1.CheRestore{
+				ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "che-restored"},
+				Spec:       orgv1.CheRestoreSpec{Target: testBackupTarget, SnapshotName: snapshotName},
+			}
+			cli := fake.NewFakeClientWithScheme(scheme.Scheme, restore)
+			r := &ReconcileCheRestore{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: &fakeDumper{}}
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "restore", Namespace: "che-restored"}}
+
+			if _, err := r.Reconcile(request); err != nil {
+				t.Fatalf("Failed to reconcile restore: %v", err)
+			}
+			if err := cli.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+				t.Fatal(err)
+			}
+			if restore.Status.Phase != orgv1.BackupPhaseFailed {
+				t.Errorf("Restore is expected to fail, but got: %v", restore.Status)
+			}
+		})
+	}
+}
+
+func TestReconcileCheRestoreFailsWithUnknownDatabase(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+
+	snapshot := &Snapshot{
+		CheCluster: &orgv1.CheCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "eclipse-che"},
+			Spec:       getTestCheCluster().Spec,
+		},
+		Databases: []string{"dbche", "postgres"},
+	}
+	storage := &fakeStorage{snapshots: map[string][]byte{}, ready: true}
+	backupDumper := &fakeDumper{databases: map[string][]byte{"dbche": []byte("che dump"), "postgres": []byte("postgres dump")}}
+	if err := storeSnapshot(storage, backupDumper, getTestCheCluster(), snapshot, "snapshot.tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	restore := &orgv1.CheRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "che-restored"},
+		Spec:       orgv1.CheRestoreSpec{Target: testBackupTarget, SnapshotName: "snapshot.tar.gz"},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, restore)
+	dumper := &fakeDumper{databases: map[string][]byte{}}
+	r := &ReconcileCheRestore{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: dumper}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "restore", Namespace: "che-restored"}}
+
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile restore: %v", err)
+	}
+	cheCluster := &orgv1.CheCluster{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "eclipse-che", Namespace: "che-restored"}, cheCluster); err != nil {
+		t.Fatalf("CheCluster is expected to be restored: %v", err)
+	}
+	cheCluster.Status = getTestCheCluster().Status
+	if err := cli.Status().Update(context.TODO(), cheCluster); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(request); err != nil {
+			t.Fatalf("Failed to reconcile restore: %v", err)
+		}
+	}
+
+	if len(dumper.databases) != 0 {
+		t.Errorf("No database is expected to be restored, but got: %v", dumper.databases)
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+		t.Fatal(err)
+	}
+	if restore.Status.Phase != orgv1.BackupPhaseFailed || !strings.Contains(restore.Status.Message, "unknown database 'postgres'") {
+		t.Errorf("Restore is expected to fail, but got: %v", restore.Status)
+	}
+}
+
+func TestReconcileCheRestoreRetriesOnStorageError(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+
+	snapshot := &Snapshot{CheCluster: &orgv1.CheCluster{ObjectMeta: metav1.ObjectMeta{Name: "eclipse-che"}}}
+	data, err := snapshot.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := &fakeStorage{snapshots: map[string][]byte{"snapshot.tar.gz": data}, ready: true, err: fmt.Errorf("connection refused")}
+	restore := &orgv1.CheRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "che-restored"},
+		Spec:       orgv1.CheRestoreSpec{Target: testBackupTarget, SnapshotName: "snapshot.tar.gz"},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, restore)
+	r := &ReconcileCheRestore{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: &fakeDumper{}}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "restore", Namespace: "che-restored"}}
+
+	if _, err := r.Reconcile(request); err == nil {
+		t.Fatal("Restore is expected to be retried")
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+		t.Fatal(err)
+	}
+	if restore.Status.Phase != orgv1.BackupPhaseInProgress {
+		t.Fatalf("Restore is expected to be in progress, but got: %v", restore.Status)
+	}
+
+	storage.err = nil
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile restore: %v", err)
+	}
+	if err := cli.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+		t.Fatal(err)
+	}
+	if restore.Status.Phase != orgv1.BackupPhaseSucceeded {
+		t.Errorf("Restore is expected to succeed, but got: %v", restore.Status)
+	}
+}
+
+func TestBackupAndRestoreGeneratedSecrets(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+
+	// the secrets generated by the Operator aren't referenced by the spec
+	cheCluster := getTestCheCluster()
+	cheCluster.Spec.Database.ChePostgresSecret = ""
+	cheCluster.Spec.Auth.IdentityProviderSecret = ""
+	backup := &orgv1.CheBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "eclipse-che"},
+		Spec:       orgv1.CheBackupSpec{Target: testBackupTarget},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme,
+		cheCluster,
+		backup,
+		getTestSecret("che-postgres-secret", "postgres-password"),
+		getTestSecret("che-identity-secret", "admin-password"),
+		getTestSecret("che-identity-postgres-secret", "keycloak-postgres-password"))
+	storage := &fakeStorage{snapshots: map[string][]byte{}, ready: true}
+	backupReconciler := &ReconcileCheBackup{client: cli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: &fakeDumper{databases: map[string][]byte{}}}
+	if _, err := backupReconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "backup", Namespace: "eclipse-che"}}); err != nil {
+		t.Fatalf("Failed to reconcile backup: %v", err)
+	}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "backup", Namespace: "eclipse-che"}, backup); err != nil {
+		t.Fatal(err)
+	}
+
+	restore := &orgv1.CheRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "che-restored"},
+		Spec:       orgv1.CheRestoreSpec{Target: testBackupTarget, SnapshotName: backup.Status.SnapshotName},
+	}
+	restoreCli := fake.NewFakeClientWithScheme(scheme.Scheme, restore)
+	restoreReconciler := &ReconcileCheRestore{client: restoreCli, scheme: scheme.Scheme, newStorage: storage.factory, dumper: &fakeDumper{databases: map[string][]byte{}}}
+	if _, err := restoreReconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "restore", Namespace: "che-restored"}}); err != nil {
+		t.Fatalf("Failed to reconcile restore: %v", err)
+	}
+
+	for name, password := range map[string]string{
+		"che-postgres-secret":          "postgres-password",
+		"che-identity-secret":          "admin-password",
+		"che-identity-postgres-secret": "keycloak-postgres-password",
+	} {
+		secret := &corev1.Secret{}
+		if err := restoreCli.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "che-restored"}, secret); err != nil {
+			t.Errorf("Secret '%s' is expected to be restored: %v", name, err)
+		} else if string(secret.Data["password"]) != password {
+			t.Errorf("Secret '%s' is expected to be restored with the backed up password", name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"fmt"
+	"io"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// DatabaseDumper dumps and restores the databases of the PostgreSQL deployed by the Operator.
+// The dumps are streamed, since they may not fit in the memory of the Operator.
+type DatabaseDumper interface {
+	// Dump writes the dump of the database
+	Dump(cheCluster *orgv1.CheCluster, database string, dump io.Writer) error
+	// Restore runs the statements of the dump in the database
+	Restore(cheCluster *orgv1.CheCluster, database string, dump io.Reader) error
+}
+
+// ExecDatabaseDumper - DatabaseDumper implementation which runs `pg_dump` and `psql` in the PostgreSQL pod.
+type ExecDatabaseDumper struct {
+}
+
+func (dumper *ExecDatabaseDumper) Dump(cheCluster *orgv1.CheCluster, database string, dump io.Writer) error {
+	pod, err := getPostgresPod(cheCluster)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Dumping database '%s' in the pod '%s'", database, pod)
+	command := getPostgresCommand("pg_dump", "--clean", "--if-exists", "-d", database)
+	stderr, err := util.K8sclient.RunExecWithStreams(command, pod, cheCluster.Namespace, nil, dump)
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, stderr)
+	}
+	return nil
+}
+
+func (dumper *ExecDatabaseDumper) Restore(cheCluster *orgv1.CheCluster, database string, dump io.Reader) error {
+	pod, err := getPostgresPod(cheCluster)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Restoring database '%s' in the pod '%s'", database, pod)
+	// a failed read looks like the end of the dump to psql, so it is reported separately.
+	// The restore can be run again, since the dump drops the objects before creating them
+	command := getPostgresCommand("psql", "-v", "ON_ERROR_STOP=1", "-d", database)
+	reader := &readErrorRecorder{reader: dump}
+	_, stderr, err := util.K8sclient.RunExecWithStdin(command, pod, cheCluster.Namespace, reader)
+	if reader.err != nil {
+		return reader.err
+	}
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, stderr)
+	}
+	return nil
+}
+
+// getPostgresCommand runs the PostgreSQL client in bash, which enables the software collection of the PostgreSQL image.
+// The arguments are passed to the client as they are, they are never interpolated into the script.
+func getPostgresCommand(args ...string) []string {
+	return append([]string{"/bin/bash", "-c", `exec "$@"`, "--"}, args...)
+}
+
+func getPostgresPod(cheCluster *orgv1.CheCluster) (string, error) {
+	pod, err := util.K8sclient.GetDeploymentPod(deploy.PostgresName, cheCluster.Namespace)
+	if err != nil {
+		return "", err
+	}
+	if pod == "" {
+		return "", fmt.Errorf("PostgreSQL pod not found")
+	}
+	return pod, nil
+}
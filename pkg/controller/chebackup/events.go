@@ -0,0 +1,39 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events emitted by the backup controllers.
+// They are part of the Operator API, so don't change them.
+const (
+	BackupSucceededEventReason  = "BackupSucceeded"
+	BackupFailedEventReason     = "BackupFailed"
+	RestoreSucceededEventReason = "RestoreSucceeded"
+	RestoreFailedEventReason    = "RestoreFailed"
+	BackupScheduledEventReason  = "BackupScheduled"
+	BackupPrunedEventReason     = "BackupPruned"
+)
+
+// recordEventf emits an event regarding the object.
+// Does nothing if the event recorder is not configured, e.g. in tests.
+func recordEventf(recorder record.EventRecorder, object runtime.Object, eventType string, reason string, messageFmt string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+	recorder.Event(object, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
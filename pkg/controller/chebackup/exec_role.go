@@ -0,0 +1,125 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"context"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/sirupsen/logrus"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// BackupExecRoleName is the name of the role and role binding which allow the Operator
+	// to exec into the pods of the namespace while a backup or restore is in progress
+	BackupExecRoleName = "che-operator-backup-exec"
+	// operatorServiceAccountName is the service account of the Operator deployment
+	operatorServiceAccountName = "che-operator"
+)
+
+// grantBackupExec allows the Operator to exec into the pods of the namespace,
+// which is needed to dump and restore the databases and to access the backup PVC.
+// The Operator Role doesn't hold this permission, see revokeBackupExec.
+func grantBackupExec(cli client.Client, namespace string) error {
+	labels := map[string]string{
+		deploy.KubernetesPartOfLabelKey:    deploy.CheEclipseOrg,
+		deploy.KubernetesComponentLabelKey: BackupExecRoleName,
+	}
+
+	role := &rbac.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BackupExecRoleName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Rules: []rbac.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods/exec"},
+				Verbs:     []string{"create"},
+			},
+		},
+	}
+	if err := createIfNotExists(cli, role); err != nil {
+		return err
+	}
+
+	roleBinding := &rbac.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BackupExecRoleName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Subjects: []rbac.Subject{
+			{
+				Kind:      rbac.ServiceAccountKind,
+				Name:      operatorServiceAccountName,
+				Namespace: namespace,
+			},
+		},
+		RoleRef: rbac.RoleRef{
+			Name:     BackupExecRoleName,
+			Kind:     "Role",
+			APIGroup: rbac.GroupName,
+		},
+	}
+	return createIfNotExists(cli, roleBinding)
+}
+
+// revokeBackupExec deletes the role granted by grantBackupExec,
+// unless another backup or restore is still in progress in the namespace.
+func revokeBackupExec(cli client.Client, namespace string) error {
+	backups := &orgv1.CheBackupList{}
+	if err := cli.List(context.TODO(), backups, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for _, backup := range backups.Items {
+		if !isCompleted(backup.Status.Phase) {
+			return nil
+		}
+	}
+
+	restores := &orgv1.CheRestoreList{}
+	if err := cli.List(context.TODO(), restores, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for _, restore := range restores.Items {
+		if !isCompleted(restore.Status.Phase) {
+			return nil
+		}
+	}
+
+	if err := deploy.DeleteRoleBinding(BackupExecRoleName, namespace, cli); err != nil {
+		return err
+	}
+	return deploy.DeleteRole(BackupExecRoleName, namespace, cli)
+}
+
+func isCompleted(phase string) bool {
+	return phase == orgv1.BackupPhaseSucceeded || phase == orgv1.BackupPhaseFailed
+}
+
+func createIfNotExists(cli client.Client, object runtime.Object) error {
+	err := cli.Create(context.TODO(), object)
+	if err == nil {
+		logrus.Infof("Created %T '%s' to exec into the pods during backups", object, BackupExecRoleName)
+	} else if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"context"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBackupExecRole(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	backup := &orgv1.CheBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "eclipse-che"},
+		Status:     orgv1.CheBackupStatus{Phase: orgv1.BackupPhaseSucceeded},
+	}
+	restore := &orgv1.CheRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "eclipse-che"},
+		Status:     orgv1.CheRestoreStatus{Phase: orgv1.BackupPhaseInProgress},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, backup, restore)
+
+	if err := grantBackupExec(cli, "eclipse-che"); err != nil {
+		t.Fatalf("Failed to grant exec: %v", err)
+	}
+	// granted once per backup, the existing role is reused
+	if err := grantBackupExec(cli, "eclipse-che"); err != nil {
+		t.Fatalf("Failed to grant exec: %v", err)
+	}
+
+	roleBinding := &rbac.RoleBinding{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: BackupExecRoleName, Namespace: "eclipse-che"}, roleBinding); err != nil {
+		t.Fatalf("Role binding is expected to be created: %v", err)
+	}
+	if roleBinding.Subjects[0].Name != "che-operator" || roleBinding.RoleRef.Name != BackupExecRoleName {
+		t.Errorf("Unexpected role binding: %v", roleBinding)
+	}
+
+	if err := revokeBackupExec(cli, "eclipse-che"); err != nil {
+		t.Fatalf("Failed to revoke exec: %v", err)
+	}
+	if !isBackupExecGranted(t, cli, "eclipse-che") {
+		t.Fatal("Exec is expected to be kept while the restore is in progress")
+	}
+
+	restore.Status.Phase = orgv1.BackupPhaseFailed
+	if err := cli.Status().Update(context.TODO(), restore); err != nil {
+		t.Fatal(err)
+	}
+	if err := revokeBackupExec(cli, "eclipse-che"); err != nil {
+		t.Fatalf("Failed to revoke exec: %v", err)
+	}
+	if isBackupExecGranted(t, cli, "eclipse-che") {
+		t.Fatal("Exec is expected to be revoked once no backup or restore is in progress")
+	}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: BackupExecRoleName, Namespace: "eclipse-che"}, &rbac.RoleBinding{}); !errors.IsNotFound(err) {
+		t.Errorf("Role binding is expected to be deleted: %v", err)
+	}
+}
+
+func isBackupExecGranted(t *testing.T, cli client.Client, namespace string) bool {
+	err := cli.Get(context.TODO(), types.NamespacedName{Name: BackupExecRoleName, Namespace: namespace}, &rbac.Role{})
+	if errors.IsNotFound(err) {
+		return false
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return true
+}
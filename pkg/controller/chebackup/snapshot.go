@@ -0,0 +1,331 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	snapshotCheClusterFile = "checluster.json"
+	snapshotSecretsFile    = "secrets.json"
+	snapshotDatabasesFile  = "databases.json"
+	snapshotFileExtension  = ".tar.gz"
+	dumpFileExtension      = ".sql.gz"
+
+	keycloakDatabase = "keycloak"
+)
+
+// Snapshot is the state of Che installation kept in a backup.
+// Everything else is provisioned by the Operator when the snapshot is restored.
+type Snapshot struct {
+	CheCluster *orgv1.CheCluster
+	Secrets    []corev1.Secret
+	// Names of the dumped databases. The dumps don't fit in memory, so they are stored next to the snapshot
+	Databases []string
+}
+
+// GetSnapshotName returns the name of the snapshot of the CheCluster taken at the given time.
+func GetSnapshotName(cheCluster *orgv1.CheCluster, timestamp time.Time) string {
+	return fmt.Sprintf("%s-%s%s", cheCluster.Name, timestamp.UTC().Format("20060102150405"), snapshotFileExtension)
+}
+
+// GetDatabaseDumpName returns the name of the dump of the database stored next to the snapshot.
+func GetDatabaseDumpName(snapshotName string, database string) string {
+	return strings.TrimSuffix(snapshotName, snapshotFileExtension) + "-" + database + dumpFileExtension
+}
+
+// getBackupDatabases returns the databases which are deployed by the Operator and have to be backed up.
+func getBackupDatabases(cheCluster *orgv1.CheCluster) []string {
+	if cheCluster.Spec.Database.ExternalDb || deploy.GetCheMultiUser(cheCluster) != "true" {
+		return []string{}
+	}
+
+	databases := []string{cheCluster.Spec.Database.ChePostgresDb}
+	if databases[0] == "" {
+		databases[0] = deploy.DefaultChePostgresDb
+	}
+	if deploy.IsKeycloakDeployed(cheCluster) {
+		databases = append(databases, keycloakDatabase)
+	}
+	return databases
+}
+
+// getBackupSecretNames returns the names of the secrets the CheCluster refers to.
+// The credentials secrets generated by the Operator aren't referenced by the spec,
+// so their names are resolved the same way the Operator does.
+func getBackupSecretNames(cheCluster *orgv1.CheCluster) []string {
+	names := []string{}
+	for _, name := range []string{
+		util.GetValue(cheCluster.Spec.Database.ChePostgresSecret, deploy.DefaultChePostgresSecret()),
+		util.GetValue(cheCluster.Spec.Auth.IdentityProviderSecret, deploy.DefaultCheIdentitySecret()),
+		util.GetValue(cheCluster.Spec.Auth.IdentityProviderPostgresSecret, deploy.DefaultCheIdentityPostgresSecret()),
+		cheCluster.Spec.Server.ProxySecret,
+		cheCluster.Spec.Server.CheHostTLSSecret,
+		cheCluster.Spec.K8s.TlsSecretName,
+		deploy.CheTLSSelfSignedCertificateSecretName,
+	} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// TakeSnapshot collects the CheCluster, the secrets it refers to and the databases to dump.
+func TakeSnapshot(cli client.Client, cheCluster *orgv1.CheCluster) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		CheCluster: &orgv1.CheCluster{
+			TypeMeta:   metav1.TypeMeta{APIVersion: orgv1.SchemeGroupVersion.String(), Kind: "CheCluster"},
+			ObjectMeta: cleanObjectMeta(cheCluster.ObjectMeta),
+			Spec:       cheCluster.Spec,
+		},
+		Secrets:   []corev1.Secret{},
+		Databases: getBackupDatabases(cheCluster),
+	}
+
+	for _, name := range getBackupSecretNames(cheCluster) {
+		secret := &corev1.Secret{}
+		err := cli.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cheCluster.Namespace}, secret)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		snapshot.Secrets = append(snapshot.Secrets, corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: cleanObjectMeta(secret.ObjectMeta),
+			Type:       secret.Type,
+			Data:       secret.Data,
+		})
+	}
+
+	return snapshot, nil
+}
+
+// storeSnapshot streams the dumps of the databases into the storage, and then stores the snapshot,
+// so that a stored snapshot is always complete.
+func storeSnapshot(storage BackupStorage, dumper DatabaseDumper, cheCluster *orgv1.CheCluster, snapshot *Snapshot, snapshotName string) error {
+	for _, database := range snapshot.Databases {
+		if err := storeDatabaseDump(storage, dumper, cheCluster, GetDatabaseDumpName(snapshotName, database), database); err != nil {
+			return fmt.Errorf("failed to dump database '%s': %v", database, err)
+		}
+	}
+
+	data, err := snapshot.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := storage.Put(snapshotName, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to store snapshot '%s': %v", snapshotName, err)
+	}
+	return nil
+}
+
+// storeDatabaseDump compresses the dump of the database on the fly, while it is stored.
+func storeDatabaseDump(storage BackupStorage, dumper DatabaseDumper, cheCluster *orgv1.CheCluster, dumpName string, database string) error {
+	reader, writer := io.Pipe()
+	dumpErr := make(chan error, 1)
+	go func() {
+		gzipWriter := gzip.NewWriter(writer)
+		err := dumper.Dump(cheCluster, database, gzipWriter)
+		if err == nil {
+			err = gzipWriter.Close()
+		}
+		// the storage fails to read the content if the dump failed
+		writer.CloseWithError(err)
+		dumpErr <- err
+	}()
+
+	if err := storage.Put(dumpName, reader); err != nil {
+		// stop the dump, if it's still running
+		reader.CloseWithError(err)
+		return err
+	}
+	return <-dumpErr
+}
+
+// readSnapshot reads the snapshot from the storage.
+func readSnapshot(storage BackupStorage, snapshotName string) (*Snapshot, error) {
+	reader, err := storage.Get(snapshotName)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalSnapshot(data)
+}
+
+// restoreDatabaseDump streams the dump of the database stored next to the snapshot into the database.
+func restoreDatabaseDump(storage BackupStorage, dumper DatabaseDumper, cheCluster *orgv1.CheCluster, snapshotName string, database string) error {
+	reader, err := storage.Get(GetDatabaseDumpName(snapshotName, database))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return err
+	}
+	return dumper.Restore(cheCluster, database, gzipReader)
+}
+
+// deleteSnapshot deletes the snapshot and the dumps of its databases from the storage.
+func deleteSnapshot(storage BackupStorage, snapshotName string) error {
+	snapshot, err := readSnapshot(storage, snapshotName)
+	if isObjectNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, database := range snapshot.Databases {
+		if err := storage.Delete(GetDatabaseDumpName(snapshotName, database)); err != nil {
+			return err
+		}
+	}
+	return storage.Delete(snapshotName)
+}
+
+// cleanObjectMeta keeps only the metadata which makes sense in another namespace or cluster.
+func cleanObjectMeta(objectMeta metav1.ObjectMeta) metav1.ObjectMeta {
+	annotations := map[string]string{}
+	for key, value := range objectMeta.Annotations {
+		if key != "kubectl.kubernetes.io/last-applied-configuration" {
+			annotations[key] = value
+		}
+	}
+	return metav1.ObjectMeta{
+		Name:        objectMeta.Name,
+		Labels:      objectMeta.Labels,
+		Annotations: annotations,
+	}
+}
+
+// Marshal packs the snapshot into a gzipped tarball.
+func (snapshot *Snapshot) Marshal() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	writeFile := func(name string, data []byte) error {
+		header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: time.Now()}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tarWriter.Write(data)
+		return err
+	}
+
+	cheCluster, err := json.Marshal(snapshot.CheCluster)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(snapshotCheClusterFile, cheCluster); err != nil {
+		return nil, err
+	}
+
+	secrets, err := json.Marshal(snapshot.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(snapshotSecretsFile, secrets); err != nil {
+		return nil, err
+	}
+
+	databases, err := json.Marshal(snapshot.Databases)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(snapshotDatabasesFile, databases); err != nil {
+		return nil, err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalSnapshot unpacks the snapshot from a gzipped tarball.
+func UnmarshalSnapshot(data []byte) (*Snapshot, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	snapshot := &Snapshot{Databases: []string{}}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case header.Name == snapshotCheClusterFile:
+			snapshot.CheCluster = &orgv1.CheCluster{}
+			if err := json.Unmarshal(content, snapshot.CheCluster); err != nil {
+				return nil, err
+			}
+		case header.Name == snapshotSecretsFile:
+			if err := json.Unmarshal(content, &snapshot.Secrets); err != nil {
+				return nil, err
+			}
+		case header.Name == snapshotDatabasesFile:
+			if err := json.Unmarshal(content, &snapshot.Databases); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if snapshot.CheCluster == nil {
+		return nil, fmt.Errorf("snapshot doesn't contain '%s'", snapshotCheClusterFile)
+	}
+	return snapshot, nil
+}
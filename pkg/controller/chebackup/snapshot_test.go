@@ -0,0 +1,221 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeDumper keeps the databases in memory
+type fakeDumper struct {
+	databases map[string][]byte
+}
+
+func (d *fakeDumper) Dump(cheCluster *orgv1.CheCluster, database string, dump io.Writer) error {
+	_, err := dump.Write(d.databases[database])
+	return err
+}
+
+func (d *fakeDumper) Restore(cheCluster *orgv1.CheCluster, database string, dump io.Reader) error {
+	data, err := ioutil.ReadAll(dump)
+	if err != nil {
+		return err
+	}
+	d.databases[database] = data
+	return nil
+}
+
+func getTestCheCluster() *orgv1.CheCluster {
+	return &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "eclipse-che",
+			Namespace:       "eclipse-che",
+			UID:             "uid",
+			ResourceVersion: "1",
+			Finalizers:      []string{"finalizer"},
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				"che.eclipse.org/annotation":                       "value",
+			},
+		},
+		Spec: orgv1.CheClusterSpec{
+			Database: orgv1.CheClusterSpecDB{
+				ChePostgresSecret: "che-postgres-secret",
+			},
+			Auth: orgv1.CheClusterSpecAuth{
+				IdentityProviderSecret: "che-identity-secret",
+			},
+		},
+		Status: orgv1.CheClusterStatus{
+			DbProvisoned: true,
+			Conditions: []orgv1.CheClusterCondition{
+				{Type: orgv1.ConditionPostgresReady, Status: metav1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func getTestSecret(name string, password string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "eclipse-che",
+			UID:       "uid",
+		},
+		Data: map[string][]byte{"user": []byte("user"), "password": []byte(password)},
+	}
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cheCluster := getTestCheCluster()
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{
+		cheCluster,
+		getTestSecret("che-postgres-secret", "postgres-password"),
+		getTestSecret("che-identity-secret", "admin-password"),
+		getTestSecret("unrelated-secret", "password"),
+	}...)
+
+	snapshot, err := TakeSnapshot(cli, cheCluster)
+	if err != nil {
+		t.Fatalf("Failed to take snapshot: %v", err)
+	}
+
+	data, err := snapshot.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %v", err)
+	}
+	actual, err := UnmarshalSnapshot(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal snapshot: %v", err)
+	}
+
+	if actual.CheCluster.Name != "eclipse-che" || actual.CheCluster.Namespace != "" || actual.CheCluster.UID != "" ||
+		actual.CheCluster.ResourceVersion != "" || len(actual.CheCluster.Finalizers) != 0 {
+		t.Errorf("CheCluster metadata is expected to be cleaned up, but got: %v", actual.CheCluster.ObjectMeta)
+	}
+	if !reflect.DeepEqual(actual.CheCluster.Annotations, map[string]string{"che.eclipse.org/annotation": "value"}) {
+		t.Errorf("Unexpected CheCluster annotations: %v", actual.CheCluster.Annotations)
+	}
+	if actual.CheCluster.Spec.Database.ChePostgresSecret != "che-postgres-secret" || actual.CheCluster.Status.DbProvisoned {
+		t.Errorf("CheCluster is expected to contain the spec only")
+	}
+
+	secrets := map[string]string{}
+	for _, secret := range actual.Secrets {
+		if secret.UID != "" || secret.Namespace != "" {
+			t.Errorf("Secret metadata is expected to be cleaned up, but got: %v", secret.ObjectMeta)
+		}
+		secrets[secret.Name] = string(secret.Data["password"])
+	}
+	if !reflect.DeepEqual(secrets, map[string]string{"che-postgres-secret": "postgres-password", "che-identity-secret": "admin-password"}) {
+		t.Errorf("Unexpected secrets: %v", secrets)
+	}
+
+	if !reflect.DeepEqual(actual.Databases, []string{"dbche", "keycloak"}) {
+		t.Errorf("Unexpected databases: %v", actual.Databases)
+	}
+}
+
+func TestStoreSnapshot(t *testing.T) {
+	cheCluster := getTestCheCluster()
+	snapshot := &Snapshot{CheCluster: cheCluster, Databases: []string{"dbche", "keycloak"}}
+	storage := &fakeStorage{snapshots: map[string][]byte{}}
+	dumper := &fakeDumper{databases: map[string][]byte{"dbche": []byte("che dump"), "keycloak": []byte("keycloak dump")}}
+
+	if err := storeSnapshot(storage, dumper, cheCluster, snapshot, "snapshot.tar.gz"); err != nil {
+		t.Fatalf("Failed to store snapshot: %v", err)
+	}
+	for _, name := range []string{"snapshot.tar.gz", "snapshot-dbche.sql.gz", "snapshot-keycloak.sql.gz"} {
+		if _, ok := storage.snapshots[name]; !ok {
+			t.Errorf("'%s' is expected to be stored", name)
+		}
+	}
+
+	restored := &fakeDumper{databases: map[string][]byte{}}
+	for _, database := range snapshot.Databases {
+		if err := restoreDatabaseDump(storage, restored, cheCluster, "snapshot.tar.gz", database); err != nil {
+			t.Fatalf("Failed to restore database '%s': %v", database, err)
+		}
+	}
+	if !reflect.DeepEqual(restored.databases, dumper.databases) {
+		t.Errorf("Unexpected restored databases: %v", restored.databases)
+	}
+
+	if err := deleteSnapshot(storage, "snapshot.tar.gz"); err != nil {
+		t.Fatalf("Failed to delete snapshot: %v", err)
+	}
+	if len(storage.snapshots) != 0 {
+		t.Errorf("Snapshot and dumps are expected to be deleted, but got: %v", storage.snapshots)
+	}
+}
+
+func TestStoreSnapshotFailsIfDumpFails(t *testing.T) {
+	cheCluster := getTestCheCluster()
+	snapshot := &Snapshot{CheCluster: cheCluster, Databases: []string{"dbche"}}
+	storage := &fakeStorage{snapshots: map[string][]byte{}}
+
+	if err := storeSnapshot(storage, &failingDumper{}, cheCluster, snapshot, "snapshot.tar.gz"); err == nil {
+		t.Fatal("Storing snapshot is expected to fail")
+	}
+	if _, ok := storage.snapshots["snapshot.tar.gz"]; ok {
+		t.Error("Incomplete snapshot is not expected to be stored")
+	}
+}
+
+// failingDumper fails in the middle of the dump
+type failingDumper struct{}
+
+func (d *failingDumper) Dump(cheCluster *orgv1.CheCluster, database string, dump io.Writer) error {
+	if _, err := dump.Write([]byte("partial dump")); err != nil {
+		return err
+	}
+	return fmt.Errorf("pg_dump failed")
+}
+
+func (d *failingDumper) Restore(cheCluster *orgv1.CheCluster, database string, dump io.Reader) error {
+	return fmt.Errorf("pg_restore failed")
+}
+
+func TestGetBackupDatabases(t *testing.T) {
+	cheCluster := getTestCheCluster()
+	if databases := getBackupDatabases(cheCluster); !reflect.DeepEqual(databases, []string{"dbche", "keycloak"}) {
+		t.Errorf("Unexpected databases: %v", databases)
+	}
+
+	cheCluster.Spec.Auth.OpenIdConnect = &orgv1.OpenIdConnectProvider{IssuerURL: "https://dex.example.com", ClientId: "che"}
+	if databases := getBackupDatabases(cheCluster); !reflect.DeepEqual(databases, []string{"dbche"}) {
+		t.Errorf("Keycloak database is not expected to be backed up with OpenID Connect provider: %v", databases)
+	}
+
+	cheCluster.Spec.Auth.OpenIdConnect = nil
+	cheCluster.Spec.Auth.ExternalIdentityProvider = true
+	if databases := getBackupDatabases(cheCluster); !reflect.DeepEqual(databases, []string{"dbche"}) {
+		t.Errorf("Keycloak database of external Identity Provider is not expected to be backed up: %v", databases)
+	}
+
+	cheCluster.Spec.Database.ExternalDb = true
+	if databases := getBackupDatabases(cheCluster); len(databases) != 0 {
+		t.Errorf("External database is not expected to be backed up: %v", databases)
+	}
+}
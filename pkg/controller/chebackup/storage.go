@@ -0,0 +1,97 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BackupStorage stores snapshots in a backup target.
+// The content is streamed, so that the dumps of large databases aren't kept in memory.
+type BackupStorage interface {
+	// Put stores the content read until the end of the reader,
+	// a read error is returned and nothing is stored then
+	Put(name string, content io.Reader) error
+	// Get returns the reader of the stored content, which has to be closed.
+	// Returns objectNotFoundError if nothing is stored under the name
+	Get(name string) (io.ReadCloser, error)
+	Delete(name string) error
+	// Close releases the resources acquired to access the backup target
+	Close() error
+}
+
+// BackupStorageFactory creates the storage for the backup target.
+// Returns errStorageNotReady if the storage can't be accessed yet.
+type BackupStorageFactory func(cli client.Client, namespace string, target orgv1.BackupTarget) (BackupStorage, error)
+
+// objectPathRegexp matches the paths of the objects in the backup target, which are passed to the commands run in the helper pod
+var objectPathRegexp = regexp.MustCompile(`^[A-Za-z0-9._/-]*$`)
+
+// errStorageNotReady is returned when the storage is being prepared, the operation should be retried later
+var errStorageNotReady = fmt.Errorf("backup storage is not ready")
+
+// objectNotFoundError is returned when nothing is stored under the name in the backup target
+type objectNotFoundError struct {
+	name string
+}
+
+func (e *objectNotFoundError) Error() string {
+	return fmt.Sprintf("'%s' not found in the backup target", e.name)
+}
+
+func isObjectNotFound(err error) bool {
+	_, ok := err.(*objectNotFoundError)
+	return ok
+}
+
+// NewBackupStorage creates the storage for the backup target.
+func NewBackupStorage(cli client.Client, namespace string, target orgv1.BackupTarget) (BackupStorage, error) {
+	if err := validateBackupTarget(target); err != nil {
+		return nil, err
+	}
+	if target.S3 != nil {
+		return NewS3Storage(cli, namespace, *target.S3)
+	}
+	return NewPvcStorage(cli, namespace, *target.Pvc)
+}
+
+func validateBackupTarget(target orgv1.BackupTarget) error {
+	if (target.Pvc == nil) == (target.S3 == nil) {
+		return newValidationError("exactly one of the 'pvc' and 's3' backup targets must be set")
+	}
+	if target.Pvc != nil && target.Pvc.ClaimName == "" {
+		return newValidationError("'claimName' of the 'pvc' backup target must be set")
+	}
+	if target.Pvc != nil {
+		if err := validateObjectPath("path", target.Pvc.Path); err != nil {
+			return err
+		}
+	}
+	if target.S3 != nil && (target.S3.Endpoint == "" || target.S3.Bucket == "" || target.S3.CredentialsSecretName == "") {
+		return newValidationError("'endpoint', 'bucket' and 'credentialsSecretName' of the 's3' backup target must be set")
+	}
+	return nil
+}
+
+// validateObjectPath rejects the paths which could point outside the backup target.
+func validateObjectPath(field string, value string) error {
+	if strings.HasPrefix(value, "/") || strings.Contains(value, "..") || !objectPathRegexp.MatchString(value) {
+		return newValidationError("'%s' must be a relative path without '..', made of the characters 'A-Za-z0-9._/-'", field)
+	}
+	return nil
+}
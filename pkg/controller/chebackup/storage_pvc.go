@@ -0,0 +1,187 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	backupPodComponentName = "che-backup"
+	backupPodMountPath     = "/backups"
+)
+
+// PvcStorage - BackupStorage implementation which stores snapshots in a persistent volume claim.
+// The claim is mounted into a helper pod, and snapshots are copied in and out of the pod.
+type PvcStorage struct {
+	cli       client.Client
+	namespace string
+	podName   string
+	dir       string
+}
+
+// NewPvcStorage creates the helper pod which mounts the claim.
+// Returns errStorageNotReady until the pod is running.
+func NewPvcStorage(cli client.Client, namespace string, target orgv1.BackupTargetPvc) (*PvcStorage, error) {
+	storage := &PvcStorage{
+		cli:       cli,
+		namespace: namespace,
+		podName:   backupPodComponentName + "-" + target.ClaimName,
+		dir:       path.Join(backupPodMountPath, target.Path),
+	}
+
+	pod := &corev1.Pod{}
+	err := cli.Get(context.TODO(), types.NamespacedName{Name: storage.podName, Namespace: namespace}, pod)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		logrus.Infof("Creating pod '%s' to access the persistent volume claim '%s'", storage.podName, target.ClaimName)
+		if err := cli.Create(context.TODO(), getBackupPodSpec(storage.podName, namespace, target.ClaimName)); err != nil {
+			return nil, err
+		}
+		return nil, errStorageNotReady
+	}
+
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return nil, errStorageNotReady
+	}
+	return storage, nil
+}
+
+func getBackupPodSpec(name string, namespace string, claimName string) *corev1.Pod {
+	return &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				deploy.KubernetesPartOfLabelKey:    deploy.CheEclipseOrg,
+				deploy.KubernetesComponentLabelKey: backupPodComponentName,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:            backupPodComponentName,
+					Image:           deploy.DefaultPvcJobsImage(&orgv1.CheCluster{}),
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/bin/sh", "-c", "trap 'exit 0' TERM; while true; do sleep 5; done"},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "backups", MountPath: backupPodMountPath},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "backups",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Put writes the content into a temporary file, which is moved in place once complete.
+// The paths are passed to the commands as arguments, they are never interpolated into a shell script.
+func (s *PvcStorage) Put(name string, content io.Reader) error {
+	file := path.Join(s.dir, name)
+	if _, err := s.exec([]string{"mkdir", "-p", path.Dir(file)}, nil); err != nil {
+		return err
+	}
+
+	// a failed read looks like the end of the content to the command, so the stored file is removed then
+	reader := &readErrorRecorder{reader: content}
+	_, err := s.exec([]string{"/bin/sh", "-c", `cat > "$1.part" && mv "$1.part" "$1"`, "--", file}, reader)
+	if reader.err != nil {
+		if _, err := s.exec([]string{"rm", "-f", file + ".part", file}, nil); err != nil {
+			logrus.Errorf("Failed to remove incomplete file '%s': %v", file, err)
+		}
+		return reader.err
+	}
+	return err
+}
+
+// Get streams the file out of the helper pod.
+func (s *PvcStorage) Get(name string) (io.ReadCloser, error) {
+	file := path.Join(s.dir, name)
+	stdout, err := s.exec([]string{"/bin/sh", "-c", `if [ -f "$1" ]; then echo found; fi`, "--", file}, nil)
+	if err != nil {
+		return nil, err
+	} else if strings.TrimSpace(stdout) != "found" {
+		return nil, &objectNotFoundError{name: name}
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		stderr, err := util.K8sclient.RunExecWithStreams([]string{"cat", file}, s.podName, s.namespace, nil, writer)
+		if err != nil {
+			err = fmt.Errorf("%v: %s", err, stderr)
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader, nil
+}
+
+func (s *PvcStorage) Delete(name string) error {
+	_, err := s.exec([]string{"rm", "-f", path.Join(s.dir, name)}, nil)
+	return err
+}
+
+// Close deletes the helper pod, so the claim can be mounted elsewhere.
+func (s *PvcStorage) Close() error {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: s.podName, Namespace: s.namespace}}
+	if err := s.cli.Delete(context.TODO(), pod); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *PvcStorage) exec(command []string, stdin io.Reader) (string, error) {
+	stdout, stderr, err := util.K8sclient.RunExecWithStdin(command, s.podName, s.namespace, stdin)
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, stderr)
+	}
+	return stdout, nil
+}
+
+// readErrorRecorder keeps the error of the reader, which isn't reported by the exec of a command
+type readErrorRecorder struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
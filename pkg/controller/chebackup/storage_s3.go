@@ -0,0 +1,310 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	s3AccessKeyIdSecretKey     = "awsAccessKeyId"
+	s3SecretAccessKeySecretKey = "awsSecretAccessKey"
+	s3DefaultRegion            = "us-east-1"
+	// s3PartSize is the size of the parts of the multipart uploads, they are kept in memory while uploaded
+	s3PartSize = 16 * 1024 * 1024
+)
+
+// S3Storage - BackupStorage implementation which stores snapshots in a bucket of an S3-compatible object storage.
+// Requests are signed with AWS Signature Version 4 and use path-style addressing, which MinIO supports as well.
+type S3Storage struct {
+	target          orgv1.BackupTargetS3
+	accessKeyId     string
+	secretAccessKey string
+	httpClient      *http.Client
+	now             func() time.Time
+	partSize        int
+}
+
+// NewS3Storage reads the credentials of the bucket and creates the storage.
+func NewS3Storage(cli client.Client, namespace string, target orgv1.BackupTargetS3) (*S3Storage, error) {
+	secret := &corev1.Secret{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: target.CredentialsSecretName, Namespace: namespace}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, newValidationError("secret '%s' of the 's3' backup target not found", target.CredentialsSecretName)
+		}
+		return nil, err
+	}
+
+	accessKeyId := string(secret.Data[s3AccessKeyIdSecretKey])
+	secretAccessKey := string(secret.Data[s3SecretAccessKeySecretKey])
+	if accessKeyId == "" || secretAccessKey == "" {
+		return nil, newValidationError("secret '%s' must contain the '%s' and '%s' keys", target.CredentialsSecretName, s3AccessKeyIdSecretKey, s3SecretAccessKeySecretKey)
+	}
+	if target.Region == "" {
+		target.Region = s3DefaultRegion
+	}
+
+	return &S3Storage{
+		target:          target,
+		accessKeyId:     accessKeyId,
+		secretAccessKey: secretAccessKey,
+		// the whole request isn't limited in time, since the content is streamed
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 5 * time.Minute,
+			},
+		},
+		now:      time.Now,
+		partSize: s3PartSize,
+	}, nil
+}
+
+// Put uploads the content in a single request if it fits one part, otherwise with a multipart upload,
+// so that no more than a part is kept in memory.
+func (s *S3Storage) Put(name string, content io.Reader) error {
+	part := make([]byte, s.partSize)
+	n, err := io.ReadFull(content, part)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		response, err := s.do(http.MethodPut, name, nil, part[:n])
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	} else if err != nil {
+		return err
+	}
+	return s.putMultipart(name, part, content)
+}
+
+// putMultipart uploads the content by parts, the first one is read already into the buffer.
+func (s *S3Storage) putMultipart(name string, buffer []byte, content io.Reader) error {
+	response, err := s.do(http.MethodPost, name, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return err
+	}
+	initiateResult := &struct {
+		UploadId string `xml:"UploadId"`
+	}{}
+	err = xml.NewDecoder(response.Body).Decode(initiateResult)
+	response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read the multipart upload id of '%s': %v", name, err)
+	}
+	uploadId := url.Values{"uploadId": {initiateResult.UploadId}}
+
+	complete := &s3CompleteMultipartUpload{}
+	err = func() error {
+		part := buffer
+		for partNumber := 1; len(part) > 0; partNumber++ {
+			query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {initiateResult.UploadId}}
+			response, err := s.do(http.MethodPut, name, query, part)
+			if err != nil {
+				return err
+			}
+			response.Body.Close()
+			complete.Parts = append(complete.Parts, s3CompletedPart{PartNumber: partNumber, ETag: response.Header.Get("ETag")})
+
+			n, err := io.ReadFull(content, buffer)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return err
+			}
+			part = buffer[:n]
+		}
+
+		body, err := xml.Marshal(complete)
+		if err != nil {
+			return err
+		}
+		response, err := s.do(http.MethodPost, name, uploadId, body)
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	}()
+
+	if err != nil {
+		// the parts uploaded so far are discarded
+		if response, abortErr := s.do(http.MethodDelete, name, uploadId, nil); abortErr != nil {
+			logrus.Errorf("Failed to abort the multipart upload of '%s': %v", name, abortErr)
+		} else {
+			response.Body.Close()
+		}
+	}
+	return err
+}
+
+func (s *S3Storage) Get(name string) (io.ReadCloser, error) {
+	response, err := s.do(http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func (s *S3Storage) Delete(name string) error {
+	response, err := s.do(http.MethodDelete, name, nil, nil)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+func (s *S3Storage) Close() error {
+	return nil
+}
+
+// do sends the request and returns the response, whose body has to be closed, if it succeeded.
+func (s *S3Storage) do(method string, name string, query url.Values, body []byte) (*http.Response, error) {
+	endpoint, err := url.Parse(s.target.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Path = "/" + path.Join(s.target.Bucket, s.target.Prefix, name)
+	endpoint.RawQuery = getCanonicalQueryString(query)
+
+	request, err := http.NewRequest(method, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(request, body)
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return response, nil
+	}
+
+	defer response.Body.Close()
+	if method == http.MethodGet && response.StatusCode == http.StatusNotFound {
+		return nil, &objectNotFoundError{name: name}
+	}
+	content, _ := ioutil.ReadAll(io.LimitReader(response.Body, 4096))
+	return nil, fmt.Errorf("%s '%s' failed with status %d: %s", method, endpoint.Path, response.StatusCode, string(content))
+}
+
+// getCanonicalQueryString returns the query string sorted by key and encoded the way the signature requires
+func getCanonicalQueryString(query url.Values) string {
+	keys := []string{}
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parameters := []string{}
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parameters = append(parameters, s3URIEncode(key)+"="+s3URIEncode(value))
+		}
+	}
+	return strings.Join(parameters, "&")
+}
+
+// s3URIEncode encodes every byte except the unreserved characters
+func s3URIEncode(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		if 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' || b == '-' || b == '_' || b == '.' || b == '~' {
+			builder.WriteByte(b)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// sign adds the AWS Signature Version 4 headers to the request.
+func (s *S3Storage) sign(request *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		"host:" + request.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.target.Region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.target.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyId, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
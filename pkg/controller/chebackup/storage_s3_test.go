@@ -0,0 +1,186 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newFakeBucket starts a server which keeps the objects in memory and checks the requests are signed
+func newFakeBucket(t *testing.T) (*httptest.Server, map[string][]byte) {
+	objects := map[string][]byte{}
+	// parts of the multipart uploads by the object path
+	uploads := map[string][][]byte{}
+	var mutex sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		body, _ := ioutil.ReadAll(r.Body)
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=access-key/20210102/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+			t.Errorf("Unexpected authorization header: %s", authorization)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) || r.Header.Get("X-Amz-Date") != "20210102T030405Z" {
+			t.Errorf("Unexpected signature headers: %v", r.Header)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && query.Get("uploads") == "" && r.URL.RawQuery == "uploads=":
+			uploads[r.URL.Path] = [][]byte{}
+			w.Write([]byte("<InitiateMultipartUploadResult><UploadId>upload-id</UploadId></InitiateMultipartUploadResult>"))
+		case r.Method == http.MethodPut && query.Get("uploadId") == "upload-id":
+			partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+			if partNumber != len(uploads[r.URL.Path])+1 {
+				t.Errorf("Unexpected part number: %d", partNumber)
+			}
+			uploads[r.URL.Path] = append(uploads[r.URL.Path], body)
+			w.Header().Set("ETag", fmt.Sprintf("\"etag-%d\"", partNumber))
+		case r.Method == http.MethodPost && query.Get("uploadId") == "upload-id":
+			complete := &s3CompleteMultipartUpload{}
+			if err := xml.Unmarshal(body, complete); err != nil || len(complete.Parts) != len(uploads[r.URL.Path]) {
+				t.Errorf("Unexpected completion of the multipart upload: %s", body)
+			}
+			objects[r.URL.Path] = bytes.Join(uploads[r.URL.Path], nil)
+			delete(uploads, r.URL.Path)
+		case r.Method == http.MethodPut:
+			objects[r.URL.Path] = body
+		case r.Method == http.MethodGet:
+			object, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("NoSuchKey"))
+				return
+			}
+			w.Write(object)
+		case r.Method == http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	return server, objects
+}
+
+func TestS3Storage(t *testing.T) {
+	server, objects := newFakeBucket(t)
+	defer server.Close()
+
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3-credentials", Namespace: "eclipse-che"},
+		Data: map[string][]byte{
+			s3AccessKeyIdSecretKey:     []byte("access-key"),
+			s3SecretAccessKeySecretKey: []byte("secret-key"),
+		},
+	})
+
+	storage, err := NewS3Storage(cli, "eclipse-che", orgv1.BackupTargetS3{
+		Endpoint:              server.URL,
+		Region:                "eu-west-1",
+		Bucket:                "backups",
+		Prefix:                "che",
+		CredentialsSecretName: "s3-credentials",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	storage.now = func() time.Time { return time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	if err := storage.Put("snapshot.tar.gz", strings.NewReader("snapshot")); err != nil {
+		t.Fatalf("Failed to put snapshot: %v", err)
+	}
+	if string(objects["/backups/che/snapshot.tar.gz"]) != "snapshot" {
+		t.Fatalf("Snapshot is expected to be stored in the bucket, but got: %v", objects)
+	}
+
+	reader, err := storage.Get("snapshot.tar.gz")
+	if err != nil {
+		t.Fatalf("Failed to get snapshot: %v", err)
+	}
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(data) != "snapshot" {
+		t.Fatalf("Failed to read snapshot: %s, %v", string(data), err)
+	}
+
+	if err := storage.Delete("snapshot.tar.gz"); err != nil {
+		t.Fatalf("Failed to delete snapshot: %v", err)
+	}
+	if _, err := storage.Get("snapshot.tar.gz"); !isObjectNotFound(err) {
+		t.Fatalf("Snapshot is expected to be deleted, but got: %v", err)
+	}
+}
+
+func TestS3StorageMultipartUpload(t *testing.T) {
+	server, objects := newFakeBucket(t)
+	defer server.Close()
+
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3-credentials", Namespace: "eclipse-che"},
+		Data: map[string][]byte{
+			s3AccessKeyIdSecretKey:     []byte("access-key"),
+			s3SecretAccessKeySecretKey: []byte("secret-key"),
+		},
+	})
+
+	storage, err := NewS3Storage(cli, "eclipse-che", orgv1.BackupTargetS3{
+		Endpoint:              server.URL,
+		Region:                "eu-west-1",
+		Bucket:                "backups",
+		CredentialsSecretName: "s3-credentials",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	storage.now = func() time.Time { return time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC) }
+	storage.partSize = 4
+
+	if err := storage.Put("dump.sql.gz", strings.NewReader("database dump")); err != nil {
+		t.Fatalf("Failed to put dump: %v", err)
+	}
+	if string(objects["/backups/dump.sql.gz"]) != "database dump" {
+		t.Fatalf("Dump is expected to be stored in the bucket, but got: %v", objects)
+	}
+}
+
+func TestS3StorageRequiresCredentials(t *testing.T) {
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3-credentials", Namespace: "eclipse-che"},
+		Data:       map[string][]byte{s3AccessKeyIdSecretKey: []byte("access-key")},
+	})
+
+	_, err := NewS3Storage(cli, "eclipse-che", orgv1.BackupTargetS3{Endpoint: "http://minio:9000", Bucket: "backups", CredentialsSecretName: "s3-credentials"})
+	if err == nil {
+		t.Fatal("Storage is not expected to be created without the secret access key")
+	}
+}
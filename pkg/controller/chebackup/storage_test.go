@@ -0,0 +1,45 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package chebackup
+
+import (
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+)
+
+func TestValidateBackupTargetPath(t *testing.T) {
+	type testCase struct {
+		path        string
+		expectedErr bool
+	}
+
+	testCases := []testCase{
+		{path: "", expectedErr: false},
+		{path: "che/backups", expectedErr: false},
+		{path: "che-backups_1.0/", expectedErr: false},
+		{path: "/backups", expectedErr: true},
+		{path: "../backups", expectedErr: true},
+		{path: "che/../../backups", expectedErr: true},
+		{path: "backups'; rm -rf /backups; '", expectedErr: true},
+		{path: "backups$(id)", expectedErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.path, func(t *testing.T) {
+			err := validateBackupTarget(orgv1.BackupTarget{Pvc: &orgv1.BackupTargetPvc{ClaimName: "backups", Path: testCase.path}})
+			if (err != nil) != testCase.expectedErr {
+				t.Errorf("Unexpected validation result for the path '%s': %v", testCase.path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"fmt"
+	"reflect"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+)
+
+const (
+	defaultCertManagerIssuerKind  = "Issuer"
+	defaultCertManagerIssuerGroup = "cert-manager.io"
+)
+
+var certManagerCertificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+// Only the spec of the certificate is managed, its status is written by cert-manager
+var certManagerCertificateDiffOpts = cmp.Comparer(func(x, y unstructured.Unstructured) bool {
+	return reflect.DeepEqual(x.Object["spec"], y.Object["spec"])
+})
+
+// IsCertManagerAvailable checks whether the cert-manager Certificate API is served by the cluster.
+// The other APIs which fail to be discovered are skipped.
+func IsCertManagerAvailable(deployContext *DeployContext) (bool, error) {
+	_, resourcesList, err := deployContext.ClusterAPI.DiscoveryClient.ServerGroupsAndResources()
+	if err != nil {
+		groupDiscoveryFailedErr, ok := err.(*discovery.ErrGroupDiscoveryFailed)
+		if !ok {
+			return false, err
+		}
+		if groupErr, failed := groupDiscoveryFailedErr.Groups[certManagerCertificateGVK.GroupVersion()]; failed {
+			return false, groupErr
+		}
+	}
+
+	for _, l := range resourcesList {
+		for _, r := range l.APIResources {
+			if l.GroupVersion == certManagerCertificateGVK.GroupVersion().String() && r.Kind == certManagerCertificateGVK.Kind {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// DetectCertManager records in the deploy context whether the certificates of the Che endpoints
+// can be issued by cert-manager. When an issuer is referenced but cert-manager is not installed,
+// the `CertManagerNotFound` condition is set and the endpoints are secured as if no issuer was referenced.
+func DetectCertManager(deployContext *DeployContext) error {
+	deployContext.CertManagerAvailable = false
+	cr := deployContext.CheCluster
+	if !cr.Spec.Server.TlsSupport || cr.Spec.Server.CertManagerIssuer == nil {
+		return RemoveWarningCondition(deployContext, orgv1.ConditionCertManagerNotFound)
+	}
+
+	available, err := IsCertManagerAvailable(deployContext)
+	if err != nil {
+		return err
+	}
+	deployContext.CertManagerAvailable = available
+	if !available {
+		return SetWarningCondition(deployContext, orgv1.ConditionCertManagerNotFound,
+			fmt.Sprintf("The cert-manager issuer '%s' is ignored, the cert-manager API %s is not installed", cr.Spec.Server.CertManagerIssuer.Name, certManagerCertificateGVK.GroupVersion().String()))
+	}
+	return RemoveWarningCondition(deployContext, orgv1.ConditionCertManagerNotFound)
+}
+
+// GetCertManagerCertificateName returns the name of the cert-manager Certificate, and of its secret, which secures the endpoint.
+// The endpoints share the certificate of the Che host unless they are exposed on their own host.
+func GetCertManagerCertificateName(cr *orgv1.CheCluster, endpointName string) string {
+	if util.GetServerExposureStrategy(cr, DefaultServerExposureStrategy) != "multi-host" {
+		endpointName = DefaultCheFlavor(cr)
+	}
+	return endpointName + "-certificate"
+}
+
+// UseCertManagerCertificate tells whether the endpoint is secured by a certificate issued by cert-manager.
+// The secret set in `cheHostTLSSecret` takes precedence for the Che host.
+func UseCertManagerCertificate(deployContext *DeployContext, endpointName string) bool {
+	cr := deployContext.CheCluster
+	if !deployContext.CertManagerAvailable || !cr.Spec.Server.TlsSupport || cr.Spec.Server.CertManagerIssuer == nil {
+		return false
+	}
+	if IsGatewayAPIUsed(deployContext) {
+		// TLS is terminated by the Gateway
+		return false
+	}
+	cheFlavor := DefaultCheFlavor(cr)
+	return cr.Spec.Server.CheHostTLSSecret == "" || GetCertManagerCertificateName(cr, endpointName) != GetCertManagerCertificateName(cr, cheFlavor)
+}
+
+// SyncCertManagerCertificate creates or updates the cert-manager Certificate which secures the endpoint on the given host.
+// Returns true when the certificate is ready, or when the endpoint isn't secured by cert-manager.
+func SyncCertManagerCertificate(deployContext *DeployContext, endpointName string, host string) (bool, error) {
+	cr := deployContext.CheCluster
+	if !UseCertManagerCertificate(deployContext, endpointName) || host == "" {
+		return true, nil
+	}
+	if util.GetServerExposureStrategy(cr, DefaultServerExposureStrategy) != "multi-host" && endpointName != DefaultCheFlavor(cr) {
+		// the endpoint is secured by the certificate of the Che host
+		return true, nil
+	}
+
+	name := GetCertManagerCertificateName(cr, endpointName)
+
+	specCertificate := getSpecCertManagerCertificate(deployContext, name, host)
+	clusterCertificate := &unstructured.Unstructured{}
+	clusterCertificate.SetGroupVersionKind(certManagerCertificateGVK)
+	exists, err := Get(deployContext, types.NamespacedName{Name: name, Namespace: cr.Namespace}, clusterCertificate)
+	if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		_, err := Create(deployContext, specCertificate)
+		return false, err
+	}
+
+	done, err := Update(deployContext, clusterCertificate, specCertificate, certManagerCertificateDiffOpts)
+	if !done {
+		return false, err
+	}
+
+	if !isCertManagerCertificateReady(clusterCertificate) {
+		logrus.Infof("Waiting on cert-manager certificate '%s' to be ready", name)
+		return false, nil
+	}
+	return true, nil
+}
+
+func getSpecCertManagerCertificate(deployContext *DeployContext, name string, host string) *unstructured.Unstructured {
+	issuer := deployContext.CheCluster.Spec.Server.CertManagerIssuer
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certManagerCertificateGVK)
+	certificate.SetName(name)
+	certificate.SetNamespace(deployContext.CheCluster.Namespace)
+	certificate.SetLabels(GetLabels(deployContext.CheCluster, DefaultCheFlavor(deployContext.CheCluster)))
+	certificate.Object["spec"] = map[string]interface{}{
+		"secretName": name,
+		"dnsNames":   []interface{}{host},
+		"issuerRef": map[string]interface{}{
+			"name":  issuer.Name,
+			"kind":  util.GetValue(issuer.Kind, defaultCertManagerIssuerKind),
+			"group": util.GetValue(issuer.Group, defaultCertManagerIssuerGroup),
+		},
+	}
+	return certificate
+}
+
+func isCertManagerCertificateReady(certificate *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Ready" {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
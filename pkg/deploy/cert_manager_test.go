@@ -0,0 +1,286 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestDetectCertManager(t *testing.T) {
+	type testCase struct {
+		name              string
+		issuer            *orgv1.CertManagerIssuer
+		resources         []*metav1.APIResourceList
+		expectedAvailable bool
+	}
+
+	certManagerResources := []*metav1.APIResourceList{
+		{
+			GroupVersion: "cert-manager.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "certificates", Kind: "Certificate"},
+				{Name: "issuers", Kind: "Issuer"},
+			},
+		},
+	}
+
+	testCases := []testCase{
+		{
+			name:              "No issuer referenced",
+			resources:         certManagerResources,
+			expectedAvailable: false,
+		},
+		{
+			name:              "cert-manager installed",
+			issuer:            &orgv1.CertManagerIssuer{Name: "letsencrypt"},
+			resources:         certManagerResources,
+			expectedAvailable: true,
+		},
+		{
+			name:   "cert-manager not installed",
+			issuer: &orgv1.CertManagerIssuer{Name: "letsencrypt"},
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "certificates.k8s.io/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "certificatesigningrequests", Kind: "CertificateSigningRequest"},
+					},
+				},
+			},
+			expectedAvailable: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			deployContext := getTestTLSDeployContext()
+			deployContext.CheCluster.Spec.Server.CertManagerIssuer = testCase.issuer
+			fakeDiscovery, _ := fakeclientset.NewSimpleClientset().Discovery().(*fakeDiscovery.FakeDiscovery)
+			fakeDiscovery.Fake.Resources = testCase.resources
+			deployContext.ClusterAPI.DiscoveryClient = fakeDiscovery
+
+			if err := DetectCertManager(deployContext); err != nil {
+				t.Fatalf("Failed to detect cert-manager: %v", err)
+			}
+			if deployContext.CertManagerAvailable != testCase.expectedAvailable {
+				t.Errorf("Expected cert-manager available: %t, got: %t", testCase.expectedAvailable, deployContext.CertManagerAvailable)
+			}
+			notFound := testCase.issuer != nil && !testCase.expectedAvailable
+			if orgv1.IsConditionTrue(deployContext.CheCluster.Status.Conditions, orgv1.ConditionCertManagerNotFound) != notFound {
+				t.Errorf("Expected %s condition to be set: %t", orgv1.ConditionCertManagerNotFound, notFound)
+			}
+		})
+	}
+}
+
+func TestDetectCertManagerRecordsEventOnChange(t *testing.T) {
+	deployContext := getTestTLSDeployContext()
+	deployContext.CheCluster.Spec.Server.CertManagerIssuer = &orgv1.CertManagerIssuer{Name: "letsencrypt"}
+	recorder := record.NewFakeRecorder(10)
+	deployContext.EventRecorder = recorder
+	fakeDiscovery, _ := fakeclientset.NewSimpleClientset().Discovery().(*fakeDiscovery.FakeDiscovery)
+	deployContext.ClusterAPI.DiscoveryClient = fakeDiscovery
+
+	detectCertManager := func(installed bool, expectedEvents int) {
+		fakeDiscovery.Fake.Resources = []*metav1.APIResourceList{}
+		if installed {
+			fakeDiscovery.Fake.Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: "cert-manager.io/v1",
+					APIResources: []metav1.APIResource{{Name: "certificates", Kind: "Certificate"}},
+				},
+			}
+		}
+		if err := DetectCertManager(deployContext); err != nil {
+			t.Fatalf("Failed to detect cert-manager: %v", err)
+		}
+		if len(recorder.Events) != expectedEvents {
+			t.Fatalf("Expected %d events, got %d", expectedEvents, len(recorder.Events))
+		}
+		for i := 0; i < expectedEvents; i++ {
+			if event := <-recorder.Events; !strings.Contains(event, orgv1.ConditionCertManagerNotFound) {
+				t.Errorf("Unexpected event: %s", event)
+			}
+		}
+	}
+
+	// the warning is recorded once, and again after cert-manager is installed and removed
+	detectCertManager(false, 1)
+	detectCertManager(false, 0)
+	detectCertManager(true, 0)
+	if orgv1.FindCondition(deployContext.CheCluster.Status.Conditions, orgv1.ConditionCertManagerNotFound) != nil {
+		t.Errorf("Expected %s condition to be removed", orgv1.ConditionCertManagerNotFound)
+	}
+	detectCertManager(false, 1)
+
+	cheCluster := &orgv1.CheCluster{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: "eclipse-che", Namespace: "eclipse-che"}, cheCluster); err != nil {
+		t.Fatalf("Failed to get the CheCluster: %v", err)
+	}
+	if !orgv1.IsConditionTrue(cheCluster.Status.Conditions, orgv1.ConditionCertManagerNotFound) {
+		t.Errorf("Expected %s condition to be stored in the status", orgv1.ConditionCertManagerNotFound)
+	}
+}
+
+// partialDiscovery fails to discover the given groups, like the discovery client does
+// when an aggregated API is unavailable.
+type partialDiscovery struct {
+	*fakeDiscovery.FakeDiscovery
+	failedGroups map[schema.GroupVersion]error
+}
+
+func (d *partialDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	groups, resources, _ := d.FakeDiscovery.ServerGroupsAndResources()
+	return groups, resources, &discovery.ErrGroupDiscoveryFailed{Groups: d.failedGroups}
+}
+
+func TestIsCertManagerAvailableWithPartialDiscovery(t *testing.T) {
+	type testCase struct {
+		name              string
+		failedGroup       schema.GroupVersion
+		expectedAvailable bool
+		expectedErr       bool
+	}
+
+	testCases := []testCase{
+		{
+			name:              "Another group fails to be discovered",
+			failedGroup:       schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"},
+			expectedAvailable: true,
+		},
+		{
+			name:        "The cert-manager group fails to be discovered",
+			failedGroup: certManagerCertificateGVK.GroupVersion(),
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			deployContext := getTestTLSDeployContext()
+			fakeDiscoveryClient, _ := fakeclientset.NewSimpleClientset().Discovery().(*fakeDiscovery.FakeDiscovery)
+			fakeDiscoveryClient.Fake.Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: "cert-manager.io/v1",
+					APIResources: []metav1.APIResource{{Name: "certificates", Kind: "Certificate"}},
+				},
+			}
+			deployContext.ClusterAPI.DiscoveryClient = &partialDiscovery{
+				FakeDiscovery: fakeDiscoveryClient,
+				failedGroups:  map[schema.GroupVersion]error{testCase.failedGroup: errors.New("the server is currently unable to handle the request")},
+			}
+
+			available, err := IsCertManagerAvailable(deployContext)
+			if (err != nil) != testCase.expectedErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if available != testCase.expectedAvailable {
+				t.Errorf("Expected cert-manager available: %t, got: %t", testCase.expectedAvailable, available)
+			}
+		})
+	}
+}
+
+func TestGetCertManagerCertificateName(t *testing.T) {
+	cheCluster := &orgv1.CheCluster{}
+	cheCluster.Spec.Server.ServerExposureStrategy = "multi-host"
+	if name := GetCertManagerCertificateName(cheCluster, DevfileRegistryName); name != "devfile-registry-certificate" {
+		t.Errorf("Unexpected certificate name in multi-host: %s", name)
+	}
+
+	cheCluster.Spec.Server.ServerExposureStrategy = "single-host"
+	if name := GetCertManagerCertificateName(cheCluster, DevfileRegistryName); name != "che-certificate" {
+		t.Errorf("Unexpected certificate name in single-host: %s", name)
+	}
+}
+
+func TestSyncCertManagerCertificate(t *testing.T) {
+	deployContext := getTestTLSDeployContext()
+	deployContext.CheCluster.Spec.Server.ServerExposureStrategy = "multi-host"
+	deployContext.CheCluster.Spec.Server.CertManagerIssuer = &orgv1.CertManagerIssuer{Name: "letsencrypt", Kind: "ClusterIssuer"}
+	deployContext.CertManagerAvailable = true
+
+	done, err := SyncCertManagerCertificate(deployContext, IdentityProviderName, "keycloak-eclipse-che.che.internal")
+	if done || err != nil {
+		t.Fatalf("Expected the certificate to be created and not ready, got: %t, %v", done, err)
+	}
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certManagerCertificateGVK)
+	key := types.NamespacedName{Name: "keycloak-certificate", Namespace: "eclipse-che"}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), key, certificate); err != nil {
+		t.Fatalf("Failed to get the certificate: %v", err)
+	}
+	expectedSpec := map[string]interface{}{
+		"secretName": "keycloak-certificate",
+		"dnsNames":   []interface{}{"keycloak-eclipse-che.che.internal"},
+		"issuerRef": map[string]interface{}{
+			"name":  "letsencrypt",
+			"kind":  "ClusterIssuer",
+			"group": "cert-manager.io",
+		},
+	}
+	if !reflect.DeepEqual(certificate.Object["spec"], expectedSpec) {
+		t.Errorf("Unexpected certificate spec: %v", certificate.Object["spec"])
+	}
+
+	certificate.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True"},
+		},
+	}
+	if err := deployContext.ClusterAPI.Client.Update(context.TODO(), certificate); err != nil {
+		t.Fatalf("Failed to update the certificate: %v", err)
+	}
+
+	done, err = SyncCertManagerCertificate(deployContext, IdentityProviderName, "keycloak-eclipse-che.che.internal")
+	if !done || err != nil {
+		t.Fatalf("Expected the certificate to be ready, got: %t, %v", done, err)
+	}
+}
+
+func TestGetSpecIngressWithCertManagerCertificate(t *testing.T) {
+	util.IsOpenShift = false
+	deployContext := getTestTLSDeployContext()
+	deployContext.CheCluster.Spec.Server.ServerExposureStrategy = "multi-host"
+	deployContext.CheCluster.Spec.Server.CertManagerIssuer = &orgv1.CertManagerIssuer{Name: "letsencrypt"}
+	deployContext.CertManagerAvailable = true
+
+	ingress, err := GetSpecIngress(deployContext, PluginRegistryName, "", PluginRegistryName, 8080, orgv1.IngressCustomSettings{}, PluginRegistryName)
+	if err != nil {
+		t.Fatalf("Failed to get the ingress: %v", err)
+	}
+	if len(ingress.Spec.TLS) != 1 {
+		t.Fatalf("Expected a single TLS entry, got: %v", ingress.Spec.TLS)
+	}
+	if ingress.Spec.TLS[0].SecretName != "plugin-registry-certificate" {
+		t.Errorf("Unexpected TLS secret: %s", ingress.Spec.TLS[0].SecretName)
+	}
+	if !reflect.DeepEqual(ingress.Spec.TLS[0].Hosts, []string{"plugin-registry-eclipse-che.che.internal"}) {
+		t.Errorf("Unexpected TLS hosts: %v", ingress.Spec.TLS[0].Hosts)
+	}
+}
@@ -14,23 +14,42 @@ package deploy
 import (
 	"context"
 
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/util"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 )
 
-func UpdateCheCRSpec(deployContext *DeployContext, updatedField string, value string) (err error) {
-	logrus.Infof("Updating %s CR with %s: %s", deployContext.CheCluster.Name, updatedField, value)
-	err = deployContext.ClusterAPI.Client.Update(context.TODO(), deployContext.CheCluster)
-	if err != nil {
-		logrus.Errorf("Failed to update %s CR: %s", deployContext.CheCluster.Name, err)
-		return err
-	}
-	logrus.Infof("Custom resource %s updated", deployContext.CheCluster.Name)
-	return nil
+// UpdateCheCluster applies the modification to the latest version of the CheCluster and updates it.
+// The update is retried on conflicts. The defaults applied in memory to the spec are never written back.
+func UpdateCheCluster(deployContext *DeployContext, modify func(cheCluster *orgv1.CheCluster)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cheCluster := &orgv1.CheCluster{}
+		key := types.NamespacedName{Name: deployContext.CheCluster.Name, Namespace: deployContext.CheCluster.Namespace}
+		if err := deployContext.ClusterAPI.Client.Get(context.TODO(), key, cheCluster); err != nil {
+			return err
+		}
+
+		modify(cheCluster)
+		if err := deployContext.ClusterAPI.Client.Update(context.TODO(), cheCluster); err != nil {
+			return err
+		}
+		modify(deployContext.CheCluster)
+		deployContext.CheCluster.ObjectMeta.ResourceVersion = cheCluster.ObjectMeta.ResourceVersion
+		return nil
+	})
 }
 
+// UpdateCheCRStatus updates the status of the CheCluster.
+// The spec isn't reloaded from the response, since the Operator keeps the defaults applied in memory.
 func UpdateCheCRStatus(deployContext *DeployContext, updatedField string, value string) (err error) {
 	logrus.Infof("Updating %s CR with %s: %s", deployContext.CheCluster.Name, updatedField, value)
+	spec := deployContext.CheCluster.Spec.DeepCopy()
 	err = deployContext.ClusterAPI.Client.Status().Update(context.TODO(), deployContext.CheCluster)
+	deployContext.CheCluster.Spec = *spec
 	if err != nil {
 		logrus.Errorf("Failed to update %s CR. Fetching the latest CR version: %s", deployContext.CheCluster.Name, err)
 		return err
@@ -38,3 +57,51 @@ func UpdateCheCRStatus(deployContext *DeployContext, updatedField string, value
 	logrus.Infof("Custom resource %s updated", deployContext.CheCluster.Name)
 	return nil
 }
+
+// ReloadCheCluster fetches the latest version of the CheCluster metadata and status.
+// The spec is left intact, since the Operator keeps the defaults applied in memory.
+func ReloadCheCluster(deployContext *DeployContext) error {
+	spec := deployContext.CheCluster.Spec.DeepCopy()
+	err := util.ReloadCheCluster(deployContext.ClusterAPI.Client, deployContext.CheCluster)
+	deployContext.CheCluster.Spec = *spec
+	return err
+}
+
+// SetWarningCondition reports with a condition of the CheCluster a misconfiguration which the Operator works around,
+// such as a referenced API which isn't installed. The warning event is recorded when the condition is set or its message
+// changes, rather than on every reconcile.
+func SetWarningCondition(deployContext *DeployContext, conditionType string, message string) error {
+	if !orgv1.SetCondition(&deployContext.CheCluster.Status.Conditions, orgv1.CheClusterCondition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  conditionType,
+		Message: message,
+	}) {
+		return nil
+	}
+
+	logrus.Warn(message)
+	RecordEvent(deployContext, corev1.EventTypeWarning, conditionType, message)
+	return UpdateCheCRStatus(deployContext, "condition: "+conditionType, message)
+}
+
+// RemoveWarningCondition removes the condition once the misconfiguration it reports is fixed.
+func RemoveWarningCondition(deployContext *DeployContext, conditionType string) error {
+	if !orgv1.RemoveCondition(&deployContext.CheCluster.Status.Conditions, conditionType) {
+		return nil
+	}
+	return UpdateCheCRStatus(deployContext, "condition: "+conditionType, "removed")
+}
+
+// IsReconcilePaused returns true if the Operator must not change anything in the cluster,
+// for instance while an incident is being investigated.
+// It is requested with the `che.eclipse.org/reconcile: paused` annotation of the CheCluster.
+func IsReconcilePaused(cheCluster *orgv1.CheCluster) bool {
+	return cheCluster.ObjectMeta.Annotations[CheEclipseOrgReconcile] == "paused"
+}
+
+// IsMaintenanceMode returns true if Che server is to be stopped and a maintenance page is to be shown instead.
+// It is requested with the `che.eclipse.org/maintenance-mode: "true"` annotation of the CheCluster.
+func IsMaintenanceMode(cheCluster *orgv1.CheCluster) bool {
+	return cheCluster.ObjectMeta.Annotations[CheEclipseOrgMaintenanceMode] == "true"
+}
@@ -0,0 +1,119 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"reflect"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// SetCheClusterDefaults sets the default values of the empty CheCluster spec fields.
+// It is applied by the mutating webhook when CheCluster is admitted and by the Operator in memory,
+// so the Operator never has to write defaults back to the spec.
+func SetCheClusterDefaults(cr *orgv1.CheCluster) {
+	cheFlavor := DefaultCheFlavor(cr)
+	cr.Spec.Server.CheFlavor = cheFlavor
+
+	if GetCheMultiUser(cr) == "true" {
+		cr.Spec.Database.ChePostgresDb = util.GetValue(cr.Spec.Database.ChePostgresDb, DefaultChePostgresDb)
+		// an external database host has to be provided explicitly
+		if !cr.Spec.Database.ExternalDb {
+			cr.Spec.Database.ChePostgresHostName = util.GetValue(cr.Spec.Database.ChePostgresHostName, DefaultChePostgresHostName)
+		}
+		cr.Spec.Database.ChePostgresPort = util.GetValue(cr.Spec.Database.ChePostgresPort, DefaultChePostgresPort)
+		cr.Spec.Auth.IdentityProviderRealm = util.GetValue(cr.Spec.Auth.IdentityProviderRealm, cheFlavor)
+		cr.Spec.Auth.IdentityProviderClientId = util.GetValue(cr.Spec.Auth.IdentityProviderClientId, cheFlavor+"-public")
+	}
+
+	cr.Spec.Server.CheLogLevel = util.GetValue(cr.Spec.Server.CheLogLevel, DefaultCheLogLevel)
+	cr.Spec.Server.CheDebug = util.GetValue(cr.Spec.Server.CheDebug, DefaultCheDebug)
+	cr.Spec.Storage.PvcStrategy = util.GetValue(cr.Spec.Storage.PvcStrategy, DefaultPvcStrategy)
+	cr.Spec.Storage.PvcClaimSize = util.GetValue(cr.Spec.Storage.PvcClaimSize, DefaultPvcClaimSize)
+}
+
+// SetCheClusterStatusDefaults sets in memory the values of the empty CheCluster spec fields,
+// which have been generated or detected by the Operator once and stored in the status.
+func SetCheClusterStatusDefaults(cr *orgv1.CheCluster) {
+	if cr.Spec.Auth.OpenShiftoAuth == nil && cr.Status.OpenShiftoAuthAutoDetected != nil {
+		cr.Spec.Auth.OpenShiftoAuth = util.NewBoolPointer(*cr.Status.OpenShiftoAuthAutoDetected)
+	}
+	cr.Spec.Auth.OAuthClientName = util.GetValue(cr.Spec.Auth.OAuthClientName, cr.Status.OpenShiftOAuthClientName)
+	if IsKeycloakDeployed(cr) {
+		cr.Spec.Auth.IdentityProviderURL = util.GetValue(cr.Spec.Auth.IdentityProviderURL, cr.Status.KeycloakURL)
+	}
+}
+
+// RemoveLegacySpecValues removes, once, the values which former versions of the Operator wrote to the CheCluster spec,
+// and which are now set in memory only. A value is removed only if it is equal to the one which is set in memory,
+// so that the behavior doesn't change. Nothing is removed from a CheCluster which has never been deployed.
+func RemoveLegacySpecValues(deployContext *DeployContext) error {
+	cheCluster := deployContext.CheCluster
+	if cheCluster.Status.LegacySpecValuesRemoved {
+		return nil
+	}
+
+	if cheCluster.Status.CheVersion != "" && hasLegacySpecValues(cheCluster) {
+		logrus.Infof("Removing the values written by former versions of the Operator from %s CR spec", cheCluster.Name)
+		if err := UpdateCheCluster(deployContext, removeLegacySpecValues); err != nil {
+			return err
+		}
+	}
+
+	cheCluster.Status.LegacySpecValuesRemoved = true
+	return UpdateCheCRStatus(deployContext, "legacy spec values removed", "true")
+}
+
+func hasLegacySpecValues(cheCluster *orgv1.CheCluster) bool {
+	withoutLegacyValues := cheCluster.DeepCopy()
+	removeLegacySpecValues(withoutLegacyValues)
+	return !reflect.DeepEqual(cheCluster.Spec, withoutLegacyValues.Spec)
+}
+
+func removeLegacySpecValues(cheCluster *orgv1.CheCluster) {
+	imagePuller := &cheCluster.Spec.ImagePuller.Spec
+	if imagePuller.DeploymentName == DefaultImagePullerDeploymentName {
+		imagePuller.DeploymentName = ""
+	}
+	if imagePuller.ConfigMapName == DefaultImagePullerConfigMapName {
+		imagePuller.ConfigMapName = ""
+	}
+
+	// restored from the status by SetCheClusterStatusDefaults
+	auth := &cheCluster.Spec.Auth
+	if !auth.ExternalIdentityProvider && auth.IdentityProviderURL != "" && auth.IdentityProviderURL == cheCluster.Status.KeycloakURL {
+		auth.IdentityProviderURL = ""
+	}
+	if auth.OAuthClientName != "" && auth.OAuthClientName == cheCluster.Status.OpenShiftOAuthClientName {
+		auth.OAuthClientName = ""
+	}
+
+	// the names of the generated secrets, which are set by GenerateFields under the same conditions
+	if GetCheMultiUser(cheCluster) != "true" {
+		return
+	}
+	database := &cheCluster.Spec.Database
+	if database.ChePostgresSecret == DefaultChePostgresSecret() && (database.ChePostgresUser == "" || database.ChePostgresPassword == "") {
+		database.ChePostgresSecret = ""
+	}
+	if IsOpenIdConnectProviderEnabled(cheCluster) {
+		return
+	}
+	if auth.IdentityProviderPostgresSecret == DefaultCheIdentityPostgresSecret() && auth.IdentityProviderPostgresPassword == "" {
+		auth.IdentityProviderPostgresSecret = ""
+	}
+	if auth.IdentityProviderSecret == DefaultCheIdentitySecret() && (auth.IdentityProviderAdminUserName == "" || auth.IdentityProviderPassword == "") {
+		auth.IdentityProviderSecret = ""
+	}
+}
@@ -0,0 +1,183 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	chev1alpha1 "github.com/che-incubator/kubernetes-image-puller-operator/pkg/apis/che/v1alpha1"
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRemoveLegacySpecValues(t *testing.T) {
+	type testCase struct {
+		name         string
+		cheCluster   *orgv1.CheCluster
+		expectedSpec orgv1.CheClusterSpec
+	}
+
+	testCases := []testCase{
+		{
+			name: "Remove the values written by former versions of the Operator",
+			cheCluster: &orgv1.CheCluster{
+				Spec: orgv1.CheClusterSpec{
+					ImagePuller: orgv1.CheClusterSpecImagePuller{
+						Spec: chev1alpha1.KubernetesImagePullerSpec{
+							DeploymentName: DefaultImagePullerDeploymentName,
+							ConfigMapName:  DefaultImagePullerConfigMapName,
+						},
+					},
+					Database: orgv1.CheClusterSpecDB{
+						ChePostgresSecret: DefaultChePostgresSecret(),
+					},
+					Auth: orgv1.CheClusterSpecAuth{
+						IdentityProviderURL:            "https://keycloak.example.com",
+						OAuthClientName:                "che-oauth-client",
+						IdentityProviderPostgresSecret: DefaultCheIdentityPostgresSecret(),
+						IdentityProviderSecret:         DefaultCheIdentitySecret(),
+					},
+				},
+				Status: orgv1.CheClusterStatus{
+					CheVersion:               "7.30.0",
+					KeycloakURL:              "https://keycloak.example.com",
+					OpenShiftOAuthClientName: "che-oauth-client",
+				},
+			},
+			expectedSpec: orgv1.CheClusterSpec{},
+		},
+		{
+			name: "Keep the values set by a user",
+			cheCluster: &orgv1.CheCluster{
+				Spec: orgv1.CheClusterSpec{
+					ImagePuller: orgv1.CheClusterSpecImagePuller{
+						Spec: chev1alpha1.KubernetesImagePullerSpec{
+							DeploymentName: "image-puller",
+						},
+					},
+					Database: orgv1.CheClusterSpecDB{
+						ChePostgresSecret:   DefaultChePostgresSecret(),
+						ChePostgresUser:     "user",
+						ChePostgresPassword: "password",
+					},
+					Auth: orgv1.CheClusterSpecAuth{
+						IdentityProviderURL: "https://sso.example.com",
+						OAuthClientName:     "oauth-client",
+					},
+				},
+				Status: orgv1.CheClusterStatus{
+					CheVersion:               "7.30.0",
+					KeycloakURL:              "https://keycloak.example.com",
+					OpenShiftOAuthClientName: "che-oauth-client",
+				},
+			},
+			expectedSpec: orgv1.CheClusterSpec{
+				ImagePuller: orgv1.CheClusterSpecImagePuller{
+					Spec: chev1alpha1.KubernetesImagePullerSpec{
+						DeploymentName: "image-puller",
+					},
+				},
+				Database: orgv1.CheClusterSpecDB{
+					ChePostgresSecret:   DefaultChePostgresSecret(),
+					ChePostgresUser:     "user",
+					ChePostgresPassword: "password",
+				},
+				Auth: orgv1.CheClusterSpecAuth{
+					IdentityProviderURL: "https://sso.example.com",
+					OAuthClientName:     "oauth-client",
+				},
+			},
+		},
+		{
+			name: "Keep the values of a CheCluster which has never been deployed",
+			cheCluster: &orgv1.CheCluster{
+				Spec: orgv1.CheClusterSpec{
+					ImagePuller: orgv1.CheClusterSpecImagePuller{
+						Spec: chev1alpha1.KubernetesImagePullerSpec{
+							DeploymentName: DefaultImagePullerDeploymentName,
+						},
+					},
+				},
+			},
+			expectedSpec: orgv1.CheClusterSpec{
+				ImagePuller: orgv1.CheClusterSpecImagePuller{
+					Spec: chev1alpha1.KubernetesImagePullerSpec{
+						DeploymentName: DefaultImagePullerDeploymentName,
+					},
+				},
+			},
+		},
+		{
+			name: "Remove the values only once",
+			cheCluster: &orgv1.CheCluster{
+				Spec: orgv1.CheClusterSpec{
+					ImagePuller: orgv1.CheClusterSpecImagePuller{
+						Spec: chev1alpha1.KubernetesImagePullerSpec{
+							DeploymentName: DefaultImagePullerDeploymentName,
+						},
+					},
+				},
+				Status: orgv1.CheClusterStatus{
+					CheVersion:              "7.30.0",
+					LegacySpecValuesRemoved: true,
+				},
+			},
+			expectedSpec: orgv1.CheClusterSpec{
+				ImagePuller: orgv1.CheClusterSpecImagePuller{
+					Spec: chev1alpha1.KubernetesImagePullerSpec{
+						DeploymentName: DefaultImagePullerDeploymentName,
+					},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.cheCluster.ObjectMeta = metav1.ObjectMeta{Name: "eclipse-che", Namespace: "eclipse-che"}
+
+			orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+			cli := fake.NewFakeClientWithScheme(scheme.Scheme, testCase.cheCluster.DeepCopy())
+			deployContext := &DeployContext{
+				CheCluster: testCase.cheCluster,
+				ClusterAPI: ClusterAPI{
+					Client:          cli,
+					NonCachedClient: cli,
+					Scheme:          scheme.Scheme,
+				},
+			}
+
+			if err := RemoveLegacySpecValues(deployContext); err != nil {
+				t.Fatalf("Failed to remove legacy spec values: %v", err)
+			}
+
+			cheCluster := &orgv1.CheCluster{}
+			if err := cli.Get(context.TODO(), types.NamespacedName{Name: "eclipse-che", Namespace: "eclipse-che"}, cheCluster); err != nil {
+				t.Fatalf("Failed to get CheCluster: %v", err)
+			}
+			if !cheCluster.Status.LegacySpecValuesRemoved {
+				t.Error("Removal of the legacy spec values is not recorded in the status")
+			}
+			if !reflect.DeepEqual(cheCluster.Spec, testCase.expectedSpec) {
+				t.Errorf("Unexpected spec, expected: %+v, got: %+v", testCase.expectedSpec, cheCluster.Spec)
+			}
+			if !reflect.DeepEqual(deployContext.CheCluster.Spec, testCase.expectedSpec) {
+				t.Errorf("Unexpected spec in memory, expected: %+v, got: %+v", testCase.expectedSpec, deployContext.CheCluster.Spec)
+			}
+		})
+	}
+}
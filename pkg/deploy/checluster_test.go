@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"context"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// conflictingClient fails the given number of updates with a conflict,
+// as if the object was modified in the meantime.
+type conflictingClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if c.conflicts > 0 {
+		c.conflicts--
+		return errors.NewConflict(schema.GroupResource{Group: "org.eclipse.che", Resource: "checlusters"}, "eclipse-che", nil)
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestUpdateCheClusterRetriesOnConflict(t *testing.T) {
+	deployContext := getTestTLSDeployContext()
+	cli := &conflictingClient{Client: deployContext.ClusterAPI.Client, conflicts: 2}
+	deployContext.ClusterAPI.Client = cli
+
+	err := UpdateCheCluster(deployContext, func(cheCluster *orgv1.CheCluster) {
+		cheCluster.Annotations = map[string]string{CheEclipseOrgReconcile: "paused"}
+	})
+	if err != nil {
+		t.Fatalf("Failed to update the CheCluster: %v", err)
+	}
+	if cli.conflicts != 0 {
+		t.Errorf("Expected the update to be retried on conflicts")
+	}
+
+	cheCluster := &orgv1.CheCluster{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "eclipse-che", Namespace: "eclipse-che"}, cheCluster); err != nil {
+		t.Fatalf("Failed to get the CheCluster: %v", err)
+	}
+	if !IsReconcilePaused(cheCluster) || !IsReconcilePaused(deployContext.CheCluster) {
+		t.Errorf("Expected the CheCluster to be updated in the cluster and in memory")
+	}
+}
+
+func TestUpdateCheClusterFailsOnOtherErrors(t *testing.T) {
+	deployContext := getTestTLSDeployContext()
+	deployContext.CheCluster.Name = "unknown"
+
+	err := UpdateCheCluster(deployContext, func(cheCluster *orgv1.CheCluster) {})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected the not found error to be returned, got: %v", err)
+	}
+}
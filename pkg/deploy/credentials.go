@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// CredentialsMigratedEventReason is the reason of the event emitted when the credentials
+	// set in plain text in the CheCluster spec are moved into a Secret
+	CredentialsMigratedEventReason = "CredentialsMigrated"
+
+	// StrictCredentialsModeEnv enables refusing the passwords set in plain text in the CheCluster spec
+	StrictCredentialsModeEnv = "CHE_CREDENTIALS_STRICT_MODE"
+)
+
+// default user names used when the CheCluster spec contains the password only
+var defaultCredentialsUsers = map[string]string{
+	"postgres": DefaultChePostgresUser,
+	"identity": "admin",
+}
+
+// GetCredentialsSecretName returns the name of the Secret the credentials of the component are migrated to.
+func GetCredentialsSecretName(component string) string {
+	return "che-" + component + "-credentials"
+}
+
+// IsStrictCredentialsMode checks if the passwords set in plain text in the CheCluster spec are refused.
+func IsStrictCredentialsMode() bool {
+	strict, _ := strconv.ParseBool(os.Getenv(StrictCredentialsModeEnv))
+	return strict
+}
+
+// MigratePlaintextCredentials moves the passwords set in plain text in the CheCluster spec into Secrets.
+// Every Secret is referenced by the corresponding CheCluster field, and the plain text fields are emptied.
+// When the CheCluster already references a Secret, the Secret takes precedence and the plain text password
+// is only removed from the spec.
+// Returns the descriptions of the migrated fields.
+func MigratePlaintextCredentials(deployContext *DeployContext) ([]string, error) {
+	migrated := []string{}
+	for _, credentials := range orgv1.AllPlaintextCredentials {
+		credentials := credentials
+		secretName, user, password := credentials.Fields(deployContext.CheCluster)
+		if *password == "" {
+			continue
+		}
+
+		if *secretName == "" {
+			name := GetCredentialsSecretName(credentials.Component)
+			data := map[string][]byte{"password": []byte(*password)}
+			if user != nil {
+				userName := *user
+				if userName == "" {
+					userName = defaultCredentialsUsers[credentials.Component]
+				}
+				data["user"] = []byte(userName)
+			}
+
+			secret, err := GetSpecSecret(deployContext, name, deployContext.CheCluster.Namespace, data)
+			if err != nil {
+				return migrated, err
+			}
+			secret.ObjectMeta.Labels = GetLabels(deployContext.CheCluster, credentials.Component+"-credentials")
+			secret.ObjectMeta.Labels[KubernetesPartOfLabelKey] = CheEclipseOrg
+
+			done, err := Sync(deployContext, secret, secretDiffOpts)
+			if !done {
+				return migrated, err
+			}
+
+			logrus.Infof("Credentials from '%s' moved into the secret '%s'", credentials.PasswordPath, name)
+			RecordEventf(deployContext, corev1.EventTypeNormal, CredentialsMigratedEventReason, "Credentials from '%s' moved into the secret '%s'", credentials.PasswordPath, name)
+			migrated = append(migrated, fmt.Sprintf("%s -> %s", credentials.PasswordPath, name))
+
+			err = UpdateCheCluster(deployContext, func(cheCluster *orgv1.CheCluster) {
+				secretName, user, password := credentials.Fields(cheCluster)
+				*secretName = name
+				if user != nil {
+					*user = ""
+				}
+				*password = ""
+			})
+			if err != nil {
+				return migrated, err
+			}
+		} else {
+			logrus.Infof("Credentials from '%s' are ignored, the secret '%s' is used instead", credentials.PasswordPath, *secretName)
+			RecordEventf(deployContext, corev1.EventTypeNormal, CredentialsMigratedEventReason, "Credentials from '%s' are removed, the secret '%s' is used instead", credentials.PasswordPath, *secretName)
+			migrated = append(migrated, fmt.Sprintf("%s -> %s", credentials.PasswordPath, *secretName))
+
+			err := UpdateCheCluster(deployContext, func(cheCluster *orgv1.CheCluster) {
+				_, _, password := credentials.Fields(cheCluster)
+				*password = ""
+			})
+			if err != nil {
+				return migrated, err
+			}
+		}
+	}
+	return migrated, nil
+}
+
+// GetCredentialsRotationAnnotations returns the pod template annotations of the deployments which use
+// the credentials of the Operator-managed accounts, so the deployments are rolled out once credentials are rotated.
+// Returns nil if credentials have never been rotated.
+func GetCredentialsRotationAnnotations(cheCluster *orgv1.CheCluster) map[string]string {
+	if cheCluster.Status.LastCredentialsRotationTime == nil {
+		return nil
+	}
+	return map[string]string{
+		CheEclipseOrgCredentialsRotatedAt: cheCluster.Status.LastCredentialsRotationTime.UTC().Format(time.RFC3339),
+	}
+}
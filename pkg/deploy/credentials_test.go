@@ -0,0 +1,163 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMigratePlaintextCredentials(t *testing.T) {
+	cheCluster := &orgv1.CheCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CheCluster",
+			APIVersion: "org.eclipse.che/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "eclipse-che",
+			Namespace: "eclipse-che",
+		},
+		Spec: orgv1.CheClusterSpec{
+			Database: orgv1.CheClusterSpecDB{
+				ChePostgresPassword: "db-password",
+			},
+			Auth: orgv1.CheClusterSpecAuth{
+				IdentityProviderAdminUserName:    "keycloak-admin",
+				IdentityProviderPassword:         "keycloak-password",
+				IdentityProviderPostgresSecret:   "keycloak-postgres",
+				IdentityProviderPostgresPassword: "keycloak-postgres-password",
+			},
+		},
+	}
+
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, cheCluster.DeepCopy())
+	recorder := record.NewFakeRecorder(10)
+	deployContext := &DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: ClusterAPI{
+			Client:          cli,
+			NonCachedClient: cli,
+			Scheme:          scheme.Scheme,
+		},
+		EventRecorder: recorder,
+	}
+
+	migrated, err := MigratePlaintextCredentials(deployContext)
+	if err != nil {
+		t.Fatalf("Failed to migrate credentials: %v", err)
+	}
+	if len(migrated) != 3 {
+		t.Fatalf("Expected 3 migrated credentials, but got: %v", migrated)
+	}
+
+	expectedSecrets := map[string]map[string]string{
+		"che-postgres-credentials": {"user": DefaultChePostgresUser, "password": "db-password"},
+		"che-identity-credentials": {"user": "keycloak-admin", "password": "keycloak-password"},
+	}
+	for name, expectedData := range expectedSecrets {
+		secret := &corev1.Secret{}
+		if err := cli.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "eclipse-che"}, secret); err != nil {
+			t.Fatalf("Secret '%s' not found: %v", name, err)
+		}
+		for key, value := range expectedData {
+			if string(secret.Data[key]) != value {
+				t.Errorf("Secret '%s' is expected to contain '%s' for key '%s', but got '%s'", name, value, key, string(secret.Data[key]))
+			}
+		}
+		if secret.Labels[KubernetesPartOfLabelKey] != CheEclipseOrg {
+			t.Errorf("Secret '%s' is expected to be labeled as part of Che, but got labels: %v", name, secret.Labels)
+		}
+	}
+
+	// credentials are removed from the spec stored in the cluster as well as in memory
+	actual := &orgv1.CheCluster{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "eclipse-che", Namespace: "eclipse-che"}, actual); err != nil {
+		t.Fatalf("Failed to get CheCluster: %v", err)
+	}
+	for _, cr := range []*orgv1.CheCluster{actual, cheCluster} {
+		if cr.Spec.Database.ChePostgresSecret != "che-postgres-credentials" ||
+			cr.Spec.Database.ChePostgresPassword != "" {
+			t.Errorf("Unexpected database spec: %+v", cr.Spec.Database)
+		}
+		if cr.Spec.Auth.IdentityProviderSecret != "che-identity-credentials" ||
+			cr.Spec.Auth.IdentityProviderAdminUserName != "" ||
+			cr.Spec.Auth.IdentityProviderPassword != "" {
+			t.Errorf("Unexpected identity provider spec: %+v", cr.Spec.Auth)
+		}
+		// the secret set by a user is kept
+		if cr.Spec.Auth.IdentityProviderPostgresSecret != "keycloak-postgres" ||
+			cr.Spec.Auth.IdentityProviderPostgresPassword != "" {
+			t.Errorf("Unexpected identity provider postgres spec: %+v", cr.Spec.Auth)
+		}
+	}
+
+	close(recorder.Events)
+	events := 0
+	for event := range recorder.Events {
+		if strings.Contains(event, CredentialsMigratedEventReason) {
+			events++
+		}
+	}
+	if events != 3 {
+		t.Errorf("Expected 3 '%s' events, but got %d", CredentialsMigratedEventReason, events)
+	}
+
+	// nothing to migrate anymore
+	migrated, err = MigratePlaintextCredentials(deployContext)
+	if err != nil || len(migrated) != 0 {
+		t.Fatalf("Expected nothing to migrate, but got: %v, %v", migrated, err)
+	}
+}
+
+func TestMigratePlaintextCredentialsWithoutCredentials(t *testing.T) {
+	cheCluster := &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "eclipse-che",
+			Namespace: "eclipse-che",
+		},
+	}
+
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{cheCluster.DeepCopy()}...)
+	deployContext := &DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: ClusterAPI{
+			Client:          cli,
+			NonCachedClient: cli,
+			Scheme:          scheme.Scheme,
+		},
+	}
+
+	migrated, err := MigratePlaintextCredentials(deployContext)
+	if err != nil || len(migrated) != 0 {
+		t.Fatalf("Expected nothing to migrate, but got: %v, %v", migrated, err)
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := cli.List(context.TODO(), secrets); err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	if len(secrets.Items) != 0 {
+		t.Errorf("No secrets are expected, but got %d", len(secrets.Items))
+	}
+}
@@ -16,6 +16,7 @@ import (
 	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -31,6 +32,22 @@ type DeployContext struct {
 	Proxy           *Proxy
 	InternalService InternalService
 	DefaultCheHost  string
+	EventRecorder   record.EventRecorder
+	// CertManagerAvailable is set when the certificates of the endpoints can be issued by cert-manager
+	CertManagerAvailable bool
+	// GatewayAPIVersion is the version of the Gateway API served by the cluster, if any
+	GatewayAPIVersion string
+	// NetworkingV1Ingress is set when the cluster serves the networking.k8s.io/v1 Ingress API
+	NetworkingV1Ingress bool
+	// IngressClassName is the class set in the `ingressClassName` field of the networking.k8s.io/v1 ingresses,
+	// it is empty when the IngressClass doesn't exist in the cluster
+	IngressClassName string
+	// DryRun is set when the changes are only planned, the clients then record the writes instead of applying them,
+	// and the provisioning which doesn't go through the Kubernetes API is skipped
+	DryRun bool
+	// Offline is set when the objects are rendered by the `render` subcommand, the cluster is then never contacted
+	// and the platform is the one the objects are rendered for
+	Offline bool
 }
 
 type InternalService struct {
@@ -32,12 +32,13 @@ var (
 	defaultCheVersion                          string
 	defaultPluginRegistryImage                 string
 	defaultDevfileRegistryImage                string
-	defaultCheTLSSecretsCreationJobImage       string
 	defaultPvcJobsImage                        string
 	defaultPostgresImage                       string
 	defaultKeycloakImage                       string
 	defaultSingleHostGatewayImage              string
 	defaultSingleHostGatewayConfigSidecarImage string
+	defaultSingleHostGatewayOAuthProxyImage    string
+	defaultMaintenancePageImage                string
 
 	defaultCheWorkspacePluginBrokerMetadataImage  string
 	defaultCheWorkspacePluginBrokerArtifactsImage string
@@ -66,6 +67,7 @@ const (
 	DefaultCheVolumeMountPath      = "/data"
 	DefaultCheVolumeClaimName      = "che-data-volume"
 	DefaultPostgresVolumeClaimName = "postgres-data"
+	DefaultPostgresVolumeClaimSize = "1Gi"
 
 	DefaultJavaOpts          = "-XX:MaxRAMPercentage=85.0"
 	DefaultWorkspaceJavaOpts = "-XX:MaxRAM=150m -XX:MaxRAMFraction=2 -XX:+UseParallelGC " +
@@ -99,23 +101,36 @@ const (
 	KubernetesInstanceLabelKey  = "app.kubernetes.io/instance"
 	KubernetesNameLabelKey      = "app.kubernetes.io/name"
 
-	CheEclipseOrg         = "che.eclipse.org"
-	OAuthScmConfiguration = "oauth-scm-configuration"
+	CheEclipseOrg               = "che.eclipse.org"
+	OAuthScmConfiguration       = "oauth-scm-configuration"
+	IdentityBrokerConfiguration = "identity-broker-configuration"
 
 	// che.eclipse.org annotations
-	CheEclipseOrgMountPath              = "che.eclipse.org/mount-path"
-	CheEclipseOrgMountAs                = "che.eclipse.org/mount-as"
-	CheEclipseOrgEnvName                = "che.eclipse.org/env-name"
-	CheEclipseOrgNamespace              = "che.eclipse.org/namespace"
-	CheEclipseOrgGithubOAuthCredentials = "che.eclipse.org/github-oauth-credentials"
-	CheEclipseOrgOAuthScmServer         = "che.eclipse.org/oauth-scm-server"
-	CheEclipseOrgScmServerEndpoint      = "che.eclipse.org/scm-server-endpoint"
+	CheEclipseOrgMountPath                   = "che.eclipse.org/mount-path"
+	CheEclipseOrgMountAs                     = "che.eclipse.org/mount-as"
+	CheEclipseOrgEnvName                     = "che.eclipse.org/env-name"
+	CheEclipseOrgNamespace                   = "che.eclipse.org/namespace"
+	CheEclipseOrgGithubOAuthCredentials      = "che.eclipse.org/github-oauth-credentials"
+	CheEclipseOrgOAuthScmServer              = "che.eclipse.org/oauth-scm-server"
+	CheEclipseOrgScmServerEndpoint           = "che.eclipse.org/scm-server-endpoint"
+	CheEclipseOrgRotateCredentials           = "che.eclipse.org/rotate-credentials"
+	CheEclipseOrgCredentialsRotationInterval = "che.eclipse.org/credentials-rotation-interval"
+	CheEclipseOrgCredentialsRotatedAt        = "che.eclipse.org/credentials-rotated-at"
+	CheEclipseOrgTLSCertificatesHash         = "che.eclipse.org/tls-certificates-hash"
+	CheEclipseOrgIdentityBrokerType          = "che.eclipse.org/identity-broker-type"
+	CheEclipseOrgIdentityBrokerAlias         = "che.eclipse.org/identity-broker-alias"
+	CheEclipseOrgIdentityBrokerDisplayName   = "che.eclipse.org/identity-broker-display-name"
+	CheEclipseOrgGeneratedCertificate        = "che.eclipse.org/generated-certificate"
+	CheEclipseOrgDryRun                      = "che.eclipse.org/dry-run"
+	CheEclipseOrgReconcile                   = "che.eclipse.org/reconcile"
+	CheEclipseOrgMaintenanceMode             = "che.eclipse.org/maintenance-mode"
 
 	// components
 	IdentityProviderName = "keycloak"
 	DevfileRegistryName  = "devfile-registry"
 	PluginRegistryName   = "plugin-registry"
 	PostgresName         = "postgres"
+	MaintenancePageName  = "che-maintenance"
 
 	// limits
 	DefaultPluginRegistryMemoryLimit   = "256Mi"
@@ -143,7 +158,9 @@ const (
 	DefaultPostgresCpuLimit      = "500m"
 	DefaultPostgresCpuRequest    = "100m"
 
-	BitBucketOAuthConfigMountPath   = "/che-conf/oauth/bitbucket"
+	OAuthScmConfigMountPath         = "/che-conf/oauth"
+	OAuthScmConfigClientId          = "id"
+	OAuthScmConfigClientSecret      = "secret"
 	BitBucketOAuthConfigPrivateKey  = "private.key"
 	BitBucketOAuthConfigConsumerKey = "consumer.key"
 )
@@ -168,13 +185,14 @@ func InitDefaultsFromFile(defaultsPath string) {
 	defaultKeycloakImage = util.GetDeploymentEnv(operatorDeployment, util.GetArchitectureDependentEnv("RELATED_IMAGE_keycloak"))
 	defaultSingleHostGatewayImage = util.GetDeploymentEnv(operatorDeployment, util.GetArchitectureDependentEnv("RELATED_IMAGE_single_host_gateway"))
 	defaultSingleHostGatewayConfigSidecarImage = util.GetDeploymentEnv(operatorDeployment, util.GetArchitectureDependentEnv("RELATED_IMAGE_single_host_gateway_config_sidecar"))
+	defaultSingleHostGatewayOAuthProxyImage = util.GetDeploymentEnv(operatorDeployment, util.GetArchitectureDependentEnv("RELATED_IMAGE_single_host_gateway_oauth_proxy"))
+	defaultMaintenancePageImage = util.GetDeploymentEnv(operatorDeployment, util.GetArchitectureDependentEnv("RELATED_IMAGE_che_maintenance_page"))
 	defaultCheWorkspacePluginBrokerMetadataImage = util.GetDeploymentEnv(operatorDeployment, util.GetArchitectureDependentEnv("RELATED_IMAGE_che_workspace_plugin_broker_metadata"))
 	defaultCheWorkspacePluginBrokerArtifactsImage = util.GetDeploymentEnv(operatorDeployment, util.GetArchitectureDependentEnv("RELATED_IMAGE_che_workspace_plugin_broker_artifacts"))
 	defaultCheServerSecureExposerJwtProxyImage = util.GetDeploymentEnv(operatorDeployment, util.GetArchitectureDependentEnv("RELATED_IMAGE_che_server_secure_exposer_jwt_proxy_image"))
 
 	// Don't get some k8s specific env
 	if !util.IsOpenShift {
-		defaultCheTLSSecretsCreationJobImage = util.GetDeploymentEnv(operatorDeployment, util.GetArchitectureDependentEnv("RELATED_IMAGE_che_tls_secrets_creation_job"))
 	}
 }
 
@@ -261,10 +279,6 @@ func DefaultCheServerImage(cr *orgv1.CheCluster) string {
 	return patchDefaultImageName(cr, defaultCheServerImage)
 }
 
-func DefaultCheTLSSecretsCreationJobImage() string {
-	return defaultCheTLSSecretsCreationJobImage
-}
-
 func DefaultPvcJobsImage(cr *orgv1.CheCluster) string {
 	return patchDefaultImageName(cr, defaultPvcJobsImage)
 }
@@ -305,6 +319,14 @@ func DefaultSingleHostGatewayConfigSidecarImage(cr *orgv1.CheCluster) string {
 	return patchDefaultImageName(cr, defaultSingleHostGatewayConfigSidecarImage)
 }
 
+func DefaultSingleHostGatewayOAuthProxyImage(cr *orgv1.CheCluster) string {
+	return patchDefaultImageName(cr, defaultSingleHostGatewayOAuthProxyImage)
+}
+
+func DefaultMaintenancePageImage(cr *orgv1.CheCluster) string {
+	return patchDefaultImageName(cr, defaultMaintenancePageImage)
+}
+
 func DefaultKubernetesImagePullerOperatorCSV() string {
 	return KubernetesImagePullerOperatorCSV
 }
@@ -331,6 +353,25 @@ func GetCheMultiUser(cr *orgv1.CheCluster) string {
 	return DefaultCheMultiUser
 }
 
+// IsOpenIdConnectProviderEnabled indicates whether Che users are authenticated
+// by a generic OpenID Connect provider instead of Keycloak.
+func IsOpenIdConnectProviderEnabled(cr *orgv1.CheCluster) bool {
+	return GetCheMultiUser(cr) == "true" && cr.Spec.Auth.OpenIdConnect != nil
+}
+
+// DetectOpenShift detects the platform of the cluster, unless the objects are rendered offline for a given platform.
+func DetectOpenShift(deployContext *DeployContext) (isOpenShift bool, isOpenShift4 bool, err error) {
+	if deployContext.Offline {
+		return util.IsOpenShift, util.IsOpenShift4, nil
+	}
+	return util.DetectOpenShift()
+}
+
+// IsKeycloakDeployed indicates whether Keycloak and its database are deployed by the Operator.
+func IsKeycloakDeployed(cr *orgv1.CheCluster) bool {
+	return GetCheMultiUser(cr) == "true" && !cr.Spec.Auth.ExternalIdentityProvider && !IsOpenIdConnectProviderEnabled(cr)
+}
+
 func GetSingleHostExposureType(cr *orgv1.CheCluster) string {
 	if util.IsOpenShift {
 		return DefaultOpenShiftSingleHostExposureType
@@ -406,6 +447,8 @@ func InitDefaultsFromEnv() {
 	defaultKeycloakImage = getDefaultFromEnv(util.GetArchitectureDependentEnv("RELATED_IMAGE_keycloak"))
 	defaultSingleHostGatewayImage = getDefaultFromEnv(util.GetArchitectureDependentEnv("RELATED_IMAGE_single_host_gateway"))
 	defaultSingleHostGatewayConfigSidecarImage = getDefaultFromEnv(util.GetArchitectureDependentEnv("RELATED_IMAGE_single_host_gateway_config_sidecar"))
+	defaultSingleHostGatewayOAuthProxyImage = getDefaultFromEnv(util.GetArchitectureDependentEnv("RELATED_IMAGE_single_host_gateway_oauth_proxy"))
+	defaultMaintenancePageImage = getDefaultFromEnv(util.GetArchitectureDependentEnv("RELATED_IMAGE_che_maintenance_page"))
 
 	// CRW images for that are mentioned in the Che server che.properties
 	// For CRW these should be synced by hand with images stored in RH registries
@@ -416,7 +459,6 @@ func InitDefaultsFromEnv() {
 
 	// Don't get some k8s specific env
 	if !util.IsOpenShift {
-		defaultCheTLSSecretsCreationJobImage = getDefaultFromEnv(util.GetArchitectureDependentEnv("RELATED_IMAGE_che_tls_secrets_creation_job"))
 	}
 }
 
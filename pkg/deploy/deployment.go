@@ -65,6 +65,7 @@ func SyncDeploymentToCluster(
 	if clusterDeployment == nil {
 		logrus.Infof("Creating a new object: %s, name %s", specDeployment.Kind, specDeployment.Name)
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specDeployment)
+		recordCreateEvent(deployContext, specDeployment, specDeployment.Name, err == nil, err)
 		return false, err
 	}
 
@@ -75,9 +76,11 @@ func SyncDeploymentToCluster(
 		diff := cmp.Diff(clusterDeployment, specDeployment, additionalDeploymentDiffOpts)
 		if len(diff) > 0 {
 			logrus.Infof("Updating existing object: %s, name: %s", specDeployment.Kind, specDeployment.Name)
-			fmt.Printf("Difference:\n%s", diff)
+			logrus.Infof("Difference:\n%s", diff)
+			changedFields := getChangedFields(clusterDeployment, specDeployment, additionalDeploymentDiffOpts)
 			clusterDeployment = additionalDeploymentMerge(specDeployment, clusterDeployment)
 			err := deployContext.ClusterAPI.Client.Update(context.TODO(), clusterDeployment)
+			recordUpdateEvent(deployContext, clusterDeployment, clusterDeployment.Name, changedFields, err == nil, err)
 			return false, err
 		}
 	}
@@ -85,9 +88,11 @@ func SyncDeploymentToCluster(
 	diff := cmp.Diff(clusterDeployment, specDeployment, DeploymentDiffOpts)
 	if len(diff) > 0 {
 		logrus.Infof("Updating existed object: %s, name: %s", specDeployment.Kind, specDeployment.Name)
-		fmt.Printf("Difference:\n%s", diff)
+		logrus.Infof("Difference:\n%s", diff)
+		changedFields := getChangedFields(clusterDeployment, specDeployment, DeploymentDiffOpts)
 		clusterDeployment.Spec = specDeployment.Spec
 		err := deployContext.ClusterAPI.Client.Update(context.TODO(), clusterDeployment)
+		recordUpdateEvent(deployContext, clusterDeployment, clusterDeployment.Name, changedFields, err == nil, err)
 		return false, err
 	}
 
@@ -0,0 +1,327 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	// DryRunPlanConfigMapName is the name of the config map which holds the summary of the changes planned by a dry run
+	DryRunPlanConfigMapName = "che-dry-run-plan"
+
+	PlannedCreate = "create"
+	PlannedUpdate = "update"
+	PlannedDelete = "delete"
+)
+
+// IsDryRun returns true if the changes to the cluster are only to be planned, not applied.
+// It is requested with the `che.eclipse.org/dry-run: "true"` annotation of the CheCluster.
+func IsDryRun(cheCluster *orgv1.CheCluster) bool {
+	return cheCluster.ObjectMeta.Annotations[CheEclipseOrgDryRun] == "true"
+}
+
+// PlannedChange is a change to an object the Operator would make.
+type PlannedChange struct {
+	Action    string
+	Kind      string
+	Namespace string
+	Name      string
+	// Restart is set when the pods of an updated workload would be restarted
+	Restart bool
+}
+
+func (c *PlannedChange) String() string {
+	if c.Namespace == "" {
+		return fmt.Sprintf("%s %s", c.Kind, c.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", c.Kind, c.Namespace, c.Name)
+}
+
+// DryRunPlan records the changes made through the clients it wraps, instead of applying them.
+// The changed objects are read back from the plan, as if the changes were applied.
+type DryRunPlan struct {
+	scheme  *runtime.Scheme
+	changes []*PlannedChange
+	keys    []string
+	byKey   map[string]*PlannedChange
+	// planned state of the changed objects, nil for the deleted ones
+	objects map[string]runtime.Object
+	errors  []string
+}
+
+func NewDryRunPlan(scheme *runtime.Scheme) *DryRunPlan {
+	return &DryRunPlan{
+		scheme:  scheme,
+		byKey:   map[string]*PlannedChange{},
+		objects: map[string]runtime.Object{},
+	}
+}
+
+// WrapClient returns a client which reads through the given one, and records the writes in the plan.
+func (p *DryRunPlan) WrapClient(delegate client.Client) client.Client {
+	return &dryRunClient{delegate: delegate, plan: p}
+}
+
+// AddError records that the changes to the component can't be fully planned.
+func (p *DryRunPlan) AddError(component string, err error) {
+	p.errors = append(p.errors, fmt.Sprintf("%s: %v", component, err))
+}
+
+// Changes returns the planned changes, in the order the objects were first changed.
+func (p *DryRunPlan) Changes() []PlannedChange {
+	changes := []PlannedChange{}
+	for _, change := range p.changes {
+		if change.Action != "" {
+			changes = append(changes, *change)
+		}
+	}
+	return changes
+}
+
+// Objects returns the planned state of the created and updated objects, in the order they were first changed.
+func (p *DryRunPlan) Objects() []runtime.Object {
+	objects := []runtime.Object{}
+	for i, change := range p.changes {
+		if change.Action == PlannedCreate || change.Action == PlannedUpdate {
+			objects = append(objects, p.objects[p.keys[i]].DeepCopyObject())
+		}
+	}
+	return objects
+}
+
+// ResetErrors forgets the recorded errors, before the components are planned again.
+func (p *DryRunPlan) ResetErrors() {
+	p.errors = nil
+}
+
+// Errors returns the errors of the components whose changes failed to be planned.
+func (p *DryRunPlan) Errors() []string {
+	return p.errors
+}
+
+// Summary returns a one line summary of the plan, for instance
+// `1 object(s) to create, 2 to update, 0 to delete, 1 workload(s) to restart`.
+func (p *DryRunPlan) Summary() string {
+	counts := map[string]int{}
+	restarts := 0
+	for _, change := range p.Changes() {
+		counts[change.Action]++
+		if change.Restart {
+			restarts++
+		}
+	}
+	return fmt.Sprintf("%d object(s) to create, %d to update, %d to delete, %d workload(s) to restart",
+		counts[PlannedCreate], counts[PlannedUpdate], counts[PlannedDelete], restarts)
+}
+
+// ConfigMapData returns the content of the config map holding the plan.
+// The changed objects are listed one per line, the values of the objects are never disclosed.
+func (p *DryRunPlan) ConfigMapData() map[string]string {
+	objects := map[string][]string{}
+	restarts := []string{}
+	for _, change := range p.Changes() {
+		objects[change.Action] = append(objects[change.Action], change.String())
+		if change.Restart {
+			restarts = append(restarts, change.String())
+		}
+	}
+
+	return map[string]string{
+		"summary": p.Summary(),
+		"create":  strings.Join(objects[PlannedCreate], "\n"),
+		"update":  strings.Join(objects[PlannedUpdate], "\n"),
+		"delete":  strings.Join(objects[PlannedDelete], "\n"),
+		"restart": strings.Join(restarts, "\n"),
+		"errors":  strings.Join(p.errors, "\n"),
+	}
+}
+
+func (p *DryRunPlan) record(action string, obj runtime.Object, restart bool) error {
+	gvk, err := apiutil.GVKForObject(obj, p.scheme)
+	if err != nil {
+		return err
+	}
+	meta, ok := obj.(metav1.Object)
+	if !ok {
+		return fmt.Errorf("object %T is not a metav1.Object. Cannot plan it", obj)
+	}
+
+	key := getDryRunKey(gvk, meta.GetNamespace(), meta.GetName())
+	if action == PlannedDelete {
+		p.objects[key] = nil
+	} else {
+		p.objects[key] = obj.DeepCopyObject()
+	}
+
+	change, exists := p.byKey[key]
+	if !exists {
+		change = &PlannedChange{Action: action, Kind: gvk.Kind, Namespace: meta.GetNamespace(), Name: meta.GetName()}
+		p.changes = append(p.changes, change)
+		p.keys = append(p.keys, key)
+		p.byKey[key] = change
+	} else {
+		change.Action = mergePlannedActions(change.Action, action)
+	}
+	change.Restart = change.Restart || restart
+
+	logrus.Infof("Dry run, not applied: %s %s", action, change.String())
+	return nil
+}
+
+// get reads the object from the plan, if it has been changed.
+// Returns false if the object hasn't been changed.
+func (p *DryRunPlan) get(key client.ObjectKey, obj runtime.Object) (bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, p.scheme)
+	if err != nil {
+		return false, err
+	}
+
+	planned, exists := p.objects[getDryRunKey(gvk, key.Namespace, key.Name)]
+	if !exists {
+		return false, nil
+	}
+	if planned == nil {
+		return true, errors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}, key.Name)
+	}
+	return true, copyObject(planned, obj)
+}
+
+// mergePlannedActions returns the action equivalent to the two successive ones on the same object.
+// An empty action means there is nothing to change.
+func mergePlannedActions(previous string, next string) string {
+	switch {
+	case previous == PlannedCreate && next == PlannedDelete:
+		return ""
+	case previous == PlannedCreate:
+		return PlannedCreate
+	case previous == PlannedDelete && next == PlannedCreate:
+		// services, ingresses and routes are updated by recreating them
+		return PlannedUpdate
+	default:
+		return next
+	}
+}
+
+func getDryRunKey(gvk schema.GroupVersionKind, namespace string, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", gvk.Group, gvk.Kind, namespace, name)
+}
+
+// copyObject copies the content of an object into another one of the same kind,
+// which may be a typed or an unstructured one.
+func copyObject(from runtime.Object, to runtime.Object) error {
+	if reflect.TypeOf(from) == reflect.TypeOf(to) {
+		reflect.ValueOf(to).Elem().Set(reflect.ValueOf(from.DeepCopyObject()).Elem())
+		return nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
+	if err != nil {
+		return err
+	}
+	if u, ok := to.(*unstructured.Unstructured); ok {
+		u.SetUnstructuredContent(content)
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(content, to)
+}
+
+// dryRunClient reads the objects from the plan or the delegate client, and records the writes in the plan.
+type dryRunClient struct {
+	delegate client.Client
+	plan     *DryRunPlan
+}
+
+func (c *dryRunClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	planned, err := c.plan.get(key, obj)
+	if planned || err != nil {
+		return err
+	}
+	return c.delegate.Get(ctx, key, obj)
+}
+
+func (c *dryRunClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	return c.delegate.List(ctx, list, opts...)
+}
+
+func (c *dryRunClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	return c.plan.record(PlannedCreate, obj, false)
+}
+
+func (c *dryRunClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	return c.plan.record(PlannedDelete, obj, false)
+}
+
+func (c *dryRunClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	restart, err := c.isRestartNeeded(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return c.plan.record(PlannedUpdate, obj, restart)
+}
+
+func (c *dryRunClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return c.plan.record(PlannedUpdate, obj, false)
+}
+
+func (c *dryRunClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	return fmt.Errorf("deleting all the objects of a kind can't be planned")
+}
+
+// Status returns a writer which ignores the updates, the status isn't part of the plan.
+func (c *dryRunClient) Status() client.StatusWriter {
+	return &dryRunStatusWriter{}
+}
+
+// isRestartNeeded returns true if the update of the object changes the template of the pods of a deployment.
+func (c *dryRunClient) isRestartNeeded(ctx context.Context, obj runtime.Object) (bool, error) {
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return false, nil
+	}
+
+	actual := &appsv1.Deployment{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: deployment.Namespace, Name: deployment.Name}, actual); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	// the fields which are not set in the spec are defaulted by the cluster
+	return !equality.Semantic.DeepDerivative(deployment.Spec.Template, actual.Spec.Template), nil
+}
+
+type dryRunStatusWriter struct{}
+
+func (w *dryRunStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return nil
+}
+
+func (w *dryRunStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return nil
+}
@@ -0,0 +1,141 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsDryRun(t *testing.T) {
+	cheCluster := &orgv1.CheCluster{}
+	if IsDryRun(cheCluster) {
+		t.Fatalf("Dry run is expected to be disabled by default")
+	}
+
+	cheCluster.ObjectMeta.Annotations = map[string]string{CheEclipseOrgDryRun: "true"}
+	if !IsDryRun(cheCluster) {
+		t.Fatalf("Dry run is expected to be enabled by the annotation")
+	}
+}
+
+func TestDryRunPlan(t *testing.T) {
+	newDeployment := func(name string, image string, labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "eclipse-che", Labels: labels},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: name, Image: image}},
+					},
+				},
+			},
+		}
+	}
+
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(
+		scheme.Scheme,
+		newDeployment("che", "che:1", nil),
+		newDeployment("gateway", "gateway:1", nil),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "obsolete", Namespace: "eclipse-che"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "che-host", Namespace: "eclipse-che"}},
+	)
+	plan := NewDryRunPlan(scheme.Scheme)
+	dryRunClient := plan.WrapClient(cli)
+	ctx := context.TODO()
+
+	// create an object which is read back from the plan
+	if err := dryRunClient.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "che", Namespace: "eclipse-che"}}); err != nil {
+		t.Fatalf("Failed to create config map: %v", err)
+	}
+	if err := dryRunClient.Get(ctx, client.ObjectKey{Name: "che", Namespace: "eclipse-che"}, &corev1.ConfigMap{}); err != nil {
+		t.Fatalf("Created config map is expected to be read back: %v", err)
+	}
+
+	// update the template of the pods, and only the labels of the deployments
+	if err := dryRunClient.Update(ctx, newDeployment("che", "che:2", nil)); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+	if err := dryRunClient.Update(ctx, newDeployment("gateway", "gateway:1", map[string]string{"a": "b"})); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+
+	// delete an object which is not read back anymore
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "obsolete", Namespace: "eclipse-che"}}
+	if err := dryRunClient.Delete(ctx, secret); err != nil {
+		t.Fatalf("Failed to delete secret: %v", err)
+	}
+	if err := dryRunClient.Get(ctx, client.ObjectKey{Name: "obsolete", Namespace: "eclipse-che"}, &corev1.Secret{}); err == nil {
+		t.Fatalf("Deleted secret is not expected to be read back")
+	}
+
+	// recreate an object, and create then delete another one
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "che-host", Namespace: "eclipse-che"}}
+	dryRunClient.Delete(ctx, service)
+	dryRunClient.Create(ctx, service)
+	temporary := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "temporary", Namespace: "eclipse-che"}}
+	dryRunClient.Create(ctx, temporary)
+	dryRunClient.Delete(ctx, temporary)
+
+	// status isn't part of the plan
+	if err := dryRunClient.Status().Update(ctx, newDeployment("che", "che:3", nil)); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+
+	expectedChanges := []PlannedChange{
+		{Action: PlannedCreate, Kind: "ConfigMap", Namespace: "eclipse-che", Name: "che"},
+		{Action: PlannedUpdate, Kind: "Deployment", Namespace: "eclipse-che", Name: "che", Restart: true},
+		{Action: PlannedUpdate, Kind: "Deployment", Namespace: "eclipse-che", Name: "gateway"},
+		{Action: PlannedDelete, Kind: "Secret", Namespace: "eclipse-che", Name: "obsolete"},
+		{Action: PlannedUpdate, Kind: "Service", Namespace: "eclipse-che", Name: "che-host"},
+	}
+	if changes := plan.Changes(); !reflect.DeepEqual(changes, expectedChanges) {
+		t.Fatalf("Expected changes %v, but got %v", expectedChanges, changes)
+	}
+
+	expectedData := map[string]string{
+		"summary": "1 object(s) to create, 3 to update, 1 to delete, 1 workload(s) to restart",
+		"create":  "ConfigMap eclipse-che/che",
+		"update":  "Deployment eclipse-che/che\nDeployment eclipse-che/gateway\nService eclipse-che/che-host",
+		"delete":  "Secret eclipse-che/obsolete",
+		"restart": "Deployment eclipse-che/che",
+		"errors":  "",
+	}
+	if data := plan.ConfigMapData(); !reflect.DeepEqual(data, expectedData) {
+		t.Fatalf("Expected config map data %v, but got %v", expectedData, data)
+	}
+
+	// nothing is applied to the cluster
+	if err := cli.Get(ctx, client.ObjectKey{Name: "che", Namespace: "eclipse-che"}, &corev1.ConfigMap{}); err == nil {
+		t.Fatalf("Config map is not expected to be created")
+	}
+	if err := cli.Get(ctx, client.ObjectKey{Name: "obsolete", Namespace: "eclipse-che"}, &corev1.Secret{}); err != nil {
+		t.Fatalf("Secret is not expected to be deleted: %v", err)
+	}
+	deployment := &appsv1.Deployment{}
+	if err := cli.Get(ctx, client.ObjectKey{Name: "che", Namespace: "eclipse-che"}, deployment); err != nil {
+		t.Fatalf("Failed to get deployment: %v", err)
+	}
+	if deployment.Spec.Template.Spec.Containers[0].Image != "che:1" {
+		t.Fatalf("Deployment is not expected to be updated")
+	}
+}
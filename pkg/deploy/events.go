@@ -0,0 +1,203 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-che/che-operator/pkg/metrics"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Reasons of the events emitted when syncing objects to the cluster
+const (
+	ObjectCreatedEventReason      = "ObjectCreated"
+	ObjectUpdatedEventReason      = "ObjectUpdated"
+	ObjectDeletedEventReason      = "ObjectDeleted"
+	ObjectCreateFailedEventReason = "ObjectCreateFailed"
+	ObjectUpdateFailedEventReason = "ObjectUpdateFailed"
+	ObjectDeleteFailedEventReason = "ObjectDeleteFailed"
+)
+
+const (
+	// Event message size is limited, so too long lists of changed fields are truncated
+	maxEventDiffLength = 1000
+)
+
+// RecordEvent emits an event regarding the CheCluster custom resource.
+// Does nothing if the event recorder is not configured.
+func RecordEvent(deployContext *DeployContext, eventType string, reason string, message string) {
+	if deployContext.EventRecorder == nil || deployContext.CheCluster == nil {
+		return
+	}
+	deployContext.EventRecorder.Event(deployContext.CheCluster, eventType, reason, message)
+}
+
+// RecordEventf is just like RecordEvent, but with Sprintf for the message field.
+func RecordEventf(deployContext *DeployContext, eventType string, reason string, messageFmt string, args ...interface{}) {
+	RecordEvent(deployContext, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func recordObjectEvent(deployContext *DeployContext, object runtime.Object, name string, reason string, details string) {
+	eventType := corev1.EventTypeNormal
+	action := ""
+	switch reason {
+	case ObjectCreatedEventReason:
+		action = "Created"
+	case ObjectUpdatedEventReason:
+		action = "Updated"
+	case ObjectDeletedEventReason:
+		action = "Deleted"
+	case ObjectCreateFailedEventReason:
+		eventType, action = corev1.EventTypeWarning, "Failed to create"
+	case ObjectUpdateFailedEventReason:
+		eventType, action = corev1.EventTypeWarning, "Failed to update"
+	case ObjectDeleteFailedEventReason:
+		eventType, action = corev1.EventTypeWarning, "Failed to delete"
+	}
+
+	message := fmt.Sprintf("%s %s '%s'", action, getObjectKind(deployContext, object), name)
+	if details != "" {
+		message = fmt.Sprintf("%s: %s", message, details)
+	}
+	RecordEvent(deployContext, eventType, reason, message)
+}
+
+func recordCreateEvent(deployContext *DeployContext, object runtime.Object, name string, done bool, err error) {
+	if err != nil {
+		recordObjectEvent(deployContext, object, name, ObjectCreateFailedEventReason, err.Error())
+	} else if done {
+		recordObjectEvent(deployContext, object, name, ObjectCreatedEventReason, "")
+		recordObjectChange(deployContext, object, metrics.ObjectCreated)
+	}
+}
+
+// recordUpdateEvent records the update of the object, changedFields is the list of the paths of the changed fields,
+// see getChangedFields.
+func recordUpdateEvent(deployContext *DeployContext, object runtime.Object, name string, changedFields string, done bool, err error) {
+	if err != nil {
+		recordObjectEvent(deployContext, object, name, ObjectUpdateFailedEventReason, err.Error())
+	} else if done {
+		// don't disclose sensitive data, even the names of the keys
+		if getObjectKind(deployContext, object) == "Secret" {
+			changedFields = ""
+		}
+		recordObjectEvent(deployContext, object, name, ObjectUpdatedEventReason, getDiffSummary(changedFields))
+		recordObjectChange(deployContext, object, metrics.ObjectUpdated)
+	}
+}
+
+func recordDeleteEvent(deployContext *DeployContext, object runtime.Object, name string, err error) {
+	if err != nil && !errors.IsNotFound(err) {
+		recordObjectEvent(deployContext, object, name, ObjectDeleteFailedEventReason, err.Error())
+	} else if err == nil {
+		recordObjectEvent(deployContext, object, name, ObjectDeletedEventReason, "")
+		recordObjectChange(deployContext, object, metrics.ObjectDeleted)
+	}
+}
+
+// recordObjectChange counts the change in the metrics, unless it is only planned
+func recordObjectChange(deployContext *DeployContext, object runtime.Object, action string) {
+	if !deployContext.DryRun {
+		metrics.RecordObjectChange(getObjectKind(deployContext, object), action)
+	}
+}
+
+// getObjectKind returns the kind of the object even if its TypeMeta is not set
+func getObjectKind(deployContext *DeployContext, object runtime.Object) string {
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" && deployContext.ClusterAPI.Scheme != nil {
+		if gvk, err := apiutil.GVKForObject(object, deployContext.ClusterAPI.Scheme); err == nil {
+			kind = gvk.Kind
+		}
+	}
+	return kind
+}
+
+// getDiffSummary returns the changed fields shortened to fit an event message
+func getDiffSummary(changedFields string) string {
+	if len(changedFields) > maxEventDiffLength {
+		return changedFields[:maxEventDiffLength] + "..."
+	}
+	return changedFields
+}
+
+// getChangedFields returns the comma separated paths of the fields which differ between the objects.
+// The values aren't included, since they may be sensitive, like the passwords in the environment
+// variables of a deployment.
+func getChangedFields(x interface{}, y interface{}, opts ...cmp.Option) string {
+	reporter := &changedFieldsReporter{fields: map[string]bool{}}
+	cmp.Equal(x, y, append(opts, cmp.Reporter(reporter))...)
+
+	fields := make([]string, 0, len(reporter.fields))
+	for field := range reporter.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, ", ")
+}
+
+// changedFieldsReporter collects the paths of the unequal fields reported by cmp.Equal
+type changedFieldsReporter struct {
+	path   cmp.Path
+	fields map[string]bool
+}
+
+func (r *changedFieldsReporter) PushStep(step cmp.PathStep) {
+	r.path = append(r.path, step)
+}
+
+func (r *changedFieldsReporter) Report(result cmp.Result) {
+	if !result.Equal() {
+		r.fields[formatFieldPath(r.path)] = true
+	}
+}
+
+func (r *changedFieldsReporter) PopStep() {
+	r.path = r.path[:len(r.path)-1]
+}
+
+// formatFieldPath formats the path like `Spec.Template.Spec.Containers[0].Env[1].Value`
+func formatFieldPath(path cmp.Path) string {
+	var formatted strings.Builder
+	for _, step := range path {
+		switch step := step.(type) {
+		case cmp.StructField:
+			if formatted.Len() > 0 {
+				formatted.WriteString(".")
+			}
+			formatted.WriteString(step.Name())
+		case cmp.SliceIndex:
+			index := step.Key()
+			if index < 0 {
+				// the element is either added or removed
+				xIndex, yIndex := step.SplitKeys()
+				if index = xIndex; index < 0 {
+					index = yIndex
+				}
+			}
+			fmt.Fprintf(&formatted, "[%d]", index)
+		case cmp.MapIndex:
+			fmt.Fprintf(&formatted, "[%v]", step.Key())
+		}
+	}
+	if formatted.Len() == 0 {
+		return "."
+	}
+	return formatted.String()
+}
@@ -72,6 +72,26 @@ func Expose(
 			if err := deploy.DeleteIngressIfExists(endpointName, deployContext); !util.IsTestMode() && err != nil {
 				logrus.Error(err)
 			}
+			if err := deploy.DeleteHTTPRouteIfExists(endpointName, deployContext); !util.IsTestMode() && err != nil {
+				logrus.Error(err)
+			}
+		} else if deploy.IsGatewayAPIUsed(deployContext) {
+			route, err := deploy.SyncHTTPRouteToCluster(deployContext, endpointName, domain, endpointName, 8080, component)
+			if !util.IsTestMode() {
+				if route == nil {
+					logrus.Infof("Waiting on HTTP route '%s' to be ready", endpointName)
+					if err != nil {
+						logrus.Error(err)
+					}
+					return "", false, err
+				}
+			}
+			if err := deploy.DeleteIngressIfExists(endpointName, deployContext); !util.IsTestMode() && err != nil {
+				logrus.Error(err)
+			}
+			if err := gateway.DeleteGatewayRouteConfig(gatewayConfig, deployContext); !util.IsTestMode() && err != nil {
+				logrus.Error(err)
+			}
 		} else {
 			ingress, err := deploy.SyncIngressToCluster(deployContext, endpointName, domain, endpointName, 8080, ingressCustomSettings, component)
 			if !util.IsTestMode() {
@@ -86,6 +106,19 @@ func Expose(
 			if err := gateway.DeleteGatewayRouteConfig(gatewayConfig, deployContext); !util.IsTestMode() && err != nil {
 				logrus.Error(err)
 			}
+			if err := deploy.DeleteHTTPRouteIfExists(endpointName, deployContext); !util.IsTestMode() && err != nil {
+				logrus.Error(err)
+			}
+
+			done, err := deploy.SyncCertManagerCertificate(deployContext, endpointName, domain)
+			if !util.IsTestMode() {
+				if !done {
+					if err != nil {
+						logrus.Error(err)
+					}
+					return "", false, err
+				}
+			}
 		}
 	} else {
 		if useGateway {
@@ -117,6 +150,14 @@ func Expose(
 				logrus.Error(err)
 			}
 
+			done, err := deploy.SyncCertManagerCertificate(deployContext, endpointName, route.Spec.Host)
+			if !done {
+				if err != nil {
+					logrus.Error(err)
+				}
+				return "", false, err
+			}
+
 			endpoint = route.Spec.Host
 		}
 	}
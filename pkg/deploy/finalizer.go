@@ -12,32 +12,26 @@
 package deploy
 
 import (
-	"context"
 
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"github.com/eclipse-che/che-operator/pkg/util"
 	"github.com/sirupsen/logrus"
-	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func AppendFinalizer(deployContext *DeployContext, finalizer string) error {
 	if !util.ContainsString(deployContext.CheCluster.ObjectMeta.Finalizers, finalizer) {
-		deployContext.CheCluster.ObjectMeta.Finalizers = append(deployContext.CheCluster.ObjectMeta.Finalizers, finalizer)
-		for {
-			err := deployContext.ClusterAPI.Client.Update(context.TODO(), deployContext.CheCluster)
-			if err == nil {
-				logrus.Infof("Added finalizer: %s", finalizer)
-				return nil
-			} else if !errors.IsConflict(err) {
-				return err
-			}
-
-			err = util.ReloadCheCluster(deployContext.ClusterAPI.Client, deployContext.CheCluster)
-			if err != nil {
-				return err
+		err := updateFinalizers(deployContext, func(finalizers []string) []string {
+			if util.ContainsString(finalizers, finalizer) {
+				return finalizers
 			}
+			return append(finalizers, finalizer)
+		})
+		if err != nil {
+			return err
 		}
+		logrus.Infof("Added finalizer: %s", finalizer)
 	}
 
 	return nil
@@ -45,26 +39,24 @@ func AppendFinalizer(deployContext *DeployContext, finalizer string) error {
 
 func DeleteFinalizer(deployContext *DeployContext, finalizer string) error {
 	if util.ContainsString(deployContext.CheCluster.ObjectMeta.Finalizers, finalizer) {
-		deployContext.CheCluster.ObjectMeta.Finalizers = util.DoRemoveString(deployContext.CheCluster.ObjectMeta.Finalizers, finalizer)
-		for {
-			err := deployContext.ClusterAPI.Client.Update(context.TODO(), deployContext.CheCluster)
-			if err == nil {
-				logrus.Infof("Deleted finalizer: %s", finalizer)
-				return nil
-			} else if !errors.IsConflict(err) {
-				return err
-			}
-
-			err = util.ReloadCheCluster(deployContext.ClusterAPI.Client, deployContext.CheCluster)
-			if err != nil {
-				return err
-			}
+		err := updateFinalizers(deployContext, func(finalizers []string) []string {
+			return util.DoRemoveString(finalizers, finalizer)
+		})
+		if err != nil {
+			return err
 		}
+		logrus.Infof("Deleted finalizer: %s", finalizer)
 	}
 
 	return nil
 }
 
+func updateFinalizers(deployContext *DeployContext, modify func(finalizers []string) []string) error {
+	return UpdateCheCluster(deployContext, func(cheCluster *orgv1.CheCluster) {
+		cheCluster.ObjectMeta.Finalizers = modify(cheCluster.ObjectMeta.Finalizers)
+	})
+}
+
 func DeleteObjectWithFinalizer(deployContext *DeployContext, key client.ObjectKey, objectMeta metav1.Object, finalizer string) error {
 	_, err := Delete(deployContext, key, objectMeta)
 	if err != nil {
@@ -54,14 +54,19 @@ var (
 
 // SyncGatewayToCluster installs or deletes the gateway based on the custom resource configuration
 func SyncGatewayToCluster(deployContext *deploy.DeployContext) error {
-	if deployContext.CheCluster.Spec.Server.ServerExposureStrategy == "single-host" &&
-		(deploy.GetSingleHostExposureType(deployContext.CheCluster) == "gateway") {
+	if IsGatewayEnabled(deployContext.CheCluster) {
 		return syncAll(deployContext)
 	}
 
 	return deleteAll(deployContext)
 }
 
+// IsGatewayEnabled returns true when Che is exposed on a single host using the gateway.
+func IsGatewayEnabled(instance *orgv1.CheCluster) bool {
+	return instance.Spec.Server.ServerExposureStrategy == "single-host" &&
+		deploy.GetSingleHostExposureType(instance) == "gateway"
+}
+
 func syncAll(deployContext *deploy.DeployContext) error {
 	instance := deployContext.CheCluster
 	sa := getGatewayServiceAccountSpec(instance)
@@ -84,7 +89,11 @@ func syncAll(deployContext *deploy.DeployContext) error {
 		return err
 	}
 
-	depl := getGatewayDeploymentSpec(instance)
+	if err := syncOAuthProxy(deployContext); err != nil {
+		return err
+	}
+
+	depl := getGatewayDeploymentSpec(deployContext)
 	if _, err := deploy.Sync(deployContext, &depl, deploy.DeploymentDiffOpts); err != nil {
 		return err
 	}
@@ -106,6 +115,10 @@ func deleteAll(deployContext *deploy.DeployContext) error {
 	instance := deployContext.CheCluster
 	clusterAPI := deployContext.ClusterAPI
 
+	if err := deleteOAuthProxy(deployContext); err != nil {
+		return err
+	}
+
 	deployment := appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GatewayServiceName,
@@ -197,10 +210,20 @@ http:
       service: ` + serviceName + `
       priority: ` + strconv.Itoa(priority)
 
+	middlewares := []string{}
+	if isAuthenticatedRouteConfig(deployContext.CheCluster, serviceName) {
+		middlewares = append(middlewares, oauthProxyMiddlewareName)
+	}
 	if pathRewrite {
+		middlewares = append(middlewares, serviceName)
+	}
+	if len(middlewares) > 0 {
 		data += `
-      middlewares:
-      - "` + serviceName + `"`
+      middlewares:`
+		for _, middleware := range middlewares {
+			data += `
+      - "` + middleware + `"`
+		}
 	}
 
 	data += `
@@ -255,6 +278,10 @@ func DeleteGatewayRouteConfig(serviceName string, deployContext *deploy.DeployCo
 // below functions declare the desired states of the various objects required for the gateway
 
 func getGatewayServerConfigSpec(deployContext *deploy.DeployContext) corev1.ConfigMap {
+	// the maintenance page is shown instead of Che while it is in maintenance mode
+	if deploy.IsMaintenanceMode(deployContext.CheCluster) {
+		return GetGatewayRouteConfig(deployContext, gatewayServerConfigName, "/", 1, "http://"+deploy.MaintenancePageName+":8080", false)
+	}
 	return GetGatewayRouteConfig(deployContext, gatewayServerConfigName, "/", 1, "http://"+deploy.CheServiceName+":8080", false)
 }
 
@@ -353,7 +380,8 @@ log:
 	}
 }
 
-func getGatewayDeploymentSpec(instance *orgv1.CheCluster) appsv1.Deployment {
+func getGatewayDeploymentSpec(deployContext *deploy.DeployContext) appsv1.Deployment {
+	instance := deployContext.CheCluster
 	gatewayImage := util.GetValue(instance.Spec.Server.SingleHostGatewayImage, deploy.DefaultSingleHostGatewayImage(instance))
 	sidecarImage := util.GetValue(instance.Spec.Server.SingleHostGatewayConfigSidecarImage, deploy.DefaultSingleHostGatewayConfigSidecarImage(instance))
 	configLabelsMap := util.GetMapValue(instance.Spec.Server.SingleHostGatewayConfigMapLabels, deploy.DefaultSingleHostGatewayConfigMapLabels)
@@ -362,7 +390,7 @@ func getGatewayDeploymentSpec(instance *orgv1.CheCluster) appsv1.Deployment {
 	configLabels := labels.FormatLabels(configLabelsMap)
 	labels, labelsSelector := deploy.GetLabelsAndSelector(instance, GatewayServiceName)
 
-	return appsv1.Deployment{
+	deployment := appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: appsv1.SchemeGroupVersion.String(),
 			Kind:       "Deployment",
@@ -381,7 +409,8 @@ func getGatewayDeploymentSpec(instance *orgv1.CheCluster) appsv1.Deployment {
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: deploy.GetCheTLSCertificatesAnnotations(deployContext),
 				},
 				Spec: corev1.PodSpec{
 					TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
@@ -456,6 +485,12 @@ func getGatewayDeploymentSpec(instance *orgv1.CheCluster) appsv1.Deployment {
 			},
 		},
 	}
+
+	if IsGatewayAuthenticationEnabled(instance) {
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, getOAuthProxyContainerSpec(instance))
+	}
+
+	return deployment
 }
 
 func getGatewayServiceSpec(instance *orgv1.CheCluster) corev1.Service {
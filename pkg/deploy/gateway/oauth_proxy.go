@@ -0,0 +1,192 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package gateway
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	oauthProxyConfigName      = "che-gateway-route-oauth-proxy"
+	oauthProxySecretName      = "che-gateway-oauth-proxy"
+	oauthProxyCookieSecretKey = "cookie-secret"
+	// name of the Traefik middleware which authenticates the requests with the OAuth2 proxy
+	oauthProxyMiddlewareName = "che-gateway-authentication"
+	// the proxy listens on the loopback interface only, it is reachable from the gateway container of the same pod
+	oauthProxyAddress = "127.0.0.1:4180"
+
+	defaultOAuthProxyCookieExpire = "168h"
+)
+
+var (
+	// routes which are not authenticated by the OAuth2 proxy: Che server and the Identity Provider authenticate
+	// the requests themselves, and the proxy must be reachable to sign in
+	unauthenticatedRouteConfigs = []string{gatewayServerConfigName, "che-gateway-route-" + deploy.IdentityProviderName, oauthProxyConfigName}
+)
+
+// IsGatewayAuthenticationEnabled returns true when the requests routed by the gateway are authenticated by the OAuth2 proxy.
+func IsGatewayAuthenticationEnabled(instance *orgv1.CheCluster) bool {
+	return IsGatewayEnabled(instance) && instance.Spec.Server.SingleHostGatewayAuthentication.Enable
+}
+
+// syncOAuthProxy generates the cookie secret of the OAuth2 proxy and exposes the proxy endpoints
+// on the `/oauth2` path, or deletes them if the authentication is disabled.
+func syncOAuthProxy(deployContext *deploy.DeployContext) error {
+	if !IsGatewayAuthenticationEnabled(deployContext.CheCluster) {
+		return deleteOAuthProxy(deployContext)
+	}
+
+	if err := syncOAuthProxyCookieSecret(deployContext); err != nil {
+		return err
+	}
+
+	config := getOAuthProxyConfigSpec(deployContext)
+	_, err := deploy.Sync(deployContext, &config, configMapDiffOpts)
+	return err
+}
+
+// syncOAuthProxyCookieSecret generates the secret used by the proxy to encrypt the session cookies, unless it already exists.
+func syncOAuthProxyCookieSecret(deployContext *deploy.DeployContext) error {
+	namespace := deployContext.CheCluster.Namespace
+	secret, err := deploy.GetSecret(deployContext, oauthProxySecretName, namespace)
+	if err != nil || secret != nil {
+		return err
+	}
+
+	cookieSecret, err := generateCookieSecret()
+	if err != nil {
+		return err
+	}
+	_, err = deploy.SyncSecret(deployContext, oauthProxySecretName, namespace, map[string][]byte{oauthProxyCookieSecretKey: []byte(cookieSecret)})
+	return err
+}
+
+func deleteOAuthProxy(deployContext *deploy.DeployContext) error {
+	namespace := deployContext.CheCluster.Namespace
+	config := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oauthProxyConfigName,
+			Namespace: namespace,
+		},
+	}
+	if err := delete(deployContext.ClusterAPI, &config); err != nil {
+		return err
+	}
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oauthProxySecretName,
+			Namespace: namespace,
+		},
+	}
+	return delete(deployContext.ClusterAPI, &secret)
+}
+
+// generateCookieSecret returns 32 random bytes, base64 encoded, as expected by the OAuth2 proxy.
+func generateCookieSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(bytes), nil
+}
+
+// isAuthenticatedRouteConfig indicates whether the route is authenticated by the OAuth2 proxy.
+func isAuthenticatedRouteConfig(instance *orgv1.CheCluster, serviceName string) bool {
+	return IsGatewayAuthenticationEnabled(instance) && !util.ContainsString(unauthenticatedRouteConfigs, serviceName)
+}
+
+// getOAuthProxyIssuerURL returns the URL of the OpenID Connect issuer which authenticates the users.
+func getOAuthProxyIssuerURL(instance *orgv1.CheCluster) string {
+	if issuerURL := instance.Spec.Server.SingleHostGatewayAuthentication.IssuerURL; issuerURL != "" {
+		return issuerURL
+	}
+	if deploy.IsOpenIdConnectProviderEnabled(instance) {
+		return instance.Spec.Auth.OpenIdConnect.IssuerURL
+	}
+	realm := util.GetValue(instance.Spec.Auth.IdentityProviderRealm, deploy.DefaultCheFlavor(instance))
+	return instance.Spec.Auth.IdentityProviderURL + "/auth/realms/" + realm
+}
+
+// getOAuthProxyConfigSpec routes the `/oauth2` path to the proxy, to sign in and out, and declares
+// the middleware which authenticates the requests of the other routes.
+func getOAuthProxyConfigSpec(deployContext *deploy.DeployContext) corev1.ConfigMap {
+	config := GetGatewayRouteConfig(deployContext, oauthProxyConfigName, "/oauth2", 10, "http://"+oauthProxyAddress, false)
+	config.Data[oauthProxyConfigName+".yml"] += `
+  middlewares:
+    ` + oauthProxyMiddlewareName + `:
+      forwardAuth:
+        address: "http://` + oauthProxyAddress + `/"
+        trustForwardHeader: true
+        authResponseHeaders:
+        - "X-Auth-Request-User"
+        - "X-Auth-Request-Email"`
+	return config
+}
+
+// getOAuthProxyContainerSpec returns the container of the OAuth2 proxy, which runs in the gateway pod.
+// Unauthenticated requests are redirected to the issuer to sign in, and requests with a valid bearer token are let through.
+func getOAuthProxyContainerSpec(instance *orgv1.CheCluster) corev1.Container {
+	authentication := instance.Spec.Server.SingleHostGatewayAuthentication
+	image := util.GetValue(authentication.Image, deploy.DefaultSingleHostGatewayOAuthProxyImage(instance))
+	protocol := "http"
+	if instance.Spec.Server.TlsSupport {
+		protocol = "https"
+	}
+
+	return corev1.Container{
+		Name:            "oauth-proxy",
+		Image:           image,
+		ImagePullPolicy: corev1.PullPolicy(deploy.DefaultPullPolicyFromDockerImage(image)),
+		Args: []string{
+			"--http-address=" + oauthProxyAddress,
+			"--provider=oidc",
+			"--oidc-issuer-url=" + getOAuthProxyIssuerURL(instance),
+			"--redirect-url=" + protocol + "://" + instance.Spec.Server.CheHost + "/oauth2/callback",
+			"--upstream=static://202",
+			"--reverse-proxy=true",
+			"--set-xauthrequest=true",
+			"--skip-jwt-bearer-tokens=true",
+			"--skip-provider-button=true",
+			"--email-domain=*",
+			"--cookie-secure=" + strconv.FormatBool(instance.Spec.Server.TlsSupport),
+			"--cookie-expire=" + util.GetValue(authentication.CookieExpire, defaultOAuthProxyCookieExpire),
+		},
+		Env: []corev1.EnvVar{
+			getSecretEnvVar("OAUTH2_PROXY_CLIENT_ID", authentication.ClientSecretName, "client-id"),
+			getSecretEnvVar("OAUTH2_PROXY_CLIENT_SECRET", authentication.ClientSecretName, "client-secret"),
+			getSecretEnvVar("OAUTH2_PROXY_COOKIE_SECRET", oauthProxySecretName, oauthProxyCookieSecretKey),
+		},
+	}
+}
+
+func getSecretEnvVar(name string, secretName string, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				Key: key,
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,187 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newGatewayDeployContext(authentication bool) *deploy.DeployContext {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cheCluster := &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "eclipse-che",
+			Namespace: "eclipse-che",
+		},
+		Spec: orgv1.CheClusterSpec{
+			Server: orgv1.CheClusterSpecServer{
+				CheHost:                "che.example.com",
+				TlsSupport:             true,
+				ServerExposureStrategy: "single-host",
+				SingleHostGatewayAuthentication: orgv1.SingleHostGatewayAuthentication{
+					Enable:           authentication,
+					ClientSecretName: "gateway-client",
+				},
+			},
+			Auth: orgv1.CheClusterSpecAuth{
+				IdentityProviderURL: "https://keycloak.example.com",
+			},
+			K8s: orgv1.CheClusterSpecK8SOnly{
+				SingleHostExposureType: "gateway",
+			},
+		},
+	}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, cheCluster)
+	return &deploy.DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: deploy.ClusterAPI{
+			Client:          cli,
+			NonCachedClient: cli,
+			Scheme:          scheme.Scheme,
+		},
+	}
+}
+
+func TestSyncGatewayWithAuthentication(t *testing.T) {
+	util.IsOpenShift = false
+	deployContext := newGatewayDeployContext(true)
+	if err := SyncGatewayToCluster(deployContext); err != nil {
+		t.Fatalf("Failed to sync gateway: %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: oauthProxySecretName, Namespace: "eclipse-che"}, secret); err != nil {
+		t.Fatalf("Cookie secret not generated: %v", err)
+	}
+	cookieSecret, err := base64.URLEncoding.DecodeString(string(secret.Data[oauthProxyCookieSecretKey]))
+	if err != nil || len(cookieSecret) != 32 {
+		t.Errorf("Expected 32 bytes cookie secret, but got: %v", secret.Data)
+	}
+
+	config := &corev1.ConfigMap{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: oauthProxyConfigName, Namespace: "eclipse-che"}, config); err != nil {
+		t.Fatalf("OAuth2 proxy route not created: %v", err)
+	}
+	if data := config.Data[oauthProxyConfigName+".yml"]; !strings.Contains(data, "PathPrefix(`/oauth2`)") ||
+		!strings.Contains(data, oauthProxyMiddlewareName+":\n      forwardAuth:") {
+		t.Errorf("Unexpected OAuth2 proxy route:\n%s", data)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: GatewayServiceName, Namespace: "eclipse-che"}, deployment); err != nil {
+		t.Fatalf("Gateway deployment not created: %v", err)
+	}
+	containers := deployment.Spec.Template.Spec.Containers
+	if len(containers) != 3 || containers[2].Name != "oauth-proxy" {
+		t.Fatalf("Expected OAuth2 proxy container, but got: %v", containers)
+	}
+	args := strings.Join(containers[2].Args, " ")
+	for _, expected := range []string{
+		"--oidc-issuer-url=https://keycloak.example.com/auth/realms/che",
+		"--redirect-url=https://che.example.com/oauth2/callback",
+		"--cookie-secure=true",
+		"--cookie-expire=168h",
+	} {
+		if !strings.Contains(args, expected) {
+			t.Errorf("Expected argument '%s', but got: %s", expected, args)
+		}
+	}
+
+	// the generated cookie secret is kept
+	if err := SyncGatewayToCluster(deployContext); err != nil {
+		t.Fatalf("Failed to sync gateway: %v", err)
+	}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: oauthProxySecretName, Namespace: "eclipse-che"}, secret); err != nil {
+		t.Fatalf("Failed to get cookie secret: %v", err)
+	}
+	if decoded, _ := base64.URLEncoding.DecodeString(string(secret.Data[oauthProxyCookieSecretKey])); string(decoded) != string(cookieSecret) {
+		t.Error("Cookie secret is expected to be generated once")
+	}
+
+	// the authentication is disabled
+	deployContext.CheCluster.Spec.Server.SingleHostGatewayAuthentication.Enable = false
+	if err := SyncGatewayToCluster(deployContext); err != nil {
+		t.Fatalf("Failed to sync gateway: %v", err)
+	}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: oauthProxyConfigName, Namespace: "eclipse-che"}, config); !errors.IsNotFound(err) {
+		t.Errorf("Expected OAuth2 proxy route to be deleted, but got: %v", err)
+	}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: oauthProxySecretName, Namespace: "eclipse-che"}, secret); !errors.IsNotFound(err) {
+		t.Errorf("Expected cookie secret to be deleted, but got: %v", err)
+	}
+}
+
+func TestGatewayRouteConfigAuthentication(t *testing.T) {
+	type testCase struct {
+		name                string
+		authentication      bool
+		serviceName         string
+		stripPrefix         bool
+		expectedMiddlewares string
+	}
+
+	testCases := []testCase{
+		{
+			name:                "Route is authenticated",
+			authentication:      true,
+			serviceName:         "che-gateway-route-dashboard",
+			expectedMiddlewares: "      middlewares:\n      - \"che-gateway-authentication\"\n",
+		},
+		{
+			name:                "Route is authenticated and prefix stripped",
+			authentication:      true,
+			serviceName:         "che-gateway-route-plugin-registry",
+			stripPrefix:         true,
+			expectedMiddlewares: "      middlewares:\n      - \"che-gateway-authentication\"\n      - \"che-gateway-route-plugin-registry\"\n",
+		},
+		{
+			name:           "Che server route authenticates requests itself",
+			authentication: true,
+			serviceName:    gatewayServerConfigName,
+		},
+		{
+			name:           "Identity provider route must be reachable to sign in",
+			authentication: true,
+			serviceName:    "che-gateway-route-keycloak",
+		},
+		{
+			name:        "Authentication is disabled",
+			serviceName: "che-gateway-route-dashboard",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			deployContext := newGatewayDeployContext(testCase.authentication)
+			config := GetGatewayRouteConfig(deployContext, testCase.serviceName, "/prefix", 10, "http://service:8080", testCase.stripPrefix)
+			data := config.Data[testCase.serviceName+".yml"]
+			if testCase.expectedMiddlewares == "" && strings.Contains(data, "che-gateway-authentication") ||
+				testCase.expectedMiddlewares != "" && !strings.Contains(data, testCase.expectedMiddlewares) {
+				t.Errorf("Expected middlewares:\n%s\nbut got:\n%s", testCase.expectedMiddlewares, data)
+			}
+		})
+	}
+}
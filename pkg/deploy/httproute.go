@@ -0,0 +1,215 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"fmt"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+)
+
+const (
+	gatewayAPIGroup = "gateway.networking.k8s.io"
+)
+
+// The versions of the Gateway API in the order of preference
+var gatewayAPIVersions = []string{"v1", "v1beta1"}
+
+// DetectGatewayAPI records in the deploy context the version of the Gateway API served by the cluster, if any.
+// When a Gateway is referenced but the Gateway API is not installed, the `GatewayAPINotFound` condition is set
+// and the endpoints are exposed with ingresses.
+func DetectGatewayAPI(deployContext *DeployContext) error {
+	deployContext.GatewayAPIVersion = ""
+	if util.IsOpenShift {
+		return nil
+	}
+
+	// the discovery is done on each reconcile, the APIs which fail to be discovered are skipped
+	_, resourcesList, err := deployContext.ClusterAPI.DiscoveryClient.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return err
+	}
+
+	for _, version := range gatewayAPIVersions {
+		for _, l := range resourcesList {
+			for _, r := range l.APIResources {
+				if l.GroupVersion == gatewayAPIGroup+"/"+version && r.Kind == "HTTPRoute" {
+					deployContext.GatewayAPIVersion = version
+					return RemoveWarningCondition(deployContext, orgv1.ConditionGatewayAPINotFound)
+				}
+			}
+		}
+	}
+
+	parentRef := deployContext.CheCluster.Spec.K8s.GatewayAPIParentRef
+	if parentRef == nil {
+		return RemoveWarningCondition(deployContext, orgv1.ConditionGatewayAPINotFound)
+	}
+	return SetWarningCondition(deployContext, orgv1.ConditionGatewayAPINotFound,
+		fmt.Sprintf("The Gateway '%s' is ignored, the Gateway API is not installed", parentRef.Name))
+}
+
+// IsGatewayAPIUsed tells whether the endpoints are exposed with Gateway API `HTTPRoute` objects instead of ingresses.
+func IsGatewayAPIUsed(deployContext *DeployContext) bool {
+	return !util.IsOpenShift && deployContext.GatewayAPIVersion != "" && deployContext.CheCluster.Spec.K8s.GatewayAPIParentRef != nil
+}
+
+// SyncHTTPRouteToCluster creates or updates the `HTTPRoute` which exposes the service.
+// Returns the route once the one in the cluster is up to date.
+func SyncHTTPRouteToCluster(
+	deployContext *DeployContext,
+	name string,
+	host string,
+	serviceName string,
+	servicePort int,
+	component string) (*unstructured.Unstructured, error) {
+
+	specRoute := GetSpecHTTPRoute(deployContext, name, host, serviceName, servicePort, component)
+	clusterRoute := &unstructured.Unstructured{}
+	clusterRoute.SetGroupVersionKind(getHTTPRouteGVK(deployContext))
+	exists, err := Get(deployContext, types.NamespacedName{Name: name, Namespace: deployContext.CheCluster.Namespace}, clusterRoute)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		_, err := Create(deployContext, specRoute)
+		return nil, err
+	}
+
+	if isUnstructuredUpToDate(clusterRoute, specRoute) {
+		return specRoute, nil
+	}
+
+	done, err := Update(deployContext, clusterRoute, specRoute, unstructuredDiffOpts)
+	if !done {
+		return nil, err
+	}
+	return specRoute, nil
+}
+
+// DeleteHTTPRouteIfExists removes the `HTTPRoute`, if the Gateway API is installed.
+func DeleteHTTPRouteIfExists(name string, deployContext *DeployContext) error {
+	if deployContext.GatewayAPIVersion == "" {
+		return nil
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(getHTTPRouteGVK(deployContext))
+	_, err := DeleteNamespacedObject(deployContext, name, route)
+	return err
+}
+
+// GetSpecHTTPRoute returns the `HTTPRoute` attached to the referenced Gateway which exposes the service.
+// The paths are the ones of the ingresses, the prefixes of the registries are stripped.
+func GetSpecHTTPRoute(
+	deployContext *DeployContext,
+	name string,
+	host string,
+	serviceName string,
+	servicePort int,
+	component string) *unstructured.Unstructured {
+
+	cr := deployContext.CheCluster
+	parentRef := cr.Spec.K8s.GatewayAPIParentRef
+	host = getEndpointHost(cr, name, host)
+
+	path := "/"
+	stripPrefix := false
+	if util.GetServerExposureStrategy(cr, DefaultServerExposureStrategy) != "multi-host" {
+		switch name {
+		case IdentityProviderName:
+			path = "/auth"
+		case DevfileRegistryName, PluginRegistryName:
+			path = "/" + name
+			stripPrefix = true
+		}
+	}
+
+	gateway := map[string]interface{}{
+		"group":     gatewayAPIGroup,
+		"kind":      "Gateway",
+		"name":      parentRef.Name,
+		"namespace": util.GetValue(parentRef.Namespace, cr.Namespace),
+	}
+	if parentRef.SectionName != "" {
+		gateway["sectionName"] = parentRef.SectionName
+	}
+
+	rule := map[string]interface{}{
+		"matches": []interface{}{
+			map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":  "PathPrefix",
+					"value": path,
+				},
+			},
+		},
+		"backendRefs": []interface{}{
+			map[string]interface{}{
+				"name": serviceName,
+				"port": int64(servicePort),
+			},
+		},
+	}
+	if stripPrefix {
+		rule["filters"] = []interface{}{
+			map[string]interface{}{
+				"type": "URLRewrite",
+				"urlRewrite": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":               "ReplacePrefixMatch",
+						"replacePrefixMatch": "/",
+					},
+				},
+			},
+		}
+	}
+
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{gateway},
+		"rules":      []interface{}{rule},
+	}
+	if host != "" {
+		spec["hostnames"] = []interface{}{host}
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(getHTTPRouteGVK(deployContext))
+	route.SetName(name)
+	route.SetNamespace(cr.Namespace)
+	route.SetLabels(GetLabels(cr, component))
+	route.Object["spec"] = spec
+	return route
+}
+
+// GetHTTPRouteHost returns the host name the `HTTPRoute` matches, if any.
+func GetHTTPRouteHost(route *unstructured.Unstructured) string {
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if len(hostnames) == 0 {
+		return ""
+	}
+	return hostnames[0]
+}
+
+func getHTTPRouteGVK(deployContext *DeployContext) schema.GroupVersionKind {
+	return schema.GroupVersionKind{
+		Group:   gatewayAPIGroup,
+		Version: deployContext.GatewayAPIVersion,
+		Kind:    "HTTPRoute",
+	}
+}
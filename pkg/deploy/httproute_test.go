@@ -0,0 +1,214 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestDetectGatewayAPI(t *testing.T) {
+	type testCase struct {
+		name            string
+		groupVersions   []string
+		expectedVersion string
+	}
+
+	testCases := []testCase{
+		{
+			name:            "Gateway API not installed",
+			groupVersions:   []string{"networking.k8s.io/v1"},
+			expectedVersion: "",
+		},
+		{
+			name:            "Gateway API v1beta1",
+			groupVersions:   []string{"gateway.networking.k8s.io/v1beta1"},
+			expectedVersion: "v1beta1",
+		},
+		{
+			name:            "Gateway API v1 preferred",
+			groupVersions:   []string{"gateway.networking.k8s.io/v1beta1", "gateway.networking.k8s.io/v1"},
+			expectedVersion: "v1",
+		},
+	}
+
+	util.IsOpenShift = false
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resources := []*metav1.APIResourceList{}
+			for _, groupVersion := range testCase.groupVersions {
+				resources = append(resources, &metav1.APIResourceList{
+					GroupVersion: groupVersion,
+					APIResources: []metav1.APIResource{{Name: "httproutes", Kind: "HTTPRoute"}},
+				})
+			}
+			fakeDiscovery, _ := fakeclientset.NewSimpleClientset().Discovery().(*fakeDiscovery.FakeDiscovery)
+			fakeDiscovery.Fake.Resources = resources
+
+			deployContext := getTestTLSDeployContext()
+			deployContext.CheCluster.Spec.K8s.GatewayAPIParentRef = &orgv1.GatewayAPIParentRef{Name: "che-gateway"}
+			deployContext.ClusterAPI.DiscoveryClient = fakeDiscovery
+			recorder := record.NewFakeRecorder(10)
+			deployContext.EventRecorder = recorder
+
+			// the detection is done on each reconcile
+			for i := 0; i < 2; i++ {
+				if err := DetectGatewayAPI(deployContext); err != nil {
+					t.Fatalf("Failed to detect the Gateway API: %v", err)
+				}
+			}
+			if deployContext.GatewayAPIVersion != testCase.expectedVersion {
+				t.Errorf("Expected Gateway API version '%s', got: '%s'", testCase.expectedVersion, deployContext.GatewayAPIVersion)
+			}
+			if IsGatewayAPIUsed(deployContext) != (testCase.expectedVersion != "") {
+				t.Errorf("Unexpected Gateway API usage: %t", IsGatewayAPIUsed(deployContext))
+			}
+
+			// the missing Gateway API is reported once
+			notFound := testCase.expectedVersion == ""
+			if orgv1.IsConditionTrue(deployContext.CheCluster.Status.Conditions, orgv1.ConditionGatewayAPINotFound) != notFound {
+				t.Errorf("Expected %s condition to be set: %t", orgv1.ConditionGatewayAPINotFound, notFound)
+			}
+			expectedEvents := 0
+			if notFound {
+				expectedEvents = 1
+			}
+			if len(recorder.Events) != expectedEvents {
+				t.Errorf("Expected %d events, got %d", expectedEvents, len(recorder.Events))
+			}
+		})
+	}
+}
+
+func TestGetSpecHTTPRoute(t *testing.T) {
+	deployContext := getTestTLSDeployContext()
+	deployContext.GatewayAPIVersion = "v1"
+	deployContext.CheCluster.Spec.Server.ServerExposureStrategy = "single-host"
+	deployContext.CheCluster.Spec.K8s.GatewayAPIParentRef = &orgv1.GatewayAPIParentRef{
+		Name:        "che-gateway",
+		Namespace:   "gateways",
+		SectionName: "https",
+	}
+
+	route := GetSpecHTTPRoute(deployContext, PluginRegistryName, "che.internal", PluginRegistryName, 8080, PluginRegistryName)
+	if route.GetAPIVersion() != "gateway.networking.k8s.io/v1" || route.GetKind() != "HTTPRoute" {
+		t.Errorf("Unexpected HTTP route type: %s, %s", route.GetAPIVersion(), route.GetKind())
+	}
+
+	expectedSpec := map[string]interface{}{
+		"parentRefs": []interface{}{
+			map[string]interface{}{
+				"group":       "gateway.networking.k8s.io",
+				"kind":        "Gateway",
+				"name":        "che-gateway",
+				"namespace":   "gateways",
+				"sectionName": "https",
+			},
+		},
+		"hostnames": []interface{}{"che.internal"},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{
+						"path": map[string]interface{}{"type": "PathPrefix", "value": "/plugin-registry"},
+					},
+				},
+				"filters": []interface{}{
+					map[string]interface{}{
+						"type": "URLRewrite",
+						"urlRewrite": map[string]interface{}{
+							"path": map[string]interface{}{"type": "ReplacePrefixMatch", "replacePrefixMatch": "/"},
+						},
+					},
+				},
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": "plugin-registry", "port": int64(8080)},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(route.Object["spec"], expectedSpec) {
+		t.Errorf("Unexpected HTTP route spec (-want, +got): %v", cmp.Diff(expectedSpec, route.Object["spec"]))
+	}
+}
+
+func TestSyncHTTPRouteToCluster(t *testing.T) {
+	deployContext := getTestTLSDeployContext()
+	deployContext.GatewayAPIVersion = "v1"
+	deployContext.CheCluster.Spec.Server.ServerExposureStrategy = "multi-host"
+	deployContext.CheCluster.Spec.K8s.GatewayAPIParentRef = &orgv1.GatewayAPIParentRef{Name: "che-gateway"}
+
+	route, err := SyncHTTPRouteToCluster(deployContext, "che", "", "che-host", 8080, "che")
+	if route != nil || err != nil {
+		t.Fatalf("Expected the HTTP route to be created, got: %v, %v", route, err)
+	}
+
+	clusterRoute := &unstructured.Unstructured{}
+	clusterRoute.SetGroupVersionKind(getHTTPRouteGVK(deployContext))
+	key := types.NamespacedName{Name: "che", Namespace: "eclipse-che"}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), key, clusterRoute); err != nil {
+		t.Fatalf("Failed to get the HTTP route: %v", err)
+	}
+	if len(clusterRoute.GetOwnerReferences()) != 1 {
+		t.Errorf("Expected the HTTP route to be owned by the CheCluster, got: %v", clusterRoute.GetOwnerReferences())
+	}
+
+	// fields defaulted by the cluster don't cause updates
+	unstructured.SetNestedField(clusterRoute.Object, []interface{}{
+		map[string]interface{}{
+			"group":     "gateway.networking.k8s.io",
+			"kind":      "Gateway",
+			"name":      "che-gateway",
+			"namespace": "eclipse-che",
+		},
+	}, "spec", "parentRefs")
+	rules, _, _ := unstructured.NestedSlice(clusterRoute.Object, "spec", "rules")
+	backendRef := map[string]interface{}{"group": "", "kind": "Service", "name": "che-host", "port": int64(8080), "weight": int64(1)}
+	rules[0].(map[string]interface{})["backendRefs"] = []interface{}{backendRef}
+	unstructured.SetNestedSlice(clusterRoute.Object, rules, "spec", "rules")
+	if err := deployContext.ClusterAPI.Client.Update(context.TODO(), clusterRoute); err != nil {
+		t.Fatalf("Failed to update the HTTP route: %v", err)
+	}
+
+	route, err = SyncHTTPRouteToCluster(deployContext, "che", "", "che-host", 8080, "che")
+	if route == nil || err != nil {
+		t.Fatalf("Expected the HTTP route to be up to date, got: %v, %v", route, err)
+	}
+	if host := GetHTTPRouteHost(route); host != "che-eclipse-che.che.internal" {
+		t.Errorf("Unexpected HTTP route host: %s", host)
+	}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), key, clusterRoute); err != nil {
+		t.Fatalf("Failed to get the HTTP route: %v", err)
+	}
+	backendRefs, _, _ := unstructured.NestedSlice(clusterRoute.Object, "spec", "rules")
+	if !reflect.DeepEqual(backendRefs[0].(map[string]interface{})["backendRefs"], []interface{}{backendRef}) {
+		t.Errorf("The HTTP route has been updated: %v", clusterRoute.Object["spec"])
+	}
+
+	if err := DeleteHTTPRouteIfExists("che", deployContext); err != nil {
+		t.Fatalf("Failed to delete the HTTP route: %v", err)
+	}
+	if exists, _ := Get(deployContext, key, clusterRoute); exists {
+		t.Errorf("Expected the HTTP route to be deleted")
+	}
+}
@@ -18,8 +18,8 @@ import (
 	"strconv"
 	"strings"
 
-	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/deploy/postgres"
 	"github.com/eclipse-che/che-operator/pkg/util"
 	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
@@ -32,12 +32,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-const (
-	selectSslRequiredCommand = "OUT=$(psql keycloak -tAc \"SELECT 1 FROM REALM WHERE id = 'master'\"); " +
-		"if [ $OUT -eq 1 ]; then psql keycloak -tAc \"SELECT ssl_required FROM REALM WHERE id = 'master'\"; fi"
-	updateSslRequiredCommand = "psql keycloak -c \"update REALM set ssl_required='NONE' where id = 'master'\""
-)
-
 var (
 	trustpass              = util.GeneratePasswd(12)
 	keycloakCustomDiffOpts = cmp.Options{
@@ -102,6 +96,7 @@ func GetSpecKeycloakDeployment(
 		}
 	}
 
+	postgresUser := getKeycloakPostgresUser(deployContext)
 	cmResourceVersions := deploy.GetAdditionalCACertsConfigMapVersion(deployContext)
 	terminationGracePeriodSeconds := int64(30)
 	cheCertSecretVersion := getSecretResourceVersion("self-signed-certificate", deployContext.CheCluster.Namespace, deployContext.ClusterAPI)
@@ -257,7 +252,7 @@ func GetSpecKeycloakDeployment(
 		},
 		{
 			Name:  "POSTGRES_USER",
-			Value: "keycloak",
+			Value: postgresUser,
 		},
 		{
 			Name:  "SSO_TRUSTSTORE",
@@ -380,7 +375,7 @@ func GetSpecKeycloakDeployment(
 			},
 			{
 				Name:  "DB_USERNAME",
-				Value: "keycloak",
+				Value: postgresUser,
 			},
 			{
 				Name:  "DB_VENDOR",
@@ -599,7 +594,8 @@ func GetSpecKeycloakDeployment(
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: deploy.GetCredentialsRotationAnnotations(deployContext.CheCluster),
 				},
 				Spec: corev1.PodSpec{
 					Volumes: []corev1.Volume{
@@ -708,6 +704,21 @@ func getSecretResourceVersion(name string, namespace string, clusterAPI deploy.C
 	return secret.ResourceVersion
 }
 
+// getKeycloakPostgresUser returns the PostgreSQL user of Keycloak. The user is stored in the secret
+// along with the password once the credentials have been rotated.
+func getKeycloakPostgresUser(deployContext *deploy.DeployContext) string {
+	identityProviderPostgresSecret := deployContext.CheCluster.Spec.Auth.IdentityProviderPostgresSecret
+	if len(identityProviderPostgresSecret) > 0 {
+		secret, err := deploy.GetSecret(deployContext, identityProviderPostgresSecret, deployContext.CheCluster.Namespace)
+		if err != nil {
+			logrus.Errorf("Failed to get %s secret: %s", identityProviderPostgresSecret, err)
+		} else if secret != nil && len(secret.Data["user"]) > 0 {
+			return string(secret.Data["user"])
+		}
+	}
+	return "keycloak"
+}
+
 func isSslRequiredUpdatedForMasterRealm(deployContext *deploy.DeployContext) bool {
 	if deployContext.CheCluster.Spec.Database.ExternalDb {
 		return false
@@ -727,53 +738,64 @@ func isSslRequiredUpdatedForMasterRealm(deployContext *deploy.DeployContext) boo
 		return true
 	}
 
-	dbValue, _ := getSslRequiredForMasterRealm(deployContext.CheCluster)
+	dbValue, _ := getSslRequiredForMasterRealm(deployContext)
 	return dbValue == "NONE"
 }
 
-func getSslRequiredForMasterRealm(cr *orgv1.CheCluster) (string, error) {
-	stdout, err := util.K8sclient.ExecIntoPod(
-		cr,
-		deploy.PostgresName,
-		func(cr *orgv1.CheCluster) (string, error) {
-			return selectSslRequiredCommand, nil
-		},
-		"")
-	return strings.TrimSpace(stdout), err
+// getSslRequiredForMasterRealm reads the SSL mode of the master realm right from Keycloak database.
+// Returns empty string if the realm isn't created yet.
+func getSslRequiredForMasterRealm(deployContext *deploy.DeployContext) (string, error) {
+	client, err := postgres.ConnectAsAdmin(deployContext, postgres.KeycloakPostgresDatabase)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	rows, err := client.Query("SELECT ssl_required FROM realm WHERE id = 'master'")
+	if err != nil {
+		if postgres.IsErrorCode(err, postgres.UndefinedTableErrorCode) {
+			return "", nil
+		}
+		return "", err
+	}
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return "", nil
+	}
+	return strings.TrimSpace(rows[0][0]), nil
 }
 
-func updateSslRequiredForMasterRealm(cr *orgv1.CheCluster) error {
-	_, err := util.K8sclient.ExecIntoPod(
-		cr,
-		deploy.PostgresName,
-		func(cr *orgv1.CheCluster) (string, error) {
-			return updateSslRequiredCommand, nil
-		},
-		"Update ssl_required to NONE")
-	return err
+func updateSslRequiredForMasterRealm(deployContext *deploy.DeployContext) error {
+	client, err := postgres.ConnectAsAdmin(deployContext, postgres.KeycloakPostgresDatabase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	logrus.Info("Updating ssl_required of the master realm to NONE")
+	return client.Exec("UPDATE realm SET ssl_required = 'NONE' WHERE id = 'master'")
 }
 
+// ProvisionKeycloakResources creates the realm, the client and the user of Che in Keycloak.
 func ProvisionKeycloakResources(deployContext *deploy.DeployContext) error {
 	if !deployContext.CheCluster.Spec.Database.ExternalDb {
-		value, err := getSslRequiredForMasterRealm(deployContext.CheCluster)
+		value, err := getSslRequiredForMasterRealm(deployContext)
 		if err != nil {
 			return err
 		}
 
 		if value != "NONE" {
-			err := updateSslRequiredForMasterRealm(deployContext.CheCluster)
+			err := updateSslRequiredForMasterRealm(deployContext)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	_, err := util.K8sclient.ExecIntoPod(
-		deployContext.CheCluster,
-		deploy.IdentityProviderName,
-		GetKeycloakProvisionCommand,
-		"create realm, client and user")
-	return err
+	client, err := newKeycloakAdminClient(deployContext)
+	if err != nil {
+		return err
+	}
+	return provisionRealm(client, deployContext.CheCluster)
 }
 
 // getImportCABundleScript returns string which contains bash function that imports ca-bundle into jks
@@ -0,0 +1,325 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	IdentityBrokerProvisionedEventReason = "IdentityBrokerProvisioned"
+	IdentityBrokerDeletedEventReason     = "IdentityBrokerDeleted"
+	IdentityBrokerFailedEventReason      = "IdentityBrokerFailed"
+
+	identityBrokerTypeOIDC = "oidc"
+	identityBrokerTypeSAML = "saml"
+	// Keycloak never returns the client secret of an identity provider, so it is excluded from the comparison
+	identityBrokerClientSecretKey = "clientSecret"
+)
+
+var (
+	// aliases of the identity providers which are provisioned by the Operator on their own
+	reservedIdentityBrokerAliases = []string{"github", "openshift-v3", "openshift-v4"}
+)
+
+// identityBroker is an identity broker declared in a secret
+type identityBroker struct {
+	alias       string
+	displayName string
+	brokerType  string
+	secret      *corev1.Secret
+}
+
+// syncIdentityBrokers provisions OIDC and SAML identity brokers from the secrets labeled with
+// `app.kubernetes.io/component=identity-broker-configuration`, the same way as GitHub OAuth is provisioned from
+// the `oauth-scm-configuration` ones. The type of the broker is set by the `che.eclipse.org/identity-broker-type` annotation.
+func syncIdentityBrokers(deployContext *deploy.DeployContext) (bool, error) {
+	cr := deployContext.CheCluster
+	if util.IsTestMode() || !cr.Status.KeycloakProvisoned {
+		return true, nil
+	}
+
+	secrets, err := getIdentityBrokerSecrets(deployContext)
+	if err != nil {
+		return false, err
+	}
+	if len(secrets) == 0 && len(cr.Status.IdentityBrokers) == 0 {
+		return true, nil
+	}
+
+	client, err := newKeycloakAdminClient(deployContext)
+	if err != nil {
+		return false, err
+	}
+	if err := reconcileIdentityBrokers(deployContext, client, secrets); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func getIdentityBrokerSecrets(deployContext *deploy.DeployContext) ([]corev1.Secret, error) {
+	return deploy.GetSecrets(deployContext, map[string]string{
+		deploy.KubernetesPartOfLabelKey:    deploy.CheEclipseOrg,
+		deploy.KubernetesComponentLabelKey: deploy.IdentityBrokerConfiguration,
+	}, map[string]string{})
+}
+
+// reconcileIdentityBrokers provisions the identity brokers declared in the secrets, and removes the ones provisioned
+// previously, but whose secrets no longer exist. A failure to provision a broker doesn't block the reconciliation,
+// it is reported in the status and in an event.
+func reconcileIdentityBrokers(deployContext *deploy.DeployContext, client *KeycloakClient, secrets []corev1.Secret) error {
+	cr := deployContext.CheCluster
+	keycloakRealm, _ := getKeycloakRealmAndClientId(cr)
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	statuses := []orgv1.IdentityBrokerStatus{}
+	declaredAliases := map[string]bool{}
+	for i := range secrets {
+		broker := getIdentityBroker(&secrets[i])
+		previousStatus := getIdentityBrokerStatus(cr.Status.IdentityBrokers, broker.alias, broker.secret.Name)
+		status := orgv1.IdentityBrokerStatus{
+			Alias:         broker.alias,
+			Type:          broker.brokerType,
+			SecretName:    broker.secret.Name,
+			SecretVersion: previousStatus.SecretVersion,
+		}
+
+		var err error
+		if declaredAliases[broker.alias] {
+			err = fmt.Errorf("identity broker '%s' is already declared in another secret", broker.alias)
+			status.SecretVersion = ""
+		} else {
+			declaredAliases[broker.alias] = true
+			err = provisionIdentityBroker(deployContext, client, keycloakRealm, broker, &status)
+		}
+
+		if err != nil {
+			logrus.Errorf("Failed to provision identity broker '%s': %v", broker.alias, err)
+			if previousStatus.Message != err.Error() {
+				deploy.RecordEventf(deployContext, corev1.EventTypeWarning, IdentityBrokerFailedEventReason,
+					"Failed to provision identity broker '%s' from secret '%s': %v", broker.alias, broker.secret.Name, err)
+			}
+			status.Message = err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+
+	// only the brokers provisioned previously are deleted, not the ones which failed, since their aliases
+	// might be reserved, or taken by the identity providers created in Keycloak manually
+	for _, previousStatus := range cr.Status.IdentityBrokers {
+		if declaredAliases[previousStatus.Alias] || previousStatus.SecretVersion == "" {
+			continue
+		}
+		if err := deleteIdentityProvider(client, keycloakRealm, previousStatus.Alias); err != nil {
+			logrus.Errorf("Failed to delete identity broker '%s': %v", previousStatus.Alias, err)
+			previousStatus.Message = err.Error()
+			statuses = append(statuses, previousStatus)
+			continue
+		}
+		logrus.Infof("Identity broker '%s' deleted", previousStatus.Alias)
+		deploy.RecordEventf(deployContext, corev1.EventTypeNormal, IdentityBrokerDeletedEventReason,
+			"Identity broker '%s' deleted", previousStatus.Alias)
+	}
+
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	if reflect.DeepEqual(statuses, cr.Status.IdentityBrokers) {
+		return nil
+	}
+	cr.Status.IdentityBrokers = statuses
+	return deploy.UpdateCheCRStatus(deployContext, "status: identity brokers", strconv.Itoa(len(statuses)))
+}
+
+// provisionIdentityBroker creates the identity broker, or updates it when the secret has changed since the last
+// provisioning. The broker is recreated if it has been deleted in Keycloak.
+func provisionIdentityBroker(deployContext *deploy.DeployContext, client *KeycloakClient, realm string, broker *identityBroker, status *orgv1.IdentityBrokerStatus) error {
+	if util.ContainsString(reservedIdentityBrokerAliases, broker.alias) {
+		return fmt.Errorf("alias '%s' is reserved", broker.alias)
+	}
+
+	identityProvider, err := client.GetIdentityProvider(realm, broker.alias)
+	if err != nil {
+		return err
+	}
+	if identityProvider != nil && status.SecretVersion == broker.secret.ResourceVersion {
+		status.Message = ""
+		return nil
+	}
+
+	config, err := getIdentityBrokerConfig(client, realm, broker)
+	if err != nil {
+		return err
+	}
+
+	if identityProvider == nil {
+		logrus.Infof("Creating identity broker '%s'", broker.alias)
+		err := client.CreateIdentityProvider(realm, &IdentityProviderRepresentation{
+			Alias:       broker.alias,
+			ProviderId:  broker.brokerType,
+			DisplayName: broker.displayName,
+			Enabled:     true,
+			StoreToken:  true,
+			Config:      mergeIdentityBrokerConfig(map[string]string{}, config),
+		})
+		if err != nil {
+			return err
+		}
+		deploy.RecordEventf(deployContext, corev1.EventTypeNormal, IdentityBrokerProvisionedEventReason,
+			"Identity broker '%s' provisioned from secret '%s'", broker.alias, broker.secret.Name)
+	} else if identityProvider.ProviderId != broker.brokerType {
+		return fmt.Errorf("identity provider '%s' of type '%s' already exists", broker.alias, identityProvider.ProviderId)
+	} else if identityProvider.DisplayName != broker.displayName || !identityProvider.Enabled || !isIdentityBrokerConfigInSync(config, identityProvider.Config) {
+		logrus.Infof("Updating identity broker '%s'", broker.alias)
+		identityProvider.DisplayName = broker.displayName
+		identityProvider.Enabled = true
+		identityProvider.Config = mergeIdentityBrokerConfig(identityProvider.Config, config)
+		if err := client.UpdateIdentityProvider(realm, identityProvider); err != nil {
+			return err
+		}
+		deploy.RecordEventf(deployContext, corev1.EventTypeNormal, IdentityBrokerProvisionedEventReason,
+			"Identity broker '%s' updated from secret '%s'", broker.alias, broker.secret.Name)
+	}
+
+	status.SecretVersion = broker.secret.ResourceVersion
+	status.Message = ""
+	return nil
+}
+
+// getIdentityBrokerStatus returns the status of the identity broker declared in the given secret,
+// or an empty one, if it is not found.
+func getIdentityBrokerStatus(statuses []orgv1.IdentityBrokerStatus, alias string, secretName string) orgv1.IdentityBrokerStatus {
+	for _, status := range statuses {
+		if status.Alias == alias && status.SecretName == secretName {
+			return status
+		}
+	}
+	return orgv1.IdentityBrokerStatus{}
+}
+
+func getIdentityBroker(secret *corev1.Secret) *identityBroker {
+	return &identityBroker{
+		alias:       util.GetValue(secret.Annotations[deploy.CheEclipseOrgIdentityBrokerAlias], secret.Name),
+		displayName: secret.Annotations[deploy.CheEclipseOrgIdentityBrokerDisplayName],
+		brokerType:  secret.Annotations[deploy.CheEclipseOrgIdentityBrokerType],
+		secret:      secret,
+	}
+}
+
+// getIdentityBrokerConfig returns the configuration of the identity broker in Keycloak format. The endpoints are
+// read from the OpenID Connect discovery document, or the SAML metadata, if their URL is set in the secret, and
+// can be overridden with the explicit ones. Empty values stand for the attributes to be unset.
+func getIdentityBrokerConfig(client *KeycloakClient, realm string, broker *identityBroker) (map[string]string, error) {
+	data := map[string]string{}
+	for key, value := range broker.secret.Data {
+		data[key] = string(value)
+	}
+
+	var config map[string]string
+	var discoveryUrl string
+	switch broker.brokerType {
+	case identityBrokerTypeOIDC:
+		if data["client-id"] == "" || data["client-secret"] == "" {
+			return nil, fmt.Errorf("'client-id' and 'client-secret' are required")
+		}
+		if data["discovery-url"] == "" && (data["authorization-url"] == "" || data["token-url"] == "") {
+			return nil, fmt.Errorf("either 'discovery-url', or 'authorization-url' and 'token-url', are required")
+		}
+		discoveryUrl = data["discovery-url"]
+		config = map[string]string{
+			"clientId":         data["client-id"],
+			"clientSecret":     data["client-secret"],
+			"clientAuthMethod": "client_secret_post",
+			"defaultScope":     util.GetValue(data["default-scope"], "openid profile email"),
+			"authorizationUrl": data["authorization-url"],
+			"tokenUrl":         data["token-url"],
+			"userInfoUrl":      data["userinfo-url"],
+			"logoutUrl":        data["logout-url"],
+			"issuer":           data["issuer"],
+			"jwksUrl":          data["jwks-url"],
+			"syncMode":         "IMPORT",
+		}
+	case identityBrokerTypeSAML:
+		if data["metadata-url"] == "" && data["sso-url"] == "" {
+			return nil, fmt.Errorf("either 'metadata-url' or 'sso-url' is required")
+		}
+		discoveryUrl = data["metadata-url"]
+		config = map[string]string{
+			"singleSignOnServiceUrl":  data["sso-url"],
+			"singleLogoutServiceUrl":  data["slo-url"],
+			"signingCertificate":      data["signing-certificate"],
+			"nameIDPolicyFormat":      util.GetValue(data["name-id-policy-format"], "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"),
+			"principalType":           "SUBJECT",
+			"postBindingResponse":     "true",
+			"postBindingAuthnRequest": "true",
+			"syncMode":                "IMPORT",
+		}
+	default:
+		return nil, fmt.Errorf("unsupported identity broker type '%s', annotation '%s' must be set to '%s' or '%s'",
+			broker.brokerType, deploy.CheEclipseOrgIdentityBrokerType, identityBrokerTypeOIDC, identityBrokerTypeSAML)
+	}
+
+	if discoveryUrl != "" {
+		discoveredConfig, err := client.ImportIdentityProviderConfig(realm, broker.brokerType, discoveryUrl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read '%s': %v", discoveryUrl, err)
+		}
+		for key, value := range discoveredConfig {
+			if config[key] == "" {
+				config[key] = value
+			}
+		}
+	}
+
+	switch broker.brokerType {
+	case identityBrokerTypeOIDC:
+		config["useJwksUrl"] = strconv.FormatBool(config["jwksUrl"] != "")
+		config["validateSignature"] = strconv.FormatBool(config["jwksUrl"] != "")
+	case identityBrokerTypeSAML:
+		config["validateSignature"] = strconv.FormatBool(config["signingCertificate"] != "")
+	}
+	return config, nil
+}
+
+func isIdentityBrokerConfigInSync(config map[string]string, existing map[string]string) bool {
+	for key, value := range config {
+		if key != identityBrokerClientSecretKey && existing[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeIdentityBrokerConfig overrides the attributes of the existing configuration with the desired ones.
+// Attributes which are not managed by the Operator are left as is.
+func mergeIdentityBrokerConfig(existing map[string]string, config map[string]string) map[string]string {
+	result := map[string]string{}
+	for key, value := range existing {
+		result[key] = value
+	}
+	for key, value := range config {
+		if value == "" {
+			delete(result, key)
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
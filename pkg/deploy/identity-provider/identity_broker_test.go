@@ -0,0 +1,210 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newIdentityBrokerSecret(name string, brokerType string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "eclipse-che",
+			ResourceVersion: "1",
+			Labels: map[string]string{
+				deploy.KubernetesPartOfLabelKey:    deploy.CheEclipseOrg,
+				deploy.KubernetesComponentLabelKey: deploy.IdentityBrokerConfiguration,
+			},
+			Annotations: map[string]string{
+				deploy.CheEclipseOrgIdentityBrokerType: brokerType,
+			},
+		},
+		Data: map[string][]byte{},
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+	return secret
+}
+
+func TestReconcileIdentityBrokers(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+	keycloak.importedConfigs["https://login.example.com/.well-known/openid-configuration"] = map[string]string{
+		"authorizationUrl": "https://login.example.com/authorize",
+		"tokenUrl":         "https://login.example.com/token",
+		"jwksUrl":          "https://login.example.com/keys",
+		"issuer":           "https://login.example.com",
+	}
+
+	azure := newIdentityBrokerSecret("azure-ad", "oidc", map[string]string{
+		"client-id":     "azure-id",
+		"client-secret": "azure-secret",
+		"discovery-url": "https://login.example.com/.well-known/openid-configuration",
+	})
+	azure.Annotations[deploy.CheEclipseOrgIdentityBrokerDisplayName] = "Azure AD"
+	saml := newIdentityBrokerSecret("saml", "saml", map[string]string{
+		"sso-url":             "https://idp.example.com/sso",
+		"signing-certificate": "MIIC",
+	})
+	saml.Annotations[deploy.CheEclipseOrgIdentityBrokerAlias] = "corporate-saml"
+
+	cheCluster := newTestCheCluster()
+	deployContext, recorder := newRealmConfigDeployContext(cheCluster)
+	client := keycloak.newClient()
+	if err := provisionRealm(client, cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+	if err := reconcileIdentityBrokers(deployContext, client, []corev1.Secret{*saml, *azure}); err != nil {
+		t.Fatalf("Failed to reconcile identity brokers: %v", err)
+	}
+
+	oidcBroker, ok := keycloak.identityProviders["che"]["azure-ad"]
+	if !ok {
+		t.Fatal("Identity broker 'azure-ad' not created")
+	}
+	expectedConfig := map[string]string{
+		"clientId":          "azure-id",
+		"clientSecret":      "azure-secret",
+		"clientAuthMethod":  "client_secret_post",
+		"defaultScope":      "openid profile email",
+		"authorizationUrl":  "https://login.example.com/authorize",
+		"tokenUrl":          "https://login.example.com/token",
+		"jwksUrl":           "https://login.example.com/keys",
+		"issuer":            "https://login.example.com",
+		"useJwksUrl":        "true",
+		"validateSignature": "true",
+		"syncMode":          "IMPORT",
+	}
+	if oidcBroker.ProviderId != "oidc" || oidcBroker.DisplayName != "Azure AD" || !oidcBroker.Enabled || !reflect.DeepEqual(oidcBroker.Config, expectedConfig) {
+		t.Errorf("Unexpected identity broker: %v", oidcBroker)
+	}
+	samlBroker, ok := keycloak.identityProviders["che"]["corporate-saml"]
+	if !ok {
+		t.Fatal("Identity broker 'corporate-saml' not created")
+	}
+	if samlBroker.ProviderId != "saml" || samlBroker.Config["singleSignOnServiceUrl"] != "https://idp.example.com/sso" || samlBroker.Config["validateSignature"] != "true" {
+		t.Errorf("Unexpected identity broker: %v", samlBroker)
+	}
+
+	status := cheCluster.Status.IdentityBrokers
+	if len(status) != 2 || status[0].Alias != "azure-ad" || status[0].Type != "oidc" || status[0].SecretVersion != "1" ||
+		status[1].Alias != "corporate-saml" || status[1].SecretName != "saml" {
+		t.Fatalf("Unexpected status: %v", status)
+	}
+	assertRealmEvent(t, recorder, "Normal IdentityBrokerProvisioned Identity broker 'azure-ad' provisioned")
+	assertRealmEvent(t, recorder, "Normal IdentityBrokerProvisioned Identity broker 'corporate-saml' provisioned")
+
+	// nothing is updated when the secrets are not changed
+	keycloak.modifications = nil
+	if err := reconcileIdentityBrokers(deployContext, client, []corev1.Secret{*saml, *azure}); err != nil {
+		t.Fatalf("Failed to reconcile identity brokers: %v", err)
+	}
+	if len(keycloak.modifications) != 0 {
+		t.Errorf("Unexpected modifications of identity brokers in sync: %v", keycloak.modifications)
+	}
+
+	// the secret is updated, and the other one deleted
+	azure.ResourceVersion = "2"
+	azure.Data["default-scope"] = []byte("openid")
+	if err := reconcileIdentityBrokers(deployContext, client, []corev1.Secret{*azure}); err != nil {
+		t.Fatalf("Failed to reconcile identity brokers: %v", err)
+	}
+	if scope := keycloak.identityProviders["che"]["azure-ad"].Config["defaultScope"]; scope != "openid" {
+		t.Errorf("Expected identity broker to be updated, but got scope '%s'", scope)
+	}
+	if _, ok := keycloak.identityProviders["che"]["corporate-saml"]; ok {
+		t.Error("Identity broker 'corporate-saml' not deleted")
+	}
+	status = cheCluster.Status.IdentityBrokers
+	if len(status) != 1 || status[0].Alias != "azure-ad" || status[0].SecretVersion != "2" {
+		t.Fatalf("Unexpected status: %v", status)
+	}
+	assertRealmEvent(t, recorder, "Normal IdentityBrokerProvisioned Identity broker 'azure-ad' updated")
+	assertRealmEvent(t, recorder, "Normal IdentityBrokerDeleted Identity broker 'corporate-saml' deleted")
+
+	// all the secrets are deleted
+	if err := reconcileIdentityBrokers(deployContext, client, nil); err != nil {
+		t.Fatalf("Failed to reconcile identity brokers: %v", err)
+	}
+	if _, ok := keycloak.identityProviders["che"]["azure-ad"]; ok {
+		t.Error("Identity broker 'azure-ad' not deleted")
+	}
+	if cheCluster.Status.IdentityBrokers != nil {
+		t.Errorf("Unexpected status: %v", cheCluster.Status.IdentityBrokers)
+	}
+}
+
+func TestReconcileIdentityBrokersFailure(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	invalid := newIdentityBrokerSecret("okta", "oidc", map[string]string{"client-id": "okta-id"})
+	reserved := newIdentityBrokerSecret("github", "oidc", map[string]string{})
+	unsupported := newIdentityBrokerSecret("google", "oauth", map[string]string{})
+
+	cheCluster := newTestCheCluster()
+	deployContext, recorder := newRealmConfigDeployContext(cheCluster)
+	client := keycloak.newClient()
+	if err := provisionRealm(client, cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+	keycloak.modifications = nil
+	for i := 0; i < 2; i++ {
+		if err := reconcileIdentityBrokers(deployContext, client, []corev1.Secret{*invalid, *reserved, *unsupported}); err != nil {
+			t.Fatalf("Failure to provision identity brokers expected to be reported in status, but got: %v", err)
+		}
+	}
+
+	expectedMessages := []string{
+		"alias 'github' is reserved",
+		"unsupported identity broker type 'oauth'",
+		"'client-id' and 'client-secret' are required",
+	}
+	status := cheCluster.Status.IdentityBrokers
+	if len(status) != len(expectedMessages) {
+		t.Fatalf("Unexpected status: %v", status)
+	}
+	for i, expectedMessage := range expectedMessages {
+		if !strings.HasPrefix(status[i].Message, expectedMessage) || status[i].SecretVersion != "" {
+			t.Errorf("Expected message '%s', but got: %v", expectedMessage, status[i])
+		}
+	}
+	if len(keycloak.modifications) != 0 {
+		t.Errorf("Unexpected modifications of realm: %v", keycloak.modifications)
+	}
+	// the same failures are reported once
+	for range expectedMessages {
+		assertRealmEvent(t, recorder, "Warning IdentityBrokerFailed")
+	}
+	if len(recorder.Events) != 0 {
+		t.Errorf("Unexpected event: %s", <-recorder.Events)
+	}
+
+	// the failed brokers are not deleted from the realm
+	if err := provisionGitHubIdentityProvider(client, "che", "github-id", "github-secret"); err != nil {
+		t.Fatalf("Failed to provision GitHub identity provider: %v", err)
+	}
+	if err := reconcileIdentityBrokers(deployContext, client, nil); err != nil {
+		t.Fatalf("Failed to reconcile identity brokers: %v", err)
+	}
+	if _, ok := keycloak.identityProviders["che"]["github"]; !ok {
+		t.Error("Identity provider 'github' deleted")
+	}
+}
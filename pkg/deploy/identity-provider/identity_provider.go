@@ -16,31 +16,44 @@ import (
 	"fmt"
 	"strings"
 
-	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"github.com/eclipse-che/che-operator/pkg/deploy"
 	"github.com/eclipse-che/che-operator/pkg/deploy/expose"
+	"github.com/eclipse-che/che-operator/pkg/metrics"
 	"github.com/eclipse-che/che-operator/pkg/util"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	oauth "github.com/openshift/api/oauth/v1"
+	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 var (
 	oAuthClientDiffOpts = cmpopts.IgnoreFields(oauth.OAuthClient{}, "TypeMeta", "ObjectMeta")
-	syncItems           = []func(*deploy.DeployContext) (bool, error){
+	// objectSyncItems sync the Kubernetes objects of Keycloak
+	objectSyncItems = []func(*deploy.DeployContext) (bool, error){
 		syncService,
 		syncExposure,
 		SyncKeycloakDeploymentToCluster,
+	}
+	// the next sync items provision Keycloak through its REST API
+	syncItems = append(objectSyncItems,
 		syncKeycloakResources,
+		syncRealmConfig,
+		syncLdapUserFederation,
 		syncOpenShiftIdentityProvider,
 		SyncGitHubOAuth,
-	}
+		syncIdentityBrokers,
+	)
 )
 
 // SyncIdentityProviderToCluster instantiates the identity provider (Keycloak) in the cluster. Returns true if
 // the provisioning is complete, false if requeue of the reconcile request is needed.
 func SyncIdentityProviderToCluster(deployContext *deploy.DeployContext) (bool, error) {
+	if err := clearOpenIdConnectProviderStatus(deployContext); err != nil {
+		return false, err
+	}
+
 	cr := deployContext.CheCluster
 	if cr.Spec.Auth.ExternalIdentityProvider {
 		return true, nil
@@ -51,7 +64,12 @@ func SyncIdentityProviderToCluster(deployContext *deploy.DeployContext) (bool, e
 		return deploy.DeleteNamespacedObject(deployContext, deploy.IdentityProviderName, &appsv1.Deployment{})
 	}
 
-	for _, syncItem := range syncItems {
+	items := syncItems
+	if deployContext.DryRun {
+		// a dry run only plans the changes to the Kubernetes objects
+		items = objectSyncItems
+	}
+	for _, syncItem := range items {
 		provisioned, err := syncItem(deployContext)
 		if !util.IsTestMode() {
 			if !provisioned {
@@ -94,12 +112,9 @@ func syncExposure(deployContext *deploy.DeployContext) (bool, error) {
 	keycloakURL := protocol + "://" + endpoint
 	deployContext.InternalService.KeycloakHost = fmt.Sprintf("%s://%s.%s.svc:%d", "http", deploy.IdentityProviderName, cr.Namespace, 8080)
 
-	if cr.Spec.Auth.IdentityProviderURL != keycloakURL {
-		cr.Spec.Auth.IdentityProviderURL = keycloakURL
-		if err := deploy.UpdateCheCRSpec(deployContext, "Keycloak URL", keycloakURL); err != nil {
-			return false, err
-		}
-
+	// the URL is kept in the status only, the spec is set in memory
+	cr.Spec.Auth.IdentityProviderURL = keycloakURL
+	if cr.Status.KeycloakURL != keycloakURL {
 		cr.Status.KeycloakURL = keycloakURL
 		if err := deploy.UpdateCheCRStatus(deployContext, "Keycloak URL", keycloakURL); err != nil {
 			return false, err
@@ -114,6 +129,7 @@ func syncKeycloakResources(deployContext *deploy.DeployContext) (bool, error) {
 		cr := deployContext.CheCluster
 		if !cr.Status.KeycloakProvisoned {
 			if err := ProvisionKeycloakResources(deployContext); err != nil {
+				metrics.RecordProvisioningFailure(metrics.ProvisioningKeycloak)
 				return false, err
 			}
 
@@ -122,7 +138,7 @@ func syncKeycloakResources(deployContext *deploy.DeployContext) (bool, error) {
 				if err := deploy.UpdateCheCRStatus(deployContext, "status: provisioned with Keycloak", "true"); err != nil &&
 					apierrors.IsConflict(err) {
 
-					util.ReloadCheCluster(deployContext.ClusterAPI.Client, deployContext.CheCluster)
+					deploy.ReloadCheCluster(deployContext)
 					continue
 				}
 				break
@@ -148,18 +164,30 @@ func SyncOpenShiftIdentityProviderItems(deployContext *deploy.DeployContext) (bo
 	if len(oAuthClientName) < 1 {
 		oAuthClientName = cr.Name + "-openshift-identity-provider-" + strings.ToLower(util.GeneratePasswd(6))
 		cr.Spec.Auth.OAuthClientName = oAuthClientName
-		if err := deploy.UpdateCheCRSpec(deployContext, "oAuthClient name", oAuthClientName); err != nil {
+	}
+	// the name is kept in the status, so it can be omitted in the spec, including
+	// the CheClusters where it was written into the spec by the previous Operator versions
+	if cr.Status.OpenShiftOAuthClientName != oAuthClientName {
+		cr.Status.OpenShiftOAuthClientName = oAuthClientName
+		if err := deploy.UpdateCheCRStatus(deployContext, "oAuthClient name", oAuthClientName); err != nil {
 			return false, err
 		}
 	}
 
 	oauthSecret := cr.Spec.Auth.OAuthSecret
 	if len(oauthSecret) < 1 {
-		oauthSecret = util.GeneratePasswd(12)
-		cr.Spec.Auth.OAuthSecret = oauthSecret
-		if err := deploy.UpdateCheCRSpec(deployContext, "oAuth secret name", oauthSecret); err != nil {
+		// reuse the secret of the existing OAuthClient, it isn't stored anywhere else
+		existingOAuthClient := &oauth.OAuthClient{}
+		exists, err := deploy.GetClusterObject(deployContext, oAuthClientName, existingOAuthClient)
+		if err != nil {
 			return false, err
 		}
+		if exists {
+			oauthSecret = existingOAuthClient.Secret
+		} else {
+			oauthSecret = util.GeneratePasswd(12)
+		}
+		cr.Spec.Auth.OAuthSecret = oauthSecret
 	}
 
 	keycloakURL := cr.Spec.Auth.IdentityProviderURL
@@ -171,27 +199,32 @@ func SyncOpenShiftIdentityProviderItems(deployContext *deploy.DeployContext) (bo
 		return false, err
 	}
 
-	if !util.IsTestMode() {
+	// a dry run, including the offline rendering, doesn't provision Keycloak
+	if !util.IsTestMode() && !deployContext.DryRun {
 		if !cr.Status.OpenShiftoAuthProvisioned {
 			// note that this uses the instance.Spec.Auth.IdentityProviderRealm and instance.Spec.Auth.IdentityProviderClientId.
 			// because we're not doing much of a change detection on those fields, we can't react on them changing here.
-			_, err := util.K8sclient.ExecIntoPod(
-				cr,
-				deploy.IdentityProviderName,
-				func(cr *orgv1.CheCluster) (string, error) {
-					return GetOpenShiftIdentityProviderProvisionCommand(cr, oAuthClientName, oauthSecret)
-				},
-				"Create OpenShift identity provider")
+			providerId := (map[bool]string{true: "openshift-v4", false: "openshift-v3"})[util.IsOpenShift4]
+			openShiftApiUrl, err := util.GetClusterPublicHostname(util.IsOpenShift4)
+			if err != nil {
+				logrus.Errorf("Failed to auto-detect public OpenShift API URL. Configure it in Identity provider details page in Keycloak admin console: %s", err)
+				return false, err
+			}
+
+			client, err := newKeycloakAdminClient(deployContext)
 			if err != nil {
 				return false, err
 			}
+			if err := provisionOpenShiftIdentityProvider(client, cr, providerId, oAuthClientName, oauthSecret, openShiftApiUrl); err != nil {
+				return false, err
+			}
 
 			for {
 				cr.Status.OpenShiftoAuthProvisioned = true
 				if err := deploy.UpdateCheCRStatus(deployContext, "status: provisioned with OpenShift identity provider", "true"); err != nil &&
 					apierrors.IsConflict(err) {
 
-					util.ReloadCheCluster(deployContext.ClusterAPI.Client, deployContext.CheCluster)
+					deploy.ReloadCheCluster(deployContext)
 					continue
 				}
 				break
@@ -235,16 +268,20 @@ func SyncGitHubOAuth(deployContext *deploy.DeployContext) (bool, error) {
 	if isGitHubOAuthCredentialsExists {
 		if !cr.Status.GitHubOAuthProvisioned {
 			if !util.IsTestMode() {
-				_, err := util.K8sclient.ExecIntoPod(
-					cr,
-					deploy.IdentityProviderName,
-					func(cr *orgv1.CheCluster) (string, error) {
-						return GetGitHubIdentityProviderCreateCommand(deployContext)
-					},
-					"Create GitHub OAuth")
+				secret := append(secrets, legacySecrets...)[0]
+				clientId, clientSecret, err := getGitHubOAuthCredentials(&secret)
 				if err != nil {
 					return false, err
 				}
+
+				client, err := newKeycloakAdminClient(deployContext)
+				if err != nil {
+					return false, err
+				}
+				keycloakRealm, _ := getKeycloakRealmAndClientId(cr)
+				if err := provisionGitHubIdentityProvider(client, keycloakRealm, clientId, clientSecret); err != nil {
+					return false, err
+				}
 			}
 
 			cr.Status.GitHubOAuthProvisioned = true
@@ -255,14 +292,7 @@ func SyncGitHubOAuth(deployContext *deploy.DeployContext) (bool, error) {
 	} else {
 		if cr.Status.GitHubOAuthProvisioned {
 			if !util.IsTestMode() {
-				_, err := util.K8sclient.ExecIntoPod(
-					cr,
-					deploy.IdentityProviderName,
-					func(cr *orgv1.CheCluster) (string, error) {
-						return GetIdentityProviderDeleteCommand(cr, "github")
-					},
-					"Delete GitHub OAuth")
-				if err != nil {
+				if err := DeleteIdentityProvider(deployContext, "github"); err != nil {
 					return false, err
 				}
 			}
@@ -276,3 +306,26 @@ func SyncGitHubOAuth(deployContext *deploy.DeployContext) (bool, error) {
 
 	return true, nil
 }
+
+// getGitHubOAuthCredentials returns the credentials of GitHub OAuth application stored in the secret.
+// The legacy secrets are mounted into Keycloak container as environment variables,
+// so the keys are found by the names of the variables in the annotations.
+func getGitHubOAuthCredentials(secret *corev1.Secret) (string, string, error) {
+	clientId := string(secret.Data["id"])
+	clientSecret := string(secret.Data["secret"])
+	if secret.Annotations[deploy.CheEclipseOrgGithubOAuthCredentials] == "true" {
+		for key, value := range secret.Data {
+			switch secret.Annotations[deploy.CheEclipseOrg+"/"+key+"_env-name"] {
+			case "GITHUB_CLIENT_ID":
+				clientId = string(value)
+			case "GITHUB_SECRET":
+				clientSecret = string(value)
+			}
+		}
+	}
+
+	if clientId == "" || clientSecret == "" {
+		return "", "", fmt.Errorf("GitHub OAuth client id or secret not found in the secret '%s'", secret.Name)
+	}
+	return clientId, clientSecret, nil
+}
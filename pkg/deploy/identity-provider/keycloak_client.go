@@ -0,0 +1,543 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	keycloakRequestTimeout = 30 * time.Second
+	keycloakAdminClientId  = "admin-cli"
+	keycloakMasterRealm    = "master"
+)
+
+// KeycloakError is an error response of Keycloak admin REST API.
+type KeycloakError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	Message    string
+}
+
+func (e *KeycloakError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// IsKeycloakNotFound checks if the error is reported by Keycloak because the object doesn't exist.
+func IsKeycloakNotFound(err error) bool {
+	return isKeycloakStatusCode(err, http.StatusNotFound)
+}
+
+// IsKeycloakConflict checks if the error is reported by Keycloak because the object already exists.
+func IsKeycloakConflict(err error) bool {
+	return isKeycloakStatusCode(err, http.StatusConflict)
+}
+
+// IsKeycloakUnauthorized checks if the error is reported by Keycloak because of invalid credentials.
+func IsKeycloakUnauthorized(err error) bool {
+	return isKeycloakStatusCode(err, http.StatusUnauthorized)
+}
+
+func isKeycloakStatusCode(err error, statusCode int) bool {
+	keycloakError, ok := err.(*KeycloakError)
+	return ok && keycloakError.StatusCode == statusCode
+}
+
+type RealmRepresentation struct {
+	Id                   string `json:"id,omitempty"`
+	Realm                string `json:"realm"`
+	DisplayName          string `json:"displayName,omitempty"`
+	Enabled              bool   `json:"enabled"`
+	SslRequired          string `json:"sslRequired,omitempty"`
+	RegistrationAllowed  bool   `json:"registrationAllowed"`
+	ResetPasswordAllowed bool   `json:"resetPasswordAllowed"`
+	LoginTheme           string `json:"loginTheme,omitempty"`
+	AccountTheme         string `json:"accountTheme,omitempty"`
+	AdminTheme           string `json:"adminTheme,omitempty"`
+	EmailTheme           string `json:"emailTheme,omitempty"`
+}
+
+type ClientRepresentation struct {
+	Id                        string   `json:"id,omitempty"`
+	ClientId                  string   `json:"clientId"`
+	WebOrigins                []string `json:"webOrigins,omitempty"`
+	RedirectUris              []string `json:"redirectUris,omitempty"`
+	DirectAccessGrantsEnabled bool     `json:"directAccessGrantsEnabled"`
+	PublicClient              bool     `json:"publicClient"`
+	DefaultRoles              []string `json:"defaultRoles,omitempty"`
+}
+
+type UserRepresentation struct {
+	Id              string   `json:"id,omitempty"`
+	Username        string   `json:"username"`
+	Email           string   `json:"email,omitempty"`
+	Enabled         bool     `json:"enabled"`
+	RequiredActions []string `json:"requiredActions"`
+}
+
+type CredentialRepresentation struct {
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Temporary bool   `json:"temporary"`
+}
+
+type RoleRepresentation struct {
+	Id          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Composite   bool   `json:"composite"`
+	ClientRole  bool   `json:"clientRole"`
+	ContainerId string `json:"containerId,omitempty"`
+}
+
+type IdentityProviderRepresentation struct {
+	Alias                    string            `json:"alias"`
+	InternalId               string            `json:"internalId,omitempty"`
+	ProviderId               string            `json:"providerId"`
+	DisplayName              string            `json:"displayName,omitempty"`
+	Enabled                  bool              `json:"enabled"`
+	StoreToken               bool              `json:"storeToken"`
+	AddReadTokenRoleOnCreate bool              `json:"addReadTokenRoleOnCreate"`
+	Config                   map[string]string `json:"config,omitempty"`
+}
+
+type AuthenticationExecutionInfoRepresentation struct {
+	Id                   string `json:"id"`
+	ProviderId           string `json:"providerId,omitempty"`
+	Alias                string `json:"alias,omitempty"`
+	AuthenticationConfig string `json:"authenticationConfig,omitempty"`
+}
+
+type AuthenticatorConfigRepresentation struct {
+	Alias  string            `json:"alias"`
+	Config map[string]string `json:"config"`
+}
+
+type ManagementPermissionReference struct {
+	Enabled          bool              `json:"enabled"`
+	Resource         string            `json:"resource,omitempty"`
+	ScopePermissions map[string]string `json:"scopePermissions,omitempty"`
+}
+
+type ClientPolicyRepresentation struct {
+	Id               string   `json:"id,omitempty"`
+	Name             string   `json:"name"`
+	Type             string   `json:"type"`
+	Logic            string   `json:"logic"`
+	DecisionStrategy string   `json:"decisionStrategy"`
+	Clients          []string `json:"clients"`
+}
+
+type ScopeRepresentation struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ComponentRepresentation is a component of a realm, such as a user federation provider or its mapper.
+type ComponentRepresentation struct {
+	Id           string              `json:"id,omitempty"`
+	Name         string              `json:"name"`
+	ProviderId   string              `json:"providerId"`
+	ProviderType string              `json:"providerType"`
+	ParentId     string              `json:"parentId"`
+	Config       map[string][]string `json:"config"`
+}
+
+// KeycloakClient is a client of Keycloak admin REST API.
+// It logs in to the master realm with the administrator credentials.
+type KeycloakClient struct {
+	url        string
+	user       string
+	password   string
+	token      string
+	httpClient *http.Client
+}
+
+// NewKeycloakClient creates a client of Keycloak available at the given URL, for example `http://keycloak:8080/auth`.
+func NewKeycloakClient(keycloakURL string, user string, password string) *KeycloakClient {
+	return &KeycloakClient{
+		url:        strings.TrimSuffix(keycloakURL, "/"),
+		user:       user,
+		password:   password,
+		httpClient: &http.Client{Timeout: keycloakRequestTimeout},
+	}
+}
+
+// Login obtains an access token of the administrator.
+func (c *KeycloakClient) Login() error {
+	path := "/realms/" + keycloakMasterRealm + "/protocol/openid-connect/token"
+	form := url.Values{
+		"grant_type": {"password"},
+		"client_id":  {keycloakAdminClientId},
+		"username":   {c.user},
+		"password":   {c.password},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, c.url+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := c.send(request, path, &token); err != nil {
+		return err
+	}
+	if token.AccessToken == "" {
+		return &KeycloakError{Method: http.MethodPost, Path: path, Message: "no access token in the response"}
+	}
+
+	c.token = token.AccessToken
+	return nil
+}
+
+// GetRealm returns the realm, or nil if it doesn't exist.
+func (c *KeycloakClient) GetRealm(realm string) (*RealmRepresentation, error) {
+	result := &RealmRepresentation{}
+	if err := c.get("/realms/"+url.PathEscape(realm), result); err != nil {
+		if IsKeycloakNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetRealmAttributes returns all the attributes of the realm, including the ones
+// not covered by RealmRepresentation, or nil if the realm doesn't exist.
+func (c *KeycloakClient) GetRealmAttributes(realm string) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if err := c.get("/realms/"+url.PathEscape(realm), &result); err != nil {
+		if IsKeycloakNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *KeycloakClient) CreateRealm(realm *RealmRepresentation) error {
+	return c.do(http.MethodPost, "/realms", realm, nil)
+}
+
+// UpdateRealm updates the realm attributes set in the representation, other attributes are left as is.
+func (c *KeycloakClient) UpdateRealm(realm string, representation interface{}) error {
+	return c.do(http.MethodPut, "/realms/"+url.PathEscape(realm), representation, nil)
+}
+
+// GetClient returns the client with the given client id, or nil if it doesn't exist.
+func (c *KeycloakClient) GetClient(realm string, clientId string) (*ClientRepresentation, error) {
+	clients := []ClientRepresentation{}
+	if err := c.get(realmPath(realm, "clients?clientId="+url.QueryEscape(clientId)), &clients); err != nil {
+		return nil, err
+	}
+	for i := range clients {
+		if clients[i].ClientId == clientId {
+			return &clients[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// GetClientAttributes returns all the attributes of the client with the given client id,
+// including the ones not covered by ClientRepresentation, or nil if the client doesn't exist.
+func (c *KeycloakClient) GetClientAttributes(realm string, clientId string) (map[string]interface{}, error) {
+	clients := []map[string]interface{}{}
+	if err := c.get(realmPath(realm, "clients?clientId="+url.QueryEscape(clientId)), &clients); err != nil {
+		return nil, err
+	}
+	for _, client := range clients {
+		if client["clientId"] == clientId {
+			return client, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *KeycloakClient) CreateClient(realm string, client *ClientRepresentation) error {
+	return c.do(http.MethodPost, realmPath(realm, "clients"), client, nil)
+}
+
+// UpdateClient updates the client attributes set in the representation, other attributes are left as is.
+func (c *KeycloakClient) UpdateClient(realm string, id string, representation interface{}) error {
+	return c.do(http.MethodPut, realmPath(realm, "clients/"+url.PathEscape(id)), representation, nil)
+}
+
+// GetClientRole returns the role of the client identified by its internal id, or nil if it doesn't exist.
+func (c *KeycloakClient) GetClientRole(realm string, id string, role string) (*RoleRepresentation, error) {
+	result := &RoleRepresentation{}
+	if err := c.get(realmPath(realm, "clients/"+url.PathEscape(id)+"/roles/"+url.PathEscape(role)), result); err != nil {
+		if IsKeycloakNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetUser returns the user with the given username, or nil if it doesn't exist.
+func (c *KeycloakClient) GetUser(realm string, username string) (*UserRepresentation, error) {
+	users := []UserRepresentation{}
+	if err := c.get(realmPath(realm, "users?username="+url.QueryEscape(username)), &users); err != nil {
+		return nil, err
+	}
+	for i := range users {
+		if users[i].Username == strings.ToLower(username) {
+			return &users[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *KeycloakClient) CreateUser(realm string, user *UserRepresentation) error {
+	return c.do(http.MethodPost, realmPath(realm, "users"), user, nil)
+}
+
+// ResetUserPassword sets the password of the user identified by its id.
+func (c *KeycloakClient) ResetUserPassword(realm string, userId string, password string, temporary bool) error {
+	credential := &CredentialRepresentation{Type: "password", Value: password, Temporary: temporary}
+	return c.do(http.MethodPut, realmPath(realm, "users/"+url.PathEscape(userId)+"/reset-password"), credential, nil)
+}
+
+// AddUserClientRoles maps the roles of the client identified by its internal id to the user.
+func (c *KeycloakClient) AddUserClientRoles(realm string, userId string, id string, roles []RoleRepresentation) error {
+	return c.do(http.MethodPost, realmPath(realm, "users/"+url.PathEscape(userId)+"/role-mappings/clients/"+url.PathEscape(id)), roles, nil)
+}
+
+// GetIdentityProvider returns the identity provider, or nil if it doesn't exist.
+func (c *KeycloakClient) GetIdentityProvider(realm string, alias string) (*IdentityProviderRepresentation, error) {
+	result := &IdentityProviderRepresentation{}
+	if err := c.get(realmPath(realm, "identity-provider/instances/"+url.PathEscape(alias)), result); err != nil {
+		if IsKeycloakNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *KeycloakClient) CreateIdentityProvider(realm string, identityProvider *IdentityProviderRepresentation) error {
+	return c.do(http.MethodPost, realmPath(realm, "identity-provider/instances"), identityProvider, nil)
+}
+
+func (c *KeycloakClient) UpdateIdentityProvider(realm string, identityProvider *IdentityProviderRepresentation) error {
+	return c.do(http.MethodPut, realmPath(realm, "identity-provider/instances/"+url.PathEscape(identityProvider.Alias)), identityProvider, nil)
+}
+
+func (c *KeycloakClient) DeleteIdentityProvider(realm string, alias string) error {
+	return c.do(http.MethodDelete, realmPath(realm, "identity-provider/instances/"+url.PathEscape(alias)), nil, nil)
+}
+
+// ImportIdentityProviderConfig returns the configuration of the identity provider of the given type,
+// `oidc` or `saml`, read by Keycloak from the OpenID Connect discovery document, or the SAML metadata, at the given URL.
+func (c *KeycloakClient) ImportIdentityProviderConfig(realm string, providerId string, fromUrl string) (map[string]string, error) {
+	config := map[string]string{}
+	body := map[string]string{"providerId": providerId, "fromUrl": fromUrl}
+	err := c.do(http.MethodPost, realmPath(realm, "identity-provider/import-config"), body, &config)
+	return config, err
+}
+
+// EnableIdentityProviderPermissions enables fine-grained permissions on the identity provider
+// and returns the references to the permissions.
+func (c *KeycloakClient) EnableIdentityProviderPermissions(realm string, alias string) (*ManagementPermissionReference, error) {
+	result := &ManagementPermissionReference{}
+	err := c.do(http.MethodPut, realmPath(realm, "identity-provider/instances/"+url.PathEscape(alias)+"/management/permissions"), &ManagementPermissionReference{Enabled: true}, result)
+	return result, err
+}
+
+func (c *KeycloakClient) GetFlowExecutions(realm string, flow string) ([]AuthenticationExecutionInfoRepresentation, error) {
+	executions := []AuthenticationExecutionInfoRepresentation{}
+	err := c.get(realmPath(realm, "authentication/flows/"+url.PathEscape(flow)+"/executions"), &executions)
+	return executions, err
+}
+
+func (c *KeycloakClient) CreateExecutionConfig(realm string, executionId string, config *AuthenticatorConfigRepresentation) error {
+	return c.do(http.MethodPost, realmPath(realm, "authentication/executions/"+url.PathEscape(executionId)+"/config"), config, nil)
+}
+
+// GetClientPolicy returns the client policy of the resource server identified by its internal id, or nil if it doesn't exist.
+func (c *KeycloakClient) GetClientPolicy(realm string, id string, name string) (*ClientPolicyRepresentation, error) {
+	policies := []ClientPolicyRepresentation{}
+	if err := c.get(resourceServerPath(realm, id, "policy/client?name="+url.QueryEscape(name)), &policies); err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		if policies[i].Name == name {
+			return &policies[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *KeycloakClient) CreateClientPolicy(realm string, id string, policy *ClientPolicyRepresentation) error {
+	return c.do(http.MethodPost, resourceServerPath(realm, id, "policy/client"), policy, nil)
+}
+
+// GetScopePermission returns the scope permission of the resource server identified by its internal id.
+// The permission is returned as is, so it can be updated without losing attributes.
+func (c *KeycloakClient) GetScopePermission(realm string, id string, permissionId string) (map[string]interface{}, error) {
+	permission := map[string]interface{}{}
+	err := c.get(resourceServerPath(realm, id, "permission/scope/"+url.PathEscape(permissionId)), &permission)
+	return permission, err
+}
+
+func (c *KeycloakClient) UpdateScopePermission(realm string, id string, permissionId string, permission map[string]interface{}) error {
+	return c.do(http.MethodPut, resourceServerPath(realm, id, "permission/scope/"+url.PathEscape(permissionId)), permission, nil)
+}
+
+func (c *KeycloakClient) GetResourceScopes(realm string, id string, resourceId string) ([]ScopeRepresentation, error) {
+	scopes := []ScopeRepresentation{}
+	err := c.get(resourceServerPath(realm, id, "resource/"+url.PathEscape(resourceId)+"/scopes"), &scopes)
+	return scopes, err
+}
+
+// GetComponents returns the components of the given type which are children of the given parent.
+func (c *KeycloakClient) GetComponents(realm string, parentId string, providerType string) ([]ComponentRepresentation, error) {
+	components := []ComponentRepresentation{}
+	query := "components?parent=" + url.QueryEscape(parentId) + "&type=" + url.QueryEscape(providerType)
+	err := c.get(realmPath(realm, query), &components)
+	return components, err
+}
+
+// GetComponent returns the component, or nil if it doesn't exist.
+func (c *KeycloakClient) GetComponent(realm string, id string) (*ComponentRepresentation, error) {
+	result := &ComponentRepresentation{}
+	if err := c.get(realmPath(realm, "components/"+url.PathEscape(id)), result); err != nil {
+		if IsKeycloakNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *KeycloakClient) CreateComponent(realm string, component *ComponentRepresentation) error {
+	return c.do(http.MethodPost, realmPath(realm, "components"), component, nil)
+}
+
+func (c *KeycloakClient) UpdateComponent(realm string, component *ComponentRepresentation) error {
+	return c.do(http.MethodPut, realmPath(realm, "components/"+url.PathEscape(component.Id)), component, nil)
+}
+
+func (c *KeycloakClient) DeleteComponent(realm string, id string) error {
+	return c.do(http.MethodDelete, realmPath(realm, "components/"+url.PathEscape(id)), nil, nil)
+}
+
+// TriggerUserStorageSync starts the synchronization of the users of the user federation provider,
+// `triggerFullSync` or `triggerChangedUsersSync`.
+func (c *KeycloakClient) TriggerUserStorageSync(realm string, id string, action string) error {
+	return c.do(http.MethodPost, realmPath(realm, "user-storage/"+url.PathEscape(id)+"/sync?action="+url.QueryEscape(action)), nil, nil)
+}
+
+func (c *KeycloakClient) get(path string, result interface{}) error {
+	return c.do(http.MethodGet, path, nil, result)
+}
+
+// do sends the request to the admin REST API. The client logs in first if needed,
+// and once again if the access token has expired.
+func (c *KeycloakClient) do(method string, path string, body interface{}, result interface{}) error {
+	if c.token == "" {
+		if err := c.Login(); err != nil {
+			return err
+		}
+	}
+
+	err := c.doAuthorized(method, path, body, result)
+	if IsKeycloakUnauthorized(err) {
+		if err := c.Login(); err != nil {
+			return err
+		}
+		err = c.doAuthorized(method, path, body, result)
+	}
+	return err
+}
+
+func (c *KeycloakClient) doAuthorized(method string, path string, body interface{}, result interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	request, err := http.NewRequest(method, c.url+"/admin"+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+c.token)
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(request, path, result)
+}
+
+func (c *KeycloakClient) send(request *http.Request, path string, result interface{}) error {
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return &KeycloakError{
+			StatusCode: response.StatusCode,
+			Method:     request.Method,
+			Path:       strings.Split(path, "?")[0],
+			Message:    getKeycloakErrorMessage(data),
+		}
+	}
+
+	if result != nil && len(data) != 0 {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse response of %s %s: %v", request.Method, path, err)
+		}
+	}
+	return nil
+}
+
+// getKeycloakErrorMessage extracts the message from the error response.
+// Keycloak reports it in different fields depending on the endpoint.
+func getKeycloakErrorMessage(data []byte) string {
+	response := map[string]interface{}{}
+	if err := json.Unmarshal(data, &response); err == nil {
+		for _, key := range []string{"errorMessage", "error_description", "error"} {
+			if message, ok := response[key].(string); ok && message != "" {
+				return message
+			}
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func realmPath(realm string, path string) string {
+	return "/realms/" + url.PathEscape(realm) + "/" + path
+}
+
+func resourceServerPath(realm string, id string, path string) string {
+	return realmPath(realm, "clients/"+url.PathEscape(id)+"/authz/resource-server/"+path)
+}
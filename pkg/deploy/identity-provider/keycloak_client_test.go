@@ -0,0 +1,448 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testAdminUser     = "admin"
+	testAdminPassword = "secret"
+)
+
+// fakeKeycloak is an in-process server which implements the part of Keycloak admin REST API used by the Operator.
+// Objects are kept in memory, and the requests which modify them are recorded.
+type fakeKeycloak struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	password string
+	tokens   map[string]bool
+	nextId   int
+
+	realms            map[string]map[string]interface{}
+	clients           map[string][]*ClientRepresentation
+	clientAttributes  map[string]map[string]interface{}
+	clientRoles       map[string][]RoleRepresentation
+	users             map[string][]*UserRepresentation
+	passwords         map[string]string
+	userRoles         map[string][]string
+	identityProviders map[string]map[string]*IdentityProviderRepresentation
+	executionConfigs  map[string]*AuthenticatorConfigRepresentation
+	policies          []*ClientPolicyRepresentation
+	permission        map[string]interface{}
+	components        map[string][]*ComponentRepresentation
+	importedConfigs   map[string]map[string]string
+	userStorageSyncs  []string
+	modifications     []string
+}
+
+func newFakeKeycloak() *fakeKeycloak {
+	keycloak := &fakeKeycloak{
+		password:          testAdminPassword,
+		tokens:            map[string]bool{},
+		realms:            map[string]map[string]interface{}{},
+		clients:           map[string][]*ClientRepresentation{},
+		clientAttributes:  map[string]map[string]interface{}{},
+		clientRoles:       map[string][]RoleRepresentation{},
+		users:             map[string][]*UserRepresentation{},
+		passwords:         map[string]string{},
+		userRoles:         map[string][]string{},
+		identityProviders: map[string]map[string]*IdentityProviderRepresentation{},
+		executionConfigs:  map[string]*AuthenticatorConfigRepresentation{},
+		permission:        map[string]interface{}{"id": "token-exchange-permission", "name": "token-exchange.permission"},
+		components:        map[string][]*ComponentRepresentation{},
+		importedConfigs:   map[string]map[string]string{},
+	}
+	keycloak.createRealm(map[string]interface{}{"realm": "master", "sslRequired": "external"})
+	keycloak.users["master"] = []*UserRepresentation{{Id: "master-admin", Username: testAdminUser, Enabled: true}}
+	keycloak.passwords["master-admin"] = testAdminPassword
+	keycloak.server = httptest.NewServer(http.HandlerFunc(keycloak.handle))
+	return keycloak
+}
+
+func (k *fakeKeycloak) close() {
+	k.server.Close()
+}
+
+func (k *fakeKeycloak) url() string {
+	return k.server.URL + "/auth"
+}
+
+func (k *fakeKeycloak) newClient() *KeycloakClient {
+	return NewKeycloakClient(k.url(), testAdminUser, testAdminPassword)
+}
+
+// createRealm adds the realm along with the built-in clients and flows
+func (k *fakeKeycloak) createRealm(realm map[string]interface{}) {
+	name := realm["realm"].(string)
+	if _, ok := realm["id"]; !ok {
+		realm["id"] = name
+	}
+	k.realms[name] = realm
+	k.clients[name] = []*ClientRepresentation{
+		{Id: name + "-broker", ClientId: keycloakBrokerClientId},
+		{Id: name + "-realm-management", ClientId: keycloakRealmManagement},
+	}
+	k.clientRoles[name+"-broker"] = []RoleRepresentation{{Id: name + "-read-token", Name: keycloakReadTokenRole, ClientRole: true}}
+	k.identityProviders[name] = map[string]*IdentityProviderRepresentation{}
+}
+
+// getClientAttributes returns the attributes of the client not covered by ClientRepresentation,
+// along with the ones of the representation.
+func (k *fakeKeycloak) getClientAttributes(client *ClientRepresentation) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for key, value := range k.clientAttributes[client.Id] {
+		attributes[key] = value
+	}
+	data, _ := json.Marshal(client)
+	json.Unmarshal(data, &attributes)
+	return attributes
+}
+
+func (k *fakeKeycloak) generateId() string {
+	k.nextId++
+	return fmt.Sprintf("id-%d", k.nextId)
+}
+
+func (k *fakeKeycloak) handle(w http.ResponseWriter, r *http.Request) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if r.URL.Path == "/auth/realms/master/protocol/openid-connect/token" {
+		r.ParseForm()
+		if r.Form.Get("client_id") != keycloakAdminClientId || r.Form.Get("username") != testAdminUser || r.Form.Get("password") != k.passwords["master-admin"] {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_grant", "error_description": "Invalid user credentials"})
+			return
+		}
+		token := k.generateId()
+		k.tokens[token] = true
+		writeJSON(w, http.StatusOK, map[string]string{"access_token": token})
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, "/auth/admin/realms") {
+		writeJSON(w, http.StatusNotFound, nil)
+		return
+	}
+	if !k.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "HTTP 401 Unauthorized"})
+		return
+	}
+	if r.Method != http.MethodGet {
+		k.modifications = append(k.modifications, r.Method+" "+r.URL.Path)
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/auth/admin/realms"), "/")
+	if path == "" && r.Method == http.MethodPost {
+		realm := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&realm)
+		if _, ok := k.realms[realm["realm"].(string)]; ok {
+			writeJSON(w, http.StatusConflict, map[string]string{"errorMessage": "Conflict detected. See logs for details"})
+			return
+		}
+		k.createRealm(realm)
+		writeJSON(w, http.StatusCreated, nil)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	realm, ok := k.realms[parts[0]]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Realm not found."})
+		return
+	}
+	if len(parts) == 1 {
+		k.handleRealm(w, r, realm)
+		return
+	}
+
+	status, result := k.handleRealmResource(r, parts[0], strings.Join(parts[1:], "/"))
+	writeJSON(w, status, result)
+}
+
+func (k *fakeKeycloak) handleRealm(w http.ResponseWriter, r *http.Request, realm map[string]interface{}) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, realm)
+	case http.MethodPut:
+		update := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&update)
+		for key, value := range update {
+			realm[key] = value
+		}
+		writeJSON(w, http.StatusNoContent, nil)
+	}
+}
+
+func (k *fakeKeycloak) handleRealmResource(r *http.Request, realm string, path string) (int, interface{}) {
+	notFound := map[string]string{"error": "Could not find resource"}
+	parts := strings.Split(path, "/")
+	query := r.URL.Query()
+
+	switch {
+	case path == "clients" && r.Method == http.MethodGet:
+		result := []map[string]interface{}{}
+		for _, client := range k.clients[realm] {
+			if client.ClientId == query.Get("clientId") {
+				result = append(result, k.getClientAttributes(client))
+			}
+		}
+		return http.StatusOK, result
+	case path == "clients" && r.Method == http.MethodPost:
+		client := &ClientRepresentation{}
+		json.NewDecoder(r.Body).Decode(client)
+		for _, existing := range k.clients[realm] {
+			if existing.ClientId == client.ClientId {
+				return http.StatusConflict, map[string]string{"errorMessage": "Client " + client.ClientId + " already exists"}
+			}
+		}
+		k.clients[realm] = append(k.clients[realm], client)
+		return http.StatusCreated, nil
+	case parts[0] == "clients" && len(parts) == 2 && r.Method == http.MethodPut:
+		for _, client := range k.clients[realm] {
+			if client.Id == parts[1] {
+				attributes := k.getClientAttributes(client)
+				json.NewDecoder(r.Body).Decode(&attributes)
+				k.clientAttributes[client.Id] = attributes
+				data, _ := json.Marshal(attributes)
+				*client = ClientRepresentation{}
+				json.Unmarshal(data, client)
+				return http.StatusNoContent, nil
+			}
+		}
+		return http.StatusNotFound, notFound
+	case parts[0] == "clients" && len(parts) == 4 && parts[2] == "roles":
+		for _, role := range k.clientRoles[parts[1]] {
+			if role.Name == parts[3] {
+				return http.StatusOK, role
+			}
+		}
+		return http.StatusNotFound, notFound
+	case parts[0] == "clients" && len(parts) > 3 && parts[2] == "authz":
+		return k.handleAuthorization(r, strings.Join(parts[4:], "/"))
+	case path == "users" && r.Method == http.MethodGet:
+		result := []*UserRepresentation{}
+		for _, user := range k.users[realm] {
+			if strings.Contains(user.Username, query.Get("username")) {
+				result = append(result, user)
+			}
+		}
+		return http.StatusOK, result
+	case path == "users" && r.Method == http.MethodPost:
+		user := &UserRepresentation{}
+		json.NewDecoder(r.Body).Decode(user)
+		user.Id = k.generateId()
+		k.users[realm] = append(k.users[realm], user)
+		return http.StatusCreated, nil
+	case parts[0] == "users" && len(parts) == 3 && parts[2] == "reset-password":
+		credential := &CredentialRepresentation{}
+		json.NewDecoder(r.Body).Decode(credential)
+		k.passwords[parts[1]] = credential.Value
+		return http.StatusNoContent, nil
+	case parts[0] == "users" && len(parts) == 5 && parts[2] == "role-mappings":
+		roles := []RoleRepresentation{}
+		json.NewDecoder(r.Body).Decode(&roles)
+		for _, role := range roles {
+			k.userRoles[parts[1]] = append(k.userRoles[parts[1]], role.Name)
+		}
+		return http.StatusNoContent, nil
+	case path == "identity-provider/instances" && r.Method == http.MethodPost:
+		identityProvider := &IdentityProviderRepresentation{}
+		json.NewDecoder(r.Body).Decode(identityProvider)
+		if _, ok := k.identityProviders[realm][identityProvider.Alias]; ok {
+			return http.StatusConflict, map[string]string{"errorMessage": "Identity Provider " + identityProvider.Alias + " already exists"}
+		}
+		identityProvider.InternalId = k.generateId()
+		k.identityProviders[realm][identityProvider.Alias] = identityProvider
+		return http.StatusCreated, nil
+	case path == "identity-provider/import-config" && r.Method == http.MethodPost:
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		config, ok := k.importedConfigs[body["fromUrl"]]
+		if !ok {
+			return http.StatusBadRequest, map[string]string{"errorMessage": "Could not import config"}
+		}
+		return http.StatusOK, config
+	case strings.HasPrefix(path, "identity-provider/instances/"):
+		identityProvider, ok := k.identityProviders[realm][parts[2]]
+		if !ok {
+			return http.StatusNotFound, notFound
+		}
+		if len(parts) == 5 && parts[3] == "management" {
+			return http.StatusOK, &ManagementPermissionReference{
+				Enabled:          true,
+				Resource:         "idp-resource",
+				ScopePermissions: map[string]string{keycloakTokenExchangeScope: "token-exchange-permission"},
+			}
+		}
+		switch r.Method {
+		case http.MethodPut:
+			*identityProvider = IdentityProviderRepresentation{}
+			json.NewDecoder(r.Body).Decode(identityProvider)
+			return http.StatusNoContent, nil
+		case http.MethodDelete:
+			delete(k.identityProviders[realm], parts[2])
+			return http.StatusNoContent, nil
+		}
+		return http.StatusOK, identityProvider
+	case path == "authentication/flows/browser/executions":
+		execution := AuthenticationExecutionInfoRepresentation{Id: "redirector", ProviderId: keycloakRedirectorProvider}
+		if config, ok := k.executionConfigs["redirector"]; ok {
+			execution.Alias = config.Alias
+			execution.AuthenticationConfig = "redirector-config"
+		}
+		return http.StatusOK, []AuthenticationExecutionInfoRepresentation{{Id: "cookie", ProviderId: "auth-cookie"}, execution}
+	case parts[0] == "authentication" && len(parts) == 4 && parts[3] == "config":
+		config := &AuthenticatorConfigRepresentation{}
+		json.NewDecoder(r.Body).Decode(config)
+		k.executionConfigs[parts[2]] = config
+		return http.StatusCreated, nil
+	case path == "components" && r.Method == http.MethodGet:
+		result := []*ComponentRepresentation{}
+		for _, component := range k.components[realm] {
+			if component.ParentId == query.Get("parent") && component.ProviderType == query.Get("type") {
+				result = append(result, component)
+			}
+		}
+		return http.StatusOK, result
+	case path == "components" && r.Method == http.MethodPost:
+		component := &ComponentRepresentation{}
+		json.NewDecoder(r.Body).Decode(component)
+		component.Id = k.generateId()
+		k.components[realm] = append(k.components[realm], component)
+		return http.StatusCreated, nil
+	case parts[0] == "components" && len(parts) == 2:
+		for i, component := range k.components[realm] {
+			if component.Id != parts[1] {
+				continue
+			}
+			switch r.Method {
+			case http.MethodPut:
+				json.NewDecoder(r.Body).Decode(component)
+				return http.StatusNoContent, nil
+			case http.MethodDelete:
+				k.components[realm] = append(k.components[realm][:i], k.components[realm][i+1:]...)
+				return http.StatusNoContent, nil
+			}
+			return http.StatusOK, component
+		}
+		return http.StatusNotFound, notFound
+	case parts[0] == "user-storage" && len(parts) == 3 && parts[2] == "sync":
+		k.userStorageSyncs = append(k.userStorageSyncs, parts[1]+" "+query.Get("action"))
+		return http.StatusOK, map[string]interface{}{"added": 0}
+	}
+	return http.StatusNotFound, notFound
+}
+
+func (k *fakeKeycloak) handleAuthorization(r *http.Request, path string) (int, interface{}) {
+	switch {
+	case path == "policy/client" && r.Method == http.MethodGet:
+		result := []*ClientPolicyRepresentation{}
+		for _, policy := range k.policies {
+			if strings.Contains(policy.Name, r.URL.Query().Get("name")) {
+				result = append(result, policy)
+			}
+		}
+		return http.StatusOK, result
+	case path == "policy/client" && r.Method == http.MethodPost:
+		policy := &ClientPolicyRepresentation{}
+		json.NewDecoder(r.Body).Decode(policy)
+		policy.Id = k.generateId()
+		k.policies = append(k.policies, policy)
+		return http.StatusCreated, policy
+	case path == "permission/scope/token-exchange-permission" && r.Method == http.MethodGet:
+		return http.StatusOK, k.permission
+	case path == "permission/scope/token-exchange-permission" && r.Method == http.MethodPut:
+		k.permission = map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&k.permission)
+		return http.StatusCreated, nil
+	case path == "resource/idp-resource/scopes":
+		return http.StatusOK, []ScopeRepresentation{{Id: "view-scope", Name: "view"}, {Id: "token-exchange-scope", Name: keycloakTokenExchangeScope}}
+	}
+	return http.StatusNotFound, map[string]string{"error": "Could not find resource"}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func TestKeycloakClientLogin(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	client := NewKeycloakClient(keycloak.url(), testAdminUser, "invalid")
+	err := client.Login()
+	if !IsKeycloakUnauthorized(err) {
+		t.Fatalf("Expected unauthorized error, but got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "Invalid user credentials") {
+		t.Errorf("Expected error message from Keycloak, but got: %v", err)
+	}
+
+	if err := keycloak.newClient().Login(); err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+}
+
+func TestKeycloakClientRelogin(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	client := keycloak.newClient()
+	if _, err := client.GetRealm("master"); err != nil {
+		t.Fatalf("Failed to get realm: %v", err)
+	}
+
+	// the access token expires
+	keycloak.tokens = map[string]bool{}
+
+	realm, err := client.GetRealm("master")
+	if err != nil {
+		t.Fatalf("Failed to get realm after token expiration: %v", err)
+	}
+	if realm == nil || realm.Realm != "master" {
+		t.Fatalf("Unexpected realm: %v", realm)
+	}
+}
+
+func TestKeycloakClientNotFound(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	client := keycloak.newClient()
+	realm, err := client.GetRealm("che")
+	if err != nil || realm != nil {
+		t.Fatalf("Expected no realm, but got: %v, %v", realm, err)
+	}
+
+	err = client.DeleteIdentityProvider("master", "github")
+	if !IsKeycloakNotFound(err) {
+		t.Fatalf("Expected not found error, but got: %v", err)
+	}
+	keycloakError := err.(*KeycloakError)
+	if keycloakError.Method != http.MethodDelete || keycloakError.Path != "/realms/master/identity-provider/instances/github" {
+		t.Errorf("Unexpected error details: %v", keycloakError)
+	}
+}
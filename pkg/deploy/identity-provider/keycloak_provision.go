@@ -0,0 +1,406 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"fmt"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	keycloakAdminUser          = "admin"
+	keycloakBrokerClientId     = "broker"
+	keycloakReadTokenRole      = "read-token"
+	keycloakRealmManagement    = "realm-management"
+	keycloakBrowserFlow        = "browser"
+	keycloakRedirectorProvider = "identity-provider-redirector"
+	keycloakTokenExchangeScope = "token-exchange"
+)
+
+var (
+	// newKeycloakAdminClient creates the client of Keycloak deployed by the Operator,
+	// logged in with the administrator credentials. Replaced in tests.
+	newKeycloakAdminClient = func(deployContext *deploy.DeployContext) (*KeycloakClient, error) {
+		user, password, err := getKeycloakAdminCredentials(deployContext)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeycloakClient(GetKeycloakInternalURL(deployContext.CheCluster), user, password), nil
+	}
+)
+
+// GetKeycloakInternalURL returns the URL of Keycloak deployed by the Operator, available inside the cluster.
+func GetKeycloakInternalURL(cr *orgv1.CheCluster) string {
+	return fmt.Sprintf("http://%s.%s.svc:8080/auth", deploy.IdentityProviderName, cr.Namespace)
+}
+
+// getKeycloakAdminCredentials returns the credentials of Keycloak administrator,
+// the same ones which are passed to the Keycloak container.
+func getKeycloakAdminCredentials(deployContext *deploy.DeployContext) (string, string, error) {
+	cr := deployContext.CheCluster
+	if cr.Spec.Auth.IdentityProviderSecret != "" {
+		secret, err := deploy.GetSecret(deployContext, cr.Spec.Auth.IdentityProviderSecret, cr.Namespace)
+		if err != nil {
+			return "", "", err
+		}
+		if secret == nil {
+			return "", "", fmt.Errorf("secret '%s' not found", cr.Spec.Auth.IdentityProviderSecret)
+		}
+		return string(secret.Data["user"]), string(secret.Data["password"]), nil
+	}
+	return cr.Spec.Auth.IdentityProviderAdminUserName, cr.Spec.Auth.IdentityProviderPassword, nil
+}
+
+// getKeycloakRealmAndClientId returns the realm and the client of Che in Keycloak.
+func getKeycloakRealmAndClientId(cr *orgv1.CheCluster) (string, string) {
+	cheFlavor := deploy.DefaultCheFlavor(cr)
+	keycloakRealm := util.GetValue(cr.Spec.Auth.IdentityProviderRealm, cheFlavor)
+	keycloakClientId := util.GetValue(cr.Spec.Auth.IdentityProviderClientId, cheFlavor+"-public")
+	return keycloakRealm, keycloakClientId
+}
+
+// provisionRealm creates the realm of Che along with its client and the default user.
+// Objects which already exist are left as is.
+func provisionRealm(client *KeycloakClient, cr *orgv1.CheCluster) error {
+	if err := client.UpdateRealm(keycloakMasterRealm, map[string]string{"sslRequired": "none"}); err != nil {
+		return err
+	}
+
+	cheFlavor := deploy.DefaultCheFlavor(cr)
+	keycloakTheme := (map[bool]string{true: "rh-sso", false: "che"})[cheFlavor == "codeready"]
+	realmDisplayName := (map[bool]string{true: "CodeReady Workspaces", false: "Eclipse Che"})[cheFlavor == "codeready"]
+	keycloakRealm, keycloakClientId := getKeycloakRealmAndClientId(cr)
+
+	realm, err := client.GetRealm(keycloakRealm)
+	if err != nil {
+		return err
+	}
+	if realm == nil {
+		logrus.Infof("Creating Keycloak realm '%s'", keycloakRealm)
+		err := client.CreateRealm(&RealmRepresentation{
+			Realm:                keycloakRealm,
+			DisplayName:          realmDisplayName,
+			Enabled:              true,
+			SslRequired:          "none",
+			RegistrationAllowed:  false,
+			ResetPasswordAllowed: true,
+			LoginTheme:           keycloakTheme,
+			AccountTheme:         keycloakTheme,
+			AdminTheme:           keycloakTheme,
+			EmailTheme:           keycloakTheme,
+		})
+		if err != nil && !IsKeycloakConflict(err) {
+			return err
+		}
+	}
+
+	if err := provisionCheClient(client, keycloakRealm, keycloakClientId, cr.Spec.Server.CheHost); err != nil {
+		return err
+	}
+	return provisionAdminUser(client, keycloakRealm, cr.Spec.Auth.UpdateAdminPassword)
+}
+
+func provisionCheClient(client *KeycloakClient, realm string, clientId string, cheHost string) error {
+	cheClient, err := client.GetClient(realm, clientId)
+	if err != nil || cheClient != nil {
+		return err
+	}
+
+	logrus.Infof("Creating Keycloak client '%s'", clientId)
+	err = client.CreateClient(realm, &ClientRepresentation{
+		Id:                        clientId,
+		ClientId:                  clientId,
+		WebOrigins:                getCheClientWebOrigins(cheHost),
+		RedirectUris:              getCheClientRedirectUris(cheHost),
+		DirectAccessGrantsEnabled: true,
+		PublicClient:              true,
+	})
+	if err != nil && !IsKeycloakConflict(err) {
+		return err
+	}
+	return nil
+}
+
+func getCheClientRedirectUris(cheHost string) []string {
+	redirectUris := []string{}
+	for _, path := range []string{"/dashboard/*", "/workspace-loader/*", "/_app/*", "/swagger/*"} {
+		redirectUris = append(redirectUris, "http://"+cheHost+path, "https://"+cheHost+path)
+	}
+	return redirectUris
+}
+
+func getCheClientWebOrigins(cheHost string) []string {
+	return []string{"http://" + cheHost, "https://" + cheHost}
+}
+
+// provisionAdminUser creates the default user of Che, allowed to read the tokens of identity providers.
+func provisionAdminUser(client *KeycloakClient, realm string, updateAdminPassword bool) error {
+	user, err := client.GetUser(realm, keycloakAdminUser)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		requiredActions := []string{}
+		if updateAdminPassword {
+			requiredActions = append(requiredActions, "UPDATE_PASSWORD")
+		}
+
+		logrus.Infof("Creating Keycloak user '%s'", keycloakAdminUser)
+		err = client.CreateUser(realm, &UserRepresentation{
+			Username:        keycloakAdminUser,
+			Email:           "admin@admin.com",
+			Enabled:         true,
+			RequiredActions: requiredActions,
+		})
+		if err != nil && !IsKeycloakConflict(err) {
+			return err
+		}
+
+		user, err = client.GetUser(realm, keycloakAdminUser)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user '%s' not found in realm '%s'", keycloakAdminUser, realm)
+		}
+		if err := client.ResetUserPassword(realm, user.Id, keycloakAdminUser, false); err != nil {
+			return err
+		}
+	}
+
+	brokerClient, err := client.GetClient(realm, keycloakBrokerClientId)
+	if err != nil {
+		return err
+	}
+	if brokerClient == nil {
+		return fmt.Errorf("client '%s' not found in realm '%s'", keycloakBrokerClientId, realm)
+	}
+	readTokenRole, err := client.GetClientRole(realm, brokerClient.Id, keycloakReadTokenRole)
+	if err != nil {
+		return err
+	}
+	if readTokenRole == nil {
+		return fmt.Errorf("role '%s' of client '%s' not found in realm '%s'", keycloakReadTokenRole, keycloakBrokerClientId, realm)
+	}
+	if err := client.AddUserClientRoles(realm, user.Id, brokerClient.Id, []RoleRepresentation{*readTokenRole}); err != nil {
+		return err
+	}
+
+	if util.ContainsString(brokerClient.DefaultRoles, keycloakReadTokenRole) {
+		return nil
+	}
+	defaultRoles := append(brokerClient.DefaultRoles, keycloakReadTokenRole)
+	return client.UpdateClient(realm, brokerClient.Id, map[string][]string{"defaultRoles": defaultRoles})
+}
+
+// provisionOpenShiftIdentityProvider creates OpenShift identity provider, makes it the default one,
+// and allows Che client to exchange Keycloak tokens for OpenShift ones.
+func provisionOpenShiftIdentityProvider(client *KeycloakClient, cr *orgv1.CheCluster, providerId string, oAuthClientName string, oauthSecret string, openShiftApiUrl string) error {
+	keycloakRealm, keycloakClientId := getKeycloakRealmAndClientId(cr)
+
+	identityProvider, err := client.GetIdentityProvider(keycloakRealm, providerId)
+	if err != nil {
+		return err
+	}
+	if identityProvider == nil {
+		logrus.Infof("Creating Keycloak identity provider '%s'", providerId)
+		err := client.CreateIdentityProvider(keycloakRealm, &IdentityProviderRepresentation{
+			Alias:                    providerId,
+			ProviderId:               providerId,
+			Enabled:                  true,
+			StoreToken:               true,
+			AddReadTokenRoleOnCreate: true,
+			Config: map[string]string{
+				"useJwksUrl":   "true",
+				"clientId":     oAuthClientName,
+				"clientSecret": oauthSecret,
+				"baseUrl":      openShiftApiUrl,
+				"defaultScope": "user:full",
+			},
+		})
+		if err != nil && !IsKeycloakConflict(err) {
+			return err
+		}
+	}
+
+	if err := setDefaultIdentityProvider(client, keycloakRealm, providerId); err != nil {
+		return err
+	}
+	return enableTokenExchange(client, keycloakRealm, providerId, keycloakClientId)
+}
+
+// setDefaultIdentityProvider configures the browser flow to redirect users to the identity provider.
+// An existing configuration is left as is, since it might be customized.
+func setDefaultIdentityProvider(client *KeycloakClient, realm string, providerId string) error {
+	executions, err := client.GetFlowExecutions(realm, keycloakBrowserFlow)
+	if err != nil {
+		return err
+	}
+
+	for _, execution := range executions {
+		if execution.ProviderId != keycloakRedirectorProvider {
+			continue
+		}
+		if execution.AuthenticationConfig != "" || execution.Alias != "" {
+			return nil
+		}
+		return client.CreateExecutionConfig(realm, execution.Id, &AuthenticatorConfigRepresentation{
+			Alias:  providerId,
+			Config: map[string]string{"defaultProvider": providerId},
+		})
+	}
+	return fmt.Errorf("execution '%s' not found in flow '%s' of realm '%s'", keycloakRedirectorProvider, keycloakBrowserFlow, realm)
+}
+
+// enableTokenExchange permits the client to exchange its tokens for the tokens of the identity provider.
+func enableTokenExchange(client *KeycloakClient, realm string, providerId string, clientId string) error {
+	permissions, err := client.EnableIdentityProviderPermissions(realm, providerId)
+	if err != nil {
+		return err
+	}
+	permissionId := permissions.ScopePermissions[keycloakTokenExchangeScope]
+	if permissions.Resource == "" || permissionId == "" {
+		return fmt.Errorf("token exchange permission of identity provider '%s' not found", providerId)
+	}
+
+	realmManagementClient, err := client.GetClient(realm, keycloakRealmManagement)
+	if err != nil {
+		return err
+	}
+	if realmManagementClient == nil {
+		return fmt.Errorf("client '%s' not found in realm '%s'", keycloakRealmManagement, realm)
+	}
+
+	policy, err := client.GetClientPolicy(realm, realmManagementClient.Id, providerId)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		err := client.CreateClientPolicy(realm, realmManagementClient.Id, &ClientPolicyRepresentation{
+			Name:             providerId,
+			Type:             "client",
+			Logic:            "POSITIVE",
+			DecisionStrategy: "UNANIMOUS",
+			Clients:          []string{clientId},
+		})
+		if err != nil && !IsKeycloakConflict(err) {
+			return err
+		}
+		if policy, err = client.GetClientPolicy(realm, realmManagementClient.Id, providerId); err != nil {
+			return err
+		}
+		if policy == nil {
+			return fmt.Errorf("policy '%s' not found in realm '%s'", providerId, realm)
+		}
+	}
+
+	scopes, err := client.GetResourceScopes(realm, realmManagementClient.Id, permissions.Resource)
+	if err != nil {
+		return err
+	}
+	scopeId := ""
+	for _, scope := range scopes {
+		if scope.Name == keycloakTokenExchangeScope {
+			scopeId = scope.Id
+		}
+	}
+	if scopeId == "" {
+		return fmt.Errorf("scope '%s' of identity provider '%s' not found", keycloakTokenExchangeScope, providerId)
+	}
+
+	permission, err := client.GetScopePermission(realm, realmManagementClient.Id, permissionId)
+	if err != nil {
+		return err
+	}
+	permission["resources"] = []string{permissions.Resource}
+	permission["scopes"] = []string{scopeId}
+	permission["policies"] = []string{policy.Id}
+	return client.UpdateScopePermission(realm, realmManagementClient.Id, permissionId, permission)
+}
+
+// provisionGitHubIdentityProvider creates GitHub identity provider with the given OAuth application credentials.
+func provisionGitHubIdentityProvider(client *KeycloakClient, realm string, clientId string, clientSecret string) error {
+	identityProvider, err := client.GetIdentityProvider(realm, "github")
+	if err != nil || identityProvider != nil {
+		return err
+	}
+
+	logrus.Infof("Creating Keycloak identity provider 'github'")
+	err = client.CreateIdentityProvider(realm, &IdentityProviderRepresentation{
+		Alias:      "github",
+		ProviderId: "github",
+		Enabled:    true,
+		StoreToken: true,
+		Config: map[string]string{
+			"useJwksUrl":   "true",
+			"clientId":     clientId,
+			"clientSecret": clientSecret,
+			"defaultScope": "repo,user,write:public_key",
+		},
+	})
+	if err != nil && !IsKeycloakConflict(err) {
+		return err
+	}
+	return nil
+}
+
+// deleteIdentityProvider deletes the identity provider if it exists.
+func deleteIdentityProvider(client *KeycloakClient, realm string, alias string) error {
+	err := client.DeleteIdentityProvider(realm, alias)
+	if err != nil && !IsKeycloakNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// DeleteIdentityProvider deletes the identity provider from the realm of Che, if it exists.
+func DeleteIdentityProvider(deployContext *deploy.DeployContext, alias string) error {
+	client, err := newKeycloakAdminClient(deployContext)
+	if err != nil {
+		return err
+	}
+	keycloakRealm, _ := getKeycloakRealmAndClientId(deployContext.CheCluster)
+	return deleteIdentityProvider(client, keycloakRealm, alias)
+}
+
+// UpdateAdminPassword changes the password of Keycloak administrator user.
+// Unlike other operations, the credentials are passed explicitly since the Keycloak container isn't restarted yet.
+// The password might be already updated if the previous attempt failed afterwards, so the new one is tried as well.
+func UpdateAdminPassword(deployContext *deploy.DeployContext, user string, password string, newPassword string) error {
+	keycloakURL := GetKeycloakInternalURL(deployContext.CheCluster)
+	client := NewKeycloakClient(keycloakURL, user, password)
+	if err := client.Login(); err != nil {
+		if !IsKeycloakUnauthorized(err) {
+			return err
+		}
+		client = NewKeycloakClient(keycloakURL, user, newPassword)
+		if err := client.Login(); err != nil {
+			return err
+		}
+	}
+	return updateAdminPassword(client, user, newPassword)
+}
+
+func updateAdminPassword(client *KeycloakClient, user string, newPassword string) error {
+	adminUser, err := client.GetUser(keycloakMasterRealm, user)
+	if err != nil {
+		return err
+	}
+	if adminUser == nil {
+		return fmt.Errorf("user '%s' not found in realm '%s'", user, keycloakMasterRealm)
+	}
+	return client.ResetUserPassword(keycloakMasterRealm, adminUser.Id, newPassword, false)
+}
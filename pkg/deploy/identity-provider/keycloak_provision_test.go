@@ -0,0 +1,264 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"reflect"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestCheCluster() *orgv1.CheCluster {
+	return &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "eclipse-che",
+			Namespace: "eclipse-che",
+		},
+		Spec: orgv1.CheClusterSpec{
+			Server: orgv1.CheClusterSpecServer{
+				CheHost: "che.example.com",
+			},
+			Auth: orgv1.CheClusterSpecAuth{
+				UpdateAdminPassword: true,
+			},
+		},
+	}
+}
+
+func TestProvisionRealm(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	cheCluster := newTestCheCluster()
+	if err := provisionRealm(keycloak.newClient(), cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+
+	if sslRequired := keycloak.realms["master"]["sslRequired"]; sslRequired != "none" {
+		t.Errorf("Expected sslRequired of master realm 'none', but got '%v'", sslRequired)
+	}
+
+	realm, ok := keycloak.realms["che"]
+	if !ok {
+		t.Fatal("Realm 'che' not created")
+	}
+	if realm["displayName"] != "Eclipse Che" || realm["loginTheme"] != "che" || realm["registrationAllowed"] != false {
+		t.Errorf("Unexpected realm: %v", realm)
+	}
+
+	var cheClient *ClientRepresentation
+	for _, client := range keycloak.clients["che"] {
+		if client.ClientId == "che-public" {
+			cheClient = client
+		}
+	}
+	if cheClient == nil {
+		t.Fatal("Client 'che-public' not created")
+	}
+	if !cheClient.PublicClient || len(cheClient.RedirectUris) != 8 || cheClient.RedirectUris[0] != "http://che.example.com/dashboard/*" {
+		t.Errorf("Unexpected client: %v", cheClient)
+	}
+
+	users := keycloak.users["che"]
+	if len(users) != 1 || users[0].Username != "admin" || !reflect.DeepEqual(users[0].RequiredActions, []string{"UPDATE_PASSWORD"}) {
+		t.Fatalf("Unexpected users: %v", users)
+	}
+	if password := keycloak.passwords[users[0].Id]; password != "admin" {
+		t.Errorf("Expected password 'admin', but got '%s'", password)
+	}
+	if roles := keycloak.userRoles[users[0].Id]; !reflect.DeepEqual(roles, []string{"read-token"}) {
+		t.Errorf("Expected 'read-token' role mapped to the user, but got: %v", roles)
+	}
+	if defaultRoles := keycloak.clients["che"][0].DefaultRoles; !reflect.DeepEqual(defaultRoles, []string{"read-token"}) {
+		t.Errorf("Expected 'read-token' default role of broker client, but got: %v", defaultRoles)
+	}
+
+	// provisioning is idempotent
+	keycloak.modifications = nil
+	if err := provisionRealm(keycloak.newClient(), cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm again: %v", err)
+	}
+	expectedModifications := []string{
+		"PUT /auth/admin/realms/master",
+		"POST /auth/admin/realms/che/users/" + users[0].Id + "/role-mappings/clients/che-broker",
+	}
+	if !reflect.DeepEqual(keycloak.modifications, expectedModifications) {
+		t.Errorf("Unexpected modifications of existing realm: %v", keycloak.modifications)
+	}
+	if len(keycloak.users["che"]) != 1 || len(keycloak.clients["che"]) != 3 {
+		t.Errorf("Objects duplicated: %v, %v", keycloak.users["che"], keycloak.clients["che"])
+	}
+}
+
+func TestProvisionRealmForCodeReady(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	cheCluster := newTestCheCluster()
+	cheCluster.Spec.Server.CheFlavor = "codeready"
+	cheCluster.Spec.Auth.IdentityProviderRealm = "crw"
+	cheCluster.Spec.Auth.IdentityProviderClientId = "crw-client"
+	if err := provisionRealm(keycloak.newClient(), cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+
+	realm, ok := keycloak.realms["crw"]
+	if !ok {
+		t.Fatal("Realm 'crw' not created")
+	}
+	if realm["displayName"] != "CodeReady Workspaces" || realm["loginTheme"] != "rh-sso" {
+		t.Errorf("Unexpected realm: %v", realm)
+	}
+	if client := keycloak.clients["crw"][2]; client.ClientId != "crw-client" {
+		t.Errorf("Unexpected client: %v", client)
+	}
+}
+
+func TestProvisionOpenShiftIdentityProvider(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	cheCluster := newTestCheCluster()
+	client := keycloak.newClient()
+	if err := provisionRealm(client, cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		err := provisionOpenShiftIdentityProvider(client, cheCluster, "openshift-v4", "oauth-client", "oauth-secret", "https://api.example.com:6443")
+		if err != nil {
+			t.Fatalf("Failed to provision OpenShift identity provider: %v", err)
+		}
+	}
+
+	identityProvider, ok := keycloak.identityProviders["che"]["openshift-v4"]
+	if !ok {
+		t.Fatal("Identity provider 'openshift-v4' not created")
+	}
+	expectedConfig := map[string]string{
+		"useJwksUrl":   "true",
+		"clientId":     "oauth-client",
+		"clientSecret": "oauth-secret",
+		"baseUrl":      "https://api.example.com:6443",
+		"defaultScope": "user:full",
+	}
+	if !identityProvider.StoreToken || !identityProvider.AddReadTokenRoleOnCreate || !reflect.DeepEqual(identityProvider.Config, expectedConfig) {
+		t.Errorf("Unexpected identity provider: %v", identityProvider)
+	}
+
+	config, ok := keycloak.executionConfigs["redirector"]
+	if !ok || config.Alias != "openshift-v4" || config.Config["defaultProvider"] != "openshift-v4" {
+		t.Errorf("Unexpected redirector configuration: %v", config)
+	}
+
+	if len(keycloak.policies) != 1 {
+		t.Fatalf("Expected 1 policy, but got: %v", keycloak.policies)
+	}
+	policy := keycloak.policies[0]
+	if policy.Name != "openshift-v4" || policy.Type != "client" || !reflect.DeepEqual(policy.Clients, []string{"che-public"}) {
+		t.Errorf("Unexpected policy: %v", policy)
+	}
+
+	permission := keycloak.permission
+	if permission["name"] != "token-exchange.permission" ||
+		!reflect.DeepEqual(permission["resources"], []interface{}{"idp-resource"}) ||
+		!reflect.DeepEqual(permission["scopes"], []interface{}{"token-exchange-scope"}) ||
+		!reflect.DeepEqual(permission["policies"], []interface{}{policy.Id}) {
+		t.Errorf("Unexpected token exchange permission: %v", permission)
+	}
+}
+
+func TestProvisionAndDeleteGitHubIdentityProvider(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	client := keycloak.newClient()
+	if err := provisionRealm(client, newTestCheCluster()); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := provisionGitHubIdentityProvider(client, "che", "github-id", "github-secret"); err != nil {
+			t.Fatalf("Failed to provision GitHub identity provider: %v", err)
+		}
+	}
+	identityProvider, ok := keycloak.identityProviders["che"]["github"]
+	if !ok {
+		t.Fatal("Identity provider 'github' not created")
+	}
+	if identityProvider.Config["clientId"] != "github-id" || identityProvider.Config["clientSecret"] != "github-secret" {
+		t.Errorf("Unexpected identity provider: %v", identityProvider)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := deleteIdentityProvider(client, "che", "github"); err != nil {
+			t.Fatalf("Failed to delete GitHub identity provider: %v", err)
+		}
+	}
+	if _, ok := keycloak.identityProviders["che"]["github"]; ok {
+		t.Fatal("Identity provider 'github' not deleted")
+	}
+}
+
+func TestUpdateAdminPassword(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	if err := updateAdminPassword(keycloak.newClient(), testAdminUser, "new-secret"); err != nil {
+		t.Fatalf("Failed to update administrator password: %v", err)
+	}
+	if password := keycloak.passwords["master-admin"]; password != "new-secret" {
+		t.Fatalf("Expected new password, but got '%s'", password)
+	}
+
+	if err := keycloak.newClient().Login(); !IsKeycloakUnauthorized(err) {
+		t.Fatalf("Expected old password to be rejected, but got: %v", err)
+	}
+}
+
+func TestGetGitHubOAuthCredentials(t *testing.T) {
+	testCases := map[string]*corev1.Secret{
+		"secret": {
+			Data: map[string][]byte{"id": []byte("github-id"), "secret": []byte("github-secret")},
+		},
+		"legacy secret": {
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					deploy.CheEclipseOrgGithubOAuthCredentials:   "true",
+					deploy.CheEclipseOrg + "/client-id_env-name": "GITHUB_CLIENT_ID",
+					deploy.CheEclipseOrg + "/secret_env-name":    "GITHUB_SECRET",
+				},
+			},
+			Data: map[string][]byte{"client-id": []byte("github-id"), "secret": []byte("github-secret")},
+		},
+	}
+
+	for name, secret := range testCases {
+		t.Run(name, func(t *testing.T) {
+			clientId, clientSecret, err := getGitHubOAuthCredentials(secret)
+			if err != nil {
+				t.Fatalf("Failed to get credentials: %v", err)
+			}
+			if clientId != "github-id" || clientSecret != "github-secret" {
+				t.Errorf("Unexpected credentials: %s, %s", clientId, clientSecret)
+			}
+		})
+	}
+
+	if _, _, err := getGitHubOAuthCredentials(&corev1.Secret{}); err == nil {
+		t.Error("Expected error for the secret without credentials")
+	}
+}
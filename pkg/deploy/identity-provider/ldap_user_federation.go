@@ -0,0 +1,379 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	LdapUserFederationProvisionedEventReason = "LdapUserFederationProvisioned"
+	LdapUserFederationFailedEventReason      = "LdapUserFederationFailed"
+
+	ldapProviderId            = "ldap"
+	ldapGroupMapperProviderId = "group-ldap-mapper"
+	userStorageProviderType   = "org.keycloak.storage.UserStorageProvider"
+	ldapMapperProviderType    = "org.keycloak.storage.ldap.mappers.LDAPStorageMapper"
+	ldapVendorActiveDirectory = "ad"
+	// the bind password is never returned by Keycloak, so it is excluded from the comparison
+	ldapBindCredentialKey = "bindCredential"
+)
+
+// IsLdapBindCredentialsSecret indicates whether the secret holds the bind credentials of an LDAP user federation provider.
+func IsLdapBindCredentialsSecret(cr *orgv1.CheCluster, name string) bool {
+	for _, provider := range cr.Spec.Auth.LdapUserFederation {
+		if provider.BindCredentialsSecret != "" && provider.BindCredentialsSecret == name {
+			return true
+		}
+	}
+	return false
+}
+
+func syncLdapUserFederation(deployContext *deploy.DeployContext) (bool, error) {
+	cr := deployContext.CheCluster
+	if util.IsTestMode() || !cr.Status.KeycloakProvisoned {
+		return true, nil
+	}
+	if len(cr.Spec.Auth.LdapUserFederation) == 0 && len(cr.Status.LdapUserFederation) == 0 {
+		return true, nil
+	}
+
+	client, err := newKeycloakAdminClient(deployContext)
+	if err != nil {
+		return false, err
+	}
+	if err := reconcileLdapUserFederation(deployContext, client); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reconcileLdapUserFederation provisions the LDAP user federation providers declared in the CheCluster,
+// and removes the ones provisioned previously, but no longer declared. A failure to provision a provider
+// doesn't block the reconciliation, it is reported in the status and in an event.
+func reconcileLdapUserFederation(deployContext *deploy.DeployContext, client *KeycloakClient) error {
+	cr := deployContext.CheCluster
+	keycloakRealm, _ := getKeycloakRealmAndClientId(cr)
+	realm, err := client.GetRealm(keycloakRealm)
+	if err != nil {
+		return err
+	}
+	if realm == nil {
+		return fmt.Errorf("realm '%s' not found", keycloakRealm)
+	}
+
+	previousStatuses := map[string]orgv1.LdapUserFederationStatus{}
+	for _, status := range cr.Status.LdapUserFederation {
+		previousStatuses[status.Name] = status
+	}
+
+	statuses := []orgv1.LdapUserFederationStatus{}
+	for _, provider := range cr.Spec.Auth.LdapUserFederation {
+		previousStatus := previousStatuses[provider.Name]
+		status := previousStatus
+		status.Name = provider.Name
+		if err := provisionLdapProvider(deployContext, client, keycloakRealm, realm.Id, &provider, &status); err != nil {
+			logrus.Errorf("Failed to provision LDAP user federation provider '%s': %v", provider.Name, err)
+			if previousStatus.Message != err.Error() {
+				deploy.RecordEventf(deployContext, corev1.EventTypeWarning, LdapUserFederationFailedEventReason,
+					"Failed to provision LDAP user federation provider '%s': %v", provider.Name, err)
+			}
+			status.Message = err.Error()
+		} else {
+			status.Message = ""
+		}
+		statuses = append(statuses, status)
+		delete(previousStatuses, provider.Name)
+	}
+
+	removedProviders := []string{}
+	for name := range previousStatuses {
+		removedProviders = append(removedProviders, name)
+	}
+	sort.Strings(removedProviders)
+	for _, name := range removedProviders {
+		status := previousStatuses[name]
+		if status.ComponentId != "" {
+			if err := client.DeleteComponent(keycloakRealm, status.ComponentId); err != nil && !IsKeycloakNotFound(err) {
+				logrus.Errorf("Failed to delete LDAP user federation provider '%s': %v", name, err)
+				status.Message = err.Error()
+				statuses = append(statuses, status)
+				continue
+			}
+			logrus.Infof("LDAP user federation provider '%s' deleted", name)
+		}
+	}
+
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	if reflect.DeepEqual(statuses, cr.Status.LdapUserFederation) {
+		return nil
+	}
+	cr.Status.LdapUserFederation = statuses
+	return deploy.UpdateCheCRStatus(deployContext, "status: LDAP user federation", strconv.Itoa(len(statuses)))
+}
+
+// provisionLdapProvider creates the LDAP user federation provider, or updates it when it differs from the configuration,
+// along with its group mappers.
+func provisionLdapProvider(deployContext *deploy.DeployContext, client *KeycloakClient, realm string, realmId string, provider *orgv1.LdapUserFederation, status *orgv1.LdapUserFederationStatus) error {
+	config, secretVersion, err := getLdapProviderConfig(deployContext, provider)
+	if err != nil {
+		return err
+	}
+
+	component, err := findLdapProvider(client, realm, realmId, provider.Name, status.ComponentId)
+	if err != nil {
+		return err
+	}
+	if component == nil {
+		logrus.Infof("Creating LDAP user federation provider '%s'", provider.Name)
+		err := client.CreateComponent(realm, &ComponentRepresentation{
+			Name:         provider.Name,
+			ProviderId:   ldapProviderId,
+			ProviderType: userStorageProviderType,
+			ParentId:     realmId,
+			Config:       getComponentConfig(config),
+		})
+		if err != nil {
+			return err
+		}
+		if component, err = findLdapProvider(client, realm, realmId, provider.Name, ""); err != nil {
+			return err
+		}
+		if component == nil {
+			return fmt.Errorf("LDAP user federation provider '%s' not found after creation", provider.Name)
+		}
+
+		deploy.RecordEventf(deployContext, corev1.EventTypeNormal, LdapUserFederationProvisionedEventReason,
+			"LDAP user federation provider '%s' provisioned", provider.Name)
+		// the users are imported right away, rather than at the first periodic synchronization, if it is enabled at all
+		if err := client.TriggerUserStorageSync(realm, component.Id, "triggerFullSync"); err != nil {
+			logrus.Warnf("Failed to synchronize users of LDAP user federation provider '%s': %v", provider.Name, err)
+		}
+	} else if component.Name != provider.Name || !isComponentConfigInSync(config, component.Config) || secretVersion != status.BindCredentialsSecretVersion {
+		logrus.Infof("Updating LDAP user federation provider '%s'", provider.Name)
+		component.Name = provider.Name
+		component.Config = mergeComponentConfig(component.Config, config)
+		if err := client.UpdateComponent(realm, component); err != nil {
+			return err
+		}
+	}
+	status.ComponentId = component.Id
+	status.BindCredentialsSecretVersion = secretVersion
+
+	return syncLdapGroupMappers(client, realm, component.Id, provider)
+}
+
+// findLdapProvider returns the LDAP user federation provider with the given id,
+// or the one with the given name, if there is no such id.
+func findLdapProvider(client *KeycloakClient, realm string, realmId string, name string, id string) (*ComponentRepresentation, error) {
+	if id != "" {
+		component, err := client.GetComponent(realm, id)
+		if err != nil || component != nil {
+			return component, err
+		}
+	}
+
+	components, err := client.GetComponents(realm, realmId, userStorageProviderType)
+	if err != nil {
+		return nil, err
+	}
+	for i := range components {
+		if components[i].ProviderId == ldapProviderId && components[i].Name == name {
+			return &components[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// syncLdapGroupMappers provisions the group mappers of the LDAP user federation provider,
+// and removes the group mappers which are not declared. Mappers of other types are left as is.
+func syncLdapGroupMappers(client *KeycloakClient, realm string, parentId string, provider *orgv1.LdapUserFederation) error {
+	components, err := client.GetComponents(realm, parentId, ldapMapperProviderType)
+	if err != nil {
+		return err
+	}
+	existingMappers := map[string]*ComponentRepresentation{}
+	for i := range components {
+		if components[i].ProviderId == ldapGroupMapperProviderId {
+			existingMappers[components[i].Name] = &components[i]
+		}
+	}
+
+	for _, mapper := range provider.GroupMappers {
+		config := getLdapGroupMapperConfig(provider, &mapper)
+		component, exists := existingMappers[mapper.Name]
+		delete(existingMappers, mapper.Name)
+		if !exists {
+			logrus.Infof("Creating group mapper '%s' of LDAP user federation provider '%s'", mapper.Name, provider.Name)
+			err := client.CreateComponent(realm, &ComponentRepresentation{
+				Name:         mapper.Name,
+				ProviderId:   ldapGroupMapperProviderId,
+				ProviderType: ldapMapperProviderType,
+				ParentId:     parentId,
+				Config:       getComponentConfig(config),
+			})
+			if err != nil {
+				return err
+			}
+		} else if !isComponentConfigInSync(config, component.Config) {
+			logrus.Infof("Updating group mapper '%s' of LDAP user federation provider '%s'", mapper.Name, provider.Name)
+			component.Config = mergeComponentConfig(component.Config, config)
+			if err := client.UpdateComponent(realm, component); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, component := range existingMappers {
+		logrus.Infof("Deleting group mapper '%s' of LDAP user federation provider '%s'", name, provider.Name)
+		if err := client.DeleteComponent(realm, component.Id); err != nil && !IsKeycloakNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// getLdapProviderConfig returns the configuration of the LDAP user federation provider in Keycloak format,
+// along with the resource version of the bind credentials secret. Empty values stand for the attributes to be unset.
+func getLdapProviderConfig(deployContext *deploy.DeployContext, provider *orgv1.LdapUserFederation) (map[string]string, string, error) {
+	isActiveDirectory := provider.Vendor == ldapVendorActiveDirectory
+	usernameAttribute := (map[bool]string{true: "sAMAccountName", false: "uid"})[isActiveDirectory]
+	rdnAttribute := (map[bool]string{true: "cn", false: "uid"})[isActiveDirectory]
+	uuidAttribute := (map[bool]string{true: "objectGUID", false: "entryUUID"})[isActiveDirectory]
+	userObjectClasses := (map[bool]string{true: "person, organizationalPerson, user", false: "inetOrgPerson, organizationalPerson"})[isActiveDirectory]
+
+	config := map[string]string{
+		"enabled":                "true",
+		"vendor":                 util.GetValue(provider.Vendor, "other"),
+		"connectionUrl":          provider.ConnectionURL,
+		"usersDn":                provider.UsersDN,
+		"usernameLDAPAttribute":  util.GetValue(provider.UsernameLDAPAttribute, usernameAttribute),
+		"rdnLDAPAttribute":       rdnAttribute,
+		"uuidLDAPAttribute":      uuidAttribute,
+		"userObjectClasses":      util.GetValue(provider.UserObjectClasses, userObjectClasses),
+		"customUserSearchFilter": provider.UserSearchFilter,
+		// subtree, users are often spread over organizational units
+		"searchScope":       "2",
+		"editMode":          "READ_ONLY",
+		"importEnabled":     "true",
+		"syncRegistrations": "false",
+		"useTruststoreSpi":  "ldapsOnly",
+		"pagination":        "true",
+		"fullSyncPeriod":    getLdapSyncPeriod(provider.FullSyncPeriod),
+		"changedSyncPeriod": getLdapSyncPeriod(provider.ChangedSyncPeriod),
+		"authType":          "none",
+		"bindDn":            "",
+	}
+
+	if provider.BindCredentialsSecret == "" {
+		return config, "", nil
+	}
+	cr := deployContext.CheCluster
+	secret, err := deploy.GetSecret(deployContext, provider.BindCredentialsSecret, cr.Namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	if secret == nil {
+		return nil, "", fmt.Errorf("secret '%s' not found", provider.BindCredentialsSecret)
+	}
+	if len(secret.Data["user"]) == 0 || len(secret.Data["password"]) == 0 {
+		return nil, "", fmt.Errorf("secret '%s' must contain 'user' and 'password'", provider.BindCredentialsSecret)
+	}
+	config["authType"] = "simple"
+	config["bindDn"] = string(secret.Data["user"])
+	config[ldapBindCredentialKey] = string(secret.Data["password"])
+	return config, secret.ResourceVersion, nil
+}
+
+func getLdapGroupMapperConfig(provider *orgv1.LdapUserFederation, mapper *orgv1.LdapGroupMapper) map[string]string {
+	isActiveDirectory := provider.Vendor == ldapVendorActiveDirectory
+	groupObjectClasses := (map[bool]string{true: "group", false: "groupOfNames"})[isActiveDirectory]
+	userLdapAttribute := (map[bool]string{true: "cn", false: "uid"})[isActiveDirectory]
+
+	return map[string]string{
+		"groups.dn":                            mapper.GroupsDN,
+		"group.name.ldap.attribute":            util.GetValue(mapper.GroupNameLDAPAttribute, "cn"),
+		"group.object.classes":                 util.GetValue(mapper.GroupObjectClasses, groupObjectClasses),
+		"membership.ldap.attribute":            util.GetValue(mapper.MembershipLDAPAttribute, "member"),
+		"membership.attribute.type":            "DN",
+		"membership.user.ldap.attribute":       userLdapAttribute,
+		"groups.ldap.filter":                   mapper.GroupsFilter,
+		"mode":                                 "READ_ONLY",
+		"user.roles.retrieve.strategy":         "LOAD_GROUPS_BY_MEMBER_ATTRIBUTE",
+		"memberof.ldap.attribute":              "memberOf",
+		"preserve.group.inheritance":           "false",
+		"ignore.missing.groups":                "false",
+		"drop.non.existing.groups.during.sync": "false",
+	}
+}
+
+func getLdapSyncPeriod(period *int32) string {
+	if period == nil || *period <= 0 {
+		return "-1"
+	}
+	return strconv.Itoa(int(*period))
+}
+
+// getComponentConfig converts the configuration to Keycloak format, leaving out the unset attributes.
+func getComponentConfig(config map[string]string) map[string][]string {
+	result := map[string][]string{}
+	for key, value := range config {
+		if value != "" {
+			result[key] = []string{value}
+		}
+	}
+	return result
+}
+
+// mergeComponentConfig overrides the attributes of the existing configuration with the desired ones.
+// Attributes which are not managed by the Operator are left as is.
+func mergeComponentConfig(existing map[string][]string, config map[string]string) map[string][]string {
+	result := map[string][]string{}
+	for key, value := range existing {
+		result[key] = value
+	}
+	for key, value := range config {
+		if value == "" {
+			delete(result, key)
+		} else {
+			result[key] = []string{value}
+		}
+	}
+	return result
+}
+
+func isComponentConfigInSync(config map[string]string, existing map[string][]string) bool {
+	for key, value := range config {
+		if key == ldapBindCredentialKey {
+			continue
+		}
+		existingValue := ""
+		if len(existing[key]) != 0 {
+			existingValue = existing[key][0]
+		}
+		if existingValue != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newLdapBindCredentialsSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ldap-credentials",
+			Namespace: "eclipse-che",
+		},
+		Data: map[string][]byte{
+			"user":     []byte("cn=admin,dc=example,dc=com"),
+			"password": []byte("ldap-secret"),
+		},
+	}
+}
+
+func getLdapComponents(keycloak *fakeKeycloak, parentId string, providerId string) []*ComponentRepresentation {
+	result := []*ComponentRepresentation{}
+	for _, component := range keycloak.components["che"] {
+		if component.ParentId == parentId && component.ProviderId == providerId {
+			result = append(result, component)
+		}
+	}
+	return result
+}
+
+func TestReconcileLdapUserFederation(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	fullSyncPeriod := int32(86400)
+	cheCluster := newTestCheCluster()
+	cheCluster.Spec.Auth.LdapUserFederation = []orgv1.LdapUserFederation{
+		{
+			Name:                  "corporate",
+			Vendor:                "ad",
+			ConnectionURL:         "ldaps://ldap.example.com:636",
+			BindCredentialsSecret: "ldap-credentials",
+			UsersDN:               "ou=users,dc=example,dc=com",
+			FullSyncPeriod:        &fullSyncPeriod,
+			GroupMappers: []orgv1.LdapGroupMapper{
+				{Name: "groups", GroupsDN: "ou=groups,dc=example,dc=com"},
+			},
+		},
+	}
+	deployContext, recorder := newRealmConfigDeployContext(cheCluster, newLdapBindCredentialsSecret())
+
+	client := keycloak.newClient()
+	if err := provisionRealm(client, cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+	if err := reconcileLdapUserFederation(deployContext, client); err != nil {
+		t.Fatalf("Failed to reconcile LDAP user federation: %v", err)
+	}
+
+	providers := getLdapComponents(keycloak, "che", ldapProviderId)
+	if len(providers) != 1 {
+		t.Fatalf("Expected 1 LDAP provider, but got: %v", providers)
+	}
+	provider := providers[0]
+	expectedConfig := map[string][]string{
+		"vendor":                {"ad"},
+		"connectionUrl":         {"ldaps://ldap.example.com:636"},
+		"usersDn":               {"ou=users,dc=example,dc=com"},
+		"usernameLDAPAttribute": {"sAMAccountName"},
+		"uuidLDAPAttribute":     {"objectGUID"},
+		"userObjectClasses":     {"person, organizationalPerson, user"},
+		"authType":              {"simple"},
+		"bindDn":                {"cn=admin,dc=example,dc=com"},
+		"bindCredential":        {"ldap-secret"},
+		"fullSyncPeriod":        {"86400"},
+		"changedSyncPeriod":     {"-1"},
+	}
+	for key, value := range expectedConfig {
+		if !reflect.DeepEqual(provider.Config[key], value) {
+			t.Errorf("Expected %s %v, but got %v", key, value, provider.Config[key])
+		}
+	}
+	if _, ok := provider.Config["customUserSearchFilter"]; ok {
+		t.Errorf("Unexpected unset attribute: %v", provider.Config["customUserSearchFilter"])
+	}
+
+	mappers := getLdapComponents(keycloak, provider.Id, ldapGroupMapperProviderId)
+	if len(mappers) != 1 || mappers[0].Name != "groups" || mappers[0].Config["groups.dn"][0] != "ou=groups,dc=example,dc=com" ||
+		mappers[0].Config["group.object.classes"][0] != "group" {
+		t.Fatalf("Unexpected group mappers: %v", mappers)
+	}
+	if !reflect.DeepEqual(keycloak.userStorageSyncs, []string{provider.Id + " triggerFullSync"}) {
+		t.Errorf("Expected full synchronization of users, but got: %v", keycloak.userStorageSyncs)
+	}
+
+	status := cheCluster.Status.LdapUserFederation
+	if len(status) != 1 || status[0].Name != "corporate" || status[0].ComponentId != provider.Id || status[0].Message != "" {
+		t.Fatalf("Unexpected status: %v", status)
+	}
+	assertRealmEvent(t, recorder, "Normal LdapUserFederationProvisioned")
+
+	// nothing is updated when the provider is in sync
+	keycloak.modifications = nil
+	if err := reconcileLdapUserFederation(deployContext, client); err != nil {
+		t.Fatalf("Failed to reconcile LDAP user federation: %v", err)
+	}
+	if len(keycloak.modifications) != 0 {
+		t.Errorf("Unexpected modifications of provider in sync: %v", keycloak.modifications)
+	}
+
+	// the bind password changes, and the group mapper is no longer declared
+	secret := &corev1.Secret{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: "ldap-credentials", Namespace: "eclipse-che"}, secret); err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	secret.Data["password"] = []byte("new-ldap-secret")
+	if err := deployContext.ClusterAPI.Client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("Failed to update secret: %v", err)
+	}
+	cheCluster.Spec.Auth.LdapUserFederation[0].GroupMappers = nil
+	if err := reconcileLdapUserFederation(deployContext, client); err != nil {
+		t.Fatalf("Failed to reconcile LDAP user federation: %v", err)
+	}
+	if password := provider.Config["bindCredential"]; !reflect.DeepEqual(password, []string{"new-ldap-secret"}) {
+		t.Errorf("Expected bind password to be updated, but got: %v", password)
+	}
+	if mappers := getLdapComponents(keycloak, provider.Id, ldapGroupMapperProviderId); len(mappers) != 0 {
+		t.Errorf("Expected group mapper to be deleted, but got: %v", mappers)
+	}
+
+	// the provider is no longer declared
+	cheCluster.Spec.Auth.LdapUserFederation = nil
+	if err := reconcileLdapUserFederation(deployContext, client); err != nil {
+		t.Fatalf("Failed to reconcile LDAP user federation: %v", err)
+	}
+	if providers := getLdapComponents(keycloak, "che", ldapProviderId); len(providers) != 0 {
+		t.Errorf("Expected provider to be deleted, but got: %v", providers)
+	}
+	if cheCluster.Status.LdapUserFederation != nil {
+		t.Errorf("Unexpected status: %v", cheCluster.Status.LdapUserFederation)
+	}
+}
+
+func TestReconcileLdapUserFederationFailure(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	cheCluster := newTestCheCluster()
+	cheCluster.Spec.Auth.LdapUserFederation = []orgv1.LdapUserFederation{
+		{Name: "corporate", ConnectionURL: "ldap://ldap.example.com", BindCredentialsSecret: "ldap-credentials"},
+	}
+	deployContext, recorder := newRealmConfigDeployContext(cheCluster)
+
+	client := keycloak.newClient()
+	if err := provisionRealm(client, cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+	keycloak.modifications = nil
+	for i := 0; i < 2; i++ {
+		if err := reconcileLdapUserFederation(deployContext, client); err != nil {
+			t.Fatalf("Failure to provision provider expected to be reported in status, but got: %v", err)
+		}
+	}
+
+	status := cheCluster.Status.LdapUserFederation
+	if len(status) != 1 || !strings.Contains(status[0].Message, "secret 'ldap-credentials' not found") {
+		t.Fatalf("Unexpected status: %v", status)
+	}
+	if len(keycloak.modifications) != 0 {
+		t.Errorf("Unexpected modifications of realm: %v", keycloak.modifications)
+	}
+	// the same failure is reported once
+	assertRealmEvent(t, recorder, "Warning LdapUserFederationFailed")
+	if len(recorder.Events) != 0 {
+		t.Errorf("Unexpected event: %s", <-recorder.Events)
+	}
+}
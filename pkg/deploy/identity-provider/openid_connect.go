@@ -0,0 +1,194 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/deploy/gateway"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	OpenIdConnectProviderValidatedEventReason = "OpenIdConnectProviderValidated"
+
+	openIdConnectDiscoveryPath      = "/.well-known/openid-configuration"
+	openIdConnectDiscoveryTimeout   = 30 * time.Second
+	openIdConnectDiscoveryMaxLength = 1 << 20
+)
+
+// openIdConnectDiscovery is the subset of the OpenID Provider Metadata used by Che
+type openIdConnectDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// SyncOpenIdConnectProviderToCluster removes Keycloak, which is replaced by the generic OpenID Connect provider,
+// and validates the discovery document of the provider. The endpoints of the provider are kept in the status.
+// Returns true if the provider is valid, false if requeue of the reconcile request is needed.
+func SyncOpenIdConnectProviderToCluster(deployContext *deploy.DeployContext) (bool, error) {
+	if done, err := deleteKeycloak(deployContext); !done {
+		return false, err
+	}
+
+	cr := deployContext.CheCluster
+	issuerURL := cr.Spec.Auth.OpenIdConnect.IssuerURL
+	// the discovery document is fetched again only when the issuer changes
+	if cr.Status.OpenIdConnectProvider != nil && cr.Status.OpenIdConnectProvider.Issuer == issuerURL {
+		return true, nil
+	}
+	if util.IsTestMode() {
+		return true, nil
+	}
+
+	status, err := discoverOpenIdConnectProvider(deployContext, issuerURL)
+	if err != nil {
+		return false, err
+	}
+	cr.Status.OpenIdConnectProvider = status
+	if err := deploy.UpdateCheCRStatus(deployContext, "status: OpenID Connect provider", issuerURL); err != nil {
+		return false, err
+	}
+	deploy.RecordEventf(deployContext, corev1.EventTypeNormal, OpenIdConnectProviderValidatedEventReason,
+		"OpenID Connect provider '%s' validated", issuerURL)
+	return true, nil
+}
+
+// deleteKeycloak removes the Keycloak objects which have been deployed before the generic OpenID Connect provider is set.
+func deleteKeycloak(deployContext *deploy.DeployContext) (bool, error) {
+	if done, err := deploy.DeleteNamespacedObject(deployContext, deploy.IdentityProviderName, &appsv1.Deployment{}); !done {
+		return false, err
+	}
+	if done, err := deploy.DeleteNamespacedObject(deployContext, deploy.IdentityProviderName, &corev1.Service{}); !done {
+		return false, err
+	}
+
+	var err error
+	if util.IsOpenShift {
+		err = deploy.DeleteRouteIfExists(deploy.IdentityProviderName, deployContext)
+	} else {
+		err = deploy.DeleteIngressIfExists(deploy.IdentityProviderName, deployContext)
+		if err == nil {
+			err = deploy.DeleteHTTPRouteIfExists(deploy.IdentityProviderName, deployContext)
+		}
+	}
+	if err == nil {
+		err = gateway.DeleteGatewayRouteConfig("che-gateway-route-"+deploy.IdentityProviderName, deployContext)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// clearOpenIdConnectProviderStatus removes the endpoints of the generic OpenID Connect provider
+// from the status, once the provider is no longer used.
+func clearOpenIdConnectProviderStatus(deployContext *deploy.DeployContext) error {
+	cr := deployContext.CheCluster
+	if cr.Status.OpenIdConnectProvider == nil {
+		return nil
+	}
+	cr.Status.OpenIdConnectProvider = nil
+	return deploy.UpdateCheCRStatus(deployContext, "status: OpenID Connect provider", "")
+}
+
+// discoverOpenIdConnectProvider fetches and validates the discovery document of the issuer,
+// as defined by the OpenID Connect Discovery specification.
+func discoverOpenIdConnectProvider(deployContext *deploy.DeployContext, issuerURL string) (*orgv1.OpenIdConnectProviderStatus, error) {
+	discoveryURL := strings.TrimSuffix(issuerURL, "/") + openIdConnectDiscoveryPath
+	client, err := newOpenIdConnectDiscoveryClient(deployContext)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.Get(discoveryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OpenID Connect discovery document: %v", err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(&io.LimitedReader{R: response.Body, N: openIdConnectDiscoveryMaxLength})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenID Connect discovery document '%s': %v", discoveryURL, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get OpenID Connect discovery document '%s': %s", discoveryURL, response.Status)
+	}
+
+	discovery := &openIdConnectDiscovery{}
+	if err := json.Unmarshal(body, discovery); err != nil {
+		return nil, fmt.Errorf("invalid OpenID Connect discovery document '%s': %v", discoveryURL, err)
+	}
+	if err := validateOpenIdConnectDiscovery(issuerURL, discovery); err != nil {
+		return nil, fmt.Errorf("invalid OpenID Connect discovery document '%s': %v", discoveryURL, err)
+	}
+
+	logrus.Infof("OpenID Connect provider '%s' validated", issuerURL)
+	return &orgv1.OpenIdConnectProviderStatus{
+		Issuer:                issuerURL,
+		AuthorizationEndpoint: discovery.AuthorizationEndpoint,
+		TokenEndpoint:         discovery.TokenEndpoint,
+		JwksURI:               discovery.JwksURI,
+		EndSessionEndpoint:    discovery.EndSessionEndpoint,
+	}, nil
+}
+
+// newOpenIdConnectDiscoveryClient returns the client which reaches the issuer the way Che components do:
+// through the proxy, and trusting the CA certificates of Che.
+func newOpenIdConnectDiscoveryClient(deployContext *deploy.DeployContext) (*http.Client, error) {
+	rootCAs, err := deploy.GetTrustedCertPool(deployContext)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}}
+	if deployContext.Proxy != nil && (deployContext.Proxy.HttpProxy != "" || deployContext.Proxy.HttpsProxy != "") {
+		deploy.ConfigureProxy(deployContext, transport)
+	}
+	return &http.Client{Transport: transport, Timeout: openIdConnectDiscoveryTimeout}, nil
+}
+
+func validateOpenIdConnectDiscovery(issuerURL string, discovery *openIdConnectDiscovery) error {
+	// tokens are issued by the issuer of the discovery document, which must be the configured one
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(issuerURL, "/") {
+		return fmt.Errorf("issuer '%s' doesn't match '%s'", discovery.Issuer, issuerURL)
+	}
+	missing := []string{}
+	for name, value := range map[string]string{
+		"authorization_endpoint": discovery.AuthorizationEndpoint,
+		"token_endpoint":         discovery.TokenEndpoint,
+		"jwks_uri":               discovery.JwksURI,
+	} {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("'%s' required", strings.Join(missing, "', '"))
+	}
+	return nil
+}
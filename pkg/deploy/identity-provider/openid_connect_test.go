@@ -0,0 +1,172 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// newMockIssuer starts an OpenID Connect provider which serves the discovery document returned by the function.
+func newMockIssuer(discovery func(issuer string) string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != openIdConnectDiscoveryPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, discovery(server.URL))
+	}))
+	return server
+}
+
+func TestDiscoverOpenIdConnectProvider(t *testing.T) {
+	issuer := newMockIssuer(func(issuer string) string {
+		return fmt.Sprintf(`{
+			"issuer": "%[1]s",
+			"authorization_endpoint": "%[1]s/auth",
+			"token_endpoint": "%[1]s/token",
+			"jwks_uri": "%[1]s/keys",
+			"end_session_endpoint": "%[1]s/logout",
+			"response_types_supported": ["code"]
+		}`, issuer)
+	})
+	defer issuer.Close()
+
+	// the trailing slash of the issuer is not significant
+	deployContext, _ := newRealmConfigDeployContext(newTestCheCluster())
+	status, err := discoverOpenIdConnectProvider(deployContext, issuer.URL+"/")
+	if err != nil {
+		t.Fatalf("Failed to discover OpenID Connect provider: %v", err)
+	}
+	if status.Issuer != issuer.URL+"/" || status.AuthorizationEndpoint != issuer.URL+"/auth" || status.TokenEndpoint != issuer.URL+"/token" ||
+		status.JwksURI != issuer.URL+"/keys" || status.EndSessionEndpoint != issuer.URL+"/logout" {
+		t.Errorf("Unexpected status: %v", status)
+	}
+}
+
+func TestDiscoverInvalidOpenIdConnectProvider(t *testing.T) {
+	type testCase struct {
+		name            string
+		discovery       func(issuer string) string
+		expectedMessage string
+	}
+
+	testCases := []testCase{
+		{
+			name: "Issuer mismatch",
+			discovery: func(issuer string) string {
+				return `{"issuer": "https://other.example.com", "authorization_endpoint": "a", "token_endpoint": "t", "jwks_uri": "j"}`
+			},
+			expectedMessage: "issuer 'https://other.example.com' doesn't match",
+		},
+		{
+			name: "Missing endpoints",
+			discovery: func(issuer string) string {
+				return fmt.Sprintf(`{"issuer": "%s", "authorization_endpoint": "a"}`, issuer)
+			},
+			expectedMessage: "'jwks_uri', 'token_endpoint' required",
+		},
+		{
+			name: "Malformed document",
+			discovery: func(issuer string) string {
+				return "<html></html>"
+			},
+			expectedMessage: "invalid character",
+		},
+	}
+
+	deployContext, _ := newRealmConfigDeployContext(newTestCheCluster())
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			issuer := newMockIssuer(testCase.discovery)
+			defer issuer.Close()
+
+			_, err := discoverOpenIdConnectProvider(deployContext, issuer.URL)
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedMessage) {
+				t.Fatalf("Expected error '%s', but got: %v", testCase.expectedMessage, err)
+			}
+		})
+	}
+
+	issuer := newMockIssuer(nil)
+	issuer.Close()
+	if _, err := discoverOpenIdConnectProvider(deployContext, issuer.URL+"/dex"); err == nil {
+		t.Fatal("Expected error for unavailable issuer")
+	}
+}
+
+func TestDiscoverOpenIdConnectProviderWithTrustedCA(t *testing.T) {
+	var issuer *httptest.Server
+	issuer = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"issuer": "%[1]s", "authorization_endpoint": "%[1]s/auth", "token_endpoint": "%[1]s/token", "jwks_uri": "%[1]s/keys"}`, issuer.URL)
+	}))
+	defer issuer.Close()
+
+	deployContext, _ := newRealmConfigDeployContext(newTestCheCluster())
+	if _, err := discoverOpenIdConnectProvider(deployContext, issuer.URL); err == nil {
+		t.Fatal("Expected error for the issuer with a certificate which isn't trusted")
+	}
+
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: issuer.Certificate().Raw})
+	deployContext, _ = newRealmConfigDeployContext(newTestCheCluster(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: deploy.CheAllCACertsConfigMapName, Namespace: "eclipse-che"},
+		Data:       map[string]string{"eclipse-che.custom-ca.crt": string(caCert)},
+	})
+	if _, err := discoverOpenIdConnectProvider(deployContext, issuer.URL); err != nil {
+		t.Fatalf("Failed to discover OpenID Connect provider with a trusted certificate: %v", err)
+	}
+}
+
+func TestDeleteKeycloak(t *testing.T) {
+	util.IsOpenShift = false
+	objectMeta := metav1.ObjectMeta{Name: deploy.IdentityProviderName, Namespace: "eclipse-che"}
+	deployContext, _ := newRealmConfigDeployContext(newTestCheCluster(),
+		&appsv1.Deployment{ObjectMeta: objectMeta},
+		&corev1.Service{ObjectMeta: objectMeta},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "che-gateway-route-keycloak", Namespace: "eclipse-che"}})
+
+	if done, err := deleteKeycloak(deployContext); !done || err != nil {
+		t.Fatalf("Failed to delete Keycloak: %v", err)
+	}
+	key := types.NamespacedName{Name: deploy.IdentityProviderName, Namespace: "eclipse-che"}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), key, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected Keycloak deployment to be deleted, but got: %v", err)
+	}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), key, &corev1.Service{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected Keycloak service to be deleted, but got: %v", err)
+	}
+	key.Name = "che-gateway-route-keycloak"
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), key, &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected Keycloak gateway route to be deleted, but got: %v", err)
+	}
+
+	// nothing to delete
+	if done, err := deleteKeycloak(deployContext); !done || err != nil {
+		t.Fatalf("Failed to delete Keycloak: %v", err)
+	}
+}
@@ -0,0 +1,333 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// RealmConfigMapRealmKey is the key of the realm config map with a partial realm representation
+	RealmConfigMapRealmKey = "realm.json"
+	// RealmConfigMapClientKey is the key of the realm config map with a partial Che client representation
+	RealmConfigMapClientKey = "client.json"
+
+	KeycloakRealmConfiguredEventReason    = "KeycloakRealmConfigured"
+	KeycloakRealmDriftDetectedEventReason = "KeycloakRealmDriftDetected"
+	KeycloakRealmConfigFailedEventReason  = "KeycloakRealmConfigFailed"
+
+	// realm attributes are compared periodically, since Keycloak doesn't notify about changes
+	realmSyncPeriod = 5 * time.Minute
+)
+
+var (
+	// attributes which identify the realm and the client, and can't be configured
+	realmIdentityAttributes  = []string{"id", "realm"}
+	clientIdentityAttributes = []string{"id", "clientId"}
+)
+
+// realmConfig is the desired state of the realm and the Che client.
+// Values are kept in the form they have after JSON decoding, to be comparable with the ones returned by Keycloak.
+type realmConfig struct {
+	Realm  map[string]interface{} `json:"realm"`
+	Client map[string]interface{} `json:"client"`
+}
+
+// GetRealmSyncRequeueDelay returns the delay after which the CheCluster should be reconciled
+// to detect drift of the realm configuration, or zero if Keycloak isn't managed by the Operator.
+func GetRealmSyncRequeueDelay(cr *orgv1.CheCluster) time.Duration {
+	if !deploy.IsKeycloakDeployed(cr) || !cr.Status.KeycloakProvisoned {
+		return 0
+	}
+	return realmSyncPeriod
+}
+
+// IsRealmConfigMap indicates whether the config map holds the realm configuration of the CheCluster.
+func IsRealmConfigMap(cr *orgv1.CheCluster, name string) bool {
+	realmConfig := cr.Spec.Auth.IdentityProviderRealmConfig
+	return realmConfig != nil && realmConfig.ConfigMap != "" && realmConfig.ConfigMap == name
+}
+
+func syncRealmConfig(deployContext *deploy.DeployContext) (bool, error) {
+	if util.IsTestMode() || !deployContext.CheCluster.Status.KeycloakProvisoned {
+		return true, nil
+	}
+
+	client, err := newKeycloakAdminClient(deployContext)
+	if err != nil {
+		return false, err
+	}
+	if err := reconcileRealmConfig(deployContext, client); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reconcileRealmConfig applies the configuration to the realm and the Che client, and reports the result in the status.
+// A failure to apply the configuration doesn't block the reconciliation, it is reported in the status and in an event.
+func reconcileRealmConfig(deployContext *deploy.DeployContext, client *KeycloakClient) error {
+	cr := deployContext.CheCluster
+	status := &orgv1.IdentityProviderRealmStatus{}
+	if cr.Status.IdentityProviderRealm != nil {
+		status = cr.Status.IdentityProviderRealm.DeepCopy()
+	}
+
+	if err := applyRealmConfig(deployContext, client, status); err != nil {
+		logrus.Errorf("Failed to configure Keycloak realm: %v", err)
+		if status.Message != err.Error() {
+			deploy.RecordEventf(deployContext, corev1.EventTypeWarning, KeycloakRealmConfigFailedEventReason, "Failed to configure Keycloak realm: %v", err)
+		}
+		status.Message = err.Error()
+	} else {
+		status.Message = ""
+	}
+
+	if reflect.DeepEqual(status, cr.Status.IdentityProviderRealm) {
+		return nil
+	}
+	cr.Status.IdentityProviderRealm = status
+	return deploy.UpdateCheCRStatus(deployContext, "status: identity provider realm", status.ConfigHash)
+}
+
+func applyRealmConfig(deployContext *deploy.DeployContext, client *KeycloakClient, status *orgv1.IdentityProviderRealmStatus) error {
+	cr := deployContext.CheCluster
+	desired, err := getRealmConfig(deployContext)
+	if err != nil {
+		return err
+	}
+	configHash, err := getRealmConfigHash(desired)
+	if err != nil {
+		return err
+	}
+
+	keycloakRealm, keycloakClientId := getKeycloakRealmAndClientId(cr)
+	realm, err := client.GetRealmAttributes(keycloakRealm)
+	if err != nil {
+		return err
+	}
+	if realm == nil {
+		return fmt.Errorf("realm '%s' not found", keycloakRealm)
+	}
+	cheClient, err := client.GetClientAttributes(keycloakRealm, keycloakClientId)
+	if err != nil {
+		return err
+	}
+	if cheClient == nil {
+		return fmt.Errorf("client '%s' not found in realm '%s'", keycloakClientId, keycloakRealm)
+	}
+
+	realmUpdate := getAttributesDiff(desired.Realm, realm)
+	clientUpdate := getAttributesDiff(desired.Client, cheClient)
+	changedAttributes := []string{}
+	for key := range realmUpdate {
+		changedAttributes = append(changedAttributes, "realm."+key)
+	}
+	for key := range clientUpdate {
+		changedAttributes = append(changedAttributes, "client."+key)
+	}
+	sort.Strings(changedAttributes)
+
+	if len(realmUpdate) != 0 {
+		if err := client.UpdateRealm(keycloakRealm, realmUpdate); err != nil {
+			return err
+		}
+	}
+	if len(clientUpdate) != 0 {
+		id, _ := cheClient["id"].(string)
+		if err := client.UpdateClient(keycloakRealm, id, clientUpdate); err != nil {
+			return err
+		}
+	}
+
+	if len(changedAttributes) != 0 {
+		// the configuration hasn't changed since it was applied, so the realm has been modified outside of the Operator
+		if status.ConfigHash == configHash {
+			logrus.Warnf("Keycloak realm '%s' drifted from the configuration, reverted: %s", keycloakRealm, strings.Join(changedAttributes, ", "))
+			deploy.RecordEventf(deployContext, corev1.EventTypeWarning, KeycloakRealmDriftDetectedEventReason,
+				"Keycloak realm '%s' modified outside of the Operator, reverted attributes: %s", keycloakRealm, strings.Join(changedAttributes, ", "))
+			now := metav1.Now()
+			status.DriftedAttributes = changedAttributes
+			status.LastDriftTime = &now
+		} else {
+			logrus.Infof("Keycloak realm '%s' configured: %s", keycloakRealm, strings.Join(changedAttributes, ", "))
+			deploy.RecordEventf(deployContext, corev1.EventTypeNormal, KeycloakRealmConfiguredEventReason,
+				"Keycloak realm '%s' configured, updated attributes: %s", keycloakRealm, strings.Join(changedAttributes, ", "))
+		}
+	}
+	status.ConfigHash = configHash
+	return nil
+}
+
+// getRealmConfig returns the desired attributes of the realm and the Che client.
+// The ones set in the CheCluster take precedence over the ones from the config map.
+// Redirect URIs and web origins of the client always follow the Che host.
+func getRealmConfig(deployContext *deploy.DeployContext) (*realmConfig, error) {
+	cr := deployContext.CheCluster
+	realm := map[string]interface{}{}
+	cheClient := map[string]interface{}{}
+
+	spec := cr.Spec.Auth.IdentityProviderRealmConfig
+	if spec != nil && spec.ConfigMap != "" {
+		configMap := &corev1.ConfigMap{}
+		err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: spec.ConfigMap, Namespace: cr.Namespace}, configMap)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("config map '%s' not found", spec.ConfigMap)
+			}
+			return nil, err
+		}
+		if err := parseRealmConfigMapKey(configMap, RealmConfigMapRealmKey, realm); err != nil {
+			return nil, err
+		}
+		if err := parseRealmConfigMapKey(configMap, RealmConfigMapClientKey, cheClient); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range realmIdentityAttributes {
+		delete(realm, key)
+	}
+	for _, key := range clientIdentityAttributes {
+		delete(cheClient, key)
+	}
+
+	if spec != nil {
+		if spec.AccessTokenLifespan != nil {
+			realm["accessTokenLifespan"] = *spec.AccessTokenLifespan
+		}
+		if spec.SsoSessionIdleTimeout != nil {
+			realm["ssoSessionIdleTimeout"] = *spec.SsoSessionIdleTimeout
+		}
+		if spec.SsoSessionMaxLifespan != nil {
+			realm["ssoSessionMaxLifespan"] = *spec.SsoSessionMaxLifespan
+		}
+		if spec.PasswordPolicy != "" {
+			realm["passwordPolicy"] = spec.PasswordPolicy
+		}
+	}
+	if cr.Spec.Server.CheHost != "" {
+		cheClient["redirectUris"] = getCheClientRedirectUris(cr.Spec.Server.CheHost)
+		cheClient["webOrigins"] = getCheClientWebOrigins(cr.Spec.Server.CheHost)
+	}
+
+	// round trip through JSON, for the values to have the same types as the ones returned by Keycloak
+	data, err := json.Marshal(&realmConfig{Realm: realm, Client: cheClient})
+	if err != nil {
+		return nil, err
+	}
+	config := &realmConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func parseRealmConfigMapKey(configMap *corev1.ConfigMap, key string, result map[string]interface{}) error {
+	data, ok := configMap.Data[key]
+	if !ok || strings.TrimSpace(data) == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return fmt.Errorf("invalid JSON under the key '%s' of config map '%s': %v", key, configMap.Name, err)
+	}
+	return nil
+}
+
+// getRealmConfigHash returns a hash of the configuration, used to tell configuration changes from drift.
+func getRealmConfigHash(config *realmConfig) (string, error) {
+	// keys of maps are sorted, so the same configuration always gives the same hash
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// getAttributesDiff returns the desired attributes which differ from the actual ones.
+func getAttributesDiff(desired map[string]interface{}, actual map[string]interface{}) map[string]interface{} {
+	diff := map[string]interface{}{}
+	for key, value := range desired {
+		if !isAttributeInSync(value, actual[key]) {
+			diff[key] = value
+		}
+	}
+	return diff
+}
+
+// isAttributeInSync compares the desired value with the actual one.
+// Objects are compared by the desired keys only, since Keycloak returns many more of them,
+// and lists of strings are compared regardless of the order.
+func isAttributeInSync(desired interface{}, actual interface{}) bool {
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		actualValue, ok := actual.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range desiredValue {
+			if !isAttributeInSync(value, actualValue[key]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		actualValue, ok := actual.([]interface{})
+		if !ok {
+			return len(desiredValue) == 0 && actual == nil
+		}
+		if len(desiredValue) != len(actualValue) {
+			return false
+		}
+		desiredStrings, desiredOk := toSortedStrings(desiredValue)
+		actualStrings, actualOk := toSortedStrings(actualValue)
+		if desiredOk && actualOk {
+			return reflect.DeepEqual(desiredStrings, actualStrings)
+		}
+		for i := range desiredValue {
+			if !isAttributeInSync(desiredValue[i], actualValue[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(desired, actual)
+}
+
+func toSortedStrings(values []interface{}) ([]string, bool) {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, str)
+	}
+	sort.Strings(result)
+	return result, true
+}
@@ -0,0 +1,225 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package identity_provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newRealmConfigDeployContext(cheCluster *orgv1.CheCluster, initObjects ...runtime.Object) (*deploy.DeployContext, *record.FakeRecorder) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, append(initObjects, cheCluster)...)
+	recorder := record.NewFakeRecorder(10)
+	return &deploy.DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: deploy.ClusterAPI{
+			Client:          cli,
+			NonCachedClient: cli,
+			Scheme:          scheme.Scheme,
+		},
+		EventRecorder: recorder,
+	}, recorder
+}
+
+func newRealmConfigMap(realm string, client string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "realm-config",
+			Namespace: "eclipse-che",
+		},
+		Data: map[string]string{
+			RealmConfigMapRealmKey:  realm,
+			RealmConfigMapClientKey: client,
+		},
+	}
+}
+
+func assertRealmEvent(t *testing.T, recorder *record.FakeRecorder, expectedPrefix string) {
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, expectedPrefix) {
+			t.Fatalf("Expected event '%s', got '%s'", expectedPrefix, event)
+		}
+	default:
+		t.Fatalf("Expected event '%s', but no event recorded", expectedPrefix)
+	}
+}
+
+func TestReconcileRealmConfig(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	accessTokenLifespan := int32(600)
+	cheCluster := newTestCheCluster()
+	cheCluster.Spec.Auth.IdentityProviderRealmConfig = &orgv1.IdentityProviderRealmConfig{
+		AccessTokenLifespan: &accessTokenLifespan,
+		PasswordPolicy:      "length(8)",
+		ConfigMap:           "realm-config",
+	}
+	configMap := newRealmConfigMap(
+		`{"realm": "other", "accessTokenLifespan": 60, "bruteForceProtected": true}`,
+		`{"clientId": "other", "attributes": {"pkce.code.challenge.method": "S256"}}`)
+	deployContext, recorder := newRealmConfigDeployContext(cheCluster, configMap)
+
+	client := keycloak.newClient()
+	if err := provisionRealm(client, cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+	if err := reconcileRealmConfig(deployContext, client); err != nil {
+		t.Fatalf("Failed to reconcile realm configuration: %v", err)
+	}
+
+	realm := keycloak.realms["che"]
+	if realm["realm"] != "che" || realm["accessTokenLifespan"] != float64(600) || realm["passwordPolicy"] != "length(8)" || realm["bruteForceProtected"] != true {
+		t.Errorf("Unexpected realm: %v", realm)
+	}
+	attributes := keycloak.getClientAttributes(keycloak.clients["che"][2])
+	if !reflect.DeepEqual(attributes["attributes"], map[string]interface{}{"pkce.code.challenge.method": "S256"}) || attributes["clientId"] != "che-public" {
+		t.Errorf("Unexpected client: %v", attributes)
+	}
+	status := cheCluster.Status.IdentityProviderRealm
+	if status == nil || status.ConfigHash == "" || status.Message != "" || status.DriftedAttributes != nil {
+		t.Fatalf("Unexpected status: %v", status)
+	}
+	assertRealmEvent(t, recorder, "Normal KeycloakRealmConfigured Keycloak realm 'che' configured, updated attributes: "+
+		"client.attributes, realm.accessTokenLifespan, realm.bruteForceProtected, realm.passwordPolicy")
+
+	// nothing is updated when the realm is in sync
+	keycloak.modifications = nil
+	if err := reconcileRealmConfig(deployContext, client); err != nil {
+		t.Fatalf("Failed to reconcile realm configuration: %v", err)
+	}
+	if len(keycloak.modifications) != 0 {
+		t.Errorf("Unexpected modifications of realm in sync: %v", keycloak.modifications)
+	}
+	configHash := status.ConfigHash
+
+	// the realm is modified outside of the Operator
+	if err := client.UpdateRealm("che", map[string]interface{}{"accessTokenLifespan": 3600}); err != nil {
+		t.Fatalf("Failed to update realm: %v", err)
+	}
+	if err := reconcileRealmConfig(deployContext, client); err != nil {
+		t.Fatalf("Failed to reconcile realm configuration: %v", err)
+	}
+	if realm := keycloak.realms["che"]; realm["accessTokenLifespan"] != float64(600) {
+		t.Errorf("Expected drifted attribute to be reverted, but got: %v", realm["accessTokenLifespan"])
+	}
+	status = cheCluster.Status.IdentityProviderRealm
+	if status.ConfigHash != configHash || !reflect.DeepEqual(status.DriftedAttributes, []string{"realm.accessTokenLifespan"}) || status.LastDriftTime == nil {
+		t.Fatalf("Unexpected status after drift: %v", status)
+	}
+	assertRealmEvent(t, recorder, "Warning KeycloakRealmDriftDetected")
+
+	// the Che host changes
+	cheCluster.Spec.Server.CheHost = "che.example.org"
+	if err := reconcileRealmConfig(deployContext, client); err != nil {
+		t.Fatalf("Failed to reconcile realm configuration: %v", err)
+	}
+	cheClient := keycloak.clients["che"][2]
+	if len(cheClient.RedirectUris) != 8 || cheClient.RedirectUris[0] != "http://che.example.org/dashboard/*" ||
+		!reflect.DeepEqual(cheClient.WebOrigins, []string{"http://che.example.org", "https://che.example.org"}) {
+		t.Errorf("Unexpected client: %v", cheClient)
+	}
+	status = cheCluster.Status.IdentityProviderRealm
+	if status.ConfigHash == configHash || !reflect.DeepEqual(status.DriftedAttributes, []string{"realm.accessTokenLifespan"}) {
+		t.Fatalf("Unexpected status after configuration change: %v", status)
+	}
+	assertRealmEvent(t, recorder, "Normal KeycloakRealmConfigured Keycloak realm 'che' configured, updated attributes: client.redirectUris, client.webOrigins")
+}
+
+func TestReconcileRealmConfigFailure(t *testing.T) {
+	keycloak := newFakeKeycloak()
+	defer keycloak.close()
+
+	cheCluster := newTestCheCluster()
+	cheCluster.Spec.Auth.IdentityProviderRealmConfig = &orgv1.IdentityProviderRealmConfig{ConfigMap: "realm-config"}
+	deployContext, recorder := newRealmConfigDeployContext(cheCluster, newRealmConfigMap(`{"accessTokenLifespan": `, ""))
+
+	client := keycloak.newClient()
+	if err := provisionRealm(client, cheCluster); err != nil {
+		t.Fatalf("Failed to provision realm: %v", err)
+	}
+	keycloak.modifications = nil
+	for i := 0; i < 2; i++ {
+		if err := reconcileRealmConfig(deployContext, client); err != nil {
+			t.Fatalf("Failure to apply configuration expected to be reported in status, but got: %v", err)
+		}
+	}
+
+	status := cheCluster.Status.IdentityProviderRealm
+	if status == nil || !strings.Contains(status.Message, "invalid JSON under the key 'realm.json'") || status.ConfigHash != "" {
+		t.Fatalf("Unexpected status: %v", status)
+	}
+	if len(keycloak.modifications) != 0 {
+		t.Errorf("Unexpected modifications of realm: %v", keycloak.modifications)
+	}
+	// the same failure is reported once
+	assertRealmEvent(t, recorder, "Warning KeycloakRealmConfigFailed")
+	if len(recorder.Events) != 0 {
+		t.Errorf("Unexpected event: %s", <-recorder.Events)
+	}
+}
+
+func TestIsAttributeInSync(t *testing.T) {
+	type testCase struct {
+		name     string
+		desired  interface{}
+		actual   interface{}
+		expected bool
+	}
+
+	testCases := []testCase{
+		{name: "same number", desired: float64(60), actual: float64(60), expected: true},
+		{name: "different number", desired: float64(60), actual: float64(600), expected: false},
+		{name: "missing attribute", desired: "length(8)", actual: nil, expected: false},
+		{name: "strings in different order", desired: []interface{}{"a", "b"}, actual: []interface{}{"b", "a"}, expected: true},
+		{name: "different strings", desired: []interface{}{"a", "b"}, actual: []interface{}{"a", "c"}, expected: false},
+		{name: "empty list", desired: []interface{}{}, actual: nil, expected: true},
+		{
+			name:     "subset of object",
+			desired:  map[string]interface{}{"a": "1"},
+			actual:   map[string]interface{}{"a": "1", "b": "2"},
+			expected: true,
+		},
+		{
+			name:     "different object",
+			desired:  map[string]interface{}{"a": "1", "c": "3"},
+			actual:   map[string]interface{}{"a": "1", "b": "2"},
+			expected: false,
+		},
+		{
+			name:     "list of objects",
+			desired:  []interface{}{map[string]interface{}{"name": "mapper"}},
+			actual:   []interface{}{map[string]interface{}{"id": "1", "name": "mapper"}},
+			expected: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if inSync := isAttributeInSync(testCase.desired, testCase.actual); inSync != testCase.expected {
+				t.Errorf("Expected %t, but got %t", testCase.expected, inSync)
+			}
+		})
+	}
+}
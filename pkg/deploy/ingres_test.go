@@ -12,6 +12,7 @@
 package deploy
 
 import (
+	"context"
 	"os"
 	"reflect"
 
@@ -21,9 +22,14 @@ import (
 	"github.com/eclipse-che/che-operator/pkg/util"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -149,3 +155,242 @@ func TestIngressSpec(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncNetworkingV1IngressToCluster(t *testing.T) {
+	cheCluster := &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "eclipse-che",
+			Name:      "eclipse-che",
+		},
+		Spec: orgv1.CheClusterSpec{
+			Server: orgv1.CheClusterSpecServer{
+				TlsSupport: true,
+			},
+			K8s: orgv1.CheClusterSpecK8SOnly{
+				IngressDomain: "che.internal",
+				IngressClass:  "traefik",
+				TlsSecretName: "che-tls",
+			},
+		},
+	}
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, cheCluster)
+	deployContext := &DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: ClusterAPI{
+			Client:          cli,
+			NonCachedClient: cli,
+			Scheme:          scheme.Scheme,
+		},
+		NetworkingV1Ingress: true,
+		IngressClassName:    "traefik",
+	}
+
+	ingress, err := SyncIngressToCluster(deployContext, "keycloak", "", "keycloak", 8080, orgv1.IngressCustomSettings{}, "keycloak")
+	if ingress != nil || err != nil {
+		t.Fatalf("Expected the ingress to be created, got: %v, %v", ingress, err)
+	}
+
+	clusterIngress := &unstructured.Unstructured{}
+	clusterIngress.SetGroupVersionKind(NetworkingV1IngressGVK)
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "keycloak", Namespace: "eclipse-che"}, clusterIngress); err != nil {
+		t.Fatalf("Failed to get the networking.k8s.io/v1 ingress: %v", err)
+	}
+	if _, ok := clusterIngress.GetAnnotations()["kubernetes.io/ingress.class"]; ok {
+		t.Errorf("The ingress class annotation is set: %v", clusterIngress.GetAnnotations())
+	}
+	expectedSpec := map[string]interface{}{
+		"ingressClassName": "traefik",
+		"rules": []interface{}{
+			map[string]interface{}{
+				"host": "keycloak-eclipse-che.che.internal",
+				"http": map[string]interface{}{
+					"paths": []interface{}{
+						map[string]interface{}{
+							"path":     "/",
+							"pathType": "ImplementationSpecific",
+							"backend": map[string]interface{}{
+								"service": map[string]interface{}{
+									"name": "keycloak",
+									"port": map[string]interface{}{"number": int64(8080)},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"tls": []interface{}{
+			map[string]interface{}{
+				"hosts":      []interface{}{"che.internal"},
+				"secretName": "che-tls",
+			},
+		},
+	}
+	if !reflect.DeepEqual(clusterIngress.Object["spec"], expectedSpec) {
+		t.Errorf("Unexpected ingress spec (-want, +got): %v", cmp.Diff(expectedSpec, clusterIngress.Object["spec"]))
+	}
+
+	ingress, err = SyncIngressToCluster(deployContext, "keycloak", "", "keycloak", 8080, orgv1.IngressCustomSettings{}, "keycloak")
+	if ingress == nil || err != nil {
+		t.Fatalf("Expected the ingress to be up to date, got: %v, %v", ingress, err)
+	}
+	if ingress.Spec.Rules[0].Host != "keycloak-eclipse-che.che.internal" {
+		t.Errorf("Unexpected ingress host: %s", ingress.Spec.Rules[0].Host)
+	}
+
+	if err := DeleteIngressIfExists("keycloak", deployContext); err != nil {
+		t.Fatalf("Failed to delete the ingress: %v", err)
+	}
+	host, err := getClusterIngressHost(deployContext, "keycloak")
+	if host != "" || err != nil {
+		t.Errorf("Expected the ingress to be deleted, got: %s, %v", host, err)
+	}
+}
+
+func TestDetectNetworkingV1Ingress(t *testing.T) {
+	type testCase struct {
+		name                        string
+		networkingV1IngressServed   bool
+		ingressClasses              []runtime.Object
+		expectedNetworkingV1Ingress bool
+		expectedIngressClassName    string
+	}
+
+	testCases := []testCase{
+		{
+			name:                        "Set the ingress class name when the IngressClass exists",
+			networkingV1IngressServed:   true,
+			ingressClasses:              []runtime.Object{newIngressClass("traefik")},
+			expectedNetworkingV1Ingress: true,
+			expectedIngressClassName:    "traefik",
+		},
+		{
+			name:                        "Keep the ingress class in the annotation when the IngressClass doesn't exist",
+			networkingV1IngressServed:   true,
+			ingressClasses:              []runtime.Object{newIngressClass("nginx")},
+			expectedNetworkingV1Ingress: true,
+			expectedIngressClassName:    "",
+		},
+		{
+			name:                        "Use extensions/v1beta1 ingresses when networking.k8s.io/v1 ones are not served",
+			networkingV1IngressServed:   false,
+			ingressClasses:              []runtime.Object{newIngressClass("traefik")},
+			expectedNetworkingV1Ingress: false,
+			expectedIngressClassName:    "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cheCluster := &orgv1.CheCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "eclipse-che",
+					Name:      "eclipse-che",
+				},
+				Spec: orgv1.CheClusterSpec{
+					K8s: orgv1.CheClusterSpecK8SOnly{
+						IngressClass: "traefik",
+					},
+				},
+			}
+			orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+			cli := fake.NewFakeClientWithScheme(scheme.Scheme, append(testCase.ingressClasses, cheCluster)...)
+			discoveryClient := &fakeDiscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+			if testCase.networkingV1IngressServed {
+				discoveryClient.Resources = []*metav1.APIResourceList{
+					{
+						GroupVersion: "networking.k8s.io/v1",
+						APIResources: []metav1.APIResource{{Name: "ingresses", Kind: "Ingress"}},
+					},
+				}
+			}
+			recorder := record.NewFakeRecorder(10)
+			deployContext := &DeployContext{
+				CheCluster: cheCluster,
+				ClusterAPI: ClusterAPI{
+					Client:          cli,
+					NonCachedClient: cli,
+					DiscoveryClient: discoveryClient,
+					Scheme:          scheme.Scheme,
+				},
+				EventRecorder: recorder,
+			}
+
+			// the detection is done on each reconcile
+			for i := 0; i < 2; i++ {
+				if err := DetectNetworkingV1Ingress(deployContext); err != nil {
+					t.Fatalf("Failed to detect the Ingress API: %v", err)
+				}
+			}
+			if deployContext.NetworkingV1Ingress != testCase.expectedNetworkingV1Ingress {
+				t.Errorf("Expected networking.k8s.io/v1 ingresses to be %t, got %t", testCase.expectedNetworkingV1Ingress, deployContext.NetworkingV1Ingress)
+			}
+			if deployContext.IngressClassName != testCase.expectedIngressClassName {
+				t.Errorf("Expected the ingress class name '%s', got '%s'", testCase.expectedIngressClassName, deployContext.IngressClassName)
+			}
+
+			// the missing IngressClass is reported once
+			notFound := testCase.expectedNetworkingV1Ingress && testCase.expectedIngressClassName == ""
+			if orgv1.IsConditionTrue(cheCluster.Status.Conditions, orgv1.ConditionIngressClassNotFound) != notFound {
+				t.Errorf("Expected %s condition to be set: %t", orgv1.ConditionIngressClassNotFound, notFound)
+			}
+			expectedEvents := 0
+			if notFound {
+				expectedEvents = 1
+			}
+			if len(recorder.Events) != expectedEvents {
+				t.Errorf("Expected %d events, got %d", expectedEvents, len(recorder.Events))
+			}
+		})
+	}
+}
+
+func TestSyncNetworkingV1IngressWithoutIngressClassNameToCluster(t *testing.T) {
+	cheCluster := &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "eclipse-che",
+			Name:      "eclipse-che",
+		},
+		Spec: orgv1.CheClusterSpec{
+			K8s: orgv1.CheClusterSpecK8SOnly{
+				IngressDomain: "che.internal",
+				IngressClass:  "traefik",
+			},
+		},
+	}
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, cheCluster)
+	deployContext := &DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: ClusterAPI{
+			Client:          cli,
+			NonCachedClient: cli,
+			Scheme:          scheme.Scheme,
+		},
+		NetworkingV1Ingress: true,
+	}
+
+	if _, err := SyncIngressToCluster(deployContext, "keycloak", "", "keycloak", 8080, orgv1.IngressCustomSettings{}, "keycloak"); err != nil {
+		t.Fatalf("Failed to sync the ingress: %v", err)
+	}
+
+	clusterIngress := &unstructured.Unstructured{}
+	clusterIngress.SetGroupVersionKind(NetworkingV1IngressGVK)
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "keycloak", Namespace: "eclipse-che"}, clusterIngress); err != nil {
+		t.Fatalf("Failed to get the networking.k8s.io/v1 ingress: %v", err)
+	}
+	if clusterIngress.GetAnnotations()["kubernetes.io/ingress.class"] != "traefik" {
+		t.Errorf("Expected the ingress class to be set in the annotation, got: %v", clusterIngress.GetAnnotations())
+	}
+	if _, ok, _ := unstructured.NestedString(clusterIngress.Object, "spec", "ingressClassName"); ok {
+		t.Errorf("The ingress class name is set: %v", clusterIngress.Object["spec"])
+	}
+}
+
+func newIngressClass(name string) *unstructured.Unstructured {
+	ingressClass := &unstructured.Unstructured{}
+	ingressClass.SetGroupVersionKind(ingressClassGVK)
+	ingressClass.SetName(name)
+	return ingressClass
+}
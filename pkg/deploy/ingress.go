@@ -23,14 +23,23 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/sirupsen/logrus"
 	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/discovery"
 	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+const (
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+)
+
 var ingressDiffOpts = cmp.Options{
 	cmpopts.IgnoreFields(v1beta1.Ingress{}, "TypeMeta", "Status"),
 	cmp.Comparer(func(x, y metav1.ObjectMeta) bool {
@@ -38,6 +47,86 @@ var ingressDiffOpts = cmp.Options{
 	}),
 }
 
+var NetworkingV1IngressGVK = schema.GroupVersionKind{
+	Group:   "networking.k8s.io",
+	Version: "v1",
+	Kind:    "Ingress",
+}
+
+var ingressClassGVK = schema.GroupVersionKind{
+	Group:   "networking.k8s.io",
+	Version: "v1",
+	Kind:    "IngressClass",
+}
+
+// The fields of the spec defaulted by the cluster are ignored
+var unstructuredDiffOpts = cmp.Comparer(func(x, y unstructured.Unstructured) bool {
+	return reflect.DeepEqual(x.GetLabels(), y.GetLabels()) && reflect.DeepEqual(x.Object["spec"], y.Object["spec"])
+})
+
+// isUnstructuredUpToDate tells if the object in the cluster matches the blueprint,
+// ignoring the fields of the spec defaulted by the cluster.
+func isUnstructuredUpToDate(actual *unstructured.Unstructured, blueprint *unstructured.Unstructured) bool {
+	return reflect.DeepEqual(actual.GetLabels(), blueprint.GetLabels()) &&
+		equality.Semantic.DeepDerivative(blueprint.Object["spec"], actual.Object["spec"])
+}
+
+// DetectNetworkingV1Ingress records in the deploy context whether the cluster serves the networking.k8s.io/v1 Ingress API,
+// which replaces the extensions/v1beta1 one removed in Kubernetes 1.22, along with the class set in the `ingressClassName` field.
+// When the IngressClass doesn't exist, a warning is recorded and the class is only set in the annotation of the ingresses,
+// which is still honored by the ingress controllers not registering their class.
+func DetectNetworkingV1Ingress(deployContext *DeployContext) error {
+	deployContext.NetworkingV1Ingress = false
+	deployContext.IngressClassName = ""
+	if util.IsOpenShift {
+		return nil
+	}
+
+	ingressClass := util.GetValue(deployContext.CheCluster.Spec.K8s.IngressClass, DefaultIngressClass)
+	if deployContext.Offline {
+		// the objects are rendered for a recent Kubernetes
+		deployContext.NetworkingV1Ingress = true
+		deployContext.IngressClassName = ingressClass
+		return nil
+	}
+
+	served, err := IsNetworkingV1IngressServed(deployContext.ClusterAPI.DiscoveryClient)
+	if err != nil {
+		return err
+	}
+	if !served {
+		return RemoveWarningCondition(deployContext, orgv1.ConditionIngressClassNotFound)
+	}
+	deployContext.NetworkingV1Ingress = true
+
+	exists, err := isIngressClassExists(deployContext, ingressClass)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return SetWarningCondition(deployContext, orgv1.ConditionIngressClassNotFound,
+			fmt.Sprintf("The IngressClass '%s' doesn't exist, it is set in the '%s' annotation of the ingresses", ingressClass, ingressClassAnnotation))
+	}
+	deployContext.IngressClassName = ingressClass
+	return RemoveWarningCondition(deployContext, orgv1.ConditionIngressClassNotFound)
+}
+
+// IsNetworkingV1IngressServed checks whether the cluster serves the networking.k8s.io/v1 Ingress API.
+// The APIs which fail to be discovered are skipped.
+func IsNetworkingV1IngressServed(discoveryClient discovery.DiscoveryInterface) (bool, error) {
+	_, resourcesList, err := discoveryClient.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return false, err
+	}
+
+	for _, l := range resourcesList {
+		if l.GroupVersion == NetworkingV1IngressGVK.GroupVersion().String() {
+			return util.HasAPIResourceNameInList("ingresses", []*metav1.APIResourceList{l}), nil
+		}
+	}
+	return false, nil
+}
+
 func SyncIngressToCluster(
 	deployContext *DeployContext,
 	name string,
@@ -52,6 +141,10 @@ func SyncIngressToCluster(
 		return nil, err
 	}
 
+	if deployContext.NetworkingV1Ingress {
+		return syncNetworkingV1IngressToCluster(deployContext, specIngress)
+	}
+
 	clusterIngress, err := GetClusterIngress(specIngress.Name, specIngress.Namespace, deployContext.ClusterAPI.Client)
 	if err != nil {
 		return nil, err
@@ -60,28 +153,155 @@ func SyncIngressToCluster(
 	if clusterIngress == nil {
 		logrus.Infof("Creating a new object: %s, name %s", specIngress.Kind, specIngress.Name)
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specIngress)
+		recordCreateEvent(deployContext, specIngress, specIngress.Name, err == nil, err)
 		return nil, err
 	}
 
 	diff := cmp.Diff(clusterIngress, specIngress, ingressDiffOpts)
 	if len(diff) > 0 {
 		logrus.Infof("Updating existed object: %s, name: %s", clusterIngress.Kind, clusterIngress.Name)
-		fmt.Printf("Difference:\n%s", diff)
+		logrus.Infof("Difference:\n%s", diff)
 
 		err := deployContext.ClusterAPI.Client.Delete(context.TODO(), clusterIngress)
 		if err != nil {
+			recordUpdateEvent(deployContext, clusterIngress, clusterIngress.Name, "", false, err)
 			return nil, err
 		}
 
 		err = deployContext.ClusterAPI.Client.Create(context.TODO(), specIngress)
+		recordUpdateEvent(deployContext, specIngress, specIngress.Name, getChangedFields(clusterIngress, specIngress, ingressDiffOpts), err == nil, err)
 		return nil, err
 	}
 
 	return clusterIngress, nil
 }
 
+// syncNetworkingV1IngressToCluster syncs the ingress in the networking.k8s.io/v1 API version.
+// Returns the given ingress once the one in the cluster is up to date.
+func syncNetworkingV1IngressToCluster(deployContext *DeployContext, specIngress *v1beta1.Ingress) (*v1beta1.Ingress, error) {
+	blueprint := toNetworkingV1Ingress(specIngress, deployContext.IngressClassName)
+	clusterIngress := &unstructured.Unstructured{}
+	clusterIngress.SetGroupVersionKind(NetworkingV1IngressGVK)
+	exists, err := Get(deployContext, types.NamespacedName{Name: specIngress.Name, Namespace: specIngress.Namespace}, clusterIngress)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		_, err := Create(deployContext, blueprint)
+		return nil, err
+	}
+
+	if isUnstructuredUpToDate(clusterIngress, blueprint) {
+		return specIngress, nil
+	}
+
+	done, err := Update(deployContext, clusterIngress, blueprint, unstructuredDiffOpts)
+	if !done {
+		return nil, err
+	}
+	return specIngress, nil
+}
+
+// getIngressObject returns the ingress in the API version served by the cluster.
+func getIngressObject(deployContext *DeployContext, ingress *v1beta1.Ingress) runtime.Object {
+	if deployContext.NetworkingV1Ingress {
+		return toNetworkingV1Ingress(ingress, deployContext.IngressClassName)
+	}
+	return ingress
+}
+
+// isIngressClassExists checks whether the cluster-scoped IngressClass exists.
+// It is read with the non cached client, so the IngressClasses are not watched.
+func isIngressClassExists(deployContext *DeployContext, name string) (bool, error) {
+	ingressClass := &unstructured.Unstructured{}
+	ingressClass.SetGroupVersionKind(ingressClassGVK)
+	err := deployContext.ClusterAPI.NonCachedClient.Get(context.TODO(), types.NamespacedName{Name: name}, ingressClass)
+	if err == nil {
+		return true, nil
+	}
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// toNetworkingV1Ingress converts the ingress into the networking.k8s.io/v1 API version.
+// The ingress class annotation is replaced with the `ingressClassName` field, unless the class name is empty.
+func toNetworkingV1Ingress(ingress *v1beta1.Ingress, ingressClassName string) *unstructured.Unstructured {
+	rules := []interface{}{}
+	for _, rule := range ingress.Spec.Rules {
+		paths := []interface{}{}
+		if rule.HTTP != nil {
+			for _, path := range rule.HTTP.Paths {
+				port := map[string]interface{}{"number": int64(path.Backend.ServicePort.IntValue())}
+				if path.Backend.ServicePort.Type == intstr.String {
+					port = map[string]interface{}{"name": path.Backend.ServicePort.StrVal}
+				}
+				paths = append(paths, map[string]interface{}{
+					"path":     path.Path,
+					"pathType": "ImplementationSpecific",
+					"backend": map[string]interface{}{
+						"service": map[string]interface{}{
+							"name": path.Backend.ServiceName,
+							"port": port,
+						},
+					},
+				})
+			}
+		}
+		rules = append(rules, map[string]interface{}{
+			"host": rule.Host,
+			"http": map[string]interface{}{"paths": paths},
+		})
+	}
+
+	spec := map[string]interface{}{"rules": rules}
+	if ingressClassName != "" {
+		spec["ingressClassName"] = ingressClassName
+	}
+	if len(ingress.Spec.TLS) > 0 {
+		tls := []interface{}{}
+		for _, t := range ingress.Spec.TLS {
+			hosts := []interface{}{}
+			for _, host := range t.Hosts {
+				hosts = append(hosts, host)
+			}
+			tls = append(tls, map[string]interface{}{
+				"hosts":      hosts,
+				"secretName": t.SecretName,
+			})
+		}
+		spec["tls"] = tls
+	}
+
+	annotations := map[string]string{}
+	for key, value := range ingress.Annotations {
+		if key != ingressClassAnnotation || ingressClassName == "" {
+			annotations[key] = value
+		}
+	}
+
+	result := &unstructured.Unstructured{}
+	result.SetGroupVersionKind(NetworkingV1IngressGVK)
+	result.SetName(ingress.Name)
+	result.SetNamespace(ingress.Namespace)
+	result.SetLabels(ingress.Labels)
+	result.SetAnnotations(annotations)
+	result.SetOwnerReferences(ingress.OwnerReferences)
+	result.Object["spec"] = spec
+	return result
+}
+
 // DeleteIngressIfExists removes specified ingress if any
 func DeleteIngressIfExists(name string, deployContext *DeployContext) error {
+	if deployContext.NetworkingV1Ingress {
+		ingress := &unstructured.Unstructured{}
+		ingress.SetGroupVersionKind(NetworkingV1IngressGVK)
+		_, err := DeleteNamespacedObject(deployContext, name, ingress)
+		return err
+	}
+
 	ingress, err := GetClusterIngress(name, deployContext.CheCluster.Namespace, deployContext.ClusterAPI.Client)
 	if err != nil {
 		return err
@@ -89,6 +309,7 @@ func DeleteIngressIfExists(name string, deployContext *DeployContext) error {
 
 	if ingress != nil {
 		err = deployContext.ClusterAPI.Client.Delete(context.TODO(), ingress)
+		recordDeleteEvent(deployContext, ingress, ingress.Name, err)
 		if err != nil {
 			return err
 		}
@@ -114,6 +335,44 @@ func GetClusterIngress(name string, namespace string, client runtimeClient.Clien
 	return ingress, nil
 }
 
+// getClusterIngressHost returns the host of the first rule of the ingress, or an empty string if it doesn't exist yet.
+func getClusterIngressHost(deployContext *DeployContext, name string) (string, error) {
+	if deployContext.NetworkingV1Ingress {
+		ingress := &unstructured.Unstructured{}
+		ingress.SetGroupVersionKind(NetworkingV1IngressGVK)
+		exists, err := Get(deployContext, types.NamespacedName{Name: name, Namespace: deployContext.CheCluster.Namespace}, ingress)
+		if !exists {
+			return "", err
+		}
+		rules, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "rules")
+		if len(rules) == 0 {
+			return "", nil
+		}
+		rule, _ := rules[0].(map[string]interface{})
+		host, _, _ := unstructured.NestedString(rule, "host")
+		return host, nil
+	}
+
+	ingress, err := GetClusterIngress(name, deployContext.CheCluster.Namespace, deployContext.ClusterAPI.Client)
+	if ingress == nil || len(ingress.Spec.Rules) == 0 {
+		return "", err
+	}
+	return ingress.Spec.Rules[0].Host, nil
+}
+
+// getEndpointHost returns the host the endpoint is exposed on, when it isn't set explicitly.
+func getEndpointHost(cr *orgv1.CheCluster, name string, host string) string {
+	if host == "" {
+		exposureStrategy := util.GetServerExposureStrategy(cr, DefaultServerExposureStrategy)
+		if exposureStrategy == "multi-host" {
+			host = name + "-" + cr.Namespace + "." + cr.Spec.K8s.IngressDomain
+		} else if exposureStrategy == "single-host" {
+			host = cr.Spec.K8s.IngressDomain
+		}
+	}
+	return host
+}
+
 // GetSpecIngress returns expected ingress config for given parameters
 func GetSpecIngress(
 	deployContext *DeployContext,
@@ -131,17 +390,15 @@ func GetSpecIngress(
 	labels := GetLabels(deployContext.CheCluster, component)
 	MergeLabels(labels, ingressCustomSettings.Labels)
 
-	if host == "" {
-		if ingressStrategy == "multi-host" {
-			host = name + "-" + deployContext.CheCluster.Namespace + "." + ingressDomain
-		} else if ingressStrategy == "single-host" {
-			host = ingressDomain
-		}
-	}
+	host = getEndpointHost(deployContext.CheCluster, name, host)
 
 	tlsSecretName := util.GetValue(deployContext.CheCluster.Spec.K8s.TlsSecretName, "")
+	tlsHosts := []string{ingressDomain}
 	if tlsSupport {
-		if name == DefaultCheFlavor(deployContext.CheCluster) && deployContext.CheCluster.Spec.Server.CheHostTLSSecret != "" {
+		if UseCertManagerCertificate(deployContext, name) {
+			tlsSecretName = GetCertManagerCertificateName(deployContext.CheCluster, name)
+			tlsHosts = []string{host}
+		} else if name == DefaultCheFlavor(deployContext.CheCluster) && deployContext.CheCluster.Spec.Server.CheHostTLSSecret != "" {
 			tlsSecretName = deployContext.CheCluster.Spec.Server.CheHostTLSSecret
 		}
 	}
@@ -159,7 +416,7 @@ func GetSpecIngress(
 	}
 
 	annotations := map[string]string{
-		"kubernetes.io/ingress.class":                       ingressClass,
+		ingressClassAnnotation:                              ingressClass,
 		"nginx.ingress.kubernetes.io/proxy-read-timeout":    "3600",
 		"nginx.ingress.kubernetes.io/proxy-connect-timeout": "3600",
 		"nginx.ingress.kubernetes.io/ssl-redirect":          strconv.FormatBool(tlsSupport),
@@ -204,9 +461,7 @@ func GetSpecIngress(
 	if tlsSupport {
 		ingress.Spec.TLS = []v1beta1.IngressTLS{
 			{
-				Hosts: []string{
-					ingressDomain,
-				},
+				Hosts:      tlsHosts,
 				SecretName: tlsSecretName,
 			},
 		}
@@ -13,7 +13,6 @@ package deploy
 
 import (
 	"context"
-	"fmt"
 	"reflect"
 
 	"github.com/eclipse-che/che-operator/pkg/util"
@@ -63,6 +62,12 @@ func SyncJobToCluster(
 		return nil, err
 	}
 
+	return SyncJobSpecToCluster(deployContext, specJob)
+}
+
+// SyncJobSpecToCluster creates the job, or recreates it if its spec differs from the cluster one.
+// Returns the cluster job if it is up to date, nil otherwise.
+func SyncJobSpecToCluster(deployContext *DeployContext, specJob *batchv1.Job) (*batchv1.Job, error) {
 	clusterJob, err := getClusterJob(specJob.Name, specJob.Namespace, deployContext.ClusterAPI)
 	if err != nil {
 		return nil, err
@@ -71,19 +76,22 @@ func SyncJobToCluster(
 	if clusterJob == nil {
 		logrus.Infof("Creating a new object: %s, name %s", specJob.Kind, specJob.Name)
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specJob)
+		recordCreateEvent(deployContext, specJob, specJob.Name, err == nil, err)
 		return nil, err
 	}
 
 	diff := cmp.Diff(clusterJob, specJob, jobDiffOpts)
 	if len(diff) > 0 {
 		logrus.Infof("Updating existed object: %s, name: %s", clusterJob.Kind, clusterJob.Name)
-		fmt.Printf("Difference:\n%s", diff)
+		logrus.Infof("Difference:\n%s", diff)
 
 		if err := deployContext.ClusterAPI.Client.Delete(context.TODO(), clusterJob); err != nil {
+			recordUpdateEvent(deployContext, clusterJob, clusterJob.Name, "", false, err)
 			return nil, err
 		}
 
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specJob)
+		recordUpdateEvent(deployContext, specJob, specJob.Name, getChangedFields(clusterJob, specJob, jobDiffOpts), err == nil, err)
 		return nil, err
 	}
 
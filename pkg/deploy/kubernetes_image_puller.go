@@ -31,6 +31,12 @@ import (
 
 var imagePullerFinalizerName = "kubernetesimagepullers.finalizers.che.eclipse.org"
 
+const (
+	// Names the image puller operator defaults to when none is set in the KubernetesImagePuller spec
+	DefaultImagePullerDeploymentName = "kubernetes-image-puller"
+	DefaultImagePullerConfigMapName  = "k8s-image-puller"
+)
+
 // Reconcile the imagePuller section of the CheCluster CR.  If imagePuller.enable is set to true, install the Kubernetes Image Puller operator and create
 // a KubernetesImagePuller CR.  Add a finalizer to the CheCluster CR.  If false, remove the KubernetesImagePuller CR, uninstall the operator, and remove the finalizer.
 func ReconcileImagePuller(ctx *DeployContext) (reconcile.Result, error) {
@@ -108,25 +114,15 @@ func ReconcileImagePuller(ctx *DeployContext) (reconcile.Result, error) {
 		}
 		// If the KubernetesImagePuller API service exists, attempt to reconcile creation/update
 		if foundKubernetesImagePullerAPI {
+			// The image puller operator updates the KubernetesImagePuller with the default config map and deployment names
+			// if none are given. They are set in memory, otherwise che-operator would be stuck in an update loop.
+			SetImagePullerSpecDefaults(ctx.CheCluster)
+
 			// Check KubernetesImagePuller options
 			imagePuller := &chev1alpha1.KubernetesImagePuller{}
 			err := ctx.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Namespace: ctx.CheCluster.Namespace, Name: ctx.CheCluster.Name + "-image-puller"}, imagePuller)
 			if err != nil {
 				if errors.IsNotFound(err) {
-
-					// If the image puller spec is empty, set default values, update the CheCluster CR and requeue
-					// These assignments are needed because the image puller operator updates the CR with a default configmap and deployment name
-					// if none are given.  Without these, che-operator will be stuck in an update loop
-					if ctx.CheCluster.IsImagePullerSpecEmpty() {
-						logrus.Infof("Updating CheCluster to set KubernetesImagePuller default values")
-						_, err := UpdateImagePullerSpecIfEmpty(ctx)
-						if err != nil {
-							logrus.Errorf("Error updating CheCluster: %v", err)
-							return reconcile.Result{}, err
-						}
-						return reconcile.Result{Requeue: true}, nil
-					}
-
 					logrus.Infof("Creating KubernetesImagePuller for CheCluster %v", ctx.CheCluster.Name)
 					createdImagePuller, err := CreateKubernetesImagePuller(ctx)
 					if err != nil {
@@ -218,9 +214,10 @@ func ReconcileImagePullerFinalizer(ctx *DeployContext) (err error) {
 
 func DeleteImagePullerFinalizer(ctx *DeployContext) (err error) {
 	instance := ctx.CheCluster
-	instance.ObjectMeta.Finalizers = util.DoRemoveString(instance.ObjectMeta.Finalizers, imagePullerFinalizerName)
 	logrus.Infof("Removing image puller finalizer on %s CR", instance.Name)
-	if err := ctx.ClusterAPI.Client.Update(context.Background(), instance); err != nil {
+	if err := updateFinalizers(ctx, func(finalizers []string) []string {
+		return util.DoRemoveString(finalizers, imagePullerFinalizerName)
+	}); err != nil {
 		logrus.Errorf("Failed to update %s CR: %s", instance.Name, err)
 		return err
 	}
@@ -363,20 +360,11 @@ func CompareExpectedSubscription(ctx *DeployContext, packageManifest *packagesv1
 	return SubscriptionsAreEqual(expected, actual), nil
 }
 
-// Update the CheCluster ImagePuller spec if the default values are not set
-// returns the updated spec and an error during update
-func UpdateImagePullerSpecIfEmpty(ctx *DeployContext) (orgv1.CheClusterSpecImagePuller, error) {
-	if ctx.CheCluster.Spec.ImagePuller.Spec.DeploymentName == "" {
-		ctx.CheCluster.Spec.ImagePuller.Spec.DeploymentName = "kubernetes-image-puller"
-	}
-	if ctx.CheCluster.Spec.ImagePuller.Spec.ConfigMapName == "" {
-		ctx.CheCluster.Spec.ImagePuller.Spec.ConfigMapName = "k8s-image-puller"
-	}
-	err := ctx.ClusterAPI.Client.Update(context.TODO(), ctx.CheCluster, &client.UpdateOptions{})
-	if err != nil {
-		return ctx.CheCluster.Spec.ImagePuller, err
-	}
-	return ctx.CheCluster.Spec.ImagePuller, nil
+// Set in memory the default values of the CheCluster ImagePuller spec which are not set
+func SetImagePullerSpecDefaults(cheCluster *orgv1.CheCluster) {
+	imagePuller := &cheCluster.Spec.ImagePuller.Spec
+	imagePuller.DeploymentName = util.GetValue(imagePuller.DeploymentName, DefaultImagePullerDeploymentName)
+	imagePuller.ConfigMapName = util.GetValue(imagePuller.ConfigMapName, DefaultImagePullerConfigMapName)
 }
 
 func CreateKubernetesImagePuller(ctx *DeployContext) (bool, error) {
@@ -406,14 +394,14 @@ func GetExpectedKubernetesImagePuller(ctx *DeployContext) *chev1alpha1.Kubernete
 	}
 }
 
-// Unisntall the CSV, OperatorGroup, Subscription, KubernetesImagePuller, and update the CheCluster to remove
-// the image puller spec.  Returns true if the CheCluster was updated
+// Unisntall the CSV, OperatorGroup, Subscription and KubernetesImagePuller.  The CheCluster image puller spec
+// is left as is.  Returns true if any of the objects was deleted
 func UninstallImagePullerOperator(ctx *DeployContext) (bool, error) {
-	updated := false
+	removed := false
 
 	_, hasOperatorsAPIs, hasImagePullerAPIs, err := CheckNeededImagePullerApis(ctx)
 	if err != nil {
-		return updated, err
+		return removed, err
 	}
 
 	if hasImagePullerAPIs {
@@ -421,13 +409,14 @@ func UninstallImagePullerOperator(ctx *DeployContext) (bool, error) {
 		imagePuller := &chev1alpha1.KubernetesImagePuller{}
 		err := ctx.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Namespace: ctx.CheCluster.Namespace, Name: ctx.CheCluster.Name + "-image-puller"}, imagePuller)
 		if err != nil && !errors.IsNotFound(err) {
-			return updated, err
+			return removed, err
 		}
 		if imagePuller.Name != "" {
 			logrus.Infof("Deleting KubernetesImagePuller %v", imagePuller.Name)
 			if err = ctx.ClusterAPI.Client.Delete(context.TODO(), imagePuller, &client.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
-				return updated, err
+				return removed, err
 			}
+			removed = true
 		}
 	}
 
@@ -436,56 +425,49 @@ func UninstallImagePullerOperator(ctx *DeployContext) (bool, error) {
 		csv := &operatorsv1alpha1.ClusterServiceVersion{}
 		err = ctx.ClusterAPI.NonCachedClient.Get(context.TODO(), types.NamespacedName{Namespace: ctx.CheCluster.Namespace, Name: DefaultKubernetesImagePullerOperatorCSV()}, csv)
 		if err != nil && !errors.IsNotFound(err) {
-			return updated, err
+			return removed, err
 		}
 
 		if csv.Name != "" {
 			logrus.Infof("Deleting ClusterServiceVersion %v", csv.Name)
 			err := ctx.ClusterAPI.NonCachedClient.Delete(context.TODO(), csv, &client.DeleteOptions{})
 			if err != nil && !errors.IsNotFound(err) {
-				return updated, err
+				return removed, err
 			}
+			removed = true
 		}
 
 		// Delete the Subscription
 		subscription := &operatorsv1alpha1.Subscription{}
 		err = ctx.ClusterAPI.NonCachedClient.Get(context.TODO(), types.NamespacedName{Namespace: ctx.CheCluster.Namespace, Name: "kubernetes-imagepuller-operator"}, subscription)
 		if err != nil && !errors.IsNotFound(err) {
-			return updated, err
+			return removed, err
 		}
 
 		if subscription.Name != "" {
 			logrus.Infof("Deleting Subscription %v", subscription.Name)
 			err := ctx.ClusterAPI.NonCachedClient.Delete(context.TODO(), subscription, &client.DeleteOptions{})
 			if err != nil && !errors.IsNotFound(err) {
-				return updated, err
+				return removed, err
 			}
+			removed = true
 		}
 		// Delete the OperatorGroup if it was created
 		operatorGroup := &operatorsv1.OperatorGroup{}
 		err = ctx.ClusterAPI.NonCachedClient.Get(context.TODO(), types.NamespacedName{Namespace: ctx.CheCluster.Namespace, Name: "kubernetes-imagepuller-operator"}, operatorGroup)
 		if err != nil && !errors.IsNotFound(err) {
-			return updated, err
+			return removed, err
 		}
 
 		if operatorGroup.Name != "" {
 			logrus.Infof("Deleting OperatorGroup %v", operatorGroup.Name)
 			err := ctx.ClusterAPI.NonCachedClient.Delete(context.TODO(), operatorGroup, &client.DeleteOptions{})
 			if err != nil && !errors.IsNotFound(err) {
-				return updated, err
+				return removed, err
 			}
+			removed = true
 		}
 	}
 
-	// Update CR to remove imagePullerSpec
-	if ctx.CheCluster.Spec.ImagePuller.Enable || ctx.CheCluster.Spec.ImagePuller.Spec != (chev1alpha1.KubernetesImagePullerSpec{}) {
-		ctx.CheCluster.Spec.ImagePuller.Spec = chev1alpha1.KubernetesImagePullerSpec{}
-		logrus.Infof("Updating CheCluster %v to remove image puller spec", ctx.CheCluster.Name)
-		err := ctx.ClusterAPI.Client.Update(context.TODO(), ctx.CheCluster, &client.UpdateOptions{})
-		if err != nil {
-			return updated, err
-		}
-		updated = true
-	}
-	return updated, nil
+	return removed, nil
 }
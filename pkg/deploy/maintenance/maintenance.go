@@ -0,0 +1,207 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package maintenance
+
+import (
+	"fmt"
+
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	pageFileName   = "maintenance.html"
+	configFileName = "maintenance.conf"
+
+	// The httpd server answers every request with the maintenance page and the 503 status,
+	// so that the clients of Che API don't mistake the page for a response of Che server
+	httpdConfig = `ErrorDocument 503 /` + pageFileName + `
+RedirectMatch 503 ^/(?!maintenance\.html$)
+`
+)
+
+// SyncMaintenancePageToCluster deploys the static page which is shown instead of Che while it is in maintenance mode.
+func SyncMaintenancePageToCluster(deployContext *deploy.DeployContext) (bool, error) {
+	configMapData := map[string]string{
+		pageFileName:   getMaintenancePage(deployContext),
+		configFileName: httpdConfig,
+	}
+	done, err := deploy.SyncConfigMapDataToCluster(deployContext, deploy.MaintenancePageName, configMapData, deploy.MaintenancePageName)
+	if !done {
+		return false, err
+	}
+
+	serviceStatus := deploy.SyncServiceToCluster(deployContext, deploy.MaintenancePageName, []string{"http"}, []int32{8080}, deploy.MaintenancePageName)
+	if !util.IsTestMode() && !serviceStatus.Continue {
+		logrus.Infof("Waiting on service '%s' to be ready", deploy.MaintenancePageName)
+		return false, serviceStatus.Err
+	}
+
+	specDeployment, err := GetSpecMaintenancePageDeployment(deployContext)
+	if err != nil {
+		return false, err
+	}
+	clusterDeployment, err := deploy.GetClusterDeployment(deploy.MaintenancePageName, deployContext.CheCluster.Namespace, deployContext.ClusterAPI.Client)
+	if err != nil {
+		return false, err
+	}
+	return deploy.SyncDeploymentToCluster(deployContext, specDeployment, clusterDeployment, nil, nil)
+}
+
+// DeleteMaintenancePage removes the maintenance page once Che is back.
+func DeleteMaintenancePage(deployContext *deploy.DeployContext) (bool, error) {
+	for _, object := range []metav1.Object{&appsv1.Deployment{}, &corev1.Service{}, &corev1.ConfigMap{}} {
+		if done, err := deploy.DeleteNamespacedObject(deployContext, deploy.MaintenancePageName, object); !done {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func GetSpecMaintenancePageDeployment(deployContext *deploy.DeployContext) (*appsv1.Deployment, error) {
+	labels, labelSelector := deploy.GetLabelsAndSelector(deployContext.CheCluster, deploy.MaintenancePageName)
+	image := deploy.DefaultMaintenancePageImage(deployContext.CheCluster)
+	replicas := int32(1)
+	terminationGracePeriodSeconds := int64(10)
+	probe := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   "/" + pageFileName,
+				Port:   intstr.FromInt(8080),
+				Scheme: corev1.URISchemeHTTP,
+			},
+		},
+		InitialDelaySeconds: 3,
+		FailureThreshold:    10,
+		TimeoutSeconds:      3,
+		SuccessThreshold:    1,
+		PeriodSeconds:       10,
+	}
+
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploy.MaintenancePageName,
+			Namespace: deployContext.CheCluster.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labelSelector},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            deploy.MaintenancePageName,
+							Image:           image,
+							ImagePullPolicy: corev1.PullPolicy(deploy.DefaultPullPolicyFromDockerImage(image)),
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: 8080,
+									Protocol:      "TCP",
+								},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("32Mi"),
+									corev1.ResourceCPU:    resource.MustParse("10m"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("128Mi"),
+									corev1.ResourceCPU:    resource.MustParse("100m"),
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "page",
+									MountPath: "/var/www/html",
+								},
+								{
+									Name:      "config",
+									MountPath: "/opt/app-root/etc/httpd.d",
+								},
+							},
+							ReadinessProbe: probe,
+							LivenessProbe:  probe,
+							SecurityContext: &corev1.SecurityContext{
+								Capabilities: &corev1.Capabilities{
+									Drop: []corev1.Capability{"ALL"},
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						getConfigMapVolume("page", pageFileName),
+						getConfigMapVolume("config", configFileName),
+					},
+					RestartPolicy:                 "Always",
+					TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
+				},
+			},
+		},
+	}
+
+	if !util.IsTestMode() {
+		err := controllerutil.SetControllerReference(deployContext.CheCluster, deployment, deployContext.ClusterAPI.Scheme)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return deployment, nil
+}
+
+func getConfigMapVolume(name string, key string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: deploy.MaintenancePageName,
+				},
+				Items: []corev1.KeyToPath{{Key: key, Path: key}},
+			},
+		},
+	}
+}
+
+func getMaintenancePage(deployContext *deploy.DeployContext) string {
+	name := "Eclipse Che"
+	if deploy.DefaultCheFlavor(deployContext.CheCluster) == "codeready" {
+		name = "CodeReady Workspaces"
+	}
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s is under maintenance</title>
+</head>
+<body>
+<h1>%s is under maintenance</h1>
+<p>%s is temporarily unavailable. Please try again later.</p>
+</body>
+</html>
+`, name, name, name)
+}
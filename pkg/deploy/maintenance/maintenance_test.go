@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2012-2019 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package maintenance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncMaintenancePage(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme)
+	deployContext := &deploy.DeployContext{
+		CheCluster: &orgv1.CheCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "eclipse-che",
+				Namespace:   "eclipse-che",
+				Annotations: map[string]string{deploy.CheEclipseOrgMaintenanceMode: "true"},
+			},
+		},
+		ClusterAPI: deploy.ClusterAPI{
+			Client: cli,
+			Scheme: scheme.Scheme,
+		},
+	}
+
+	// the deployment is created once the config map and the service exist
+	for i := 0; i < 3; i++ {
+		if _, err := SyncMaintenancePageToCluster(deployContext); err != nil {
+			t.Fatalf("Failed to sync maintenance page: %v", err)
+		}
+	}
+
+	key := types.NamespacedName{Name: deploy.MaintenancePageName, Namespace: "eclipse-che"}
+	configMap := &corev1.ConfigMap{}
+	if err := cli.Get(context.TODO(), key, configMap); err != nil {
+		t.Fatalf("Config map not found: %v", err)
+	}
+	if !strings.Contains(configMap.Data[pageFileName], "Eclipse Che is under maintenance") {
+		t.Fatalf("Unexpected maintenance page: %s", configMap.Data[pageFileName])
+	}
+	if err := cli.Get(context.TODO(), key, &corev1.Service{}); err != nil {
+		t.Fatalf("Service not found: %v", err)
+	}
+	deployment := &appsv1.Deployment{}
+	if err := cli.Get(context.TODO(), key, deployment); err != nil {
+		t.Fatalf("Deployment not found: %v", err)
+	}
+	if deployment.Spec.Template.Spec.Containers[0].Image != deploy.DefaultMaintenancePageImage(deployContext.CheCluster) {
+		t.Fatalf("Unexpected image: %s", deployment.Spec.Template.Spec.Containers[0].Image)
+	}
+	util.ValidateSecurityContext(deployment, t)
+
+	done, err := DeleteMaintenancePage(deployContext)
+	if !done {
+		t.Fatalf("Failed to delete maintenance page: %v", err)
+	}
+	for _, object := range []runtime.Object{&appsv1.Deployment{}, &corev1.Service{}, &corev1.ConfigMap{}} {
+		if err := cli.Get(context.TODO(), key, object); err == nil {
+			t.Fatalf("%T is expected to be deleted", object)
+		}
+	}
+}
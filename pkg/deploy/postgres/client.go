@@ -0,0 +1,146 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	connectTimeout = 10 * time.Second
+	queryTimeout   = 30 * time.Second
+)
+
+// SQLSTATE codes of the errors handled by the Operator
+const (
+	DuplicateObjectErrorCode   = "42710"
+	DuplicateDatabaseErrorCode = "42P04"
+	UndefinedTableErrorCode    = "42P01"
+	InvalidPasswordErrorCode   = "28P01"
+)
+
+// IsErrorCode returns true if the error is reported by the PostgreSQL server with the given SQLSTATE code.
+func IsErrorCode(err error, code string) bool {
+	pgErr, ok := err.(*pq.Error)
+	return ok && string(pgErr.Code) == code
+}
+
+// Client runs queries over a single connection to the PostgreSQL server.
+type Client struct {
+	db   *sql.DB
+	conn *sql.Conn
+}
+
+// Connect opens a connection to the database as the given user.
+// The connection is encrypted when the server supports TLS, as with the `prefer` SSL mode of libpq.
+func Connect(address string, user string, password string, database string) (*Client, error) {
+	client, err := connect(address, user, password, database, "require")
+	if err == pq.ErrSSLNotSupported {
+		return connect(address, user, password, database, "disable")
+	}
+	return client, err
+}
+
+func connect(address string, user string, password string, database string, sslMode string) (*Client, error) {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     address,
+		Path:     "/" + database,
+		RawQuery: url.Values{"sslmode": {sslMode}, "connect_timeout": {strconv.Itoa(int(connectTimeout.Seconds()))}}.Encode(),
+	}
+	connector, err := pq.NewConnector(dsn.String())
+	if err != nil {
+		return nil, err
+	}
+
+	db := sql.OpenDB(connector)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Client{db: db, conn: conn}, nil
+}
+
+// Close terminates the connection.
+func (c *Client) Close() error {
+	c.conn.Close()
+	return c.db.Close()
+}
+
+// Exec runs the statements, which may be separated by semicolons.
+func (c *Client) Exec(query string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	_, err := c.conn.ExecContext(ctx, query)
+	return err
+}
+
+// Query runs the query and returns the rows of its result, NULL values are returned as empty strings.
+func (c *Client) Query(query string) ([][]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	rows, err := c.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result [][]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make([]string, len(columns))
+		for i, value := range values {
+			row[i] = value.String
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// QuoteIdentifier quotes the identifier, such as a user name, to be used in a query.
+func QuoteIdentifier(identifier string) string {
+	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+}
+
+// QuoteLiteral quotes the string literal, such as a password, to be used in a query.
+func QuoteLiteral(literal string) string {
+	literal = strings.Replace(literal, `'`, `''`, -1)
+	if strings.Contains(literal, `\`) {
+		return `E'` + strings.Replace(literal, `\`, `\\`, -1) + `'`
+	}
+	return `'` + literal + `'`
+}
@@ -0,0 +1,376 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+const (
+	testUser     = "postgres"
+	testPassword = "pass'word"
+
+	sslRequestCode = 80877103
+
+	authenticationOk           = 0
+	authenticationMD5Password  = 5
+	authenticationSASL         = 10
+	authenticationSASLContinue = 11
+	authenticationSASLFinal    = 12
+
+	scramSHA256Mechanism = "SCRAM-SHA-256"
+)
+
+// fakePostgres is an in-process server which speaks PostgreSQL protocol.
+// TLS isn't supported. Queries are answered by the handler, and are recorded in the order they are received.
+type fakePostgres struct {
+	listener net.Listener
+	// authentication method: md5, scram or trust
+	auth    string
+	handler func(query string) ([][]string, *pq.Error)
+
+	mutex   sync.Mutex
+	queries []string
+}
+
+func newFakePostgres(t *testing.T, auth string, handler func(query string) ([][]string, *pq.Error)) *fakePostgres {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := &fakePostgres{listener: listener, auth: auth, handler: handler}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakePostgres) close() {
+	s.listener.Close()
+}
+
+func (s *fakePostgres) address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakePostgres) getQueries() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.queries...)
+}
+
+func (s *fakePostgres) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	startup, err := readStartupMessage(reader)
+	if err != nil {
+		return
+	}
+	if binary.BigEndian.Uint32(startup) == sslRequestCode {
+		conn.Write([]byte{'N'})
+		return
+	}
+	params := strings.Split(string(startup[4:]), "\x00")
+	user := ""
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i] == "user" {
+			user = params[i+1]
+		}
+	}
+
+	if !s.authenticate(conn, reader, user) {
+		writeMessage(conn, 'E', errorBody(&pq.Error{Severity: "FATAL", Code: InvalidPasswordErrorCode, Message: "password authentication failed"}))
+		return
+	}
+	writeMessage(conn, 'R', int32Bytes(authenticationOk))
+	writeMessage(conn, 'Z', []byte{'I'})
+
+	for {
+		messageType, body, err := readMessage(reader)
+		if err != nil || messageType == 'X' {
+			return
+		}
+		if messageType != 'Q' {
+			continue
+		}
+
+		query := strings.TrimSuffix(string(body), "\x00")
+		s.mutex.Lock()
+		s.queries = append(s.queries, query)
+		s.mutex.Unlock()
+
+		rows, queryErr := s.handler(query)
+		if queryErr != nil {
+			writeMessage(conn, 'E', errorBody(queryErr))
+		} else {
+			writeRows(conn, rows)
+		}
+		writeMessage(conn, 'Z', []byte{'I'})
+	}
+}
+
+func (s *fakePostgres) authenticate(conn net.Conn, reader *bufio.Reader, user string) bool {
+	switch s.auth {
+	case "md5":
+		salt := []byte{1, 2, 3, 4}
+		writeMessage(conn, 'R', append(int32Bytes(authenticationMD5Password), salt...))
+		_, body, err := readMessage(reader)
+		return err == nil && string(body) == string(cstring(md5Password(user, testPassword, salt)))
+	case "scram":
+		writeMessage(conn, 'R', append(int32Bytes(authenticationSASL), append(cstring(scramSHA256Mechanism), 0)...))
+		_, body, err := readMessage(reader)
+		if err != nil || !bytes.HasPrefix(body, cstring(scramSHA256Mechanism)) {
+			return false
+		}
+		clientFirst := string(body[len(scramSHA256Mechanism)+5:])
+		clientFirstBare := strings.TrimPrefix(clientFirst, "n,,")
+		nonce := parseScramAttributes(clientFirstBare)["r"] + "server"
+		salt := []byte("salt")
+		serverFirst := "r=" + nonce + ",s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+		writeMessage(conn, 'R', append(int32Bytes(authenticationSASLContinue), serverFirst...))
+
+		_, body, err = readMessage(reader)
+		if err != nil {
+			return false
+		}
+		clientFinal := string(body)
+		proofIndex := strings.Index(clientFinal, ",p=")
+		proof, _ := base64.StdEncoding.DecodeString(clientFinal[proofIndex+3:])
+		authMessage := clientFirstBare + "," + serverFirst + "," + clientFinal[:proofIndex]
+
+		saltedPassword := pbkdf2SHA256([]byte(testPassword), salt, 4096)
+		clientKey := hmacSHA256(saltedPassword, []byte("Client Key"))
+		storedKey := sha256.Sum256(clientKey)
+		clientSignature := hmacSHA256(storedKey[:], []byte(authMessage))
+		for i := range proof {
+			proof[i] ^= clientSignature[i]
+		}
+		if !bytes.Equal(proof, clientKey) {
+			return false
+		}
+
+		serverKey := hmacSHA256(saltedPassword, []byte("Server Key"))
+		serverFinal := "v=" + base64.StdEncoding.EncodeToString(hmacSHA256(serverKey, []byte(authMessage)))
+		writeMessage(conn, 'R', append(int32Bytes(authenticationSASLFinal), serverFinal...))
+		return true
+	}
+	return true
+}
+
+func readStartupMessage(reader *bufio.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header)-4)
+	_, err := io.ReadFull(reader, body)
+	return body, err
+}
+
+func readMessage(reader *bufio.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	_, err := io.ReadFull(reader, body)
+	return header[0], body, err
+}
+
+func writeMessage(conn net.Conn, messageType byte, body []byte) {
+	message := append([]byte{messageType}, int32Bytes(len(body)+4)...)
+	conn.Write(append(message, body...))
+}
+
+// writeRows sends the rows as text columns, followed by the completion of the command.
+func writeRows(conn net.Conn, rows [][]string) {
+	if len(rows) > 0 {
+		description := int16Bytes(len(rows[0]))
+		for i := range rows[0] {
+			description = append(description, cstring(fmt.Sprintf("column%d", i))...)
+			// table, column, text type, type size, type modifier and text format
+			description = append(description, int32Bytes(0)...)
+			description = append(description, int16Bytes(0)...)
+			description = append(description, int32Bytes(25)...)
+			description = append(description, int16Bytes(-1)...)
+			description = append(description, int32Bytes(-1)...)
+			description = append(description, int16Bytes(0)...)
+		}
+		writeMessage(conn, 'T', description)
+	}
+	for _, row := range rows {
+		data := int16Bytes(len(row))
+		for _, value := range row {
+			data = append(data, int32Bytes(len(value))...)
+			data = append(data, value...)
+		}
+		writeMessage(conn, 'D', data)
+	}
+	writeMessage(conn, 'C', cstring(fmt.Sprintf("SELECT %d", len(rows))))
+}
+
+func errorBody(err *pq.Error) []byte {
+	body := append([]byte{'S'}, cstring(err.Severity)...)
+	body = append(body, 'C')
+	body = append(body, cstring(string(err.Code))...)
+	body = append(body, 'M')
+	body = append(body, cstring(err.Message)...)
+	return append(body, 0)
+}
+
+func md5Password(user string, password string, salt []byte) string {
+	inner := md5.Sum([]byte(password + user))
+	outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+	return "md5" + hex.EncodeToString(outer[:])
+}
+
+func parseScramAttributes(message string) map[string]string {
+	attributes := map[string]string{}
+	for _, attribute := range strings.Split(message, ",") {
+		if len(attribute) > 2 && attribute[1] == '=' {
+			attributes[attribute[:1]] = attribute[2:]
+		}
+	}
+	return attributes
+}
+
+func hmacSHA256(key []byte, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// pbkdf2SHA256 derives a key of the SHA-256 size, see RFC 8018.
+func pbkdf2SHA256(password []byte, salt []byte, iterations int) []byte {
+	u := hmacSHA256(password, append(append([]byte{}, salt...), 0, 0, 0, 1))
+	result := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		u = hmacSHA256(password, u)
+		for j := range result {
+			result[j] ^= u[j]
+		}
+	}
+	return result
+}
+
+func cstring(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+func int16Bytes(i int) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, uint16(i))
+	return b
+}
+
+func int32Bytes(i int) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(i))
+	return b
+}
+
+func TestConnect(t *testing.T) {
+	for _, auth := range []string{"md5", "scram", "trust"} {
+		t.Run(auth, func(t *testing.T) {
+			server := newFakePostgres(t, auth, func(query string) ([][]string, *pq.Error) {
+				return [][]string{{"1", ""}}, nil
+			})
+			defer server.close()
+
+			client, err := Connect(server.address(), testUser, testPassword, "postgres")
+			if err != nil {
+				t.Fatalf("Failed to connect: %v", err)
+			}
+			defer client.Close()
+
+			rows, err := client.Query("SELECT 1, NULL")
+			if err != nil {
+				t.Fatalf("Failed to query: %v", err)
+			}
+			if len(rows) != 1 || rows[0][0] != "1" || rows[0][1] != "" {
+				t.Fatalf("Unexpected rows: %v", rows)
+			}
+		})
+	}
+}
+
+func TestConnectWithInvalidPassword(t *testing.T) {
+	for _, auth := range []string{"md5", "scram"} {
+		t.Run(auth, func(t *testing.T) {
+			server := newFakePostgres(t, auth, nil)
+			defer server.close()
+
+			_, err := Connect(server.address(), testUser, "invalid", "postgres")
+			if err == nil {
+				t.Fatal("Connection with invalid password expected to fail")
+			}
+		})
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	server := newFakePostgres(t, "md5", func(query string) ([][]string, *pq.Error) {
+		return nil, &pq.Error{Severity: "ERROR", Code: UndefinedTableErrorCode, Message: "relation \"realm\" does not exist"}
+	})
+	defer server.close()
+
+	client, err := Connect(server.address(), testUser, testPassword, "keycloak")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.Query("SELECT ssl_required FROM realm")
+	if !IsErrorCode(err, UndefinedTableErrorCode) {
+		t.Fatalf("Expected undefined table error, but got: %v", err)
+	}
+
+	// the connection is still usable after the error
+	if err := client.Exec("SELECT 1"); !IsErrorCode(err, UndefinedTableErrorCode) {
+		t.Fatalf("Expected undefined table error, but got: %v", err)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if quoted := QuoteIdentifier(`us"er`); quoted != `"us""er"` {
+		t.Errorf("Unexpected quoted identifier: %s", quoted)
+	}
+	if quoted := QuoteLiteral(`pa'ss`); quoted != `'pa''ss'` {
+		t.Errorf("Unexpected quoted literal: %s", quoted)
+	}
+	if quoted := QuoteLiteral(`pa\ss`); quoted != `E'pa\\ss'` {
+		t.Errorf("Unexpected quoted literal: %s", quoted)
+	}
+}
@@ -99,7 +99,7 @@ func TestDeployment(t *testing.T) {
 				Proxy: &deploy.Proxy{},
 			}
 
-			deployment, err := GetSpecPostgresDeployment(deployContext, nil)
+			deployment, err := GetSpecPostgresDeployment(deployContext)
 			if err != nil {
 				t.Fatalf("Error creating deployment: %v", err)
 			}
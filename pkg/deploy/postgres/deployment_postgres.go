@@ -12,6 +12,8 @@
 package postgres
 
 import (
+	"fmt"
+
 	"github.com/eclipse-che/che-operator/pkg/deploy"
 	"github.com/eclipse-che/che-operator/pkg/util"
 	appsv1 "k8s.io/api/apps/v1"
@@ -21,8 +23,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-var (
-	postgresAdminPassword = util.GeneratePasswd(12)
+const (
+	// postgresAdminSecret stores the password of the PostgreSQL superuser
+	postgresAdminSecret      = "che-postgres-admin-secret"
+	postgresAdminPasswordKey = "password"
 )
 
 func SyncPostgresDeploymentToCluster(deployContext *deploy.DeployContext) (bool, error) {
@@ -31,7 +35,11 @@ func SyncPostgresDeploymentToCluster(deployContext *deploy.DeployContext) (bool,
 		return false, err
 	}
 
-	specDeployment, err := GetSpecPostgresDeployment(deployContext, clusterDeployment)
+	if err := syncPostgresAdminSecret(deployContext, clusterDeployment); err != nil {
+		return false, err
+	}
+
+	specDeployment, err := GetSpecPostgresDeployment(deployContext)
 	if err != nil {
 		return false, err
 	}
@@ -39,8 +47,53 @@ func SyncPostgresDeploymentToCluster(deployContext *deploy.DeployContext) (bool,
 	return deploy.SyncDeploymentToCluster(deployContext, specDeployment, clusterDeployment, nil, nil)
 }
 
-func GetSpecPostgresDeployment(deployContext *deploy.DeployContext, clusterDeployment *appsv1.Deployment) (*appsv1.Deployment, error) {
-	isOpenShift, _, err := util.DetectOpenShift()
+// syncPostgresAdminSecret generates the password of the PostgreSQL superuser, unless the secret exists already.
+// The password set in plain text by the former deployment is kept, since it's the one stored in the data volume.
+func syncPostgresAdminSecret(deployContext *deploy.DeployContext, clusterDeployment *appsv1.Deployment) error {
+	secret, err := deploy.GetSecret(deployContext, postgresAdminSecret, deployContext.CheCluster.Namespace)
+	if err != nil || secret != nil {
+		return err
+	}
+
+	password := util.GeneratePasswd(12)
+	if clusterDeployment != nil {
+		if env := util.FindEnv(clusterDeployment.Spec.Template.Spec.Containers[0].Env, "POSTGRESQL_ADMIN_PASSWORD"); env != nil && env.Value != "" {
+			password = env.Value
+		}
+	}
+	_, err = deploy.SyncSecret(deployContext, postgresAdminSecret, deployContext.CheCluster.Namespace, map[string][]byte{postgresAdminPasswordKey: []byte(password)})
+	return err
+}
+
+// getPostgresAdminPassword reads the password of the PostgreSQL superuser from its secret.
+func getPostgresAdminPassword(deployContext *deploy.DeployContext) (string, error) {
+	secret, err := deploy.GetSecret(deployContext, postgresAdminSecret, deployContext.CheCluster.Namespace)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil {
+		return "", fmt.Errorf("secret '%s' not found", postgresAdminSecret)
+	}
+	return string(secret.Data[postgresAdminPasswordKey]), nil
+}
+
+// getPostgresAdminPasswordEnv returns the env var which sets the password of the PostgreSQL superuser from its secret.
+func getPostgresAdminPasswordEnv(name string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				Key: postgresAdminPasswordKey,
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: postgresAdminSecret,
+				},
+			},
+		},
+	}
+}
+
+func GetSpecPostgresDeployment(deployContext *deploy.DeployContext) (*appsv1.Deployment, error) {
+	isOpenShift, _, err := deploy.DetectOpenShift(deployContext)
 	if err != nil {
 		return nil, err
 	}
@@ -48,17 +101,9 @@ func GetSpecPostgresDeployment(deployContext *deploy.DeployContext, clusterDeplo
 	terminationGracePeriodSeconds := int64(30)
 	labels, labelSelector := deploy.GetLabelsAndSelector(deployContext.CheCluster, deploy.PostgresName)
 	chePostgresDb := util.GetValue(deployContext.CheCluster.Spec.Database.ChePostgresDb, "dbche")
-	postgresImage := util.GetValue(deployContext.CheCluster.Spec.Database.PostgresImage, deploy.DefaultPostgresImage(deployContext.CheCluster))
+	postgresImage := getPostgresImage(deployContext.CheCluster)
 	pullPolicy := corev1.PullPolicy(util.GetValue(string(deployContext.CheCluster.Spec.Database.PostgresImagePullPolicy), deploy.DefaultPullPolicyFromDockerImage(postgresImage)))
 
-	if clusterDeployment != nil {
-		clusterContainer := &clusterDeployment.Spec.Template.Spec.Containers[0]
-		env := util.FindEnv(clusterContainer.Env, "POSTGRESQL_ADMIN_PASSWORD")
-		if env != nil {
-			postgresAdminPassword = env.Value
-		}
-	}
-
 	deployment := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Deployment",
@@ -164,10 +209,7 @@ func GetSpecPostgresDeployment(deployContext *deploy.DeployContext, clusterDeplo
 									Name:  "POSTGRESQL_DATABASE",
 									Value: chePostgresDb,
 								},
-								{
-									Name:  "POSTGRESQL_ADMIN_PASSWORD",
-									Value: postgresAdminPassword,
-								},
+								getPostgresAdminPasswordEnv("POSTGRESQL_ADMIN_PASSWORD"),
 							}},
 					},
 					TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
@@ -0,0 +1,187 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PostgresAdminUser is the superuser of the PostgreSQL deployed by the Operator
+	PostgresAdminUser = "postgres"
+	// KeycloakPostgresUser is the PostgreSQL user of Keycloak
+	KeycloakPostgresUser = "keycloak"
+	// KeycloakPostgresDatabase is the PostgreSQL database of Keycloak
+	KeycloakPostgresDatabase = "keycloak"
+
+	// standbyUserSuffix is appended to the name of a user to get the name of the login role
+	// which takes over from the user while its credentials are rotated
+	standbyUserSuffix = "_standby"
+
+	postgresPort = 5432
+	// the maintenance database, which always exists
+	postgresDatabase = "postgres"
+)
+
+var (
+	// ConnectAsAdmin opens a connection to the given database of the PostgreSQL deployed by the Operator
+	// as the superuser. Replaced in tests.
+	ConnectAsAdmin = func(deployContext *deploy.DeployContext, database string) (*Client, error) {
+		password, err := getPostgresAdminPassword(deployContext)
+		if err != nil {
+			return nil, err
+		}
+
+		return Connect(GetPostgresAddress(deployContext), PostgresAdminUser, password, database)
+	}
+)
+
+// GetPostgresAddress returns the address of the PostgreSQL service deployed by the Operator.
+func GetPostgresAddress(deployContext *deploy.DeployContext) string {
+	return fmt.Sprintf("%s.%s.svc:%d", deploy.PostgresName, deployContext.CheCluster.Namespace, postgresPort)
+}
+
+// ProvisionDatabases creates the user and the database of Keycloak, and grants the superuser role to the Che user.
+// The Keycloak user and database are skipped when keycloakPassword is empty, i.e. Keycloak isn't deployed.
+// It can be called repeatedly: objects which already exist are left as is.
+func ProvisionDatabases(deployContext *deploy.DeployContext, cheUser string, keycloakPassword string) error {
+	client, err := ConnectAsAdmin(deployContext, postgresDatabase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return provisionDatabases(client, cheUser, keycloakPassword)
+}
+
+func provisionDatabases(client *Client, cheUser string, keycloakPassword string) error {
+	if keycloakPassword != "" {
+		if err := provisionKeycloakDatabase(client, keycloakPassword); err != nil {
+			return err
+		}
+	}
+
+	if cheUser != "" {
+		if err := client.Exec(fmt.Sprintf("ALTER USER %s WITH SUPERUSER", QuoteIdentifier(cheUser))); err != nil {
+			return fmt.Errorf("failed to grant superuser role to user '%s': %v", cheUser, err)
+		}
+	}
+
+	logrus.Infof("PostgreSQL databases and users provisioned")
+	return nil
+}
+
+func provisionKeycloakDatabase(client *Client, keycloakPassword string) error {
+	err := client.Exec(fmt.Sprintf("CREATE USER %s WITH PASSWORD %s", QuoteIdentifier(KeycloakPostgresUser), QuoteLiteral(keycloakPassword)))
+	if err != nil && !IsErrorCode(err, DuplicateObjectErrorCode) {
+		return fmt.Errorf("failed to create user '%s': %v", KeycloakPostgresUser, err)
+	}
+
+	err = client.Exec("CREATE DATABASE " + QuoteIdentifier(KeycloakPostgresDatabase))
+	if err != nil && !IsErrorCode(err, DuplicateDatabaseErrorCode) {
+		return fmt.Errorf("failed to create database '%s': %v", KeycloakPostgresDatabase, err)
+	}
+
+	err = client.Exec(fmt.Sprintf("GRANT ALL PRIVILEGES ON DATABASE %s TO %s", QuoteIdentifier(KeycloakPostgresDatabase), QuoteIdentifier(KeycloakPostgresUser)))
+	if err != nil {
+		return fmt.Errorf("failed to grant privileges on database '%s': %v", KeycloakPostgresDatabase, err)
+	}
+	return nil
+}
+
+// GetStandbyUser returns the user which takes over from the given one when its credentials are rotated.
+// The users alternate: the standby user of a standby user is the user it stands for.
+func GetStandbyUser(user string) string {
+	if strings.HasSuffix(user, standbyUserSuffix) {
+		return strings.TrimSuffix(user, standbyUserSuffix)
+	}
+	return user + standbyUserSuffix
+}
+
+// EnableUser sets the password of the given PostgreSQL user. A standby user is created if it doesn't exist yet,
+// as a member of the user it stands for, and switches to that user on login, so the objects it creates
+// belong to the same owner whichever of the users is in use.
+func EnableUser(deployContext *deploy.DeployContext, user string, password string) error {
+	client, err := ConnectAsAdmin(deployContext, postgresDatabase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return enableUser(client, user, password)
+}
+
+func enableUser(client *Client, user string, password string) error {
+	if !strings.HasSuffix(user, standbyUserSuffix) {
+		err := client.Exec(fmt.Sprintf("ALTER USER %s WITH LOGIN PASSWORD %s", QuoteIdentifier(user), QuoteLiteral(password)))
+		if err != nil {
+			return fmt.Errorf("failed to update password of user '%s': %v", user, err)
+		}
+		return nil
+	}
+
+	owner := GetStandbyUser(user)
+	err := client.Exec(fmt.Sprintf("CREATE USER %s WITH PASSWORD %s IN ROLE %s", QuoteIdentifier(user), QuoteLiteral(password), QuoteIdentifier(owner)))
+	if IsErrorCode(err, DuplicateObjectErrorCode) {
+		err = client.Exec(fmt.Sprintf("ALTER USER %s WITH LOGIN PASSWORD %s", QuoteIdentifier(user), QuoteLiteral(password)))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update password of user '%s': %v", user, err)
+	}
+
+	err = client.Exec(fmt.Sprintf("ALTER USER %s SET role TO %s", QuoteIdentifier(user), QuoteIdentifier(owner)))
+	if err != nil {
+		return fmt.Errorf("failed to set role of user '%s': %v", user, err)
+	}
+	return nil
+}
+
+// RevokeUserPassword removes the password of the given PostgreSQL user, so it can't be used to log in anymore.
+func RevokeUserPassword(deployContext *deploy.DeployContext, user string) error {
+	client, err := ConnectAsAdmin(deployContext, postgresDatabase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	err = client.Exec(fmt.Sprintf("ALTER USER %s WITH PASSWORD NULL", QuoteIdentifier(user)))
+	if err != nil {
+		return fmt.Errorf("failed to revoke password of user '%s': %v", user, err)
+	}
+	return nil
+}
+
+// GetServerVersion returns the major version of the PostgreSQL server, for example `9.6` or `13`.
+func GetServerVersion(client *Client) (string, error) {
+	rows, err := client.Query("SHOW server_version_num")
+	if err != nil {
+		return "", err
+	}
+	if len(rows) != 1 || len(rows[0]) != 1 {
+		return "", fmt.Errorf("unexpected response to server version query: %v", rows)
+	}
+
+	number, err := strconv.Atoi(rows[0][0])
+	if err != nil {
+		return "", fmt.Errorf("unexpected server version '%s': %v", rows[0][0], err)
+	}
+	// since 10 the major version is a single number
+	if number >= 100000 {
+		return strconv.Itoa(number / 10000), nil
+	}
+	return fmt.Sprintf("%d.%d", number/10000, number/100%100), nil
+}
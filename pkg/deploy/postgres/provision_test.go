@@ -0,0 +1,190 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package postgres
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestProvisionDatabases(t *testing.T) {
+	server := newFakePostgres(t, "md5", func(query string) ([][]string, *pq.Error) {
+		return nil, nil
+	})
+	defer server.close()
+
+	client, err := Connect(server.address(), testUser, testPassword, postgresDatabase)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	if err := provisionDatabases(client, "che", "key'cloak"); err != nil {
+		t.Fatalf("Failed to provision databases: %v", err)
+	}
+
+	expectedQueries := []string{
+		`CREATE USER "keycloak" WITH PASSWORD 'key''cloak'`,
+		`CREATE DATABASE "keycloak"`,
+		`GRANT ALL PRIVILEGES ON DATABASE "keycloak" TO "keycloak"`,
+		`ALTER USER "che" WITH SUPERUSER`,
+	}
+	if queries := server.getQueries(); !reflect.DeepEqual(queries, expectedQueries) {
+		t.Fatalf("Unexpected queries:\n%s", strings.Join(queries, "\n"))
+	}
+}
+
+func TestProvisionDatabasesWithoutKeycloak(t *testing.T) {
+	server := newFakePostgres(t, "md5", func(query string) ([][]string, *pq.Error) {
+		return nil, nil
+	})
+	defer server.close()
+
+	client, err := Connect(server.address(), testUser, testPassword, postgresDatabase)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	if err := provisionDatabases(client, "che", ""); err != nil {
+		t.Fatalf("Failed to provision databases: %v", err)
+	}
+	expectedQueries := []string{`ALTER USER "che" WITH SUPERUSER`}
+	if queries := server.getQueries(); !reflect.DeepEqual(queries, expectedQueries) {
+		t.Fatalf("Unexpected queries:\n%s", strings.Join(queries, "\n"))
+	}
+}
+
+func TestProvisionExistingDatabases(t *testing.T) {
+	server := newFakePostgres(t, "scram", func(query string) ([][]string, *pq.Error) {
+		if strings.HasPrefix(query, "CREATE USER") {
+			return nil, &pq.Error{Severity: "ERROR", Code: DuplicateObjectErrorCode, Message: "role \"keycloak\" already exists"}
+		}
+		if strings.HasPrefix(query, "CREATE DATABASE") {
+			return nil, &pq.Error{Severity: "ERROR", Code: DuplicateDatabaseErrorCode, Message: "database \"keycloak\" already exists"}
+		}
+		return nil, nil
+	})
+	defer server.close()
+
+	client, err := Connect(server.address(), testUser, testPassword, postgresDatabase)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	if err := provisionDatabases(client, "che", "keycloak"); err != nil {
+		t.Fatalf("Provisioning of existing databases expected to succeed: %v", err)
+	}
+	if queries := server.getQueries(); len(queries) != 4 {
+		t.Fatalf("Expected 4 queries, but got:\n%s", strings.Join(queries, "\n"))
+	}
+}
+
+func TestProvisionDatabasesFailure(t *testing.T) {
+	server := newFakePostgres(t, "md5", func(query string) ([][]string, *pq.Error) {
+		if strings.HasPrefix(query, "GRANT") {
+			return nil, &pq.Error{Severity: "ERROR", Code: "42501", Message: "permission denied"}
+		}
+		return nil, nil
+	})
+	defer server.close()
+
+	client, err := Connect(server.address(), testUser, testPassword, postgresDatabase)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	if err := provisionDatabases(client, "che", "keycloak"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("Expected permission error, but got: %v", err)
+	}
+}
+
+func TestEnableUser(t *testing.T) {
+	server := newFakePostgres(t, "md5", func(query string) ([][]string, *pq.Error) {
+		if strings.HasPrefix(query, `CREATE USER "keycloak_standby"`) {
+			return nil, &pq.Error{Code: DuplicateObjectErrorCode, Message: "role already exists"}
+		}
+		return nil, nil
+	})
+	defer server.close()
+
+	client, err := Connect(server.address(), testUser, testPassword, postgresDatabase)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	if err := enableUser(client, "che", `new\pass`); err != nil {
+		t.Fatalf("Failed to enable user: %v", err)
+	}
+	if err := enableUser(client, "che_standby", "pass"); err != nil {
+		t.Fatalf("Failed to enable user: %v", err)
+	}
+	if err := enableUser(client, "keycloak_standby", "pass"); err != nil {
+		t.Fatalf("Failed to enable user: %v", err)
+	}
+	expectedQueries := []string{
+		`ALTER USER "che" WITH LOGIN PASSWORD E'new\\pass'`,
+		`CREATE USER "che_standby" WITH PASSWORD 'pass' IN ROLE "che"`,
+		`ALTER USER "che_standby" SET role TO "che"`,
+		`CREATE USER "keycloak_standby" WITH PASSWORD 'pass' IN ROLE "keycloak"`,
+		`ALTER USER "keycloak_standby" WITH LOGIN PASSWORD 'pass'`,
+		`ALTER USER "keycloak_standby" SET role TO "keycloak"`,
+	}
+	if queries := server.getQueries(); !reflect.DeepEqual(queries, expectedQueries) {
+		t.Fatalf("Unexpected queries:\n%s", strings.Join(queries, "\n"))
+	}
+}
+
+func TestGetStandbyUser(t *testing.T) {
+	if user := GetStandbyUser("pgche"); user != "pgche_standby" {
+		t.Errorf("Expected standby user 'pgche_standby', but got '%s'", user)
+	}
+	if user := GetStandbyUser("pgche_standby"); user != "pgche" {
+		t.Errorf("Expected standby user 'pgche', but got '%s'", user)
+	}
+}
+
+func TestGetServerVersion(t *testing.T) {
+	testCases := map[string]string{
+		"90624":  "9.6",
+		"100017": "10",
+		"130003": "13",
+	}
+
+	for versionNum, expectedVersion := range testCases {
+		server := newFakePostgres(t, "md5", func(query string) ([][]string, *pq.Error) {
+			return [][]string{{versionNum}}, nil
+		})
+
+		client, err := Connect(server.address(), testUser, testPassword, postgresDatabase)
+		if err != nil {
+			server.close()
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		version, err := GetServerVersion(client)
+		client.Close()
+		server.close()
+
+		if err != nil {
+			t.Fatalf("Failed to get server version: %v", err)
+		}
+		if version != expectedVersion {
+			t.Errorf("Expected version '%s' for '%s', but got '%s'", expectedVersion, versionNum, version)
+		}
+	}
+}
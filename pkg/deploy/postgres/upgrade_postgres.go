@@ -0,0 +1,649 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// PostgresUpgradeBackupVolumeClaimName is the claim the databases are dumped to before a major version upgrade.
+	// The dump is kept after the upgrade as a backup of the previous version.
+	PostgresUpgradeBackupVolumeClaimName = "postgres-upgrade-backup"
+
+	postgresDataMountPath   = "/var/lib/pgsql/data"
+	postgresBackupMountPath = "/var/lib/pgsql/backup"
+	// directory of the PostgreSQL image data inside the persistent volume
+	postgresDataDirName = "userdata"
+
+	postgresUpgradeComponentName = "postgres-upgrade"
+	postgresDumpJobName          = "postgres-upgrade-dump"
+	postgresArchiveJobName       = "postgres-upgrade-archive"
+	postgresRestoreJobName       = "postgres-upgrade-restore"
+	postgresRollbackJobName      = "postgres-upgrade-rollback"
+
+	// time given to the new PostgreSQL version to become available before rolling back
+	postgresUpgradeStartTimeout = 10 * time.Minute
+)
+
+// Stages of a PostgreSQL major version upgrade
+const (
+	PostgresUpgradeStageStoppingClients = "StoppingClients"
+	PostgresUpgradeStageDumping         = "DumpingDatabases"
+	PostgresUpgradeStageArchiving       = "ArchivingData"
+	PostgresUpgradeStageRestoring       = "RestoringDatabases"
+	PostgresUpgradeStageRollingBack     = "RollingBack"
+)
+
+// Reasons of the events emitted during a PostgreSQL major version upgrade
+const (
+	PostgresUpgradeStartedEventReason    = "PostgresUpgradeStarted"
+	PostgresUpgradeSucceededEventReason  = "PostgresUpgradeSucceeded"
+	PostgresUpgradeRolledBackEventReason = "PostgresUpgradeRolledBack"
+	PostgresUpgradeFailedEventReason     = "PostgresUpgradeFailed"
+)
+
+var (
+	// version in the image tag, for example `9.6-b681d78` or `13`
+	postgresImageTagVersionRegexp = regexp.MustCompile(`^(\d+(?:\.\d+)?)(?:[-_.]|$)`)
+	// version in the image name, for example `postgresql-96-centos7` or `postgresql-13-rhel8`
+	postgresImageNameVersionRegexp = regexp.MustCompile(`postgresql-?(\d+)`)
+
+	// getPostgresDataVersion reads the major version of the data stored in the persistent volume
+	// from the running PostgreSQL server, which refuses to start on data of another major version. Replaced in tests.
+	getPostgresDataVersion = func(deployContext *deploy.DeployContext) (string, error) {
+		client, err := ConnectAsAdmin(deployContext, postgresDatabase)
+		if err != nil {
+			return "", err
+		}
+		defer client.Close()
+
+		return GetServerVersion(client)
+	}
+)
+
+// GetPostgresImageVersion returns the major version of PostgreSQL shipped in the image, for example `9.6` or `13`.
+// The version is taken from the image tag, or from the image name. Returns empty string if it is unknown.
+func GetPostgresImageVersion(image string) string {
+	image = strings.Split(image, "@")[0]
+	name := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name = image[:i]
+		if match := postgresImageTagVersionRegexp.FindStringSubmatch(image[i+1:]); match != nil {
+			return normalizePostgresVersion(match[1])
+		}
+	}
+
+	match := postgresImageNameVersionRegexp.FindStringSubmatch(path.Base(name))
+	if match == nil {
+		return ""
+	}
+	// `96` stands for `9.6`
+	if len(match[1]) == 2 && match[1][0] == '9' {
+		return match[1][:1] + "." + match[1][1:]
+	}
+	return normalizePostgresVersion(match[1])
+}
+
+// normalizePostgresVersion drops the minor version, which is part of the major version before PostgreSQL 10.
+func normalizePostgresVersion(version string) string {
+	parts := strings.Split(version, ".")
+	if major, err := strconv.Atoi(parts[0]); err == nil && major < 10 && len(parts) > 1 {
+		return parts[0] + "." + parts[1]
+	}
+	return parts[0]
+}
+
+// isNewerPostgresVersion returns true if the major version `version` is newer than `than`.
+func isNewerPostgresVersion(version string, than string) bool {
+	v1, ok1 := parsePostgresVersion(version)
+	v2, ok2 := parsePostgresVersion(than)
+	if !ok1 || !ok2 {
+		return false
+	}
+	return v1[0] > v2[0] || v1[0] == v2[0] && v1[1] > v2[1]
+}
+
+func parsePostgresVersion(version string) ([2]int, bool) {
+	var parsed [2]int
+	parts := strings.SplitN(version, ".", 2)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return parsed, false
+		}
+		parsed[i] = number
+	}
+	return parsed, true
+}
+
+func getTargetPostgresImage(cheCluster *orgv1.CheCluster) string {
+	return util.GetValue(cheCluster.Spec.Database.PostgresImage, deploy.DefaultPostgresImage(cheCluster))
+}
+
+// getPostgresImage returns the image of the PostgreSQL deployment.
+// The image of the previous major version is kept until the data is dumped and archived, and after a failed upgrade.
+func getPostgresImage(cheCluster *orgv1.CheCluster) string {
+	image := getTargetPostgresImage(cheCluster)
+	upgrade := cheCluster.Status.PostgresUpgrade
+	if upgrade == nil || upgrade.ToImage != image {
+		return image
+	}
+
+	switch upgrade.Phase {
+	case orgv1.PostgresUpgradePhaseInProgress:
+		if upgrade.Stage != PostgresUpgradeStageRestoring {
+			return upgrade.FromImage
+		}
+	case orgv1.PostgresUpgradePhaseRolledBack, orgv1.PostgresUpgradePhaseFailed:
+		return upgrade.FromImage
+	}
+	return image
+}
+
+// SyncPostgresUpgrade upgrades the PostgreSQL data when the PostgreSQL image is replaced with an image
+// of a newer major version, which can't read the data of the previous one.
+// The clients are stopped, the databases are dumped with the previous version, and restored into the new one.
+// If the upgrade fails, the previous image and data are restored, and the upgrade isn't retried
+// until the PostgreSQL image changes again.
+// Returns true if there is no upgrade in progress and the PostgreSQL deployment can be synced.
+func SyncPostgresUpgrade(deployContext *deploy.DeployContext) (bool, error) {
+	cheCluster := deployContext.CheCluster
+	toImage := getTargetPostgresImage(cheCluster)
+
+	upgrade := cheCluster.Status.PostgresUpgrade
+	if upgrade != nil && upgrade.Phase == orgv1.PostgresUpgradePhaseInProgress {
+		return continuePostgresUpgrade(deployContext)
+	}
+	if upgrade != nil && upgrade.ToImage == toImage {
+		switch upgrade.Phase {
+		case orgv1.PostgresUpgradePhaseFailed:
+			return false, fmt.Errorf("upgrade of PostgreSQL from %s to %s failed, the data requires manual recovery: %s",
+				upgrade.FromVersion, upgrade.ToVersion, upgrade.Message)
+		case orgv1.PostgresUpgradePhaseRolledBack:
+			return true, nil
+		}
+	}
+
+	clusterDeployment, err := deploy.GetClusterDeployment(deploy.PostgresName, cheCluster.Namespace, deployContext.ClusterAPI.Client)
+	if err != nil || clusterDeployment == nil {
+		return err == nil, err
+	}
+
+	fromImage := clusterDeployment.Spec.Template.Spec.Containers[0].Image
+	if fromImage == toImage {
+		return true, nil
+	}
+	toVersion := GetPostgresImageVersion(toImage)
+	if toVersion == "" {
+		return true, nil
+	}
+	fromVersion, err := getCurrentPostgresVersion(deployContext, fromImage)
+	if err != nil {
+		return false, err
+	}
+	if fromVersion == "" || !isNewerPostgresVersion(toVersion, fromVersion) {
+		return true, nil
+	}
+
+	// jobs of a previous attempt
+	for _, name := range []string{postgresDumpJobName, postgresArchiveJobName, postgresRestoreJobName, postgresRollbackJobName} {
+		if err := deletePostgresUpgradeJob(deployContext, name); err != nil {
+			return false, err
+		}
+	}
+
+	now := metav1.Now()
+	cheCluster.Status.PostgresUpgrade = &orgv1.PostgresUpgradeStatus{
+		Phase:          orgv1.PostgresUpgradePhaseInProgress,
+		Stage:          PostgresUpgradeStageStoppingClients,
+		Message:        "Stopping the PostgreSQL clients",
+		FromVersion:    fromVersion,
+		ToVersion:      toVersion,
+		FromImage:      fromImage,
+		ToImage:        toImage,
+		StartTime:      &now,
+		StageStartTime: &now,
+	}
+	if err := deploy.UpdateCheCRStatus(deployContext, "status: PostgreSQL upgrade", orgv1.PostgresUpgradePhaseInProgress); err != nil {
+		return false, err
+	}
+
+	logrus.Infof("Upgrading PostgreSQL from %s to %s", fromVersion, toVersion)
+	deploy.RecordEventf(deployContext, corev1.EventTypeNormal, PostgresUpgradeStartedEventReason, "Upgrading PostgreSQL from %s to %s", fromVersion, toVersion)
+	return false, nil
+}
+
+// getCurrentPostgresVersion returns the major version of the data stored in the persistent volume.
+// The version is read once from the PostgreSQL pod, and then kept in the CheCluster status.
+// If the pod isn't available, the version of the deployed image is returned instead.
+func getCurrentPostgresVersion(deployContext *deploy.DeployContext, image string) (string, error) {
+	if deployContext.CheCluster.Status.PostgresVersion != "" {
+		return deployContext.CheCluster.Status.PostgresVersion, nil
+	}
+
+	version, err := getPostgresDataVersion(deployContext)
+	if err != nil || version == "" {
+		logrus.Warnf("Failed to read PostgreSQL data version, falling back to the version of the image '%s': %v", image, err)
+		return GetPostgresImageVersion(image), nil
+	}
+
+	deployContext.CheCluster.Status.PostgresVersion = version
+	if err := deploy.UpdateCheCRStatus(deployContext, "status: PostgreSQL version", version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+func continuePostgresUpgrade(deployContext *deploy.DeployContext) (bool, error) {
+	upgrade := deployContext.CheCluster.Status.PostgresUpgrade
+
+	switch upgrade.Stage {
+	case PostgresUpgradeStageStoppingClients:
+		done, err := stopPostgresClients(deployContext)
+		if !done {
+			return false, err
+		}
+		return false, setPostgresUpgradeStage(deployContext, PostgresUpgradeStageDumping, "Dumping the databases")
+
+	case PostgresUpgradeStageDumping:
+		claimSize, err := getPostgresUpgradeBackupClaimSize(deployContext)
+		if err != nil {
+			return false, err
+		}
+		done, err := deploy.SyncPVCToCluster(deployContext, PostgresUpgradeBackupVolumeClaimName, claimSize, deploy.PostgresName)
+		if !done {
+			return false, err
+		}
+		command := fmt.Sprintf("pg_dumpall -h %s -U postgres -f %s", deploy.PostgresName, getPostgresDumpFile(upgrade))
+		succeeded, err := syncPostgresUpgradeJob(deployContext, postgresDumpJobName, upgrade.FromImage, command, PostgresUpgradeBackupVolumeClaimName, postgresBackupMountPath)
+		if err != nil {
+			// the data is left untouched
+			return false, completePostgresUpgrade(deployContext, orgv1.PostgresUpgradePhaseRolledBack, fmt.Sprintf("Failed to dump the databases: %v", err))
+		} else if !succeeded {
+			return false, nil
+		}
+		return false, setPostgresUpgradeStage(deployContext, PostgresUpgradeStageArchiving, "Archiving the data of PostgreSQL "+upgrade.FromVersion)
+
+	case PostgresUpgradeStageArchiving:
+		done, err := scaleDeployment(deployContext, deploy.PostgresName, 0)
+		if !done {
+			return false, err
+		}
+		// the data of the previous version is moved aside, so the new version starts with an empty data directory
+		archiveDir := getPostgresArchiveDirName(upgrade)
+		command := fmt.Sprintf(
+			"cd %s && if [ -d %s ]; then if [ -e %s ]; then echo '%s already exists' >&2; exit 1; fi; mv %s %s; fi && test -d %s",
+			postgresDataMountPath, postgresDataDirName, archiveDir, archiveDir, postgresDataDirName, archiveDir, archiveDir)
+		succeeded, err := syncPostgresUpgradeJob(deployContext, postgresArchiveJobName, upgrade.FromImage, command, deploy.DefaultPostgresVolumeClaimName, postgresDataMountPath)
+		if err != nil {
+			// the data is moved atomically, so it is either archived or left untouched
+			return false, completePostgresUpgrade(deployContext, orgv1.PostgresUpgradePhaseRolledBack, fmt.Sprintf("Failed to archive the data: %v", err))
+		} else if !succeeded {
+			return false, nil
+		}
+		return false, setPostgresUpgradeStage(deployContext, PostgresUpgradeStageRestoring, "Restoring the databases into PostgreSQL "+upgrade.ToVersion)
+
+	case PostgresUpgradeStageRestoring:
+		available, err := SyncPostgresDeploymentToCluster(deployContext)
+		if err != nil {
+			return false, err
+		}
+		if done, err := scaleDeployment(deployContext, deploy.PostgresName, 1); !done {
+			return false, err
+		}
+		if !available {
+			if upgrade.StageStartTime != nil && time.Since(upgrade.StageStartTime.Time) > postgresUpgradeStartTimeout {
+				return false, setPostgresUpgradeStage(deployContext, PostgresUpgradeStageRollingBack,
+					fmt.Sprintf("PostgreSQL %s didn't become available in %v", upgrade.ToVersion, postgresUpgradeStartTimeout))
+			}
+			return false, nil
+		}
+
+		// `already exists` errors are expected, since the new database cluster is initialized
+		// with the Che database and user, and `pg_dumpall` doesn't skip them
+		command := fmt.Sprintf(
+			"psql -h %s -U postgres -d postgres -f %s 2> /tmp/restore.log; status=$?; cat /tmp/restore.log >&2; "+
+				"[ $status -eq 0 ] && ! grep ERROR /tmp/restore.log | grep -qv 'already exists'",
+			deploy.PostgresName, getPostgresDumpFile(upgrade))
+		succeeded, err := syncPostgresUpgradeJob(deployContext, postgresRestoreJobName, upgrade.ToImage, command, PostgresUpgradeBackupVolumeClaimName, postgresBackupMountPath)
+		if err != nil {
+			return false, setPostgresUpgradeStage(deployContext, PostgresUpgradeStageRollingBack, fmt.Sprintf("Failed to restore the databases: %v", err))
+		} else if !succeeded {
+			return false, nil
+		}
+		return false, completePostgresUpgrade(deployContext, orgv1.PostgresUpgradePhaseSucceeded, "PostgreSQL has been upgraded to "+upgrade.ToVersion)
+
+	case PostgresUpgradeStageRollingBack:
+		done, err := scaleDeployment(deployContext, deploy.PostgresName, 0)
+		if !done {
+			return false, err
+		}
+		archiveDir := getPostgresArchiveDirName(upgrade)
+		command := fmt.Sprintf(
+			"cd %s && if [ -d %s ]; then rm -rf %s && mv %s %s; fi && test -d %s",
+			postgresDataMountPath, archiveDir, postgresDataDirName, archiveDir, postgresDataDirName, postgresDataDirName)
+		succeeded, err := syncPostgresUpgradeJob(deployContext, postgresRollbackJobName, upgrade.FromImage, command, deploy.DefaultPostgresVolumeClaimName, postgresDataMountPath)
+		if err != nil {
+			return false, completePostgresUpgrade(deployContext, orgv1.PostgresUpgradePhaseFailed,
+				fmt.Sprintf("%s. Failed to restore the data of PostgreSQL %s: %v", upgrade.Message, upgrade.FromVersion, err))
+		} else if !succeeded {
+			return false, nil
+		}
+		// bring back the previous image
+		if _, err := SyncPostgresDeploymentToCluster(deployContext); err != nil {
+			return false, err
+		}
+		return false, completePostgresUpgrade(deployContext, orgv1.PostgresUpgradePhaseRolledBack, upgrade.Message)
+	}
+
+	return false, fmt.Errorf("unknown stage of PostgreSQL upgrade: %s", upgrade.Stage)
+}
+
+func setPostgresUpgradeStage(deployContext *deploy.DeployContext, stage string, message string) error {
+	now := metav1.Now()
+	upgrade := deployContext.CheCluster.Status.PostgresUpgrade
+	upgrade.Stage = stage
+	upgrade.Message = message
+	upgrade.StageStartTime = &now
+
+	logrus.Infof("PostgreSQL upgrade: %s", message)
+	return deploy.UpdateCheCRStatus(deployContext, "status: PostgreSQL upgrade stage", stage)
+}
+
+// completePostgresUpgrade starts the PostgreSQL clients again, unless the data requires manual recovery.
+func completePostgresUpgrade(deployContext *deploy.DeployContext, phase string, message string) error {
+	cheCluster := deployContext.CheCluster
+	upgrade := cheCluster.Status.PostgresUpgrade
+
+	if phase != orgv1.PostgresUpgradePhaseFailed {
+		if _, err := scaleDeployment(deployContext, deploy.PostgresName, 1); err != nil {
+			return err
+		}
+		if _, err := startPostgresClients(deployContext); err != nil {
+			return err
+		}
+	}
+
+	now := metav1.Now()
+	upgrade.Phase = phase
+	upgrade.Stage = ""
+	upgrade.Message = message
+	upgrade.CompletionTime = &now
+	if phase == orgv1.PostgresUpgradePhaseSucceeded {
+		cheCluster.Status.PostgresVersion = upgrade.ToVersion
+	}
+	if err := deploy.UpdateCheCRStatus(deployContext, "status: PostgreSQL upgrade", phase); err != nil {
+		return err
+	}
+
+	switch phase {
+	case orgv1.PostgresUpgradePhaseSucceeded:
+		for _, name := range []string{postgresDumpJobName, postgresArchiveJobName, postgresRestoreJobName} {
+			if err := deletePostgresUpgradeJob(deployContext, name); err != nil {
+				logrus.Errorf("Failed to delete job '%s': %v", name, err)
+			}
+		}
+		logrus.Infof("PostgreSQL has been upgraded from %s to %s", upgrade.FromVersion, upgrade.ToVersion)
+		deploy.RecordEventf(deployContext, corev1.EventTypeNormal, PostgresUpgradeSucceededEventReason,
+			"PostgreSQL has been upgraded from %s to %s", upgrade.FromVersion, upgrade.ToVersion)
+	case orgv1.PostgresUpgradePhaseRolledBack:
+		logrus.Errorf("PostgreSQL upgrade to %s has been rolled back: %s", upgrade.ToVersion, message)
+		deploy.RecordEventf(deployContext, corev1.EventTypeWarning, PostgresUpgradeRolledBackEventReason,
+			"PostgreSQL upgrade to %s has been rolled back to %s: %s", upgrade.ToVersion, upgrade.FromVersion, message)
+	default:
+		logrus.Errorf("PostgreSQL upgrade to %s failed: %s", upgrade.ToVersion, message)
+		deploy.RecordEventf(deployContext, corev1.EventTypeWarning, PostgresUpgradeFailedEventReason,
+			"PostgreSQL upgrade to %s failed: %s", upgrade.ToVersion, message)
+	}
+	return nil
+}
+
+func getPostgresDumpFile(upgrade *orgv1.PostgresUpgradeStatus) string {
+	return path.Join(postgresBackupMountPath, "postgres-"+upgrade.FromVersion+".sql")
+}
+
+func getPostgresArchiveDirName(upgrade *orgv1.PostgresUpgradeStatus) string {
+	return postgresDataDirName + "-" + upgrade.FromVersion
+}
+
+// getPostgresUpgradeBackupClaimSize returns the size of the claim the databases are dumped to,
+// which is the size of the PostgreSQL data claim, since the dump is not larger than the data.
+func getPostgresUpgradeBackupClaimSize(deployContext *deploy.DeployContext) (string, error) {
+	claim := &corev1.PersistentVolumeClaim{}
+	exists, err := deploy.GetNamespacedObject(deployContext, deploy.DefaultPostgresVolumeClaimName, claim)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		if size, ok := claim.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			return size.String(), nil
+		}
+	}
+	return deploy.DefaultPostgresVolumeClaimSize, nil
+}
+
+func getPostgresClientNames(cheCluster *orgv1.CheCluster) []string {
+	return []string{deploy.DefaultCheFlavor(cheCluster), deploy.IdentityProviderName}
+}
+
+// stopPostgresClients scales the Che server and Identity Provider deployments down,
+// so the databases don't change while they are upgraded. Their number of replicas is recorded
+// in the upgrade status beforehand, so it is restored once the upgrade completes.
+func stopPostgresClients(deployContext *deploy.DeployContext) (bool, error) {
+	upgrade := deployContext.CheCluster.Status.PostgresUpgrade
+	if upgrade.ClientReplicas == nil {
+		clientReplicas := map[string]int32{}
+		for _, name := range getPostgresClientNames(deployContext.CheCluster) {
+			deployment, err := deploy.GetClusterDeployment(name, deployContext.CheCluster.Namespace, deployContext.ClusterAPI.Client)
+			if err != nil {
+				return false, err
+			}
+			if deployment != nil && deployment.Spec.Replicas != nil {
+				clientReplicas[name] = *deployment.Spec.Replicas
+			}
+		}
+		upgrade.ClientReplicas = clientReplicas
+		if err := deploy.UpdateCheCRStatus(deployContext, "status: PostgreSQL clients replicas", fmt.Sprint(clientReplicas)); err != nil {
+			return false, err
+		}
+	}
+
+	done := true
+	for _, name := range getPostgresClientNames(deployContext.CheCluster) {
+		scaled, err := scaleDeployment(deployContext, name, 0)
+		if err != nil {
+			return false, err
+		}
+		done = done && scaled
+	}
+	return done, nil
+}
+
+// startPostgresClients scales the Che server and Identity Provider deployments back
+// to the number of replicas they had before the upgrade, one replica if it is unknown.
+func startPostgresClients(deployContext *deploy.DeployContext) (bool, error) {
+	upgrade := deployContext.CheCluster.Status.PostgresUpgrade
+	done := true
+	for _, name := range getPostgresClientNames(deployContext.CheCluster) {
+		replicas, ok := upgrade.ClientReplicas[name]
+		if !ok {
+			replicas = 1
+		}
+		scaled, err := scaleDeployment(deployContext, name, replicas)
+		if err != nil {
+			return false, err
+		}
+		done = done && scaled
+	}
+	return done, nil
+}
+
+// scaleDeployment sets the number of replicas of the deployment, if it exists.
+// When scaling down to zero, returns true once all the pods are gone.
+func scaleDeployment(deployContext *deploy.DeployContext, name string, replicas int32) (bool, error) {
+	deployment, err := deploy.GetClusterDeployment(name, deployContext.CheCluster.Namespace, deployContext.ClusterAPI.Client)
+	if err != nil || deployment == nil {
+		return err == nil, err
+	}
+
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != replicas {
+		logrus.Infof("Scaling deployment '%s' to %d replicas", name, replicas)
+		deployment.Spec.Replicas = &replicas
+		return false, deployContext.ClusterAPI.Client.Update(context.TODO(), deployment)
+	}
+	return replicas != 0 || deployment.Status.Replicas == 0, nil
+}
+
+// syncPostgresUpgradeJob runs the command in a job, with the claim mounted.
+// Returns true once the job succeeded, or an error if it failed.
+func syncPostgresUpgradeJob(deployContext *deploy.DeployContext, name string, image string, command string, claimName string, mountPath string) (bool, error) {
+	specJob, err := getSpecPostgresUpgradeJob(deployContext, name, image, command, claimName, mountPath)
+	if err != nil {
+		return false, err
+	}
+
+	job, err := deploy.SyncJobSpecToCluster(deployContext, specJob)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	if job.Status.Failed > 0 {
+		return false, fmt.Errorf("job '%s' failed, see the logs of its pod for details", name)
+	}
+	return job.Status.Succeeded > 0, nil
+}
+
+func getSpecPostgresUpgradeJob(deployContext *deploy.DeployContext, name string, image string, command string, claimName string, mountPath string) (*batchv1.Job, error) {
+	isOpenShift, _, err := deploy.DetectOpenShift(deployContext)
+	if err != nil {
+		return nil, err
+	}
+
+	// the job may run before the deployment is synced, which moves the password to the secret
+	clusterDeployment, err := deploy.GetClusterDeployment(deploy.PostgresName, deployContext.CheCluster.Namespace, deployContext.ClusterAPI.Client)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncPostgresAdminSecret(deployContext, clusterDeployment); err != nil {
+		return nil, err
+	}
+
+	labels := deploy.GetLabels(deployContext.CheCluster, postgresUpgradeComponentName)
+	// the job isn't retried, since restoring the databases twice leads to duplicates
+	backoffLimit := int32(0)
+	terminationGracePeriodSeconds := int64(30)
+
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: deployContext.CheCluster.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:                 corev1.RestartPolicyNever,
+					TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
+					Volumes: []corev1.Volume{
+						{
+							Name: claimName,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: claimName,
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:            name,
+							Image:           image,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command:         []string{"/bin/sh", "-c", command},
+							Env: []corev1.EnvVar{
+								getPostgresAdminPasswordEnv("PGPASSWORD"),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      claimName,
+									MountPath: mountPath,
+								},
+							},
+							SecurityContext: &corev1.SecurityContext{
+								Capabilities: &corev1.Capabilities{
+									Drop: []corev1.Capability{"ALL"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if !isOpenShift {
+		var runAsUser int64 = 26
+		job.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{
+			RunAsUser: &runAsUser,
+			FSGroup:   &runAsUser,
+		}
+	}
+	if err := controllerutil.SetControllerReference(deployContext.CheCluster, job, deployContext.ClusterAPI.Scheme); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func deletePostgresUpgradeJob(deployContext *deploy.DeployContext, name string) error {
+	job := &batchv1.Job{}
+	err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: deployContext.CheCluster.Namespace}, job)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// the pods of the job are removed as well
+	err = deployContext.ClusterAPI.Client.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
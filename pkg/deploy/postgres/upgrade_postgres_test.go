@@ -0,0 +1,422 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testPostgres96Image = "quay.io/eclipse/che--centos--postgresql-96-centos7:9.6-b681d78"
+	testPostgres13Image = "quay.io/eclipse/che--centos--postgresql-13-centos7:13-b681d78"
+)
+
+func TestGetPostgresImageVersion(t *testing.T) {
+	testCases := map[string]string{
+		testPostgres96Image: "9.6",
+		testPostgres13Image: "13",
+		"quay.io/eclipse/che--centos--postgresql-96-centos7@sha256:b681d78125361519180a6ac05242c296f8906c11eab7e207b5ca9a89b6344392": "9.6",
+		"registry.redhat.io/rhel8/postgresql-13:latest": "13",
+		"registry.redhat.io/rhel8/postgresql-10":        "10",
+		"postgres:12.4":                                 "12",
+		"postgres:9.5.3":                                "9.5",
+		"localhost:5000/postgres":                       "",
+		"quay.io/eclipse/postgres:latest":               "",
+	}
+
+	for image, expected := range testCases {
+		if actual := GetPostgresImageVersion(image); actual != expected {
+			t.Errorf("Expected version '%s' of image '%s', but got '%s'", expected, image, actual)
+		}
+	}
+}
+
+func TestIsNewerPostgresVersion(t *testing.T) {
+	type testCase struct {
+		version  string
+		than     string
+		expected bool
+	}
+
+	testCases := []testCase{
+		{version: "13", than: "9.6", expected: true},
+		{version: "10", than: "9.6", expected: true},
+		{version: "9.6", than: "9.5", expected: true},
+		{version: "9.6", than: "9.6", expected: false},
+		{version: "9.6", than: "13", expected: false},
+		{version: "13", than: "unknown", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		if actual := isNewerPostgresVersion(testCase.version, testCase.than); actual != testCase.expected {
+			t.Errorf("Expected isNewerPostgresVersion(%s, %s) to be %t", testCase.version, testCase.than, testCase.expected)
+		}
+	}
+}
+
+func TestSyncPostgresUpgradeSkipped(t *testing.T) {
+	getPostgresDataVersion = func(*deploy.DeployContext) (string, error) { return "9.6", nil }
+
+	type testCase struct {
+		name          string
+		clusterImage  string
+		specImage     string
+		status        orgv1.CheClusterStatus
+		expectedImage string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "No deployment yet",
+			specImage:     testPostgres13Image,
+			expectedImage: testPostgres13Image,
+		},
+		{
+			name:          "Same major version",
+			clusterImage:  "quay.io/eclipse/che--centos--postgresql-96-centos7:9.6-0000000",
+			specImage:     testPostgres96Image,
+			expectedImage: testPostgres96Image,
+		},
+		{
+			name:          "Older major version",
+			clusterImage:  testPostgres13Image,
+			specImage:     testPostgres96Image,
+			status:        orgv1.CheClusterStatus{PostgresVersion: "13"},
+			expectedImage: testPostgres96Image,
+		},
+		{
+			name:          "Unknown major version",
+			clusterImage:  testPostgres96Image,
+			specImage:     "quay.io/eclipse/postgres:latest",
+			expectedImage: "quay.io/eclipse/postgres:latest",
+		},
+		{
+			name:         "Rolled back",
+			clusterImage: testPostgres96Image,
+			specImage:    testPostgres13Image,
+			status: orgv1.CheClusterStatus{
+				PostgresUpgrade: &orgv1.PostgresUpgradeStatus{
+					Phase:     orgv1.PostgresUpgradePhaseRolledBack,
+					FromImage: testPostgres96Image,
+					ToImage:   testPostgres13Image,
+				},
+			},
+			expectedImage: testPostgres96Image,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cheCluster := getTestCheCluster(testCase.specImage)
+			cheCluster.Status = testCase.status
+			initObjects := []runtime.Object{cheCluster}
+			if testCase.clusterImage != "" {
+				initObjects = append(initObjects, getTestDeployment(deploy.PostgresName, testCase.clusterImage))
+			}
+			deployContext := getTestDeployContext(cheCluster, initObjects...)
+
+			done, err := SyncPostgresUpgrade(deployContext)
+			if err != nil {
+				t.Fatalf("Failed to sync PostgreSQL upgrade: %v", err)
+			}
+			if !done {
+				t.Fatalf("PostgreSQL upgrade is not expected")
+			}
+			if image := getPostgresImage(deployContext.CheCluster); image != testCase.expectedImage {
+				t.Fatalf("Expected image '%s', but got '%s'", testCase.expectedImage, image)
+			}
+		})
+	}
+}
+
+func TestSyncPostgresUpgrade(t *testing.T) {
+	getPostgresDataVersion = func(*deploy.DeployContext) (string, error) { return "9.6", nil }
+
+	cheCluster := getTestCheCluster(testPostgres13Image)
+	deployContext := getTestDeployContext(cheCluster,
+		cheCluster,
+		getTestDeployment(deploy.PostgresName, testPostgres96Image),
+		getTestDeployment("che", "quay.io/eclipse/che-server:next"),
+		getTestDeployment(deploy.IdentityProviderName, "quay.io/eclipse/che-keycloak:next"),
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      deploy.DefaultPostgresVolumeClaimName,
+				Namespace: "eclipse-che",
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")},
+				},
+			},
+		})
+	scaleTestDeployment(t, deployContext, "che", 2)
+
+	// upgrade starts
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageStoppingClients)
+	upgrade := deployContext.CheCluster.Status.PostgresUpgrade
+	if upgrade.FromVersion != "9.6" || upgrade.ToVersion != "13" || upgrade.FromImage != testPostgres96Image {
+		t.Fatalf("Unexpected upgrade status: %+v", upgrade)
+	}
+	if deployContext.CheCluster.Status.PostgresVersion != "9.6" {
+		t.Fatalf("Expected PostgreSQL data version to be recorded")
+	}
+
+	// clients are stopped
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageStoppingClients)
+	assertReplicas(t, deployContext, "che", 0)
+	assertReplicas(t, deployContext, deploy.IdentityProviderName, 0)
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageDumping)
+	expectedClientReplicas := map[string]int32{"che": 2, deploy.IdentityProviderName: 1}
+	if !reflect.DeepEqual(deployContext.CheCluster.Status.PostgresUpgrade.ClientReplicas, expectedClientReplicas) {
+		t.Fatalf("Expected replicas of the clients %v to be recorded, got %v", expectedClientReplicas, deployContext.CheCluster.Status.PostgresUpgrade.ClientReplicas)
+	}
+
+	// databases are dumped with the previous version, to a claim of the size of the data one
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageDumping)
+	backupClaim := &corev1.PersistentVolumeClaim{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: PostgresUpgradeBackupVolumeClaimName, Namespace: "eclipse-che"}, backupClaim); err != nil {
+		t.Fatalf("Failed to get the backup claim: %v", err)
+	}
+	if size := backupClaim.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "5Gi" {
+		t.Fatalf("Expected the backup claim to request 5Gi, got %s", size.String())
+	}
+	job := completeJob(t, deployContext, postgresDumpJobName, true)
+	if job.Spec.Template.Spec.Containers[0].Image != testPostgres96Image {
+		t.Fatalf("Expected databases to be dumped with the previous image")
+	}
+	passwordEnv := util.FindEnv(job.Spec.Template.Spec.Containers[0].Env, "PGPASSWORD")
+	if passwordEnv == nil || passwordEnv.Value != "" || passwordEnv.ValueFrom == nil || passwordEnv.ValueFrom.SecretKeyRef == nil ||
+		passwordEnv.ValueFrom.SecretKeyRef.Name != postgresAdminSecret {
+		t.Fatalf("Expected the admin password to be read from the secret '%s', got %+v", postgresAdminSecret, passwordEnv)
+	}
+	if _, err := getPostgresAdminPassword(deployContext); err != nil {
+		t.Fatalf("Expected the admin password secret to be created: %v", err)
+	}
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageArchiving)
+
+	// data of the previous version is archived
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageArchiving)
+	assertReplicas(t, deployContext, deploy.PostgresName, 0)
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageArchiving)
+	completeJob(t, deployContext, postgresArchiveJobName, true)
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageRestoring)
+
+	// new version is deployed and databases are restored
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageRestoring)
+	deployment := assertReplicas(t, deployContext, deploy.PostgresName, 1)
+	if deployment.Spec.Template.Spec.Containers[0].Image != testPostgres13Image {
+		t.Fatalf("Expected PostgreSQL deployment to be updated to the new image")
+	}
+	setDeploymentAvailable(t, deployContext, deploy.PostgresName)
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageRestoring)
+	completeJob(t, deployContext, postgresRestoreJobName, true)
+
+	syncPostgresUpgradeAndCheckStage(t, deployContext, "")
+	upgrade = deployContext.CheCluster.Status.PostgresUpgrade
+	if upgrade.Phase != orgv1.PostgresUpgradePhaseSucceeded || upgrade.CompletionTime == nil {
+		t.Fatalf("Expected upgrade to succeed: %+v", upgrade)
+	}
+	if deployContext.CheCluster.Status.PostgresVersion != "13" {
+		t.Fatalf("Expected PostgreSQL version to be updated")
+	}
+	assertReplicas(t, deployContext, "che", 2)
+	assertReplicas(t, deployContext, deploy.IdentityProviderName, 1)
+
+	done, err := SyncPostgresUpgrade(deployContext)
+	if !done || err != nil {
+		t.Fatalf("Expected no upgrade after completion: %v", err)
+	}
+}
+
+func TestSyncPostgresUpgradeRollback(t *testing.T) {
+	cheCluster := getTestCheCluster(testPostgres13Image)
+	now := metav1.Now()
+	cheCluster.Status.PostgresUpgrade = &orgv1.PostgresUpgradeStatus{
+		Phase:          orgv1.PostgresUpgradePhaseInProgress,
+		Stage:          PostgresUpgradeStageRestoring,
+		FromVersion:    "9.6",
+		ToVersion:      "13",
+		FromImage:      testPostgres96Image,
+		ToImage:        testPostgres13Image,
+		StartTime:      &now,
+		StageStartTime: &now,
+	}
+	deployContext := getTestDeployContext(cheCluster,
+		cheCluster,
+		getTestDeployment(deploy.PostgresName, testPostgres13Image),
+		getTestDeployment("che", "quay.io/eclipse/che-server:next"))
+	scaleTestDeployment(t, deployContext, "che", 0)
+
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageRestoring)
+	setDeploymentAvailable(t, deployContext, deploy.PostgresName)
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageRestoring)
+	completeJob(t, deployContext, postgresRestoreJobName, false)
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageRollingBack)
+
+	// data of the previous version is restored
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageRollingBack)
+	assertReplicas(t, deployContext, deploy.PostgresName, 0)
+	syncPostgresUpgradeAndCheckStage(t, deployContext, PostgresUpgradeStageRollingBack)
+	completeJob(t, deployContext, postgresRollbackJobName, true)
+
+	syncPostgresUpgradeAndCheckStage(t, deployContext, "")
+	upgrade := deployContext.CheCluster.Status.PostgresUpgrade
+	if upgrade.Phase != orgv1.PostgresUpgradePhaseRolledBack {
+		t.Fatalf("Expected upgrade to be rolled back: %+v", upgrade)
+	}
+	deployment := assertReplicas(t, deployContext, deploy.PostgresName, 1)
+	if deployment.Spec.Template.Spec.Containers[0].Image != testPostgres96Image {
+		t.Fatalf("Expected PostgreSQL deployment to be rolled back to the previous image")
+	}
+	assertReplicas(t, deployContext, "che", 1)
+
+	// the upgrade isn't retried
+	done, err := SyncPostgresUpgrade(deployContext)
+	if !done || err != nil {
+		t.Fatalf("Expected upgrade not to be retried: %v", err)
+	}
+}
+
+func getTestCheCluster(postgresImage string) *orgv1.CheCluster {
+	return &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "eclipse-che",
+			Namespace: "eclipse-che",
+		},
+		Spec: orgv1.CheClusterSpec{
+			Database: orgv1.CheClusterSpecDB{
+				PostgresImage: postgresImage,
+			},
+		},
+	}
+}
+
+func getTestDeployContext(cheCluster *orgv1.CheCluster, initObjects ...runtime.Object) *deploy.DeployContext {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, initObjects...)
+
+	return &deploy.DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: deploy.ClusterAPI{
+			Client: cli,
+			Scheme: scheme.Scheme,
+		},
+		Proxy: &deploy.Proxy{},
+	}
+}
+
+func getTestDeployment(name string, image string) *appsv1.Deployment {
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "eclipse-che",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: name, Image: image}},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{
+			Replicas: 1,
+		},
+	}
+}
+
+func syncPostgresUpgradeAndCheckStage(t *testing.T, deployContext *deploy.DeployContext, expectedStage string) {
+	done, err := SyncPostgresUpgrade(deployContext)
+	if err != nil {
+		t.Fatalf("Failed to sync PostgreSQL upgrade: %v", err)
+	}
+	if done {
+		t.Fatalf("Expected PostgreSQL upgrade to be in progress")
+	}
+	if stage := deployContext.CheCluster.Status.PostgresUpgrade.Stage; stage != expectedStage {
+		t.Fatalf("Expected upgrade stage '%s', but got '%s'", expectedStage, stage)
+	}
+}
+
+// assertReplicas checks the number of replicas of the deployment,
+// and updates its status as if the pods were started or stopped.
+func assertReplicas(t *testing.T, deployContext *deploy.DeployContext, name string, expected int32) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "eclipse-che"}, deployment); err != nil {
+		t.Fatalf("Failed to get deployment '%s': %v", name, err)
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != expected {
+		t.Fatalf("Expected deployment '%s' to have %d replicas", name, expected)
+	}
+
+	deployment.Status.Replicas = expected
+	if err := deployContext.ClusterAPI.Client.Update(context.TODO(), deployment); err != nil {
+		t.Fatalf("Failed to update deployment '%s': %v", name, err)
+	}
+	return deployment
+}
+
+func scaleTestDeployment(t *testing.T, deployContext *deploy.DeployContext, name string, replicas int32) {
+	deployment := &appsv1.Deployment{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "eclipse-che"}, deployment); err != nil {
+		t.Fatalf("Failed to get deployment '%s': %v", name, err)
+	}
+	deployment.Spec.Replicas = &replicas
+	deployment.Status.Replicas = replicas
+	if err := deployContext.ClusterAPI.Client.Update(context.TODO(), deployment); err != nil {
+		t.Fatalf("Failed to update deployment '%s': %v", name, err)
+	}
+}
+
+func setDeploymentAvailable(t *testing.T, deployContext *deploy.DeployContext, name string) {
+	deployment := &appsv1.Deployment{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "eclipse-che"}, deployment); err != nil {
+		t.Fatalf("Failed to get deployment '%s': %v", name, err)
+	}
+	deployment.Status.Replicas = 1
+	deployment.Status.AvailableReplicas = 1
+	if err := deployContext.ClusterAPI.Client.Update(context.TODO(), deployment); err != nil {
+		t.Fatalf("Failed to update deployment '%s': %v", name, err)
+	}
+}
+
+func completeJob(t *testing.T, deployContext *deploy.DeployContext, name string, succeeded bool) *batchv1.Job {
+	job := &batchv1.Job{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "eclipse-che"}, job); err != nil {
+		t.Fatalf("Failed to get job '%s': %v", name, err)
+	}
+	if succeeded {
+		job.Status.Succeeded = 1
+	} else {
+		job.Status.Failed = 1
+	}
+	if err := deployContext.ClusterAPI.Client.Update(context.TODO(), job); err != nil {
+		t.Fatalf("Failed to update job '%s': %v", name, err)
+	}
+	return job
+}
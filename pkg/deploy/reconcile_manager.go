@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Reconcilable is a part of Che installation reconciled by the ReconcileManager.
+type Reconcilable interface {
+	// Reconcile syncs the component with the CheCluster.
+	// Returns true if the component is up to date, the next components are reconciled only then.
+	Reconcile(deployContext *DeployContext) (done bool, err error)
+	// Finalize cleans up the objects of the component which are not garbage collected with the CheCluster,
+	// once it is being deleted.
+	Finalize(deployContext *DeployContext) error
+}
+
+// Switchable is implemented by the components which are reconciled only with some configurations of the CheCluster.
+type Switchable interface {
+	// IsEnabled tells whether the component is reconciled with the CheCluster. A disabled component is skipped
+	// and its readiness condition is removed, it is still finalized once the CheCluster is being deleted.
+	IsEnabled(deployContext *DeployContext) bool
+}
+
+// ConditionReporter is implemented by the components which report their readiness
+// with a condition of the CheCluster status.
+type ConditionReporter interface {
+	// Condition returns the type of the readiness condition of the component and whether the component is deployed.
+	// The condition of a component which is not deployed is removed.
+	Condition(deployContext *DeployContext) (conditionType string, deployed bool)
+}
+
+// ConditionWriter records the readiness conditions of the components in the CheCluster status.
+type ConditionWriter interface {
+	SetComponentCondition(instance *orgv1.CheCluster, conditionType string, done bool, err error) error
+	RemoveComponentCondition(instance *orgv1.CheCluster, conditionType string) error
+}
+
+// ReconcileManager reconciles the components of Che installation in the order they are registered.
+type ReconcileManager struct {
+	names           []string
+	reconcilers     []Reconcilable
+	conditionWriter ConditionWriter
+}
+
+func NewReconcileManager(conditionWriter ConditionWriter) *ReconcileManager {
+	return &ReconcileManager{conditionWriter: conditionWriter}
+}
+
+// RegisterReconciler appends the component to the ones to reconcile.
+func (manager *ReconcileManager) RegisterReconciler(name string, reconciler Reconcilable) {
+	manager.names = append(manager.names, name)
+	manager.reconcilers = append(manager.reconcilers, reconciler)
+}
+
+// ReconcileAll reconciles the components one after the other and stops at the first one which is not done.
+// Returns true if every component is up to date. The duration and the result of each reconciliation are recorded in the metrics.
+func (manager *ReconcileManager) ReconcileAll(deployContext *DeployContext) (bool, error) {
+	for i, reconciler := range manager.reconcilers {
+		if !isEnabled(deployContext, reconciler) {
+			if err := manager.removeCondition(deployContext, reconciler); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		start := time.Now()
+		done, err := reconciler.Reconcile(deployContext)
+		metrics.ObserveReconcile(manager.names[i], time.Since(start), done, err)
+		if err != nil {
+			logrus.Errorf("Failed to reconcile %s: %v", manager.names[i], err)
+		} else if !done {
+			logrus.Infof("Waiting on %s to be ready", manager.names[i])
+		}
+
+		conditionErr := manager.reportCondition(deployContext, reconciler, done, err)
+		if !done {
+			return false, err
+		}
+		if conditionErr != nil {
+			return false, conditionErr
+		}
+	}
+	return true, nil
+}
+
+// FinalizeAll finalizes the components in the reverse order of their registration.
+// Returns true if every component has been finalized.
+func (manager *ReconcileManager) FinalizeAll(deployContext *DeployContext) bool {
+	done := true
+	for i := len(manager.reconcilers) - 1; i >= 0; i-- {
+		if err := manager.reconcilers[i].Finalize(deployContext); err != nil {
+			logrus.Errorf("Failed to finalize %s: %v", manager.names[i], err)
+			done = false
+		}
+	}
+	return done
+}
+
+// PlanAll reconciles every enabled component with a dry run context, whether the previous ones are done or not,
+// so that the changes to all of them are planned. The errors are recorded in the plan.
+func (manager *ReconcileManager) PlanAll(deployContext *DeployContext, plan *DryRunPlan) {
+	for i, reconciler := range manager.reconcilers {
+		if !isEnabled(deployContext, reconciler) {
+			continue
+		}
+		if _, err := reconciler.Reconcile(deployContext); err != nil {
+			plan.AddError(manager.names[i], err)
+		}
+	}
+}
+
+func isEnabled(deployContext *DeployContext, reconciler Reconcilable) bool {
+	switchable, ok := reconciler.(Switchable)
+	return !ok || switchable.IsEnabled(deployContext)
+}
+
+func (manager *ReconcileManager) removeCondition(deployContext *DeployContext, reconciler Reconcilable) error {
+	reporter, ok := reconciler.(ConditionReporter)
+	if !ok || manager.conditionWriter == nil {
+		return nil
+	}
+
+	conditionType, _ := reporter.Condition(deployContext)
+	return manager.conditionWriter.RemoveComponentCondition(deployContext.CheCluster, conditionType)
+}
+
+func (manager *ReconcileManager) reportCondition(deployContext *DeployContext, reconciler Reconcilable, done bool, err error) error {
+	reporter, ok := reconciler.(ConditionReporter)
+	if !ok || manager.conditionWriter == nil {
+		return nil
+	}
+
+	conditionType, deployed := reporter.Condition(deployContext)
+	if done && !deployed {
+		return manager.conditionWriter.RemoveComponentCondition(deployContext.CheCluster, conditionType)
+	}
+	return manager.conditionWriter.SetComponentCondition(deployContext.CheCluster, conditionType, done, err)
+}
@@ -0,0 +1,192 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+)
+
+type fakeReconciler struct {
+	name          string
+	done          bool
+	err           error
+	conditionType string
+	deployed      bool
+	calls         *[]string
+}
+
+func (f *fakeReconciler) Reconcile(deployContext *DeployContext) (bool, error) {
+	*f.calls = append(*f.calls, "reconcile "+f.name)
+	return f.done, f.err
+}
+
+func (f *fakeReconciler) Finalize(deployContext *DeployContext) error {
+	*f.calls = append(*f.calls, "finalize "+f.name)
+	return f.err
+}
+
+type fakeConditionReporter struct {
+	fakeReconciler
+}
+
+func (f *fakeConditionReporter) Condition(deployContext *DeployContext) (string, bool) {
+	return f.conditionType, f.deployed
+}
+
+type fakeSwitchable struct {
+	fakeConditionReporter
+	enabled bool
+}
+
+func (f *fakeSwitchable) IsEnabled(deployContext *DeployContext) bool {
+	return f.enabled
+}
+
+type fakeConditionWriter struct {
+	calls *[]string
+}
+
+func (f *fakeConditionWriter) SetComponentCondition(instance *orgv1.CheCluster, conditionType string, done bool, err error) error {
+	if done {
+		*f.calls = append(*f.calls, "set "+conditionType+" true")
+	} else {
+		*f.calls = append(*f.calls, "set "+conditionType+" false")
+	}
+	return nil
+}
+
+func (f *fakeConditionWriter) RemoveComponentCondition(instance *orgv1.CheCluster, conditionType string) error {
+	*f.calls = append(*f.calls, "remove "+conditionType)
+	return nil
+}
+
+func TestReconcileAll(t *testing.T) {
+	type testCase struct {
+		name          string
+		reconcilers   func(calls *[]string) []Reconcilable
+		expectedDone  bool
+		expectedErr   bool
+		expectedCalls []string
+	}
+
+	testCases := []testCase{
+		{
+			name: "all components done",
+			reconcilers: func(calls *[]string) []Reconcilable {
+				return []Reconcilable{
+					&fakeReconciler{name: "a", done: true, calls: calls},
+					&fakeConditionReporter{fakeReconciler{name: "b", done: true, conditionType: "BReady", deployed: true, calls: calls}},
+					&fakeConditionReporter{fakeReconciler{name: "c", done: true, conditionType: "CReady", deployed: false, calls: calls}},
+				}
+			},
+			expectedDone:  true,
+			expectedCalls: []string{"reconcile a", "reconcile b", "set BReady true", "reconcile c", "remove CReady"},
+		},
+		{
+			name: "skip the disabled components",
+			reconcilers: func(calls *[]string) []Reconcilable {
+				return []Reconcilable{
+					&fakeSwitchable{fakeConditionReporter{fakeReconciler{name: "a", done: false, conditionType: "AReady", calls: calls}}, false},
+					&fakeSwitchable{fakeConditionReporter{fakeReconciler{name: "b", done: true, conditionType: "BReady", deployed: true, calls: calls}}, true},
+				}
+			},
+			expectedDone:  true,
+			expectedCalls: []string{"remove AReady", "reconcile b", "set BReady true"},
+		},
+		{
+			name: "stop at the first component which is not done",
+			reconcilers: func(calls *[]string) []Reconcilable {
+				return []Reconcilable{
+					&fakeReconciler{name: "a", done: true, calls: calls},
+					&fakeConditionReporter{fakeReconciler{name: "b", done: false, conditionType: "BReady", deployed: true, calls: calls}},
+					&fakeReconciler{name: "c", done: true, calls: calls},
+				}
+			},
+			expectedDone:  false,
+			expectedCalls: []string{"reconcile a", "reconcile b", "set BReady false"},
+		},
+		{
+			name: "stop at the first component which fails",
+			reconcilers: func(calls *[]string) []Reconcilable {
+				return []Reconcilable{
+					&fakeReconciler{name: "a", done: false, err: errors.New("failure"), calls: calls},
+					&fakeReconciler{name: "b", done: true, calls: calls},
+				}
+			},
+			expectedDone:  false,
+			expectedErr:   true,
+			expectedCalls: []string{"reconcile a"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			calls := []string{}
+			manager := NewReconcileManager(&fakeConditionWriter{calls: &calls})
+			for i, reconciler := range testCase.reconcilers(&calls) {
+				manager.RegisterReconciler(fmt.Sprintf("component %d", i), reconciler)
+			}
+
+			done, err := manager.ReconcileAll(&DeployContext{CheCluster: &orgv1.CheCluster{}})
+			if done != testCase.expectedDone {
+				t.Fatalf("Expected done to be %t, but got %t", testCase.expectedDone, done)
+			}
+			if (err != nil) != testCase.expectedErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, testCase.expectedCalls) {
+				t.Fatalf("Expected calls %v, but got %v", testCase.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestFinalizeAll(t *testing.T) {
+	calls := []string{}
+	manager := NewReconcileManager(nil)
+	manager.RegisterReconciler("a", &fakeReconciler{name: "a", calls: &calls})
+	manager.RegisterReconciler("b", &fakeReconciler{name: "b", err: errors.New("failure"), calls: &calls})
+	manager.RegisterReconciler("c", &fakeReconciler{name: "c", calls: &calls})
+
+	if manager.FinalizeAll(&DeployContext{CheCluster: &orgv1.CheCluster{}}) {
+		t.Fatalf("Expected finalization not to be done when a component fails")
+	}
+
+	expectedCalls := []string{"finalize c", "finalize b", "finalize a"}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Fatalf("Expected calls %v, but got %v", expectedCalls, calls)
+	}
+}
+
+func TestPlanAll(t *testing.T) {
+	calls := []string{}
+	manager := NewReconcileManager(nil)
+	manager.RegisterReconciler("a", &fakeReconciler{name: "a", err: errors.New("failure"), calls: &calls})
+	manager.RegisterReconciler("b", &fakeSwitchable{fakeConditionReporter{fakeReconciler{name: "b", calls: &calls}}, false})
+	manager.RegisterReconciler("c", &fakeReconciler{name: "c", calls: &calls})
+
+	plan := NewDryRunPlan(nil)
+	manager.PlanAll(&DeployContext{CheCluster: &orgv1.CheCluster{}, DryRun: true}, plan)
+
+	expectedCalls := []string{"reconcile a", "reconcile c"}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Fatalf("Expected calls %v, but got %v", expectedCalls, calls)
+	}
+	if len(plan.Errors()) != 1 {
+		t.Fatalf("Expected the failure of the first component to be recorded, but got %v", plan.Errors())
+	}
+}
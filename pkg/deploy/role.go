@@ -13,7 +13,6 @@ package deploy
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -38,23 +37,6 @@ var roleDiffOpts = cmp.Options{
 	cmpopts.IgnoreFields(rbac.PolicyRule{}, "ResourceNames", "NonResourceURLs"),
 }
 
-func SyncTLSRoleToCluster(deployContext *DeployContext) (*rbac.Role, error) {
-	tlsPolicyRule := []rbac.PolicyRule{
-		{
-			APIGroups: []string{
-				"",
-			},
-			Resources: []string{
-				"secrets",
-			},
-			Verbs: []string{
-				"create",
-			},
-		},
-	}
-	return SyncRoleToCluster(deployContext, CheTLSJobRoleName, tlsPolicyRule)
-}
-
 func SyncExecRoleToCluster(deployContext *DeployContext) (*rbac.Role, error) {
 	execPolicyRule := []rbac.PolicyRule{
 		{
@@ -118,15 +100,18 @@ func SyncRoleToCluster(
 	if clusterRole == nil {
 		logrus.Infof("Creating a new object: %s, name %s", specRole.Kind, specRole.Name)
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specRole)
+		recordCreateEvent(deployContext, specRole, specRole.Name, err == nil, err)
 		return nil, err
 	}
 
 	diff := cmp.Diff(clusterRole, specRole, roleDiffOpts)
 	if len(diff) > 0 {
 		logrus.Infof("Updating existed object: %s, name: %s", clusterRole.Kind, clusterRole.Name)
-		fmt.Printf("Difference:\n%s", diff)
+		logrus.Infof("Difference:\n%s", diff)
+		changedFields := getChangedFields(clusterRole, specRole, roleDiffOpts)
 		clusterRole.Rules = specRole.Rules
 		err := deployContext.ClusterAPI.Client.Update(context.TODO(), clusterRole)
+		recordUpdateEvent(deployContext, clusterRole, clusterRole.Name, changedFields, err == nil, err)
 		return nil, err
 	}
 
@@ -43,6 +43,7 @@ func SyncRoleBindingToCluster(
 	if roleBinding == nil {
 		logrus.Infof("Creating a new object: %s, name %s", specRB.Kind, specRB.Name)
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specRB)
+		recordCreateEvent(deployContext, specRB, specRB.Name, err == nil, err)
 		return nil, err
 	}
 
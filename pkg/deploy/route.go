@@ -72,6 +72,7 @@ func SyncRouteToCluster(
 	if clusterRoute == nil {
 		logrus.Infof("Creating a new object: %s, name %s", specRoute.Kind, specRoute.Name)
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specRoute)
+		recordCreateEvent(deployContext, specRoute, specRoute.Name, err == nil, err)
 		if !errors.IsAlreadyExists(err) {
 			return nil, err
 		}
@@ -85,9 +86,10 @@ func SyncRouteToCluster(
 	diff := cmp.Diff(clusterRoute, specRoute, diffOpts)
 	if len(diff) > 0 {
 		logrus.Infof("Deleting existed object: %s, name: %s", clusterRoute.Kind, clusterRoute.Name)
-		fmt.Printf("Difference:\n%s", diff)
+		logrus.Infof("Difference:\n%s", diff)
 
 		err := deployContext.ClusterAPI.Client.Delete(context.TODO(), clusterRoute)
+		recordUpdateEvent(deployContext, clusterRoute, clusterRoute.Name, getChangedFields(clusterRoute, specRoute, diffOpts), err == nil, err)
 		if !errors.IsNotFound(err) {
 			return nil, err
 		}
@@ -106,6 +108,7 @@ func DeleteRouteIfExists(name string, deployContext *DeployContext) error {
 
 	if ingress != nil {
 		err = deployContext.ClusterAPI.Client.Delete(context.TODO(), ingress)
+		recordDeleteEvent(deployContext, ingress, ingress.Name, err)
 		if !errors.IsNotFound(err) {
 			return err
 		}
@@ -186,7 +189,17 @@ func GetSpecRoute(
 			Termination:                   routev1.TLSTerminationEdge,
 		}
 
-		if name == DefaultCheFlavor(deployContext.CheCluster) && deployContext.CheCluster.Spec.Server.CheHostTLSSecret != "" {
+		if UseCertManagerCertificate(deployContext, name) {
+			// the certificate is set once it is issued
+			secret, err := GetSecret(deployContext, GetCertManagerCertificateName(deployContext.CheCluster, name), deployContext.CheCluster.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			if secret != nil {
+				route.Spec.TLS.Key = string(secret.Data["tls.key"])
+				route.Spec.TLS.Certificate = string(secret.Data["tls.crt"])
+			}
+		} else if name == DefaultCheFlavor(deployContext.CheCluster) && deployContext.CheCluster.Spec.Server.CheHostTLSSecret != "" {
 			secret := &corev1.Secret{}
 			namespacedName := types.NamespacedName{
 				Namespace: deployContext.CheCluster.Namespace,
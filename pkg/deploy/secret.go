@@ -13,7 +13,6 @@ package deploy
 
 import (
 	"context"
-	"fmt"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -53,20 +52,23 @@ func SyncSecret(
 	if clusterSecret == nil {
 		logrus.Infof("Creating a new object: %s, name %s", specSecret.Kind, specSecret.Name)
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specSecret)
+		recordCreateEvent(deployContext, specSecret, specSecret.Name, err == nil, err)
 		return specSecret, err
 	}
 
 	diff := cmp.Diff(clusterSecret, specSecret, secretDiffOpts)
 	if len(diff) > 0 {
 		logrus.Infof("Updating existed object: %s, name: %s", clusterSecret.Kind, clusterSecret.Name)
-		fmt.Printf("Difference:\n%s", diff)
+		logrus.Infof("Difference:\n%s", diff)
 
 		err := deployContext.ClusterAPI.Client.Delete(context.TODO(), clusterSecret)
 		if err != nil {
+			recordUpdateEvent(deployContext, clusterSecret, clusterSecret.Name, "", false, err)
 			return nil, err
 		}
 
 		err = deployContext.ClusterAPI.Client.Create(context.TODO(), specSecret)
+		recordUpdateEvent(deployContext, specSecret, specSecret.Name, "", err == nil, err)
 		if err != nil {
 			return nil, err
 		}
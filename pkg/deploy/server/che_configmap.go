@@ -64,6 +64,8 @@ type CheConfigMap struct {
 	KeycloakInternalURL                    string `json:"CHE_KEYCLOAK_AUTH__INTERNAL__SERVER__URL,omitempty"`
 	KeycloakRealm                          string `json:"CHE_KEYCLOAK_REALM,omitempty"`
 	KeycloakClientId                       string `json:"CHE_KEYCLOAK_CLIENT__ID,omitempty"`
+	OidcProvider                           string `json:"CHE_KEYCLOAK_OIDC__PROVIDER,omitempty"`
+	OidcUsernameClaim                      string `json:"CHE_KEYCLOAK_USERNAME__CLAIM,omitempty"`
 	OpenShiftIdentityProvider              string `json:"CHE_INFRA_OPENSHIFT_OAUTH__IDENTITY__PROVIDER"`
 	JavaOpts                               string `json:"JAVA_OPTS"`
 	WorkspaceJavaOpts                      string `json:"CHE_WORKSPACE_JAVA__OPTIONS"`
@@ -261,10 +263,21 @@ func GetCheConfigMapData(deployContext *deploy.DeployContext) (cheEnv map[string
 	}
 
 	if cheMultiUser == "true" {
-		data.KeycloakURL = keycloakURL + "/auth"
-		data.KeycloakInternalURL = keycloakInternalURL + "/auth"
-		data.KeycloakRealm = keycloakRealm
-		data.KeycloakClientId = keycloakClientId
+		if deploy.IsOpenIdConnectProviderEnabled(deployContext.CheCluster) {
+			openIdConnect := deployContext.CheCluster.Spec.Auth.OpenIdConnect
+			// Keycloak specific settings must be explicitly disabled when a generic OpenID Connect provider is used
+			data.KeycloakURL = "NULL"
+			data.KeycloakRealm = "NULL"
+			data.KeycloakClientId = openIdConnect.ClientId
+			data.OidcProvider = openIdConnect.IssuerURL
+			data.OidcUsernameClaim = openIdConnect.UsernameClaim
+			data.OpenShiftIdentityProvider = "NULL"
+		} else {
+			data.KeycloakURL = keycloakURL + "/auth"
+			data.KeycloakInternalURL = keycloakInternalURL + "/auth"
+			data.KeycloakRealm = keycloakRealm
+			data.KeycloakClientId = keycloakClientId
+		}
 		data.DatabaseURL = "jdbc:postgresql://" + chePostgresHostName + ":" + chePostgresPort + "/" + chePostgresDb
 		if len(deployContext.CheCluster.Spec.Database.ChePostgresSecret) < 1 {
 			data.DbUserName = deployContext.CheCluster.Spec.Database.ChePostgresUser
@@ -290,6 +303,12 @@ func GetCheConfigMapData(deployContext *deploy.DeployContext) (cheEnv map[string
 			"CHE_INFRA_KUBERNETES_INGRESS_PATH__TRANSFORM":             "%s(.*)",
 		}
 
+		// The workspace ingresses use the same class as the Che ones, the annotation is kept for the ingress
+		// controllers which don't register their class
+		if deployContext.IngressClassName != "" {
+			k8sCheEnv["CHE_INFRA_KUBERNETES_INGRESS_CLASS__NAME"] = deployContext.IngressClassName
+		}
+
 		// Add TLS key and server certificate to properties when user workspaces should be created in another
 		// than Che server namespace, from where the Che TLS secret is not accessable
 		if !util.IsWorkspaceInSameNamespaceWithChe(deployContext.CheCluster) {
@@ -318,23 +337,9 @@ func GetCheConfigMapData(deployContext *deploy.DeployContext) (cheEnv map[string
 
 	addMap(cheEnv, deployContext.CheCluster.Spec.Server.CustomCheProperties)
 
-	// Update BitBucket endpoints
-	secrets, err := deploy.GetSecrets(deployContext, map[string]string{
-		deploy.KubernetesPartOfLabelKey:    deploy.CheEclipseOrg,
-		deploy.KubernetesComponentLabelKey: deploy.OAuthScmConfiguration,
-	}, map[string]string{
-		deploy.CheEclipseOrgOAuthScmServer: "bitbucket",
-	})
-	if err != nil {
+	// Update the endpoints of the SCM servers
+	if err := addScmServerEndpoints(deployContext, cheEnv); err != nil {
 		return nil, err
-	} else if len(secrets) == 1 {
-		serverEndpoint := secrets[0].Annotations[deploy.CheEclipseOrgScmServerEndpoint]
-		endpoints, exists := cheEnv["CHE_INTEGRATION_BITBUCKET_SERVER__ENDPOINTS"]
-		if exists {
-			cheEnv["CHE_INTEGRATION_BITBUCKET_SERVER__ENDPOINTS"] = endpoints + "," + serverEndpoint
-		} else {
-			cheEnv["CHE_INTEGRATION_BITBUCKET_SERVER__ENDPOINTS"] = serverEndpoint
-		}
 	}
 
 	return cheEnv, nil
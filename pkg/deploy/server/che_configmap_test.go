@@ -102,13 +102,14 @@ func TestNewCheConfigMap(t *testing.T) {
 
 func TestConfigMap(t *testing.T) {
 	type testCase struct {
-		name            string
-		isOpenShift     bool
-		isOpenShift4    bool
-		initObjects     []runtime.Object
-		cheCluster      *orgv1.CheCluster
-		internalService deploy.InternalService
-		expectedData    map[string]string
+		name             string
+		isOpenShift      bool
+		isOpenShift4     bool
+		initObjects      []runtime.Object
+		cheCluster       *orgv1.CheCluster
+		internalService  deploy.InternalService
+		ingressClassName string
+		expectedData     map[string]string
 	}
 
 	testCases := []testCase{
@@ -166,6 +167,20 @@ func TestConfigMap(t *testing.T) {
 				"CHE_INFRA_KUBERNETES_TLS__KEY":  "KEY",
 			},
 		},
+		{
+			name: "Test k8s data, with ingress class name",
+			cheCluster: &orgv1.CheCluster{
+				Spec: orgv1.CheClusterSpec{
+					K8s: orgv1.CheClusterSpecK8SOnly{
+						IngressClass: "traefik",
+					},
+				},
+			},
+			ingressClassName: "traefik",
+			expectedData: map[string]string{
+				"CHE_INFRA_KUBERNETES_INGRESS_CLASS__NAME": "traefik",
+			},
+		},
 		{
 			name: "Test k8s data, with internal cluster svc names",
 			cheCluster: &orgv1.CheCluster{
@@ -202,6 +217,34 @@ func TestConfigMap(t *testing.T) {
 				"CHE_WEBSOCKET_ENDPOINT__MINOR": "ws://che-host/api/websocket-minor",
 			},
 		},
+		{
+			name:         "Test generic OpenID Connect provider",
+			isOpenShift:  true,
+			isOpenShift4: true,
+			cheCluster: &orgv1.CheCluster{
+				Spec: orgv1.CheClusterSpec{
+					Server: orgv1.CheClusterSpecServer{
+						CheHost: "che-host",
+					},
+					Auth: orgv1.CheClusterSpecAuth{
+						OpenShiftoAuth: util.NewBoolPointer(true),
+						OpenIdConnect: &orgv1.OpenIdConnectProvider{
+							IssuerURL:     "https://dex.example.com",
+							ClientId:      "che",
+							UsernameClaim: "email",
+						},
+					},
+				},
+			},
+			expectedData: map[string]string{
+				"CHE_KEYCLOAK_AUTH__SERVER__URL":                "NULL",
+				"CHE_KEYCLOAK_REALM":                            "NULL",
+				"CHE_KEYCLOAK_CLIENT__ID":                       "che",
+				"CHE_KEYCLOAK_OIDC__PROVIDER":                   "https://dex.example.com",
+				"CHE_KEYCLOAK_USERNAME__CLAIM":                  "email",
+				"CHE_INFRA_OPENSHIFT_OAUTH__IDENTITY__PROVIDER": "NULL",
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -220,7 +263,9 @@ func TestConfigMap(t *testing.T) {
 					NonCachedClient: nonCachedClient,
 					Scheme:          scheme.Scheme,
 				},
-				Proxy: &deploy.Proxy{},
+				Proxy:               &deploy.Proxy{},
+				NetworkingV1Ingress: testCase.ingressClassName != "",
+				IngressClassName:    testCase.ingressClassName,
 			}
 
 			util.IsOpenShift = testCase.isOpenShift
@@ -12,7 +12,6 @@
 package server
 
 import (
-	"errors"
 	"strconv"
 	"strings"
 
@@ -20,6 +19,8 @@ import (
 
 	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -27,6 +28,20 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+var (
+	// the replicas are ignored by the common deployment diff, Che server is scaled down in maintenance mode and back up afterwards
+	cheDeploymentReplicasDiffOpts = cmp.Options{
+		cmpopts.IgnoreFields(appsv1.Deployment{}, "TypeMeta", "ObjectMeta", "Status"),
+		cmp.FilterPath(func(path cmp.Path) bool {
+			return strings.HasPrefix(path.String(), "Spec.") && path.String() != "Spec.Replicas"
+		}, cmp.Ignore()),
+	}
+	cheDeploymentReplicasMerge = func(specDeployment *appsv1.Deployment, clusterDeployment *appsv1.Deployment) *appsv1.Deployment {
+		clusterDeployment.Spec.Replicas = specDeployment.Spec.Replicas
+		return clusterDeployment
+	}
+)
+
 func SyncCheDeploymentToCluster(deployContext *deploy.DeployContext) (bool, error) {
 	clusterDeployment, err := deploy.GetClusterDeployment(deploy.DefaultCheFlavor(deployContext.CheCluster), deployContext.CheCluster.Namespace, deployContext.ClusterAPI.Client)
 	if err != nil {
@@ -38,11 +53,11 @@ func SyncCheDeploymentToCluster(deployContext *deploy.DeployContext) (bool, erro
 		return false, err
 	}
 
-	return deploy.SyncDeploymentToCluster(deployContext, specDeployment, clusterDeployment, nil, nil)
+	return deploy.SyncDeploymentToCluster(deployContext, specDeployment, clusterDeployment, cheDeploymentReplicasDiffOpts, cheDeploymentReplicasMerge)
 }
 
 func GetSpecCheDeployment(deployContext *deploy.DeployContext) (*appsv1.Deployment, error) {
-	isOpenShift, _, err := util.DetectOpenShift()
+	isOpenShift, _, err := deploy.DetectOpenShift(deployContext)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +70,18 @@ func GetSpecCheDeployment(deployContext *deploy.DeployContext) (*appsv1.Deployme
 	cmResourceVersions := GetCheConfigMapVersion(deployContext)
 	cmResourceVersions += "," + deploy.GetAdditionalCACertsConfigMapVersion(deployContext)
 
+	// pods are restarted once the credentials are rotated or the certificates are renewed
+	annotations := deploy.GetCredentialsRotationAnnotations(deployContext.CheCluster)
+	if tlsAnnotations := deploy.GetCheTLSCertificatesAnnotations(deployContext); tlsAnnotations != nil {
+		annotations = util.MergeMaps(annotations, tlsAnnotations)
+	}
+
 	terminationGracePeriodSeconds := int64(30)
+	// Che server is stopped while the maintenance page is shown instead
+	replicas := int32(1)
+	if deploy.IsMaintenanceMode(deployContext.CheCluster) {
+		replicas = 0
+	}
 	cheFlavor := deploy.DefaultCheFlavor(deployContext.CheCluster)
 	labels, labelSelector := deploy.GetLabelsAndSelector(deployContext.CheCluster, cheFlavor)
 	optionalEnv := true
@@ -195,13 +221,15 @@ func GetSpecCheDeployment(deployContext *deploy.DeployContext) (*appsv1.Deployme
 			Labels:    labels,
 		},
 		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{MatchLabels: labelSelector},
 			Strategy: appsv1.DeploymentStrategy{
 				Type: appsv1.RollingUpdateDeploymentStrategyType,
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: annotations,
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName:       "che",
@@ -274,7 +302,7 @@ func GetSpecCheDeployment(deployContext *deploy.DeployContext) (*appsv1.Deployme
 		},
 	}
 
-	err = MountBitBucketOAuthConfig(deployContext, deployment)
+	err = MountScmOAuthConfig(deployContext, deployment)
 	if err != nil {
 		return nil, err
 	}
@@ -413,51 +441,3 @@ func GetFullCheServerImageLink(checluster *orgv1.CheCluster) string {
 	imageParts := strings.Split(defaultCheServerImage, separator)
 	return imageParts[0] + ":" + checluster.Spec.Server.CheImageTag
 }
-
-func MountBitBucketOAuthConfig(deployContext *deploy.DeployContext, deployment *appsv1.Deployment) error {
-	// mount BitBucket configuration
-	secrets, err := deploy.GetSecrets(deployContext, map[string]string{
-		deploy.KubernetesPartOfLabelKey:    deploy.CheEclipseOrg,
-		deploy.KubernetesComponentLabelKey: deploy.OAuthScmConfiguration,
-	}, map[string]string{
-		deploy.CheEclipseOrgOAuthScmServer: "bitbucket",
-	})
-
-	if err != nil {
-		return err
-	} else if len(secrets) > 1 {
-		return errors.New("More than 1 BitBucket OAuth configuration secrets found")
-	} else if len(secrets) == 1 {
-		// mount secrets
-		container := &deployment.Spec.Template.Spec.Containers[0]
-		deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes,
-			corev1.Volume{
-				Name: secrets[0].Name,
-				VolumeSource: corev1.VolumeSource{
-					Secret: &corev1.SecretVolumeSource{
-						SecretName: secrets[0].Name,
-					},
-				},
-			})
-		container.VolumeMounts = append(container.VolumeMounts,
-			corev1.VolumeMount{
-				Name:      secrets[0].Name,
-				MountPath: deploy.BitBucketOAuthConfigMountPath,
-			})
-
-		// mount env
-		endpoint := secrets[0].Annotations[deploy.CheEclipseOrgScmServerEndpoint]
-		container.Env = append(container.Env, corev1.EnvVar{
-			Name:  "CHE_OAUTH1_BITBUCKET_CONSUMERKEYPATH",
-			Value: deploy.BitBucketOAuthConfigMountPath + "/" + deploy.BitBucketOAuthConfigConsumerKey,
-		}, corev1.EnvVar{
-			Name:  "CHE_OAUTH1_BITBUCKET_PRIVATEKEYPATH",
-			Value: deploy.BitBucketOAuthConfigMountPath + "/" + deploy.BitBucketOAuthConfigPrivateKey,
-		}, corev1.EnvVar{
-			Name:  "CHE_OAUTH1_BITBUCKET_ENDPOINT",
-			Value: endpoint,
-		})
-	}
-
-	return nil
-}
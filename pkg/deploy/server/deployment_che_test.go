@@ -21,6 +21,7 @@ import (
 	"github.com/eclipse-che/che-operator/pkg/deploy"
 
 	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -234,3 +235,26 @@ func TestMountBitBucketOAuthEnvVar(t *testing.T) {
 		})
 	}
 }
+
+func TestCheDeploymentReplicasDiffOpts(t *testing.T) {
+	newDeployment := func(replicas int32, image string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "che", ResourceVersion: image},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "che", Image: image}},
+					},
+				},
+			},
+		}
+	}
+
+	if diff := cmp.Diff(newDeployment(1, "che:1"), newDeployment(1, "che:2"), cheDeploymentReplicasDiffOpts); diff != "" {
+		t.Errorf("Only replicas are expected to be compared, but got the difference:\n%s", diff)
+	}
+	if diff := cmp.Diff(newDeployment(1, "che:1"), newDeployment(0, "che:1"), cheDeploymentReplicasDiffOpts); diff == "" {
+		t.Error("Replicas are expected to differ")
+	}
+}
@@ -0,0 +1,274 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// scmOAuthProvider configures Che server to integrate with the SCM servers of one type.
+// The servers are declared by the secrets annotated with `che.eclipse.org/oauth-scm-server=<type>`,
+// one secret per server. When there are several servers of the same type, the values of the
+// environment variables are comma separated lists, in the order of the names of the secrets.
+type scmOAuthProvider interface {
+	// getEnv returns the environment variables which configure OAuth of the servers in Che server,
+	// given the directories where the secrets of the servers are mounted.
+	getEnv(secrets []corev1.Secret, mountPaths []string) ([]corev1.EnvVar, error)
+	// getEndpoints returns the endpoints of the servers.
+	getEndpoints(secrets []corev1.Secret) []string
+	// getEndpointsProperty returns the Che property which lists the endpoints of the servers.
+	getEndpointsProperty() string
+}
+
+var (
+	// scmOAuthProviders are the providers by the value of the `che.eclipse.org/oauth-scm-server` annotation.
+	// GitHub OAuth is provisioned in Keycloak, see `identity_provider.SyncGitHubOAuth`.
+	scmOAuthProviders = map[string]scmOAuthProvider{
+		"bitbucket":    &bitBucketOAuthProvider{},
+		"gitlab":       &oAuth2ScmProvider{name: "GitLab", propertyName: "GITLAB", oAuthEndpointProperty: "CHE_INTEGRATION_GITLAB_OAUTH__ENDPOINT"},
+		"gitea":        &oAuth2ScmProvider{name: "Gitea", propertyName: "GITEA", oAuthEndpointProperty: "CHE_INTEGRATION_GITEA_OAUTH__ENDPOINT"},
+		"azure-devops": &oAuth2ScmProvider{name: "Azure DevOps", propertyName: "AZURE_DEVOPS", defaultEndpoint: "https://dev.azure.com"},
+	}
+)
+
+// bitBucketOAuthProvider configures OAuth 1.0 of Bitbucket Server.
+type bitBucketOAuthProvider struct{}
+
+func (p *bitBucketOAuthProvider) getEnv(secrets []corev1.Secret, mountPaths []string) ([]corev1.EnvVar, error) {
+	consumerKeyPaths := []string{}
+	privateKeyPaths := []string{}
+	for _, mountPath := range mountPaths {
+		consumerKeyPaths = append(consumerKeyPaths, mountPath+"/"+deploy.BitBucketOAuthConfigConsumerKey)
+		privateKeyPaths = append(privateKeyPaths, mountPath+"/"+deploy.BitBucketOAuthConfigPrivateKey)
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "CHE_OAUTH1_BITBUCKET_CONSUMERKEYPATH",
+			Value: strings.Join(consumerKeyPaths, ","),
+		},
+		{
+			Name:  "CHE_OAUTH1_BITBUCKET_PRIVATEKEYPATH",
+			Value: strings.Join(privateKeyPaths, ","),
+		},
+		{
+			Name:  "CHE_OAUTH1_BITBUCKET_ENDPOINT",
+			Value: strings.Join(p.getEndpoints(secrets), ","),
+		},
+	}, nil
+}
+
+func (p *bitBucketOAuthProvider) getEndpoints(secrets []corev1.Secret) []string {
+	return getScmServerEndpoints(secrets, "")
+}
+
+func (p *bitBucketOAuthProvider) getEndpointsProperty() string {
+	return "CHE_INTEGRATION_BITBUCKET_SERVER__ENDPOINTS"
+}
+
+// oAuth2ScmProvider configures OAuth 2.0 of an SCM server. The secrets hold the id and the secret
+// of the OAuth application registered in the server.
+type oAuth2ScmProvider struct {
+	// name is the name of the provider used in the error messages.
+	name string
+	// propertyName is the name of the provider used in the names of the Che properties.
+	propertyName string
+	// oAuthEndpointProperty is the Che property which holds the OAuth endpoints of the servers,
+	// if the provider supports self-hosted servers.
+	oAuthEndpointProperty string
+	// defaultEndpoint is the endpoint of the server when the secret has no endpoint annotation.
+	defaultEndpoint string
+}
+
+func (p *oAuth2ScmProvider) getEnv(secrets []corev1.Secret, mountPaths []string) ([]corev1.EnvVar, error) {
+	for _, secret := range secrets {
+		for _, key := range []string{deploy.OAuthScmConfigClientId, deploy.OAuthScmConfigClientSecret} {
+			if len(secret.Data[key]) == 0 {
+				return nil, fmt.Errorf("%s OAuth configuration secret '%s' has no '%s' key", p.name, secret.Name, key)
+			}
+		}
+		if p.defaultEndpoint == "" && secret.Annotations[deploy.CheEclipseOrgScmServerEndpoint] == "" {
+			return nil, fmt.Errorf("%s OAuth configuration secret '%s' has no '%s' annotation", p.name, secret.Name, deploy.CheEclipseOrgScmServerEndpoint)
+		}
+	}
+
+	clientIdPaths := []string{}
+	clientSecretPaths := []string{}
+	for _, mountPath := range mountPaths {
+		clientIdPaths = append(clientIdPaths, mountPath+"/"+deploy.OAuthScmConfigClientId)
+		clientSecretPaths = append(clientSecretPaths, mountPath+"/"+deploy.OAuthScmConfigClientSecret)
+	}
+
+	env := []corev1.EnvVar{
+		{
+			Name:  "CHE_OAUTH2_" + p.propertyName + "_CLIENTID__FILEPATH",
+			Value: strings.Join(clientIdPaths, ","),
+		},
+		{
+			Name:  "CHE_OAUTH2_" + p.propertyName + "_CLIENTSECRET__FILEPATH",
+			Value: strings.Join(clientSecretPaths, ","),
+		},
+	}
+	if p.oAuthEndpointProperty != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  p.oAuthEndpointProperty,
+			Value: strings.Join(p.getEndpoints(secrets), ","),
+		})
+	}
+	return env, nil
+}
+
+func (p *oAuth2ScmProvider) getEndpoints(secrets []corev1.Secret) []string {
+	return getScmServerEndpoints(secrets, p.defaultEndpoint)
+}
+
+func (p *oAuth2ScmProvider) getEndpointsProperty() string {
+	return "CHE_INTEGRATION_" + p.propertyName + "_SERVER__ENDPOINTS"
+}
+
+// getScmOAuthSecrets returns the OAuth configuration secrets of the SCM servers supported by Che server,
+// by the type of the servers, sorted by name.
+func getScmOAuthSecrets(deployContext *deploy.DeployContext) (map[string][]corev1.Secret, error) {
+	secrets, err := deploy.GetSecrets(deployContext, map[string]string{
+		deploy.KubernetesPartOfLabelKey:    deploy.CheEclipseOrg,
+		deploy.KubernetesComponentLabelKey: deploy.OAuthScmConfiguration,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+
+	secretsByType := map[string][]corev1.Secret{}
+	for _, secret := range secrets {
+		scmServer := secret.Annotations[deploy.CheEclipseOrgOAuthScmServer]
+		if _, ok := scmOAuthProviders[scmServer]; ok {
+			secretsByType[scmServer] = append(secretsByType[scmServer], secret)
+		} else if scmServer != "github" {
+			logrus.Warnf("Secret '%s' has unsupported SCM server type '%s'", secret.Name, scmServer)
+		}
+	}
+	return secretsByType, nil
+}
+
+// getScmServerEndpoints returns the endpoints of the SCM servers configured by the secrets.
+func getScmServerEndpoints(secrets []corev1.Secret, defaultEndpoint string) []string {
+	endpoints := []string{}
+	for _, secret := range secrets {
+		endpoint := secret.Annotations[deploy.CheEclipseOrgScmServerEndpoint]
+		if endpoint == "" {
+			endpoint = defaultEndpoint
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// MountScmOAuthConfig mounts the OAuth configuration secrets of the SCM servers into Che server container
+// and sets the environment variables which refer to them. The secret of the only server of a type is mounted
+// into `/che-conf/oauth/<type>`, the secrets of several servers into `/che-conf/oauth/<type>/<secret name>`.
+func MountScmOAuthConfig(deployContext *deploy.DeployContext, deployment *appsv1.Deployment) error {
+	secretsByType, err := getScmOAuthSecrets(deployContext)
+	if err != nil {
+		return err
+	}
+
+	scmServers := []string{}
+	for scmServer := range secretsByType {
+		scmServers = append(scmServers, scmServer)
+	}
+	sort.Strings(scmServers)
+
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	for _, scmServer := range scmServers {
+		secrets := secretsByType[scmServer]
+		mountPaths := []string{}
+		for _, secret := range secrets {
+			mountPath := deploy.OAuthScmConfigMountPath + "/" + scmServer
+			if len(secrets) > 1 {
+				mountPath += "/" + secret.Name
+			}
+			mountPaths = append(mountPaths, mountPath)
+
+			volumeName := getScmOAuthVolumeName(secret.Name)
+			deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes,
+				corev1.Volume{
+					Name: volumeName,
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: secret.Name,
+						},
+					},
+				})
+			container.VolumeMounts = append(container.VolumeMounts,
+				corev1.VolumeMount{
+					Name:      volumeName,
+					MountPath: mountPath,
+				})
+		}
+
+		env, err := scmOAuthProviders[scmServer].getEnv(secrets, mountPaths)
+		if err != nil {
+			return err
+		}
+		container.Env = append(container.Env, env...)
+	}
+
+	return nil
+}
+
+// getScmOAuthVolumeName returns the name of the volume of the secret. The name of the secret is kept
+// when it's a valid volume name. Otherwise, since the name of a secret may contain dots and be longer than
+// allowed for a volume, it's truncated and suffixed by its hash to remain unique.
+func getScmOAuthVolumeName(secretName string) string {
+	if len(validation.IsDNS1123Label(secretName)) == 0 {
+		return secretName
+	}
+
+	hash := sha256.Sum256([]byte(secretName))
+	suffix := "-" + hex.EncodeToString(hash[:])[:8]
+	prefix := strings.ReplaceAll(secretName, ".", "-")
+	if len(prefix) > validation.DNS1123LabelMaxLength-len(suffix) {
+		prefix = prefix[:validation.DNS1123LabelMaxLength-len(suffix)]
+	}
+	return prefix + suffix
+}
+
+// addScmServerEndpoints adds the endpoints of the SCM servers to the endpoints
+// already listed in Che properties.
+func addScmServerEndpoints(deployContext *deploy.DeployContext, cheEnv map[string]string) error {
+	secretsByType, err := getScmOAuthSecrets(deployContext)
+	if err != nil {
+		return err
+	}
+
+	for scmServer, secrets := range secretsByType {
+		provider := scmOAuthProviders[scmServer]
+		property := provider.getEndpointsProperty()
+		endpoints := provider.getEndpoints(secrets)
+		if cheEnv[property] != "" {
+			endpoints = append([]string{cheEnv[property]}, endpoints...)
+		}
+		cheEnv[property] = strings.Join(endpoints, ",")
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package server
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+func getScmOAuthSecret(name string, scmServer string, endpoint string, data map[string][]byte) *corev1.Secret {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "eclipse-che",
+			Labels: map[string]string{
+				"app.kubernetes.io/part-of":   "che.eclipse.org",
+				"app.kubernetes.io/component": "oauth-scm-configuration",
+			},
+			Annotations: map[string]string{
+				"che.eclipse.org/oauth-scm-server": scmServer,
+			},
+		},
+		Data: data,
+	}
+	if endpoint != "" {
+		secret.Annotations["che.eclipse.org/scm-server-endpoint"] = endpoint
+	}
+	return secret
+}
+
+func TestMountScmOAuthConfig(t *testing.T) {
+	type testCase struct {
+		name                 string
+		initObjects          []runtime.Object
+		expectedEnv          []corev1.EnvVar
+		expectedVolumeMounts []corev1.VolumeMount
+		expectedData         map[string]string
+		expectedErr          bool
+	}
+
+	oAuth2Data := map[string][]byte{"id": []byte("id"), "secret": []byte("secret")}
+
+	testCases := []testCase{
+		{
+			name: "Test mount several GitLab servers",
+			initObjects: []runtime.Object{
+				getScmOAuthSecret("gitlab-internal", "gitlab", "https://gitlab.internal", oAuth2Data),
+				getScmOAuthSecret("gitlab-com", "gitlab", "https://gitlab.com", oAuth2Data),
+			},
+			expectedEnv: []corev1.EnvVar{
+				{
+					Name:  "CHE_OAUTH2_GITLAB_CLIENTID__FILEPATH",
+					Value: "/che-conf/oauth/gitlab/gitlab-com/id,/che-conf/oauth/gitlab/gitlab-internal/id",
+				},
+				{
+					Name:  "CHE_OAUTH2_GITLAB_CLIENTSECRET__FILEPATH",
+					Value: "/che-conf/oauth/gitlab/gitlab-com/secret,/che-conf/oauth/gitlab/gitlab-internal/secret",
+				},
+				{
+					Name:  "CHE_INTEGRATION_GITLAB_OAUTH__ENDPOINT",
+					Value: "https://gitlab.com,https://gitlab.internal",
+				},
+			},
+			expectedVolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      "gitlab-com",
+					MountPath: "/che-conf/oauth/gitlab/gitlab-com",
+				},
+				{
+					Name:      "gitlab-internal",
+					MountPath: "/che-conf/oauth/gitlab/gitlab-internal",
+				},
+			},
+			expectedData: map[string]string{
+				"CHE_INTEGRATION_GITLAB_SERVER__ENDPOINTS": "https://gitlab.com,https://gitlab.internal",
+			},
+		},
+		{
+			name: "Test mount Gitea and Azure DevOps servers",
+			initObjects: []runtime.Object{
+				getScmOAuthSecret("gitea", "gitea", "https://gitea.internal", oAuth2Data),
+				getScmOAuthSecret("azure", "azure-devops", "", oAuth2Data),
+			},
+			expectedEnv: []corev1.EnvVar{
+				{
+					Name:  "CHE_OAUTH2_AZURE_DEVOPS_CLIENTID__FILEPATH",
+					Value: "/che-conf/oauth/azure-devops/id",
+				},
+				{
+					Name:  "CHE_OAUTH2_AZURE_DEVOPS_CLIENTSECRET__FILEPATH",
+					Value: "/che-conf/oauth/azure-devops/secret",
+				},
+				{
+					Name:  "CHE_OAUTH2_GITEA_CLIENTID__FILEPATH",
+					Value: "/che-conf/oauth/gitea/id",
+				},
+				{
+					Name:  "CHE_OAUTH2_GITEA_CLIENTSECRET__FILEPATH",
+					Value: "/che-conf/oauth/gitea/secret",
+				},
+				{
+					Name:  "CHE_INTEGRATION_GITEA_OAUTH__ENDPOINT",
+					Value: "https://gitea.internal",
+				},
+			},
+			expectedVolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      "azure",
+					MountPath: "/che-conf/oauth/azure-devops",
+				},
+				{
+					Name:      "gitea",
+					MountPath: "/che-conf/oauth/gitea",
+				},
+			},
+			expectedData: map[string]string{
+				"CHE_INTEGRATION_AZURE_DEVOPS_SERVER__ENDPOINTS": "https://dev.azure.com",
+				"CHE_INTEGRATION_GITEA_SERVER__ENDPOINTS":        "https://gitea.internal",
+			},
+		},
+		{
+			name: "Test ignore GitHub and unsupported servers",
+			initObjects: []runtime.Object{
+				getScmOAuthSecret("github", "github", "", oAuth2Data),
+				getScmOAuthSecret("unknown", "unknown", "https://unknown.internal", oAuth2Data),
+			},
+			expectedEnv:          []corev1.EnvVar{},
+			expectedVolumeMounts: []corev1.VolumeMount{},
+			expectedData:         map[string]string{},
+		},
+		{
+			name: "Test fail when client secret is missing",
+			initObjects: []runtime.Object{
+				getScmOAuthSecret("gitlab", "gitlab", "https://gitlab.internal", map[string][]byte{"id": []byte("id")}),
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Test fail when endpoint is missing",
+			initObjects: []runtime.Object{
+				getScmOAuthSecret("gitea", "gitea", "", oAuth2Data),
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			logf.SetLogger(zap.LoggerTo(os.Stdout, true))
+			orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+			cli := fake.NewFakeClientWithScheme(scheme.Scheme, testCase.initObjects...)
+
+			deployContext := &deploy.DeployContext{
+				CheCluster: &orgv1.CheCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "eclipse-che",
+					},
+				},
+				ClusterAPI: deploy.ClusterAPI{
+					Client:          cli,
+					NonCachedClient: cli,
+					Scheme:          scheme.Scheme,
+				},
+				Proxy: &deploy.Proxy{},
+			}
+
+			deployment, err := GetSpecCheDeployment(deployContext)
+			if testCase.expectedErr {
+				if err == nil {
+					t.Fatalf("Error expected")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("Error creating deployment: %v", err)
+			}
+
+			container := &deployment.Spec.Template.Spec.Containers[0]
+			for _, expectedEnv := range testCase.expectedEnv {
+				env := util.FindEnv(container.Env, expectedEnv.Name)
+				if env == nil || !reflect.DeepEqual(expectedEnv, *env) {
+					t.Errorf("Expected Env and Env returned from API server differ (-want, +got): %v", cmp.Diff(expectedEnv, env))
+				}
+			}
+
+			scmVolumeMounts := []corev1.VolumeMount{}
+			for _, volumeMount := range container.VolumeMounts {
+				if volumeMount.Name != "che-public-certs" {
+					scmVolumeMounts = append(scmVolumeMounts, volumeMount)
+				}
+			}
+			if !reflect.DeepEqual(testCase.expectedVolumeMounts, scmVolumeMounts) {
+				t.Errorf("Expected VolumeMounts and VolumeMounts returned from API server differ (-want, +got): %v", cmp.Diff(testCase.expectedVolumeMounts, scmVolumeMounts))
+			}
+
+			actualData, err := GetCheConfigMapData(deployContext)
+			if err != nil {
+				t.Fatalf("Error creating ConfigMap data: %v", err)
+			}
+			util.ValidateContainData(actualData, testCase.expectedData, t)
+		})
+	}
+}
+
+func TestGetScmOAuthVolumeName(t *testing.T) {
+	testCases := map[string]string{
+		"gitlab-oauth-config":               "gitlab-oauth-config",
+		"gitlab.internal.oauth-config":      "gitlab-internal-oauth-config-a9af5687",
+		"gitlab-" + strings.Repeat("a", 60): "gitlab-" + strings.Repeat("a", 47) + "-ec31a29b",
+	}
+
+	for secretName, expectedVolumeName := range testCases {
+		volumeName := getScmOAuthVolumeName(secretName)
+		if volumeName != expectedVolumeName {
+			t.Errorf("Expected volume name of the secret '%s' to be '%s', got '%s'", secretName, expectedVolumeName, volumeName)
+		}
+		if errs := validation.IsDNS1123Label(volumeName); len(errs) > 0 {
+			t.Errorf("Volume name '%s' is invalid: %v", volumeName, errs)
+		}
+	}
+}
@@ -14,7 +14,6 @@ package deploy
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/eclipse-che/che-operator/pkg/util"
 	"github.com/google/go-cmp/cmp"
@@ -68,6 +67,7 @@ func DoSyncServiceToCluster(deployContext *DeployContext, specService *corev1.Se
 	if clusterService == nil {
 		logrus.Infof("Creating a new object: %s, name %s", specService.Kind, specService.Name)
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specService)
+		recordCreateEvent(deployContext, specService, specService.Name, err == nil, err)
 		return ServiceProvisioningStatus{
 			ProvisioningStatus: ProvisioningStatus{Requeue: true, Err: err},
 		}
@@ -77,17 +77,23 @@ func DoSyncServiceToCluster(deployContext *DeployContext, specService *corev1.Se
 	diffSelectors := cmp.Diff(clusterService.Spec.Selector, specService.Spec.Selector)
 	if len(diffPorts) > 0 || len(diffSelectors) > 0 {
 		logrus.Infof("Updating existed object: %s, name: %s", specService.Kind, specService.Name)
-		fmt.Printf("Ports difference:\n%s", diffPorts)
-		fmt.Printf("Selectors difference:\n%s", diffSelectors)
+		logrus.Infof("Ports difference:\n%s", diffPorts)
+		logrus.Infof("Selectors difference:\n%s", diffSelectors)
 
 		err := deployContext.ClusterAPI.Client.Delete(context.TODO(), clusterService)
 		if err != nil {
+			recordUpdateEvent(deployContext, clusterService, clusterService.Name, "", false, err)
 			return ServiceProvisioningStatus{
 				ProvisioningStatus: ProvisioningStatus{Requeue: true, Err: err},
 			}
 		}
 
 		err = deployContext.ClusterAPI.Client.Create(context.TODO(), specService)
+		changedFields := getChangedFields(
+			corev1.ServiceSpec{Ports: clusterService.Spec.Ports, Selector: clusterService.Spec.Selector},
+			corev1.ServiceSpec{Ports: specService.Spec.Ports, Selector: specService.Spec.Selector},
+			portsDiffOpts)
+		recordUpdateEvent(deployContext, specService, specService.Name, changedFields, err == nil, err)
 		return ServiceProvisioningStatus{
 			ProvisioningStatus: ProvisioningStatus{Requeue: true, Err: err},
 		}
@@ -37,6 +37,7 @@ func SyncServiceAccountToCluster(deployContext *DeployContext, name string) (*co
 	if clusterSA == nil {
 		logrus.Infof("Creating a new object: %s, name %s", specSA.Kind, specSA.Name)
 		err := deployContext.ClusterAPI.Client.Create(context.TODO(), specSA)
+		recordCreateEvent(deployContext, specSA, specSA.Name, err == nil, err)
 		return nil, err
 	}
 
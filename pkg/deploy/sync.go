@@ -138,7 +138,9 @@ func CreateIfNotExists(deployContext *DeployContext, blueprint metav1.Object) (b
 		return false, err
 	}
 
-	return doCreate(client, runtimeObject, true)
+	done, err := doCreate(client, runtimeObject, true)
+	recordCreateEvent(deployContext, runtimeObject, blueprint.GetName(), done, err)
+	return done, err
 }
 
 // Creates object.
@@ -164,26 +166,28 @@ func Create(deployContext *DeployContext, blueprint metav1.Object) (bool, error)
 		return false, err
 	}
 
-	return doCreate(client, runtimeObject, false)
+	done, err := doCreate(client, runtimeObject, false)
+	recordCreateEvent(deployContext, runtimeObject, blueprint.GetName(), done, err)
+	return done, err
 }
 
 // Deletes object.
 // Returns true if object deleted or not found otherwise returns false.
 func Delete(deployContext *DeployContext, key client.ObjectKey, objectMeta metav1.Object) (bool, error) {
 	client := getClientForObject(key.Namespace, deployContext)
-	return doDeleteByKey(client, deployContext.ClusterAPI.Scheme, key, objectMeta)
+	return doDeleteByKey(deployContext, client, key, objectMeta)
 }
 
 func DeleteNamespacedObject(deployContext *DeployContext, name string, objectMeta metav1.Object) (bool, error) {
 	client := deployContext.ClusterAPI.Client
 	key := types.NamespacedName{Name: name, Namespace: deployContext.CheCluster.Namespace}
-	return doDeleteByKey(client, deployContext.ClusterAPI.Scheme, key, objectMeta)
+	return doDeleteByKey(deployContext, client, key, objectMeta)
 }
 
 func DeleteClusterObject(deployContext *DeployContext, name string, objectMeta metav1.Object) (bool, error) {
 	client := deployContext.ClusterAPI.NonCachedClient
 	key := types.NamespacedName{Name: name}
-	return doDeleteByKey(client, deployContext.ClusterAPI.Scheme, key, objectMeta)
+	return doDeleteByKey(deployContext, client, key, objectMeta)
 }
 
 // Updates object.
@@ -204,12 +208,14 @@ func Update(deployContext *DeployContext, actual runtime.Object, blueprint metav
 	if len(diff) > 0 {
 		kind := actual.GetObjectKind().GroupVersionKind().Kind
 		logrus.Infof("Updating existing object: %s, name: %s", kind, actualMeta.GetName())
-		fmt.Printf("Difference:\n%s", diff)
+		logrus.Infof("Difference:\n%s", diff)
+		changedFields := getChangedFields(actual, blueprint, diffOpts)
 
 		client := getClientForObject(actualMeta.GetNamespace(), deployContext)
 		if isUpdateUsingDeleteCreate(actual.GetObjectKind().GroupVersionKind().Kind) {
 			done, err := doDelete(client, actual)
 			if !done {
+				recordUpdateEvent(deployContext, actual, actualMeta.GetName(), changedFields, done, err)
 				return false, err
 			}
 
@@ -218,7 +224,9 @@ func Update(deployContext *DeployContext, actual runtime.Object, blueprint metav
 				return false, err
 			}
 
-			return doCreate(client, blueprint.(runtime.Object), false)
+			done, err = doCreate(client, blueprint.(runtime.Object), false)
+			recordUpdateEvent(deployContext, actual, actualMeta.GetName(), changedFields, done, err)
+			return done, err
 		} else {
 			err := setOwnerReferenceIfNeeded(deployContext, blueprint)
 			if err != nil {
@@ -232,7 +240,9 @@ func Update(deployContext *DeployContext, actual runtime.Object, blueprint metav
 
 			// to be able to update, we need to set the resource version of the object that we know of
 			obj.(metav1.Object).SetResourceVersion(actualMeta.GetResourceVersion())
-			return doUpdate(client, obj)
+			done, err := doUpdate(client, obj)
+			recordUpdateEvent(deployContext, actual, actualMeta.GetName(), changedFields, done, err)
+			return done, err
 		}
 	}
 	return true, nil
@@ -249,7 +259,7 @@ func doCreate(client client.Client, object runtime.Object, returnTrueIfAlreadyEx
 	}
 }
 
-func doDeleteByKey(client client.Client, scheme *runtime.Scheme, key client.ObjectKey, objectMeta metav1.Object) (bool, error) {
+func doDeleteByKey(deployContext *DeployContext, client client.Client, key client.ObjectKey, objectMeta metav1.Object) (bool, error) {
 	runtimeObject, ok := objectMeta.(runtime.Object)
 	if !ok {
 		return false, fmt.Errorf("object %T is not a runtime.Object. Cannot sync it", runtimeObject)
@@ -266,7 +276,9 @@ func doDeleteByKey(client client.Client, scheme *runtime.Scheme, key client.Obje
 	kind := actual.GetObjectKind().GroupVersionKind().Kind
 	logrus.Infof("Deleting object: %s, name: %s", kind, key.Name)
 
-	return doDelete(client, actual)
+	done, err := doDelete(client, actual)
+	recordDeleteEvent(deployContext, actual, key.Name, err)
+	return done, err
 }
 
 func doDelete(client client.Client, actual runtime.Object) (bool, error) {
@@ -13,14 +13,17 @@ package deploy
 
 import (
 	"context"
+	"strings"
 
 	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"github.com/eclipse-che/che-operator/pkg/util"
 	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -263,6 +266,109 @@ func TestShouldNotDeleteObject(t *testing.T) {
 	}
 }
 
+func TestSyncShouldRecordEvents(t *testing.T) {
+	_, deployContext := initDeployContext()
+	recorder := record.NewFakeRecorder(10)
+	deployContext.EventRecorder = recorder
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-configmap", Namespace: "eclipse-che"},
+		Data:       map[string]string{"a": "b"},
+	}
+	if _, err := Sync(deployContext, configMap.DeepCopy(), cmp.Options{}); err != nil {
+		t.Fatalf("Error syncing object: %v", err)
+	}
+	assertEvent(t, recorder, "Normal ObjectCreated Created ConfigMap 'test-configmap'")
+
+	configMap.Data["a"] = "c"
+	if _, err := Sync(deployContext, configMap.DeepCopy(), cmp.Options{}); err != nil {
+		t.Fatalf("Error syncing object: %v", err)
+	}
+	assertEvent(t, recorder, "Normal ObjectUpdated Updated ConfigMap 'test-configmap': ")
+
+	if _, err := Delete(deployContext, client.ObjectKey{Name: "test-configmap", Namespace: "eclipse-che"}, &corev1.ConfigMap{}); err != nil {
+		t.Fatalf("Failed to delete object: %v", err)
+	}
+	assertEvent(t, recorder, "Normal ObjectDeleted Deleted ConfigMap 'test-configmap'")
+}
+
+func TestSyncShouldNotDiscloseSecretDataInEvents(t *testing.T) {
+	cli, deployContext := initDeployContext()
+	recorder := record.NewFakeRecorder(10)
+	deployContext.EventRecorder = recorder
+
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret-data", Namespace: "eclipse-che"},
+		Data:       map[string][]byte{"password": []byte("old-password")},
+	}
+	if err := cli.Create(context.TODO(), secret.DeepCopy()); err != nil {
+		t.Fatalf("Failed to create object: %v", err)
+	}
+
+	secret.Data["password"] = []byte("new-password")
+	if _, err := Sync(deployContext, secret, cmp.Options{}); err != nil {
+		t.Fatalf("Error syncing object: %v", err)
+	}
+
+	event := <-recorder.Events
+	if strings.Contains(event, "password") {
+		t.Fatalf("Event discloses secret data: %s", event)
+	}
+}
+
+func TestSyncShouldNotDiscloseValuesInEvents(t *testing.T) {
+	_, deployContext := initDeployContext()
+	recorder := record.NewFakeRecorder(10)
+	deployContext.EventRecorder = recorder
+
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: "eclipse-che"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "che",
+							Env:  []corev1.EnvVar{{Name: "CHE_JDBC_PASSWORD", Value: "old-password"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	if _, err := Sync(deployContext, deployment.DeepCopy(), cmp.Options{}); err != nil {
+		t.Fatalf("Error syncing object: %v", err)
+	}
+	<-recorder.Events
+
+	deployment.Spec.Template.Spec.Containers[0].Env[0].Value = "new-password"
+	if _, err := Sync(deployContext, deployment.DeepCopy(), cmp.Options{}); err != nil {
+		t.Fatalf("Error syncing object: %v", err)
+	}
+
+	event := <-recorder.Events
+	if strings.Contains(event, "password") {
+		t.Fatalf("Event discloses environment variable value: %s", event)
+	}
+	if !strings.Contains(event, "Spec.Template.Spec.Containers[0].Env[0].Value") {
+		t.Fatalf("Event doesn't list the changed field: %s", event)
+	}
+}
+
+func assertEvent(t *testing.T, recorder *record.FakeRecorder, expectedPrefix string) {
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, expectedPrefix) {
+			t.Fatalf("Expected event '%s', got '%s'", expectedPrefix, event)
+		}
+	default:
+		t.Fatalf("Expected event '%s', but no event recorded", expectedPrefix)
+	}
+}
+
 func initDeployContext() (client.Client, *DeployContext) {
 	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
 	cli := fake.NewFakeClientWithScheme(scheme.Scheme)
@@ -22,13 +22,15 @@ import (
 	"strings"
 	"time"
 
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"github.com/eclipse-che/che-operator/pkg/util"
 	routev1 "github.com/openshift/api/route/v1"
 	"github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/api/extensions/v1beta1"
+	rbac "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
@@ -39,14 +41,15 @@ import (
 
 // TLS related constants
 const (
-	CheTLSJobServiceAccountName           = "che-tls-job-service-account"
-	CheTLSJobRoleName                     = "che-tls-job-role"
-	CheTLSJobRoleBindingName              = "che-tls-job-role-binding"
-	CheTLSJobName                         = "che-tls-job"
-	CheTLSJobComponentName                = "che-create-tls-secret-job"
 	CheTLSSelfSignedCertificateSecretName = "self-signed-certificate"
 	DefaultCheTLSSecretName               = "che-tls"
 
+	// The certificate generation job and its permissions, deployed by the previous versions
+	CheTLSJobServiceAccountName = "che-tls-job-service-account"
+	CheTLSJobRoleName           = "che-tls-job-role"
+	CheTLSJobRoleBindingName    = "che-tls-job-role-binding"
+	CheTLSJobName               = "che-tls-job"
+
 	// CheCACertsConfigMapLabelKey is the label key which marks config map with additional CA certificates
 	CheCACertsConfigMapLabelKey = "app.kubernetes.io/component"
 	// CheCACertsConfigMapLabelKey is the label value which marks config map with additional CA certificates
@@ -68,6 +71,10 @@ func IsSelfSignedCertificateUsed(deployContext *DeployContext) (bool, error) {
 	if util.IsTestMode() {
 		return true, nil
 	}
+	if deployContext.Offline {
+		// the certificates of the cluster can't be checked, the provided ones are used
+		return false, nil
+	}
 
 	cheTLSSelfSignedCertificateSecret := &corev1.Secret{}
 	err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Namespace: deployContext.CheCluster.Namespace, Name: CheTLSSelfSignedCertificateSecretName}, cheTLSSelfSignedCertificateSecret)
@@ -127,6 +134,9 @@ func GetEndpointTLSCrtChain(deployContext *DeployContext, endpointURL string) ([
 	if util.IsTestMode() {
 		return nil, stderrors.New("Not allowed for tests")
 	}
+	if deployContext.Offline {
+		return nil, stderrors.New("The certificates of the endpoints can't be read in offline mode")
+	}
 
 	var useTestEndpoint bool = len(endpointURL) < 1
 	var requestURL string
@@ -194,7 +204,8 @@ func GetEndpointTLSCrtChain(deployContext *DeployContext, endpointURL string) ([
 			// Remove controller reference to prevent queueing new reconcile loop
 			ingressSpec.SetOwnerReferences(nil)
 			// Create ingress manually
-			if err := deployContext.ClusterAPI.Client.Create(context.TODO(), ingressSpec); err != nil {
+			ingressObject := getIngressObject(deployContext, ingressSpec)
+			if err := deployContext.ClusterAPI.Client.Create(context.TODO(), ingressObject); err != nil {
 				if !errors.IsAlreadyExists(err) {
 					logrus.Errorf("Failed to create test ingress 'test': %s", err)
 					return nil, err
@@ -203,23 +214,22 @@ func GetEndpointTLSCrtChain(deployContext *DeployContext, endpointURL string) ([
 
 			// Schedule test ingress cleanup after the job done.
 			defer func() {
-				if err := deployContext.ClusterAPI.Client.Delete(context.TODO(), ingressSpec); err != nil {
+				if err := deployContext.ClusterAPI.Client.Delete(context.TODO(), ingressObject); err != nil {
 					logrus.Errorf("Failed to delete test ingress %s: %s", ingressSpec.Name, err)
 				}
 			}()
 
 			// Wait till the ingress is ready
-			var ingress *v1beta1.Ingress
-			for wait := true; wait; {
+			var host string
+			for host == "" {
 				time.Sleep(time.Duration(1) * time.Second)
-				ingress, err = GetClusterIngress(ingressSpec.Name, ingressSpec.Namespace, deployContext.ClusterAPI.Client)
+				host, err = getClusterIngressHost(deployContext, ingressSpec.Name)
 				if err != nil {
 					return nil, err
 				}
-				wait = len(ingress.Spec.Rules[0].Host) == 0
 			}
 
-			requestURL = "https://" + ingress.Spec.Rules[0].Host
+			requestURL = "https://" + host
 		}
 	} else {
 		requestURL = endpointURL
@@ -240,6 +250,27 @@ func GetEndpointTLSCrtChain(deployContext *DeployContext, endpointURL string) ([
 	return certificates, nil
 }
 
+// GetTrustedCertPool returns the system CA certificates along with the ones trusted by Che, which are merged
+// into the `ca-certs-merged` config map, including the CA of the self-signed certificates.
+func GetTrustedCertPool(deployContext *DeployContext) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	mergedCAConfigMap := &corev1.ConfigMap{}
+	exists, err := GetNamespacedObject(deployContext, CheAllCACertsConfigMapName, mergedCAConfigMap)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		for _, certificates := range mergedCAConfigMap.Data {
+			pool.AppendCertsFromPEM([]byte(certificates))
+		}
+	}
+	return pool, nil
+}
+
 func doRequestForTLSCrtChain(deployContext *DeployContext, requestURL string, skipProxy bool) ([]*x509.Certificate, error) {
 	transport := &http.Transport{}
 	// Adding the proxy settings to the Transport object.
@@ -324,64 +355,30 @@ func K8sHandleCheTLSSecrets(deployContext *DeployContext) (reconcile.Result, err
 			}
 		}
 
-		// Prepare permissions for the certificate generation job
-		sa, err := SyncServiceAccountToCluster(deployContext, CheTLSJobServiceAccountName)
-		if sa == nil {
+		// Generate the certificates in-process and store them in the secrets
+		certificates, err := generateCheTLSCertificates(getCheTLSDomains(deployContext.CheCluster))
+		if err != nil {
+			logrus.Errorf("Error generating Che TLS certificates: %v", err)
 			return reconcile.Result{RequeueAfter: time.Second}, err
 		}
 
-		role, err := SyncTLSRoleToCluster(deployContext)
-		if role == nil {
+		if _, err := CreateIfNotExists(deployContext, getSpecCheCASecret(deployContext, certificates)); err != nil {
+			logrus.Errorf("Error creating Che self-signed certificate secret \"%s\": %v", CheTLSSelfSignedCertificateSecretName, err)
 			return reconcile.Result{RequeueAfter: time.Second}, err
 		}
-
-		roleBiding, err := SyncRoleBindingToCluster(deployContext, CheTLSJobRoleBindingName, CheTLSJobServiceAccountName, CheTLSJobRoleName, "Role")
-		if roleBiding == nil {
+		if _, err := CreateIfNotExists(deployContext, getSpecCheTLSSecret(deployContext, cheTLSSecretName, certificates)); err != nil {
+			logrus.Errorf("Error creating Che TLS secret \"%s\": %v", cheTLSSecretName, err)
 			return reconcile.Result{RequeueAfter: time.Second}, err
 		}
 
-		domains := deployContext.CheCluster.Spec.K8s.IngressDomain + ",*." + deployContext.CheCluster.Spec.K8s.IngressDomain
-		if deployContext.CheCluster.Spec.Server.CheHost != "" && strings.Index(deployContext.CheCluster.Spec.Server.CheHost, deployContext.CheCluster.Spec.K8s.IngressDomain) == -1 && deployContext.CheCluster.Spec.Server.CheHostTLSSecret == "" {
-			domains += "," + deployContext.CheCluster.Spec.Server.CheHost
-		}
-		cheTLSSecretsCreationJobImage := DefaultCheTLSSecretsCreationJobImage()
-		jobEnvVars := map[string]string{
-			"DOMAIN":                         domains,
-			"CHE_NAMESPACE":                  deployContext.CheCluster.Namespace,
-			"CHE_SERVER_TLS_SECRET_NAME":     cheTLSSecretName,
-			"CHE_CA_CERTIFICATE_SECRET_NAME": CheTLSSelfSignedCertificateSecretName,
-		}
-		job, err := SyncJobToCluster(deployContext, CheTLSJobName, CheTLSJobComponentName, cheTLSSecretsCreationJobImage, CheTLSJobServiceAccountName, jobEnvVars)
-		if err != nil {
-			logrus.Error(err)
-			return reconcile.Result{RequeueAfter: time.Second}, err
-		}
-		if job == nil || job.Status.Succeeded == 0 {
-			logrus.Infof("Waiting on job '%s' to be finished", CheTLSJobName)
-			return reconcile.Result{RequeueAfter: time.Second}, err
-		}
+		logrus.Infof("Import public part of Eclipse Che self-signed CA certificate from \"%s\" secret into your browser.", CheTLSSelfSignedCertificateSecretName)
+		return reconcile.Result{RequeueAfter: time.Second}, nil
 	}
 
-	// cleanup job
-	job := &batchv1.Job{}
-	err = deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: CheTLSJobName, Namespace: deployContext.CheCluster.Namespace}, job)
-	if err != nil && !errors.IsNotFound(err) {
-		// Failed to get the job
+	// Remove the certificate generation job and its permissions left by the previous versions
+	if err := deleteCheTLSJob(deployContext); err != nil {
 		return reconcile.Result{RequeueAfter: time.Second}, err
 	}
-	if err == nil {
-		// The job object is present
-		if job.Status.Succeeded > 0 {
-			logrus.Infof("Import public part of Eclipse Che self-signed CA certificate from \"%s\" secret into your browser.", CheTLSSelfSignedCertificateSecretName)
-			deleteJob(deployContext, job)
-		} else if job.Status.Failed > 0 {
-			// The job failed, but the certificate is present, shouldn't happen
-			deleteJob(deployContext, job)
-			return reconcile.Result{}, nil
-		}
-		// Job hasn't reported finished status yet, wait more
-		return reconcile.Result{RequeueAfter: time.Second}, nil
-	}
 
 	// Che TLS certificate exists, check for required data fields
 	if !isCheTLSSecretValid(cheTLSSecret) {
@@ -454,6 +451,15 @@ func K8sHandleCheTLSSecrets(deployContext *DeployContext) (reconcile.Result, err
 		}
 	}
 
+	// Renew the generated certificates before they expire
+	renewed, err := RenewCheTLSCertificates(deployContext)
+	if err != nil {
+		logrus.Errorf("Error renewing Che TLS certificates: %v", err)
+		return reconcile.Result{RequeueAfter: time.Second}, err
+	} else if renewed {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+
 	// TLS configuration is ok, go further in reconcile loop
 	return reconcile.Result{}, nil
 }
@@ -475,22 +481,72 @@ func isCheCASecretValid(cheCASelfSignedCertificateSecret *corev1.Secret) bool {
 	return true
 }
 
-func deleteJob(deployContext *DeployContext, job *batchv1.Job) {
-	names := util.K8sclient.GetPodsByComponent(CheTLSJobComponentName, deployContext.CheCluster.Namespace)
-	for _, podName := range names {
-		pod := &corev1.Pod{}
-		err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: podName, Namespace: deployContext.CheCluster.Namespace}, pod)
-		if err == nil {
-			// Delete pod (for some reasons pod isn't removed when job is removed)
-			if err = deployContext.ClusterAPI.Client.Delete(context.TODO(), pod); err != nil {
-				logrus.Errorf("Error deleting pod: '%s', error: %v", podName, err)
-			}
+// getSpecCheCASecret returns the secret which holds the self-signed CA certificate and its key.
+func getSpecCheCASecret(deployContext *DeployContext, certificates *cheTLSCertificates) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CheTLSSelfSignedCertificateSecretName,
+			Namespace: deployContext.CheCluster.Namespace,
+			Labels:    GetLabels(deployContext.CheCluster, DefaultCheFlavor(deployContext.CheCluster)),
+			Annotations: map[string]string{
+				CheEclipseOrgGeneratedCertificate: "true",
+			},
+		},
+		Data: map[string][]byte{
+			"ca.crt": certificates.caCert,
+			"ca.key": certificates.caKey,
+		},
+	}
+}
+
+// getSpecCheTLSSecret returns the secret which holds the server certificate and its key.
+func getSpecCheTLSSecret(deployContext *DeployContext, name string, certificates *cheTLSCertificates) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: deployContext.CheCluster.Namespace,
+			Labels:    GetLabels(deployContext.CheCluster, DefaultCheFlavor(deployContext.CheCluster)),
+			Annotations: map[string]string{
+				CheEclipseOrgGeneratedCertificate: "true",
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt": certificates.cert,
+			"tls.key": certificates.key,
+		},
+	}
+}
+
+// deleteCheTLSJob deletes the certificate generation job, along with its pods, and its permissions.
+func deleteCheTLSJob(deployContext *DeployContext) error {
+	job := &batchv1.Job{}
+	err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: CheTLSJobName, Namespace: deployContext.CheCluster.Namespace}, job)
+	if err == nil {
+		if err := deployContext.ClusterAPI.Client.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			logrus.Errorf("Error deleting job: '%s', error: %v", CheTLSJobName, err)
+			return err
 		}
+	} else if !errors.IsNotFound(err) {
+		return err
 	}
 
-	if err := deployContext.ClusterAPI.Client.Delete(context.TODO(), job); err != nil {
-		logrus.Errorf("Error deleting job: '%s', error: %v", CheTLSJobName, err)
+	if _, err := DeleteNamespacedObject(deployContext, CheTLSJobRoleBindingName, &rbac.RoleBinding{}); err != nil {
+		return err
+	}
+	if _, err := DeleteNamespacedObject(deployContext, CheTLSJobRoleName, &rbac.Role{}); err != nil {
+		return err
 	}
+	_, err = DeleteNamespacedObject(deployContext, CheTLSJobServiceAccountName, &corev1.ServiceAccount{})
+	return err
 }
 
 // SyncAdditionalCACertsConfigMapToCluster makes sure that additional CA certs config map is up to date if any
@@ -501,15 +557,44 @@ func SyncAdditionalCACertsConfigMapToCluster(deployContext *DeployContext) (bool
 	if err != nil {
 		return false, err
 	}
+	crConfigMapNames := []string{}
 	if len(cr.Spec.Server.ServerTrustStoreConfigMapName) > 0 {
+		crConfigMapNames = append(crConfigMapNames, cr.Spec.Server.ServerTrustStoreConfigMapName)
+	}
+	crConfigMapNames = append(crConfigMapNames, GetLdapCACertsConfigMapNames(cr)...)
+	for _, name := range crConfigMapNames {
+		if isConfigMapListed(caConfigMaps, name) {
+			continue
+		}
 		crConfigMap := &corev1.ConfigMap{}
-		err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Namespace: deployContext.CheCluster.Namespace, Name: cr.Spec.Server.ServerTrustStoreConfigMapName}, crConfigMap)
+		err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Namespace: deployContext.CheCluster.Namespace, Name: name}, crConfigMap)
 		if err != nil {
 			return false, err
 		}
 		caConfigMaps = append(caConfigMaps, *crConfigMap)
 	}
 
+	// Propagate the self-signed CA certificate and the CA of the cert-manager issuer, which change when they are renewed
+	caSecretNames := []string{CheTLSSelfSignedCertificateSecretName}
+	if UseCertManagerCertificate(deployContext, DefaultCheFlavor(cr)) {
+		caSecretNames = append(caSecretNames, GetCertManagerCertificateName(cr, DefaultCheFlavor(cr)))
+	}
+	for _, caSecretName := range caSecretNames {
+		caSecret, err := GetSecret(deployContext, caSecretName, cr.Namespace)
+		if err != nil {
+			return false, err
+		}
+		if caSecret != nil && len(caSecret.Data["ca.crt"]) > 0 {
+			caConfigMaps = append(caConfigMaps, corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            caSecretName,
+					ResourceVersion: caSecret.ResourceVersion,
+				},
+				Data: map[string]string{"ca.crt": string(caSecret.Data["ca.crt"])},
+			})
+		}
+	}
+
 	mergedCAConfigMap := &corev1.ConfigMap{}
 	err = deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Namespace: deployContext.CheCluster.Namespace, Name: CheAllCACertsConfigMapName}, mergedCAConfigMap)
 	if err == nil {
@@ -567,6 +652,27 @@ func SyncAdditionalCACertsConfigMapToCluster(deployContext *DeployContext) (bool
 	return SyncConfigMapSpecToCluster(deployContext, mergedCAConfigMapSpec)
 }
 
+// GetLdapCACertsConfigMapNames returns the names of the config maps with CA certificates of the LDAP servers
+// the Identity Provider federates users from
+func GetLdapCACertsConfigMapNames(cr *orgv1.CheCluster) []string {
+	names := []string{}
+	for _, provider := range cr.Spec.Auth.LdapUserFederation {
+		if provider.CACertsConfigMap != "" && !util.ContainsString(names, provider.CACertsConfigMap) {
+			names = append(names, provider.CACertsConfigMap)
+		}
+	}
+	return names
+}
+
+func isConfigMapListed(configMaps []corev1.ConfigMap, name string) bool {
+	for _, cm := range configMaps {
+		if cm.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // getCACertsConfigMaps returns list of config maps with additional CA certificates that should be trusted by Che
 // The selection is based on the specific label
 func getCACertsConfigMaps(deployContext *DeployContext) ([]corev1.ConfigMap, error) {
@@ -0,0 +1,398 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	stderrors "errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/metrics"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// cheTLSCertificateKeySize is the size of the RSA keys of the generated certificates.
+	cheTLSCertificateKeySize = 2048
+	// cheTLSCACertificateValidity is the validity period of the generated CA certificate.
+	cheTLSCACertificateValidity = 5 * 365 * 24 * time.Hour
+	// cheTLSCertificateValidity is the validity period of the generated server certificate.
+	cheTLSCertificateValidity = 365 * 24 * time.Hour
+	// cheTLSCACommonName is the common name of the subject of the generated CA certificate.
+	cheTLSCACommonName = "Local Eclipse Che Signer"
+
+	// tlsCertificateRenewBefore is how long before the expiry the generated certificates are renewed,
+	// and the other ones are warned about.
+	tlsCertificateRenewBefore = 30 * 24 * time.Hour
+	// tlsCertificateCheckInterval is how often the certificates which expire soon are checked.
+	tlsCertificateCheckInterval = 24 * time.Hour
+)
+
+// Reasons of the events emitted about TLS certificates
+const (
+	TLSCertificateExpiringEventReason = "TLSCertificateExpiring"
+	TLSCertificateRenewedEventReason  = "TLSCertificateRenewed"
+)
+
+// cheTLSCertificates holds the PEM encoded self-signed CA certificate and the server certificate signed by it.
+type cheTLSCertificates struct {
+	caCert []byte
+	caKey  []byte
+	cert   []byte
+	key    []byte
+}
+
+// getCheTLSDomains returns the domains the server certificate is issued for: the ingress domain,
+// its subdomains which expose the components in multi-host mode, and Che host if it is outside of
+// the ingress domain and has no certificate of its own.
+func getCheTLSDomains(cr *orgv1.CheCluster) []string {
+	ingressDomain := cr.Spec.K8s.IngressDomain
+	domains := []string{ingressDomain, "*." + ingressDomain}
+
+	cheHost := cr.Spec.Server.CheHost
+	if cheHost != "" && !strings.Contains(cheHost, ingressDomain) && cr.Spec.Server.CheHostTLSSecret == "" {
+		domains = append(domains, cheHost)
+	}
+	return domains
+}
+
+// generateCheTLSCertificates generates a self-signed CA certificate and a server certificate
+// signed by it for the given domains.
+func generateCheTLSCertificates(domains []string) (*cheTLSCertificates, error) {
+	now := time.Now()
+
+	caKey, err := rsa.GenerateKey(rand.Reader, cheTLSCertificateKeySize)
+	if err != nil {
+		return nil, err
+	}
+	caSerialNumber, err := newCertificateSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          caSerialNumber,
+		Subject:               pkix.Name{CommonName: cheTLSCACommonName, Organization: []string{"Local Eclipse Che"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(cheTLSCACertificateValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caCertDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return issueCheTLSCertificate(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCertDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(caKey)}),
+		domains)
+}
+
+// issueCheTLSCertificate issues a server certificate for the given domains signed by the given PEM encoded CA.
+func issueCheTLSCertificate(caCertPEM []byte, caKeyPEM []byte, domains []string) (*cheTLSCertificates, error) {
+	caCert, err := parsePEMCertificate(caCertPEM)
+	if err != nil {
+		return nil, err
+	}
+	caKeyBlock, _ := pem.Decode(caKeyPEM)
+	if caKeyBlock == nil {
+		return nil, stderrors.New("failed to decode the CA key")
+	}
+	caKey, err := x509.ParsePKCS1PrivateKey(caKeyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, cheTLSCertificateKeySize)
+	if err != nil {
+		return nil, err
+	}
+	serialNumber, err := newCertificateSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: domains[0]},
+		DNSNames:     domains,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(cheTLSCertificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cheTLSCertificates{
+		caCert: caCertPEM,
+		caKey:  caKeyPEM,
+		cert:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		key:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+	}, nil
+}
+
+// newCertificateSerialNumber returns a random 128-bit certificate serial number.
+func newCertificateSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// parsePEMCertificate parses the first certificate of the PEM encoded data.
+func parsePEMCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, stderrors.New("no PEM encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// isCheCASecretGenerated tells whether the CA certificate secret has been generated by the Operator,
+// or by the certificate generation job of the previous versions, which used the same subject.
+func isCheCASecretGenerated(caSecret *corev1.Secret) bool {
+	if caSecret.Annotations[CheEclipseOrgGeneratedCertificate] == "true" {
+		return true
+	}
+	caCert, err := parsePEMCertificate(caSecret.Data["ca.crt"])
+	return err == nil && caCert.Subject.CommonName == cheTLSCACommonName
+}
+
+// RenewCheTLSCertificates renews the certificates generated by the Operator on Kubernetes before they expire.
+// The server certificate is reissued with the same CA, unless the CA expires soon as well, or its key
+// isn't known, in which case both are regenerated. Returns true if the certificates have been renewed.
+func RenewCheTLSCertificates(deployContext *DeployContext) (bool, error) {
+	cr := deployContext.CheCluster
+	caSecret, err := GetSecret(deployContext, CheTLSSelfSignedCertificateSecretName, cr.Namespace)
+	if err != nil {
+		return false, err
+	}
+	tlsSecret, err := GetSecret(deployContext, cr.Spec.K8s.TlsSecretName, cr.Namespace)
+	if err != nil {
+		return false, err
+	}
+	if caSecret == nil || tlsSecret == nil || !isCheCASecretGenerated(caSecret) {
+		return false, nil
+	}
+
+	renewBefore := time.Now().Add(tlsCertificateRenewBefore)
+	caCert, caErr := parsePEMCertificate(caSecret.Data["ca.crt"])
+	isCADue := caErr != nil || caCert.NotAfter.Before(renewBefore)
+	cert, certErr := parsePEMCertificate(tlsSecret.Data["tls.crt"])
+	isCertDue := certErr != nil || cert.NotAfter.Before(renewBefore) || !isCADue && cert.CheckSignatureFrom(caCert) != nil
+
+	var certificates *cheTLSCertificates
+	domains := getCheTLSDomains(cr)
+	switch {
+	case isCADue || isCertDue && len(caSecret.Data["ca.key"]) == 0:
+		certificates, err = generateCheTLSCertificates(domains)
+	case isCertDue:
+		certificates, err = issueCheTLSCertificate(caSecret.Data["ca.crt"], caSecret.Data["ca.key"], domains)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if isCADue || len(caSecret.Data["ca.key"]) == 0 {
+		caSpec := getSpecCheCASecret(deployContext, certificates)
+		caSecret.Annotations = caSpec.Annotations
+		caSecret.Data = caSpec.Data
+		if err := deployContext.ClusterAPI.Client.Update(context.TODO(), caSecret); err != nil {
+			return false, err
+		}
+		RecordEventf(deployContext, corev1.EventTypeNormal, TLSCertificateRenewedEventReason, "Renewed the CA certificate stored in the secret '%s'", caSecret.Name)
+	}
+
+	tlsSpec := getSpecCheTLSSecret(deployContext, tlsSecret.Name, certificates)
+	tlsSecret.Annotations = tlsSpec.Annotations
+	tlsSecret.Data = tlsSpec.Data
+	if err := deployContext.ClusterAPI.Client.Update(context.TODO(), tlsSecret); err != nil {
+		return false, err
+	}
+	RecordEventf(deployContext, corev1.EventTypeNormal, TLSCertificateRenewedEventReason, "Renewed the certificate stored in the secret '%s'", tlsSecret.Name)
+	logrus.Infof("Renewed Che TLS certificate stored in the secret \"%s\"", tlsSecret.Name)
+	return true, nil
+}
+
+// GetCheTLSCertificatesAnnotations returns the pod template annotations with the hash of the CA and server certificates.
+// Components read the CA certificate from an environment variable, which isn't refreshed once the certificates
+// are renewed, so the hash makes their pods restart. Returns nil if there are no certificates.
+func GetCheTLSCertificatesAnnotations(deployContext *DeployContext) map[string]string {
+	if deployContext.Offline {
+		// the certificates generated while rendering are not deployed
+		return nil
+	}
+
+	cr := deployContext.CheCluster
+	sources := []tlsCertificateSource{{CheTLSSelfSignedCertificateSecretName, "ca.crt"}}
+	if cr.Spec.K8s.TlsSecretName != "" {
+		sources = append(sources, tlsCertificateSource{cr.Spec.K8s.TlsSecretName, "tls.crt"})
+	}
+
+	hash := sha256.New()
+	found := false
+	for _, source := range sources {
+		secret, err := GetSecret(deployContext, source.secretName, cr.Namespace)
+		if err != nil || secret == nil || len(secret.Data[source.key]) == 0 {
+			continue
+		}
+		hash.Write(secret.Data[source.key])
+		found = true
+	}
+	if !found {
+		return nil
+	}
+	return map[string]string{
+		CheEclipseOrgTLSCertificatesHash: hex.EncodeToString(hash.Sum(nil)),
+	}
+}
+
+// tlsCertificateSource is the key of a secret which holds a PEM encoded certificate.
+type tlsCertificateSource struct {
+	secretName string
+	key        string
+}
+
+// SyncTLSCertificatesStatus records the expiry of the TLS certificates generated or used by the Operator
+// in the CheCluster status, and warns about the certificates which expire soon.
+func SyncTLSCertificatesStatus(deployContext *DeployContext) error {
+	cr := deployContext.CheCluster
+	sources := []tlsCertificateSource{{CheTLSSelfSignedCertificateSecretName, "ca.crt"}}
+	if !util.IsOpenShift && cr.Spec.Server.TlsSupport && cr.Spec.K8s.TlsSecretName != "" {
+		sources = append(sources, tlsCertificateSource{cr.Spec.K8s.TlsSecretName, "tls.crt"})
+	}
+	if cr.Spec.Server.CheHostTLSSecret != "" {
+		sources = append(sources, tlsCertificateSource{cr.Spec.Server.CheHostTLSSecret, "tls.crt"})
+	}
+
+	generated := false
+	statuses := []orgv1.TLSCertificateStatus{}
+	expiry := map[string]time.Time{}
+	for _, source := range sources {
+		secret, err := GetSecret(deployContext, source.secretName, cr.Namespace)
+		if err != nil {
+			return err
+		} else if secret == nil {
+			continue
+		}
+
+		if source.secretName == CheTLSSelfSignedCertificateSecretName {
+			generated = !util.IsOpenShift && isCheCASecretGenerated(secret)
+		}
+		status := orgv1.TLSCertificateStatus{
+			SecretName: source.secretName,
+			Generated:  generated && source.secretName != cr.Spec.Server.CheHostTLSSecret,
+		}
+
+		cert, err := parsePEMCertificate(secret.Data[source.key])
+		if err != nil {
+			status.Message = fmt.Sprintf("Failed to read the certificate: %s", err.Error())
+		} else {
+			notAfter := metav1.NewTime(cert.NotAfter)
+			status.Subject = cert.Subject.String()
+			status.NotAfter = &notAfter
+			status.Message = getTLSCertificateExpiryMessage(cert.NotAfter)
+			expiry[source.secretName] = cert.NotAfter
+		}
+
+		if status.Message != "" && status.Message != getTLSCertificateStatusMessage(cr, source.secretName) {
+			RecordEventf(deployContext, corev1.EventTypeWarning, TLSCertificateExpiringEventReason, "Certificate stored in the secret '%s': %s", source.secretName, status.Message)
+		}
+		statuses = append(statuses, status)
+	}
+
+	if !deployContext.DryRun {
+		metrics.SetCertificatesExpiry(cr.Namespace, expiry)
+	}
+
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	if equalTLSCertificatesStatus(cr.Status.TLSCertificates, statuses) {
+		return nil
+	}
+	cr.Status.TLSCertificates = statuses
+	return UpdateCheCRStatus(deployContext, "status: TLS certificates", fmt.Sprintf("%d", len(statuses)))
+}
+
+// getTLSCertificateExpiryMessage returns the message warning about the expiry of the certificate,
+// or an empty one if the certificate doesn't expire soon.
+func getTLSCertificateExpiryMessage(notAfter time.Time) string {
+	remaining := time.Until(notAfter)
+	switch {
+	case remaining <= 0:
+		return fmt.Sprintf("The certificate expired on %s", notAfter.UTC().Format(time.RFC3339))
+	case remaining < tlsCertificateRenewBefore:
+		return fmt.Sprintf("The certificate expires in %d days, on %s", int(remaining.Hours()/24), notAfter.UTC().Format(time.RFC3339))
+	}
+	return ""
+}
+
+func getTLSCertificateStatusMessage(cr *orgv1.CheCluster, secretName string) string {
+	for _, status := range cr.Status.TLSCertificates {
+		if status.SecretName == secretName {
+			return status.Message
+		}
+	}
+	return ""
+}
+
+// equalTLSCertificatesStatus compares the statuses, ignoring the location of the times,
+// which differs once the status is read back from the cluster.
+func equalTLSCertificatesStatus(a []orgv1.TLSCertificateStatus, b []orgv1.TLSCertificateStatus) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].SecretName != b[i].SecretName || a[i].Subject != b[i].Subject || a[i].Generated != b[i].Generated || a[i].Message != b[i].Message ||
+			(a[i].NotAfter == nil) != (b[i].NotAfter == nil) || a[i].NotAfter != nil && !a[i].NotAfter.Equal(b[i].NotAfter) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTLSCertificatesRequeueDelay returns the delay until the next certificate is due to be renewed,
+// or to be warned about. The certificates which expire soon are checked again daily.
+func GetTLSCertificatesRequeueDelay(cr *orgv1.CheCluster) time.Duration {
+	var result time.Duration
+	for _, status := range cr.Status.TLSCertificates {
+		if status.NotAfter == nil {
+			continue
+		}
+		delay := time.Until(status.NotAfter.Add(-tlsCertificateRenewBefore))
+		if delay <= 0 {
+			delay = tlsCertificateCheckInterval
+		}
+		if result == 0 || delay < result {
+			result = delay
+		}
+	}
+	return result
+}
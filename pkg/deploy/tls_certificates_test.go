@@ -0,0 +1,473 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/util"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func parseCertificate(t *testing.T, data []byte) *x509.Certificate {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("Failed to decode PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestGetCheTLSDomains(t *testing.T) {
+	type testCase struct {
+		name            string
+		cheCluster      *orgv1.CheCluster
+		expectedDomains []string
+	}
+
+	testCases := []testCase{
+		{
+			name: "Test ingress domain",
+			cheCluster: &orgv1.CheCluster{
+				Spec: orgv1.CheClusterSpec{
+					K8s: orgv1.CheClusterSpecK8SOnly{IngressDomain: "che.internal"},
+				},
+			},
+			expectedDomains: []string{"che.internal", "*.che.internal"},
+		},
+		{
+			name: "Test Che host outside of ingress domain",
+			cheCluster: &orgv1.CheCluster{
+				Spec: orgv1.CheClusterSpec{
+					Server: orgv1.CheClusterSpecServer{CheHost: "che.example.com"},
+					K8s:    orgv1.CheClusterSpecK8SOnly{IngressDomain: "che.internal"},
+				},
+			},
+			expectedDomains: []string{"che.internal", "*.che.internal", "che.example.com"},
+		},
+		{
+			name: "Test Che host with its own certificate",
+			cheCluster: &orgv1.CheCluster{
+				Spec: orgv1.CheClusterSpec{
+					Server: orgv1.CheClusterSpecServer{CheHost: "che.example.com", CheHostTLSSecret: "che-host-tls"},
+					K8s:    orgv1.CheClusterSpecK8SOnly{IngressDomain: "che.internal"},
+				},
+			},
+			expectedDomains: []string{"che.internal", "*.che.internal"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			domains := getCheTLSDomains(testCase.cheCluster)
+			if !reflect.DeepEqual(testCase.expectedDomains, domains) {
+				t.Errorf("Expected domains %v, but got %v", testCase.expectedDomains, domains)
+			}
+		})
+	}
+}
+
+func TestGenerateCheTLSCertificates(t *testing.T) {
+	domains := []string{"che.internal", "*.che.internal"}
+	certificates, err := generateCheTLSCertificates(domains)
+	if err != nil {
+		t.Fatalf("Failed to generate certificates: %v", err)
+	}
+
+	caCert := parseCertificate(t, certificates.caCert)
+	if !caCert.IsCA {
+		t.Errorf("CA certificate is expected to be a CA")
+	}
+
+	cert := parseCertificate(t, certificates.cert)
+	if !reflect.DeepEqual(domains, cert.DNSNames) {
+		t.Errorf("Expected DNS names %v, but got %v", domains, cert.DNSNames)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	for _, dnsName := range []string{"che.internal", "keycloak-eclipse-che.che.internal"} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: dnsName, Roots: roots}); err != nil {
+			t.Errorf("Failed to verify certificate for '%s': %v", dnsName, err)
+		}
+	}
+}
+
+func TestK8sHandleCheTLSSecrets(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CheTLSJobName,
+			Namespace: "eclipse-che",
+		},
+	}
+	role := &rbac.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CheTLSJobRoleName,
+			Namespace: "eclipse-che",
+		},
+	}
+
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, job, role)
+	deployContext := &DeployContext{
+		CheCluster: &orgv1.CheCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "eclipse-che",
+				Name:      "eclipse-che",
+			},
+			Spec: orgv1.CheClusterSpec{
+				K8s: orgv1.CheClusterSpecK8SOnly{
+					IngressDomain: "che.internal",
+					TlsSecretName: "che-tls",
+				},
+			},
+		},
+		ClusterAPI: ClusterAPI{
+			Client:          cli,
+			NonCachedClient: cli,
+			Scheme:          scheme.Scheme,
+		},
+	}
+
+	// generate the secrets
+	if _, err := K8sHandleCheTLSSecrets(deployContext); err != nil {
+		t.Fatalf("Failed to handle TLS secrets: %v", err)
+	}
+
+	cheTLSSecret := &corev1.Secret{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: "che-tls", Namespace: "eclipse-che"}, cheTLSSecret); err != nil {
+		t.Fatalf("Che TLS secret not found: %v", err)
+	}
+	if cheTLSSecret.Type != corev1.SecretTypeTLS || !isCheTLSSecretValid(cheTLSSecret) {
+		t.Errorf("Che TLS secret is invalid")
+	}
+
+	caSecret := &corev1.Secret{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: CheTLSSelfSignedCertificateSecretName, Namespace: "eclipse-che"}, caSecret); err != nil {
+		t.Fatalf("Che self-signed certificate secret not found: %v", err)
+	}
+	if !isCheCASecretValid(caSecret) {
+		t.Errorf("Che self-signed certificate secret is invalid")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(parseCertificate(t, caSecret.Data["ca.crt"]))
+	if _, err := parseCertificate(t, cheTLSSecret.Data["tls.crt"]).Verify(x509.VerifyOptions{DNSName: "che-eclipse-che.che.internal", Roots: roots}); err != nil {
+		t.Errorf("Failed to verify Che TLS certificate: %v", err)
+	}
+
+	// check the secrets and remove the job left by the previous versions
+	if _, err := K8sHandleCheTLSSecrets(deployContext); err != nil {
+		t.Fatalf("Failed to handle TLS secrets: %v", err)
+	}
+
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: CheTLSJobName, Namespace: "eclipse-che"}, &batchv1.Job{}); err == nil {
+		t.Errorf("Certificate generation job is expected to be deleted")
+	}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: CheTLSJobRoleName, Namespace: "eclipse-che"}, &rbac.Role{}); err == nil {
+		t.Errorf("Certificate generation job role is expected to be deleted")
+	}
+}
+
+// newTestCertificate returns a PEM encoded certificate, and its key, which expires at the given time.
+// The certificate is self-signed CA one if the CA is not given.
+func newTestCertificate(t *testing.T, caCertPEM []byte, caKeyPEM []byte, commonName string, notAfter time.Time) ([]byte, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	serialNumber, _ := newCertificateSerialNumber()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+		IsCA:                  caCertPEM == nil,
+	}
+
+	parent, signer := template, key
+	if caCertPEM != nil {
+		parent = parseCertificate(t, caCertPEM)
+		block, _ := pem.Decode(caKeyPEM)
+		signer, _ = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func getTestTLSDeployContext(initObjects ...runtime.Object) *DeployContext {
+	cheCluster := &orgv1.CheCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "eclipse-che",
+			Name:      "eclipse-che",
+		},
+		Spec: orgv1.CheClusterSpec{
+			Server: orgv1.CheClusterSpecServer{
+				TlsSupport: true,
+			},
+			K8s: orgv1.CheClusterSpecK8SOnly{
+				IngressDomain: "che.internal",
+				TlsSecretName: "che-tls",
+			},
+		},
+	}
+
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme, append(initObjects, cheCluster)...)
+	return &DeployContext{
+		CheCluster: cheCluster,
+		ClusterAPI: ClusterAPI{
+			Client:          cli,
+			NonCachedClient: cli,
+			Scheme:          scheme.Scheme,
+		},
+	}
+}
+
+func getTestSecret(name string, annotations map[string]string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "eclipse-che",
+			Annotations: annotations,
+		},
+		Data: data,
+	}
+}
+
+func TestRenewCheTLSCertificates(t *testing.T) {
+	type testCase struct {
+		name            string
+		caName          string
+		caNotAfter      time.Time
+		certNotAfter    time.Time
+		annotations     map[string]string
+		expectedRenewed bool
+		expectedNewCA   bool
+	}
+
+	generated := map[string]string{"che.eclipse.org/generated-certificate": "true"}
+	testCases := []testCase{
+		{
+			name:         "Test don't renew valid certificates",
+			caName:       "Local Eclipse Che Signer",
+			caNotAfter:   time.Now().Add(365 * 24 * time.Hour),
+			certNotAfter: time.Now().Add(90 * 24 * time.Hour),
+			annotations:  generated,
+		},
+		{
+			name:            "Test renew expiring server certificate",
+			caName:          "Local Eclipse Che Signer",
+			caNotAfter:      time.Now().Add(365 * 24 * time.Hour),
+			certNotAfter:    time.Now().Add(10 * 24 * time.Hour),
+			annotations:     generated,
+			expectedRenewed: true,
+		},
+		{
+			name:            "Test renew expiring CA certificate",
+			caName:          "Local Eclipse Che Signer",
+			caNotAfter:      time.Now().Add(10 * 24 * time.Hour),
+			certNotAfter:    time.Now().Add(5 * 24 * time.Hour),
+			annotations:     generated,
+			expectedRenewed: true,
+			expectedNewCA:   true,
+		},
+		{
+			name:            "Test renew expired certificates generated by the job",
+			caName:          "Local Eclipse Che Signer",
+			caNotAfter:      time.Now().Add(365 * 24 * time.Hour),
+			certNotAfter:    time.Now().Add(-24 * time.Hour),
+			expectedRenewed: true,
+			expectedNewCA:   true,
+		},
+		{
+			name:         "Test don't renew certificates provided by the user",
+			caName:       "My CA",
+			caNotAfter:   time.Now().Add(365 * 24 * time.Hour),
+			certNotAfter: time.Now().Add(-24 * time.Hour),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			caCert, caKey := newTestCertificate(t, nil, nil, testCase.caName, testCase.caNotAfter)
+			cert, key := newTestCertificate(t, caCert, caKey, "che.internal", testCase.certNotAfter)
+			caData := map[string][]byte{"ca.crt": caCert}
+			if testCase.annotations != nil {
+				caData["ca.key"] = caKey
+			}
+
+			deployContext := getTestTLSDeployContext(
+				getTestSecret(CheTLSSelfSignedCertificateSecretName, testCase.annotations, caData),
+				getTestSecret("che-tls", testCase.annotations, map[string][]byte{"tls.crt": cert, "tls.key": key}))
+
+			renewed, err := RenewCheTLSCertificates(deployContext)
+			if err != nil {
+				t.Fatalf("Failed to renew certificates: %v", err)
+			}
+			if renewed != testCase.expectedRenewed {
+				t.Fatalf("Expected renewed %t, but got %t", testCase.expectedRenewed, renewed)
+			}
+
+			caSecret, _ := GetSecret(deployContext, CheTLSSelfSignedCertificateSecretName, "eclipse-che")
+			tlsSecret, _ := GetSecret(deployContext, "che-tls", "eclipse-che")
+			if isNewCA := !bytes.Equal(caCert, caSecret.Data["ca.crt"]); isNewCA != testCase.expectedNewCA {
+				t.Errorf("Expected new CA certificate %t, but got %t", testCase.expectedNewCA, isNewCA)
+			}
+			if !testCase.expectedRenewed {
+				return
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(parseCertificate(t, caSecret.Data["ca.crt"]))
+			renewedCert := parseCertificate(t, tlsSecret.Data["tls.crt"])
+			if _, err := renewedCert.Verify(x509.VerifyOptions{DNSName: "che.internal", Roots: roots}); err != nil {
+				t.Errorf("Failed to verify renewed certificate: %v", err)
+			}
+			if time.Until(renewedCert.NotAfter) < tlsCertificateRenewBefore {
+				t.Errorf("Renewed certificate expires too soon: %v", renewedCert.NotAfter)
+			}
+		})
+	}
+}
+
+func TestGetCheTLSCertificatesAnnotations(t *testing.T) {
+	if annotations := GetCheTLSCertificatesAnnotations(getTestTLSDeployContext()); annotations != nil {
+		t.Fatalf("No annotations expected without certificates, but got %v", annotations)
+	}
+
+	generated := map[string]string{"che.eclipse.org/generated-certificate": "true"}
+	caCert, caKey := newTestCertificate(t, nil, nil, "Local Eclipse Che Signer", time.Now().Add(10*24*time.Hour))
+	cert, key := newTestCertificate(t, caCert, caKey, "che.internal", time.Now().Add(5*24*time.Hour))
+	deployContext := getTestTLSDeployContext(
+		getTestSecret(CheTLSSelfSignedCertificateSecretName, generated, map[string][]byte{"ca.crt": caCert, "ca.key": caKey}),
+		getTestSecret("che-tls", generated, map[string][]byte{"tls.crt": cert, "tls.key": key}))
+
+	annotations := GetCheTLSCertificatesAnnotations(deployContext)
+	if annotations[CheEclipseOrgTLSCertificatesHash] == "" {
+		t.Fatalf("Annotation %s expected, but got %v", CheEclipseOrgTLSCertificatesHash, annotations)
+	}
+	if !reflect.DeepEqual(annotations, GetCheTLSCertificatesAnnotations(deployContext)) {
+		t.Fatalf("Annotations are expected to be stable")
+	}
+
+	if _, err := RenewCheTLSCertificates(deployContext); err != nil {
+		t.Fatalf("Failed to renew certificates: %v", err)
+	}
+	renewedAnnotations := GetCheTLSCertificatesAnnotations(deployContext)
+	if renewedAnnotations[CheEclipseOrgTLSCertificatesHash] == annotations[CheEclipseOrgTLSCertificatesHash] {
+		t.Fatalf("Annotation %s is expected to change once the certificates are renewed", CheEclipseOrgTLSCertificatesHash)
+	}
+}
+
+func TestSyncTLSCertificatesStatus(t *testing.T) {
+	caCert, caKey := newTestCertificate(t, nil, nil, "Local Eclipse Che Signer", time.Now().Add(365*24*time.Hour))
+	cert, key := newTestCertificate(t, caCert, caKey, "che.internal", time.Now().Add(90*24*time.Hour))
+	cheHostCert, cheHostKey := newTestCertificate(t, caCert, caKey, "che.example.com", time.Now().Add(10*24*time.Hour))
+
+	deployContext := getTestTLSDeployContext(
+		getTestSecret(CheTLSSelfSignedCertificateSecretName, nil, map[string][]byte{"ca.crt": caCert}),
+		getTestSecret("che-tls", nil, map[string][]byte{"tls.crt": cert, "tls.key": key}),
+		getTestSecret("che-host-tls", nil, map[string][]byte{"tls.crt": cheHostCert, "tls.key": cheHostKey}))
+	deployContext.CheCluster.Spec.Server.CheHost = "che.example.com"
+	deployContext.CheCluster.Spec.Server.CheHostTLSSecret = "che-host-tls"
+	util.IsOpenShift = false
+
+	if err := SyncTLSCertificatesStatus(deployContext); err != nil {
+		t.Fatalf("Failed to sync TLS certificates status: %v", err)
+	}
+
+	statuses := deployContext.CheCluster.Status.TLSCertificates
+	if len(statuses) != 3 {
+		t.Fatalf("Expected 3 certificates in status, but got %v", statuses)
+	}
+	for _, status := range statuses {
+		if status.NotAfter == nil {
+			t.Errorf("Expiry of the certificate stored in the secret '%s' is not set", status.SecretName)
+		}
+		switch status.SecretName {
+		case CheTLSSelfSignedCertificateSecretName, "che-tls":
+			if !status.Generated || status.Message != "" {
+				t.Errorf("Unexpected status of the certificate stored in the secret '%s': %v", status.SecretName, status)
+			}
+		case "che-host-tls":
+			if status.Generated || !strings.HasPrefix(status.Message, "The certificate expires in 9 days") {
+				t.Errorf("Unexpected status of the certificate stored in the secret '%s': %v", status.SecretName, status)
+			}
+		}
+	}
+
+	// the certificate provided by the user expires soon, so it's checked again daily
+	if delay := GetTLSCertificatesRequeueDelay(deployContext.CheCluster); delay != tlsCertificateCheckInterval {
+		t.Errorf("Expected requeue delay %v, but got %v", tlsCertificateCheckInterval, delay)
+	}
+
+	// the CA certificate is propagated into the merged CA certificates config map
+	if _, err := SyncAdditionalCACertsConfigMapToCluster(deployContext); err != nil {
+		t.Fatalf("Failed to sync config map: %v", err)
+	}
+	mergedCAConfigMap := &corev1.ConfigMap{}
+	if err := deployContext.ClusterAPI.Client.Get(context.TODO(), types.NamespacedName{Name: CheAllCACertsConfigMapName, Namespace: "eclipse-che"}, mergedCAConfigMap); err != nil {
+		t.Fatalf("Failed to get config map: %v", err)
+	}
+	if mergedCAConfigMap.Data["self-signed-certificate.ca.crt"] != string(caCert) {
+		t.Errorf("CA certificate is not propagated into the merged CA certificates config map")
+	}
+}
+
+func TestGetTLSCertificatesRequeueDelay(t *testing.T) {
+	notAfter := metav1.NewTime(time.Now().Add(40 * 24 * time.Hour))
+	cheCluster := &orgv1.CheCluster{
+		Status: orgv1.CheClusterStatus{
+			TLSCertificates: []orgv1.TLSCertificateStatus{
+				{SecretName: "che-tls", NotAfter: &notAfter},
+				{SecretName: "invalid", Message: "Failed to read the certificate"},
+			},
+		},
+	}
+
+	delay := GetTLSCertificatesRequeueDelay(cheCluster)
+	if delay > 10*24*time.Hour || delay < 10*24*time.Hour-time.Minute {
+		t.Errorf("Expected requeue delay of 10 days, but got %v", delay)
+	}
+	if delay := GetTLSCertificatesRequeueDelay(&orgv1.CheCluster{}); delay != 0 {
+		t.Errorf("Expected no requeue, but got %v", delay)
+	}
+}
@@ -0,0 +1,209 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// The metrics of the Operator are served by the controller-runtime metrics server, along with the ones of the controllers.
+const (
+	namespace = "che_operator"
+
+	ReconcileSuccess = "success"
+	ReconcileWaiting = "waiting"
+	ReconcileError   = "error"
+
+	// Reasons of the requeues of a CheCluster
+	RequeueFinalization      = "finalization"
+	RequeueInitialization    = "initialization"
+	RequeueComponentNotReady = "component_not_ready"
+	RequeueError             = "error"
+	RequeueScheduled         = "scheduled"
+
+	ObjectCreated = "created"
+	ObjectUpdated = "updated"
+	ObjectDeleted = "deleted"
+
+	// Operations provisioning Che which don't go through the Kubernetes API
+	ProvisioningDatabase = "database"
+	ProvisioningKeycloak = "keycloak"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "reconcile_duration_seconds",
+			Help:      "Duration of the reconciliation of the components of Che installation, by component and result.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		},
+		[]string{"component", "result"},
+	)
+
+	requeues = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requeues_total",
+			Help:      "Number of the requeues of the CheCluster reconciliation, by reason.",
+		},
+		[]string{"reason"},
+	)
+
+	lastSuccessfulReconcile = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_successful_reconcile_timestamp_seconds",
+			Help:      "Time of the last reconciliation of the CheCluster which left every component up to date, in seconds since the epoch.",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	objectChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "managed_object_changes_total",
+			Help:      "Number of the objects created, updated and deleted by the Operator, by kind and action.",
+		},
+		[]string{"kind", "action"},
+	)
+
+	provisioningFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "provisioning_failures_total",
+			Help:      "Number of the failures of the provisioning which doesn't go through the Kubernetes API, by operation.",
+		},
+		[]string{"operation"},
+	)
+
+	certificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_expiry_timestamp_seconds",
+			Help:      "Expiry of the TLS certificates generated or used by the Operator, in seconds since the epoch.",
+		},
+		[]string{"namespace", "secret"},
+	)
+
+	cheClusterRunning = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "checluster_running",
+			Help:      "Current state of Che installation reported by the CheCluster status, the gauge of the current state is 1.",
+		},
+		[]string{"namespace", "name", "state"},
+	)
+
+	// the state reported for each CheCluster and the secrets of the certificates of each namespace,
+	// so that the outdated series are removed
+	cheClusterStates   = map[string]string{}
+	certificateSecrets = map[string][]string{}
+	seriesLock         sync.Mutex
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileDuration,
+		requeues,
+		lastSuccessfulReconcile,
+		objectChanges,
+		provisioningFailures,
+		certificateExpiry,
+		cheClusterRunning,
+	)
+}
+
+// ObserveReconcile records the duration and the result of the reconciliation of the component.
+func ObserveReconcile(component string, duration time.Duration, done bool, err error) {
+	result := ReconcileSuccess
+	if err != nil {
+		result = ReconcileError
+	} else if !done {
+		result = ReconcileWaiting
+	}
+	reconcileDuration.WithLabelValues(component, result).Observe(duration.Seconds())
+}
+
+// RecordRequeue counts a requeue of the CheCluster reconciliation.
+func RecordRequeue(reason string) {
+	requeues.WithLabelValues(reason).Inc()
+}
+
+// SetLastSuccessfulReconcile records the time the CheCluster has been fully reconciled.
+func SetLastSuccessfulReconcile(namespace string, name string, t time.Time) {
+	lastSuccessfulReconcile.WithLabelValues(namespace, name).Set(float64(t.Unix()))
+}
+
+// RecordObjectChange counts an object created, updated or deleted by the Operator.
+func RecordObjectChange(kind string, action string) {
+	objectChanges.WithLabelValues(kind, action).Inc()
+}
+
+// RecordProvisioningFailure counts a failed provisioning operation.
+func RecordProvisioningFailure(operation string) {
+	provisioningFailures.WithLabelValues(operation).Inc()
+}
+
+// SetCertificatesExpiry records the expiry of the certificates stored in the secrets of the namespace.
+// The series of the secrets which are no longer used are removed.
+func SetCertificatesExpiry(namespace string, notAfter map[string]time.Time) {
+	seriesLock.Lock()
+	defer seriesLock.Unlock()
+
+	for _, secretName := range certificateSecrets[namespace] {
+		if _, ok := notAfter[secretName]; !ok {
+			certificateExpiry.DeleteLabelValues(namespace, secretName)
+		}
+	}
+	secretNames := []string{}
+	for secretName, t := range notAfter {
+		certificateExpiry.WithLabelValues(namespace, secretName).Set(float64(t.Unix()))
+		secretNames = append(secretNames, secretName)
+	}
+	certificateSecrets[namespace] = secretNames
+}
+
+// SetCheClusterRunning records the current state of Che installation and removes the series of the previous one.
+func SetCheClusterRunning(namespace string, name string, state string) {
+	seriesLock.Lock()
+	defer seriesLock.Unlock()
+
+	key := namespace + "/" + name
+	if previous, ok := cheClusterStates[key]; ok && previous != state {
+		cheClusterRunning.DeleteLabelValues(namespace, name, previous)
+	}
+	cheClusterStates[key] = state
+	cheClusterRunning.WithLabelValues(namespace, name, state).Set(1)
+}
+
+// DeleteCheCluster removes the series of the CheCluster once it is deleted.
+func DeleteCheCluster(namespace string, name string) {
+	seriesLock.Lock()
+	defer seriesLock.Unlock()
+
+	key := namespace + "/" + name
+	if state, ok := cheClusterStates[key]; ok {
+		cheClusterRunning.DeleteLabelValues(namespace, name, state)
+		delete(cheClusterStates, key)
+	}
+	lastSuccessfulReconcile.DeleteLabelValues(namespace, name)
+
+	for _, secretName := range certificateSecrets[namespace] {
+		certificateExpiry.DeleteLabelValues(namespace, secretName)
+	}
+	delete(certificateSecrets, namespace)
+}
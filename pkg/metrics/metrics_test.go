@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package metrics
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestObserveReconcile(t *testing.T) {
+	ObserveReconcile("test success", time.Second, true, nil)
+	ObserveReconcile("test waiting", time.Second, false, nil)
+	ObserveReconcile("test error", time.Second, false, errors.New("failed"))
+
+	series := gatherSeries(t, "che_operator_reconcile_duration_seconds")
+	for _, expected := range []string{
+		"component=test success,result=success",
+		"component=test waiting,result=waiting",
+		"component=test error,result=error",
+	} {
+		if _, ok := series[expected]; !ok {
+			t.Errorf("Expected series %s, found %v", expected, series)
+		}
+	}
+}
+
+func TestSetCheClusterRunning(t *testing.T) {
+	SetCheClusterRunning("eclipse-che", "eclipse-che", "Unavailable")
+	SetCheClusterRunning("eclipse-che", "eclipse-che", "Available")
+
+	series := gatherSeries(t, "che_operator_checluster_running")
+	expected := map[string]float64{"name=eclipse-che,namespace=eclipse-che,state=Available": 1}
+	if !reflect.DeepEqual(series, expected) {
+		t.Errorf("Expected series %v, found %v", expected, series)
+	}
+
+	DeleteCheCluster("eclipse-che", "eclipse-che")
+	if series := gatherSeries(t, "che_operator_checluster_running"); len(series) != 0 {
+		t.Errorf("Expected series to be removed, found %v", series)
+	}
+}
+
+func TestSetCertificatesExpiry(t *testing.T) {
+	notAfter := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	SetCertificatesExpiry("eclipse-che", map[string]time.Time{"self-signed-certificate": notAfter, "che-tls": notAfter})
+	SetCertificatesExpiry("eclipse-che", map[string]time.Time{"self-signed-certificate": notAfter})
+
+	series := gatherSeries(t, "che_operator_certificate_expiry_timestamp_seconds")
+	expected := map[string]float64{"namespace=eclipse-che,secret=self-signed-certificate": float64(notAfter.Unix())}
+	if !reflect.DeepEqual(series, expected) {
+		t.Errorf("Expected series %v, found %v", expected, series)
+	}
+
+	DeleteCheCluster("eclipse-che", "eclipse-che")
+	if series := gatherSeries(t, "che_operator_certificate_expiry_timestamp_seconds"); len(series) != 0 {
+		t.Errorf("Expected series to be removed, found %v", series)
+	}
+}
+
+// gatherSeries returns the values of the series of the metric by their labels
+func gatherSeries(t *testing.T, name string) map[string]float64 {
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	series := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.Metric {
+			labels := []string{}
+			for _, label := range metric.Label {
+				labels = append(labels, label.GetName()+"="+label.GetValue())
+			}
+			sort.Strings(labels)
+
+			value := metric.GetGauge().GetValue()
+			if metric.Histogram != nil {
+				value = float64(metric.Histogram.GetSampleCount())
+			}
+			series[strings.Join(labels, ",")] = value
+		}
+	}
+	return series
+}
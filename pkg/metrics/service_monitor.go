@@ -0,0 +1,185 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package metrics
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Port is the port the metrics server listens to, the `metrics` port of the Operator container
+	Port     = 60000
+	portName = "metrics"
+
+	// ServiceName is the name of the service exposing the metrics of the Operator, and of its ServiceMonitor
+	ServiceName = "che-operator-metrics"
+)
+
+var serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+
+// SyncMetricsService exposes the metrics of the Operator with a service, and lets the Prometheus Operator scrape them
+// with a ServiceMonitor when the monitoring CRDs are installed. Both are owned by the Deployment of the Operator,
+// so that they are removed along with it.
+func SyncMetricsService(cl client.Client, discoveryClient discovery.DiscoveryInterface, namespace string, operatorName string) error {
+	deployment := &appsv1.Deployment{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: operatorName}, deployment); err != nil {
+		return err
+	}
+	// the deletion of the Deployment isn't blocked, it would require the permission to update its finalizers
+	ownerReference := metav1.OwnerReference{
+		APIVersion: appsv1.SchemeGroupVersion.String(),
+		Kind:       "Deployment",
+		Name:       deployment.Name,
+		UID:        deployment.UID,
+	}
+
+	if err := createOrUpdate(cl, getSpecMetricsService(namespace, ownerReference), &corev1.Service{}); err != nil {
+		return err
+	}
+
+	available, err := IsServiceMonitorAvailable(discoveryClient)
+	if err != nil {
+		return err
+	}
+	if !available {
+		logrus.Info("ServiceMonitor CRD not found, the metrics of the Operator are not scraped by the Prometheus Operator")
+		return nil
+	}
+	actual := &unstructured.Unstructured{}
+	actual.SetGroupVersionKind(serviceMonitorGVK)
+	return createOrUpdate(cl, getSpecServiceMonitor(namespace, ownerReference), actual)
+}
+
+// IsServiceMonitorAvailable returns true if the ServiceMonitor CRD of the Prometheus Operator is installed.
+// The APIs of other groups which fail to be discovered, e.g. an unavailable aggregated API, are skipped.
+func IsServiceMonitorAvailable(discoveryClient discovery.DiscoveryInterface) (bool, error) {
+	_, resourcesList, err := discoveryClient.ServerGroupsAndResources()
+	if err != nil {
+		groupDiscoveryFailedErr, ok := err.(*discovery.ErrGroupDiscoveryFailed)
+		if !ok {
+			return false, err
+		}
+		if groupErr, failed := groupDiscoveryFailedErr.Groups[serviceMonitorGVK.GroupVersion()]; failed {
+			return false, groupErr
+		}
+	}
+
+	for _, l := range resourcesList {
+		for _, r := range l.APIResources {
+			if l.GroupVersion == serviceMonitorGVK.GroupVersion().String() && r.Kind == serviceMonitorGVK.Kind {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func getSpecMetricsService(namespace string, ownerReference metav1.OwnerReference) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            ServiceName,
+			Namespace:       namespace,
+			Labels:          getLabels(),
+			OwnerReferences: []metav1.OwnerReference{ownerReference},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "che-operator"},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       portName,
+					Port:       Port,
+					Protocol:   corev1.ProtocolTCP,
+					TargetPort: intstr.FromString(portName),
+				},
+			},
+		},
+	}
+}
+
+func getSpecServiceMonitor(namespace string, ownerReference metav1.OwnerReference) *unstructured.Unstructured {
+	serviceMonitor := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{
+					"matchLabels": toInterfaceMap(getLabels()),
+				},
+				"namespaceSelector": map[string]interface{}{
+					"matchNames": []interface{}{namespace},
+				},
+				"endpoints": []interface{}{
+					map[string]interface{}{
+						"port": portName,
+						"path": "/metrics",
+					},
+				},
+			},
+		},
+	}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	serviceMonitor.SetName(ServiceName)
+	serviceMonitor.SetNamespace(namespace)
+	serviceMonitor.SetLabels(getLabels())
+	serviceMonitor.SetOwnerReferences([]metav1.OwnerReference{ownerReference})
+	return serviceMonitor
+}
+
+func getLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      "che",
+		"app.kubernetes.io/instance":  "che",
+		"app.kubernetes.io/component": ServiceName,
+	}
+}
+
+func toInterfaceMap(labels map[string]string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range labels {
+		result[key] = value
+	}
+	return result
+}
+
+// createOrUpdate creates the object, or replaces the existing one, which is read into actual.
+func createOrUpdate(cl client.Client, blueprint runtime.Object, actual runtime.Object) error {
+	meta := blueprint.(metav1.Object)
+	err := cl.Get(context.TODO(), types.NamespacedName{Namespace: meta.GetNamespace(), Name: meta.GetName()}, actual)
+	if errors.IsNotFound(err) {
+		logrus.Infof("Creating a new object: %s, name: %s", blueprint.GetObjectKind().GroupVersionKind().Kind, meta.GetName())
+		return cl.Create(context.TODO(), blueprint)
+	} else if err != nil {
+		return err
+	}
+
+	meta.SetResourceVersion(actual.(metav1.Object).GetResourceVersion())
+	if service, ok := blueprint.(*corev1.Service); ok {
+		// the cluster IP is immutable
+		service.Spec.ClusterIP = actual.(*corev1.Service).Spec.ClusterIP
+	}
+	return cl.Update(context.TODO(), blueprint)
+}
@@ -0,0 +1,157 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncMetricsService(t *testing.T) {
+	type testCase struct {
+		name                   string
+		resources              []*metav1.APIResourceList
+		expectedServiceMonitor bool
+	}
+
+	testCases := []testCase{
+		{
+			name: "Prometheus Operator installed",
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "monitoring.coreos.com/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "servicemonitors", Kind: "ServiceMonitor"},
+						{Name: "prometheusrules", Kind: "PrometheusRule"},
+					},
+				},
+			},
+			expectedServiceMonitor: true,
+		},
+		{
+			name:                   "Prometheus Operator not installed",
+			resources:              []*metav1.APIResourceList{},
+			expectedServiceMonitor: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "che-operator",
+					Namespace: "eclipse-che",
+					UID:       "che-operator-uid",
+				},
+			}
+			cl := fake.NewFakeClientWithScheme(scheme.Scheme, deployment)
+			discoveryClient, _ := fakeclientset.NewSimpleClientset().Discovery().(*fakeDiscovery.FakeDiscovery)
+			discoveryClient.Fake.Resources = testCase.resources
+
+			// the objects are updated once they exist
+			for i := 0; i < 2; i++ {
+				if err := SyncMetricsService(cl, discoveryClient, "eclipse-che", "che-operator"); err != nil {
+					t.Fatalf("Error syncing the metrics service: %v", err)
+				}
+			}
+
+			service := &corev1.Service{}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "eclipse-che", Name: ServiceName}, service); err != nil {
+				t.Fatalf("Service not found: %v", err)
+			}
+			if service.Spec.Ports[0].Port != Port {
+				t.Errorf("Expected port %d, found %d", Port, service.Spec.Ports[0].Port)
+			}
+			if len(service.OwnerReferences) != 1 || service.OwnerReferences[0].UID != deployment.UID {
+				t.Errorf("Expected service to be owned by the Operator deployment, found %v", service.OwnerReferences)
+			}
+
+			serviceMonitor := &unstructured.Unstructured{}
+			serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+			err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "eclipse-che", Name: ServiceName}, serviceMonitor)
+			if exists := err == nil; exists != testCase.expectedServiceMonitor {
+				t.Errorf("Expected ServiceMonitor to exist: %t, error: %v", testCase.expectedServiceMonitor, err)
+			}
+		})
+	}
+}
+
+// partialDiscovery fails to discover the given groups, like the discovery client does
+// when an aggregated API is unavailable.
+type partialDiscovery struct {
+	*fakeDiscovery.FakeDiscovery
+	failedGroups map[schema.GroupVersion]error
+}
+
+func (d *partialDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	groups, resources, _ := d.FakeDiscovery.ServerGroupsAndResources()
+	return groups, resources, &discovery.ErrGroupDiscoveryFailed{Groups: d.failedGroups}
+}
+
+func TestIsServiceMonitorAvailableWithPartialDiscovery(t *testing.T) {
+	type testCase struct {
+		name              string
+		failedGroup       schema.GroupVersion
+		expectedAvailable bool
+		expectedErr       bool
+	}
+
+	testCases := []testCase{
+		{
+			name:              "Another group fails to be discovered",
+			failedGroup:       schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"},
+			expectedAvailable: true,
+		},
+		{
+			name:        "The monitoring group fails to be discovered",
+			failedGroup: serviceMonitorGVK.GroupVersion(),
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fakeDiscoveryClient, _ := fakeclientset.NewSimpleClientset().Discovery().(*fakeDiscovery.FakeDiscovery)
+			fakeDiscoveryClient.Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: "monitoring.coreos.com/v1",
+					APIResources: []metav1.APIResource{{Name: "servicemonitors", Kind: "ServiceMonitor"}},
+				},
+			}
+			discoveryClient := &partialDiscovery{
+				FakeDiscovery: fakeDiscoveryClient,
+				failedGroups:  map[schema.GroupVersion]error{testCase.failedGroup: errors.New("the server is currently unable to handle the request")},
+			}
+
+			available, err := IsServiceMonitorAvailable(discoveryClient)
+			if (err != nil) != testCase.expectedErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if available != testCase.expectedAvailable {
+				t.Errorf("Expected ServiceMonitor to be available: %t, got %t", testCase.expectedAvailable, available)
+			}
+		})
+	}
+}
@@ -16,7 +16,6 @@ import (
 	"fmt"
 	"io"
 
-	v1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
 	"github.com/sirupsen/logrus"
 	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -38,9 +37,11 @@ var (
 func GetK8Client() *k8s {
 	tests := IsTestMode()
 	if !tests {
+		// the cluster isn't configured when the objects are rendered offline,
+		// the Operator itself fails on start in that case
 		cfg, err := config.GetConfig()
 		if err != nil {
-			logrus.Errorf(err.Error())
+			return nil
 		}
 		client := k8s{}
 		client.clientset, err = kubernetes.NewForConfig(cfg)
@@ -54,44 +55,6 @@ func GetK8Client() *k8s {
 	return nil
 }
 
-func (cl *k8s) ExecIntoPod(
-	cr *v1.CheCluster,
-	deploymentName string,
-	getCommand func(*v1.CheCluster) (string, error),
-	reason string) (string, error) {
-
-	command, err := getCommand(cr)
-	if err != nil {
-		return "", err
-	}
-
-	pod, err := cl.GetDeploymentPod(deploymentName, cr.Namespace)
-	if err != nil {
-		return "", err
-	}
-
-	return cl.DoExecIntoPod(cr.Namespace, pod, command, reason)
-}
-
-func (cl *k8s) DoExecIntoPod(namespace string, podName string, command string, reason string) (string, error) {
-	if reason != "" {
-		logrus.Infof("Running exec for '%s' in the pod '%s'", reason, podName)
-	}
-
-	args := []string{"/bin/bash", "-c", command}
-	stdout, stderr, err := cl.RunExec(args, podName, namespace)
-	if err != nil {
-		logrus.Errorf("Error running exec: %v, command: %s", err, args)
-		logrus.Errorf("Stderr: %s", stderr)
-		return stdout, err
-	}
-
-	if reason != "" {
-		logrus.Info("Exec successfully completed.")
-	}
-	return stdout, nil
-}
-
 //GetDeploymentPod queries all pods is a selected namespace by LabelSelector
 func (cl *k8s) GetDeploymentPod(name string, ns string) (podName string, err error) {
 	api := cl.clientset.CoreV1()
@@ -134,7 +97,19 @@ func (cl *k8s) ReadSecret(name string, ns string) (user string, password string,
 }
 
 func (cl *k8s) RunExec(command []string, podName, namespace string) (string, string, error) {
+	return cl.RunExecWithStdin(command, podName, namespace, nil)
+}
+
+// RunExecWithStdin runs the command in the pod and streams the given reader to its standard input.
+func (cl *k8s) RunExecWithStdin(command []string, podName, namespace string, stdin io.Reader) (string, string, error) {
+	var stdout bytes.Buffer
+	stderr, err := cl.RunExecWithStreams(command, podName, namespace, stdin, &stdout)
+	return stdout.String(), stderr, err
+}
 
+// RunExecWithStreams runs the command in the pod, streams the given reader to its standard input
+// and its standard output to the given writer, so that large outputs aren't kept in memory.
+func (cl *k8s) RunExecWithStreams(command []string, podName, namespace string, stdin io.Reader, stdout io.Writer) (string, error) {
 	req := cl.clientset.CoreV1().RESTClient().Post().
 		Resource("pods").
 		Name(podName).
@@ -143,7 +118,7 @@ func (cl *k8s) RunExec(command []string, podName, namespace string) (string, str
 
 	req.VersionedParams(&corev1.PodExecOptions{
 		Command: command,
-		Stdin:   false,
+		Stdin:   stdin != nil,
 		Stdout:  true,
 		Stderr:  true,
 		TTY:     false,
@@ -152,22 +127,17 @@ func (cl *k8s) RunExec(command []string, podName, namespace string) (string, str
 	cfg, _ := config.GetConfig()
 	exec, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
 	if err != nil {
-		return "", "", fmt.Errorf("error while creating executor: %v", err)
+		return "", fmt.Errorf("error while creating executor: %v", err)
 	}
 
-	var stdout, stderr bytes.Buffer
-	var stdin io.Reader
+	var stderr bytes.Buffer
 	err = exec.Stream(remotecommand.StreamOptions{
 		Stdin:  stdin,
-		Stdout: &stdout,
+		Stdout: stdout,
 		Stderr: &stderr,
 		Tty:    false,
 	})
-	if err != nil {
-		return stdout.String(), stderr.String(), err
-	}
-
-	return stdout.String(), stderr.String(), nil
+	return stderr.String(), err
 }
 
 func (cl *k8s) IsResourceOperationPermitted(resourceAttr *authorizationv1.ResourceAttributes) (ok bool, err error) {
@@ -0,0 +1,52 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// cheClusterDefaulter sets the defaults of the empty CheCluster spec fields when CheCluster is admitted,
+// so the Operator doesn't have to write them back to the spec.
+type cheClusterDefaulter struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &cheClusterDefaulter{}
+var _ admission.DecoderInjector = &cheClusterDefaulter{}
+
+func (d *cheClusterDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cheCluster := &orgv1.CheCluster{}
+	if err := d.decoder.Decode(req, cheCluster); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	deploy.SetCheClusterDefaults(cheCluster)
+
+	marshaled, err := json.Marshal(cheCluster)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+func (d *cheClusterDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestCheClusterDefaulter(t *testing.T) {
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	if err != nil {
+		t.Fatalf("Failed to create decoder: %v", err)
+	}
+	defaulter := &cheClusterDefaulter{}
+	defaulter.InjectDecoder(decoder)
+
+	cheCluster := &orgv1.CheCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CheCluster",
+			APIVersion: "org.eclipse.che/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "eclipse-che",
+			Namespace: "eclipse-che",
+		},
+		Spec: orgv1.CheClusterSpec{
+			Server: orgv1.CheClusterSpecServer{
+				CheLogLevel: "DEBUG",
+			},
+			Database: orgv1.CheClusterSpecDB{
+				ExternalDb: true,
+			},
+		},
+	}
+	raw, err := json.Marshal(cheCluster)
+	if err != nil {
+		t.Fatalf("Failed to marshal CheCluster: %v", err)
+	}
+
+	response := defaulter.Handle(context.TODO(), admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	if !response.Allowed {
+		t.Fatalf("CheCluster is expected to be allowed: %v", response.Result)
+	}
+
+	patches := map[string]interface{}{}
+	for _, patch := range response.Patches {
+		patches[patch.Path] = patch.Value
+	}
+	expectedPatches := map[string]interface{}{
+		"/spec/server/cheFlavor":       "che",
+		"/spec/server/cheDebug":        "false",
+		"/spec/storage/pvcStrategy":    "common",
+		"/spec/database/chePostgresDb": "dbche",
+	}
+	for path, value := range expectedPatches {
+		if patches[path] != value {
+			t.Errorf("Expected patch %s: %v, got: %v", path, value, patches[path])
+		}
+	}
+
+	// the user defined value is kept
+	if _, ok := patches["/spec/server/cheLogLevel"]; ok {
+		t.Errorf("User defined log level is not supposed to be changed")
+	}
+	// an external database host has to be provided by a user
+	if _, ok := patches["/spec/database/chePostgresHostName"]; ok {
+		t.Errorf("External database host name is not supposed to be defaulted")
+	}
+}
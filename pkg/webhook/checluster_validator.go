@@ -0,0 +1,76 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package webhook
+
+import (
+	"context"
+	"net/http"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// cheClusterValidator refuses the CheCluster configurations with which it is impossible to deploy Che.
+// In the strict credentials mode, it also refuses the new passwords set in plain text in the spec.
+type cheClusterValidator struct {
+	decoder           *admission.Decoder
+	strictCredentials bool
+}
+
+var _ admission.Handler = &cheClusterValidator{}
+var _ admission.DecoderInjector = &cheClusterValidator{}
+
+func (v *cheClusterValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	cheCluster := &orgv1.CheCluster{}
+	if err := v.decoder.DecodeRaw(req.Object, cheCluster); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var oldCheCluster *orgv1.CheCluster
+	if req.Operation == admissionv1beta1.Update {
+		oldCheCluster = &orgv1.CheCluster{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldCheCluster); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	var err error
+	if oldCheCluster == nil {
+		err = cheCluster.ValidateCreate()
+	} else {
+		err = cheCluster.ValidateUpdate(oldCheCluster)
+	}
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	if v.strictCredentials {
+		if errs := orgv1.ValidateNoPlaintextCredentials(cheCluster, oldCheCluster); len(errs) > 0 {
+			err := apierrors.NewInvalid(schema.GroupKind{Group: orgv1.SchemeGroupVersion.Group, Kind: "CheCluster"}, cheCluster.Name, errs)
+			return admission.Denied(err.Error())
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+func (v *cheClusterValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
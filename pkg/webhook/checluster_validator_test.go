@@ -0,0 +1,119 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestCheClusterValidator(t *testing.T) {
+	type testCase struct {
+		name              string
+		strictCredentials bool
+		spec              orgv1.CheClusterSpec
+		oldSpec           *orgv1.CheClusterSpec
+		allowed           bool
+	}
+
+	testCases := []testCase{
+		{
+			name:    "Valid CheCluster",
+			spec:    orgv1.CheClusterSpec{Server: orgv1.CheClusterSpecServer{ServerMemoryLimit: "1Gi"}},
+			allowed: true,
+		},
+		{
+			name:    "Invalid CheCluster",
+			spec:    orgv1.CheClusterSpec{Server: orgv1.CheClusterSpecServer{ServerMemoryLimit: "1Gb"}},
+			allowed: false,
+		},
+		{
+			name:    "Plaintext password",
+			spec:    orgv1.CheClusterSpec{Server: orgv1.CheClusterSpecServer{ProxyPassword: "password"}},
+			allowed: true,
+		},
+		{
+			name:              "Plaintext password in strict mode",
+			strictCredentials: true,
+			spec:              orgv1.CheClusterSpec{Server: orgv1.CheClusterSpecServer{ProxyPassword: "password"}},
+			allowed:           false,
+		},
+		{
+			name:              "Unchanged plaintext password in strict mode",
+			strictCredentials: true,
+			spec:              orgv1.CheClusterSpec{Server: orgv1.CheClusterSpecServer{ProxyPassword: "password", CheLogLevel: "DEBUG"}},
+			oldSpec:           &orgv1.CheClusterSpec{Server: orgv1.CheClusterSpecServer{ProxyPassword: "password"}},
+			allowed:           true,
+		},
+		{
+			name:              "Invalid update",
+			strictCredentials: true,
+			spec:              orgv1.CheClusterSpec{Server: orgv1.CheClusterSpecServer{CheFlavor: "codeready"}},
+			oldSpec:           &orgv1.CheClusterSpec{Server: orgv1.CheClusterSpecServer{CheFlavor: "che"}},
+			allowed:           false,
+		},
+	}
+
+	orgv1.SchemeBuilder.AddToScheme(scheme.Scheme)
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	if err != nil {
+		t.Fatalf("Failed to create decoder: %v", err)
+	}
+
+	toRaw := func(spec orgv1.CheClusterSpec) runtime.RawExtension {
+		cheCluster := &orgv1.CheCluster{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "CheCluster",
+				APIVersion: "org.eclipse.che/v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "eclipse-che",
+				Namespace: "eclipse-che",
+			},
+			Spec: spec,
+		}
+		raw, err := json.Marshal(cheCluster)
+		if err != nil {
+			t.Fatalf("Failed to marshal CheCluster: %v", err)
+		}
+		return runtime.RawExtension{Raw: raw}
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			validator := &cheClusterValidator{strictCredentials: testCase.strictCredentials}
+			validator.InjectDecoder(decoder)
+
+			request := admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    toRaw(testCase.spec),
+			}
+			if testCase.oldSpec != nil {
+				request.Operation = admissionv1beta1.Update
+				request.OldObject = toRaw(*testCase.oldSpec)
+			}
+
+			response := validator.Handle(context.TODO(), admission.Request{AdmissionRequest: request})
+			if response.Allowed != testCase.allowed {
+				t.Fatalf("Expected allowed: %t, got: %t, %v", testCase.allowed, response.Allowed, response.Result)
+			}
+		})
+	}
+}
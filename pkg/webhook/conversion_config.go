@@ -0,0 +1,105 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CheClusterCRDName is the name of the CheCluster custom resource definition
+	CheClusterCRDName = "checlusters.org.eclipse.che"
+	// ServiceName is the name of the service of the webhook server, OLM names it after the deployment
+	ServiceName = "che-operator-service"
+
+	// caFileName is the CA which signed the serving certificate, cert-manager puts it next to the certificate
+	caFileName = "ca.crt"
+	// olmCertSecretName is the secret where OLM keeps the serving certificate and its CA
+	olmCertSecretName = ServiceName + "-cert"
+	olmCAKey          = "olmCAKey"
+)
+
+// ConfigureConversion points the conversion webhook of the CheCluster CRD to the webhook service in the namespace of the Operator.
+// The CRD is cluster-scoped, so the namespace is not known when the CRD is installed.
+// The CA which signed the serving certificate is set as well when it is found,
+// otherwise the CA injected by cert-manager or by the OpenShift service CA operator is kept.
+func ConfigureConversion(cli client.Client, namespace string) error {
+	crd := &apiextensionsv1beta1.CustomResourceDefinition{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: CheClusterCRDName}, crd); err != nil {
+		return err
+	}
+
+	conversion := crd.Spec.Conversion
+	if conversion == nil || conversion.Strategy != apiextensionsv1beta1.WebhookConverter {
+		logrus.Infof("Conversion webhook is not enabled in the CRD '%s'", CheClusterCRDName)
+		return nil
+	}
+
+	caBundle, err := getCABundle(cli, namespace)
+	if err != nil {
+		return err
+	}
+
+	path := ConvertPath
+	clientConfig := &apiextensionsv1beta1.WebhookClientConfig{
+		Service: &apiextensionsv1beta1.ServiceReference{Namespace: namespace, Name: ServiceName, Path: &path},
+	}
+	if len(caBundle) > 0 {
+		clientConfig.CABundle = caBundle
+	} else if conversion.WebhookClientConfig != nil {
+		clientConfig.CABundle = conversion.WebhookClientConfig.CABundle
+	}
+	if len(clientConfig.CABundle) == 0 {
+		logrus.Warnf("CA of the webhook server certificate not found, the conversion webhook of '%s' is not trusted", CheClusterCRDName)
+	}
+
+	if reflect.DeepEqual(conversion.WebhookClientConfig, clientConfig) {
+		return nil
+	}
+	conversion.WebhookClientConfig = clientConfig
+	if err := cli.Update(context.TODO(), crd); err != nil {
+		return err
+	}
+
+	logrus.Infof("Conversion webhook of the CRD '%s' is served in the namespace '%s'", CheClusterCRDName, namespace)
+	return nil
+}
+
+// getCABundle returns the CA which signed the serving certificate or nil if it is not found.
+func getCABundle(cli client.Client, namespace string) ([]byte, error) {
+	caBundle, err := ioutil.ReadFile(filepath.Join(CertDir, caFileName))
+	if err == nil {
+		return caBundle, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: olmCertSecretName, Namespace: namespace}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return secret.Data[olmCAKey], nil
+}
@@ -0,0 +1,109 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apix "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestCheClusterCRD(caBundle []byte) *apix.CustomResourceDefinition {
+	path := ConvertPath
+	return &apix.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: CheClusterCRDName},
+		Spec: apix.CustomResourceDefinitionSpec{
+			Conversion: &apix.CustomResourceConversion{
+				Strategy: apix.WebhookConverter,
+				WebhookClientConfig: &apix.WebhookClientConfig{
+					Service:  &apix.ServiceReference{Namespace: "che", Name: ServiceName, Path: &path},
+					CABundle: caBundle,
+				},
+			},
+		},
+	}
+}
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := apix.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func TestConfigureConversionWithOLMCertificate(t *testing.T) {
+	olmSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: olmCertSecretName, Namespace: "eclipse-che"},
+		Data:       map[string][]byte{olmCAKey: []byte("olm-ca")},
+	}
+	cli := fake.NewFakeClientWithScheme(newTestScheme(t), newTestCheClusterCRD(nil), olmSecret)
+
+	if err := ConfigureConversion(cli, "eclipse-che"); err != nil {
+		t.Fatalf("Failed to configure conversion: %v", err)
+	}
+
+	crd := &apix.CustomResourceDefinition{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: CheClusterCRDName}, crd); err != nil {
+		t.Fatal(err)
+	}
+	clientConfig := crd.Spec.Conversion.WebhookClientConfig
+	if clientConfig.Service.Namespace != "eclipse-che" || clientConfig.Service.Name != ServiceName || *clientConfig.Service.Path != ConvertPath {
+		t.Errorf("Conversion webhook is expected to point to the Operator, but got: %v", clientConfig.Service)
+	}
+	if string(clientConfig.CABundle) != "olm-ca" {
+		t.Errorf("Unexpected CA bundle: %s", clientConfig.CABundle)
+	}
+}
+
+func TestConfigureConversionKeepsInjectedCA(t *testing.T) {
+	cli := fake.NewFakeClientWithScheme(newTestScheme(t), newTestCheClusterCRD([]byte("injected-ca")))
+
+	if err := ConfigureConversion(cli, "eclipse-che"); err != nil {
+		t.Fatalf("Failed to configure conversion: %v", err)
+	}
+
+	crd := &apix.CustomResourceDefinition{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: CheClusterCRDName}, crd); err != nil {
+		t.Fatal(err)
+	}
+	clientConfig := crd.Spec.Conversion.WebhookClientConfig
+	if clientConfig.Service.Namespace != "eclipse-che" || string(clientConfig.CABundle) != "injected-ca" {
+		t.Errorf("Unexpected conversion webhook config: %v", clientConfig)
+	}
+}
+
+func TestConfigureConversionWhenDisabled(t *testing.T) {
+	crd := newTestCheClusterCRD(nil)
+	crd.Spec.Conversion = &apix.CustomResourceConversion{Strategy: apix.NoneConverter}
+	cli := fake.NewFakeClientWithScheme(newTestScheme(t), crd)
+
+	if err := ConfigureConversion(cli, "eclipse-che"); err != nil {
+		t.Fatalf("Failed to configure conversion: %v", err)
+	}
+
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: CheClusterCRDName}, crd); err != nil {
+		t.Fatal(err)
+	}
+	if crd.Spec.Conversion.WebhookClientConfig != nil {
+		t.Errorf("Conversion webhook is not expected to be configured, but got: %v", crd.Spec.Conversion.WebhookClientConfig)
+	}
+}
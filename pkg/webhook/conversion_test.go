@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eclipse-che/che-operator/pkg/apis"
+	orgv1 "github.com/eclipse-che/che-operator/pkg/apis/org/v1"
+	orgv2 "github.com/eclipse-che/che-operator/pkg/apis/org/v2"
+	apix "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+func TestConversionWebhook(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to create scheme: %v", err)
+	}
+	webhook := &conversion.Webhook{}
+	if err := webhook.InjectScheme(scheme); err != nil {
+		t.Fatalf("Failed to inject scheme: %v", err)
+	}
+
+	cheCluster := &orgv1.CheCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CheCluster",
+			APIVersion: orgv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "eclipse-che",
+			Namespace: "eclipse-che",
+		},
+		Spec: orgv1.CheClusterSpec{
+			Server: orgv1.CheClusterSpecServer{
+				PluginRegistryMemoryLimit: "256Mi",
+			},
+			Database: orgv1.CheClusterSpecDB{
+				ChePostgresSecret: "postgres-credentials",
+			},
+		},
+	}
+	raw, err := json.Marshal(cheCluster)
+	if err != nil {
+		t.Fatalf("Failed to marshal CheCluster: %v", err)
+	}
+	review, err := json.Marshal(&apix.ConversionReview{
+		TypeMeta: metav1.TypeMeta{Kind: "ConversionReview", APIVersion: apix.SchemeGroupVersion.String()},
+		Request: &apix.ConversionRequest{
+			UID:               types.UID("uid"),
+			DesiredAPIVersion: orgv2.SchemeGroupVersion.String(),
+			Objects:           []runtime.RawExtension{{Raw: raw}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal ConversionReview: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	webhook.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ConvertPath, bytes.NewReader(review)))
+
+	response := &apix.ConversionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("Failed to unmarshal ConversionReview: %v", err)
+	}
+	if response.Response == nil || response.Response.Result.Status != metav1.StatusSuccess {
+		t.Fatalf("Conversion is expected to succeed: %v", response.Response)
+	}
+	if len(response.Response.ConvertedObjects) != 1 {
+		t.Fatalf("Expected 1 converted object, got %d", len(response.Response.ConvertedObjects))
+	}
+
+	converted := &orgv2.CheCluster{}
+	if err := json.Unmarshal(response.Response.ConvertedObjects[0].Raw, converted); err != nil {
+		t.Fatalf("Failed to unmarshal converted CheCluster: %v", err)
+	}
+	if converted.APIVersion != orgv2.SchemeGroupVersion.String() {
+		t.Errorf("Expected API version %s, got %s", orgv2.SchemeGroupVersion.String(), converted.APIVersion)
+	}
+	if converted.Spec.Components.PluginRegistry.Deployment.Resources.Limits.Memory != "256Mi" {
+		t.Errorf("Unexpected plugin registry memory limit: %s", converted.Spec.Components.PluginRegistry.Deployment.Resources.Limits.Memory)
+	}
+	if converted.Spec.Components.Database.CredentialsSecretName != "postgres-credentials" {
+		t.Errorf("Unexpected database credentials secret: %s", converted.Spec.Components.Database.CredentialsSecretName)
+	}
+}
@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2021 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+//
+
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/eclipse-che/che-operator/pkg/deploy"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+const (
+	// Port is the port the webhook server listens to
+	Port = 9443
+	// CertDir is the directory with the webhook server certificate, OLM mounts certificates there
+	CertDir = "/tmp/k8s-webhook-server/serving-certs"
+
+	ValidateCheClusterPath = "/validate-org-eclipse-che-v1-checluster"
+	MutateCheClusterPath   = "/mutate-org-eclipse-che-v1-checluster"
+	// ConvertPath serves the conversion between the CheCluster API versions
+	ConvertPath = "/convert"
+)
+
+// AddToManager registers all webhooks in the webhook server of the Manager.
+// Webhooks are registered only when the serving certificate is provided,
+// otherwise the webhook server wouldn't be able to start.
+func AddToManager(m manager.Manager) error {
+	if !IsCertificateProvided() {
+		logrus.Infof("Webhook server certificate not found in '%s', webhooks are disabled", CertDir)
+		return nil
+	}
+
+	strictCredentials := deploy.IsStrictCredentialsMode()
+	if strictCredentials {
+		logrus.Info("Strict credentials mode is enabled, passwords in plain text are refused in CheCluster")
+	}
+
+	server := m.GetWebhookServer()
+	server.Register(ValidateCheClusterPath, &admission.Webhook{Handler: &cheClusterValidator{strictCredentials: strictCredentials}})
+	server.Register(MutateCheClusterPath, &admission.Webhook{Handler: &cheClusterDefaulter{}})
+	server.Register(ConvertPath, &conversion.Webhook{})
+
+	logrus.Infof("Webhooks are served on port %d", Port)
+	return nil
+}
+
+// IsCertificateProvided checks if the webhook server certificate and key exist.
+func IsCertificateProvided() bool {
+	for _, fileName := range []string{"tls.crt", "tls.key"} {
+		if _, err := os.Stat(filepath.Join(CertDir, fileName)); err != nil {
+			return false
+		}
+	}
+	return true
+}